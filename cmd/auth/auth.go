@@ -17,6 +17,10 @@ func NewAuthCmdGroup() *cobra.Command {
 
 	authCmd.AddCommand(NewAuthStatusCmd())
 	authCmd.AddCommand(NewAuthLoginCmd())
+	authCmd.AddCommand(NewAuthListCmd())
+	authCmd.AddCommand(NewAuthSwitchCmd())
+	authCmd.AddCommand(NewAuthLogoutCmd())
+	authCmd.AddCommand(NewAuthMigrateCmd())
 
 	return authCmd
 }
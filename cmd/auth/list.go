@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/authentication"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/pathUtils"
+)
+
+// AnnotationServerOptional marks commands that work without --server.
+const AnnotationServerOptional = "serverOptional"
+
+type keyEntry struct {
+	Server    string    `json:"server"`
+	User      string    `json:"user"`
+	Default   bool      `json:"default"`
+	IssuedAt  time.Time `json:"issued_at,omitzero"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	Expired   bool      `json:"expired"`
+}
+
+func NewAuthListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "list",
+		Aliases:     []string{"ls"},
+		Short:       "List the stored logins",
+		Long:        `List the identities stored in the keyfile. With --server only the identities of that server are shown.`,
+		Annotations: map[string]string{AnnotationServerOptional: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get global options: %w", err)
+			}
+
+			keys, err := authentication.LoadKeys(cfg.KeyFile)
+			if err != nil {
+				return fmt.Errorf("failed to load keys: %w", err)
+			}
+			if cfg.Server != "" {
+				keys = authentication.KeysForServer(keys, cfg.Server)
+			}
+
+			now := time.Now()
+			entries := make([]keyEntry, 0, len(keys))
+			for _, key := range keys {
+				entries = append(entries, keyEntry{
+					Server:    authentication.ServerKey(key.ServerURL),
+					User:      key.User,
+					Default:   isDefault(keys, key),
+					IssuedAt:  key.IssuedAt,
+					ExpiresAt: key.ExpiresAt,
+					Expired:   key.Expired(now),
+				})
+			}
+
+			if utils.StructuredOutput(cfg) {
+				return utils.PrintValue(cfg, entries)
+			}
+
+			utils.PrintTable(entries, []utils.Column[keyEntry]{
+				{Header: "Default", Value: func(e keyEntry) string {
+					if e.Default {
+						return "*"
+					}
+					return ""
+				}},
+				{Header: "Server", Value: func(e keyEntry) string { return e.Server }},
+				{Header: "User", Value: func(e keyEntry) string { return e.User }},
+				{Header: "Expires", Value: func(e keyEntry) string {
+					switch {
+					case e.ExpiresAt.IsZero():
+						return "unknown"
+					case e.Expired:
+						return "expired"
+					}
+					return e.ExpiresAt.Local().Format(time.DateTime)
+				}},
+			})
+			return nil
+		},
+	}
+	utils.AddOutputFlags(cmd)
+	return cmd
+}
+
+// isDefault reports whether key is used for its server when no --user is
+// given, which also covers keyfiles written before defaults existed.
+func isDefault(keys []pathUtils.GNS3Key, key pathUtils.GNS3Key) bool {
+	serverKeys := authentication.KeysForServer(keys, key.ServerURL)
+	for _, k := range serverKeys {
+		if k.Default {
+			return k.User == key.User
+		}
+	}
+	return len(serverKeys) > 0 && serverKeys[0].User == key.User
+}
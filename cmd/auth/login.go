@@ -17,6 +17,7 @@ import (
 var (
 	username string
 	password string
+	remember bool
 )
 
 func NewAuthLoginCmd() *cobra.Command {
@@ -95,6 +96,12 @@ func NewAuthLoginCmd() *cobra.Command {
 				if writeErr != nil {
 					return fmt.Errorf("failed to write authentication data to the keyfile: %w", writeErr)
 				}
+				if remember {
+					if keyringErr := authentication.RememberPassword(cmd.Context(), cfg.Server, credentials.Username, credentials.Password); keyringErr != nil {
+						return fmt.Errorf("failed to store the password in the OS keyring: %w", keyringErr)
+					}
+					fmt.Printf("%v Stored the password in the OS keyring to renew expired sessions\n", messageUtils.InfoMsg("Stored password"))
+				}
 			} else {
 				return fmt.Errorf("authentication failed (status: %d)", status)
 			}
@@ -103,6 +110,7 @@ func NewAuthLoginCmd() *cobra.Command {
 	}
 	cmd.Flags().StringVarP(&username, "user", "u", "", "User to log in as (env: GNS3_USER)")
 	cmd.Flags().StringVarP(&password, "password", "p", "", "Password to use (env: GNS3_PASSWORD)")
+	cmd.Flags().BoolVar(&remember, "remember", false, "Store the password in the OS keyring to log in again automatically when the token expires")
 
 	return cmd
 }
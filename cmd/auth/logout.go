@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/authentication"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewAuthLogoutCmd() *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Remove a stored login",
+		Long:  `Remove the stored token of the current identity of the server, or of every identity with --all. Passwords stored with auth login --remember are removed from the OS keyring as well.`,
+		Example: `
+  # Log out the default user
+  gns3util -s https://controller:3080 auth logout
+
+  # Log out a specific user
+  gns3util -s https://controller:3080 --user student1 auth logout
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get global options: %w", err)
+			}
+
+			removed, err := authentication.RemoveKeys(cfg, all)
+			if err != nil {
+				return err
+			}
+			for _, key := range removed {
+				if err := authentication.ForgetPassword(cmd.Context(), key.ServerURL, key.User); err != nil && !errors.Is(err, authentication.ErrKeyringUnavailable) && !errors.Is(err, authentication.ErrNotInKeyring) {
+					fmt.Printf("%v failed to remove the stored password of %s: %v\n", messageUtils.WarningMsg("Warning"), key.User, err)
+				}
+				fmt.Printf("%v %s from %s\n", messageUtils.SuccessMsg("Logged out"), messageUtils.Bold(key.User), messageUtils.Highlight(cfg.Server))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "Remove every stored identity of the server")
+	return cmd
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/authentication"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/pathUtils"
+)
+
+func NewAuthMigrateCmd() *cobra.Command {
+	var (
+		from       string
+		to         string
+		keepSource bool
+	)
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Move the stored logins to another key store",
+		Long: fmt.Sprintf(`Move the stored API tokens from one key store to another and make the new one the default in ~/.gns3/config.toml.
+
+Key stores:
+  %s  plaintext JSON lines in ~/.gns3/gns3key
+  %s  ~/.gns3/gns3key.enc, encrypted with a passphrase (GNS3_KEY_PASSPHRASE or prompt)
+  %s  the OS keyring, Secret Service on Linux and Keychain on macOS
+
+The source is emptied after the keys were written unless --keep-source is given. Keys already in the target are kept unless the source has a key for the same server and user.`,
+			authentication.KeyStoreFile, authentication.KeyStoreEncrypted, authentication.KeyStoreKeyring),
+		Example: `
+  # Move the plaintext keyfile into the Secret Service
+  gns3util auth migrate --to keyring
+
+  # Encrypt the keys on a shared machine
+  gns3util auth migrate --to encrypted-file
+		`,
+		Annotations: map[string]string{AnnotationServerOptional: "true"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get global options: %w", err)
+			}
+			if from == "" {
+				if from, err = authentication.ConfiguredKeyStore(); err != nil {
+					return err
+				}
+			}
+			if from == to {
+				return fmt.Errorf("the keys are already stored in %s", to)
+			}
+
+			src, err := authentication.OpenKeyStore(from, cfg.KeyFile)
+			if err != nil {
+				return err
+			}
+			dst, err := authentication.OpenKeyStore(to, cfg.KeyFile)
+			if err != nil {
+				return err
+			}
+
+			keys, err := src.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load keys from %s: %w", src.Location(), err)
+			}
+			existing, err := dst.Load()
+			if err != nil {
+				return fmt.Errorf("failed to load keys from %s: %w", dst.Location(), err)
+			}
+			merged := mergeKeys(existing, keys)
+			if err := dst.Save(merged); err != nil {
+				return fmt.Errorf("failed to write keys to %s: %w", dst.Location(), err)
+			}
+			if check, err := dst.Load(); err != nil || len(check) != len(merged) {
+				return fmt.Errorf("failed to read back the keys from %s, %s was left untouched: %v", dst.Location(), src.Location(), err)
+			}
+			fmt.Printf("%v %d key(s) to %s\n", messageUtils.SuccessMsg("Migrated"), len(keys), messageUtils.Highlight(dst.Location()))
+
+			if !keepSource {
+				if err := src.Delete(); err != nil {
+					return fmt.Errorf("failed to remove the keys from %s: %w", src.Location(), err)
+				}
+				fmt.Printf("%v %s\n", messageUtils.InfoMsg("Removed"), src.Location())
+			}
+
+			uc, err := config.LoadUserConfig()
+			if err != nil {
+				return err
+			}
+			uc.KeyStore = to
+			if err := config.WriteUserConfig(uc); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+			fmt.Printf("%v %s is now the default key store\n", messageUtils.InfoMsg("Key store"), messageUtils.Bold(to))
+			if env := os.Getenv("GNS3_KEY_STORE"); env != "" && env != to {
+				fmt.Printf("%v GNS3_KEY_STORE=%s overrides the config, unset it to use %s\n", messageUtils.WarningMsg("Warning"), env, to)
+			}
+			return nil
+		},
+	}
+	stores := strings.Join(authentication.KeyStores, ", ")
+	cmd.Flags().StringVar(&to, "to", "", "Key store to move the keys to ("+stores+")")
+	cmd.Flags().StringVar(&from, "from", "", "Key store to move the keys from (default the configured one)")
+	cmd.Flags().BoolVar(&keepSource, "keep-source", false, "Leave the keys in the source key store")
+	_ = cmd.MarkFlagRequired("to")
+	return cmd
+}
+
+// mergeKeys adds keys to existing, replacing the identities both contain.
+// Each server keeps exactly one default identity: the default of keys if
+// it has one for the server, else the one of existing, else its first key.
+func mergeKeys(existing, keys []pathUtils.GNS3Key) []pathUtils.GNS3Key {
+	out := slices.Clone(existing)
+	for _, key := range keys {
+		replaced := false
+		for i, e := range out {
+			if authentication.ServerKey(e.ServerURL) == authentication.ServerKey(key.ServerURL) && e.User == key.User {
+				out[i] = key
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			out = append(out, key)
+		}
+	}
+
+	defaults := map[string]string{}
+	for _, set := range [][]pathUtils.GNS3Key{keys, existing} {
+		for _, k := range set {
+			server := authentication.ServerKey(k.ServerURL)
+			if _, ok := defaults[server]; !ok && k.Default {
+				defaults[server] = k.User
+			}
+		}
+	}
+	for i, k := range out {
+		server := authentication.ServerKey(k.ServerURL)
+		if _, ok := defaults[server]; !ok {
+			defaults[server] = k.User
+		}
+		out[i].Default = defaults[server] == k.User
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/pkg/utils/pathUtils"
+)
+
+func key(server, user, token string, isDefault bool) pathUtils.GNS3Key {
+	return pathUtils.GNS3Key{ServerURL: server, User: user, AccessToken: token, Default: isDefault}
+}
+
+func TestMergeKeys(t *testing.T) {
+	const gns3, other = "http://gns3:3080", "http://other:3080"
+	tests := []struct {
+		name     string
+		existing []pathUtils.GNS3Key
+		keys     []pathUtils.GNS3Key
+		want     []pathUtils.GNS3Key
+	}{
+		{
+			name: "into an empty store",
+			keys: []pathUtils.GNS3Key{key(gns3, "admin", "a", true), key(gns3, "teacher", "t", false)},
+			want: []pathUtils.GNS3Key{key(gns3, "admin", "a", true), key(gns3, "teacher", "t", false)},
+		},
+		{
+			name:     "the default of the source wins",
+			existing: []pathUtils.GNS3Key{key(gns3, "admin", "a", true)},
+			keys:     []pathUtils.GNS3Key{key(gns3, "teacher", "t", true)},
+			want:     []pathUtils.GNS3Key{key(gns3, "admin", "a", false), key(gns3, "teacher", "t", true)},
+		},
+		{
+			name:     "the default of the target stays",
+			existing: []pathUtils.GNS3Key{key(gns3, "admin", "a", true)},
+			keys:     []pathUtils.GNS3Key{key(gns3, "teacher", "t", false), key(gns3, "admin", "new", false)},
+			want:     []pathUtils.GNS3Key{key(gns3, "admin", "new", true), key(gns3, "teacher", "t", false)},
+		},
+		{
+			name:     "same server on another URL",
+			existing: []pathUtils.GNS3Key{key("http://GNS3:3080/", "admin", "a", true)},
+			keys:     []pathUtils.GNS3Key{key(gns3, "admin", "new", true)},
+			want:     []pathUtils.GNS3Key{key(gns3, "admin", "new", true)},
+		},
+		{
+			name:     "a server without a default gets its first key",
+			existing: []pathUtils.GNS3Key{key(other, "bob", "b", false)},
+			keys:     []pathUtils.GNS3Key{key(other, "carol", "c", false), key(gns3, "admin", "a", true)},
+			want:     []pathUtils.GNS3Key{key(other, "bob", "b", true), key(other, "carol", "c", false), key(gns3, "admin", "a", true)},
+		},
+		{
+			name:     "servers are kept apart",
+			existing: []pathUtils.GNS3Key{key(other, "bob", "b", true)},
+			keys:     []pathUtils.GNS3Key{key(gns3, "admin", "a", true)},
+			want:     []pathUtils.GNS3Key{key(other, "bob", "b", true), key(gns3, "admin", "a", true)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeKeys(tt.existing, tt.keys); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
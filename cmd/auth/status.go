@@ -3,6 +3,7 @@ package auth
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
@@ -38,7 +39,19 @@ func NewAuthStatusCmd() *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("error unmarshaling JSON: %w", err)
 			}
-			fmt.Printf("%s logged in as user %s", messageUtils.SuccessMsg("Logged in as user"), messageUtils.Bold(*user.Username))
+			fmt.Printf("%s logged in as user %s\n", messageUtils.SuccessMsg("Logged in as user"), messageUtils.Bold(*user.Username))
+
+			// the key may have been renewed while checking it
+			key, found, err := authentication.FindKey(cfg)
+			if err != nil || !found || key.ExpiresAt.IsZero() {
+				return nil
+			}
+			fmt.Printf("%s token issued %s, expires %s (in %s)\n",
+				messageUtils.InfoMsg("Token"),
+				key.IssuedAt.Local().Format(time.DateTime),
+				key.ExpiresAt.Local().Format(time.DateTime),
+				time.Until(key.ExpiresAt).Round(time.Minute),
+			)
 			return nil
 		},
 	}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/authentication"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/fuzzy"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewAuthSwitchCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "switch [user]",
+		Short: "Change the default login of a server",
+		Long:  `Change which stored identity is used for the server when no --user is given. Without an argument the identity is selected interactively.`,
+		Example: `
+  # Act as the test student by default
+  gns3util -s https://controller:3080 auth switch student1
+
+  # Select the identity interactively
+  gns3util -s https://controller:3080 auth switch
+		`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get global options: %w", err)
+			}
+
+			var target string
+			if len(args) > 0 {
+				target = args[0]
+			} else {
+				keys, err := authentication.LoadKeys(cfg.KeyFile)
+				if err != nil {
+					return fmt.Errorf("failed to load keys: %w", err)
+				}
+				var users []string
+				for _, key := range authentication.KeysForServer(keys, cfg.Server) {
+					users = append(users, key.User)
+				}
+				if len(users) == 0 {
+					return fmt.Errorf("no stored logins for %s, use auth login first", cfg.Server)
+				}
+				selected := fuzzy.NewFuzzyFinderWithTitle(users, false, "Select the default user")
+				if len(selected) == 0 {
+					return fmt.Errorf("no user selected")
+				}
+				target = selected[0]
+			}
+
+			if err := authentication.SetDefaultKey(cfg, target); err != nil {
+				return err
+			}
+			fmt.Printf("%v %s is now the default user for %s\n", messageUtils.SuccessMsg("Switched user"), messageUtils.Bold(target), messageUtils.Highlight(cfg.Server))
+			return nil
+		},
+	}
+	return cmd
+}
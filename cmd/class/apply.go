@@ -0,0 +1,125 @@
+package class
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	clusterutils "github.com/stefanistkuhl/gns3util/pkg/utils/clusterUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/colorUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewApplyClassCmd() *cobra.Command {
+	applyClassCmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Update a class to match a class file",
+		Long: `Compare a class file with the class of the same name and change the class to
+match it. New groups are assigned to nodes and created, new students are created,
+students in another group are moved and students or groups missing from the file
+are removed, together with the exercise projects of removed groups. Students who
+were deleted on their node or taken out of their groups there are recreated or
+added back. Classes that do not exist yet are created like with class create.
+
+Passwords in the file are only used for new students, moved and recreated students
+keep their initial password. The plan is printed and applied after confirmation.
+` + placementHelp,
+		Example: `
+  # Show what would change
+  gns3util -s https://controller:3080 class apply --file class.json --dry-run
+
+  # Apply the changes to a class on a cluster and keep the projects of removed groups
+  gns3util class apply --cluster lab --file class.json --archive-dir ./archive
+
+  # Apply without asking
+  gns3util -s https://controller:3080 class apply --file class.json --no-confirm
+		`,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			serverUrl, _ := cmd.InheritedFlags().GetString("server")
+			cluster, _ := cmd.Flags().GetString("cluster")
+			if serverUrl != "" && cluster != "" {
+				return fmt.Errorf("cannot specify both --cluster and --server")
+			}
+			if serverUrl == "" && cluster == "" {
+				return fmt.Errorf("either --cluster or --server must be specified")
+			}
+			return nil
+		},
+		RunE: runApplyClass,
+	}
+
+	applyClassCmd.Flags().String("file", "", "JSON or YAML (.yaml, .yml) file containing class data")
+	applyClassCmd.Flags().String("archive-dir", "", "Export the exercise projects of removed groups to this directory before deleting them")
+	applyClassCmd.Flags().Bool("dry-run", false, "Only print the plan")
+	applyClassCmd.Flags().Bool("no-confirm", false, "Apply the plan without confirmation")
+	applyClassCmd.Flags().StringP("cluster", "c", "", "Cluster name")
+	addPlacementFlags(applyClassCmd)
+	_ = applyClassCmd.MarkFlagRequired("file")
+
+	return applyClassCmd
+}
+
+func runApplyClass(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get global options: %w", err)
+	}
+	filePath, _ := cmd.Flags().GetString("file")
+	archiveDir, _ := cmd.Flags().GetString("archive-dir")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	noConfirm, _ := cmd.Flags().GetBool("no-confirm")
+	clusterName, _ := cmd.Flags().GetString("cluster")
+
+	classData, err := class.LoadClassFromFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	scheduler, err := schedulerFor(cmd, cfg, clusterName)
+	if err != nil {
+		return err
+	}
+
+	var plan *class.ApplyPlan
+	clusterID, err := clusterutils.ResolveClusterID(cfg, clusterName, cmd.Context())
+	if err == nil {
+		plan, err = class.PlanClassApply(cmd.Context(), cfg, clusterID, classData, scheduler)
+	} else if clusterName == "" {
+		// the single node cluster of --server is created with the first class
+		err = class.ErrClassNotFound
+	}
+	if errors.Is(err, class.ErrClassNotFound) {
+		students := 0
+		for _, g := range classData.Groups {
+			students += len(g.Students)
+		}
+		fmt.Printf("Plan for class %s:\n  %s class with %d groups and %d students\n",
+			messageUtils.Bold(classData.Name), colorUtils.Success("+"), len(classData.Groups), students)
+		if dryRun || (!noConfirm && !utils.ConfirmPrompt("Create the class?", false)) {
+			return nil
+		}
+		return createClass(cmd, classData)
+	}
+	if err != nil {
+		return err
+	}
+
+	if plan.Empty() {
+		fmt.Printf("%v class %s already matches %s\n", messageUtils.InfoMsg("Up to date"), messageUtils.Bold(classData.Name), filePath)
+		return nil
+	}
+	plan.Print()
+	if dryRun || (!noConfirm && !utils.ConfirmPrompt("Apply these changes?", false)) {
+		return nil
+	}
+	if err := class.ApplyClassPlan(cmd.Context(), cfg, plan, archiveDir); err != nil {
+		return err
+	}
+	fmt.Printf("%v class %s\n", messageUtils.SuccessMsg("Applied"), messageUtils.Bold(classData.Name))
+	return nil
+}
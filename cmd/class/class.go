@@ -13,8 +13,13 @@ func NewClassCmdGroup() *cobra.Command {
 
 	classCmd.AddCommand(
 		NewCreateClassCmd(),
+		NewImportClassCmd(),
 		NewClassDeleteCmd(),
 		NewClassLsCmd(),
+		NewClassCredentialsCmd(),
+		NewApplyClassCmd(),
+		NewExportClassCmd(),
+		NewClassNotifyCmd(),
 	)
 
 	return classCmd
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/spf13/cobra"
@@ -32,15 +33,19 @@ func NewCreateClassCmd() *cobra.Command {
 The class structure includes:
 - A main class group
 - Student groups within the class
-- Students assigned to both the class group and their respective student groups`,
+- Students assigned to both the class group and their respective student groups
+` + placementHelp,
 		Example: `
   # Create class from JSON file
   gns3util -s https://controller:3080 class create --file class.json
 
   # Launch interactive class creation
   gns3util -s https://controller:3080 class create --interactive
+
+  # Fill the nodes of a cluster one after another with groups of 4 GB
+  gns3util class create --cluster lab --file class.json --placement binpack --group-ram 4096
 		`,
-		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		PreRunE: func(cmd *cobra.Command, args []string) error {
 			serverUrl, _ := cmd.InheritedFlags().GetString("server")
 			cluster, _ := cmd.Flags().GetString("cluster")
 			filePath, _ := cmd.Flags().GetString("file")
@@ -59,11 +64,12 @@ The class structure includes:
 		RunE: runCreateClass,
 	}
 
-	createClassCmd.Flags().String("file", "", "JSON file containing class data")
+	createClassCmd.Flags().String("file", "", "JSON or YAML (.yaml, .yml) file containing class data")
 	createClassCmd.Flags().BoolVar(&interactive, "interactive", false, "Launch interactive web interface for class creation")
 	createClassCmd.Flags().Int("port", 8080, "Port for interactive web interface")
 	createClassCmd.Flags().String("host", "localhost", "Host for interactive web interface")
 	createClassCmd.Flags().StringP("cluster", "c", "", "Cluster name")
+	addPlacementFlags(createClassCmd)
 
 	return createClassCmd
 }
@@ -71,15 +77,10 @@ The class structure includes:
 func runCreateClass(cmd *cobra.Command, args []string) error {
 	cmd.SilenceUsage = true
 
-	serverUrl, _ := cmd.InheritedFlags().GetString("server")
-
-	cfg, _ := config.GetGlobalOptionsFromContext(cmd.Context())
-
 	filePath, _ := cmd.Flags().GetString("file")
 	className, _ := cmd.Flags().GetString("name")
 	port, _ := cmd.Flags().GetInt("port")
 	host, _ := cmd.Flags().GetString("host")
-	clusterName, _ := cmd.Flags().GetString("cluster")
 
 	var classData schemas.Class
 
@@ -101,6 +102,18 @@ func runCreateClass(cmd *cobra.Command, args []string) error {
 		classData.Name = className
 	}
 
+	return createClass(cmd, classData)
+}
+
+// createClass creates classData on the --server or the nodes of the
+// --cluster of cmd.
+func createClass(cmd *cobra.Command, classData schemas.Class) error {
+	serverUrl, _ := cmd.InheritedFlags().GetString("server")
+
+	cfg, _ := config.GetGlobalOptionsFromContext(cmd.Context())
+
+	clusterName, _ := cmd.Flags().GetString("cluster")
+
 	clusterExists := false
 	nodeExists := false
 	nodeData := db.NodeData{}
@@ -120,6 +133,7 @@ func runCreateClass(cmd *cobra.Command, args []string) error {
 		if convErr != nil {
 			return fmt.Errorf("failed to convert port to int: %w", convErr)
 		}
+		nodeData.Protocol = urlObj.Scheme
 		nodeData.Host = urlObj.Hostname()
 		nodeData.Port = port
 		nodeData.Weight = 10
@@ -127,6 +141,11 @@ func runCreateClass(cmd *cobra.Command, args []string) error {
 		nodeData.User = user
 	}
 
+	scheduler, err := schedulerFor(cmd, cfg, clusterName)
+	if err != nil {
+		return err
+	}
+
 	store, err := db.Init()
 	if err != nil {
 		return fmt.Errorf("failed to initialize database: %w", err)
@@ -196,7 +215,7 @@ func runCreateClass(cmd *cobra.Command, args []string) error {
 			} else {
 				maxGroups = sql.NullInt64{Int64: int64(nodeData.MaxGroups), Valid: true}
 			}
-			sqlcNodeData := sqlc.InsertNodeParams{ClusterID: int64(clusterID), Host: nodeData.Host, Port: int64(nodeData.Port), Weight: int64(nodeData.Weight), MaxGroups: maxGroups, AuthUser: nodeData.User}
+			sqlcNodeData := sqlc.InsertNodeParams{ClusterID: int64(clusterID), Protocol: nodeData.Protocol, Host: nodeData.Host, Port: int64(nodeData.Port), Weight: int64(nodeData.Weight), MaxGroups: maxGroups, AuthUser: nodeData.User}
 			if insertErr := qtx.InsertNode(ctx, sqlcNodeData); insertErr != nil {
 				return fmt.Errorf("failed to create node: %w", insertErr)
 			}
@@ -247,13 +266,24 @@ func runCreateClass(cmd *cobra.Command, args []string) error {
 
 	var nodes []db.NodeDataAll
 	for _, node := range insertedNodes {
-		nodes = append(nodes, db.NodeDataAll{ClusterID: int(node.ClusterID), Host: node.Host, Port: int(node.Port), Weight: int(node.Weight), MaxGroups: int(node.MaxGroups.Int64)})
+		if node.Cordoned {
+			continue
+		}
+		// a node without max_groups, like the implicit single node cluster, has no limit
+		maxGroups := math.MaxInt32
+		if node.MaxGroups.Valid {
+			maxGroups = int(node.MaxGroups.Int64)
+		}
+		nodes = append(nodes, db.NodeDataAll{ID: int(node.NodeID), ClusterID: int(node.ClusterID), User: node.AuthUser, Protocol: node.Protocol, Host: node.Host, Port: int(node.Port), Weight: int(node.Weight), MaxGroups: maxGroups})
 	}
 	commitErr := tx.Commit()
 	if commitErr != nil {
 		return fmt.Errorf("failed to commit transaction: %w", commitErr)
 	}
-	success, err := class.CreateClass(cfg, clusterID, classData, nodes)
+	if len(nodes) == 0 {
+		return fmt.Errorf("all nodes of cluster %s are cordoned, use cluster uncordon", messageUtils.Bold(clusterName))
+	}
+	success, err := class.CreateClass(cfg, clusterID, classData, nodes, scheduler)
 	if err != nil {
 		return fmt.Errorf("failed to create class: %w", err)
 	}
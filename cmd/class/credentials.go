@@ -0,0 +1,123 @@
+package class
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/fuzzy"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	clusterutils "github.com/stefanistkuhl/gns3util/pkg/utils/clusterUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewClassCredentialsCmd() *cobra.Command {
+	credentialsCmd := &cobra.Command{
+		Use:   "credentials",
+		Short: "Print the logins of a class for handing them out",
+		Long: `Print the username, initial password, group and assigned server of every student
+of a class, as stored in the cluster database when the class was created. The html
+format is a printable page with one card per student to cut out.`,
+		Example: `
+  # Credential sheet as CSV
+  gns3util -s https://controller:3080 class credentials --class CS101
+
+  # Printable cards for a class on a cluster
+  gns3util class credentials --cluster lab --class CS101 -o html --out cs101.html
+
+  # Select the class with the fuzzy finder and print a markdown table
+  gns3util -s https://controller:3080 class credentials -o markdown
+		`,
+		RunE: runClassCredentials,
+	}
+
+	credentialsCmd.Flags().String("class", "", "Name of the class (default select with the fuzzy finder)")
+	utils.AddFileOutputFlags(credentialsCmd, class.CredentialFormats, class.CredentialsCSV, "")
+	credentialsCmd.Flags().StringP("cluster", "c", "", "Cluster name")
+
+	return credentialsCmd
+}
+
+func runClassCredentials(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get global options: %w", err)
+	}
+	className, _ := cmd.Flags().GetString("class")
+	clusterName, _ := cmd.Flags().GetString("cluster")
+
+	format, out, err := utils.FileOutputFlags(cmd, class.CredentialFormats)
+	if err != nil {
+		return err
+	}
+
+	clusterID, err := clusterutils.ResolveClusterID(cfg, clusterName, cmd.Context())
+	if err != nil {
+		return err
+	}
+	if className == "" {
+		className, err = selectClass(cmd.Context(), clusterID)
+		if err != nil {
+			return err
+		}
+	}
+
+	creds, err := class.GetClassCredentials(cmd.Context(), clusterID, className)
+	if err != nil {
+		return err
+	}
+	if len(creds) == 0 {
+		return fmt.Errorf("the class %s has no students assigned to a node", messageUtils.Bold(className))
+	}
+
+	if out == "" {
+		return class.WriteCredentials(os.Stdout, format, className, creds)
+	}
+	// the sheet holds passwords
+	f, err := os.OpenFile(out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", out, err)
+	}
+	if err := class.WriteCredentials(f, format, className, creds); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	fmt.Printf("%v %d logins of class %s to %s\n",
+		messageUtils.SuccessMsg("Wrote"),
+		len(creds),
+		messageUtils.Bold(className),
+		messageUtils.Bold(out))
+	return nil
+}
+
+func selectClass(ctx context.Context, clusterID int) (string, error) {
+	store, err := db.Init()
+	if err != nil {
+		return "", fmt.Errorf("failed to init db: %w", err)
+	}
+	classes, err := store.GetClasses(ctx, int64(clusterID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get classes: %w", err)
+	}
+	if len(classes) == 0 {
+		return "", fmt.Errorf("no classes found")
+	}
+	names := make([]string, 0, len(classes))
+	for _, c := range classes {
+		names = append(names, c.Name)
+	}
+	selected := fuzzy.NewFuzzyFinderWithTitle(names, false, "Select a class")
+	if len(selected) == 0 {
+		return "", fmt.Errorf("no class selected")
+	}
+	return selected[0], nil
+}
@@ -0,0 +1,114 @@
+package class
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	clusterutils "github.com/stefanistkuhl/gns3util/pkg/utils/clusterUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewExportClassCmd() *cobra.Command {
+	exportClassCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write a class back to a class file",
+		Long: `Rebuild the class file of a class from the cluster database, for backups or to
+reuse the groups for another class with class create or class apply. The nodes are
+checked for the students and groups of the class and differences are reported.
+Emails are taken from the nodes since the database does not keep them.
+
+The initial passwords are included unless --no-passwords is set. Files without
+passwords still work with class apply, which keeps the passwords of existing students.`,
+		Example: `
+  # Print a class as JSON
+  gns3util -s https://controller:3080 class export --class CS101
+
+  # Back up a class of a cluster as YAML without passwords
+  gns3util class export --cluster lab --class CS101 --out cs101.yaml --no-passwords
+		`,
+		RunE: runExportClass,
+	}
+
+	exportClassCmd.Flags().String("class", "", "Name of the class (default select with the fuzzy finder)")
+	utils.AddFileOutputFlags(exportClassCmd, class.ClassFileFormats, "", " (default from the file extension, else json)")
+	exportClassCmd.Flags().Bool("no-passwords", false, "Leave out the initial passwords")
+	exportClassCmd.Flags().StringP("cluster", "c", "", "Cluster name")
+
+	return exportClassCmd
+}
+
+func runExportClass(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get global options: %w", err)
+	}
+	className, _ := cmd.Flags().GetString("class")
+	noPasswords, _ := cmd.Flags().GetBool("no-passwords")
+	clusterName, _ := cmd.Flags().GetString("cluster")
+
+	format, out, err := utils.FileOutputFlags(cmd, class.ClassFileFormats)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		format = class.ClassFileJSON
+		switch strings.ToLower(filepath.Ext(out)) {
+		case ".yaml", ".yml":
+			format = class.ClassFileYAML
+		}
+	}
+
+	clusterID, err := clusterutils.ResolveClusterID(cfg, clusterName, cmd.Context())
+	if err != nil {
+		return err
+	}
+	if className == "" {
+		className, err = selectClass(cmd.Context(), clusterID)
+		if err != nil {
+			return err
+		}
+	}
+
+	classData, warnings, err := class.ExportClass(cmd.Context(), cfg, clusterID, className, !noPasswords)
+	if err != nil {
+		return err
+	}
+	// stdout may hold the class file
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "%v %s\n", messageUtils.WarningMsg("Warning"), w)
+	}
+
+	if out == "" {
+		return class.WriteClassFile(os.Stdout, format, classData)
+	}
+	f, err := os.OpenFile(out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", out, err)
+	}
+	if err := class.WriteClassFile(f, format, classData); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	students := 0
+	for _, g := range classData.Groups {
+		students += len(g.Students)
+	}
+	fmt.Printf("%v class %s with %d students in %d groups to %s\n",
+		messageUtils.SuccessMsg("Exported"),
+		messageUtils.Bold(classData.Name),
+		students,
+		len(classData.Groups),
+		messageUtils.Bold(out))
+	return nil
+}
@@ -0,0 +1,184 @@
+package class
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/cmd/auth"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewImportClassCmd() *cobra.Command {
+	importClassCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Build a class from a CSV or XLSX roster",
+		Long: `Build a class from a roster exported as CSV or XLSX. The columns are matched by
+their header, common names like "Name", "First Name", "Student ID", "E-Mail" and
+"Group" are found automatically and other headers can be mapped with the column flags.
+
+Students are split into groups by --group-size or --group-count in roster order, or
+by the group column of the roster. Usernames are built from --username-pattern with
+the placeholders {first}, {last}, {f}, {l} (initials), {id}, {email} (the part
+before the @), {class} and {n} (the position in the roster), GNS3 only allows
+letters, digits, - and _ in usernames. Every student gets a generated password.
+
+The class is printed as JSON for class create --file, written to --out or created
+right away with --create, which places the groups like class create.
+` + placementHelp,
+		Example: `
+  # Groups of 3 with usernames like jdoe, written to a class file
+  gns3util class import --csv roster.csv --name CS101 --group-size 3 --username-pattern "{f}{last}" --out cs101.json
+
+  # Use the groups of the roster and create the class on a server
+  gns3util -s https://controller:3080 class import --xlsx roster.xlsx --name CS101 --group-column Team --create
+
+  # Map the columns of a registry export and create the class on a cluster
+  gns3util class import --csv export.csv --name CS101 --name-column "Student" --id-column "Matr.Nr." --group-count 8 --username-pattern "s{id}" --create --cluster lab
+		`,
+		Annotations: map[string]string{auth.AnnotationServerOptional: "true"},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			csvPath, _ := cmd.Flags().GetString("csv")
+			xlsxPath, _ := cmd.Flags().GetString("xlsx")
+			create, _ := cmd.Flags().GetBool("create")
+			serverUrl, _ := cmd.InheritedFlags().GetString("server")
+			cluster, _ := cmd.Flags().GetString("cluster")
+
+			if (csvPath == "") == (xlsxPath == "") {
+				return fmt.Errorf("specify the roster with either --csv or --xlsx")
+			}
+			if create {
+				if serverUrl != "" && cluster != "" {
+					return fmt.Errorf("cannot specify both --cluster and --server")
+				}
+				if serverUrl == "" && cluster == "" {
+					return fmt.Errorf("--create needs either --cluster or --server")
+				}
+			}
+			return nil
+		},
+		RunE: runImportClass,
+	}
+
+	importClassCmd.Flags().String("csv", "", "CSV roster to import")
+	importClassCmd.Flags().String("xlsx", "", "XLSX roster to import")
+	importClassCmd.Flags().String("sheet", "", "Sheet of the XLSX roster (default the first one)")
+	importClassCmd.Flags().String("delimiter", "", "Field delimiter of the CSV roster, like ; or tab (default detected)")
+	importClassCmd.Flags().String("name", "", "Name of the class")
+	importClassCmd.Flags().String("description", "", "Description of the class")
+	importClassCmd.Flags().String("name-column", "", "Column with the full name of the students")
+	importClassCmd.Flags().String("first-name-column", "", "Column with the first names")
+	importClassCmd.Flags().String("last-name-column", "", "Column with the last names")
+	importClassCmd.Flags().String("id-column", "", "Column with the student IDs")
+	importClassCmd.Flags().String("email-column", "", "Column with the email addresses")
+	importClassCmd.Flags().String("group-column", "", "Column with the group of each student")
+	importClassCmd.Flags().Int("group-size", 0, "Number of students per group")
+	importClassCmd.Flags().Int("group-count", 0, "Number of groups to split the students into")
+	importClassCmd.Flags().Bool("shuffle", false, "Shuffle the students before splitting them into groups")
+	importClassCmd.Flags().String("username-pattern", "", "Pattern for the usernames (default {first}-{last}, {id} without names)")
+	importClassCmd.Flags().String("group-pattern", class.DefaultGroupPattern, "Pattern for the group names with --group-size or --group-count")
+	importClassCmd.Flags().Int("password-length", class.DefaultPasswordLength, "Length of the generated passwords")
+	importClassCmd.Flags().String("out", "", "Write the class JSON to this file")
+	importClassCmd.Flags().Bool("create", false, "Create the class on the --server or --cluster")
+	importClassCmd.Flags().StringP("cluster", "c", "", "Cluster name")
+	addPlacementFlags(importClassCmd)
+	_ = importClassCmd.MarkFlagRequired("name")
+
+	return importClassCmd
+}
+
+func runImportClass(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	csvPath, _ := cmd.Flags().GetString("csv")
+	xlsxPath, _ := cmd.Flags().GetString("xlsx")
+	sheet, _ := cmd.Flags().GetString("sheet")
+	delimiter, _ := cmd.Flags().GetString("delimiter")
+	out, _ := cmd.Flags().GetString("out")
+	create, _ := cmd.Flags().GetBool("create")
+
+	opts := class.RosterOptions{}
+	opts.ClassName, _ = cmd.Flags().GetString("name")
+	opts.Description, _ = cmd.Flags().GetString("description")
+	opts.NameColumn, _ = cmd.Flags().GetString("name-column")
+	opts.FirstNameColumn, _ = cmd.Flags().GetString("first-name-column")
+	opts.LastNameColumn, _ = cmd.Flags().GetString("last-name-column")
+	opts.IDColumn, _ = cmd.Flags().GetString("id-column")
+	opts.EmailColumn, _ = cmd.Flags().GetString("email-column")
+	opts.GroupColumn, _ = cmd.Flags().GetString("group-column")
+	opts.GroupSize, _ = cmd.Flags().GetInt("group-size")
+	opts.GroupCount, _ = cmd.Flags().GetInt("group-count")
+	opts.Shuffle, _ = cmd.Flags().GetBool("shuffle")
+	opts.UsernamePattern, _ = cmd.Flags().GetString("username-pattern")
+	opts.GroupPattern, _ = cmd.Flags().GetString("group-pattern")
+	opts.PasswordLength, _ = cmd.Flags().GetInt("password-length")
+
+	var records [][]string
+	var err error
+	if xlsxPath != "" {
+		records, err = class.ReadRosterXLSX(xlsxPath, sheet)
+	} else {
+		var comma rune
+		comma, err = parseDelimiter(delimiter)
+		if err != nil {
+			return err
+		}
+		records, err = class.ReadRosterCSV(csvPath, comma)
+	}
+	if err != nil {
+		return err
+	}
+
+	classData, err := class.ClassFromRoster(records, opts)
+	if err != nil {
+		return err
+	}
+	students := 0
+	for _, g := range classData.Groups {
+		students += len(g.Students)
+	}
+
+	data, err := json.MarshalIndent(classData, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal class: %w", err)
+	}
+	if out != "" {
+		// the file holds the initial passwords
+		if err := os.WriteFile(out, append(data, '\n'), 0o600); err != nil {
+			return fmt.Errorf("failed to write class file: %w", err)
+		}
+		fmt.Printf("%v class %v with %d students in %d groups to %v\n",
+			messageUtils.SuccessMsg("Wrote"),
+			messageUtils.Bold(classData.Name),
+			students,
+			len(classData.Groups),
+			messageUtils.Bold(out))
+	} else if !create {
+		fmt.Println(string(data))
+	}
+	if !create {
+		return nil
+	}
+	fmt.Printf("%v %d students in %d groups\n",
+		messageUtils.InfoMsg("Imported"),
+		students,
+		len(classData.Groups))
+	return createClass(cmd, classData)
+}
+
+func parseDelimiter(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+	r := []rune(s)
+	if len(r) != 1 {
+		return 0, fmt.Errorf("the delimiter has to be a single character or tab")
+	}
+	return r[0], nil
+}
@@ -0,0 +1,88 @@
+package class
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/authentication"
+)
+
+const roster = "Name;E-Mail;Team\nDoe, Jane;jane@school.edu;A\nJohn Doe;john@school.edu;A\nMax Mustermann;max@school.edu;B\n"
+
+func writeRoster(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "roster.csv")
+	if err := os.WriteFile(path, []byte(roster), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestImportClassOut(t *testing.T) {
+	tc := testutil.NewCluster(t)
+	out := filepath.Join(t.TempDir(), "net.json")
+	tc.Run(t, NewImportClassCmd(), "--csv", writeRoster(t), "--name", "NET", "--username-pattern", "{f}{last}", "--out", out)
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var classData schemas.Class
+	if err := json.Unmarshal(data, &classData); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]string{}
+	for _, g := range classData.Groups {
+		for _, s := range g.Students {
+			got[g.Name] = append(got[g.Name], s.UserName)
+		}
+	}
+	if want := map[string][]string{"NET-A": {"jdoe", "jdoe-2"}, "NET-B": {"mmustermann"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("groups %v, want %v", got, want)
+	}
+	if _, ok := tc.GroupMembers(t)["NET"]; ok {
+		t.Error("the class was created without --create")
+	}
+}
+
+func TestImportClassCreate(t *testing.T) {
+	tc := testutil.NewCluster(t)
+	out := filepath.Join(t.TempDir(), "net.json")
+	tc.Run(t, NewImportClassCmd(), "--csv", writeRoster(t), "--name", "NET", "--group-size", "2", "--username-pattern", "{email}", "--create", "--cluster", tc.Name, "--out", out)
+
+	got := tc.GroupMembers(t)
+	want := map[string][]string{
+		"NET":         {"jane", "john", "max"},
+		"NET-group-1": {"jane", "john"},
+		"NET-group-2": {"max"},
+	}
+	for group, members := range want {
+		if !reflect.DeepEqual(got[group], members) {
+			t.Errorf("members of %s: %v, want %v", group, got[group], members)
+		}
+	}
+	if u, ok := tc.User(t, "jane"); !ok || u.FullName == nil || *u.FullName != "Jane Doe" {
+		t.Errorf("user jane %+v", u)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var classData schemas.Class
+	if err := json.Unmarshal(data, &classData); err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range classData.Groups {
+		for _, s := range g.Students {
+			if _, err := authentication.Login(t.Context(), tc.Cfg, s.UserName, s.Password); err != nil {
+				t.Errorf("%s cannot log in with the generated password: %v", s.UserName, err)
+			}
+		}
+	}
+}
@@ -29,6 +29,7 @@ func NewClassLsCmd() *cobra.Command {
 	listCmd.Flags().Bool("db-only", false, "Show only classes from database (skip API calls)")
 	listCmd.Flags().Bool("api-only", false, "Show only classes from API (skip database)")
 	listCmd.Flags().StringP("cluster", "c", "", "Cluster name")
+	utils.AddOutputFlags(listCmd)
 
 	return listCmd
 }
@@ -77,7 +78,11 @@ func runListClasses(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	structured := utils.StructuredOutput(cfg)
 	if len(classes) == 0 {
+		if structured {
+			return utils.PrintValue(cfg, []ClassDistribution{})
+		}
 		fmt.Printf("%v No classes found\n", messageUtils.InfoMsg("No classes found"))
 		return nil
 	}
@@ -98,6 +103,9 @@ func runListClasses(cmd *cobra.Command, args []string) error {
 	for _, class := range uniqueClasses {
 		finalClasses = append(finalClasses, class)
 	}
+	if structured {
+		return utils.PrintValue(cfg, finalClasses)
+	}
 
 	utils.PrintTable(finalClasses, []utils.Column[ClassDistribution]{
 		{
@@ -185,15 +193,15 @@ func runListClasses(cmd *cobra.Command, args []string) error {
 }
 
 type ClassDistribution struct {
-	Name        string
-	Description string
-	Nodes       map[string]NodeInfo
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Nodes       map[string]NodeInfo `json:"nodes"`
 }
 
 type NodeInfo struct {
-	GroupCount int
-	UserCount  int
-	GroupNames []string
+	GroupCount int      `json:"group_count"`
+	UserCount  int      `json:"user_count"`
+	GroupNames []string `json:"group_names"`
 }
 
 func getClassDistributionFromDB(clusterID int) ([]ClassDistribution, error) {
@@ -0,0 +1,243 @@
+package class
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	clusterutils "github.com/stefanistkuhl/gns3util/pkg/utils/clusterUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/mailer"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewClassNotifyCmd() *cobra.Command {
+	notifyCmd := &cobra.Command{
+		Use:   "notify",
+		Short: "Mail every student of a class its login",
+		Long: `Mail every student of a class its username, initial password, group and server
+through an SMTP server. Emails are taken from the users on the nodes, or from a class
+file with --file. Every attempt is recorded in the cluster database, students that
+were already mailed are skipped unless --resend is set, so the command can be run
+again after failures. --history prints the log.
+
+Subject and body are Go templates with the fields .Class, .Username, .FullName,
+.Password, .Group, .Server and .Email.
+
+The server defaults to the [smtp] table of ~/.gns3/config.toml:
+
+  [smtp]
+  host = "smtp.example.com"
+  port = 587
+  username = "lab"
+  from = "Networking Lab <lab@example.com>"
+  tls = "starttls"
+
+The password is read from GNS3_SMTP_PASSWORD or prompted for.`,
+		Example: `
+  # Render the mails to files to check them before sending
+  gns3util -s https://controller:3080 class notify --class CS101 --from lab@example.com --dry-run-dir ./mails
+
+  # Send through the server of config.toml with a custom body, 20 mails per minute
+  gns3util class notify --cluster lab --class CS101 --body-file welcome.tmpl --rate 20
+
+  # Send to a local test server (gns3util dev fake-smtp)
+  gns3util -s https://controller:3080 class notify --class CS101 --smtp-host 127.0.0.1 --smtp-port 1025 --smtp-tls none --from lab@example.com
+
+  # Show who was mailed
+  gns3util -s https://controller:3080 class notify --class CS101 --history
+		`,
+		RunE: runClassNotify,
+	}
+
+	notifyCmd.Flags().String("class", "", "Name of the class (default select with the fuzzy finder)")
+	notifyCmd.Flags().String("subject", class.DefaultNotifySubject, "Subject template")
+	notifyCmd.Flags().String("body-file", "", "File with the body template (default a short message with the login)")
+	notifyCmd.Flags().String("file", "", "Class file to take the emails from instead of the nodes")
+	notifyCmd.Flags().StringSlice("only", nil, "Only mail these usernames")
+	notifyCmd.Flags().Bool("resend", false, "Also mail students that were already mailed")
+	notifyCmd.Flags().Int("rate", 30, "Maximum mails per minute, 0 for no limit")
+	notifyCmd.Flags().String("dry-run-dir", "", "Write the mails as .eml files to this directory instead of sending them")
+	notifyCmd.Flags().Bool("history", false, "Print the delivery log of the class")
+	notifyCmd.Flags().String("smtp-host", "", "SMTP server (default from config.toml)")
+	notifyCmd.Flags().Int("smtp-port", 0, "SMTP port (default from config.toml, else 587)")
+	notifyCmd.Flags().String("smtp-user", "", "SMTP username (default from config.toml)")
+	notifyCmd.Flags().String("smtp-tls", "", "TLS mode: "+strings.Join(mailer.TLSModes, ", ")+" (default from config.toml, else starttls)")
+	notifyCmd.Flags().Bool("smtp-insecure", false, "Do not verify the certificate of the SMTP server")
+	notifyCmd.Flags().String("from", "", "Sender address (default from config.toml)")
+	notifyCmd.Flags().StringP("cluster", "c", "", "Cluster name")
+
+	return notifyCmd
+}
+
+func runClassNotify(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get global options: %w", err)
+	}
+	className, _ := cmd.Flags().GetString("class")
+	subject, _ := cmd.Flags().GetString("subject")
+	bodyFile, _ := cmd.Flags().GetString("body-file")
+	filePath, _ := cmd.Flags().GetString("file")
+	only, _ := cmd.Flags().GetStringSlice("only")
+	resend, _ := cmd.Flags().GetBool("resend")
+	rate, _ := cmd.Flags().GetInt("rate")
+	dryRunDir, _ := cmd.Flags().GetString("dry-run-dir")
+	history, _ := cmd.Flags().GetBool("history")
+	clusterName, _ := cmd.Flags().GetString("cluster")
+
+	if rate < 0 {
+		return fmt.Errorf("--rate must not be negative")
+	}
+
+	clusterID, err := clusterutils.ResolveClusterID(cfg, clusterName, cmd.Context())
+	if err != nil {
+		return err
+	}
+	if className == "" {
+		className, err = selectClass(cmd.Context(), clusterID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if history {
+		log, err := class.GetNotificationLog(cmd.Context(), clusterID, className)
+		if err != nil {
+			return err
+		}
+		if len(log) == 0 {
+			fmt.Printf("%v no mails were sent for class %s\n", messageUtils.InfoMsg("Empty"), messageUtils.Bold(className))
+			return nil
+		}
+		if utils.StructuredOutput(cfg) {
+			return utils.PrintValue(cfg, log)
+		}
+		utils.PrintTable(log, []utils.Column[class.Notification]{
+			{Header: "Sent At", Value: func(n class.Notification) string { return n.SentAt.Local().Format("2006-01-02 15:04:05") }},
+			{Header: "Username", Value: func(n class.Notification) string { return n.Username }},
+			{Header: "Email", Value: func(n class.Notification) string { return n.Email }},
+			{Header: "Status", Value: func(n class.Notification) string { return n.Status }},
+			{Header: "Error", Value: func(n class.Notification) string { return n.Error }},
+		})
+		return nil
+	}
+
+	smtpCfg, err := smtpConfig(cmd, dryRunDir != "")
+	if err != nil {
+		return err
+	}
+
+	body := class.DefaultNotifyBody
+	if bodyFile != "" {
+		data, err := os.ReadFile(bodyFile) // #nosec G304
+		if err != nil {
+			return fmt.Errorf("failed to read the body template: %w", err)
+		}
+		body = string(data)
+	}
+
+	var emails map[string]string
+	if filePath != "" {
+		classData, err := class.LoadClassFromFile(filePath)
+		if err != nil {
+			return err
+		}
+		emails = map[string]string{}
+		for _, g := range classData.Groups {
+			for _, s := range g.Students {
+				if s.Email != nil && *s.Email != "" {
+					emails[s.UserName] = *s.Email
+				}
+			}
+		}
+	}
+
+	return class.NotifyClass(cmd.Context(), cfg, class.NotifyOptions{
+		ClusterID: clusterID,
+		ClassName: className,
+		SMTP:      smtpCfg,
+		Subject:   subject,
+		Body:      body,
+		DryRunDir: dryRunDir,
+		Rate:      rate,
+		Resend:    resend,
+		Only:      only,
+		Emails:    emails,
+	})
+}
+
+// smtpConfig merges the smtp flags over the [smtp] table of config.toml.
+// Only the sender is needed for a dry run.
+func smtpConfig(cmd *cobra.Command, dryRun bool) (mailer.Config, error) {
+	uc, err := config.LoadUserConfig()
+	if err != nil {
+		return mailer.Config{}, err
+	}
+	stored := config.SMTP{}
+	if uc.SMTP != nil {
+		stored = *uc.SMTP
+	}
+	c := mailer.Config{
+		Host:     stored.Host,
+		Port:     stored.Port,
+		Username: stored.Username,
+		From:     stored.From,
+		TLS:      stored.TLS,
+		Insecure: stored.Insecure,
+	}
+	if f := cmd.Flags().Lookup("smtp-host"); f.Changed {
+		c.Host = f.Value.String()
+	}
+	if cmd.Flags().Changed("smtp-port") {
+		c.Port, _ = cmd.Flags().GetInt("smtp-port")
+	}
+	if f := cmd.Flags().Lookup("smtp-user"); f.Changed {
+		c.Username = f.Value.String()
+	}
+	if f := cmd.Flags().Lookup("smtp-tls"); f.Changed {
+		c.TLS = f.Value.String()
+	}
+	if cmd.Flags().Changed("smtp-insecure") {
+		c.Insecure, _ = cmd.Flags().GetBool("smtp-insecure")
+	}
+	if f := cmd.Flags().Lookup("from"); f.Changed {
+		c.From = f.Value.String()
+	}
+	if c.Port == 0 {
+		c.Port = 587
+	}
+	if c.TLS == "" {
+		c.TLS = mailer.TLSStartTLS
+	}
+
+	if c.From == "" {
+		return c, fmt.Errorf("no sender, set --from or from in the [smtp] table of config.toml")
+	}
+	if !slices.Contains(mailer.TLSModes, c.TLS) {
+		return c, fmt.Errorf("unknown tls mode %q, use one of %s", c.TLS, strings.Join(mailer.TLSModes, ", "))
+	}
+	if dryRun {
+		return c, nil
+	}
+	if c.Host == "" {
+		return c, fmt.Errorf("no SMTP server, set --smtp-host or host in the [smtp] table of config.toml")
+	}
+	if c.Username != "" {
+		c.Password = os.Getenv("GNS3_SMTP_PASSWORD")
+		if c.Password == "" {
+			fmt.Printf("%v for %s on %s\n", messageUtils.InfoMsg("SMTP password"), messageUtils.Bold(c.Username), c.Host)
+			c.Password, err = utils.GetPasswordFromInput()
+			if err != nil {
+				return c, err
+			}
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,75 @@
+package class
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+)
+
+// newImportedClass creates class NET from the roster with two groups.
+func newImportedClass(t *testing.T) *testutil.Cluster {
+	t.Helper()
+	tc := testutil.NewCluster(t)
+	tc.Run(t, NewImportClassCmd(), "--csv", writeRoster(t), "--name", "NET", "--group-size", "2", "--username-pattern", "{email}", "--create", "--cluster", tc.Name, "--out", filepath.Join(t.TempDir(), "net.json"))
+	return tc
+}
+
+func TestClassLsOutput(t *testing.T) {
+	tc := newImportedClass(t)
+	tc.Cfg.Output = "json"
+	out := testutil.Stdout(t, func() { tc.Run(t, NewClassLsCmd(), "--db-only") })
+	var classes []ClassDistribution
+	if err := json.Unmarshal([]byte(out), &classes); err != nil {
+		t.Fatalf("class ls -o json printed %q: %v", out, err)
+	}
+	if len(classes) != 1 || classes[0].Name != "NET" || len(classes[0].Nodes) != 1 {
+		t.Fatalf("classes %+v, want NET on one node", classes)
+	}
+	for _, node := range classes[0].Nodes {
+		if node.GroupCount != 2 || node.UserCount != 3 {
+			t.Errorf("node %+v, want 2 groups with 3 users", node)
+		}
+	}
+}
+
+func TestClassFileOutput(t *testing.T) {
+	tc := newImportedClass(t)
+	dir := t.TempDir()
+	tests := []struct {
+		cmd  func() *cobra.Command
+		args []string
+		file string
+		want string
+	}{
+		{cmd: NewClassCredentialsCmd, args: []string{"-o", "markdown"}, file: "net.md", want: "| `jane` |"},
+		{cmd: NewClassCredentialsCmd, args: []string{"--format", "html"}, file: "net.html", want: "<html"},
+		{cmd: NewExportClassCmd, file: "net.yaml", want: "name: NET"},
+		{cmd: NewExportClassCmd, args: []string{"-o", "json"}, file: "net.txt", want: `"name": "NET"`},
+	}
+	for _, tt := range tests {
+		out := filepath.Join(dir, tt.file)
+		args := append([]string{"--class", "NET", "--cluster", tc.Name, "--out", out}, tt.args...)
+		tc.Run(t, tt.cmd(), args...)
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), tt.want) {
+			t.Errorf("%s: %s does not contain %q", strings.Join(tt.args, " "), data, tt.want)
+		}
+	}
+
+	cmd := NewExportClassCmd()
+	cmd.SetArgs([]string{"--class", "NET", "--cluster", tc.Name, "-o", "net.yaml"})
+	cmd.SetContext(config.WithGlobalOptions(t.Context(), tc.Cfg))
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "--out") {
+		t.Errorf("export -o FILE: %v, want a hint to use --out", err)
+	}
+}
@@ -0,0 +1,35 @@
+package class
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+)
+
+const placementHelp = `
+Groups are placed on the nodes of the cluster with --placement, or the placement
+of the cluster in cluster_config.toml:
+  weighted      in proportion to the node weights (default)
+  least-loaded  on the nodes with the fewest projects and the most free memory
+  binpack       on as few nodes as the free memory allows, needs --group-ram or
+                --ram-project for the memory of one group
+  spread        evenly over the labels of the nodes, like racks or sites`
+
+func addPlacementFlags(cmd *cobra.Command) {
+	cmd.Flags().String("placement", "", "Placement strategy for new groups: "+strings.Join(class.PlacementStrategies, ", ")+" (default the placement of the cluster or weighted)")
+	cmd.Flags().Int("group-ram", 0, "Memory one group needs in MB, for binpack")
+	cmd.Flags().String("ram-project", "", "Project name/ID whose nodes need the memory of one group, for binpack")
+}
+
+// schedulerFor returns the scheduler of the --placement of cmd, or of the
+// placement set for the cluster in the cluster config. An empty
+// clusterName means the single node cluster of cfg.Server.
+func schedulerFor(cmd *cobra.Command, cfg config.GlobalOptions, clusterName string) (class.Scheduler, error) {
+	var opts class.PlacementOptions
+	opts.Strategy, _ = cmd.Flags().GetString("placement")
+	opts.GroupRAM, _ = cmd.Flags().GetInt("group-ram")
+	opts.RAMProject, _ = cmd.Flags().GetString("ram-project")
+	return class.ClusterScheduler(cfg, clusterName, opts)
+}
@@ -11,9 +11,21 @@ func NewClusterCmdGroup() *cobra.Command {
 		Short: "cluster operations",
 		Long:  `Create and organize your GNS3 servers inside of a cluster`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyEnv(cmd); err != nil {
+				return err
+			}
+			if err := applyContext(cmd); err != nil {
+				return err
+			}
 			if err := validateGlobalFlags(); err != nil {
 				return err
 			}
+			if err := setupCassette(); err != nil {
+				return err
+			}
+			if err := setGlobalOptions(cmd); err != nil {
+				return err
+			}
 
 			return nil
 		},
@@ -26,6 +38,12 @@ func NewClusterCmdGroup() *cobra.Command {
 	clusterCmd.AddCommand(clustercmd.NewAddNodeCmd())
 	clusterCmd.AddCommand(clustercmd.NewAddNodesCmd())
 	clusterCmd.AddCommand(clustercmd.NewLsClusterCmd())
+	clusterCmd.AddCommand(clustercmd.NewCordonCmd())
+	clusterCmd.AddCommand(clustercmd.NewUncordonCmd())
+	clusterCmd.AddCommand(clustercmd.NewDrainCmd())
+	clusterCmd.AddCommand(clustercmd.NewRemoveNodeCmd())
+	clusterCmd.AddCommand(clustercmd.NewDoctorCmd())
 	clusterCmd.AddCommand(clustercmd.NewClusterConfigmdGroup())
+	clusterCmd.AddCommand(clustercmd.NewClusterDbCmdGroup())
 	return clusterCmd
 }
@@ -0,0 +1,65 @@
+package clustercmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewCordonCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "cordon [cluster-name] [node]",
+		Short: "Stop placing new groups on a node",
+		Long: `Stop placing new groups on a node. Class create, class apply and cluster drain
+skip cordoned nodes, the groups already on the node stay there. The node is
+given by its ID, URL or host:port.`,
+		Example: `
+  # Take a node out of the placement before maintenance
+  gns3util cluster cordon lab gns3-2.local:3080
+		`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setCordoned(cmd, args[0], args[1], true)
+		},
+	}
+}
+
+func NewUncordonCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "uncordon [cluster-name] [node]",
+		Short: "Place new groups on a cordoned node again",
+		Long:  `Place new groups on a cordoned node again. The node is given by its ID, URL or host:port.`,
+		Example: `
+  gns3util cluster uncordon lab 2
+		`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setCordoned(cmd, args[0], args[1], false)
+		},
+	}
+}
+
+func setCordoned(cmd *cobra.Command, clusterRef, nodeRef string, cordoned bool) error {
+	cmd.SilenceUsage = true
+	store, err := db.Init()
+	if err != nil {
+		return fmt.Errorf("failed to init db: %w", err)
+	}
+	_, node, err := cluster.FindNode(cmd.Context(), store, clusterRef, nodeRef)
+	if err != nil {
+		return err
+	}
+	if err := store.UpdateNodeCordoned(cmd.Context(), sqlc.UpdateNodeCordonedParams{Cordoned: cordoned, NodeID: node.NodeID}); err != nil {
+		return fmt.Errorf("failed to update node: %w", err)
+	}
+	verb := "Cordoned"
+	if !cordoned {
+		verb = "Uncordoned"
+	}
+	fmt.Printf("%v node %s\n", messageUtils.SuccessMsg(verb), messageUtils.Bold(cluster.NodeURL(node)))
+	return nil
+}
@@ -0,0 +1,122 @@
+package clustercmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/colorUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewClusterDbCmdGroup() *cobra.Command {
+	dbCmd := &cobra.Command{
+		Use:   "db",
+		Short: "cluster database operations",
+		Long: `commands to manage the schema of the cluster database in ~/.gns3/clusterData.db.
+Every command opening the database applies pending migrations on its own, a
+database migrated by a newer gns3util is refused.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_ = cmd.Help()
+			return nil
+		},
+	}
+	dbCmd.AddCommand(NewDbStatusCmd())
+	dbCmd.AddCommand(NewDbMigrateCmd())
+	return dbCmd
+}
+
+func NewDbStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "list the schema migrations of the cluster database",
+		Long:  `list the schema migrations this gns3util knows and whether they are applied to the cluster database, without applying any`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := db.Open()
+			if err != nil {
+				return fmt.Errorf("failed to open database: %w", err)
+			}
+			defer func() {
+				_ = store.DB.Close()
+			}()
+			migrations, err := store.MigrationStatus(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get migration status: %w", err)
+			}
+
+			raw, _ := cmd.InheritedFlags().GetBool("raw")
+			noColor, _ := cmd.InheritedFlags().GetBool("no-color")
+			if raw {
+				mar, err := json.Marshal(migrations)
+				if err != nil {
+					return fmt.Errorf("failed to marshal results: %w", err)
+				}
+				if noColor {
+					utils.PrintJsonUgly(mar)
+				} else {
+					utils.PrintJson(mar)
+				}
+				return nil
+			}
+			utils.PrintTable(migrations, []utils.Column[db.Migration]{
+				{
+					Header: "Version",
+					Value: func(m db.Migration) string {
+						return fmt.Sprintf("%03d", m.Version)
+					},
+				},
+				{
+					Header: "Name",
+					Value: func(m db.Migration) string {
+						return m.Name
+					},
+				},
+				{
+					Header: "State",
+					Value: func(m db.Migration) string {
+						switch {
+						case m.Unknown:
+							return colorUtils.Error("unknown, from a newer gns3util")
+						case !m.Applied:
+							return colorUtils.Warning("pending")
+						case m.AppliedAt.IsZero():
+							return "applied"
+						}
+						return "applied " + m.AppliedAt.Local().Format("2006-01-02 15:04")
+					},
+				},
+			})
+			return nil
+		},
+	}
+}
+
+func NewDbMigrateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate",
+		Short: "apply the pending schema migrations to the cluster database",
+		Long:  `apply the pending schema migrations to the cluster database, for example after receiving it with share receive`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := db.Open()
+			if err != nil {
+				return fmt.Errorf("failed to open database: %w", err)
+			}
+			defer func() {
+				_ = store.DB.Close()
+			}()
+			applied, err := store.Migrate(cmd.Context())
+			for _, m := range applied {
+				fmt.Printf("%s migration %s\n", messageUtils.SuccessMsg("Applied"), messageUtils.Bold(m.Name))
+			}
+			if err != nil {
+				return fmt.Errorf("failed to migrate database: %w", err)
+			}
+			if len(applied) == 0 {
+				fmt.Println("Nothing to do, database already up to date.")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,149 @@
+package clustercmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/cmd/exercise"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	clusterutils "github.com/stefanistkuhl/gns3util/pkg/utils/clusterUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/colorUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewDoctorCmd() *cobra.Command {
+	var clusterName string
+	var fix bool
+	var missing string
+	var untracked string
+	var noConfirm bool
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Find and repair drift between the cluster database and its nodes",
+		Long: `Cross-check the classes, groups, students, group assignments and exercises in
+the cluster database with the users, user groups, projects, pools and ACLs on
+the nodes of the cluster and list what only one side has.
+
+Rows of the database whose object is gone from its node (side db) are handled
+by --missing:
+  recreate  create the user group, student, pool or ACE again, students get
+            their initial password back, exercise projects are deployed
+            again from the template of the exercise
+  prune     delete the row
+
+Objects on a node that belong to a class but have no row (side controller),
+like a user group named after a class or a project shared with one of its
+groups, are handled by --untracked:
+  keep      only report them
+  adopt     add them to the database, projects have to be named in the
+            default exercise name format
+  prune     delete them from the node
+
+Group memberships, class user groups and pools are always recreated and the
+leftovers of a failed exercise delete always removed. Nothing is changed
+without --fix.`,
+		Example: `
+  # Report the drift of a cluster
+  gns3util cluster doctor --cluster prod
+
+  # Recreate what is missing and take over what is not tracked
+  gns3util cluster doctor --cluster prod --fix --untracked adopt`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get global options: %w", err)
+			}
+			clusterID, err := clusterutils.ResolveClusterID(cfg, clusterName, cmd.Context())
+			if err != nil {
+				return err
+			}
+			findings, err := class.DiagnoseCluster(cmd.Context(), cfg, class.DoctorOptions{
+				ClusterID: clusterID,
+				Missing:   missing,
+				Untracked: untracked,
+				Redeploy:  exercise.Redeploy(cfg, clusterID),
+			})
+			if err != nil {
+				return err
+			}
+
+			structured := utils.StructuredOutput(cfg)
+			if !fix || len(findings) == 0 {
+				return printFindings(cfg, findings)
+			}
+			fixable := 0
+			for _, f := range findings {
+				if f.Fix != "" {
+					fixable++
+				}
+			}
+			if !structured {
+				_ = printFindings(cfg, findings)
+			}
+			if fixable == 0 {
+				if !structured {
+					fmt.Println("Nothing to fix with these policies.")
+				}
+				return nil
+			}
+			if !noConfirm && !utils.ConfirmPrompt(fmt.Sprintf("Apply %d fixes?", fixable), false) {
+				fmt.Println("Aborted.")
+				return nil
+			}
+			repairErr := class.RepairFindings(cmd.Context(), findings)
+			if structured {
+				if err := printFindings(cfg, findings); err != nil {
+					return err
+				}
+				return repairErr
+			}
+			for _, f := range findings {
+				switch {
+				case f.Fixed:
+					fmt.Printf("%s %s %s: %s\n", messageUtils.SuccessMsg("Fixed"), f.Kind, messageUtils.Bold(f.Name), f.Fix)
+				case f.Error != "":
+					fmt.Printf("%v failed to fix %s %s: %s\n", messageUtils.WarningMsg("Warning"), f.Kind, messageUtils.Bold(f.Name), f.Error)
+				}
+			}
+			return repairErr
+		},
+	}
+	cmd.Flags().StringVarP(&clusterName, "cluster", "c", "", "Cluster name")
+	cmd.Flags().BoolVar(&fix, "fix", false, "Repair the findings according to the policies")
+	cmd.Flags().StringVar(&missing, "missing", class.PolicyRecreate, "Policy for rows whose object is gone: recreate or prune")
+	cmd.Flags().StringVar(&untracked, "untracked", class.PolicyKeep, "Policy for objects of a class without a row: keep, adopt or prune")
+	cmd.Flags().BoolVar(&noConfirm, "no-confirm", false, "Repair without asking")
+	utils.AddOutputFlags(cmd)
+	return cmd
+}
+
+func printFindings(cfg config.GlobalOptions, findings []class.Finding) error {
+	if utils.StructuredOutput(cfg) {
+		if findings == nil {
+			findings = []class.Finding{}
+		}
+		return utils.PrintValue(cfg, findings)
+	}
+	if len(findings) == 0 {
+		fmt.Println("No drift found, the database and the nodes agree.")
+		return nil
+	}
+	utils.PrintTable(findings, []utils.Column[class.Finding]{
+		{Header: "Node", Value: func(f class.Finding) string { return f.Node }},
+		{Header: "Side", Value: func(f class.Finding) string { return f.Side }},
+		{Header: "Kind", Value: func(f class.Finding) string { return f.Kind }},
+		{Header: "Class", Value: func(f class.Finding) string { return f.Class }},
+		{Header: "Name", Value: func(f class.Finding) string { return f.Name }},
+		{Header: "Problem", Value: func(f class.Finding) string { return colorUtils.Warning(f.Problem) }},
+		{Header: "Fix", Value: func(f class.Finding) string {
+			if f.Fix == "" {
+				return "-"
+			}
+			return f.Fix
+		}},
+	})
+	return nil
+}
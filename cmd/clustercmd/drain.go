@@ -0,0 +1,122 @@
+package clustercmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewDrainCmd() *cobra.Command {
+	var to string
+	var noConfirm bool
+	var dryRun bool
+	var force bool
+	var placement string
+	var groupRAM int
+	var ramProject string
+	cmd := &cobra.Command{
+		Use:   "drain [cluster-name] [node]",
+		Short: "Move all groups off a node",
+		Long: `Move all groups off a node to the other nodes of the cluster. The node is
+cordoned first so no new groups land on it. Each group is moved one after
+another: its students are recreated on the new node with their initial
+password, its exercise projects are exported with their snapshots and imported
+under the same ID, their pools and ACLs are recreated and the group is assigned
+to the new node. Only then the group is removed from the drained node.
+
+A running exercise project can not be exported safely, groups with one are
+refused unless --force stops their nodes first. Exercises that were running
+are started again on the new node.
+
+The groups are split over the nodes that are not cordoned within their
+max_groups, placed with --placement or the placement of the cluster in
+cluster_config.toml like new groups of class create, or all go to the node of
+--to. A group that fails to move stays on the drained node, run drain again to
+retry it.`,
+		Example: `
+  # Show where the groups of a node would go
+  gns3util cluster drain lab gns3-2.local:3080 --dry-run
+
+  # Move all groups of node 2 to node 3
+  gns3util cluster drain lab 2 --to 3 --no-confirm
+
+  # Stop running exercises and move them anyway
+  gns3util cluster drain lab 2 --force
+		`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			cfg, _ := config.GetGlobalOptionsFromContext(cmd.Context())
+			ctx := cmd.Context()
+			store, err := db.Init()
+			if err != nil {
+				return fmt.Errorf("failed to init db: %w", err)
+			}
+			clusterData, node, err := cluster.FindNode(ctx, store, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			var toNodeID int64
+			if to != "" {
+				_, toNode, err := cluster.FindNode(ctx, store, args[0], to)
+				if err != nil {
+					return err
+				}
+				toNodeID = toNode.NodeID
+			}
+
+			scheduler, err := class.ClusterScheduler(cfg, clusterData.Name, class.PlacementOptions{
+				Strategy:   placement,
+				GroupRAM:   groupRAM,
+				RAMProject: ramProject,
+			})
+			if err != nil {
+				return err
+			}
+			plan, err := class.PlanNodeDrain(ctx, int(clusterData.ClusterID), node.NodeID, toNodeID, scheduler)
+			if err != nil {
+				return err
+			}
+			if len(plan.Moves) == 0 {
+				fmt.Printf("No groups on %s\n", messageUtils.Bold(plan.NodeURL))
+			} else {
+				plan.Print()
+			}
+			if dryRun {
+				return nil
+			}
+
+			if len(plan.Moves) > 0 && !noConfirm && !utils.ConfirmPrompt(fmt.Sprintf("Move %d groups off %s?", len(plan.Moves), plan.NodeURL), false) {
+				fmt.Println("Aborted.")
+				return nil
+			}
+			if !node.Cordoned {
+				if err := store.UpdateNodeCordoned(ctx, sqlc.UpdateNodeCordonedParams{Cordoned: true, NodeID: node.NodeID}); err != nil {
+					return fmt.Errorf("failed to cordon node: %w", err)
+				}
+				fmt.Printf("%v node %s\n", messageUtils.SuccessMsg("Cordoned"), messageUtils.Bold(plan.NodeURL))
+			}
+			if len(plan.Moves) == 0 {
+				return nil
+			}
+			plan.Force = force
+			return class.DrainNode(ctx, cfg, plan)
+		},
+	}
+	cmd.Flags().StringVar(&to, "to", "", "Node to move all groups to, by ID, URL or host:port")
+	cmd.Flags().BoolVar(&noConfirm, "no-confirm", false, "Move the groups without asking")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only print the plan")
+	cmd.Flags().BoolVar(&force, "force", false, "Stop the nodes of running exercise projects instead of refusing their groups")
+	cmd.Flags().StringVar(&placement, "placement", "", "Placement strategy for the moved groups: "+strings.Join(class.PlacementStrategies, ", ")+" (default the placement of the cluster or weighted)")
+	cmd.Flags().IntVar(&groupRAM, "group-ram", 0, "Memory one group needs in MB, for binpack")
+	cmd.Flags().StringVar(&ramProject, "ram-project", "", "Project name/ID whose nodes need the memory of one group, for binpack")
+	return cmd
+}
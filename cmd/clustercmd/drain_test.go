@@ -0,0 +1,305 @@
+package clustercmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/cmd/exercise"
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+)
+
+// newDrainCluster creates a two node cluster with class NET on the first
+// node, exercise lab1 for it and the exercise of NET-g1 running.
+func newDrainCluster(t *testing.T) (*testutil.Cluster, *sdk.Client) {
+	t.Helper()
+	tc := testutil.NewCluster(t)
+	second := tc.AddNode(t)
+	classData := schemas.Class{Name: "NET", Groups: []schemas.Group{
+		{Name: "NET-g1", Students: []schemas.Student{{UserName: "alice", Password: "alice-pass1"}}},
+		{Name: "NET-g2", Students: []schemas.Student{{UserName: "bob", Password: "bob-pass1"}}},
+	}}
+	scheduler, err := class.NewScheduler(tc.Cfg, class.PlacementOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := class.CreateClass(tc.Cfg, tc.ClusterID, classData, tc.Nodes[:1], scheduler); err != nil || !ok {
+		t.Fatalf("create class: %v", err)
+	}
+	tc.TemplateProject(t, "tmpl")
+	tc.Run(t, exercise.NewExerciseCreateCmd(), "--class", "NET", "--exercise", "lab1", "--template", "tmpl", "--confirm=false")
+	tc.Run(t, exercise.NewExerciseStartCmd(), "--name", "lab1", "--class", "NET", "--group", "NET-g1")
+	return tc, second
+}
+
+func nodeURL(n db.NodeDataAll) string {
+	return fmt.Sprintf("%s://%s:%d", n.Protocol, n.Host, n.Port)
+}
+
+// groupNode returns the URL of the node a group of class NET is assigned to.
+func groupNode(t *testing.T, tc *testutil.Cluster, group string) string {
+	t.Helper()
+	store, err := db.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = store.DB.Close()
+	}()
+	row, err := store.GetGroupNode(context.Background(), sqlc.GetGroupNodeParams{ClusterID: int64(tc.ClusterID), Name: "NET", Name_2: group})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprint(row.NodeUrl)
+}
+
+// cordoned returns the cordoned flag of every node of the cluster by ID.
+func cordoned(t *testing.T, tc *testutil.Cluster) map[int64]bool {
+	t.Helper()
+	store, err := db.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = store.DB.Close()
+	}()
+	nodes, err := store.GetNodesFromClusterID(context.Background(), int64(tc.ClusterID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[int64]bool{}
+	for _, n := range nodes {
+		out[n.NodeID] = n.Cordoned
+	}
+	return out
+}
+
+func usernames(t *testing.T, client *sdk.Client) []string {
+	t.Helper()
+	users, err := client.Users().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, u := range users {
+		if u.Username != "admin" {
+			names = append(names, u.Username)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func projects(t *testing.T, client *sdk.Client) map[string]schemas.ProjectResponse {
+	t.Helper()
+	list, err := client.Projects().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]schemas.ProjectResponse{}
+	for _, p := range list {
+		out[p.Name] = p
+	}
+	return out
+}
+
+func nodes(t *testing.T, client *sdk.Client, projectID string) []schemas.NodeResponse {
+	t.Helper()
+	list, err := client.Nodes(projectID).List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+// runErr executes cmd with args and returns its error.
+func runErr(tc *testutil.Cluster, cmd *cobra.Command, args ...string) error {
+	cmd.SetArgs(args)
+	cmd.SetContext(config.WithGlobalOptions(context.Background(), tc.Cfg))
+	return cmd.Execute()
+}
+
+func TestCordon(t *testing.T) {
+	tc := testutil.NewCluster(t)
+	tc.AddNode(t)
+
+	tc.Run(t, NewCordonCmd(), tc.Name, "2")
+	if got := cordoned(t, tc); !got[2] || got[1] {
+		t.Fatalf("cordoned %v, want node 2 only", got)
+	}
+	// a node is found by its ID, host:port or URL
+	tc.Run(t, NewCordonCmd(), tc.Name, strings.TrimPrefix(nodeURL(tc.Nodes[0]), "http://"))
+	if got := cordoned(t, tc); !got[1] {
+		t.Fatalf("cordoned %v, want node 1 by host:port", got)
+	}
+	tc.Run(t, NewUncordonCmd(), tc.Name, nodeURL(tc.Nodes[0]))
+	tc.Run(t, NewUncordonCmd(), tc.Name, "2")
+	if got := cordoned(t, tc); got[1] || got[2] {
+		t.Errorf("cordoned %v after uncordon, want none", got)
+	}
+
+	for _, args := range [][]string{{tc.Name, "7"}, {"nope", "1"}} {
+		if err := runErr(tc, NewCordonCmd(), args...); err == nil {
+			t.Errorf("cordon %v succeeded", args)
+		}
+	}
+}
+
+func TestDrain(t *testing.T) {
+	tc, second := newDrainCluster(t)
+	first, target := nodeURL(tc.Nodes[0]), nodeURL(tc.Nodes[1])
+	before := tc.Projects(t)
+
+	tc.Run(t, NewDrainCmd(), tc.Name, "1", "--dry-run")
+	if got := cordoned(t, tc); got[1] {
+		t.Error("a dry run cordoned the node")
+	}
+	for _, group := range []string{"NET-g1", "NET-g2"} {
+		if got := groupNode(t, tc, group); got != first {
+			t.Errorf("a dry run moved %s to %s", group, got)
+		}
+	}
+
+	// the running exercise of NET-g1 is refused without --force
+	err := runErr(tc, NewDrainCmd(), tc.Name, "1", "--no-confirm")
+	if err == nil || !strings.Contains(err.Error(), "1 of 2 groups failed") {
+		t.Fatalf("drain with a running project: %v", err)
+	}
+	if got := groupNode(t, tc, "NET-g1"); got != first {
+		t.Errorf("the running NET-g1 moved to %s", got)
+	}
+	if got := groupNode(t, tc, "NET-g2"); got != target {
+		t.Errorf("NET-g2 is on %s, want %s", got, target)
+	}
+	running := tc.Exercises(t, "NET", "NET-g1")[0]
+	name := "NET-lab1-g1-" + running.ProjectUuid
+	if !slices.ContainsFunc(nodes(t, tc.Client, tc.Projects(t)[name].ProjectID), func(n schemas.NodeResponse) bool { return n.Status == "started" }) {
+		t.Errorf("the refused drain stopped %s", name)
+	}
+
+	tc.Run(t, NewDrainCmd(), tc.Name, "1", "--no-confirm", "--force")
+	if got := cordoned(t, tc); !got[1] || got[2] {
+		t.Errorf("cordoned %v, want the drained node", got)
+	}
+	for _, group := range []string{"NET-g1", "NET-g2"} {
+		if got := groupNode(t, tc, group); got != target {
+			t.Errorf("%s is on %s, want %s", group, got, target)
+		}
+	}
+	if got := usernames(t, tc.Client); len(got) > 0 {
+		t.Errorf("students left on the drained node: %v", got)
+	}
+	if got, want := usernames(t, second), []string{"alice", "bob"}; !slices.Equal(got, want) {
+		t.Errorf("students on the new node %v, want %v", got, want)
+	}
+
+	moved := projects(t, second)
+	left := projects(t, tc.Client)
+	for _, group := range []string{"NET-g1", "NET-g2"} {
+		ex := tc.Exercises(t, "NET", group)[0]
+		name := "NET-lab1-" + strings.TrimPrefix(group, "NET-") + "-" + ex.ProjectUuid
+		p, ok := moved[name]
+		if !ok {
+			t.Errorf("project %s not on the new node", name)
+			continue
+		}
+		if p.ProjectID != before[name].ProjectID {
+			t.Errorf("project %s has id %s on the new node, want %s", name, p.ProjectID, before[name].ProjectID)
+		}
+		if _, ok := left[name]; ok {
+			t.Errorf("project %s is still on the drained node", name)
+		}
+		if !ex.TemplateProjectID.Valid || ex.TemplateProjectID.String != moved["tmpl"].ProjectID {
+			t.Errorf("exercise of %s refers to template %v, want the copy %s", group, ex.TemplateProjectID, moved["tmpl"].ProjectID)
+		}
+		wantStatus := "closed"
+		if group == "NET-g1" {
+			wantStatus = "opened"
+		}
+		if p.Status == nil || *p.Status != wantStatus {
+			t.Errorf("project %s is %v, want %s", name, p.Status, wantStatus)
+		}
+	}
+	// the exercise that was running is started again on the new node
+	for _, n := range nodes(t, second, moved[name].ProjectID) {
+		if n.Status != "started" {
+			t.Errorf("node %s of %s is %s on the new node, want started", n.Name, name, n.Status)
+		}
+	}
+
+	// nothing is left to drain and the empty node can go
+	tc.Run(t, NewDrainCmd(), tc.Name, "1", "--no-confirm")
+	tc.Run(t, NewRemoveNodeCmd(), tc.Name, "1")
+	if got := cordoned(t, tc); len(got) != 1 {
+		t.Errorf("nodes %v after remove-node, want one", got)
+	}
+}
+
+func TestDrainAborted(t *testing.T) {
+	tc, _ := newDrainCluster(t)
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() {
+		os.Stdin = stdin
+		_ = r.Close()
+	}()
+	if _, err := w.WriteString("n\n"); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+
+	tc.Run(t, NewDrainCmd(), tc.Name, "1")
+	if got := cordoned(t, tc); got[1] {
+		t.Error("an aborted drain cordoned the node")
+	}
+	for _, group := range []string{"NET-g1", "NET-g2"} {
+		if got := groupNode(t, tc, group); got != nodeURL(tc.Nodes[0]) {
+			t.Errorf("an aborted drain moved %s to %s", group, got)
+		}
+	}
+}
+
+func TestDrainRefused(t *testing.T) {
+	tc, _ := newDrainCluster(t)
+
+	for _, args := range [][]string{
+		{tc.Name, "1", "--to", "1", "--no-confirm"},
+		{tc.Name, "1", "--to", "7", "--no-confirm"},
+		{tc.Name, "7", "--no-confirm"},
+	} {
+		if err := runErr(tc, NewDrainCmd(), args...); err == nil {
+			t.Errorf("drain %v succeeded", args)
+		}
+	}
+	if err := runErr(tc, NewRemoveNodeCmd(), tc.Name, "1"); err == nil || !strings.Contains(err.Error(), "still has 2 groups") {
+		t.Errorf("remove a node with groups: %v", err)
+	}
+
+	// a cordoned node takes no groups
+	tc.Run(t, NewCordonCmd(), tc.Name, "2")
+	if err := runErr(tc, NewDrainCmd(), tc.Name, "1", "--no-confirm"); err == nil || !strings.Contains(err.Error(), "no room") {
+		t.Errorf("drain onto a cordoned node: %v", err)
+	}
+	if err := runErr(tc, NewDrainCmd(), tc.Name, "1", "--to", "2", "--no-confirm"); err == nil || !strings.Contains(err.Error(), "is cordoned") {
+		t.Errorf("drain to a cordoned node: %v", err)
+	}
+	for _, group := range []string{"NET-g1", "NET-g2"} {
+		if got := groupNode(t, tc, group); got != nodeURL(tc.Nodes[0]) {
+			t.Errorf("refused drains moved %s to %s", group, got)
+		}
+	}
+}
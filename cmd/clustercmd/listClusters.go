@@ -2,13 +2,13 @@ package clustercmd
 
 import (
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
 	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
 	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
 	"github.com/stefanistkuhl/gns3util/pkg/utils"
 )
 
@@ -30,20 +30,16 @@ func NewLsClusterCmd() *cobra.Command {
 				}
 				return fmt.Errorf("failed to get clusters: %w", fetchErr)
 			}
-			raw, _ := cmd.InheritedFlags().GetBool("raw")
-			noColor, _ := cmd.InheritedFlags().GetBool("no-color")
-			if raw {
-				mar, err := json.Marshal(clusters)
-				if err != nil {
-					return fmt.Errorf("failed to marshal results: %w", err)
-				}
-				if noColor {
-					utils.PrintJsonUgly(mar)
-					return nil
-				} else {
-					utils.PrintJson(mar)
-					return nil
+			cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get global options: %w", err)
+			}
+			if utils.StructuredOutput(cfg) {
+				rows := make([]clusterRow, 0, len(clusters))
+				for _, c := range clusters {
+					rows = append(rows, clusterRow{ClusterID: c.ClusterID, Name: c.Name, Description: c.Description.String})
 				}
+				return utils.PrintValue(cfg, rows)
 			}
 			utils.PrintTable(clusters, []utils.Column[sqlc.Cluster]{
 				{
@@ -71,6 +67,14 @@ func NewLsClusterCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 
 	return cmd
 }
+
+// clusterRow is a cluster as printed by --output and --raw.
+type clusterRow struct {
+	ClusterID   int64  `json:"cluster_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
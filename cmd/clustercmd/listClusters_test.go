@@ -0,0 +1,21 @@
+package clustercmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+)
+
+func TestLsClusterOutput(t *testing.T) {
+	tc := testutil.NewCluster(t)
+	tc.Cfg.Output = "json"
+	out := testutil.Stdout(t, func() { tc.Run(t, NewLsClusterCmd()) })
+	var clusters []clusterRow
+	if err := json.Unmarshal([]byte(out), &clusters); err != nil {
+		t.Fatalf("cluster ls -o json printed %q: %v", out, err)
+	}
+	if len(clusters) != 1 || clusters[0].Name != tc.Name || clusters[0].ClusterID != int64(tc.ClusterID) {
+		t.Errorf("clusters %+v, want %s", clusters, tc.Name)
+	}
+}
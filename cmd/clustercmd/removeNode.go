@@ -0,0 +1,68 @@
+package clustercmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewRemoveNodeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove-node [cluster-name] [node]",
+		Short: "Remove a node without groups from a cluster",
+		Long: `Remove a node from a cluster and the cluster config. A node that still has
+groups is refused, move them with cluster drain first. The node is given by its
+ID, URL or host:port.`,
+		Example: `
+  gns3util cluster drain lab gns3-2.local:3080
+  gns3util cluster remove-node lab gns3-2.local:3080
+		`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			ctx := cmd.Context()
+			store, err := db.Init()
+			if err != nil {
+				return fmt.Errorf("failed to init db: %w", err)
+			}
+			_, node, err := cluster.FindNode(ctx, store, args[0], args[1])
+			if err != nil {
+				return err
+			}
+			groups, err := store.GetGroupsOnNode(ctx, node.NodeID)
+			if err != nil {
+				return fmt.Errorf("failed to get the groups of the node: %w", err)
+			}
+			if len(groups) > 0 {
+				return fmt.Errorf("node %s still has %d groups, run cluster drain first", cluster.NodeURL(node), len(groups))
+			}
+			if err := store.DeleteNode(ctx, node.NodeID); err != nil {
+				return fmt.Errorf("failed to delete node: %w", err)
+			}
+			fmt.Printf("%v node %s\n", messageUtils.SuccessMsg("Removed"), messageUtils.Bold(cluster.NodeURL(node)))
+
+			cfg, cfgErr := cluster.LoadClusterConfig()
+			if cfgErr != nil {
+				if errors.Is(cfgErr, cluster.ErrNoConfig) {
+					cfg = cluster.NewConfig()
+				} else {
+					return fmt.Errorf("failed to load config: %w", cfgErr)
+				}
+			}
+			cfg, changed, syncErr := cluster.SyncConfigWithDb(ctx, cfg)
+			if syncErr != nil {
+				return fmt.Errorf("failed to sync config with db: %w", syncErr)
+			}
+			if changed {
+				if err := cluster.WriteClusterConfig(cfg); err != nil {
+					return fmt.Errorf("failed to write config: %w", err)
+				}
+			}
+			return nil
+		},
+	}
+}
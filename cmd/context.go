@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/cmd/contextcmd"
+)
+
+func NewContextCmdGroup() *cobra.Command {
+	contextCmd := &cobra.Command{
+		Use:   "context",
+		Short: "manage named server contexts",
+		Long:  `Store servers with their flags as named contexts in ~/.gns3/config.toml so --server and friends can be left out.`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// contexts are local state, they neither need nor use a server
+			if err := applyEnv(cmd); err != nil {
+				return err
+			}
+			if err := validateGlobalFlags(); err != nil {
+				return err
+			}
+			if err := setGlobalOptions(cmd); err != nil {
+				return err
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_ = cmd.Help()
+			return nil
+		},
+	}
+	contextCmd.AddCommand(contextcmd.NewAddContextCmd())
+	contextCmd.AddCommand(contextcmd.NewUseContextCmd())
+	contextCmd.AddCommand(contextcmd.NewLsContextCmd())
+	contextCmd.AddCommand(contextcmd.NewRmContextCmd())
+	return contextCmd
+}
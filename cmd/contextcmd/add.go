@@ -0,0 +1,71 @@
+package contextcmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewAddContextCmd() *cobra.Command {
+	var (
+		ctx config.Context
+		use bool
+	)
+	cmd := &cobra.Command{
+		Use:   "add NAME",
+		Short: "Add or replace a context",
+		Long: `Add a named context or replace the one with the same name. The first context added becomes the current one.
+
+A context fills in every global flag that is neither given on the command line nor set through a GNS3_* environment variable. Commands that accept --cluster use the cluster of the context instead of its server.`,
+		Example: `
+  # Add a lab server with a self-signed certificate
+  gns3util context add lab --server https://lab.example.com:3080 --insecure
+
+  # Add a context for a cluster and switch to it
+  gns3util context add classroom --cluster classroom --use
+		`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx.Name = args[0]
+			ctx.Server = strings.TrimRight(ctx.Server, "/")
+			if ctx.Server == "" && ctx.Cluster == "" {
+				return fmt.Errorf("either --server or --cluster must be specified")
+			}
+			if ctx.Server != "" && !strings.Contains(ctx.Server, "://") {
+				return fmt.Errorf("server %q has no scheme, use something like https://%s", ctx.Server, ctx.Server)
+			}
+
+			uc, err := config.LoadUserConfig()
+			if err != nil {
+				return err
+			}
+			replaced := uc.SetContext(ctx)
+			if use || uc.CurrentContext == "" {
+				uc.CurrentContext = ctx.Name
+			}
+			if err := config.WriteUserConfig(uc); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+
+			verb := "Added context"
+			if replaced {
+				verb = "Updated context"
+			}
+			fmt.Printf("%v %s\n", messageUtils.SuccessMsg(verb), messageUtils.Bold(ctx.Name))
+			if uc.CurrentContext == ctx.Name {
+				fmt.Printf("%v %s\n", messageUtils.InfoMsg("Current context"), messageUtils.Bold(ctx.Name))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&ctx.Server, "server", "s", "", "GNS3v3 Server URL")
+	cmd.Flags().BoolVarP(&ctx.Insecure, "insecure", "i", false, "Ignore unsigned SSL-Certificates")
+	cmd.Flags().StringVar(&ctx.User, "user", "", "Stored identity to use by default (see auth list)")
+	cmd.Flags().StringVarP(&ctx.KeyFile, "key-file", "k", "", "Keyfile to use")
+	cmd.Flags().StringVarP(&ctx.Cluster, "cluster", "c", "", "Default cluster for commands that accept --cluster")
+	cmd.Flags().BoolVar(&use, "use", false, "Make the context the current one")
+	return cmd
+}
@@ -0,0 +1,54 @@
+package contextcmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+)
+
+func NewLsContextCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List the contexts",
+		Long:    `List the contexts in ~/.gns3/config.toml, the current one is marked with *.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("failed to get global options: %w", err)
+			}
+			uc, err := config.LoadUserConfig()
+			if err != nil {
+				return err
+			}
+
+			if cfg.Raw {
+				return utils.PrintValue(cfg, uc)
+			}
+			if utils.StructuredOutput(cfg) {
+				return utils.PrintValue(cfg, uc.Contexts)
+			}
+
+			utils.PrintTable(uc.Contexts, []utils.Column[config.Context]{
+				{Header: "Current", Value: func(c config.Context) string {
+					if c.Name == uc.CurrentContext {
+						return "*"
+					}
+					return ""
+				}},
+				{Header: "Name", Value: func(c config.Context) string { return c.Name }},
+				{Header: "Server", Value: func(c config.Context) string { return c.Server }},
+				{Header: "Insecure", Value: func(c config.Context) string { return strconv.FormatBool(c.Insecure) }},
+				{Header: "User", Value: func(c config.Context) string { return c.User }},
+				{Header: "Key file", Value: func(c config.Context) string { return c.KeyFile }},
+				{Header: "Cluster", Value: func(c config.Context) string { return c.Cluster }},
+			})
+			return nil
+		},
+	}
+	utils.AddOutputFlags(cmd)
+	return cmd
+}
@@ -0,0 +1,34 @@
+package contextcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewRmContextCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rm NAME",
+		Aliases: []string{"remove"},
+		Short:   "Remove a context",
+		Long:    `Remove a context. Removing the current context leaves no context selected.`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uc, err := config.LoadUserConfig()
+			if err != nil {
+				return err
+			}
+			if err := uc.RemoveContext(args[0]); err != nil {
+				return err
+			}
+			if err := config.WriteUserConfig(uc); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+			fmt.Printf("%v %s\n", messageUtils.SuccessMsg("Removed context"), messageUtils.Bold(args[0]))
+			return nil
+		},
+	}
+	return cmd
+}
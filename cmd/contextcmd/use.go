@@ -0,0 +1,54 @@
+package contextcmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/fuzzy"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewUseContextCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use [NAME]",
+		Short: "Set the current context",
+		Long:  `Set the context used when no --context is given. Without a name the context is selected interactively.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uc, err := config.LoadUserConfig()
+			if err != nil {
+				return err
+			}
+			if len(uc.Contexts) == 0 {
+				return fmt.Errorf("no contexts configured, use context add first")
+			}
+
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+			} else {
+				names := make([]string, 0, len(uc.Contexts))
+				for _, ctx := range uc.Contexts {
+					names = append(names, ctx.Name)
+				}
+				selected := fuzzy.NewFuzzyFinderWithTitle(names, false, "Select the context to use")
+				if len(selected) == 0 {
+					return fmt.Errorf("no context selected")
+				}
+				name = selected[0]
+			}
+			if _, ok := uc.Context(name); !ok {
+				return fmt.Errorf("%w %q, see context ls", config.ErrUnknownContext, name)
+			}
+
+			uc.CurrentContext = name
+			if err := config.WriteUserConfig(uc); err != nil {
+				return fmt.Errorf("failed to write config: %w", err)
+			}
+			fmt.Printf("%v %s\n", messageUtils.SuccessMsg("Switched context to"), messageUtils.Bold(name))
+			return nil
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/cmd/devcmd"
+)
+
+func NewDevCmdGroup() *cobra.Command {
+	devCmd := &cobra.Command{
+		Use:    "dev",
+		Short:  "development helpers",
+		Long:   `Helpers for developing and rehearsing gns3util workflows without a real GNS3 server.`,
+		Hidden: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// Server is not needed for dev commands
+			return validateGlobalFlags()
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_ = cmd.Help()
+			return nil
+		},
+	}
+	devCmd.AddCommand(devcmd.NewFakeServerCmd(), devcmd.NewFakeSMTPCmd())
+	return devCmd
+}
@@ -0,0 +1,70 @@
+package devcmd
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/api/fake"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewFakeServerCmd() *cobra.Command {
+	var (
+		host     string
+		port     int
+		username string
+		password string
+		users    []string
+		tokenTTL time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "fake-server",
+		Short: "Run an in-memory GNS3v3 controller",
+		Long: `Run an in-memory GNS3v3 controller for rehearsing workflows like class and
+exercise creation without touching a real server. All state is lost when
+the server stops.`,
+		Example: `  gns3util dev fake-server --port 3080
+  gns3util -s http://127.0.0.1:3080 auth login -u admin -p admin
+  gns3util -s http://127.0.0.1:3080 class create --file class.json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := []fake.Option{fake.WithAdmin(username, password), fake.WithTokenTTL(tokenTTL)}
+			for _, u := range users {
+				name, pw, ok := strings.Cut(u, ":")
+				if !ok {
+					return fmt.Errorf("invalid --seed-user %q, expected username:password", u)
+				}
+				opts = append(opts, fake.WithUser(name, pw))
+			}
+
+			ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+			if err != nil {
+				return fmt.Errorf("failed to listen: %w", err)
+			}
+			srv := fake.NewUnstartedServer(opts...)
+			_ = srv.Listener.Close()
+			srv.Listener = ln
+			srv.Start()
+			defer srv.Close()
+
+			fmt.Printf("%v %v\n", messageUtils.SuccessMsg("Fake GNS3 controller listening on"), messageUtils.Highlight(srv.URL))
+			fmt.Printf("%v %v / %v\n", messageUtils.InfoMsg("Superadmin credentials:"), messageUtils.Bold(username), messageUtils.Bold(password))
+			fmt.Printf("%v gns3util -s %s auth login -u %s -p %s\n", messageUtils.InfoMsg("Log in with:"), srv.URL, username, password)
+			fmt.Println(messageUtils.InfoMsg("Press Ctrl+C to stop"))
+
+			<-cmd.Context().Done()
+			fmt.Println(messageUtils.InfoMsg("Shutting down fake controller"))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&host, "host", "127.0.0.1", "Address to listen on")
+	cmd.Flags().IntVarP(&port, "port", "p", 3080, "Port to listen on (0 picks a free port)")
+	cmd.Flags().StringVar(&username, "user", fake.DefaultUsername, "Username of the seeded superadmin")
+	cmd.Flags().StringVar(&password, "password", fake.DefaultPassword, "Password of the seeded superadmin")
+	cmd.Flags().StringSliceVar(&users, "seed-user", nil, "Additional regular users as username:password (repeatable)")
+	cmd.Flags().DurationVar(&tokenTTL, "token-ttl", fake.DefaultTokenTTL, "How long issued access tokens stay valid, use a short value to rehearse expired sessions")
+	return cmd
+}
@@ -0,0 +1,80 @@
+package devcmd
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/mailer"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewFakeSMTPCmd() *cobra.Command {
+	var (
+		host string
+		port int
+		dir  string
+	)
+	cmd := &cobra.Command{
+		Use:   "fake-smtp",
+		Short: "Run an SMTP server that catches all mail",
+		Long: `Run an SMTP server without TLS that accepts every message and prints who it
+was sent to, for rehearsing class notify without sending real mail. With --dir
+every message is also written to an .eml file.`,
+		Example: `  gns3util dev fake-smtp --port 1025 --dir ./mails
+  gns3util class notify --class CS101 --smtp-host 127.0.0.1 --smtp-port 1025 --smtp-tls none --from lab@example.com`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if dir != "" {
+				if err := os.MkdirAll(dir, 0o750); err != nil {
+					return fmt.Errorf("failed to create %s: %w", dir, err)
+				}
+			}
+			ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+			if err != nil {
+				return fmt.Errorf("failed to listen: %w", err)
+			}
+
+			var count atomic.Int64
+			srv := mailer.NewFakeServer(ln, func(env mailer.Envelope) {
+				n := count.Add(1)
+				subject := ""
+				if msg, err := mail.ReadMessage(bytes.NewReader(env.Data)); err == nil {
+					subject, _ = new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+				}
+				fmt.Printf("%v %s -> %s: %s\n", messageUtils.InfoMsg("Mail"), env.From, strings.Join(env.To, ", "), subject)
+				if dir == "" {
+					return
+				}
+				name := filepath.Join(dir, fmt.Sprintf("%s-%03d.eml", time.Now().Format("20060102-150405"), n))
+				if err := os.WriteFile(name, env.Data, 0o600); err != nil {
+					fmt.Printf("%v failed to write %s: %v\n", messageUtils.WarningMsg("Warning"), name, err)
+				}
+			})
+			go func() {
+				<-cmd.Context().Done()
+				_ = srv.Close()
+			}()
+
+			fmt.Printf("%v %v\n", messageUtils.SuccessMsg("Fake SMTP server listening on"), messageUtils.Highlight(ln.Addr().String()))
+			fmt.Println(messageUtils.InfoMsg("Press Ctrl+C to stop"))
+			if err := srv.Serve(); err != nil {
+				return err
+			}
+			fmt.Println(messageUtils.InfoMsg("Shutting down fake SMTP server"))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&host, "host", "127.0.0.1", "Address to listen on")
+	cmd.Flags().IntVarP(&port, "port", "p", 1025, "Port to listen on (0 picks a free port)")
+	cmd.Flags().StringVar(&dir, "dir", "", "Write every message to an .eml file in this directory")
+	return cmd
+}
@@ -0,0 +1,173 @@
+package exercise
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	clusterutils "github.com/stefanistkuhl/gns3util/pkg/utils/clusterUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewExerciseCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Grade the projects of an exercise with a rules file",
+		Long: `Evaluate the checks of a rules file against the project of every group of an
+exercise on all nodes of the cluster and print a pass/fail matrix with a score per
+group. Closed projects are opened for the check and closed again.
+
+The rules file is YAML or JSON. Every check has a name, optional points (default 1)
+and one assertion. Link endpoints are "node" or "node:interface", the interface by
+its name or short name as shown in the GUI:
+
+  checks:
+    - name: Router present
+      node: R1
+    - name: Two switches
+      template: Ethernet switch
+      count: 2
+    - name: Uplink
+      link: ["R1:Ethernet0", "SW1:e0"]
+      points: 2
+    - name: Lab is running
+      running: ["*"]
+    - name: Loss on the uplink
+      filter:
+        link: ["R1:Ethernet0", "SW1:e0"]
+        type: packet_loss
+    - name: Subnet labelled
+      drawing: 10.0.0.0/24`,
+		Example: `
+  # Grade Lab1 of a class
+  gns3util -s https://controller:3080 exercise check --class CS101 --exercise Lab1 --rules checks.yaml
+
+  # Export the matrix for the gradebook
+  gns3util exercise check --cluster lab --class CS101 --exercise Lab1 --rules checks.yaml -o csv --out lab1.csv
+
+  # Full results with the reason of every failed check
+  gns3util -s https://controller:3080 exercise check --exercise Lab1 --rules checks.yaml -o json
+		`,
+		RunE: runExerciseCheck,
+	}
+	cmd.Flags().String("exercise", "", "Name of the exercise")
+	cmd.Flags().String("rules", "", "YAML or JSON file with the checks")
+	cmd.Flags().String("class", "", "Only the projects of this class")
+	cmd.Flags().String("group", "", "Only the project of this group")
+	utils.AddFileOutputFlags(cmd, class.CheckFormats, class.CheckTable, "")
+	cmd.Flags().StringP("cluster", "c", "", "Cluster name")
+	_ = cmd.MarkFlagRequired("exercise")
+	_ = cmd.MarkFlagRequired("rules")
+	return cmd
+}
+
+func runExerciseCheck(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get global options: %w", err)
+	}
+	exerciseName, _ := cmd.Flags().GetString("exercise")
+	rulesPath, _ := cmd.Flags().GetString("rules")
+	className, _ := cmd.Flags().GetString("class")
+	groupName, _ := cmd.Flags().GetString("group")
+	clusterName, _ := cmd.Flags().GetString("cluster")
+
+	format, out, err := utils.FileOutputFlags(cmd, class.CheckFormats)
+	if err != nil {
+		return err
+	}
+	if format == class.CheckTable && out != "" {
+		return fmt.Errorf("--out needs -o %s or %s", class.CheckCSV, class.CheckJSON)
+	}
+
+	rules, err := class.LoadCheckRules(rulesPath)
+	if err != nil {
+		return err
+	}
+	clusterID, err := clusterutils.ResolveClusterID(cfg, clusterName, cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	reports, err := class.CheckExercise(cmd.Context(), cfg, class.CheckOptions{
+		ClusterID: clusterID,
+		Exercise:  exerciseName,
+		ClassName: className,
+		GroupName: groupName,
+		Rules:     rules,
+	})
+	if err != nil {
+		return err
+	}
+
+	if format == class.CheckTable {
+		printCheckMatrix(rules, reports)
+		return nil
+	}
+	if out == "" {
+		return class.WriteCheckReport(os.Stdout, format, rules, reports)
+	}
+	f, err := os.Create(out) // #nosec G304
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", out, err)
+	}
+	if err := class.WriteCheckReport(f, format, rules, reports); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", out, err)
+	}
+	fmt.Printf("%v results of %d groups to %s\n", messageUtils.SuccessMsg("Wrote"), len(reports), messageUtils.Bold(out))
+	return nil
+}
+
+func printCheckMatrix(rules class.CheckRules, reports []class.GroupReport) {
+	columns := []utils.Column[class.GroupReport]{
+		{Header: "Group", Value: func(r class.GroupReport) string { return r.Group }},
+	}
+	for i, c := range rules.Checks {
+		columns = append(columns, utils.Column[class.GroupReport]{
+			Header: c.Name,
+			Value: func(r class.GroupReport) string {
+				switch {
+				case r.Error != "":
+					return "-"
+				case r.Results[i].Passed:
+					return "pass"
+				default:
+					return "FAIL"
+				}
+			},
+		})
+	}
+	columns = append(columns, utils.Column[class.GroupReport]{
+		Header: "Score",
+		Value: func(r class.GroupReport) string {
+			return fmt.Sprintf("%g/%g", r.Score, r.MaxScore)
+		},
+	})
+	utils.PrintTable(reports, columns)
+
+	var details []string
+	for _, r := range reports {
+		if r.Error != "" {
+			details = append(details, fmt.Sprintf("  %s: %s", messageUtils.Bold(r.Group), r.Error))
+			continue
+		}
+		for _, res := range r.Results {
+			if !res.Passed {
+				details = append(details, fmt.Sprintf("  %s: %s: %s", messageUtils.Bold(r.Group), res.Check, res.Message))
+			}
+		}
+	}
+	if len(details) > 0 {
+		fmt.Printf("\n%v\n%s\n", messageUtils.WarningMsg("Failed checks"), strings.Join(details, "\n"))
+	}
+}
@@ -0,0 +1,105 @@
+package exercise
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	clusterutils "github.com/stefanistkuhl/gns3util/pkg/utils/clusterUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewExerciseCollectCmd() *cobra.Command {
+	var export sdk.ExportOptions
+	cmd := &cobra.Command{
+		Use:   "collect",
+		Short: "Export the projects of an exercise as submissions",
+		Long: `Export the project of every group of an exercise on all nodes of the cluster into
+a directory, for grading a snapshot of the work at the deadline. With --lock every
+project is locked first so the students can not change it any more.
+
+Next to the archives a manifest.json lists group, students, project, server, export
+time and SHA-256 of every submission, and SHA256SUMS can be checked with
+"sha256sum -c SHA256SUMS".`,
+		Example: `
+  # Lock and collect Lab1 of a class at the deadline
+  gns3util -s https://controller:3080 exercise collect --class CS101 --exercise Lab1 --out ./submissions --lock
+
+  # Collect from a cluster, 8 exports at a time, without compression
+  gns3util exercise collect --cluster lab --exercise Lab1 --out ./submissions --workers 8 --compression none
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExerciseCollect(cmd, export)
+		},
+	}
+	cmd.Flags().String("exercise", "", "Name of the exercise")
+	cmd.Flags().String("out", "", "Directory to write the archives and the manifest to")
+	cmd.Flags().String("class", "", "Only the projects of this class")
+	cmd.Flags().String("group", "", "Only the project of this group")
+	cmd.Flags().Bool("lock", false, "Lock every project before exporting it")
+	cmd.Flags().Int("workers", 4, "Number of projects exported at the same time")
+	cmd.Flags().BoolVar(&export.IncludeSnapshots, "include-snapshots", false, "Include snapshots in the export")
+	cmd.Flags().BoolVar(&export.IncludeImages, "include-images", false, "Include images in the export")
+	cmd.Flags().BoolVar(&export.ResetMacAddresses, "reset-mac-addresses", false, "Reset MAC addresses in the export")
+	cmd.Flags().BoolVar(&export.KeepComputeIDs, "keep-compute-ids", false, "Keep compute IDs in the export")
+	cmd.Flags().StringVar(&export.Compression, "compression", "zstd", "Compression type for the export (deflate, bz2, xz, zstd, none)")
+	cmd.Flags().IntVar(&export.CompressionLevel, "compression-level", 3, "Compression level for the export (0-9)")
+	cmd.Flags().StringP("cluster", "c", "", "Cluster name")
+	_ = cmd.MarkFlagRequired("exercise")
+	_ = cmd.MarkFlagRequired("out")
+	return cmd
+}
+
+func runExerciseCollect(cmd *cobra.Command, export sdk.ExportOptions) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get global options: %w", err)
+	}
+	exerciseName, _ := cmd.Flags().GetString("exercise")
+	out, _ := cmd.Flags().GetString("out")
+	className, _ := cmd.Flags().GetString("class")
+	groupName, _ := cmd.Flags().GetString("group")
+	lock, _ := cmd.Flags().GetBool("lock")
+	workers, _ := cmd.Flags().GetInt("workers")
+	clusterName, _ := cmd.Flags().GetString("cluster")
+
+	if workers < 1 {
+		return fmt.Errorf("--workers must be at least 1")
+	}
+
+	clusterID, err := clusterutils.ResolveClusterID(cfg, clusterName, cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	manifest, err := class.CollectExercise(cmd.Context(), cfg, class.CollectOptions{
+		ClusterID: clusterID,
+		Exercise:  exerciseName,
+		ClassName: className,
+		GroupName: groupName,
+		OutDir:    out,
+		Export:    export,
+		Lock:      lock,
+		Workers:   workers,
+	})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, s := range manifest.Submissions {
+		if s.Error != "" {
+			failed++
+		}
+	}
+	fmt.Printf("%v %d of %d projects of exercise %s to %s\n", messageUtils.InfoMsg("Collected"),
+		len(manifest.Submissions)-failed, len(manifest.Submissions), messageUtils.Bold(exerciseName), messageUtils.Bold(out))
+	if failed > 0 {
+		return fmt.Errorf("%d projects failed, see manifest.json", failed)
+	}
+	return nil
+}
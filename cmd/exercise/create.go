@@ -1,31 +1,24 @@
 package exercise
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io"
-	"mime/multipart"
-	"net/url"
 	"os"
-	"path/filepath"
 	"slices"
-	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
-	"github.com/stefanistkuhl/gns3util/pkg/api"
-	"github.com/stefanistkuhl/gns3util/pkg/api/endpoints"
 	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
-	"github.com/stefanistkuhl/gns3util/pkg/authentication"
 	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
 	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
 	"github.com/stefanistkuhl/gns3util/pkg/config"
 	"github.com/stefanistkuhl/gns3util/pkg/fuzzy"
 	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
 	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
 )
 
@@ -35,11 +28,16 @@ func NewExerciseCreateCmd() *cobra.Command {
 		Short: "Create an exercise (project) for every group in a class with ACLs",
 		Long: `Create an exercise (project) for every group in a class with ACLs to lock down access.
 
+With --cluster the project of every group is created on the node the group is
+assigned to, on all nodes at the same time. A template project is copied to the
+nodes that do not have it.
+
 This command will:
 - Use an existing template project from the server (recommended) or create empty projects for each group
 - Create resource pools for each project
 - Create ACLs to restrict access to each group's project
-- Assign the "User" role to each group for their respective projects`,
+- Assign the "User" role to each group for their respective projects
+- Take a "baseline" snapshot of each project for exercise reset`,
 		Example: `
   # Create exercise for a class
   gns3util -s https://controller:3080 exercise create --class "CS101" --exercise "Lab1"
@@ -53,6 +51,9 @@ This command will:
   # Create exercise using a specific template project by name/ID
   gns3util -s https://controller:3080 exercise create --class "CS101" --exercise "Lab1" --template "MyTemplateProject"
 
+  # Create exercise on every node of a cluster from a template project on one of them
+  gns3util exercise create --cluster lab --class "CS101" --exercise "Lab1" --template "MyTemplateProject"
+
   # Create exercise using a template file (fallback)
   gns3util -s https://controller:3080 exercise create --class "CS101" --exercise "Lab1" --template "/path/to/template.gns3project"
 		`,
@@ -61,38 +62,22 @@ This command will:
 
 	createExerciseCmd.Flags().String("class", "", "Class name to create exercise for")
 	createExerciseCmd.Flags().String("exercise", "", "Exercise name")
-	createExerciseCmd.Flags().String("format", "{{class}}-{{exercise}}-{{group}}-{{uuid}}", "Project name format (supports {{class}}, {{exercise}}, {{group}}, {{uuid}})")
+	createExerciseCmd.Flags().String("format", class.DefaultNameFormat, "Project name format (supports {{class}}, {{exercise}}, {{group}}, {{uuid}})")
 	createExerciseCmd.Flags().String("template", "", "Existing project name/ID or path to template file (.gns3project) to use as base for all exercise projects")
 	createExerciseCmd.Flags().Bool("select-template", false, "Interactively select a template project from existing projects on the server (recommended)")
 	createExerciseCmd.Flags().Bool("confirm", true, "Confirm before creating projects")
 	createExerciseCmd.Flags().Bool("delete-template-project", false, "Delete the template when using a project as a template")
-	createExerciseCmd.Flags().StringP("cluster", "c", "", "Cluster name (note: create is server-scoped; use -s)")
+	createExerciseCmd.Flags().StringP("cluster", "c", "", "Cluster name (default the single node cluster of -s)")
 
 	_ = createExerciseCmd.MarkFlagRequired("class")
 
 	return createExerciseCmd
 }
 
-func selectAndReplicateTemplateAcrossCluster(cfg config.GlobalOptions, clusterID int) (map[string]string, error) {
-	store, err := db.Init()
-	if err != nil {
-		return nil, fmt.Errorf("init db: %w", err)
-	}
-
-	nodes, err := store.GetNodes(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("get nodes: %w", err)
-	}
-
+// clusterProjects returns the projects of every node by name with their IDs.
+func clusterProjects(cfg config.GlobalOptions, nodeURLs []string) (map[string]map[string]string, error) {
 	perNode := make(map[string]map[string]string)
-	nameSet := make(map[string]struct{})
-
-	for _, node := range nodes {
-		if int(node.ClusterID) != clusterID {
-			continue
-		}
-
-		nodeURL := fmt.Sprintf("%s://%s:%d", node.Protocol, node.Host, node.Port)
+	for _, nodeURL := range nodeURLs {
 		cfgServer := cfg
 		cfgServer.Server = nodeURL
 
@@ -112,11 +97,24 @@ func selectAndReplicateTemplateAcrossCluster(cfg config.GlobalOptions, clusterID
 		for i := range projects {
 			p := &projects[i]
 			nodeProjects[p.Name] = p.ProjectID
-			nameSet[p.Name] = struct{}{}
 		}
 		perNode[nodeURL] = nodeProjects
 	}
+	return perNode, nil
+}
+
+func selectAndReplicateTemplateAcrossCluster(cfg config.GlobalOptions, nodeURLs []string) (map[string]string, error) {
+	perNode, err := clusterProjects(cfg, nodeURLs)
+	if err != nil {
+		return nil, err
+	}
 
+	nameSet := make(map[string]struct{})
+	for _, pm := range perNode {
+		for name := range pm {
+			nameSet[name] = struct{}{}
+		}
+	}
 	if len(nameSet) == 0 {
 		return nil, fmt.Errorf("no projects found on any node in cluster")
 	}
@@ -127,16 +125,33 @@ func selectAndReplicateTemplateAcrossCluster(cfg config.GlobalOptions, clusterID
 	}
 	slices.Sort(names)
 
-	if len(names) == 0 {
-		return nil, fmt.Errorf("no template projects available")
-	}
-
 	selected := fuzzy.NewFuzzyFinder(names, false)
 	if len(selected) == 0 {
 		return nil, fmt.Errorf("no items selected")
 	}
-	selName := selected[0]
+	return replicateTemplate(cfg, perNode, selected[0])
+}
 
+// replicateTemplateAcrossCluster finds the template project by name or ID
+// on any node and copies it to the nodes that lack it.
+func replicateTemplateAcrossCluster(cfg config.GlobalOptions, nodeURLs []string, projectRef string) (map[string]string, error) {
+	perNode, err := clusterProjects(cfg, nodeURLs)
+	if err != nil {
+		return nil, err
+	}
+	for _, nodeURL := range nodeURLs {
+		for name, id := range perNode[nodeURL] {
+			if name == projectRef || id == projectRef {
+				return replicateTemplate(cfg, perNode, name)
+			}
+		}
+	}
+	return nil, fmt.Errorf("template project not found on any node: %s", projectRef)
+}
+
+// replicateTemplate returns the ID of the project named selName on every
+// node, importing an export of it on the nodes that do not have it.
+func replicateTemplate(cfg config.GlobalOptions, perNode map[string]map[string]string, selName string) (map[string]string, error) {
 	var srcURL, srcProjID string
 	for nodeURL, pm := range perNode {
 		if id, ok := pm[selName]; ok {
@@ -150,13 +165,7 @@ func selectAndReplicateTemplateAcrossCluster(cfg config.GlobalOptions, clusterID
 		return nil, fmt.Errorf("selected template not found on any node")
 	}
 
-	srcCfg := cfg
-	srcCfg.Server = srcURL
-	exportData, err := exportProjectArchive(srcCfg, srcProjID)
-	if err != nil {
-		return nil, fmt.Errorf("export from %s: %w", srcURL, err)
-	}
-
+	var exportData []byte
 	result := make(map[string]string)
 	for nodeURL, pm := range perNode {
 		tgtCfg := cfg
@@ -165,6 +174,17 @@ func selectAndReplicateTemplateAcrossCluster(cfg config.GlobalOptions, clusterID
 			result[nodeURL] = id
 			continue
 		}
+		if exportData == nil {
+			srcCfg := cfg
+			srcCfg.Server = srcURL
+			// the template is exported as it is, it may be open on its node
+			// for someone else and the controller exports open projects as well
+			data, err := exportProjectArchive(srcCfg, srcProjID)
+			if err != nil {
+				return nil, fmt.Errorf("export from %s: %w", srcURL, err)
+			}
+			exportData = data
+		}
 		newID, err := importProjectArchive(tgtCfg, exportData, selName)
 		if err != nil {
 			return nil, fmt.Errorf("import to %s failed: %w", nodeURL, err)
@@ -187,41 +207,29 @@ func exportProjectArchive(cfg config.GlobalOptions, projectID string) ([]byte, e
 	return body, nil
 }
 
-func importProjectArchive(cfg config.GlobalOptions, archive []byte, projectName string) (string, error) {
-	token, err := authentication.GetKeyForServer(cfg)
-	if err != nil {
-		return "", fmt.Errorf("get token: %w", err)
-	}
-	settings := api.NewSettings(api.WithBaseURL(cfg.Server), api.WithVerify(cfg.Insecure), api.WithToken(token))
-	client := api.NewGNS3Client(settings)
-
-	ep := endpoints.Endpoints{}
-	newID := uuid.New().String()
-	urlStr := ep.Post.ProjectImport(newID) + fmt.Sprintf("?name=%s", url.QueryEscape(projectName))
-
-	var buf bytes.Buffer
-	w := multipart.NewWriter(&buf)
-	fw, err := w.CreateFormFile("file", fmt.Sprintf("%s.gns3project", projectName))
+// createBaselineSnapshot snapshots a new exercise project for exercise reset.
+func createBaselineSnapshot(cfg config.GlobalOptions, projectID string) (string, error) {
+	client, err := utils.NewClient(cfg)
 	if err != nil {
 		return "", err
 	}
-	if _, err = fw.Write(archive); err != nil {
+	snapshot, err := client.Snapshots(projectID).Create(cfg.Context(), class.BaselineSnapshot)
+	if err != nil {
 		return "", err
 	}
-	_ = w.Close()
+	return snapshot.SnapshotID, nil
+}
 
-	req := api.NewRequestOptions(settings).WithURL(urlStr).WithMethod(api.POST).WithData(buf.String())
-	_, resp, err := client.Do(req)
+func importProjectArchive(cfg config.GlobalOptions, archive []byte, projectName string) (string, error) {
+	client, err := utils.NewClient(cfg)
 	if err != nil {
 		return "", err
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-	if resp.StatusCode != 201 {
-		return "", fmt.Errorf("import status %d", resp.StatusCode)
+	project, err := client.Projects().Import(cfg.Context(), uuid.New().String(), projectName, archive)
+	if err != nil {
+		return "", fmt.Errorf("import project: %w", err)
 	}
-	return newID, nil
+	return project.ProjectID, nil
 }
 
 func runCreateExercise(cmd *cobra.Command, args []string) error {
@@ -243,13 +251,22 @@ func runCreateExercise(cmd *cobra.Command, args []string) error {
 		exerciseName = args[0]
 	}
 
-	ctx := context.Background()
+	ctx := cmd.Context()
+
+	// class create records a server without a cluster as an implicit single node cluster
+	if clusterName == "" && cfg.Server != "" {
+		urlObj := utils.ValidateUrlWithReturn(cfg.Server)
+		clusterName = fmt.Sprintf("%s%s", urlObj.Hostname(), "_single_node_cluster")
+	}
 
 	if clusterName != "" {
 		store, err := db.Init()
 		if err != nil {
 			return fmt.Errorf("failed to init db: %w", err)
 		}
+		defer func() {
+			_ = store.DB.Close()
+		}()
 
 		clusters, err := store.GetClusters(ctx)
 		if err != nil {
@@ -291,32 +308,34 @@ func runCreateExercise(cmd *cobra.Command, args []string) error {
 			return fmt.Errorf("failed to get node groups: %w", err)
 		}
 
+		// groups are created on the node they are assigned to
 		var plans []db.NodeGroupsForClass
-		nodeMap := make(map[string]*db.NodeGroupsForClass)
-		groupMap := make(map[string]*db.GroupData)
+		nodeIdx := make(map[string]int)
+		groupIdx := make(map[string]int)
 
 		for _, dat := range data {
 			nodeURL := fmt.Sprintf("%v", dat.NodeUrl)
 
-			node, ok := nodeMap[nodeURL]
+			ni, ok := nodeIdx[nodeURL]
 			if !ok {
 				plans = append(plans, db.NodeGroupsForClass{
 					NodeURL: nodeURL,
 					Groups:  []db.GroupData{},
 				})
-				node = &plans[len(plans)-1]
-				nodeMap[nodeURL] = node
+				ni = len(plans) - 1
+				nodeIdx[nodeURL] = ni
 			}
+			node := &plans[ni]
 
 			groupKey := nodeURL + dat.GroupName
-			group, ok := groupMap[groupKey]
+			gi, ok := groupIdx[groupKey]
 			if !ok {
 				node.Groups = append(node.Groups, db.GroupData{
 					Name:     dat.GroupName,
 					Students: []db.UserData{},
 				})
-				group = &node.Groups[len(node.Groups)-1]
-				groupMap[groupKey] = group
+				gi = len(node.Groups) - 1
+				groupIdx[groupKey] = gi
 			}
 
 			fullName := ""
@@ -324,7 +343,7 @@ func runCreateExercise(cmd *cobra.Command, args []string) error {
 				fullName = dat.FullName.String
 			}
 
-			group.Students = append(group.Students, db.UserData{
+			node.Groups[gi].Students = append(node.Groups[gi].Students, db.UserData{
 				Username:  dat.Username,
 				FullName:  fullName,
 				Password:  dat.DefaultPassword,
@@ -332,71 +351,62 @@ func runCreateExercise(cmd *cobra.Command, args []string) error {
 			})
 		}
 
+		nodeURLs := make([]string, 0, len(plans))
+		totalGroups := 0
+		for i := range plans {
+			nodeURLs = append(nodeURLs, plans[i].NodeURL)
+			totalGroups += len(plans[i].Groups)
+		}
+
 		var templateIDByNode map[string]string
-		if selectTemplate {
-			templateIDByNode, err = selectAndReplicateTemplateAcrossCluster(cfg, clusterID)
+		switch {
+		case selectTemplate:
+			templateIDByNode, err = selectAndReplicateTemplateAcrossCluster(cfg, nodeURLs)
 			if err != nil {
 				return fmt.Errorf("template selection/replication failed: %w", err)
 			}
 			if templateIDByNode == nil {
 				return nil
 			}
-		}
-
-		totalCreated := 0
-		for _, plan := range plans {
-			cfgServer := cfg
-			cfgServer.Server = plan.NodeURL
-
-			groupsBody, status, err := utils.CallClient(cfgServer, "getGroups", []string{}, nil)
-			if err != nil {
-				return fmt.Errorf("[%s] getGroups: %w", plan.NodeURL, err)
-			}
-			if status != 200 {
-				return fmt.Errorf("[%s] getGroups status: %d", plan.NodeURL, status)
-			}
-			var groups []schemas.UserGroupResponse
-			if unmarshalErr := json.Unmarshal(groupsBody, &groups); unmarshalErr != nil {
-				return fmt.Errorf("[%s] parse groups: %w", plan.NodeURL, unmarshalErr)
-			}
-			want := make(map[string]bool)
-			for _, g := range plan.Groups {
-				want[g.Name] = true
-			}
-			var classGroups []schemas.UserGroupResponse
-			for _, g := range groups {
-				if want[g.Name] {
-					classGroups = append(classGroups, g)
+		case templatePath != "":
+			// template files are imported on every node by createForGroupsOnServer
+			if _, statErr := os.Stat(templatePath); statErr != nil {
+				templateIDByNode, err = replicateTemplateAcrossCluster(cfg, nodeURLs, templatePath)
+				if err != nil {
+					return fmt.Errorf("template replication failed: %w", err)
 				}
 			}
+		}
 
-			if len(classGroups) == 0 {
-				continue
+		if confirm && totalGroups > 0 {
+			if !utils.ConfirmPrompt(fmt.Sprintf("Create exercise '%s' for %d groups on %d nodes?", exerciseName, totalGroups, len(plans)), false) {
+				fmt.Println("Exercise creation cancelled.")
+				return nil
 			}
+		}
 
-			if confirm && totalCreated == 0 {
-				var totalGroups int
-				for i := range plans {
-					totalGroups += len(plans[i].Groups)
-				}
-				if !utils.ConfirmPrompt(fmt.Sprintf("Create exercise '%s' for %d groups?", exerciseName, totalGroups), false) {
-					fmt.Println("Exercise creation cancelled.")
-					return nil
-				}
-			}
+		created := make([]int, len(plans))
+		errs := make([]error, len(plans))
+		var wg sync.WaitGroup
+		for i := range plans {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				created[i], errs[i] = createForNode(ctx, store, cfg, clusterID, className, exerciseName, format, templatePath,
+					deleteTemplate, plans[i], templateIDByNode[plans[i].NodeURL])
+			}()
+		}
+		wg.Wait()
 
-			var preselectedTemplate string
-			if selectTemplate {
-				preselectedTemplate = templateIDByNode[plan.NodeURL]
-			}
-			_, created, err := createForGroupsOnServer(
-				cfgServer, className, exerciseName, format, templatePath,
-				selectTemplate, deleteTemplate, classGroups, preselectedTemplate,
-			)
+		totalCreated := 0
+		failed := 0
+		for i, err := range errs {
+			totalCreated += created[i]
 			if err != nil {
-				return err
+				failed++
+				fmt.Printf("%v Failed to create exercise on %s: %v\n",
+					messageUtils.ErrorMsg("Failed to create exercise"), messageUtils.Highlight(plans[i].NodeURL), err)
 			}
-			totalCreated += created
 		}
 
 		fmt.Printf("\n%v Created %d projects for exercise '%s' across cluster %s\n",
@@ -405,13 +415,58 @@ func runCreateExercise(cmd *cobra.Command, args []string) error {
 			messageUtils.Bold(exerciseName),
 			messageUtils.Bold(clusterName),
 		)
+		if failed > 0 {
+			return fmt.Errorf("%d of %d nodes failed", failed, len(plans))
+		}
 		return nil
 	}
 
 	return nil
 }
 
-func createForGroupsOnServer(cfg config.GlobalOptions, className, exerciseName, format, templatePath string, selectTemplate, deleteTemplate bool, classGroups []schemas.UserGroupResponse, preselectedTemplateID string) (templateID string, createdCount int, err error) {
+// createForNode creates the projects of the groups of a class assigned to
+// one node.
+func createForNode(ctx context.Context, store *db.Store, cfg config.GlobalOptions, clusterID int, className, exerciseName, format, templatePath string, deleteTemplate bool, plan db.NodeGroupsForClass, templateID string) (int, error) {
+	cfgServer := cfg
+	cfgServer.Server = plan.NodeURL
+
+	groupsBody, status, err := utils.CallClient(cfgServer, "getGroups", []string{}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("[%s] getGroups: %w", plan.NodeURL, err)
+	}
+	if status != 200 {
+		return 0, fmt.Errorf("[%s] getGroups status: %d", plan.NodeURL, status)
+	}
+	var groups []schemas.UserGroupResponse
+	if unmarshalErr := json.Unmarshal(groupsBody, &groups); unmarshalErr != nil {
+		return 0, fmt.Errorf("[%s] parse groups: %w", plan.NodeURL, unmarshalErr)
+	}
+	want := make(map[string]bool)
+	for _, g := range plan.Groups {
+		want[g.Name] = true
+	}
+	var classGroups []schemas.UserGroupResponse
+	for _, g := range groups {
+		if want[g.Name] {
+			classGroups = append(classGroups, g)
+		}
+	}
+
+	if len(classGroups) == 0 {
+		return 0, nil
+	}
+
+	_, created, err := createForGroupsOnServer(
+		ctx, store, cfgServer, clusterID, className, exerciseName, format, templatePath,
+		false, deleteTemplate, classGroups, templateID,
+	)
+	return created, err
+}
+
+// createForGroupsOnServer creates the projects of classGroups on the server
+// of cfg and records them in store, which the nodes created at the same
+// time share.
+func createForGroupsOnServer(ctx context.Context, store *db.Store, cfg config.GlobalOptions, clusterID int, className, exerciseName, format, templatePath string, selectTemplate, deleteTemplate bool, classGroups []schemas.UserGroupResponse, preselectedTemplateID string) (templateID string, createdCount int, err error) {
 	fmt.Printf("%v Found %d groups for class %v on %s\n",
 		messageUtils.InfoMsg("Found groups for class"),
 		len(classGroups),
@@ -480,28 +535,7 @@ func createForGroupsOnServer(cfg config.GlobalOptions, className, exerciseName,
 		fmt.Printf("%v Skipping groups that already have exercises\n", messageUtils.InfoMsg("Skipping groups that already have exercises"))
 	}
 
-	store, err := db.Init()
-	if err != nil {
-		return templateProjectID, 0, fmt.Errorf("failed to init db: %w", err)
-	}
-	ctx := context.Background()
-	tx, err := store.DB.BeginTx(ctx, nil)
-	if err != nil {
-		return templateProjectID, 0, fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer func() {
-		if err != nil {
-			rollbackErr := tx.Rollback()
-			if rollbackErr != nil {
-				fmt.Printf("failed to rollback transaction: %v", rollbackErr)
-			}
-		}
-		_ = tx.Commit()
-	}()
-
-	qtx := store.WithTx(tx)
-
-	successCount := 0
+	var records []sqlc.InsertExerciseRecordParams
 	for _, group := range classGroups {
 		groupName := group.Name
 		groupID := group.UserGroupID.String()
@@ -509,8 +543,15 @@ func createForGroupsOnServer(cfg config.GlobalOptions, className, exerciseName,
 			continue
 		}
 
-		groupNumber := extractGroupNumber(groupName, className)
-		projectName := generateProjectName(format, className, exerciseName, groupNumber)
+		dbGroupID, groupErr := store.GetGroupIDForClass(ctx, sqlc.GetGroupIDForClassParams{ClusterID: int64(clusterID), Name: className, Name_2: groupName})
+		if groupErr != nil {
+			fmt.Printf("%v Failed to get group %s from db: %v\n",
+				messageUtils.ErrorMsg("Failed to get group"), messageUtils.Bold(groupName), groupErr)
+			continue
+		}
+
+		groupNumber := class.GroupPart(groupName, className)
+		projectName, shortID := generateProjectName(format, className, exerciseName, groupNumber)
 
 		var projectID string
 		if len(exportData) > 0 {
@@ -541,6 +582,11 @@ func createForGroupsOnServer(cfg config.GlobalOptions, className, exerciseName,
 			}
 			projectID = pr.ProjectID
 		}
+		baselineID, snapErr := createBaselineSnapshot(cfg, projectID)
+		if snapErr != nil {
+			fmt.Printf("%v Failed to create the baseline snapshot of %s, exercise reset will use the template: %v\n",
+				messageUtils.WarningMsg("Failed to create baseline snapshot"), messageUtils.Bold(projectName), snapErr)
+		}
 		if _, status, err := utils.CallClient(cfg, "closeProject", []string{projectID}, nil); err != nil || (status != 200 && status != 204) {
 			if err == nil {
 				err = fmt.Errorf("status %d", status)
@@ -598,38 +644,23 @@ func createForGroupsOnServer(cfg config.GlobalOptions, className, exerciseName,
 			continue
 		}
 
-		if store != nil {
-			if nodes, nerr := qtx.GetNodes(ctx); nerr == nil {
-				var clusterID int
-				for _, n := range nodes {
-					nodeURL := fmt.Sprintf("%s://%s:%d", n.Protocol, n.Host, n.Port)
-					if nodeURL == cfg.Server {
-						clusterID = int(n.ClusterID)
-						break
-					}
-				}
-				if clusterID != 0 {
-					groupIDint, convertErr := strconv.Atoi(groupName)
-					if convertErr != nil {
-						return templateProjectID, 0, fmt.Errorf("failed to convert group name to int: %w", convertErr)
-					}
-					insertErr := qtx.InsertExerciseRecord(ctx, sqlc.InsertExerciseRecordParams{ProjectUuid: projectID, GroupID: int64(groupIDint), Name: exerciseName, State: sql.NullString{String: "created", Valid: true}})
-					if insertErr != nil {
-						rollbackErr := tx.Rollback()
-						if rollbackErr != nil {
-							fmt.Printf("failed to rollback transaction: %v", rollbackErr)
-						}
-						return templateProjectID, 0, fmt.Errorf("failed to insert exercise record: %w", insertErr)
-					}
-				}
-			}
+		// exercises are found by their project name in the stored name format,
+		// exercise reset duplicates the template if the baseline is gone and
+		// exercise deploy creates the projects of late groups from it
+		var templateRef sql.NullString
+		if templateProjectID != "" {
+			templateRef = sql.NullString{String: templateProjectID, Valid: true}
 		}
+		records = append(records, sqlc.InsertExerciseRecordParams{
+			ProjectUuid:        shortID,
+			GroupID:            dbGroupID,
+			Name:               exerciseName,
+			State:              sql.NullString{String: "created", Valid: true},
+			BaselineSnapshotID: sql.NullString{String: baselineID, Valid: baselineID != ""},
+			TemplateProjectID:  templateRef,
+			NameFormat:         sql.NullString{String: format, Valid: true},
+		})
 
-		successCount++
-		commitErr := tx.Commit()
-		if commitErr != nil {
-			return templateProjectID, 0, fmt.Errorf("failed to commit transaction: %w", commitErr)
-		}
 		fmt.Printf("%v Created project %s for group %s on %s\n",
 			messageUtils.SuccessMsg("Created project"),
 			messageUtils.Bold(projectName),
@@ -638,6 +669,10 @@ func createForGroupsOnServer(cfg config.GlobalOptions, className, exerciseName,
 		)
 	}
 
+	if err := insertExerciseRecords(ctx, store, records); err != nil {
+		return templateProjectID, 0, err
+	}
+
 	if templateProjectID != "" && deleteTemplate {
 		if err := cleanupTemplateProject(cfg, templateProjectID, deleteTemplate); err != nil {
 			fmt.Printf("%v Failed to clean up template project on %s: %v\n",
@@ -648,27 +683,40 @@ func createForGroupsOnServer(cfg config.GlobalOptions, className, exerciseName,
 		}
 	}
 
-	return templateProjectID, successCount, nil
+	return templateProjectID, len(records), nil
 }
 
-func extractGroupNumber(groupName, className string) string {
-	prefix := className + "-"
-	if after, ok := strings.CutPrefix(groupName, prefix); ok {
-		return after
-	}
-	return groupName
-}
+// exerciseRecordsMu serializes the inserts of the nodes created at the same
+// time, SQLite has a single writer.
+var exerciseRecordsMu sync.Mutex
 
-func generateProjectName(format, className, exerciseName, groupNumber string) string {
-	uuidStr := uuid.New().String()[:8]
+// insertExerciseRecords records the created projects in one transaction.
+func insertExerciseRecords(ctx context.Context, store *db.Store, records []sqlc.InsertExerciseRecordParams) error {
+	exerciseRecordsMu.Lock()
+	defer exerciseRecordsMu.Unlock()
 
-	projectName := format
-	projectName = strings.ReplaceAll(projectName, "{{class}}", className)
-	projectName = strings.ReplaceAll(projectName, "{{exercise}}", exerciseName)
-	projectName = strings.ReplaceAll(projectName, "{{group}}", groupNumber)
-	projectName = strings.ReplaceAll(projectName, "{{uuid}}", uuidStr)
+	tx, err := store.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	qtx := store.WithTx(tx)
+	for _, r := range records {
+		if err := qtx.InsertExerciseRecord(ctx, r); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				fmt.Printf("failed to rollback transaction: %v", rollbackErr)
+			}
+			return fmt.Errorf("failed to insert exercise record: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit exercise records: %w", err)
+	}
+	return nil
+}
 
-	return projectName
+func generateProjectName(format, className, exerciseName, groupNumber string) (projectName, shortID string) {
+	shortID = uuid.New().String()[:8]
+	return class.ProjectName(format, className, exerciseName, groupNumber, shortID), shortID
 }
 
 func getUserRoleID(cfg config.GlobalOptions) (string, error) {
@@ -779,49 +827,17 @@ func selectTemplateWithFuzzy(cfg config.GlobalOptions) (string, error) {
 }
 
 func importTemplateProject(cfg config.GlobalOptions, filePath, className, exerciseName string) (string, error) {
-	file, err := os.Open(filePath) // #nosec G304
-	if err != nil {
-		return "", fmt.Errorf("failed to open template file: %w", err)
-	}
-	defer func() {
-		_ = file.Close()
-	}()
-
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
+	archive, err := os.ReadFile(filePath) // #nosec G304
 	if err != nil {
-		return "", fmt.Errorf("failed to create form file: %w", err)
-	}
-
-	if _, err = io.Copy(part, file); err != nil {
-		return "", fmt.Errorf("failed to copy file content: %w", err)
+		return "", fmt.Errorf("failed to read template file: %w", err)
 	}
 
 	projectName := fmt.Sprintf("%s-%s-template", className, exerciseName)
-	_ = writer.WriteField("name", projectName)
-
-	if err = writer.Close(); err != nil {
-		return "", fmt.Errorf("failed to close writer: %w", err)
-	}
-
-	resp, status, err := utils.CallClient(cfg, "postProject", []string{}, body)
+	projectID, err := importProjectArchive(cfg, archive, projectName)
 	if err != nil {
 		return "", fmt.Errorf("failed to import project: %w", err)
 	}
-
-	if status != 201 {
-		return "", fmt.Errorf("failed to import project: status %d", status)
-	}
-
-	respBody := resp
-
-	var project schemas.ProjectResponse
-	if err := json.Unmarshal(respBody, &project); err != nil {
-		return "", fmt.Errorf("failed to parse project response: %w", err)
-	}
-
-	return project.ProjectID, nil
+	return projectID, nil
 }
 
 func resolveTemplateProject(cfg config.GlobalOptions, projectRef string) (string, error) {
@@ -0,0 +1,261 @@
+package exercise
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	clusterutils "github.com/stefanistkuhl/gns3util/pkg/utils/clusterUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewExerciseDeployCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deploy",
+		Short: "Deploy an existing exercise to a group added later",
+		Long: `Create the project, pool and ACL of an existing exercise for a single group, for
+groups added to a class after the exercise was created with class apply or by hand.
+
+The project is created on the node the group is assigned to, with the name format
+and from the template project the exercise was created with. If the template is on
+another node it is copied over first. Use --template if the template project was
+deleted or the exercise was created without one.`,
+		Example: `
+  # Deploy Lab1 to a group that joined the class late
+  gns3util -s https://controller:3080 exercise deploy --class CS101 --exercise Lab1 --group CS101-group-7
+
+  # Deploy it from another template project on a cluster
+  gns3util exercise deploy --cluster lab --class CS101 --exercise Lab1 --group CS101-group-7 --template Lab1-template
+		`,
+		RunE: runExerciseDeploy,
+	}
+	cmd.Flags().String("class", "", "Class of the group")
+	cmd.Flags().String("exercise", "", "Name of the exercise")
+	cmd.Flags().String("group", "", "Group to deploy the exercise to")
+	cmd.Flags().String("template", "", "Project name/ID on the node of the group to use instead of the template of the exercise")
+	cmd.Flags().StringP("cluster", "c", "", "Cluster name")
+	_ = cmd.MarkFlagRequired("class")
+	_ = cmd.MarkFlagRequired("exercise")
+	_ = cmd.MarkFlagRequired("group")
+	return cmd
+}
+
+func runExerciseDeploy(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get global options: %w", err)
+	}
+	className, _ := cmd.Flags().GetString("class")
+	exerciseName, _ := cmd.Flags().GetString("exercise")
+	groupName, _ := cmd.Flags().GetString("group")
+	templateRef, _ := cmd.Flags().GetString("template")
+	clusterName, _ := cmd.Flags().GetString("cluster")
+
+	clusterID, err := clusterutils.ResolveClusterID(cfg, clusterName, cmd.Context())
+	if err != nil {
+		return err
+	}
+	store, err := db.Init()
+	if err != nil {
+		return fmt.Errorf("failed to init db: %w", err)
+	}
+	nodeURL, err := deployExercise(cmd.Context(), cfg, store, clusterID, className, exerciseName, groupName, templateRef, "")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%v exercise %s to group %s on %s\n", messageUtils.SuccessMsg("Deployed"),
+		messageUtils.Bold(exerciseName), messageUtils.Bold(groupName), messageUtils.Highlight(nodeURL))
+	return nil
+}
+
+// Redeploy returns the class.Redeployer of cluster doctor, which deploys
+// an exercise again to a group whose project is gone like exercise deploy
+// and replaces the row of the missing project.
+func Redeploy(cfg config.GlobalOptions, clusterID int) class.Redeployer {
+	return func(ctx context.Context, store *db.Store, className, groupName string, ex sqlc.Exercise) error {
+		_, err := deployExercise(ctx, cfg, store, clusterID, className, ex.Name, groupName, "", ex.ProjectUuid)
+		return err
+	}
+}
+
+// deployExercise creates the project of an exercise for one group and
+// returns the node it is on. replace is the short uuid of a row of the
+// group whose project is gone, the row is deleted once the new project is
+// recorded.
+func deployExercise(ctx context.Context, cfg config.GlobalOptions, store *db.Store, clusterID int, className, exerciseName, groupName, templateRef, replace string) (string, error) {
+	assignment, err := store.GetGroupNode(ctx, sqlc.GetGroupNodeParams{ClusterID: int64(clusterID), Name: className, Name_2: groupName})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("group %s of class %s not found or not assigned to a node", messageUtils.Bold(groupName), messageUtils.Bold(className))
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get the node of group %s: %w", groupName, err)
+	}
+	nodeCfg := cfg
+	nodeCfg.Server = fmt.Sprintf("%v", assignment.NodeUrl)
+
+	rows, err := store.GetExerciseProjects(ctx, sqlc.GetExerciseProjectsParams{
+		ClusterID: int64(clusterID),
+		Name:      exerciseName,
+		Column3:   className,
+		Name_2:    className,
+		Column5:   "",
+		Name_3:    "",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get exercise %s: %w", exerciseName, err)
+	}
+	if len(rows) == 0 {
+		return "", fmt.Errorf("exercise %s not found for class %s, use exercise create", messageUtils.Bold(exerciseName), messageUtils.Bold(className))
+	}
+	if slices.ContainsFunc(rows, func(r sqlc.GetExerciseProjectsRow) bool { return r.GroupName == groupName && r.ProjectUuid != replace }) {
+		return "", fmt.Errorf("group %s already has a project for exercise %s", messageUtils.Bold(groupName), messageUtils.Bold(exerciseName))
+	}
+
+	// the replaced row knows the format its project was named with
+	format := ""
+	for _, row := range rows {
+		if !row.NameFormat.Valid {
+			continue
+		}
+		if row.ProjectUuid == replace {
+			format = row.NameFormat.String
+			break
+		}
+		if format == "" {
+			format = row.NameFormat.String
+		}
+	}
+	if format == "" {
+		format = class.DefaultNameFormat
+	}
+
+	var templateID string
+	if templateRef != "" {
+		templateID, err = resolveTemplateProject(nodeCfg, templateRef)
+	} else {
+		templateID, err = exerciseTemplateOnNode(cfg, nodeCfg.Server, exerciseName, rows)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	group, err := serverGroup(nodeCfg, groupName)
+	if err != nil {
+		return "", err
+	}
+
+	_, created, err := createForGroupsOnServer(ctx, store, nodeCfg, clusterID, className, exerciseName, format, "", false, false,
+		[]schemas.UserGroupResponse{group}, templateID)
+	if err != nil {
+		return "", err
+	}
+	if created == 0 {
+		return "", fmt.Errorf("failed to deploy exercise %s to group %s", exerciseName, groupName)
+	}
+	if replace != "" {
+		if err := store.DeleteExerciseRecord(ctx, replace); err != nil {
+			return "", fmt.Errorf("failed to delete the row of the missing project %s: %w", replace, err)
+		}
+	}
+	return nodeCfg.Server, nil
+}
+
+// exerciseTemplateOnNode returns the id of the template project of an
+// exercise on nodeURL. A template only found on another node is copied to
+// nodeURL. It returns "" if the exercise was created without a template.
+func exerciseTemplateOnNode(cfg config.GlobalOptions, nodeURL, exerciseName string, rows []sqlc.GetExerciseProjectsRow) (string, error) {
+	// the template of the node itself comes first, it needs no copy
+	var ordered []sqlc.GetExerciseProjectsRow
+	for _, row := range rows {
+		if fmt.Sprintf("%v", row.NodeUrl) == nodeURL {
+			ordered = append(ordered, row)
+		}
+	}
+	for _, row := range rows {
+		if fmt.Sprintf("%v", row.NodeUrl) != nodeURL {
+			ordered = append(ordered, row)
+		}
+	}
+	recorded := false
+	tried := map[string]bool{}
+	for _, row := range ordered {
+		if !row.TemplateProjectID.Valid {
+			continue
+		}
+		recorded = true
+		srcCfg := cfg
+		srcCfg.Server = fmt.Sprintf("%v", row.NodeUrl)
+		key := srcCfg.Server + "/" + row.TemplateProjectID.String
+		if tried[key] {
+			continue
+		}
+		tried[key] = true
+
+		client, err := utils.NewClient(srcCfg)
+		if err != nil {
+			return "", err
+		}
+		template, err := client.Projects().Get(srcCfg.Context(), row.TemplateProjectID.String)
+		if err != nil {
+			continue
+		}
+		if srcCfg.Server == nodeURL {
+			return template.ProjectID, nil
+		}
+
+		// the template is exported as it is, it may be open on its node for
+		// someone else and the controller exports open projects as well
+		archive, err := exportProjectArchive(srcCfg, template.ProjectID)
+		if err != nil {
+			return "", fmt.Errorf("export template from %s: %w", srcCfg.Server, err)
+		}
+		tgtCfg := cfg
+		tgtCfg.Server = nodeURL
+		newID, err := importProjectArchive(tgtCfg, archive, template.Name)
+		if err != nil {
+			return "", fmt.Errorf("import template to %s: %w", nodeURL, err)
+		}
+		fmt.Printf("%s Imported template '%s' to %s\n", messageUtils.SuccessMsg("Imported template"),
+			messageUtils.Bold(template.Name), messageUtils.Highlight(nodeURL))
+		return newID, nil
+	}
+	if recorded {
+		return "", fmt.Errorf("the template project of exercise %s was deleted, pass another one with --template", messageUtils.Bold(exerciseName))
+	}
+	fmt.Printf("%v exercise %s was created without a template, creating an empty project\n",
+		messageUtils.WarningMsg("Warning"), messageUtils.Bold(exerciseName))
+	return "", nil
+}
+
+// serverGroup returns the user group named name on the server of cfg.
+func serverGroup(cfg config.GlobalOptions, name string) (schemas.UserGroupResponse, error) {
+	body, status, err := utils.CallClient(cfg, "getGroups", []string{}, nil)
+	if err != nil {
+		return schemas.UserGroupResponse{}, fmt.Errorf("[%s] getGroups: %w", cfg.Server, err)
+	}
+	if status != 200 {
+		return schemas.UserGroupResponse{}, fmt.Errorf("[%s] getGroups status: %d", cfg.Server, status)
+	}
+	var groups []schemas.UserGroupResponse
+	if err := json.Unmarshal(body, &groups); err != nil {
+		return schemas.UserGroupResponse{}, fmt.Errorf("[%s] parse groups: %w", cfg.Server, err)
+	}
+	for _, g := range groups {
+		if g.Name == name {
+			return g, nil
+		}
+	}
+	return schemas.UserGroupResponse{}, fmt.Errorf("group %s not found on %s, run class apply first", messageUtils.Bold(name), cfg.Server)
+}
@@ -16,6 +16,13 @@ func NewExerciseCmdGroup() *cobra.Command {
 		NewExerciseDeleteCmd(),
 		NewExerciseLsCmd(),
 		NewExerciseInfoCmd(),
+		NewExerciseStartCmd(),
+		NewExerciseStopCmd(),
+		NewExerciseCompleteCmd(),
+		NewExerciseCheckCmd(),
+		NewExerciseCollectCmd(),
+		NewExerciseResetCmd(),
+		NewExerciseDeployCmd(),
 	)
 
 	return exerciseCmd
@@ -0,0 +1,687 @@
+package exercise
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+)
+
+var groups = []string{"NET-g1", "NET-g2"}
+
+// newExercise creates class NET with two groups on a fake controller and
+// exercise lab1 for it from a template project, passing args on to
+// exercise create.
+func newExercise(t *testing.T, args ...string) *testutil.Cluster {
+	t.Helper()
+	tc := testutil.NewCluster(t)
+	classData := schemas.Class{Name: "NET", Groups: []schemas.Group{
+		{Name: "NET-g1", Students: []schemas.Student{{UserName: "alice", Password: "alice-pass1"}}},
+		{Name: "NET-g2", Students: []schemas.Student{{UserName: "bob", Password: "bob-pass1"}}},
+	}}
+	scheduler, err := class.NewScheduler(tc.Cfg, class.PlacementOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := class.CreateClass(tc.Cfg, tc.ClusterID, classData, tc.Nodes, scheduler); err != nil || !ok {
+		t.Fatalf("create class: %v", err)
+	}
+	tc.TemplateProject(t, "tmpl")
+	args = append([]string{"--class", "NET", "--exercise", "lab1", "--template", "tmpl", "--confirm=false"}, args...)
+	tc.Run(t, NewExerciseCreateCmd(), args...)
+	return tc
+}
+
+// exercise returns the only exercise row of a group and its project.
+func exercise(t *testing.T, tc *testutil.Cluster, group string) (sqlc.Exercise, schemas.ProjectResponse) {
+	t.Helper()
+	rows := tc.Exercises(t, "NET", group)
+	if len(rows) != 1 {
+		t.Fatalf("exercises of %s: %+v, want one", group, rows)
+	}
+	for name, p := range tc.Projects(t) {
+		if strings.HasSuffix(name, rows[0].ProjectUuid) {
+			return rows[0], p
+		}
+	}
+	t.Fatalf("no project for exercise %+v of %s", rows[0], group)
+	return sqlc.Exercise{}, schemas.ProjectResponse{}
+}
+
+func nodeStatus(t *testing.T, tc *testutil.Cluster, p schemas.ProjectResponse) []string {
+	t.Helper()
+	nodes, err := tc.Client.Nodes(p.ProjectID).List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status []string
+	for _, n := range nodes {
+		status = append(status, n.Status)
+	}
+	return status
+}
+
+func TestExerciseCreateAndDelete(t *testing.T) {
+	tc := newExercise(t)
+
+	var want []string
+	for _, group := range groups {
+		ex, p := exercise(t, tc, group)
+		if ex.Name != "lab1" || ex.State.String != class.ExerciseCreated {
+			t.Errorf("exercise of %s: %+v", group, ex)
+		}
+		// the group part of the name drops the class prefix
+		if want := "NET-lab1-" + strings.TrimPrefix(group, "NET-") + "-" + ex.ProjectUuid; p.Name != want {
+			t.Errorf("project of %s %q, want %q", group, p.Name, want)
+		}
+		if got := nodeStatus(t, tc, p); len(got) != 1 {
+			t.Errorf("project %s has nodes %v, want the one of the template", p.Name, got)
+		}
+		want = append(want, p.Name)
+	}
+	var got []string
+	for name := range tc.Projects(t) {
+		if name != "tmpl" {
+			got = append(got, name)
+		}
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Fatalf("projects %v, want %v", got, want)
+	}
+
+	tc.Run(t, NewExerciseDeleteCmd(), "--name", "lab1", "--class", "NET", "--no-confirm")
+	for _, group := range groups {
+		if rows := tc.Exercises(t, "NET", group); len(rows) != 0 {
+			t.Errorf("exercises of %s left after delete: %+v", group, rows)
+		}
+	}
+	if got := tc.Projects(t); len(got) != 1 {
+		t.Errorf("projects left after delete: %v", got)
+	}
+}
+
+func TestExerciseLifecycle(t *testing.T) {
+	tests := []struct {
+		cmd    func() *cobra.Command
+		state  string
+		status string
+		nodes  string
+	}{
+		{NewExerciseStartCmd, class.ExerciseRunning, "opened", "started"},
+		{NewExerciseStopCmd, class.ExerciseStopped, "opened", "stopped"},
+		{NewExerciseStartCmd, class.ExerciseRunning, "opened", "started"},
+		{NewExerciseCompleteCmd, class.ExerciseCompleted, "closed", "stopped"},
+	}
+	tc := newExercise(t)
+	for _, tt := range tests {
+		tc.Run(t, tt.cmd(), "--name", "lab1")
+		for _, group := range groups {
+			ex, p := exercise(t, tc, group)
+			if ex.State.String != tt.state {
+				t.Errorf("%s: state of %s %q, want %q", tt.state, group, ex.State.String, tt.state)
+			}
+			if p.Status == nil || *p.Status != tt.status {
+				t.Errorf("%s: project of %s is %v, want %s", tt.state, group, p.Status, tt.status)
+			}
+			if got := nodeStatus(t, tc, p); !slices.Equal(got, []string{tt.nodes}) {
+				t.Errorf("%s: nodes of %s are %v, want %s", tt.state, group, got, tt.nodes)
+			}
+		}
+	}
+	ex, _ := exercise(t, tc, "NET-g1")
+	if !ex.StartedAt.Valid || !ex.StoppedAt.Valid || !ex.CompletedAt.Valid {
+		t.Errorf("transition times of %+v, want all set", ex)
+	}
+}
+
+func TestExerciseLifecycleOneGroup(t *testing.T) {
+	tc := newExercise(t)
+	tc.Run(t, NewExerciseStartCmd(), "--name", "lab1", "--class", "NET", "--group", "NET-g2")
+
+	for group, want := range map[string]string{"NET-g1": class.ExerciseCreated, "NET-g2": class.ExerciseRunning} {
+		if ex, _ := exercise(t, tc, group); ex.State.String != want {
+			t.Errorf("state of %s %q, want %q", group, ex.State.String, want)
+		}
+	}
+}
+
+func TestExerciseLifecycleLookalike(t *testing.T) {
+	tc := newExercise(t)
+	ctx := context.Background()
+	_, p := exercise(t, tc, "NET-g1")
+	// a copy with the short uuid in its name, listed before the project
+	old := p.Name + " (old)"
+	if _, err := tc.Client.Projects().Update(ctx, p.ProjectID, schemas.ProjectUpdate{Name: &old}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tc.Client.Projects().Duplicate(ctx, p.ProjectID, schemas.ProjectDuplicate{Name: p.Name}); err != nil {
+		t.Fatal(err)
+	}
+
+	tc.Run(t, NewExerciseStartCmd(), "--name", "lab1", "--class", "NET", "--group", "NET-g1")
+	_, p = exercise(t, tc, "NET-g1")
+	if got := nodeStatus(t, tc, p); !slices.Equal(got, []string{"started"}) {
+		t.Errorf("nodes of the project are %v, want started", got)
+	}
+	if copied := tc.Projects(t)[old]; copied.Status != nil && *copied.Status == "opened" {
+		t.Errorf("exercise start opened the copy %s", old)
+	}
+
+	out := t.TempDir()
+	tc.Run(t, NewExerciseCollectCmd(), "--exercise", "lab1", "--group", "NET-g1", "--out", out)
+	data, err := os.ReadFile(filepath.Join(out, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest class.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Submissions) != 1 || manifest.Submissions[0].ProjectID != p.ProjectID {
+		t.Errorf("collected %+v, want project %s", manifest.Submissions, p.ProjectID)
+	}
+}
+
+func TestExerciseLifecycleFailures(t *testing.T) {
+	ctx := context.Background()
+	tc := newExercise(t)
+	_, gone := exercise(t, tc, "NET-g1")
+	if err := tc.Client.Projects().Delete(ctx, gone.ProjectID); err != nil {
+		t.Fatal(err)
+	}
+
+	err := class.RunExerciseLifecycle(ctx, tc.Cfg, class.LifecycleOptions{ClusterID: tc.ClusterID, Exercise: "lab1", Action: class.ExerciseStart})
+	if err == nil || !strings.Contains(err.Error(), "1 projects failed") {
+		t.Fatalf("start with a deleted project: %v", err)
+	}
+	// the project that failed keeps its state, the others move on
+	if rows := tc.Exercises(t, "NET", "NET-g1"); rows[0].State.String != class.ExerciseCreated {
+		t.Errorf("state of the failed project %q, want created", rows[0].State.String)
+	}
+	if ex, _ := exercise(t, tc, "NET-g2"); ex.State.String != class.ExerciseRunning {
+		t.Errorf("state of NET-g2 %q, want running", ex.State.String)
+	}
+
+	for _, opts := range []class.LifecycleOptions{
+		{ClusterID: tc.ClusterID, Exercise: "lab1", Action: "pause"},
+		{ClusterID: tc.ClusterID, Exercise: "lab2", Action: class.ExerciseStart},
+	} {
+		if err := class.RunExerciseLifecycle(ctx, tc.Cfg, opts); err == nil {
+			t.Errorf("%s exercise %s succeeded", opts.Action, opts.Exercise)
+		}
+	}
+}
+
+func TestExerciseCollect(t *testing.T) {
+	tc := newExercise(t)
+	ctx := context.Background()
+	tc.Run(t, NewExerciseStartCmd(), "--name", "lab1", "--class", "NET", "--group", "NET-g2")
+
+	out := t.TempDir()
+	tc.Run(t, NewExerciseCollectCmd(), "--exercise", "lab1", "--out", out, "--lock")
+	data, err := os.ReadFile(filepath.Join(out, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest class.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Submissions) != len(groups) {
+		t.Fatalf("collected %+v, want a submission per group", manifest.Submissions)
+	}
+	for _, sub := range manifest.Submissions {
+		if sub.Error != "" || !sub.Locked {
+			t.Errorf("submission %+v, want a locked export", sub)
+		}
+		if _, err := os.Stat(filepath.Join(out, sub.File)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// the lock stays and only the projects collect opened are closed again
+	for group, want := range map[string]string{"NET-g1": "closed", "NET-g2": "opened"} {
+		_, p := exercise(t, tc, group)
+		if got := *p.Status; got != want {
+			t.Errorf("project of %s is %s, want %s", group, got, want)
+		}
+		if locked, err := tc.Client.Projects().Locked(ctx, p.ProjectID); err != nil || !locked {
+			t.Errorf("project of %s locked %v %v, want locked", group, locked, err)
+		}
+	}
+}
+
+func TestExerciseCheck(t *testing.T) {
+	tc := newExercise(t)
+	rules := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(rules, []byte("checks:\n  - template: VPCS\n  - running: [\"*\"]\n    points: 2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	check := func() []class.GroupReport {
+		t.Helper()
+		out := filepath.Join(t.TempDir(), "report.json")
+		tc.Run(t, NewExerciseCheckCmd(), "--exercise", "lab1", "--rules", rules, "-o", class.CheckJSON, "--out", out)
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var reports []class.GroupReport
+		if err := json.Unmarshal(data, &reports); err != nil {
+			t.Fatal(err)
+		}
+		return reports
+	}
+
+	// the closed projects are opened for the check and closed again
+	for _, r := range check() {
+		if r.Error != "" || r.Score != 1 || r.MaxScore != 3 {
+			t.Errorf("report of %s before start: %+v", r.Group, r)
+		}
+	}
+	for _, group := range groups {
+		if _, p := exercise(t, tc, group); p.Status == nil || *p.Status != "closed" {
+			t.Errorf("project of %s is %v after the check, want closed", group, p.Status)
+		}
+	}
+
+	tc.Run(t, NewExerciseStartCmd(), "--name", "lab1")
+	reports := check()
+	if len(reports) != 2 || reports[0].Group != "NET-g1" || reports[1].Group != "NET-g2" {
+		t.Fatalf("reports %+v, want one per group in order", reports)
+	}
+	for _, r := range reports {
+		if r.Score != 3 {
+			t.Errorf("report of %s after start: %+v", r.Group, r)
+		}
+	}
+}
+
+// poolProjects returns the ids of the projects in the pool of a project.
+func poolProjects(t *testing.T, tc *testutil.Cluster, project string) []string {
+	t.Helper()
+	ctx := context.Background()
+	pools, err := tc.Client.Pools().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range pools {
+		if p.Name == project+"-pool" {
+			resources, err := tc.Client.Pools().Resources(ctx, p.ResourcePoolID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, r := range resources {
+				ids = append(ids, r.ResourceID)
+			}
+			return ids
+		}
+	}
+	t.Fatalf("no pool for project %s", project)
+	return nil
+}
+
+func TestExerciseReset(t *testing.T) {
+	tests := []struct {
+		name string
+		// break damages the project of NET-g1
+		brk          func(t *testing.T, tc *testutil.Cluster, p schemas.ProjectResponse, ex sqlc.Exercise)
+		fromTemplate bool
+	}{
+		{
+			name: "restore the baseline",
+			brk:  func(t *testing.T, tc *testutil.Cluster, p schemas.ProjectResponse, ex sqlc.Exercise) {},
+		},
+		{
+			name: "duplicate the template without a baseline",
+			brk: func(t *testing.T, tc *testutil.Cluster, p schemas.ProjectResponse, ex sqlc.Exercise) {
+				if err := tc.Client.Snapshots(p.ProjectID).Delete(context.Background(), ex.BaselineSnapshotID.String); err != nil {
+					t.Fatal(err)
+				}
+			},
+			fromTemplate: true,
+		},
+		{
+			name: "finish a reset that failed halfway",
+			brk: func(t *testing.T, tc *testutil.Cluster, p schemas.ProjectResponse, ex sqlc.Exercise) {
+				ctx := context.Background()
+				if err := tc.Client.Snapshots(p.ProjectID).Delete(ctx, ex.BaselineSnapshotID.String); err != nil {
+					t.Fatal(err)
+				}
+				// the old project is gone and the copy was not renamed yet
+				pools, err := tc.Client.Pools().List(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, pool := range pools {
+					if pool.Name == p.Name+"-pool" {
+						if err := tc.Client.Pools().RemoveResource(ctx, pool.ResourcePoolID, p.ProjectID); err != nil {
+							t.Fatal(err)
+						}
+					}
+				}
+				if err := tc.Client.Projects().Delete(ctx, p.ProjectID); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := tc.Client.Projects().Duplicate(ctx, ex.TemplateProjectID.String, schemas.ProjectDuplicate{Name: p.Name + "-reset"}); err != nil {
+					t.Fatal(err)
+				}
+			},
+			fromTemplate: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tc := newExercise(t)
+			ex, p := exercise(t, tc, "NET-g1")
+			if !ex.BaselineSnapshotID.Valid || !ex.TemplateProjectID.Valid {
+				t.Fatalf("exercise without a baseline: %+v", ex)
+			}
+			tc.Run(t, NewExerciseStartCmd(), "--name", "lab1")
+			// the students delete the node of the template
+			for _, n := range mustNodes(t, tc, p) {
+				if err := tc.Client.Nodes(p.ProjectID).Delete(ctx, n.NodeID); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tt.brk(t, tc, p, ex)
+
+			tc.Run(t, NewExerciseResetCmd(), "--exercise", "lab1", "--class", "NET", "--group", "NET-g1", "--no-confirm")
+
+			reset, resetProject := exercise(t, tc, "NET-g1")
+			if resetProject.Name != p.Name {
+				t.Errorf("project %s after reset, want %s", resetProject.Name, p.Name)
+			}
+			if got := mustNodes(t, tc, resetProject); len(got) != 1 {
+				t.Errorf("project after reset has %d nodes, want the one of the template", len(got))
+			}
+			if reset.State.String != class.ExerciseCreated || !reset.BaselineSnapshotID.Valid {
+				t.Errorf("exercise after reset %+v, want created with a baseline", reset)
+			}
+			if (resetProject.ProjectID != p.ProjectID) != tt.fromTemplate {
+				t.Errorf("project id %s after reset, was %s", resetProject.ProjectID, p.ProjectID)
+			}
+			if tt.fromTemplate {
+				if reset.BaselineSnapshotID == ex.BaselineSnapshotID {
+					t.Error("the duplicate kept the deleted baseline")
+				}
+				if got := poolProjects(t, tc, p.Name); !slices.Equal(got, []string{resetProject.ProjectID}) {
+					t.Errorf("pool of %s holds %v, want the new project", p.Name, got)
+				}
+			}
+			// the other group is left alone
+			if other, _ := exercise(t, tc, "NET-g2"); other.State.String != class.ExerciseRunning {
+				t.Errorf("NET-g2 is %s after resetting NET-g1", other.State.String)
+			}
+		})
+	}
+}
+
+func TestExerciseResetWithoutBaseline(t *testing.T) {
+	ctx := context.Background()
+	tc := newExercise(t)
+	ex, _ := exercise(t, tc, "NET-g1")
+	store, err := db.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = store.DB.Close()
+	}()
+	// as recorded by exercise create before exercise reset existed
+	if _, err := store.DB.Exec("UPDATE exercises SET baseline_snapshot_id = NULL, template_project_id = NULL WHERE exercise_id = ?", ex.ExerciseID); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := class.ResetOptions{ClusterID: tc.ClusterID, Exercise: "lab1"}
+	if _, err := class.PlanExerciseReset(ctx, opts); err == nil || !strings.Contains(err.Error(), "no baseline snapshot and no template") {
+		t.Errorf("plan without a baseline: %v", err)
+	}
+	opts.GroupName = "NET-g2"
+	targets, err := class.PlanExerciseReset(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 || targets[0].Row.GroupName != "NET-g2" || targets[0].FromTemplate {
+		t.Errorf("targets %+v, want the baseline of NET-g2", targets)
+	}
+}
+
+func mustNodes(t *testing.T, tc *testutil.Cluster, p schemas.ProjectResponse) []schemas.NodeResponse {
+	t.Helper()
+	nodes, err := tc.Client.Nodes(p.ProjectID).List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nodes
+}
+
+func TestExerciseDeploy(t *testing.T) {
+	ctx := context.Background()
+	tc := newExercise(t, "--format", "{{exercise}}_{{group}}_{{uuid}}")
+	classData, _, err := class.ExportClass(ctx, tc.Cfg, tc.ClusterID, "NET", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classData.Groups = append(classData.Groups, schemas.Group{
+		Name: "NET-g3", Students: []schemas.Student{{UserName: "carol", Password: "carol-pass1"}},
+	})
+	scheduler, err := class.NewScheduler(tc.Cfg, class.PlacementOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := class.PlanClassApply(ctx, tc.Cfg, tc.ClusterID, classData, scheduler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := class.ApplyClassPlan(ctx, tc.Cfg, plan, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	tc.Run(t, NewExerciseDeployCmd(), "--class", "NET", "--exercise", "lab1", "--group", "NET-g3")
+	ex, p := exercise(t, tc, "NET-g3")
+	if ex.Name != "lab1" || ex.NameFormat.String != "{{exercise}}_{{group}}_{{uuid}}" {
+		t.Errorf("exercise of NET-g3 %+v", ex)
+	}
+	// the late group gets a project named like those of the others
+	if want := "lab1_g3_" + ex.ProjectUuid; p.Name != want {
+		t.Errorf("project of NET-g3 %q, want %q", p.Name, want)
+	}
+	if got := mustNodes(t, tc, p); len(got) != 1 {
+		t.Errorf("project of NET-g3 has %d nodes, want the one of the template", len(got))
+	}
+	if got := poolProjects(t, tc, p.Name); !slices.Equal(got, []string{p.ProjectID}) {
+		t.Errorf("pool of %s holds %v", p.Name, got)
+	}
+
+	for _, args := range [][]string{
+		{"--class", "NET", "--exercise", "lab1", "--group", "NET-g3"},
+		{"--class", "NET", "--exercise", "lab2", "--group", "NET-g3"},
+		{"--class", "NET", "--exercise", "lab1", "--group", "NET-g4"},
+	} {
+		cmd := NewExerciseDeployCmd()
+		cmd.SetArgs(args)
+		cmd.SetContext(config.WithGlobalOptions(ctx, tc.Cfg))
+		if err := cmd.Execute(); err == nil {
+			t.Errorf("deploy %v succeeded", args)
+		}
+	}
+}
+
+func TestExerciseDeployToAnotherNode(t *testing.T) {
+	ctx := context.Background()
+	tc := newExercise(t)
+	second := tc.AddNode(t)
+	// someone works on the template while the late group is deployed
+	template := tc.Projects(t)["tmpl"]
+	if err := tc.Client.Projects().Open(ctx, template.ProjectID); err != nil {
+		t.Fatal(err)
+	}
+	classData, _, err := class.ExportClass(ctx, tc.Cfg, tc.ClusterID, "NET", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	classData.Groups = append(classData.Groups, schemas.Group{
+		Name: "NET-g3", Students: []schemas.Student{{UserName: "carol", Password: "carol-pass1"}},
+	})
+	// the second node has no projects yet and takes the new group
+	scheduler, err := class.NewScheduler(tc.Cfg, class.PlacementOptions{Strategy: class.PlacementLeastLoaded})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := class.PlanClassApply(ctx, tc.Cfg, tc.ClusterID, classData, scheduler)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := class.ApplyClassPlan(ctx, tc.Cfg, plan, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	tc.Run(t, NewExerciseDeployCmd(), "--class", "NET", "--exercise", "lab1", "--group", "NET-g3")
+	ex := tc.Exercises(t, "NET", "NET-g3")
+	if len(ex) != 1 {
+		t.Fatalf("exercises of NET-g3: %+v, want one", ex)
+	}
+	list, err := second.Projects().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := slices.IndexFunc(list, func(p schemas.ProjectResponse) bool { return strings.HasSuffix(p.Name, ex[0].ProjectUuid) })
+	if idx < 0 {
+		t.Fatalf("project of NET-g3 not on the second node: %+v", list)
+	}
+	nodes, err := second.Nodes(list[idx].ProjectID).List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 {
+		t.Errorf("project of NET-g3 has %d nodes, want the one of the copied template", len(nodes))
+	}
+	if p := tc.Projects(t)["tmpl"]; p.Status == nil || *p.Status != "opened" {
+		t.Errorf("template is %v on its node after the copy, want it left opened", p.Status)
+	}
+}
+
+func TestExerciseCreateOnEveryNode(t *testing.T) {
+	ctx := context.Background()
+	tc := testutil.NewCluster(t)
+	second := tc.AddNode(t)
+	var classGroups []schemas.Group
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		classGroups = append(classGroups, schemas.Group{
+			Name: "NET-" + name, Students: []schemas.Student{{UserName: name, Password: name + "-pass1"}},
+		})
+	}
+	scheduler, err := class.NewScheduler(tc.Cfg, class.PlacementOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := class.CreateClass(tc.Cfg, tc.ClusterID, schemas.Class{Name: "NET", Groups: classGroups}, tc.Nodes, scheduler); err != nil || !ok {
+		t.Fatalf("create class: %v", err)
+	}
+	tc.TemplateProject(t, "tmpl")
+
+	// the nodes are created at the same time and record into one store
+	tc.Run(t, NewExerciseCreateCmd(), "--class", "NET", "--exercise", "lab1", "--template", "tmpl", "--confirm=false")
+	onSecond, err := second.Projects().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range classGroups {
+		rows := tc.Exercises(t, "NET", g.Name)
+		if len(rows) != 1 {
+			t.Errorf("exercises of %s: %+v, want one", g.Name, rows)
+		}
+	}
+	if len(onSecond) < 2 {
+		t.Errorf("projects on the second node %d, want a copy of the template and a group project", len(onSecond))
+	}
+}
+
+func TestDoctorRedeploysExercise(t *testing.T) {
+	ctx := context.Background()
+	tc := newExercise(t)
+	old, gone := exercise(t, tc, "NET-g1")
+	if err := tc.Client.Projects().Delete(ctx, gone.ProjectID); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := class.DiagnoseCluster(ctx, tc.Cfg, class.DoctorOptions{
+		ClusterID: tc.ClusterID,
+		Missing:   class.PolicyRecreate,
+		Untracked: class.PolicyPrune,
+		Redeploy:  Redeploy(tc.Cfg, tc.ClusterID),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := class.RepairFindings(ctx, findings); err != nil {
+		t.Fatalf("repair %+v: %v", findings, err)
+	}
+
+	ex, p := exercise(t, tc, "NET-g1")
+	if ex.ProjectUuid == old.ProjectUuid || ex.Name != "lab1" {
+		t.Errorf("exercise of NET-g1 after redeploy %+v, want a new row of lab1", ex)
+	}
+	if want := "NET-lab1-g1-" + ex.ProjectUuid; p.Name != want {
+		t.Errorf("redeployed project %q, want %q", p.Name, want)
+	}
+	if got := mustNodes(t, tc, p); len(got) != 1 {
+		t.Errorf("redeployed project has %d nodes, want the one of the template", len(got))
+	}
+	if got := poolProjects(t, tc, p.Name); !slices.Equal(got, []string{p.ProjectID}) {
+		t.Errorf("pool of %s holds %v", p.Name, got)
+	}
+	if other, _ := exercise(t, tc, "NET-g2"); other.ProjectUuid == "" {
+		t.Error("the exercise of NET-g2 was lost")
+	}
+
+	findings, err = class.DiagnoseCluster(ctx, tc.Cfg, class.DoctorOptions{
+		ClusterID: tc.ClusterID,
+		Missing:   class.PolicyRecreate,
+		Untracked: class.PolicyKeep,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("drift after the redeploy: %+v", findings)
+	}
+}
+
+func TestExerciseLsOutput(t *testing.T) {
+	tc := newExercise(t)
+	tc.Cfg.Output = "json"
+	out := testutil.Stdout(t, func() { tc.Run(t, NewExerciseLsCmd()) })
+	var nodes []db.NodeExercisesForClass
+	if err := json.Unmarshal([]byte(out), &nodes); err != nil {
+		t.Fatalf("exercise ls -o json printed %q: %v", out, err)
+	}
+	if len(nodes) != 1 {
+		t.Fatalf("nodes %+v, want the one node", nodes)
+	}
+	var got []string
+	for _, it := range nodes[0].Exercises {
+		if it.Name != "lab1" || it.ProjectUUID == "" {
+			t.Errorf("exercise %+v, want lab1 with its project", it)
+		}
+		got = append(got, it.GroupName)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, groups) {
+		t.Errorf("groups %v, want %v", got, groups)
+	}
+}
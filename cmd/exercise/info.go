@@ -88,7 +88,7 @@ func runExerciseInfo(cmd *cobra.Command, args []string) error {
 			name := it.Name
 			grp := it.GroupName
 			uuid := it.ProjectUUID
-			state := it.State
+			state := stateText(it)
 			if len(name) > 80 {
 				name = name[:77] + "..."
 			}
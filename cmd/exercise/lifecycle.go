@@ -0,0 +1,131 @@
+package exercise
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/fuzzy"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	clusterutils "github.com/stefanistkuhl/gns3util/pkg/utils/clusterUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewExerciseStartCmd() *cobra.Command {
+	return newExerciseLifecycleCmd(class.ExerciseStart,
+		"Start all nodes of an exercise",
+		`Open the project of every group of an exercise on all nodes of the cluster and
+start all of its nodes. The exercise is marked as running.`)
+}
+
+func NewExerciseStopCmd() *cobra.Command {
+	return newExerciseLifecycleCmd(class.ExerciseStop,
+		"Stop all nodes of an exercise",
+		`Stop all nodes in the project of every group of an exercise on all nodes of the
+cluster. The projects stay open and the exercise is marked as stopped.`)
+}
+
+func NewExerciseCompleteCmd() *cobra.Command {
+	return newExerciseLifecycleCmd(class.ExerciseComplete,
+		"Stop and close all projects of an exercise",
+		`Stop all nodes in the project of every group of an exercise on all nodes of the
+cluster and close the projects. The exercise is marked as completed.`)
+}
+
+func newExerciseLifecycleCmd(action, short, long string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   action,
+		Short: short,
+		Long: long + `
+
+Projects that fail keep their state, run the command again to retry them.`,
+		Example: fmt.Sprintf(`
+  # %[1]s an exercise for all classes
+  gns3util -s https://controller:3080 exercise %[2]s --name Lab1
+
+  # %[1]s it for one group of a class on a cluster
+  gns3util exercise %[2]s --cluster lab --name Lab1 --class CS101 --group class-CS101-group-1
+
+  # Select the exercise with the fuzzy finder
+  gns3util -s https://controller:3080 exercise %[2]s
+		`, lifecycleTitle(action), action),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExerciseLifecycle(cmd, action)
+		},
+	}
+	cmd.Flags().String("name", "", "Name of the exercise (default select with the fuzzy finder)")
+	cmd.Flags().String("class", "", "Only the projects of this class")
+	cmd.Flags().String("group", "", "Only the project of this group")
+	cmd.Flags().StringP("cluster", "c", "", "Cluster name")
+	return cmd
+}
+
+func runExerciseLifecycle(cmd *cobra.Command, action string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get global options: %w", err)
+	}
+	exerciseName, _ := cmd.Flags().GetString("name")
+	className, _ := cmd.Flags().GetString("class")
+	groupName, _ := cmd.Flags().GetString("group")
+	clusterName, _ := cmd.Flags().GetString("cluster")
+
+	clusterID, err := clusterutils.ResolveClusterID(cfg, clusterName, cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if exerciseName == "" {
+		exerciseName, err = selectExercise(cmd, clusterID, className, action)
+		if err != nil || exerciseName == "" {
+			return err
+		}
+	}
+
+	return class.RunExerciseLifecycle(cmd.Context(), cfg, class.LifecycleOptions{
+		ClusterID: clusterID,
+		Exercise:  exerciseName,
+		Action:    action,
+		ClassName: className,
+		GroupName: groupName,
+	})
+}
+
+// selectExercise lets the user pick an exercise of the cluster with the
+// fuzzy finder and returns "" if there is none or nothing was picked.
+func selectExercise(cmd *cobra.Command, clusterID int, className, action string) (string, error) {
+	store, err := db.Init()
+	if err != nil {
+		return "", fmt.Errorf("failed to init db: %w", err)
+	}
+	rows, err := store.GetNodeExercisesForCluster(cmd.Context(), int64(clusterID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get exercises: %w", err)
+	}
+	var names []string
+	for _, row := range rows {
+		if (className == "" || row.Name == className) && !slices.Contains(names, row.ExerciseName) {
+			names = append(names, row.ExerciseName)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Println(messageUtils.InfoMsg("No exercises found in DB for this cluster"))
+		return "", nil
+	}
+	slices.Sort(names)
+	picked := fuzzy.NewFuzzyFinderWithTitle(names, false, fmt.Sprintf("Select the exercise to %s:", action))
+	if len(picked) == 0 {
+		fmt.Println(messageUtils.InfoMsg("No exercise selected"))
+		return "", nil
+	}
+	return picked[0], nil
+}
+
+func lifecycleTitle(action string) string {
+	return strings.ToUpper(action[:1]) + action[1:]
+}
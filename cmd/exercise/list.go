@@ -23,6 +23,7 @@ func NewExerciseLsCmd() *cobra.Command {
 	cmd.Flags().Bool("api-only", false, "Use only API for listing (not implemented)")
 	cmd.Flags().StringP("cluster", "c", "", "Cluster name")
 	cmd.Flags().String("class", "", "Filter by class name")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -50,6 +51,9 @@ func runExerciseLs(cmd *cobra.Command, args []string) error {
 	}
 
 	nodes := clusterutils.TransformNodeExercises(sqlcRows, className)
+	if utils.StructuredOutput(cfg) {
+		return utils.PrintValue(cfg, nodes)
+	}
 
 	if len(nodes) == 0 {
 		fmt.Println(messageUtils.InfoMsg("No exercises found in DB for this cluster"))
@@ -93,7 +97,7 @@ func runExerciseLs(cmd *cobra.Command, args []string) error {
 			name := it.Name
 			grp := it.GroupName
 			uuid := it.ProjectUUID
-			state := it.State
+			state := stateText(it)
 			if len(name) > 80 {
 				name = name[:77] + "..."
 			}
@@ -115,3 +119,11 @@ func runExerciseLs(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// stateText returns the state of an exercise with the time it was entered.
+func stateText(it db.ExerciseItem) string {
+	if it.Since.IsZero() {
+		return it.State
+	}
+	return fmt.Sprintf("%s since %s", it.State, it.Since.Local().Format("2006-01-02 15:04"))
+}
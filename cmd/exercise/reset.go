@@ -0,0 +1,99 @@
+package exercise
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/class"
+	clusterutils "github.com/stefanistkuhl/gns3util/pkg/utils/clusterUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+func NewExerciseResetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reset",
+		Short: "Reset the projects of an exercise to their initial state",
+		Long: `Restore the project of every group of an exercise, or of a single group, to the
+state it was created in. Exercise create takes a "` + class.BaselineSnapshot + `" snapshot of every project and
+reset restores it.
+
+If the snapshot was deleted, the project is replaced by a new duplicate of the
+template project it was created from, with the same name and in the same pool, so
+the group keeps its access. This needs the template project, which is gone if the
+exercise was created with --delete-template-project.
+
+All changes the students made are lost. The exercise is marked as created again.`,
+		Example: `
+  # Reset the project of one group
+  gns3util -s https://controller:3080 exercise reset --exercise Lab1 --class CS101 --group CS101-group-3
+
+  # Reset Lab1 for all groups of a class on a cluster without asking
+  gns3util exercise reset --cluster lab --exercise Lab1 --class CS101 --no-confirm
+		`,
+		RunE: runExerciseReset,
+	}
+	cmd.Flags().String("exercise", "", "Name of the exercise (default select with the fuzzy finder)")
+	cmd.Flags().String("class", "", "Only the projects of this class")
+	cmd.Flags().String("group", "", "Only the project of this group")
+	cmd.Flags().Bool("no-confirm", false, "Skip confirmation prompt")
+	cmd.Flags().StringP("cluster", "c", "", "Cluster name")
+	return cmd
+}
+
+func runExerciseReset(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
+	if err != nil {
+		return fmt.Errorf("failed to get global options: %w", err)
+	}
+	exerciseName, _ := cmd.Flags().GetString("exercise")
+	className, _ := cmd.Flags().GetString("class")
+	groupName, _ := cmd.Flags().GetString("group")
+	noConfirm, _ := cmd.Flags().GetBool("no-confirm")
+	clusterName, _ := cmd.Flags().GetString("cluster")
+
+	clusterID, err := clusterutils.ResolveClusterID(cfg, clusterName, cmd.Context())
+	if err != nil {
+		return err
+	}
+	if exerciseName == "" {
+		exerciseName, err = selectExercise(cmd, clusterID, className, "reset")
+		if err != nil || exerciseName == "" {
+			return err
+		}
+	}
+
+	targets, err := class.PlanExerciseReset(cmd.Context(), class.ResetOptions{
+		ClusterID: clusterID,
+		Exercise:  exerciseName,
+		ClassName: className,
+		GroupName: groupName,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !noConfirm {
+		fmt.Printf("%v the projects of exercise %s for these groups:\n", messageUtils.WarningMsg("Resetting"), messageUtils.Bold(exerciseName))
+		for _, t := range targets {
+			from := "baseline snapshot"
+			if t.FromTemplate {
+				from = "template project"
+			}
+			fmt.Printf("  %s/%s from its %s\n", t.Row.ClassName, messageUtils.Bold(t.Row.GroupName), from)
+		}
+		if !utils.ConfirmPrompt(fmt.Sprintf("Discard all changes of %d groups?", len(targets)), false) {
+			fmt.Println(messageUtils.InfoMsg("Reset cancelled"))
+			return nil
+		}
+	}
+
+	if err := class.ResetExercise(cmd.Context(), cfg, targets); err != nil {
+		return err
+	}
+	fmt.Printf("%v %d projects of exercise %s\n", messageUtils.SuccessMsg("Reset"), len(targets), messageUtils.Bold(exerciseName))
+	return nil
+}
@@ -23,6 +23,7 @@ func NewGetAclCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -43,6 +44,7 @@ func NewGetAceCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -61,5 +63,6 @@ func NewGetAclEndpointsCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
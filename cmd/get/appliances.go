@@ -24,6 +24,7 @@ func NewGetAppliancesCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -79,5 +80,6 @@ func NewGetApplianceCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find an appliance")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple appliances")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -23,6 +23,7 @@ func NewGetComputesCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -49,6 +50,7 @@ func NewGetComputeCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -75,6 +77,7 @@ func NewGetComputeDockerImagesCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -101,6 +104,7 @@ func NewGetComputeVirtualboxVMSCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -127,5 +131,6 @@ func NewGetComputeVmWareVMSCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -52,7 +52,7 @@ func NewGetDrawingsCmd() *cobra.Command {
 					return fmt.Errorf("error getting drawings: %w", drawingsErr)
 				}
 
-				utils.PrintResourceWithContext(projectDrawings, "Project:")
+				utils.PrintResourceWithContext(cfg, projectDrawings, "Project:", "getDrawings")
 			} else {
 				id := args[0]
 				if !utils.IsValidUUIDv4(args[0]) {
@@ -68,6 +68,7 @@ func NewGetDrawingsCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple projects")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -95,5 +96,6 @@ func NewGetDrawingCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -61,6 +61,7 @@ func NewGetGroupCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a group")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple groups")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -79,6 +80,7 @@ func NewGetGroupsCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -125,7 +127,7 @@ func NewGetGroupMembersCmd() *cobra.Command {
 					return fmt.Errorf("error getting group members: %w", membersErr)
 				}
 
-				utils.PrintResourceWithContext(groupMembers, "Group:")
+				utils.PrintResourceWithContext(cfg, groupMembers, "Group:", "getGroupMembers")
 			} else {
 				id := args[0]
 				if !utils.IsValidUUIDv4(id) {
@@ -141,5 +143,6 @@ func NewGetGroupMembersCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a group")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple groups")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
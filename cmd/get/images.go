@@ -31,6 +31,7 @@ func NewGetImagesCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVarP(&imageType, "image-type", "t", "", "What type of image to get (qemu/ios/iou)")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -133,5 +134,6 @@ func NewGetImageCmd() *cobra.Command {
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find an image")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple images")
 	cmd.Flags().StringVarP(&imageType, "image-type", "t", "", "What type of image to get (qemu/ios/iou)")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -23,5 +23,6 @@ func NewGetIouLicenseCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -53,7 +53,7 @@ func NewGetLinksCmd() *cobra.Command {
 					return fmt.Errorf("error getting links: %w", projectErr)
 				}
 
-				utils.PrintResourceWithContext(projectLinks, "Project:")
+				utils.PrintResourceWithContext(cfg, projectLinks, "Project:", "getLinks")
 			} else {
 				id := args[0]
 				if !utils.IsValidUUIDv4(args[0]) {
@@ -69,6 +69,7 @@ func NewGetLinksCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple projects")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -158,6 +159,7 @@ func NewGetLinkCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project and link")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple links")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -247,6 +249,7 @@ func NewGetLinkIfaceCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project and link")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple links")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -336,5 +339,6 @@ func NewGetLinkFiltersCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project and link")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple links")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
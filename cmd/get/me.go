@@ -23,5 +23,6 @@ func NewGetMeCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
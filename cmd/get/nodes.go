@@ -53,7 +53,7 @@ func NewGetNodesCmd() *cobra.Command {
 					return fmt.Errorf("error getting nodes: %w", projectErr)
 				}
 
-				utils.PrintResourceWithContext(projectNodes, "Project:")
+				utils.PrintResourceWithContext(cfg, projectNodes, "Project:", "getNodes")
 			} else {
 				id := args[0]
 				if !utils.IsValidUUIDv4(args[0]) {
@@ -69,6 +69,7 @@ func NewGetNodesCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple projects")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -164,6 +165,7 @@ func NewGetNodeCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project and node")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple nodes")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -259,6 +261,7 @@ func NewGetNodeLinksCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project and node")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple nodes")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -354,6 +357,7 @@ func NewGetNodesAutoIdlePCCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project and node")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple nodes")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -449,5 +453,6 @@ func NewGetNodesAutoIdlePCProposalsCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project and node")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple nodes")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -1,106 +1,45 @@
 package get
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
-	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stefanistkuhl/gns3util/pkg/api"
-	"github.com/stefanistkuhl/gns3util/pkg/api/endpoints"
 	"github.com/stefanistkuhl/gns3util/pkg/authentication"
 	"github.com/stefanistkuhl/gns3util/pkg/config"
 	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
 	"github.com/tidwall/pretty"
 )
 
 func NewGetNotificationsCmd() *cobra.Command {
 	timeout := 5
+	var actions []string
 	var cmd = &cobra.Command{
 		Use:     "notifications",
 		Short:   "Stream the notification of the controller",
 		Long:    `Stream the notification of the controller`,
-		Example: "gns3util -s https://controller:3080 get notifications",
+		Example: "gns3util -s https://controller:3080 get notifications -t 0 -a compute.*",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg, err := config.GetGlobalOptionsFromContext(cmd.Context())
 			if err != nil {
 				return fmt.Errorf("failed to get global options: %w", err)
 			}
 
-			token, err := authentication.GetKeyForServer(cfg)
-			if err != nil {
-				return fmt.Errorf("failed to get authentication token: %w", err)
-			}
-
-			settings := api.NewSettings(
-				api.WithBaseURL(cfg.Server),
-				api.WithVerify(cfg.Insecure),
-				api.WithToken(token),
-				api.WithTimeout(time.Duration(timeout)*time.Second),
-			)
-
-			ep := endpoints.GetEndpoints{}
-			client := api.NewGNS3Client(settings)
-			reqOpts := api.NewRequestOptions(settings).
-				WithURL(ep.Notifications()).
-				WithMethod(api.GET).
-				WithStream()
-
-			_, resp, err := client.Do(reqOpts)
-			if err != nil {
-				return fmt.Errorf("request failed: %w", err)
-			}
-			if resp == nil {
-				return fmt.Errorf("no response received")
-			}
-			defer func() {
-				if err := resp.Body.Close(); err != nil {
-					// Keep silent on body close errors
-					_ = err
-				}
-			}()
-
-			scanner := bufio.NewScanner(resp.Body)
-			for scanner.Scan() {
-				line := scanner.Bytes()
-				if len(line) == 0 {
-					continue
-				}
-
-				var js json.RawMessage
-				if err := json.Unmarshal(line, &js); err != nil {
-					fmt.Fprintf(os.Stderr, "invalid JSON: %v\n", err)
-					continue
-				}
-
-				formatted := pretty.Pretty(line)
-				formatted = pretty.Color(formatted, nil)
-				fmt.Println(string(formatted))
-			}
-
-			if err := scanner.Err(); err != nil {
-				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-					return nil
-				}
-				if strings.Contains(err.Error(), "use of closed network connection") {
-					return nil
-				}
-				return fmt.Errorf("error reading stream: %w", err)
-			}
-			return nil
+			return streamNotifications(cmd, cfg, timeout, actions)
 		},
 	}
 	cmd.Flags().IntVarP(&timeout, "timeout", "t", 5, "Timeout in seconds (0 for stream until cancellation)")
+	cmd.Flags().StringSliceVarP(&actions, "action", "a", nil, "Only show events with these actions, e.g. node.updated or node.*")
 	return cmd
 }
 
 func NewGetProjectNotificationCmd() *cobra.Command {
 	timeout := 5
+	var actions []string
 	var cmd = &cobra.Command{
 		Use:     "notifications [project-name/id]",
 		Short:   "Stream the notification of a project by id or name",
@@ -120,69 +59,55 @@ func NewGetProjectNotificationCmd() *cobra.Command {
 				}
 			}
 
-			token, err := authentication.GetKeyForServer(cfg)
-			if err != nil {
-				return fmt.Errorf("failed to get authentication token: %w", err)
-			}
-
-			settings := api.NewSettings(
-				api.WithBaseURL(cfg.Server),
-				api.WithVerify(cfg.Insecure),
-				api.WithToken(token),
-				api.WithTimeout(time.Duration(timeout)*time.Second),
-			)
-
-			ep := endpoints.GetEndpoints{}
-			client := api.NewGNS3Client(settings)
-			reqOpts := api.NewRequestOptions(settings).
-				WithURL(ep.ProjectNotifications(id)).
-				WithMethod(api.GET).
-				WithStream()
-
-			_, resp, err := client.Do(reqOpts)
-			if err != nil {
-				return fmt.Errorf("request failed: %w", err)
-			}
-			if resp == nil {
-				return fmt.Errorf("no response received")
-			}
-			defer func() {
-				if err := resp.Body.Close(); err != nil {
-					// Keep silent on body close errors
-					_ = err
-				}
-			}()
-
-			scanner := bufio.NewScanner(resp.Body)
-			for scanner.Scan() {
-				line := scanner.Bytes()
-				if len(line) == 0 {
-					continue
-				}
-
-				var js json.RawMessage
-				if err := json.Unmarshal(line, &js); err != nil {
-					fmt.Fprintf(os.Stderr, "invalid JSON: %v\n", err)
-					continue
-				}
-
-				formatted := pretty.Pretty(line)
-				formatted = pretty.Color(formatted, nil)
-				fmt.Println(string(formatted))
-			}
-
-			if err := scanner.Err(); err != nil {
-				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-					return nil
-				}
-				if strings.Contains(err.Error(), "use of closed network connection") {
-					return nil
-				}
-				return fmt.Errorf("error reading stream: %w", err)
-			}
-			return nil
+			return streamNotifications(cmd, cfg, timeout, actions, api.ForProject(id))
 		},
 	}
 	cmd.Flags().IntVarP(&timeout, "timeout", "t", 5, "Timeout in seconds (0 for stream until cancellation)")
+	cmd.Flags().StringSliceVarP(&actions, "action", "a", nil, "Only show events with these actions, e.g. node.updated or node.*")
 	return cmd
 }
+
+// streamNotifications prints the events of the controller's WebSocket
+// notification stream until the timeout expires or the command is canceled.
+func streamNotifications(cmd *cobra.Command, cfg config.GlobalOptions, timeout int, actions []string, opts ...api.SubscriberOption) error {
+	token, err := authentication.GetKeyForServer(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to get authentication token: %w", err)
+	}
+
+	settings := api.NewSettings(
+		api.WithBaseURL(cfg.Server),
+		api.WithVerify(!cfg.Insecure),
+		api.WithToken(token),
+		api.WithRetries(cfg.Retries),
+		api.WithRetryDelays(cfg.RetryDelay, cfg.RetryMaxDelay),
+		api.WithRetryStatusCodes(cfg.RetryStatus),
+		api.WithReauthenticator(utils.Reauthenticator(cfg)),
+	)
+
+	ctx := cmd.Context()
+	if timeout > 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	opts = append(opts,
+		api.WithActions(actions...),
+		api.OnReconnect(func(attempt int, err error) {
+			fmt.Fprintf(os.Stderr, "%v notification stream interrupted (%v), reconnecting (attempt %d)\n",
+				messageUtils.WarningMsg("Warning"), err, attempt)
+		}),
+	)
+	sub := api.NewSubscriber(settings, opts...)
+	return sub.Run(ctx, func(ev api.Event) error {
+		if cfg.Raw {
+			fmt.Println(string(ev.Raw))
+			return nil
+		}
+		formatted := pretty.Pretty(ev.Raw)
+		formatted = pretty.Color(formatted, nil)
+		fmt.Println(string(formatted))
+		return nil
+	})
+}
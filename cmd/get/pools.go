@@ -24,6 +24,7 @@ func NewGetPoolsCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -79,6 +80,7 @@ func NewGetPoolCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a pool")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple pools")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -125,7 +127,7 @@ func NewGetPoolResourcesCmd() *cobra.Command {
 					return fmt.Errorf("error getting pool resources: %w", poolErr)
 				}
 
-				utils.PrintResourceWithContext(poolResources, "Pool:")
+				utils.PrintResourceWithContext(cfg, poolResources, "Pool:", "getPoolResources")
 			} else {
 				id := args[0]
 				if !utils.IsValidUUIDv4(args[0]) {
@@ -141,5 +143,6 @@ func NewGetPoolResourcesCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a pool")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple pools")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
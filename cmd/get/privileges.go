@@ -25,5 +25,6 @@ func NewGetPrivilegesCmd() *cobra.Command {
 		},
 	}
 
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -1,13 +1,13 @@
 package get
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
 	"github.com/stefanistkuhl/gns3util/pkg/authentication"
 	"github.com/stefanistkuhl/gns3util/pkg/config"
 	"github.com/stefanistkuhl/gns3util/pkg/utils"
@@ -29,6 +29,7 @@ func NewGetProjectsCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -55,6 +56,7 @@ func NewGetProjectCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -81,6 +83,7 @@ func NewGetProjectStatsCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -111,6 +114,7 @@ func NewGetProjectLockedCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple projects")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -157,36 +161,22 @@ func NewGetProjectExportCmd() *cobra.Command {
 				outputFile = fmt.Sprintf("%s.gns3project", projectName)
 			}
 
-			token, err := authentication.GetKeyForServer(cfg)
+			client, err := utils.NewClient(cfg)
 			if err != nil {
-				return fmt.Errorf("failed to get token: %w", err)
+				return err
 			}
 
-			settings := api.NewSettings(
-				api.WithBaseURL(cfg.Server),
-				api.WithVerify(cfg.Insecure),
-				api.WithToken(token),
-			)
-			client := api.NewGNS3Client(settings)
-
-			reqOpts := api.NewRequestOptions(settings).
-				WithURL(fmt.Sprintf("/projects/%s/export", id)).
-				WithMethod(api.GET)
-
-			exportData, resp, err := client.Do(reqOpts)
+			exportData, err := client.Projects().Export(cmd.Context(), id, sdk.ExportOptions{
+				IncludeSnapshots:  includeSnapshots,
+				IncludeImages:     includeImages,
+				ResetMacAddresses: resetMacAddresses,
+				KeepComputeIDs:    keepComputeIds,
+				Compression:       compression,
+				CompressionLevel:  compressionLevel,
+			})
 			if err != nil {
 				return fmt.Errorf("failed to export project: %w", err)
 			}
-			defer func() {
-				if closeErr := resp.Body.Close(); closeErr != nil {
-					// Keep silent on body close errors
-					_ = closeErr
-				}
-			}()
-
-			if resp.StatusCode != 200 {
-				return fmt.Errorf("export failed with status %d: %s", resp.StatusCode, string(exportData))
-			}
 
 			err = os.WriteFile(outputFile, exportData, 0o600)
 			if err != nil {
@@ -210,47 +200,18 @@ func NewGetProjectExportCmd() *cobra.Command {
 }
 
 func getProjectNameFromID(cfg config.GlobalOptions, projectID string) (string, error) {
-	token, err := authentication.GetKeyForServer(cfg)
+	client, err := utils.NewClient(cfg)
 	if err != nil {
-		return "", fmt.Errorf("failed to get token: %w", err)
+		return "", err
 	}
-
-	settings := api.NewSettings(
-		api.WithBaseURL(cfg.Server),
-		api.WithVerify(cfg.Insecure),
-		api.WithToken(token),
-	)
-	client := api.NewGNS3Client(settings)
-
-	reqOpts := api.NewRequestOptions(settings).
-		WithURL(fmt.Sprintf("/projects/%s", projectID)).
-		WithMethod(api.GET)
-
-	body, resp, err := client.Do(reqOpts)
+	project, err := client.Projects().Get(cfg.Context(), projectID)
 	if err != nil {
 		return "", fmt.Errorf("API error: %w", err)
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			// Keep silent on body close errors
-			_ = err
-		}
-	}()
-
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("failed to get project with status %d", resp.StatusCode)
-	}
-
-	var project map[string]any
-	if err := json.Unmarshal(body, &project); err != nil {
-		return "", fmt.Errorf("failed to parse project response: %w", err)
+	if project.Name == "" {
+		return "", fmt.Errorf("project name not found in response")
 	}
-
-	if name, ok := project["name"].(string); ok {
-		return name, nil
-	}
-
-	return "", fmt.Errorf("project name not found in response")
+	return project.Name, nil
 }
 
 func NewGetProjectFileCmd() *cobra.Command {
@@ -290,6 +251,7 @@ func NewGetProjectFileCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -297,7 +259,7 @@ func NewGetProjectFileCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/files/%s", projectID, filePath)).
 				WithMethod(api.GET)
 
-			fileData, resp, err := client.Do(reqOpts)
+			fileData, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to get project file: %w", err)
 			}
@@ -372,6 +334,7 @@ func NewGetNodeFileCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -379,7 +342,7 @@ func NewGetNodeFileCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/nodes/%s/files/%s", projectID, nodeID, filePath)).
 				WithMethod(api.GET)
 
-			fileData, resp, err := client.Do(reqOpts)
+			fileData, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to get node file: %w", err)
 			}
@@ -453,6 +416,7 @@ func NewStreamPcapCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -460,7 +424,7 @@ func NewStreamPcapCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/links/%s/capture/stream", projectID, linkID)).
 				WithMethod(api.GET)
 
-			fileData, resp, err := client.Do(reqOpts)
+			fileData, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to stream PCAP: %w", err)
 			}
@@ -24,6 +24,7 @@ func NewGetRolesCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -79,6 +80,7 @@ func NewGetRoleCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a role")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple roles")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -125,7 +127,7 @@ func NewGetRolePrivsCmd() *cobra.Command {
 					return fmt.Errorf("error getting role privileges: %w", privsErr)
 				}
 
-				utils.PrintResourceWithContext(rolePrivs, "Role:")
+				utils.PrintResourceWithContext(cfg, rolePrivs, "Role:", "getRolePrivs")
 			} else {
 				id := args[0]
 				if !utils.IsValidUUIDv4(args[0]) {
@@ -141,5 +143,6 @@ func NewGetRolePrivsCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a role")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple roles")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -52,7 +52,7 @@ func NewGetSnapshotsCmd() *cobra.Command {
 					return fmt.Errorf("error getting snapshots: %w", snapErr)
 				}
 
-				utils.PrintResourceWithContext(snapshots, "Project:")
+				utils.PrintResourceWithContext(cfg, snapshots, "Project:", "getSnapshots")
 			} else {
 				id := args[0]
 				if !utils.IsValidUUIDv4(args[0]) {
@@ -68,5 +68,6 @@ func NewGetSnapshotsCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a project")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get snapshots from multiple projects")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
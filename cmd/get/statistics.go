@@ -23,5 +23,6 @@ func NewGetStatisticsCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -24,6 +24,7 @@ func NewGetSymbolsCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -79,6 +80,7 @@ func NewGetSymbolCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a symbol")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple symbols")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -134,6 +136,7 @@ func NewGetSymbolDimensionsCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a symbol")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get dimensions for multiple symbols")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -152,5 +155,6 @@ func NewGetDefaultSymbolsCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
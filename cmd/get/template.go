@@ -24,6 +24,7 @@ func NewGetTemplatesCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -79,5 +80,6 @@ func NewGetTemplateCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Use fuzzy search to find a template")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Get multiple templates")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -63,6 +63,7 @@ func NewGetUserCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Enable fuzzy search mode for interactive selection")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Allow selecting multiple items (requires --fuzzy)")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -81,6 +82,7 @@ func NewGetUsersCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
 
@@ -128,7 +130,7 @@ func NewGetGroupMembershipsCmd() *cobra.Command {
 					return fmt.Errorf("error getting group memberships: %w", membershipErr)
 				}
 
-				utils.PrintResourceWithContext(userMemberships, "User:")
+				utils.PrintResourceWithContext(cfg, userMemberships, "User:", "getGroupMemberships")
 			} else {
 				id := args[0]
 				if !utils.IsValidUUIDv4(args[0]) {
@@ -144,5 +146,6 @@ func NewGetGroupMembershipsCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(&useFuzzy, "fuzzy", "f", false, "Enable fuzzy search mode for interactive selection")
 	cmd.Flags().BoolVarP(&multi, "multi", "m", false, "Allow selecting multiple items (requires --fuzzy)")
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -23,5 +23,6 @@ func NewGetVersionCmd() *cobra.Command {
 			return nil
 		},
 	}
+	utils.AddOutputFlags(cmd)
 	return cmd
 }
@@ -68,6 +68,7 @@ func NewNodeDuplicateCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -75,7 +76,7 @@ func NewNodeDuplicateCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/nodes/%s/duplicate", projectID, nodeID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to duplicate node: %w", err)
 			}
@@ -133,6 +134,7 @@ func NewNodeConsoleResetCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -140,7 +142,7 @@ func NewNodeConsoleResetCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/nodes/%s/console/reset", projectID, nodeID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to reset console: %w", err)
 			}
@@ -198,6 +200,7 @@ func NewNodeIsolateCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -205,7 +208,7 @@ func NewNodeIsolateCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/nodes/%s/isolate", projectID, nodeID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to isolate node: %w", err)
 			}
@@ -263,6 +266,7 @@ func NewNodeUnisolateCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -270,7 +274,7 @@ func NewNodeUnisolateCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/nodes/%s/unisolate", projectID, nodeID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to un-isolate node: %w", err)
 			}
@@ -323,6 +327,7 @@ func NewReloadNodesCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -330,7 +335,7 @@ func NewReloadNodesCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/nodes/reload", projectID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to reload nodes: %w", err)
 			}
@@ -383,6 +388,7 @@ func NewStartNodesCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -390,7 +396,7 @@ func NewStartNodesCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/nodes/start", projectID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to start nodes: %w", err)
 			}
@@ -443,6 +449,7 @@ func NewStopNodesCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -450,7 +457,7 @@ func NewStopNodesCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/nodes/stop", projectID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to stop nodes: %w", err)
 			}
@@ -503,6 +510,7 @@ func NewSuspendNodesCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -510,7 +518,7 @@ func NewSuspendNodesCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/nodes/suspend", projectID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to suspend nodes: %w", err)
 			}
@@ -189,6 +189,7 @@ func NewProjectLoadCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -196,7 +197,7 @@ func NewProjectLoadCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/load?path=%s", projectPath)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to load project: %w", err)
 			}
@@ -250,6 +251,7 @@ func NewProjectCloseCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -257,7 +259,7 @@ func NewProjectCloseCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/close", projectID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to close project: %w", err)
 			}
@@ -332,6 +334,7 @@ gns3util -s https://controller:3080 post project import --name "my-project" /pat
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -347,7 +350,7 @@ gns3util -s https://controller:3080 post project import --name "my-project" /pat
 				WithMethod(api.POST).
 				WithData(buf.String())
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to import project: %w", err)
 			}
@@ -401,6 +404,7 @@ func NewProjectLockCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -408,7 +412,7 @@ func NewProjectLockCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/lock", projectID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to lock project: %w", err)
 			}
@@ -462,6 +466,7 @@ func NewProjectOpenCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -469,7 +474,7 @@ func NewProjectOpenCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/open", projectID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to open project: %w", err)
 			}
@@ -523,6 +528,7 @@ func NewProjectUnlockCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -530,7 +536,7 @@ func NewProjectUnlockCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/unlock", projectID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to unlock project: %w", err)
 			}
@@ -585,6 +591,7 @@ func NewProjectWriteFileCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -592,7 +599,7 @@ func NewProjectWriteFileCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/files%s", projectID, filePath)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to write file to project: %w", err)
 			}
@@ -651,6 +658,7 @@ func NewProjectStartCaptureCmd() *cobra.Command {
 				api.WithBaseURL(cfg.Server),
 				api.WithVerify(cfg.Insecure),
 				api.WithToken(token),
+				api.WithReauthenticator(utils.Reauthenticator(cfg)),
 			)
 			client := api.NewGNS3Client(settings)
 
@@ -658,7 +666,7 @@ func NewProjectStartCaptureCmd() *cobra.Command {
 				WithURL(fmt.Sprintf("/projects/%s/links/%s/start_capture", projectID, linkID)).
 				WithMethod(api.POST)
 
-			_, resp, err := client.Do(reqOpts)
+			_, resp, err := client.Do(cmd.Context(), reqOpts)
 			if err != nil {
 				return fmt.Errorf("failed to start capture: %w", err)
 			}
@@ -1,24 +1,43 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/carapace-sh/carapace"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
 	"github.com/stefanistkuhl/gns3util/cmd/auth"
 	"github.com/stefanistkuhl/gns3util/cmd/class"
 	"github.com/stefanistkuhl/gns3util/cmd/exercise"
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/authentication"
 	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
 	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
 )
 
 var (
-	server   string
-	keyFile  string
-	insecure bool
-	raw      bool
-	noColor  bool
-	version  bool
+	server        string
+	keyFile       string
+	user          string
+	insecure      bool
+	raw           bool
+	noColor       bool
+	version       bool
+	retries       int
+	retryDelay    time.Duration
+	retryMaxDelay time.Duration
+	retryStatus   []int
+	record        string
+	replay        string
+	ctxName       string
 )
 
 var Version = "1.2.9"
@@ -40,9 +59,18 @@ var rootCmd = &cobra.Command{
 			return nil
 		}
 
+		if err := applyEnv(cmd); err != nil {
+			return err
+		}
+		if err := applyContext(cmd); err != nil {
+			return err
+		}
 		if err := validateGlobalFlags(); err != nil {
 			return err
 		}
+		if err := setupCassette(); err != nil {
+			return err
+		}
 
 		skipServer := false
 		if f := cmd.Flags().Lookup("cluster"); f != nil {
@@ -57,20 +85,19 @@ var rootCmd = &cobra.Command{
 				}
 			}
 		}
+		// commands that only work on local state, like auth list
+		if cmd.Annotations[auth.AnnotationServerOptional] == "true" {
+			skipServer = true
+		}
 		if !skipServer {
 			if err := validateRequiresServer(); err != nil {
 				return err
 			}
 		}
 
-		opts := config.GlobalOptions{
-			Server:   server,
-			Insecure: insecure,
-			KeyFile:  keyFile,
-			Raw:      raw,
+		if err := setGlobalOptions(cmd); err != nil {
+			return err
 		}
-		ctx := config.WithGlobalOptions(cmd.Context(), opts)
-		cmd.SetContext(ctx)
 
 		return nil
 	},
@@ -88,9 +115,17 @@ func init() {
 	cobra.OnFinalize()
 	rootCmd.PersistentFlags().StringVarP(&server, "server", "s", "", "GNS3v3 Server URL (required for non cluster commands)")
 	rootCmd.PersistentFlags().StringVarP(&keyFile, "key-file", "k", "", "Set a location for a keyfile to use")
+	rootCmd.PersistentFlags().StringVar(&user, "user", "", "Stored identity to use for the server instead of its default (see auth list)")
 	rootCmd.PersistentFlags().BoolVarP(&insecure, "insecure", "i", false, "Ignore unsigned SSL-Certificates")
 	rootCmd.PersistentFlags().BoolVarP(&raw, "raw", "", false, "Output all data in raw json")
 	rootCmd.PersistentFlags().BoolVarP(&noColor, "no-color", "", false, "Output all data in raw json and dont use a colored output")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", api.DefaultMaxRetries, "Number of retries for failed idempotent API requests (0 disables retries)")
+	rootCmd.PersistentFlags().DurationVar(&retryDelay, "retry-delay", api.DefaultBaseDelay, "Base delay of the exponential backoff between retries")
+	rootCmd.PersistentFlags().DurationVar(&retryMaxDelay, "retry-max-delay", api.DefaultMaxDelay, "Longest wait between retries, also caps the Retry-After of the server")
+	rootCmd.PersistentFlags().IntSliceVar(&retryStatus, "retry-status", api.DefaultRetryPolicy().StatusCodes, "HTTP status codes that are retried")
+	rootCmd.PersistentFlags().StringVar(&record, "record", "", "Record all API requests and responses into a cassette directory (tokens and passwords are redacted)")
+	rootCmd.PersistentFlags().StringVar(&replay, "replay", "", "Serve API responses from a recorded cassette directory instead of the server")
+	rootCmd.PersistentFlags().StringVar(&ctxName, "context", "", "Context to take defaults for the global flags from instead of the current one (see context ls)")
 	rootCmd.Flags().BoolVarP(&version, "version", "V", false, "Print version information")
 
 	rootCmd.AddCommand(auth.NewAuthCmdGroup())
@@ -122,28 +157,175 @@ func init() {
 
 	rootCmd.AddCommand(NewClusterCmdGroup())
 	rootCmd.AddCommand(NewShareCmdGroup())
+
+	rootCmd.AddCommand(NewContextCmdGroup())
+	rootCmd.AddCommand(NewDevCmdGroup())
 	carapace.Gen(rootCmd).FlagCompletion(carapace.ActionMap{
 		"key-file": carapace.ActionFiles(),
+		"record":   carapace.ActionDirectories(),
+		"replay":   carapace.ActionDirectories(),
 		"server":   carapace.ActionValues("http://localhost:3080", "https://gns3.example.com"),
 	})
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		fmt.Printf("%v\n", messageUtils.ErrorMsg(err.Error()))
 	}
 }
 
+func setGlobalOptions(cmd *cobra.Command) error {
+	opts := config.GlobalOptions{
+		Server:        server,
+		Insecure:      insecure,
+		KeyFile:       keyFile,
+		User:          user,
+		Raw:           raw,
+		NoColors:      noColor,
+		Retries:       retries,
+		RetryDelay:    retryDelay,
+		RetryMaxDelay: retryMaxDelay,
+		RetryStatus:   retryStatus,
+	}
+	if err := utils.ApplyOutputFlags(cmd, &opts); err != nil {
+		return err
+	}
+	cmd.SetContext(config.WithGlobalOptions(cmd.Context(), opts))
+	return nil
+}
+
+// globalFlag returns the global flag name of cmd, or nil if the command
+// shadows it with a flag of its own like auth login does with --user.
+func globalFlag(cmd *cobra.Command, name string) *pflag.Flag {
+	f := cmd.Root().PersistentFlags().Lookup(name)
+	if f == nil || cmd.Flags().Lookup(name) != f {
+		return nil
+	}
+	return f
+}
+
+// applyEnv sets every global flag that was not given on the command line
+// from its GNS3_* environment variable, e.g. GNS3_SERVER or GNS3_KEY_FILE.
+func applyEnv(cmd *cobra.Command) error {
+	v := viper.New()
+	v.SetEnvPrefix("GNS3")
+	v.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	v.AutomaticEnv()
+
+	var err error
+	cmd.Root().PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || globalFlag(cmd, f.Name) == nil || !v.IsSet(f.Name) {
+			return
+		}
+		if setErr := f.Value.Set(v.GetString(f.Name)); setErr != nil {
+			err = fmt.Errorf("invalid value for GNS3_%s: %w", strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_")), setErr)
+			return
+		}
+		f.Changed = true
+	})
+	return err
+}
+
+// applyContext fills the global flags that are still unset from the
+// selected context. Commands with a --cluster flag use the cluster of the
+// context if it has one, everything else its server. The insecure, user and
+// key-file settings of the context only apply while its server or cluster
+// is the target, not to a different one given with --server or --cluster.
+func applyContext(cmd *cobra.Command) error {
+	uc, err := config.LoadUserConfig()
+	if err != nil {
+		return err
+	}
+	c, ok, err := uc.Active(ctxName)
+	if err != nil || !ok {
+		return err
+	}
+
+	clusterFlag := cmd.Flags().Lookup("cluster")
+	serverChanged := cmd.Root().PersistentFlags().Changed("server")
+	clusterChanged := clusterFlag != nil && clusterFlag.Changed
+	if !serverChanged && !clusterChanged {
+		if c.Cluster != "" && clusterFlag != nil {
+			if err := clusterFlag.Value.Set(c.Cluster); err != nil {
+				return err
+			}
+		} else if c.Server != "" {
+			if err := setUnchanged(cmd, "server", c.Server); err != nil {
+				return err
+			}
+		}
+	} else {
+		sameServer := serverChanged && c.Server != "" && authentication.ServerKey(server) == authentication.ServerKey(c.Server)
+		sameCluster := clusterChanged && c.Cluster != "" && clusterFlag.Value.String() == c.Cluster
+		if !sameServer && !sameCluster {
+			return nil
+		}
+	}
+	if c.Insecure {
+		if err := setUnchanged(cmd, "insecure", "true"); err != nil {
+			return err
+		}
+	}
+	if err := setUnchanged(cmd, "user", c.User); err != nil {
+		return err
+	}
+	return setUnchanged(cmd, "key-file", c.KeyFile)
+}
+
+func setUnchanged(cmd *cobra.Command, name, value string) error {
+	f := globalFlag(cmd, name)
+	if f == nil || f.Changed || value == "" {
+		return nil
+	}
+	return f.Value.Set(value)
+}
+
 func validateGlobalFlags() error {
 	if noColor && !raw {
 		return fmt.Errorf("--no-color can only be used when --raw is also used")
 	}
+	if record != "" && replay != "" {
+		return fmt.Errorf("--record and --replay cannot be used together")
+	}
+	if retryDelay <= 0 || retryMaxDelay <= 0 {
+		return fmt.Errorf("--retry-delay and --retry-max-delay must be positive")
+	}
+	if retryDelay > retryMaxDelay {
+		return fmt.Errorf("--retry-delay %s is longer than --retry-max-delay %s", retryDelay, retryMaxDelay)
+	}
+	for _, code := range retryStatus {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("--retry-status %d is not an HTTP status code", code)
+		}
+	}
+	return nil
+}
+
+func setupCassette() error {
+	var (
+		c   *api.Cassette
+		err error
+	)
+	switch {
+	case record != "":
+		c, err = api.RecordCassette(record)
+	case replay != "":
+		c, err = api.ReplayCassette(replay)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	api.UseCassette(c)
 	return nil
 }
 
 func validateRequiresServer() error {
 	if server == "" {
-		return fmt.Errorf("required flag(s) \"server\" not set")
+		return fmt.Errorf("required flag(s) \"server\" not set, pass --server, set GNS3_SERVER or select a context with context use")
 	}
 	return nil
 }
@@ -12,6 +12,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/stefanistkuhl/gns3util/pkg/authentication"
 	"github.com/stefanistkuhl/gns3util/pkg/fuzzy"
 	"github.com/stefanistkuhl/gns3util/pkg/sharing/keys"
 	"github.com/stefanistkuhl/gns3util/pkg/sharing/mdns"
@@ -100,6 +101,53 @@ func selectReceiver(ctx context.Context, hint string, timeout time.Duration) (ad
 	return chosen.Addr, chosen.Instance, nil
 }
 
+// keyArtifact returns the stored logins of the configured key store:
+// gns3key or gns3key.enc in srcDir, or at keyFile if --key-file is given.
+// The keys of the OS keyring are exported into memory so the secrets never
+// land in a file on this machine.
+func keyArtifact(keyFile, srcDir string) (transport.Artifact, error) {
+	store, err := authentication.ConfiguredKeyStore()
+	if err != nil {
+		return transport.Artifact{}, err
+	}
+	ks, err := authentication.OpenKeyStore(store, keyFile)
+	if err != nil {
+		return transport.Artifact{}, err
+	}
+	switch store {
+	case authentication.KeyStoreKeyring:
+		data, err := authentication.ExportKeys(ks)
+		if err != nil {
+			return transport.Artifact{}, fmt.Errorf("failed to read the keys from the keyring: %w", err)
+		}
+		return transport.Artifact{Rel: "gns3key", Data: data}, nil
+	case authentication.KeyStoreEncrypted:
+		a := transport.Artifact{Rel: "gns3key.enc", Path: filepath.Join(srcDir, "gns3key.enc")}
+		if keyFile != "" {
+			a.Path = ks.Location()
+		}
+		return a, nil
+	}
+	a := transport.Artifact{Rel: "gns3key", Path: filepath.Join(srcDir, "gns3key")}
+	if keyFile != "" {
+		a.Path = ks.Location()
+	}
+	return a, nil
+}
+
+// artifactSize returns the size of an artifact and whether there is one to
+// send.
+func artifactSize(a transport.Artifact) (int64, bool) {
+	if a.Path == "" {
+		return int64(len(a.Data)), len(a.Data) > 0
+	}
+	st, err := os.Stat(a.Path)
+	if err != nil || !st.Mode().IsRegular() {
+		return 0, false
+	}
+	return st.Size(), true
+}
+
 func NewSendCmd() *cobra.Command {
 	var (
 		to              string
@@ -164,33 +212,42 @@ func NewSendCmd() *cobra.Command {
 
 			fmt.Printf("%s %s as %s\n", colorUtils.Success("Connected to"), colorUtils.Highlight(target), colorUtils.Bold(fmt.Sprintf("%q", hello.Label)))
 
-			candidates := []string{"cluster_config.toml", "clusterData.db", "gns3key"}
-			exists := func(rel string) (string, os.FileInfo, bool) {
-				abs := filepath.Join(srcDir, rel)
-				st, err := os.Stat(abs)
-				if err == nil && st.Mode().IsRegular() {
-					return abs, st, true
+			keyFile, _ := cmd.Flags().GetString("key-file")
+			if keyFile == "" {
+				keyFile = os.Getenv("GNS3_KEY_FILE")
+			}
+			key, err := keyArtifact(keyFile, srcDir)
+			if err != nil {
+				return err
+			}
+			candidates := []transport.Artifact{
+				{Rel: "cluster_config.toml", Path: filepath.Join(srcDir, "cluster_config.toml")},
+				{Rel: "clusterData.db", Path: filepath.Join(srcDir, "clusterData.db")},
+				key,
+			}
+			location := func(a transport.Artifact) string {
+				if a.Path == "" {
+					return "the OS keyring"
 				}
-				return abs, nil, false
+				return a.Path
 			}
 
-			selected := make([]string, 0, len(candidates))
+			selected := make([]transport.Artifact, 0, len(candidates))
 			if allFlag || sendConfigFlag || sendDBFlag || sendKeyFlag {
 				want := map[string]bool{
 					"cluster_config.toml": allFlag || sendConfigFlag,
 					"clusterData.db":      allFlag || sendDBFlag,
-					"gns3key":             allFlag || sendKeyFlag,
+					key.Rel:               allFlag || sendKeyFlag,
 				}
-				for rel, ok := range want {
-					if !ok {
+				for _, a := range candidates {
+					if !want[a.Rel] {
 						continue
 					}
-					abs, st, ok := exists(rel)
-					if ok {
-						fmt.Printf("%s %s %s\n", colorUtils.Success("Include"), colorUtils.Bold(rel), colorUtils.Highlight(fmt.Sprintf("(%d bytes)", st.Size())))
-						selected = append(selected, abs)
+					if size, ok := artifactSize(a); ok {
+						fmt.Printf("%s %s %s\n", colorUtils.Success("Include"), colorUtils.Bold(a.Rel), colorUtils.Highlight(fmt.Sprintf("(%d bytes)", size)))
+						selected = append(selected, a)
 					} else {
-						fmt.Printf("%s %s %s\n", colorUtils.Warning("Skip"), colorUtils.Bold(rel), colorUtils.Separator(fmt.Sprintf("(not found at %s)", abs)))
+						fmt.Printf("%s %s %s\n", colorUtils.Warning("Skip"), colorUtils.Bold(a.Rel), colorUtils.Separator(fmt.Sprintf("(not found at %s)", location(a))))
 					}
 				}
 				if len(selected) == 0 {
@@ -198,16 +255,16 @@ func NewSendCmd() *cobra.Command {
 				}
 			} else {
 				availableFiles := make([]string, 0)
-				fileMap := make(map[string]string)
+				fileMap := make(map[string]transport.Artifact)
 
-				for _, rel := range candidates {
-					abs, st, ok := exists(rel)
+				for _, a := range candidates {
+					size, ok := artifactSize(a)
 					if !ok {
 						continue
 					}
-					plainName := fmt.Sprintf("%-20s (%d bytes)", rel, st.Size())
+					plainName := fmt.Sprintf("%-20s (%d bytes)", a.Rel, size)
 					availableFiles = append(availableFiles, plainName)
-					fileMap[plainName] = abs
+					fileMap[plainName] = a
 				}
 
 				if len(availableFiles) == 0 {
@@ -215,8 +272,8 @@ func NewSendCmd() *cobra.Command {
 				}
 
 				if yesFlag {
-					for _, abs := range fileMap {
-						selected = append(selected, abs)
+					for _, name := range availableFiles {
+						selected = append(selected, fileMap[name])
 					}
 				} else {
 					selectedFiles := fuzzy.NewFuzzyFinderWithTitle(availableFiles, true, "Select files to send:")
@@ -227,17 +284,16 @@ func NewSendCmd() *cobra.Command {
 					}
 
 					for _, displayName := range selectedFiles {
-						if abs, ok := fileMap[displayName]; ok {
-							selected = append(selected, abs)
+						if a, ok := fileMap[displayName]; ok {
+							selected = append(selected, a)
 						}
 					}
 				}
 
 				fmt.Printf("\n%s\n", colorUtils.Info("About to send:"))
-				for _, abs := range selected {
-					rel := filepath.Base(abs)
-					st, _ := os.Stat(abs)
-					fmt.Printf("  %s %s %s\n", colorUtils.Separator("•"), colorUtils.Bold(rel), colorUtils.Highlight(fmt.Sprintf("(%d bytes)", st.Size())))
+				for _, a := range selected {
+					size, _ := artifactSize(a)
+					fmt.Printf("  %s %s %s\n", colorUtils.Separator("•"), colorUtils.Bold(a.Rel), colorUtils.Highlight(fmt.Sprintf("(%d bytes)", size)))
 				}
 			}
 
@@ -255,7 +311,7 @@ func NewSendCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&allFlag, "all", false, "send all artifacts (config, db, key)")
 	cmd.Flags().BoolVar(&sendConfigFlag, "send-config", false, "include cluster_config.toml")
 	cmd.Flags().BoolVar(&sendDBFlag, "send-db", false, "include clusterData.db")
-	cmd.Flags().BoolVar(&sendKeyFlag, "send-key", false, "include gns3key")
+	cmd.Flags().BoolVar(&sendKeyFlag, "send-key", false, "include the stored logins of the configured key store")
 	cmd.Flags().BoolVar(&yesFlag, "yes", false, "assume yes for all prompts (non-interactive)")
 	return cmd
 }
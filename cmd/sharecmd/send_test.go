@@ -0,0 +1,96 @@
+package sharecmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+	"github.com/stefanistkuhl/gns3util/pkg/authentication"
+	"github.com/stefanistkuhl/gns3util/pkg/sharing/transport"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/pathUtils"
+)
+
+func TestKeyArtifact(t *testing.T) {
+	homedir.DisableCache = true
+	t.Setenv("HOME", t.TempDir())
+	src, keyDir := t.TempDir(), t.TempDir()
+	tests := []struct {
+		store   string
+		keyFile string
+		want    transport.Artifact
+	}{
+		{store: authentication.KeyStoreFile, want: transport.Artifact{Rel: "gns3key", Path: filepath.Join(src, "gns3key")}},
+		{store: authentication.KeyStoreFile, keyFile: keyDir, want: transport.Artifact{Rel: "gns3key", Path: filepath.Join(keyDir, "gns3key")}},
+		{store: authentication.KeyStoreFile, keyFile: filepath.Join(keyDir, "lab"), want: transport.Artifact{Rel: "gns3key", Path: filepath.Join(keyDir, "lab")}},
+		{store: authentication.KeyStoreEncrypted, want: transport.Artifact{Rel: "gns3key.enc", Path: filepath.Join(src, "gns3key.enc")}},
+		{store: authentication.KeyStoreEncrypted, keyFile: keyDir, want: transport.Artifact{Rel: "gns3key.enc", Path: filepath.Join(keyDir, "gns3key.enc")}},
+	}
+	for _, tt := range tests {
+		t.Setenv("GNS3_KEY_STORE", tt.store)
+		got, err := keyArtifact(tt.keyFile, src)
+		if err != nil {
+			t.Errorf("%s with key file %q: %v", tt.store, tt.keyFile, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s with key file %q: %+v, want %+v", tt.store, tt.keyFile, got, tt.want)
+		}
+	}
+}
+
+func TestKeyArtifactFromKeyring(t *testing.T) {
+	homedir.DisableCache = true
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("TMPDIR", t.TempDir())
+	store := testutil.FakeKeyring(t)
+	t.Setenv("GNS3_KEY_STORE", authentication.KeyStoreKeyring)
+	keyDir := t.TempDir()
+	keys := []pathUtils.GNS3Key{{ServerURL: "http://gns3:3080", User: "admin", AccessToken: "a.b.c", Default: true}}
+	ks, err := authentication.OpenKeyStore(authentication.KeyStoreKeyring, keyDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Save(keys); err != nil {
+		t.Fatal(err)
+	}
+
+	// the keys of --key-file, not those of the default account
+	got, err := keyArtifact(keyDir, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Rel != "gns3key" || got.Path != "" || !strings.Contains(string(got.Data), "a.b.c") {
+		t.Errorf("artifact %+v, want the keys of %s in memory", got, keyDir)
+	}
+	if size, ok := artifactSize(got); !ok || size != int64(len(got.Data)) {
+		t.Errorf("size %d %v, want %d", size, ok, len(got.Data))
+	}
+	none, err := keyArtifact("", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := artifactSize(none); ok {
+		t.Errorf("the default account has keys: %q", none.Data)
+	}
+
+	// nothing but the fake keyring itself holds the token
+	for _, dir := range []string{os.Getenv("TMPDIR"), os.Getenv("HOME"), keyDir} {
+		err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() || strings.HasPrefix(path, store) {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err == nil && strings.Contains(string(data), "a.b.c") {
+				t.Errorf("%s holds the token", path)
+			}
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+// Package testutil sets up a fake GNS3 controller with a logged in admin
+// and a cluster database for the tests of the commands and packages that
+// need both.
+package testutil
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/fake"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
+	"github.com/stefanistkuhl/gns3util/pkg/authentication"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+)
+
+// Cluster is the single node cluster of a fake controller, recorded the way
+// class create records a server given with --server.
+type Cluster struct {
+	Server    *fake.Server
+	Name      string
+	Cfg       config.GlobalOptions
+	Client    *sdk.Client
+	ClusterID int
+	Nodes     []db.NodeDataAll
+}
+
+// NewCluster starts a fake controller, logs in as its admin and records it
+// as a single node cluster. Every cluster gets its own HOME and with it its
+// own key file and cluster database, so tests using it must not run in
+// parallel.
+func NewCluster(t *testing.T) *Cluster {
+	t.Helper()
+	homedir.DisableCache = true
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GNS3_KEY_STORE", authentication.KeyStoreFile)
+
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+	c := &Cluster{Server: srv, Cfg: config.GlobalOptions{Server: srv.URL}}
+	c.Client = login(t, srv)
+
+	store := openStore(t)
+	defer func() {
+		_ = store.DB.Close()
+	}()
+	c.Name = serverURL(t, srv).Hostname() + "_single_node_cluster"
+	cluster, err := store.CreateCluster(context.Background(), sqlc.CreateClusterParams{Name: c.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.ClusterID = int(cluster.ClusterID)
+	c.addNode(t, store, srv)
+	return c
+}
+
+// AddNode starts another fake controller, logs in as its admin, adds it
+// to the cluster and returns a client for it.
+func (c *Cluster) AddNode(t *testing.T) *sdk.Client {
+	t.Helper()
+	srv := fake.NewServer()
+	t.Cleanup(srv.Close)
+	client := login(t, srv)
+	store := openStore(t)
+	defer func() {
+		_ = store.DB.Close()
+	}()
+	c.addNode(t, store, srv)
+	return client
+}
+
+// login logs in as the admin of srv and saves the key like auth login does.
+func login(t *testing.T, srv *fake.Server) *sdk.Client {
+	t.Helper()
+	cfg := config.GlobalOptions{Server: srv.URL}
+	token, err := authentication.Login(context.Background(), cfg, fake.DefaultUsername, fake.DefaultPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := authentication.SaveAuthData(cfg, token, fake.DefaultUsername); err != nil {
+		t.Fatal(err)
+	}
+	return sdk.NewClient(api.NewSettings(api.WithBaseURL(srv.URL), api.WithToken(*token.AccessToken)))
+}
+
+func openStore(t *testing.T) *db.Store {
+	t.Helper()
+	store, err := db.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+func (c *Cluster) addNode(t *testing.T, store *db.Store, srv *fake.Server) {
+	t.Helper()
+	ctx := context.Background()
+	u := serverURL(t, srv)
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.InsertNode(ctx, sqlc.InsertNodeParams{
+		ClusterID: int64(c.ClusterID),
+		Protocol:  u.Scheme,
+		Host:      u.Hostname(),
+		Port:      int64(port),
+		Weight:    1,
+		AuthUser:  fake.DefaultUsername,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := store.GetNodes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := nodes[len(nodes)-1]
+	c.Nodes = append(c.Nodes, db.NodeDataAll{
+		ID:        int(n.NodeID),
+		ClusterID: int(n.ClusterID),
+		User:      n.AuthUser,
+		Protocol:  n.Protocol,
+		Host:      n.Host,
+		Port:      int(n.Port),
+		Weight:    int(n.Weight),
+		MaxGroups: math.MaxInt32,
+	})
+}
+
+func serverURL(t *testing.T, srv *fake.Server) *url.URL {
+	t.Helper()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// Run executes cmd with args and the global options of the cluster and
+// fails the test when it returns an error.
+func (c *Cluster) Run(t *testing.T, cmd *cobra.Command, args ...string) {
+	t.Helper()
+	cmd.SetArgs(args)
+	cmd.SetContext(config.WithGlobalOptions(context.Background(), c.Cfg))
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("%s %s: %v", cmd.Name(), strings.Join(args, " "), err)
+	}
+}
+
+// GroupMembers returns the sorted usernames of every user group by the name
+// of the group.
+func (c *Cluster) GroupMembers(t *testing.T) map[string][]string {
+	t.Helper()
+	ctx := context.Background()
+	groups, err := c.Client.Groups().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string][]string{}
+	for _, g := range groups {
+		members, err := c.Client.Groups().Members(ctx, g.UserGroupID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, m := range members {
+			names = append(names, m.Username)
+		}
+		slices.Sort(names)
+		out[g.Name] = names
+	}
+	return out
+}
+
+// User looks a user up by name.
+func (c *Cluster) User(t *testing.T, username string) (schemas.UserResponse, bool) {
+	t.Helper()
+	users, err := c.Client.Users().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range users {
+		if u.Username == username {
+			return u, true
+		}
+	}
+	return schemas.UserResponse{}, false
+}
+
+// TemplateProject creates a project with a VPCS node to create exercises
+// from.
+func (c *Cluster) TemplateProject(t *testing.T, name string) schemas.ProjectResponse {
+	t.Helper()
+	ctx := context.Background()
+	p, err := c.Client.Projects().Create(ctx, schemas.ProjectCreate{Name: &name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := c.Client.Templates().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tmpl := range templates {
+		if tmpl.TemplateType == "vpcs" {
+			if _, err := c.Client.Nodes(p.ProjectID).CreateFromTemplate(ctx, tmpl.TemplateID, 0, 0); err != nil {
+				t.Fatal(err)
+			}
+			return p
+		}
+	}
+	t.Fatal("the controller has no VPCS template")
+	return p
+}
+
+// Projects returns the projects of the controller by name.
+func (c *Cluster) Projects(t *testing.T) map[string]schemas.ProjectResponse {
+	t.Helper()
+	projects, err := c.Client.Projects().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := map[string]schemas.ProjectResponse{}
+	for _, p := range projects {
+		out[p.Name] = p
+	}
+	return out
+}
+
+// Exercises returns the exercise rows of a group of a class.
+func (c *Cluster) Exercises(t *testing.T, className, groupName string) []sqlc.Exercise {
+	t.Helper()
+	ctx := context.Background()
+	store := openStore(t)
+	defer func() {
+		_ = store.DB.Close()
+	}()
+	groupID, err := store.GetGroupIDForClass(ctx, sqlc.GetGroupIDForClassParams{ClusterID: int64(c.ClusterID), Name: className, Name_2: groupName})
+	if err != nil {
+		t.Fatalf("group %s of class %s: %v", groupName, className, err)
+	}
+	exercises, err := store.GetExercisesForGroup(ctx, groupID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exercises
+}
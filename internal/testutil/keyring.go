@@ -0,0 +1,56 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeSecretTool stands in for secret-tool. It keeps each account in a file
+// of $FAKE_KEYRING named after the hex of the account and logs the
+// arguments of every call to $FAKE_KEYRING/args.
+const fakeSecretTool = `#!/bin/sh
+store="$FAKE_KEYRING"
+echo "$@" >> "$store/args"
+cmd=$1
+shift
+while [ $# -gt 0 ]; do
+	case $1 in
+	--label) shift 2 ;;
+	account) account=$2; shift 2 ;;
+	*) shift ;;
+	esac
+done
+f="$store/$(printf %s "$account" | od -An -tx1 | tr -d ' \n')"
+case $cmd in
+store) cat > "$f" ;;
+lookup) [ -f "$f" ] || exit 1; cat "$f" ;;
+clear) rm -f "$f" ;;
+esac
+`
+
+// FakeKeyring puts a fake secret-tool first in PATH, so the keyring
+// functions work without a Secret Service, and returns the directory it
+// stores its entries in. It skips the test where the keyring is not driven
+// through secret-tool.
+func FakeKeyring(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("the fake keyring replaces secret-tool, which is only used on linux")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "bin")
+	store := filepath.Join(dir, "store")
+	for _, d := range []string{bin, store} {
+		if err := os.Mkdir(d, 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(bin, "secret-tool"), []byte(fakeSecretTool), 0o700); err != nil { // #nosec G306
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_KEYRING", store)
+	return store
+}
@@ -0,0 +1,35 @@
+package testutil
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+// Stdout runs f and returns what it printed to stdout. Tests using it must
+// not run in parallel.
+func Stdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan string, 1)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	stdout := os.Stdout
+	os.Stdout = w
+	// restores stdout when f fails the test too
+	defer func() {
+		os.Stdout = stdout
+		_ = w.Close()
+	}()
+	f()
+	os.Stdout = stdout
+	_ = w.Close()
+	out := <-done
+	_ = r.Close()
+	return out
+}
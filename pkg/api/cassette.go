@@ -0,0 +1,426 @@
+package api
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+type CassetteMode int
+
+const (
+	CassetteRecord CassetteMode = iota + 1
+	CassetteReplay
+)
+
+// Redacted replaces tokens and passwords in recorded interactions.
+const Redacted = "REDACTED"
+
+// ErrCassetteMiss is returned in replay mode for requests that have no
+// recorded response left.
+var ErrCassetteMiss = errors.New("no recorded response in cassette")
+
+// sensitiveKeys are JSON fields, form fields and query parameters whose
+// values never end up in a cassette.
+var sensitiveKeys = []string{"password", "access_token", "token"}
+
+var uuidPattern = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+// Interaction is a single request and the response the server sent for it,
+// stored as one JSON file in the cassette directory.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest holds the path and query of the request without scheme
+// and host so a cassette can be replayed against any --server value.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	RecordedBody
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	RecordedBody
+}
+
+// RecordedBody stores text bodies as is and everything else, like project
+// archives, base64 encoded.
+type RecordedBody struct {
+	Body   string `json:"body,omitempty"`
+	Base64 bool   `json:"base64,omitempty"`
+}
+
+func newRecordedBody(b []byte) RecordedBody {
+	if utf8.Valid(b) {
+		return RecordedBody{Body: string(b)}
+	}
+	return RecordedBody{Body: base64.StdEncoding.EncodeToString(b), Base64: true}
+}
+
+func (b RecordedBody) bytes() ([]byte, error) {
+	if b.Base64 {
+		return base64.StdEncoding.DecodeString(b.Body)
+	}
+	return []byte(b.Body), nil
+}
+
+// Cassette records every request made by GNS3ApiClient into a directory
+// or serves a previously recorded directory back without touching the
+// network.
+type Cassette struct {
+	dir  string
+	mode CassetteMode
+
+	mu           sync.Mutex
+	seq          int
+	interactions []Interaction
+	used         []bool
+}
+
+// RecordCassette creates dir if needed. Interactions are numbered after
+// the ones already in dir, so several commands can be recorded into the
+// same cassette.
+func RecordCassette(dir string) (*Cassette, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create cassette dir: %w", err)
+	}
+	files, err := cassetteFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Cassette{dir: dir, mode: CassetteRecord, seq: len(files)}, nil
+}
+
+// ReplayCassette loads all interactions recorded in dir.
+func ReplayCassette(dir string) (*Cassette, error) {
+	files, err := cassetteFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no interactions found in cassette %s", dir)
+	}
+	c := &Cassette{dir: dir, mode: CassetteReplay}
+	for _, f := range files {
+		data, err := os.ReadFile(f) // #nosec G304
+		if err != nil {
+			return nil, fmt.Errorf("read cassette: %w", err)
+		}
+		var in Interaction
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, fmt.Errorf("parse cassette file %s: %w", filepath.Base(f), err)
+		}
+		c.interactions = append(c.interactions, in)
+	}
+	c.used = make([]bool, len(c.interactions))
+	return c, nil
+}
+
+func cassetteFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+func (c *Cassette) Mode() CassetteMode { return c.mode }
+
+// Transport wraps next. In replay mode next is never used.
+func (c *Cassette) Transport(next http.RoundTripper) http.RoundTripper {
+	return &cassetteTransport{cassette: c, next: next}
+}
+
+type cassetteTransport struct {
+	cassette *Cassette
+	next     http.RoundTripper
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.cassette.mode == CassetteReplay {
+		return t.cassette.replay(req)
+	}
+	return t.cassette.record(req, t.next)
+}
+
+func (c *Cassette) record(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	in := Interaction{Request: RecordedRequest{
+		Method:       req.Method,
+		URL:          redactURI(req.URL.RequestURI()),
+		Header:       redactHeader(req.Header),
+		RecordedBody: newRecordedBody(redactBody(req.Header.Get("Content-Type"), reqBody)),
+	}}
+
+	resp, rtErr := next.RoundTrip(req)
+	if rtErr != nil {
+		in.Response.Error = rtErr.Error()
+	} else {
+		respBody, readErr := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if readErr != nil {
+			return nil, readErr
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+		in.Response.StatusCode = resp.StatusCode
+		in.Response.Header = redactHeader(resp.Header)
+		// redaction changes the body length
+		in.Response.Header.Del("Content-Length")
+		in.Response.RecordedBody = newRecordedBody(redactBody(resp.Header.Get("Content-Type"), respBody))
+	}
+
+	if err := c.save(in); err != nil {
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		return nil, err
+	}
+	return resp, rtErr
+}
+
+func (c *Cassette) save(in Interaction) error {
+	data, err := json.MarshalIndent(in, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode interaction: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	name := fmt.Sprintf("%04d-%s-%s.json", c.seq, strings.ToLower(in.Request.Method), slug(in.Request.URL))
+	if err := os.WriteFile(filepath.Join(c.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	return nil
+}
+
+// replay serves the first unused interaction with the same method and URL.
+// UUIDs are ignored when comparing URLs because commands generate new ids
+// for things like imported projects on every run.
+func (c *Cassette) replay(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	key := matchKey(req.Method, redactURI(req.URL.RequestURI()))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, in := range c.interactions {
+		if c.used[i] || matchKey(in.Request.Method, in.Request.URL) != key {
+			continue
+		}
+		c.used[i] = true
+		if in.Response.Error != "" {
+			return nil, errors.New(in.Response.Error)
+		}
+		body, err := in.Response.bytes()
+		if err != nil {
+			return nil, fmt.Errorf("decode recorded body: %w", err)
+		}
+		header := in.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrCassetteMiss, req.Method, redactURI(req.URL.RequestURI()))
+}
+
+func matchKey(method, uri string) string {
+	return method + " " + uuidPattern.ReplaceAllString(uri, "{uuid}")
+}
+
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = rc.Close()
+		}()
+		return io.ReadAll(rc)
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// redactURI redacts the sensitive query parameters of a request URI, like
+// the token of the notification stream. Replay compares the redacted URIs.
+func redactURI(uri string) string {
+	path, rawQuery, ok := strings.Cut(uri, "?")
+	if !ok {
+		return uri
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return uri
+	}
+	changed := false
+	for _, k := range sensitiveKeys {
+		if query.Has(k) {
+			query.Set(k, Redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return uri
+	}
+	return path + "?" + query.Encode()
+}
+
+func redactHeader(h http.Header) http.Header {
+	out := h.Clone()
+	if v := out.Get("Authorization"); v != "" {
+		scheme, _, _ := strings.Cut(v, " ")
+		out.Set("Authorization", scheme+" "+Redacted)
+	}
+	for _, k := range []string{"Cookie", "Set-Cookie"} {
+		if out.Get(k) != "" {
+			out.Set(k, Redacted)
+		}
+	}
+	return out
+}
+
+func redactBody(contentType string, body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		changed := false
+		for _, k := range sensitiveKeys {
+			if form.Has(k) {
+				form.Set(k, Redacted)
+				changed = true
+			}
+		}
+		if !changed {
+			return body
+		}
+		return []byte(form.Encode())
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if dec.Decode(&v) != nil || !redactJSON(v) {
+		return body
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func redactJSON(v any) bool {
+	changed := false
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if _, isString := val.(string); isString && slices.Contains(sensitiveKeys, k) {
+				t[k] = Redacted
+				changed = true
+				continue
+			}
+			changed = redactJSON(val) || changed
+		}
+	case []any:
+		for _, val := range t {
+			changed = redactJSON(val) || changed
+		}
+	}
+	return changed
+}
+
+func slug(uri string) string {
+	path, _, _ := strings.Cut(uri, "?")
+	path = strings.TrimPrefix(path, API_VERSION)
+	var b strings.Builder
+	for _, r := range strings.Trim(path, "/") {
+		if r < utf8.RuneSelf && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	s := b.String()
+	if len(s) > 60 {
+		s = s[:60]
+	}
+	if s == "" {
+		s = "root"
+	}
+	return s
+}
+
+var (
+	activeMu       sync.RWMutex
+	activeCassette *Cassette
+)
+
+// UseCassette routes every client created afterwards through c. A nil
+// cassette restores direct requests.
+func UseCassette(c *Cassette) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	activeCassette = c
+}
+
+// Replaying reports whether requests are served from a cassette.
+func Replaying() bool {
+	activeMu.RLock()
+	defer activeMu.RUnlock()
+	return activeCassette != nil && activeCassette.mode == CassetteReplay
+}
+
+func currentCassette() *Cassette {
+	activeMu.RLock()
+	defer activeMu.RUnlock()
+	return activeCassette
+}
@@ -0,0 +1,222 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/fake"
+)
+
+// useCassette routes the clients created by the test through c.
+func useCassette(t *testing.T, c *api.Cassette) {
+	t.Helper()
+	api.UseCassette(c)
+	t.Cleanup(func() { api.UseCassette(nil) })
+}
+
+func TestCassetteRedaction(t *testing.T) {
+	const password = "bob-secret1"
+
+	tests := []struct {
+		name string
+		// handler serves the request instead of a fake controller
+		handler     http.Handler
+		token       bool
+		method      api.HTTPMethod
+		path        string
+		contentType string
+		data        string
+		// secret returns the value that must not end up in the cassette,
+		// from the live response body if it comes from the server
+		secret func(token string, body []byte) string
+		// recorded returns the redacted value from the interaction
+		recorded func(in api.Interaction) string
+	}{
+		{
+			name:   "Authorization",
+			token:  true,
+			method: api.GET,
+			path:   "/projects",
+			secret: func(token string, _ []byte) string { return token },
+			recorded: func(in api.Interaction) string {
+				return in.Request.Header.Get("Authorization")
+			},
+		},
+		{
+			name:   "password",
+			token:  true,
+			method: api.POST,
+			path:   "/access/users",
+			data:   `{"username":"bob","password":"` + password + `"}`,
+			secret: func(string, []byte) string { return password },
+			recorded: func(in api.Interaction) string {
+				return jsonField(t, in.Request.Body, "password")
+			},
+		},
+		{
+			name:        "form password",
+			method:      api.POST,
+			path:        "/access/users/login",
+			contentType: "application/x-www-form-urlencoded",
+			data:        "username=" + fake.DefaultUsername + "&password=" + fake.DefaultPassword + "&grant_type=password",
+			secret:      func(string, []byte) string { return "password=" + fake.DefaultPassword },
+			recorded: func(in api.Interaction) string {
+				_, value, _ := strings.Cut(in.Request.Body, "password=")
+				value, _, _ = strings.Cut(value, "&")
+				return value
+			},
+		},
+		{
+			name:   "access_token",
+			method: api.POST,
+			path:   "/access/users/authenticate",
+			data:   `{"username":"` + fake.DefaultUsername + `","password":"` + fake.DefaultPassword + `"}`,
+			secret: func(_ string, body []byte) string { return jsonField(t, string(body), "access_token") },
+			recorded: func(in api.Interaction) string {
+				return jsonField(t, in.Response.Body, "access_token")
+			},
+		},
+		{
+			name: "token",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"name":"link","nested":{"token":"console-secret"}}`))
+			}),
+			method: api.GET,
+			path:   "/projects/console",
+			secret: func(string, []byte) string { return "console-secret" },
+			recorded: func(in api.Interaction) string {
+				var v struct {
+					Nested struct {
+						Token string `json:"token"`
+					} `json:"nested"`
+				}
+				if err := json.Unmarshal([]byte(in.Response.Body), &v); err != nil {
+					t.Fatal(err)
+				}
+				return v.Nested.Token
+			},
+		},
+		{
+			name: "query token",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`[]`))
+			}),
+			method: api.GET,
+			path:   "/notifications?token=query-secret",
+			secret: func(string, []byte) string { return "query-secret" },
+			recorded: func(in api.Interaction) string {
+				u, err := url.Parse(in.Request.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return u.Query().Get("token")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.handler
+			var token string
+			if handler == nil {
+				c := fake.NewController()
+				handler = c.Handler()
+				if tt.token {
+					var err error
+					if token, err = c.Token(fake.DefaultUsername); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			srv := httptest.NewServer(handler)
+			dir := t.TempDir()
+			request := func() ([]byte, *http.Response, error) {
+				settings := api.NewSettings(api.WithBaseURL(srv.URL), api.WithToken(token), api.WithRetries(0))
+				opts := api.NewRequestOptions(settings).WithURL(tt.path).WithMethod(tt.method)
+				if tt.data != "" {
+					opts = opts.WithData(tt.data)
+				}
+				if tt.contentType != "" {
+					opts = opts.WithHeader("Content-Type", tt.contentType)
+				}
+				return api.NewGNS3Client(settings).Do(context.Background(), opts)
+			}
+
+			recorder, err := api.RecordCassette(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			useCassette(t, recorder)
+			liveBody, liveResp, err := request()
+			if err != nil {
+				t.Fatalf("record: %v", err)
+			}
+			secret := tt.secret(token, liveBody)
+			if secret == "" {
+				t.Fatal("no secret to look for")
+			}
+
+			files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 {
+				t.Fatalf("recorded %d interactions, want 1", len(files))
+			}
+			data, err := os.ReadFile(files[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), secret) {
+				t.Fatalf("cassette contains the secret:\n%s", data)
+			}
+			var in api.Interaction
+			if err := json.Unmarshal(data, &in); err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.recorded(in); !strings.HasSuffix(got, api.Redacted) {
+				t.Errorf("recorded %q, want %s", got, api.Redacted)
+			}
+
+			// replay must not need the server
+			srv.Close()
+			player, err := api.ReplayCassette(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			useCassette(t, player)
+			body, resp, err := request()
+			if err != nil {
+				t.Fatalf("replay: %v", err)
+			}
+			if resp.StatusCode != liveResp.StatusCode {
+				t.Errorf("replayed status %d, want %d", resp.StatusCode, liveResp.StatusCode)
+			}
+			if string(body) != in.Response.Body {
+				t.Errorf("replayed body %s, want the recorded %s", body, in.Response.Body)
+			}
+			if strings.Contains(string(body), secret) {
+				t.Errorf("replayed body contains the secret: %s", body)
+			}
+		})
+	}
+}
+
+func jsonField(t *testing.T, body, field string) string {
+	t.Helper()
+	var v map[string]any
+	if err := json.Unmarshal([]byte(body), &v); err != nil {
+		t.Fatalf("parse %s: %v", body, err)
+	}
+	s, _ := v[field].(string)
+	return s
+}
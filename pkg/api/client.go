@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -30,14 +33,21 @@ type Settings struct {
 	Token   string
 	Verify  bool
 	Timeout time.Duration
+	Retry   RetryPolicy
+	Reauth  Reauthenticator
 }
 
+// Reauthenticator logs in again after the server rejected the token and
+// returns the new access token.
+type Reauthenticator func(ctx context.Context) (string, error)
+
 type requestOptions struct {
 	settings Settings
 	URL      string
 	header   http.Header
 	method   HTTPMethod
 	data     string
+	body     func() (io.Reader, error)
 	stream   bool
 	params   map[string]string
 }
@@ -45,6 +55,9 @@ type requestOptions struct {
 type GNS3ApiClient struct {
 	settings Settings
 	client   *http.Client
+
+	mu    sync.Mutex
+	token string
 }
 
 func NewSettings(opts ...SettingOption) Settings {
@@ -53,6 +66,7 @@ func NewSettings(opts ...SettingOption) Settings {
 		Token:   "",
 		Verify:  true,
 		Timeout: DefaultTimeout,
+		Retry:   DefaultRetryPolicy(),
 	}
 	for _, opt := range opts {
 		opt(&s)
@@ -65,10 +79,14 @@ func NewGNS3Client(settings Settings) *GNS3ApiClient {
 	if !settings.Verify {
 		tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402
 	}
+	var rt http.RoundTripper = tr
+	if c := currentCassette(); c != nil {
+		rt = c.Transport(tr)
+	}
 	return &GNS3ApiClient{
 		settings: settings,
 		client: &http.Client{
-			Transport: tr,
+			Transport: rt,
 			Timeout:   settings.Timeout,
 		},
 	}
@@ -106,6 +124,14 @@ func WithVerify(verify bool) SettingOption {
 	}
 }
 
+// WithReauthenticator makes the client log in again with fn once when a
+// request fails with 401 and repeat the request with the new token.
+func WithReauthenticator(fn Reauthenticator) SettingOption {
+	return func(s *Settings) {
+		s.Reauth = fn
+	}
+}
+
 func WithTimeout(d time.Duration) SettingOption {
 	return func(s *Settings) {
 		if d > 0 {
@@ -129,21 +155,40 @@ func (r *requestOptions) WithData(body string) *requestOptions {
 	return r
 }
 
+// WithBody sends what open returns as the request body instead of the data,
+// for uploads too large to keep in memory. open is called again for every
+// attempt. Uploads are bounded by ctx only, not by the settings timeout.
+func (r *requestOptions) WithBody(open func() (io.Reader, error)) *requestOptions {
+	r.body = open
+	return r
+}
+
 func (r *requestOptions) WithParam(key, val string) *requestOptions {
 	r.params[key] = val
 	return r
 }
 
+func (r *requestOptions) WithHeader(key, val string) *requestOptions {
+	r.header.Set(key, val)
+	return r
+}
+
+// WithStream leaves the response body to the caller, who has to close it.
 func (r *requestOptions) WithStream() *requestOptions {
 	r.stream = true
 	return r
 }
 
-func (c *GNS3ApiClient) Do(opts *requestOptions) ([]byte, *http.Response, error) {
+// Do sends the request and returns the response body. ctx bounds the whole
+// call including retries; every attempt additionally gets the settings
+// timeout, streams only until their response headers arrive and uploads
+// not at all. Failed attempts are retried according to the settings retry
+// policy.
+func (c *GNS3ApiClient) Do(ctx context.Context, opts *requestOptions) ([]byte, *http.Response, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	fullURL := c.settings.BaseURL + opts.URL
-	ctx, cancel := context.WithTimeout(context.Background(), c.settings.Timeout)
-	defer cancel()
-	defer cancel()
 
 	if len(opts.params) > 0 {
 		q := url.Values{}
@@ -153,49 +198,66 @@ func (c *GNS3ApiClient) Do(opts *requestOptions) ([]byte, *http.Response, error)
 		fullURL += "?" + q.Encode()
 	}
 
-	if opts.stream {
-		streamClient := *c.client
-		streamClient.Timeout = 0
-
-		req, err := http.NewRequestWithContext(ctx, string(opts.method), fullURL, bytes.NewBufferString(opts.data))
-		if err != nil {
-			return nil, nil, err
+	policy := c.settings.Retry
+	reauthed := false
+	for attempt := 0; ; attempt++ {
+		body, resp, err := c.doOnce(ctx, opts, fullURL)
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized && c.settings.Reauth != nil && !reauthed {
+			reauthed = true
+			if opts.stream {
+				_ = resp.Body.Close()
+			}
+			token, reauthErr := c.settings.Reauth(ctx)
+			if reauthErr != nil {
+				return body, resp, errors.Join(err, fmt.Errorf("re-authentication failed: %w", reauthErr))
+			}
+			c.setToken(token)
+			attempt--
+			continue
 		}
-		req.Header = opts.header
-
-		resp, err := streamClient.Do(req)
-		if err != nil {
-			return nil, nil, err
+		if attempt >= policy.MaxRetries || !policy.allowsMethod(opts.method) || !shouldRetry(ctx, policy, resp, err) {
+			return body, resp, err
+		}
+		if opts.stream && resp != nil {
+			_ = resp.Body.Close()
 		}
 
-		if c.settings.Timeout > 0 {
-			go func() {
-				<-time.After(c.settings.Timeout)
-				_ = resp.Body.Close()
-			}()
+		if sleepErr := sleepContext(ctx, policy.delay(attempt, resp)); sleepErr != nil {
+			return nil, nil, sleepErr
 		}
+	}
+}
 
-		return nil, resp, nil
+func shouldRetry(ctx context.Context, policy RetryPolicy, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
 	}
+	if resp != nil && policy.retryableStatus(resp.StatusCode) {
+		return true
+	}
+	return isTransientErr(ctx, err)
+}
+
+func (c *GNS3ApiClient) doOnce(parent context.Context, opts *requestOptions, fullURL string) ([]byte, *http.Response, error) {
+	if opts.stream {
+		return c.doStream(parent, opts, fullURL)
+	}
+	ctx, cancel := parent, context.CancelFunc(func() {})
+	if c.settings.Timeout > 0 && opts.body == nil {
+		ctx, cancel = context.WithTimeout(parent, c.settings.Timeout)
+	}
+	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx,
-		string(opts.method),
-		fullURL,
-		bytes.NewBufferString(opts.data),
-	)
+	req, err := c.newRequest(ctx, opts, fullURL)
 	if err != nil {
 		return nil, nil, err
 	}
-	req.Header = opts.header
-
 	resp, err := c.client.Do(req)
 	if err != nil {
 		return nil, nil, err
 	}
 	defer func() {
-		if resp != nil {
-			_ = resp.Body.Close()
-		}
+		_ = resp.Body.Close()
 	}()
 
 	body, err := io.ReadAll(resp.Body)
@@ -204,33 +266,100 @@ func (c *GNS3ApiClient) Do(opts *requestOptions) ([]byte, *http.Response, error)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if resp.StatusCode == 422 {
-			var anyPayload any
-			if err := json.Unmarshal(body, &anyPayload); err == nil {
-				switch v := anyPayload.(type) {
-				case map[string]any:
-					if msg, ok := v["message"]; ok {
-						if b, err := json.MarshalIndent(msg, "", "  "); err == nil {
-							return body, resp, fmt.Errorf("validation error (422):\n%s", string(b))
-						}
-					}
-				case []any:
-					if b, err := json.MarshalIndent(v, "", "  "); err == nil {
-						return body, resp, fmt.Errorf("validation error (422):\n%s", string(b))
-					}
-				}
-			}
-			return body, resp, fmt.Errorf("validation error (422): %s", string(body))
+		return body, resp, statusError(resp, body)
+	}
+
+	return body, resp, nil
+}
+
+// doStream sends a request whose body the caller reads. The settings
+// timeout only bounds the wait for the response headers, reading an export
+// of a large project may take much longer and is bounded by ctx alone.
+func (c *GNS3ApiClient) doStream(parent context.Context, opts *requestOptions, fullURL string) ([]byte, *http.Response, error) {
+	ctx, cancel := context.WithCancel(parent)
+	req, err := c.newRequest(ctx, opts, fullURL)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+
+	var timedOut atomic.Bool
+	stop := func() bool { return true }
+	if c.settings.Timeout > 0 {
+		timer := time.AfterFunc(c.settings.Timeout, func() {
+			timedOut.Store(true)
+			cancel()
+		})
+		stop = timer.Stop
+	}
+	streamClient := *c.client
+	streamClient.Timeout = 0
+	resp, err := streamClient.Do(req)
+	if !stop() && timedOut.Load() {
+		if err == nil {
+			_ = resp.Body.Close()
 		}
-		if resp.StatusCode == 403 {
-			var errorMsg map[string]string
-			if err := json.Unmarshal(body, &errorMsg); err == nil {
-				return body, resp, fmt.Errorf("%s", errorMsg["message"])
-			}
-			return body, resp, fmt.Errorf("unknown forbidden 403 error. ")
+		cancel()
+		return nil, nil, fmt.Errorf("no response within %s: %w", c.settings.Timeout, context.DeadlineExceeded)
+	}
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer cancel()
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		return body, resp, statusError(resp, body)
+	}
+	// closing the body releases the context
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return nil, resp, nil
+}
+
+func (c *GNS3ApiClient) newRequest(ctx context.Context, opts *requestOptions, fullURL string) (*http.Request, error) {
+	var body io.Reader = strings.NewReader(opts.data)
+	if opts.body != nil {
+		var err error
+		if body, err = opts.body(); err != nil {
+			return nil, err
 		}
-		return body, resp, fmt.Errorf("bad status %d: %s", resp.StatusCode, body)
 	}
+	req, err := http.NewRequestWithContext(ctx, string(opts.method), fullURL, body)
+	if err != nil {
+		if c, ok := body.(io.Closer); ok {
+			_ = c.Close()
+		}
+		return nil, err
+	}
+	req.Header = opts.header.Clone()
+	if token := c.currentToken(); token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	return req, nil
+}
 
-	return body, resp, nil
+// currentToken returns the token obtained by re-authenticating, requests
+// keep the token of their settings until then.
+func (c *GNS3ApiClient) currentToken() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.token
+}
+
+func (c *GNS3ApiClient) setToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
 }
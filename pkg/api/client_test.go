@@ -0,0 +1,328 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/fake"
+)
+
+// flaky answers the first failures requests with 503 and every further one
+// with an empty JSON list.
+func flaky(failures int32) (http.Handler, *atomic.Int32) {
+	var hits atomic.Int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		if hits.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, "[]")
+	}), &hits
+}
+
+func TestDoRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   api.HTTPMethod
+		data     string
+		retries  int
+		failures int32
+		hits     int32
+		wantErr  bool
+	}{
+		{name: "get recovers", method: api.GET, retries: 3, failures: 2, hits: 3},
+		{name: "get gives up", method: api.GET, retries: 1, failures: 5, hits: 2, wantErr: true},
+		{name: "retries disabled", method: api.GET, retries: 0, failures: 1, hits: 1, wantErr: true},
+		{name: "delete recovers", method: api.DELETE, retries: 3, failures: 1, hits: 2},
+		{name: "post is not repeated", method: api.POST, data: `{"name":"lab"}`, retries: 3, failures: 1, hits: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, hits := flaky(tt.failures)
+			srv := httptest.NewServer(handler)
+			defer srv.Close()
+
+			policy := api.DefaultRetryPolicy()
+			policy.BaseDelay = time.Millisecond
+			policy.MaxDelay = 5 * time.Millisecond
+			settings := api.NewSettings(
+				api.WithBaseURL(srv.URL),
+				api.WithRetryPolicy(policy),
+				api.WithRetries(tt.retries),
+			)
+			opts := api.NewRequestOptions(settings).WithURL("/projects").WithMethod(tt.method)
+			if tt.data != "" {
+				opts = opts.WithData(tt.data)
+			}
+			_, _, err := api.NewGNS3Client(settings).Do(context.Background(), opts)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := hits.Load(); got != tt.hits {
+				t.Errorf("sent %d requests, want %d", got, tt.hits)
+			}
+		})
+	}
+}
+
+func TestDoCancelStopsRetrying(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	settings := api.NewSettings(api.WithBaseURL(srv.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, _, err := api.NewGNS3Client(settings).Do(ctx, api.NewRequestOptions(settings).WithURL("/projects"))
+	if err == nil {
+		t.Fatal("want an error once the context is done")
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("sent %d requests, want 1", got)
+	}
+}
+
+func TestDoRetrySettings(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		codes      []int
+		hits       int32
+	}{
+		{name: "retry-after is capped", status: http.StatusServiceUnavailable, retryAfter: "3600", hits: 2},
+		{name: "retry-after date is capped", status: http.StatusTooManyRequests, retryAfter: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), hits: 2},
+		{name: "configured status is retried", status: http.StatusInternalServerError, codes: []int{http.StatusInternalServerError}, hits: 2},
+		{name: "default status is no longer retried", status: http.StatusServiceUnavailable, codes: []int{http.StatusInternalServerError}, hits: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if hits.Add(1) == 1 {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.status)
+					return
+				}
+				_, _ = io.WriteString(w, "[]")
+			}))
+			defer srv.Close()
+
+			settings := api.NewSettings(
+				api.WithBaseURL(srv.URL),
+				api.WithRetryDelays(time.Millisecond, 20*time.Millisecond),
+				api.WithRetryStatusCodes(tt.codes),
+			)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, _, err := api.NewGNS3Client(settings).Do(ctx, api.NewRequestOptions(settings).WithURL("/projects"))
+			if got := hits.Load(); got != tt.hits {
+				t.Fatalf("sent %d requests, want %d: %v", got, tt.hits, err)
+			}
+			if (err != nil) != (tt.hits == 1) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestDoStatusErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		want    error
+		message string
+		// detail is the message taken from a FastAPI detail field
+		detail string
+	}{
+		{name: "ok", status: http.StatusOK, body: `{}`},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `{"message":"Could not validate credentials"}`, want: api.ErrUnauthorized},
+		{name: "not found", status: http.StatusNotFound, body: `{"message":"Project ID x doesn't exist"}`, want: api.ErrNotFound},
+		{name: "conflict", status: http.StatusConflict, body: `{"message":"Project 'lab' already exists"}`, want: api.ErrConflict},
+		{name: "forbidden", status: http.StatusForbidden, body: `{"message":"Built-in role cannot be deleted"}`, want: api.ErrForbidden, message: "Built-in role cannot be deleted"},
+		{name: "validation list", status: http.StatusUnprocessableEntity, body: `[{"loc":["body","password"],"msg":"too short"}]`, want: api.ErrValidation, message: "password"},
+		{name: "validation detail", status: http.StatusUnprocessableEntity, body: `{"detail":[{"loc":["body","username"],"msg":"Field required"}]}`, want: api.ErrValidation, message: "username"},
+		{name: "unauthorized detail", status: http.StatusUnauthorized, body: `{"detail":"Authentication was unsuccessful."}`, want: api.ErrUnauthorized, detail: "Authentication was unsuccessful."},
+		{name: "validation message", status: http.StatusUnprocessableEntity, body: `{"message":{"name":"required"}}`, want: api.ErrValidation, message: "required"},
+		{name: "not json", status: http.StatusInternalServerError, body: "boom", message: "bad status 500: boom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			settings := api.NewSettings(api.WithBaseURL(srv.URL), api.WithRetries(0))
+			body, _, err := api.NewGNS3Client(settings).Do(context.Background(), api.NewRequestOptions(settings).WithURL("/projects"))
+			if tt.status == http.StatusOK {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var apiErr *api.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("got %T %v, want *api.APIError", err, err)
+			}
+			if apiErr.StatusCode != tt.status || string(apiErr.Body) != tt.body || string(body) != tt.body {
+				t.Errorf("error %d %s, want %d %s", apiErr.StatusCode, apiErr.Body, tt.status, tt.body)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+			if tt.message != "" && !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("error %q does not contain %q", err, tt.message)
+			}
+			if tt.detail != "" && apiErr.Message != tt.detail {
+				t.Errorf("message %q, want %q", apiErr.Message, tt.detail)
+			}
+		})
+	}
+}
+
+func TestDoReauthenticates(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		reauth  func(calls *atomic.Int32) api.Reauthenticator
+		want    error
+		calls   int32
+		wantErr bool
+	}{
+		{
+			name: "new token",
+			reauth: func(calls *atomic.Int32) api.Reauthenticator {
+				return func(context.Context) (string, error) {
+					calls.Add(1)
+					return srv.Controller.Token(fake.DefaultUsername)
+				}
+			},
+			calls: 1,
+		},
+		{
+			name: "login fails",
+			reauth: func(calls *atomic.Int32) api.Reauthenticator {
+				return func(context.Context) (string, error) {
+					calls.Add(1)
+					return "", errors.New("no password")
+				}
+			},
+			want:    api.ErrUnauthorized,
+			calls:   1,
+			wantErr: true,
+		},
+		{
+			name: "token still rejected",
+			reauth: func(calls *atomic.Int32) api.Reauthenticator {
+				return func(context.Context) (string, error) {
+					calls.Add(1)
+					return "still-garbage", nil
+				}
+			},
+			want:    api.ErrUnauthorized,
+			calls:   1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			settings := api.NewSettings(
+				api.WithBaseURL(srv.URL),
+				api.WithToken("expired"),
+				api.WithReauthenticator(tt.reauth(&calls)),
+			)
+			c := api.NewGNS3Client(settings)
+			_, _, err := c.Do(context.Background(), api.NewRequestOptions(settings).WithURL("/projects"))
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+			if got := calls.Load(); got != tt.calls {
+				t.Errorf("re-authenticated %d times, want %d", got, tt.calls)
+			}
+		})
+	}
+}
+
+func TestDoStreamTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+		err     error
+	}{
+		{
+			name: "slow body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				for _, chunk := range []string{"a", "b", "c"} {
+					_, _ = io.WriteString(w, chunk)
+					w.(http.Flusher).Flush()
+					time.Sleep(timeout)
+				}
+			},
+			want: "abc",
+		},
+		{
+			name: "slow headers",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(4 * timeout):
+				}
+			},
+			err: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			settings := api.NewSettings(api.WithBaseURL(srv.URL), api.WithTimeout(timeout), api.WithRetries(0))
+			_, resp, err := api.NewGNS3Client(settings).Do(context.Background(), api.NewRequestOptions(settings).WithURL("/export").WithStream())
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil || string(body) != tt.want {
+				t.Errorf("read %q %v, want %q", body, err, tt.want)
+			}
+		})
+	}
+}
@@ -215,6 +215,14 @@ func (GetEndpoints) ProjectNotifications(projectID string) string {
 	return fmt.Sprintf("/projects/%s/notifications", projectID)
 }
 
+func (GetEndpoints) NotificationsWS() string {
+	return "/notifications/ws"
+}
+
+func (GetEndpoints) ProjectNotificationsWS(projectID string) string {
+	return fmt.Sprintf("/projects/%s/notifications/ws", projectID)
+}
+
 func (GetEndpoints) ProjectExport(projectID string) string {
 	return fmt.Sprintf("/projects/%s/export", projectID)
 }
@@ -140,6 +140,10 @@ func (PostEndpoints) CreateSnapshot(projectID string) string {
 	return fmt.Sprintf("/projects/%s/snapshots", projectID)
 }
 
+func (PostEndpoints) RestoreSnapshot(projectID, snapshotID string) string {
+	return fmt.Sprintf("/projects/%s/snapshots/%s/restore", projectID, snapshotID)
+}
+
 func (PostEndpoints) CreateCompute(connect bool) string {
 	return fmt.Sprintf("/computes?connect=%t", connect)
 }
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation error")
+)
+
+// APIError is returned by GNS3ApiClient.Do for every non 2xx response. Use
+// errors.Is with the Err* sentinels to check for a specific class of error
+// or errors.As to get at the status code and raw body.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	switch e.StatusCode {
+	case http.StatusUnprocessableEntity:
+		if e.Message != "" {
+			return fmt.Sprintf("validation error (422):\n%s", e.Message)
+		}
+		return fmt.Sprintf("validation error (422): %s", string(e.Body))
+	case http.StatusForbidden:
+		if e.Message != "" {
+			return e.Message
+		}
+		return "unknown forbidden 403 error. "
+	}
+	return fmt.Sprintf("bad status %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrValidation:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	}
+	return false
+}
+
+func statusError(resp *http.Response, body []byte) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: body}
+
+	var anyPayload any
+	if err := json.Unmarshal(body, &anyPayload); err != nil {
+		return apiErr
+	}
+	switch v := anyPayload.(type) {
+	case map[string]any:
+		msg, ok := v["message"]
+		if !ok {
+			// FastAPI reports validation and authentication errors as detail
+			msg, ok = v["detail"]
+		}
+		if !ok {
+			break
+		}
+		if s, isString := msg.(string); isString && resp.StatusCode != http.StatusUnprocessableEntity {
+			apiErr.Message = s
+		} else if b, err := json.MarshalIndent(msg, "", "  "); err == nil {
+			apiErr.Message = string(b)
+		}
+	case []any:
+		if resp.StatusCode == http.StatusUnprocessableEntity {
+			if b, err := json.MarshalIndent(v, "", "  "); err == nil {
+				apiErr.Message = string(b)
+			}
+		}
+	}
+	return apiErr
+}
@@ -0,0 +1,1088 @@
+package fake
+
+import (
+	"net/http"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+type user struct {
+	UserID       string  `json:"user_id"`
+	Username     string  `json:"username"`
+	Email        *string `json:"email"`
+	FullName     *string `json:"full_name"`
+	IsActive     bool    `json:"is_active"`
+	IsSuperadmin bool    `json:"is_superadmin"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
+	LastLogin    *string `json:"last_login"`
+	password     string
+}
+
+type group struct {
+	UserGroupID string `json:"user_group_id"`
+	Name        string `json:"name"`
+	IsBuiltin   bool   `json:"is_builtin"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+	members     []string
+}
+
+type privilege struct {
+	PrivilegeID string `json:"privilege_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type role struct {
+	RoleID      string       `json:"role_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	IsBuiltin   bool         `json:"is_builtin"`
+	CreatedAt   string       `json:"created_at"`
+	UpdatedAt   string       `json:"updated_at"`
+	Privileges  []*privilege `json:"privileges"`
+}
+
+type ace struct {
+	ACEID     string  `json:"ace_id"`
+	ACEType   string  `json:"ace_type"`
+	Path      string  `json:"path"`
+	Propagate bool    `json:"propagate"`
+	Allowed   bool    `json:"allowed"`
+	UserID    *string `json:"user_id"`
+	GroupID   *string `json:"group_id"`
+	RoleID    string  `json:"role_id"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+type pool struct {
+	ResourcePoolID string `json:"resource_pool_id"`
+	Name           string `json:"name"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+	resources      []string
+}
+
+type poolResource struct {
+	ResourceID   string `json:"resource_id"`
+	ResourceType string `json:"resource_type"`
+	Name         string `json:"name"`
+}
+
+var privilegeNames = []string{
+	"Project.Allocate", "Project.Audit", "Project.Modify",
+	"Node.Allocate", "Node.Audit", "Node.Modify", "Node.Console", "Node.PowerMgmt",
+	"Link.Allocate", "Link.Audit", "Link.Modify", "Link.Capture",
+	"Drawing.Allocate", "Drawing.Audit", "Drawing.Modify",
+	"Snapshot.Allocate", "Snapshot.Audit", "Snapshot.Restore",
+	"Template.Allocate", "Template.Audit", "Template.Modify",
+	"Compute.Audit", "Image.Audit", "Symbol.Audit", "Appliance.Audit",
+	"User.Allocate", "User.Audit", "User.Modify",
+	"Group.Allocate", "Group.Audit", "Group.Modify",
+	"Role.Allocate", "Role.Audit", "Role.Modify",
+	"ACE.Allocate", "ACE.Audit", "ACE.Modify",
+	"Pool.Allocate", "Pool.Audit", "Pool.Modify",
+}
+
+// builtinRoles maps the roles a fresh 3.x controller ships with to the
+// privilege prefixes they grant.
+var builtinRoles = []struct {
+	name, description string
+	grants            func(string) bool
+}{
+	{"Administrator", "Administrator role", func(string) bool { return true }},
+	{"User", "User role", func(p string) bool {
+		return !strings.HasPrefix(p, "User.") && !strings.HasPrefix(p, "Group.") &&
+			!strings.HasPrefix(p, "Role.") && !strings.HasPrefix(p, "ACE.") &&
+			!strings.HasPrefix(p, "Pool.") && (!strings.HasPrefix(p, "Template.") || p == "Template.Audit")
+	}},
+	{"Auditor", "Role with read only access", func(p string) bool { return strings.HasSuffix(p, ".Audit") }},
+	{"Template manager", "Role to allow users to create and manage templates", func(p string) bool {
+		return strings.HasPrefix(p, "Template.") || p == "Image.Audit" || p == "Symbol.Audit" || p == "Appliance.Audit"
+	}},
+	{"User manager", "Role to allow users to create and manage users and groups", func(p string) bool {
+		return strings.HasPrefix(p, "User.") || strings.HasPrefix(p, "Group.")
+	}},
+	{"ACL manager", "Role to allow users to create and manage ACL entries", func(p string) bool {
+		return strings.HasPrefix(p, "ACE.") || p == "Role.Audit" || p == "User.Audit" || p == "Group.Audit"
+	}},
+	{"No Access", "Role with no privileges", func(string) bool { return false }},
+}
+
+func (c *Controller) seed() {
+	for _, name := range privilegeNames {
+		c.privileges = append(c.privileges, &privilege{PrivilegeID: newID(), Name: name, Description: name + " privilege"})
+	}
+	now := c.timestamp()
+	for _, br := range builtinRoles {
+		r := &role{RoleID: newID(), Name: br.name, Description: br.description, IsBuiltin: true, CreatedAt: now, UpdatedAt: now}
+		for _, p := range c.privileges {
+			if br.grants(p.Name) {
+				r.Privileges = append(r.Privileges, p)
+			}
+		}
+		c.roles.put(r.RoleID, r)
+	}
+
+	admins := &group{UserGroupID: newID(), Name: "Administrators", IsBuiltin: true, CreatedAt: now, UpdatedAt: now}
+	users := &group{UserGroupID: newID(), Name: "Users", IsBuiltin: true, CreatedAt: now, UpdatedAt: now}
+	c.groups.put(admins.UserGroupID, admins)
+	c.groups.put(users.UserGroupID, users)
+
+	admin := c.addUser(c.adminUser, c.adminPassword)
+	admin.IsSuperadmin = true
+	admins.members = append(admins.members, admin.UserID)
+	for _, u := range c.seedUsers {
+		nu := c.addUser(u[0], u[1])
+		users.members = append(users.members, nu.UserID)
+	}
+
+	c.seedTemplates()
+	c.seedComputes()
+}
+
+func (c *Controller) addUser(username, password string) *user {
+	now := c.timestamp()
+	u := &user{UserID: newID(), Username: username, IsActive: true, CreatedAt: now, UpdatedAt: now, password: password}
+	c.users.put(u.UserID, u)
+	return u
+}
+
+func (c *Controller) userByName(username string) *user {
+	return c.users.find(func(u *user) bool { return u.Username == username })
+}
+
+func (c *Controller) routes(mux *http.ServeMux) {
+	c.public(mux, "GET /version", c.getVersion)
+	c.public(mux, "POST /access/users/authenticate", c.authenticateUser)
+	c.public(mux, "POST /access/users/login", c.loginUser)
+
+	c.route(mux, "GET /access/users", c.listUsers)
+	c.route(mux, "POST /access/users", c.createUser)
+	c.route(mux, "GET /access/users/me", c.getMe)
+	c.route(mux, "PUT /access/users/me", c.updateMe)
+	c.route(mux, "GET /access/users/{id}", c.getUser)
+	c.route(mux, "PUT /access/users/{id}", c.updateUser)
+	c.route(mux, "DELETE /access/users/{id}", c.deleteUser)
+	c.route(mux, "GET /access/users/{id}/groups", c.userGroups)
+
+	c.route(mux, "GET /access/groups", c.listGroups)
+	c.route(mux, "POST /access/groups", c.createGroup)
+	c.route(mux, "GET /access/groups/{id}", c.getGroup)
+	c.route(mux, "PUT /access/groups/{id}", c.updateGroup)
+	c.route(mux, "DELETE /access/groups/{id}", c.deleteGroup)
+	c.route(mux, "GET /access/groups/{id}/members", c.groupMembers)
+	c.route(mux, "PUT /access/groups/{id}/members/{uid}", c.addGroupMember)
+	c.route(mux, "DELETE /access/groups/{id}/members/{uid}", c.removeGroupMember)
+
+	c.route(mux, "GET /access/privileges", c.listPrivileges)
+	c.route(mux, "GET /access/roles", c.listRoles)
+	c.route(mux, "POST /access/roles", c.createRole)
+	c.route(mux, "GET /access/roles/{id}", c.getRole)
+	c.route(mux, "PUT /access/roles/{id}", c.updateRole)
+	c.route(mux, "DELETE /access/roles/{id}", c.deleteRole)
+	c.route(mux, "GET /access/roles/{id}/privileges", c.rolePrivileges)
+	c.route(mux, "PUT /access/roles/{id}/privileges/{pid}", c.addRolePrivilege)
+	c.route(mux, "DELETE /access/roles/{id}/privileges/{pid}", c.removeRolePrivilege)
+
+	c.route(mux, "GET /access/acl", c.listACL)
+	c.route(mux, "POST /access/acl", c.createACE)
+	c.route(mux, "GET /access/acl/endpoints", c.aclEndpoints)
+	c.route(mux, "GET /access/acl/{id}", c.getACE)
+	c.route(mux, "PUT /access/acl/{id}", c.updateACE)
+	c.route(mux, "DELETE /access/acl/{id}", c.deleteACE)
+
+	c.route(mux, "GET /pools", c.listPools)
+	c.route(mux, "POST /pools", c.createPool)
+	c.route(mux, "GET /pools/{id}", c.getPool)
+	c.route(mux, "PUT /pools/{id}", c.updatePool)
+	c.route(mux, "DELETE /pools/{id}", c.deletePool)
+	c.route(mux, "GET /pools/{id}/resources", c.poolResources)
+	c.route(mux, "PUT /pools/{id}/resources/{rid}", c.addPoolResource)
+	c.route(mux, "DELETE /pools/{id}/resources/{rid}", c.removePoolResource)
+
+	c.projectRoutes(mux)
+	c.nodeRoutes(mux)
+	c.templateRoutes(mux)
+}
+
+func (c *Controller) login(username, password string) (int, any, error) {
+	u := c.userByName(username)
+	if u == nil || u.password != password || !u.IsActive {
+		return 0, nil, unauthorized("Authentication was unsuccessful.")
+	}
+	now := c.timestamp()
+	u.LastLogin = &now
+	return http.StatusOK, map[string]string{
+		"access_token": c.issueToken(u.Username),
+		"token_type":   "bearer",
+	}, nil
+}
+
+func (c *Controller) authenticateUser(r *http.Request, _ *user) (int, any, error) {
+	var creds struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := decode(r, &creds, "username", "password"); err != nil {
+		return 0, nil, err
+	}
+	return c.login(creds.Username, creds.Password)
+}
+
+func (c *Controller) loginUser(r *http.Request, _ *user) (int, any, error) {
+	if err := r.ParseForm(); err != nil {
+		return 0, nil, badRequest("invalid form: %v", err)
+	}
+	var errs []fieldError
+	for _, field := range []string{"username", "password"} {
+		if r.PostForm.Get(field) == "" {
+			errs = append(errs, missing("body", field))
+		}
+	}
+	if len(errs) > 0 {
+		return 0, nil, invalid(errs...)
+	}
+	return c.login(r.PostForm.Get("username"), r.PostForm.Get("password"))
+}
+
+type userInput struct {
+	Username *string `json:"username"`
+	Password *string `json:"password"`
+	Email    *string `json:"email"`
+	FullName *string `json:"full_name"`
+	IsActive *bool   `json:"is_active"`
+}
+
+func (in userInput) validate() error {
+	var errs []fieldError
+	if in.Username != nil {
+		switch {
+		case len(*in.Username) < 3:
+			errs = append(errs, tooShort("username", 3, *in.Username))
+		case !usernamePattern.MatchString(*in.Username):
+			errs = append(errs, fieldError{
+				Type:  "string_pattern_mismatch",
+				Loc:   []any{"body", "username"},
+				Msg:   "String should match pattern '^[a-zA-Z0-9_-]+$'",
+				Input: *in.Username,
+			})
+		}
+	}
+	if in.Password != nil {
+		pw := *in.Password
+		switch {
+		case len(pw) < 8:
+			errs = append(errs, tooShort("password", 8, ""))
+		case !strings.ContainsAny(pw, "0123456789") || !strings.ContainsAny(pw, "abcdefghijklmnopqrstuvwxyz"):
+			errs = append(errs, fieldError{
+				Type: "value_error",
+				Loc:  []any{"body", "password"},
+				Msg:  "Value error, Password must contain at least one number and one lowercase letter",
+			})
+		}
+	}
+	if in.Email != nil {
+		if _, err := mail.ParseAddress(*in.Email); err != nil || !strings.Contains(*in.Email, ".") {
+			errs = append(errs, fieldError{
+				Type:  "value_error",
+				Loc:   []any{"body", "email"},
+				Msg:   "value is not a valid email address: The email address is not valid.",
+				Input: *in.Email,
+			})
+		}
+	}
+	if len(errs) > 0 {
+		return invalid(errs...)
+	}
+	return nil
+}
+
+func (c *Controller) checkUserUnique(in userInput, self *user) error {
+	for _, u := range c.users.list() {
+		if u == self {
+			continue
+		}
+		if in.Username != nil && u.Username == *in.Username {
+			return badRequest("Username '%s' is already registered", *in.Username)
+		}
+		if in.Email != nil && u.Email != nil && *u.Email == *in.Email {
+			return badRequest("Email '%s' is already registered", *in.Email)
+		}
+	}
+	return nil
+}
+
+func (c *Controller) applyUser(u *user, in userInput) {
+	if in.Username != nil {
+		u.Username = *in.Username
+	}
+	if in.Password != nil {
+		u.password = *in.Password
+	}
+	if in.Email != nil {
+		u.Email = in.Email
+	}
+	if in.FullName != nil {
+		u.FullName = in.FullName
+	}
+	if in.IsActive != nil {
+		u.IsActive = *in.IsActive
+	}
+	u.UpdatedAt = c.timestamp()
+}
+
+func (c *Controller) listUsers(_ *http.Request, _ *user) (int, any, error) {
+	return http.StatusOK, c.users.list(), nil
+}
+
+func (c *Controller) createUser(r *http.Request, _ *user) (int, any, error) {
+	var in userInput
+	if err := decode(r, &in, "username", "password"); err != nil {
+		return 0, nil, err
+	}
+	if err := in.validate(); err != nil {
+		return 0, nil, err
+	}
+	if err := c.checkUserUnique(in, nil); err != nil {
+		return 0, nil, err
+	}
+	u := c.addUser(*in.Username, *in.Password)
+	if in.IsActive == nil {
+		active := true
+		in.IsActive = &active
+	}
+	c.applyUser(u, in)
+	return http.StatusCreated, u, nil
+}
+
+func (c *Controller) lookupUser(r *http.Request) (*user, error) {
+	id := r.PathValue("id")
+	u, ok := c.users.get(id)
+	if !ok {
+		return nil, notFound("User '%s' not found", id)
+	}
+	return u, nil
+}
+
+func (c *Controller) getMe(_ *http.Request, me *user) (int, any, error) {
+	return http.StatusOK, me, nil
+}
+
+func (c *Controller) updateMe(r *http.Request, me *user) (int, any, error) {
+	var in userInput
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	in.Username, in.IsActive = nil, nil
+	if err := in.validate(); err != nil {
+		return 0, nil, err
+	}
+	if err := c.checkUserUnique(in, me); err != nil {
+		return 0, nil, err
+	}
+	c.applyUser(me, in)
+	return http.StatusOK, me, nil
+}
+
+func (c *Controller) getUser(r *http.Request, _ *user) (int, any, error) {
+	u, err := c.lookupUser(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, u, nil
+}
+
+func (c *Controller) updateUser(r *http.Request, _ *user) (int, any, error) {
+	u, err := c.lookupUser(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var in userInput
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	if err := in.validate(); err != nil {
+		return 0, nil, err
+	}
+	if err := c.checkUserUnique(in, u); err != nil {
+		return 0, nil, err
+	}
+	c.applyUser(u, in)
+	return http.StatusOK, u, nil
+}
+
+func (c *Controller) deleteUser(r *http.Request, _ *user) (int, any, error) {
+	u, err := c.lookupUser(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if u.IsSuperadmin {
+		return 0, nil, forbidden("The super admin cannot be deleted")
+	}
+	c.users.remove(u.UserID)
+	for _, g := range c.groups.list() {
+		g.members = slices.DeleteFunc(g.members, func(id string) bool { return id == u.UserID })
+	}
+	c.removeACEs(func(a *ace) bool { return a.UserID != nil && *a.UserID == u.UserID })
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) userGroups(r *http.Request, _ *user) (int, any, error) {
+	u, err := c.lookupUser(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	out := []*group{}
+	for _, g := range c.groups.list() {
+		if slices.Contains(g.members, u.UserID) {
+			out = append(out, g)
+		}
+	}
+	return http.StatusOK, out, nil
+}
+
+func (c *Controller) lookupGroup(r *http.Request) (*group, error) {
+	id := r.PathValue("id")
+	g, ok := c.groups.get(id)
+	if !ok {
+		return nil, notFound("User group '%s' not found", id)
+	}
+	return g, nil
+}
+
+func (c *Controller) groupNameTaken(name string, self *group) error {
+	if c.groups.find(func(g *group) bool { return g != self && g.Name == name }) != nil {
+		return badRequest("User group '%s' already exists", name)
+	}
+	return nil
+}
+
+func (c *Controller) listGroups(_ *http.Request, _ *user) (int, any, error) {
+	return http.StatusOK, c.groups.list(), nil
+}
+
+func (c *Controller) createGroup(r *http.Request, _ *user) (int, any, error) {
+	var in struct {
+		Name string `json:"name"`
+	}
+	if err := decode(r, &in, "name"); err != nil {
+		return 0, nil, err
+	}
+	if err := c.groupNameTaken(in.Name, nil); err != nil {
+		return 0, nil, err
+	}
+	now := c.timestamp()
+	g := &group{UserGroupID: newID(), Name: in.Name, CreatedAt: now, UpdatedAt: now}
+	c.groups.put(g.UserGroupID, g)
+	return http.StatusCreated, g, nil
+}
+
+func (c *Controller) getGroup(r *http.Request, _ *user) (int, any, error) {
+	g, err := c.lookupGroup(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, g, nil
+}
+
+func (c *Controller) updateGroup(r *http.Request, _ *user) (int, any, error) {
+	g, err := c.lookupGroup(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if g.IsBuiltin {
+		return 0, nil, forbidden("Built-in user group '%s' cannot be updated", g.UserGroupID)
+	}
+	var in struct {
+		Name *string `json:"name"`
+	}
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	if in.Name != nil {
+		if err := c.groupNameTaken(*in.Name, g); err != nil {
+			return 0, nil, err
+		}
+		g.Name = *in.Name
+	}
+	g.UpdatedAt = c.timestamp()
+	return http.StatusOK, g, nil
+}
+
+func (c *Controller) deleteGroup(r *http.Request, _ *user) (int, any, error) {
+	g, err := c.lookupGroup(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if g.IsBuiltin {
+		return 0, nil, forbidden("Built-in user group '%s' cannot be deleted", g.UserGroupID)
+	}
+	c.groups.remove(g.UserGroupID)
+	c.removeACEs(func(a *ace) bool { return a.GroupID != nil && *a.GroupID == g.UserGroupID })
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) groupMembers(r *http.Request, _ *user) (int, any, error) {
+	g, err := c.lookupGroup(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	out := []*user{}
+	for _, id := range g.members {
+		if u, ok := c.users.get(id); ok {
+			out = append(out, u)
+		}
+	}
+	return http.StatusOK, out, nil
+}
+
+func (c *Controller) addGroupMember(r *http.Request, _ *user) (int, any, error) {
+	g, err := c.lookupGroup(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	uid := r.PathValue("uid")
+	if _, ok := c.users.get(uid); !ok {
+		return 0, nil, notFound("User '%s' not found", uid)
+	}
+	if !slices.Contains(g.members, uid) {
+		g.members = append(g.members, uid)
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) removeGroupMember(r *http.Request, _ *user) (int, any, error) {
+	g, err := c.lookupGroup(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	uid := r.PathValue("uid")
+	if _, ok := c.users.get(uid); !ok {
+		return 0, nil, notFound("User '%s' not found", uid)
+	}
+	g.members = slices.DeleteFunc(g.members, func(id string) bool { return id == uid })
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) listPrivileges(_ *http.Request, _ *user) (int, any, error) {
+	return http.StatusOK, c.privileges, nil
+}
+
+func (c *Controller) lookupRole(r *http.Request) (*role, error) {
+	id := r.PathValue("id")
+	ro, ok := c.roles.get(id)
+	if !ok {
+		return nil, notFound("Role '%s' not found", id)
+	}
+	return ro, nil
+}
+
+func (c *Controller) listRoles(_ *http.Request, _ *user) (int, any, error) {
+	return http.StatusOK, c.roles.list(), nil
+}
+
+func (c *Controller) createRole(r *http.Request, _ *user) (int, any, error) {
+	var in struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := decode(r, &in, "name"); err != nil {
+		return 0, nil, err
+	}
+	if c.roles.find(func(ro *role) bool { return ro.Name == in.Name }) != nil {
+		return 0, nil, badRequest("Role '%s' already exists", in.Name)
+	}
+	now := c.timestamp()
+	ro := &role{RoleID: newID(), Name: in.Name, Description: in.Description, CreatedAt: now, UpdatedAt: now, Privileges: []*privilege{}}
+	c.roles.put(ro.RoleID, ro)
+	return http.StatusCreated, ro, nil
+}
+
+func (c *Controller) getRole(r *http.Request, _ *user) (int, any, error) {
+	ro, err := c.lookupRole(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, ro, nil
+}
+
+func (c *Controller) updateRole(r *http.Request, _ *user) (int, any, error) {
+	ro, err := c.lookupRole(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if ro.IsBuiltin {
+		return 0, nil, forbidden("Built-in role '%s' cannot be updated", ro.RoleID)
+	}
+	var in struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	if in.Name != nil {
+		ro.Name = *in.Name
+	}
+	if in.Description != nil {
+		ro.Description = *in.Description
+	}
+	ro.UpdatedAt = c.timestamp()
+	return http.StatusOK, ro, nil
+}
+
+func (c *Controller) deleteRole(r *http.Request, _ *user) (int, any, error) {
+	ro, err := c.lookupRole(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if ro.IsBuiltin {
+		return 0, nil, forbidden("Built-in role '%s' cannot be deleted", ro.RoleID)
+	}
+	c.roles.remove(ro.RoleID)
+	c.removeACEs(func(a *ace) bool { return a.RoleID == ro.RoleID })
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) rolePrivileges(r *http.Request, _ *user) (int, any, error) {
+	ro, err := c.lookupRole(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, ro.Privileges, nil
+}
+
+func (c *Controller) lookupPrivilege(r *http.Request) (*privilege, error) {
+	id := r.PathValue("pid")
+	for _, p := range c.privileges {
+		if p.PrivilegeID == id {
+			return p, nil
+		}
+	}
+	return nil, notFound("Privilege '%s' not found", id)
+}
+
+func (c *Controller) addRolePrivilege(r *http.Request, _ *user) (int, any, error) {
+	ro, err := c.lookupRole(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if ro.IsBuiltin {
+		return 0, nil, forbidden("Built-in role '%s' cannot be updated", ro.RoleID)
+	}
+	p, err := c.lookupPrivilege(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !slices.Contains(ro.Privileges, p) {
+		ro.Privileges = append(ro.Privileges, p)
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) removeRolePrivilege(r *http.Request, _ *user) (int, any, error) {
+	ro, err := c.lookupRole(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if ro.IsBuiltin {
+		return 0, nil, forbidden("Built-in role '%s' cannot be updated", ro.RoleID)
+	}
+	p, err := c.lookupPrivilege(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	ro.Privileges = slices.DeleteFunc(ro.Privileges, func(x *privilege) bool { return x == p })
+	return http.StatusNoContent, nil, nil
+}
+
+type aceInput struct {
+	ACEType   *string `json:"ace_type"`
+	Path      *string `json:"path"`
+	Propagate *bool   `json:"propagate"`
+	Allowed   *bool   `json:"allowed"`
+	UserID    *string `json:"user_id"`
+	GroupID   *string `json:"group_id"`
+	RoleID    *string `json:"role_id"`
+}
+
+// applyACE validates the entry the way the controller does: the type must
+// match the referenced user or group, and path, user, group and role must
+// all exist.
+func (c *Controller) applyACE(a *ace, in aceInput) error {
+	if in.ACEType != nil {
+		if *in.ACEType != "user" && *in.ACEType != "group" {
+			return invalid(fieldError{
+				Type:  "enum",
+				Loc:   []any{"body", "ace_type"},
+				Msg:   "Input should be 'user' or 'group'",
+				Input: *in.ACEType,
+			})
+		}
+		a.ACEType = *in.ACEType
+	}
+	if in.Path != nil {
+		if !c.aclPathExists(*in.Path) {
+			return badRequest("Path '%s' doesn't match any existing endpoint", *in.Path)
+		}
+		a.Path = *in.Path
+	}
+	if in.Propagate != nil {
+		a.Propagate = *in.Propagate
+	}
+	if in.Allowed != nil {
+		a.Allowed = *in.Allowed
+	}
+	if in.UserID != nil {
+		a.UserID = in.UserID
+	}
+	if in.GroupID != nil {
+		a.GroupID = in.GroupID
+	}
+	if in.RoleID != nil {
+		if _, ok := c.roles.get(*in.RoleID); !ok {
+			return notFound("Role '%s' not found", *in.RoleID)
+		}
+		a.RoleID = *in.RoleID
+	}
+
+	switch a.ACEType {
+	case "user":
+		if a.UserID == nil {
+			return badRequest("User ID must be provided for an user ACE")
+		}
+		if _, ok := c.users.get(*a.UserID); !ok {
+			return notFound("User '%s' not found", *a.UserID)
+		}
+		a.GroupID = nil
+	case "group":
+		if a.GroupID == nil {
+			return badRequest("Group ID must be provided for a group ACE")
+		}
+		if _, ok := c.groups.get(*a.GroupID); !ok {
+			return notFound("User group '%s' not found", *a.GroupID)
+		}
+		a.UserID = nil
+	}
+	a.UpdatedAt = c.timestamp()
+	return nil
+}
+
+func (c *Controller) listACL(_ *http.Request, _ *user) (int, any, error) {
+	return http.StatusOK, c.acl.list(), nil
+}
+
+func (c *Controller) createACE(r *http.Request, _ *user) (int, any, error) {
+	var in aceInput
+	if err := decode(r, &in, "ace_type", "path", "role_id"); err != nil {
+		return 0, nil, err
+	}
+	now := c.timestamp()
+	a := &ace{ACEID: newID(), Propagate: true, Allowed: true, CreatedAt: now}
+	if err := c.applyACE(a, in); err != nil {
+		return 0, nil, err
+	}
+	c.acl.put(a.ACEID, a)
+	return http.StatusCreated, a, nil
+}
+
+func (c *Controller) lookupACE(r *http.Request) (*ace, error) {
+	id := r.PathValue("id")
+	a, ok := c.acl.get(id)
+	if !ok {
+		return nil, notFound("ACE '%s' not found", id)
+	}
+	return a, nil
+}
+
+func (c *Controller) getACE(r *http.Request, _ *user) (int, any, error) {
+	a, err := c.lookupACE(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, a, nil
+}
+
+func (c *Controller) updateACE(r *http.Request, _ *user) (int, any, error) {
+	a, err := c.lookupACE(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var in aceInput
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	updated := *a
+	if err := c.applyACE(&updated, in); err != nil {
+		return 0, nil, err
+	}
+	*a = updated
+	return http.StatusOK, a, nil
+}
+
+func (c *Controller) deleteACE(r *http.Request, _ *user) (int, any, error) {
+	a, err := c.lookupACE(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	c.acl.remove(a.ACEID)
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) removeACEs(match func(*ace) bool) {
+	for _, a := range c.acl.list() {
+		if match(a) {
+			c.acl.remove(a.ACEID)
+		}
+	}
+}
+
+type aclEndpoint struct {
+	Endpoint     string `json:"endpoint"`
+	Name         string `json:"name"`
+	EndpointType string `json:"endpoint_type"`
+}
+
+func (c *Controller) endpointList() []aclEndpoint {
+	out := []aclEndpoint{
+		{"/", "All resources", "root"},
+		{"/projects", "All projects", "project"},
+		{"/templates", "All templates", "template"},
+		{"/computes", "All computes", "compute"},
+		{"/pools", "All resource pools", "pool"},
+		{"/access/users", "All users", "user"},
+		{"/access/groups", "All groups", "group"},
+		{"/access/roles", "All roles", "role"},
+		{"/access/acl", "All ACEs", "acl"},
+		{"/images", "All images", "image"},
+		{"/symbols", "All symbols", "symbol"},
+		{"/appliances", "All appliances", "appliance"},
+	}
+	for _, p := range c.projects.list() {
+		out = append(out, aclEndpoint{"/projects/" + p.ProjectID, "Project '" + p.Name + "'", "project"})
+	}
+	for _, t := range c.templates.list() {
+		out = append(out, aclEndpoint{"/templates/" + t.id(), "Template '" + t.name() + "'", "template"})
+	}
+	for _, p := range c.pools.list() {
+		out = append(out, aclEndpoint{"/pools/" + p.ResourcePoolID, "Resource pool '" + p.Name + "'", "pool"})
+	}
+	for _, u := range c.users.list() {
+		out = append(out, aclEndpoint{"/access/users/" + u.UserID, "User '" + u.Username + "'", "user"})
+	}
+	for _, g := range c.groups.list() {
+		out = append(out, aclEndpoint{"/access/groups/" + g.UserGroupID, "User group '" + g.Name + "'", "group"})
+	}
+	return out
+}
+
+func (c *Controller) aclEndpoints(_ *http.Request, _ *user) (int, any, error) {
+	return http.StatusOK, c.endpointList(), nil
+}
+
+// aclPathExists accepts the endpoints listed by /access/acl/endpoints and
+// anything below an existing project.
+func (c *Controller) aclPathExists(path string) bool {
+	for _, e := range c.endpointList() {
+		if e.Endpoint == path {
+			return true
+		}
+	}
+	rest, ok := strings.CutPrefix(path, "/projects/")
+	if !ok {
+		return false
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	_, exists := c.projects.get(id)
+	return exists
+}
+
+// permitted is a simplified version of the controller's RBAC check:
+// superadmins may do anything, everyone else needs an ACE on the path, a
+// parent path with propagation, or a pool containing the project. The most
+// specific entry wins. Read requests need any privilege of the role, writes
+// need one that is not an .Audit privilege.
+func (c *Controller) permitted(u *user, method, path string) bool {
+	if u.IsSuperadmin || strings.HasPrefix(path, "/access/users/me") {
+		return true
+	}
+
+	candidates := []string{path}
+	if rest, ok := strings.CutPrefix(path, "/projects/"); ok {
+		id, _, _ := strings.Cut(rest, "/")
+		for _, p := range c.pools.list() {
+			if slices.Contains(p.resources, id) {
+				candidates = append(candidates, "/pools/"+p.ResourcePoolID)
+			}
+		}
+	}
+
+	var best *ace
+	for _, a := range c.acl.list() {
+		if !c.aceAppliesTo(a, u) || !aceCovers(a, candidates) {
+			continue
+		}
+		if best == nil || len(a.Path) > len(best.Path) {
+			best = a
+		}
+	}
+	if best == nil {
+		// Listing collections is allowed for anyone holding at least one
+		// entry, the controller filters the results instead.
+		return method == http.MethodGet && (path == "/projects" || path == "/templates") && c.hasAnyACE(u)
+	}
+	if !best.Allowed {
+		return false
+	}
+	ro, ok := c.roles.get(best.RoleID)
+	if !ok {
+		return false
+	}
+	return slices.ContainsFunc(ro.Privileges, func(p *privilege) bool {
+		return method == http.MethodGet || !strings.HasSuffix(p.Name, ".Audit")
+	})
+}
+
+func aceCovers(a *ace, paths []string) bool {
+	for _, p := range paths {
+		if a.Path == p || a.Path == "/" && a.Propagate ||
+			a.Propagate && strings.HasPrefix(p, strings.TrimSuffix(a.Path, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Controller) aceAppliesTo(a *ace, u *user) bool {
+	if a.UserID != nil {
+		return *a.UserID == u.UserID
+	}
+	if a.GroupID != nil {
+		g, ok := c.groups.get(*a.GroupID)
+		return ok && slices.Contains(g.members, u.UserID)
+	}
+	return false
+}
+
+func (c *Controller) hasAnyACE(u *user) bool {
+	return c.acl.find(func(a *ace) bool { return c.aceAppliesTo(a, u) }) != nil
+}
+
+func (c *Controller) canSeeProject(u *user, projectID string) bool {
+	return c.permitted(u, http.MethodGet, "/projects/"+projectID)
+}
+
+func (c *Controller) listPools(_ *http.Request, _ *user) (int, any, error) {
+	return http.StatusOK, c.pools.list(), nil
+}
+
+func (c *Controller) lookupPool(r *http.Request) (*pool, error) {
+	id := r.PathValue("id")
+	p, ok := c.pools.get(id)
+	if !ok {
+		return nil, notFound("Resource pool '%s' not found", id)
+	}
+	return p, nil
+}
+
+func (c *Controller) createPool(r *http.Request, _ *user) (int, any, error) {
+	var in struct {
+		Name string `json:"name"`
+	}
+	if err := decode(r, &in, "name"); err != nil {
+		return 0, nil, err
+	}
+	if c.pools.find(func(p *pool) bool { return p.Name == in.Name }) != nil {
+		return 0, nil, badRequest("Resource pool '%s' already exists", in.Name)
+	}
+	now := c.timestamp()
+	p := &pool{ResourcePoolID: newID(), Name: in.Name, CreatedAt: now, UpdatedAt: now}
+	c.pools.put(p.ResourcePoolID, p)
+	return http.StatusCreated, p, nil
+}
+
+func (c *Controller) getPool(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupPool(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, p, nil
+}
+
+func (c *Controller) updatePool(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupPool(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var in struct {
+		Name *string `json:"name"`
+	}
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	if in.Name != nil {
+		p.Name = *in.Name
+	}
+	p.UpdatedAt = c.timestamp()
+	return http.StatusOK, p, nil
+}
+
+func (c *Controller) deletePool(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupPool(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	c.pools.remove(p.ResourcePoolID)
+	c.removeACEs(func(a *ace) bool { return a.Path == "/pools/"+p.ResourcePoolID })
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) poolResources(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupPool(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	out := []poolResource{}
+	for _, id := range p.resources {
+		if pr, ok := c.projects.get(id); ok {
+			out = append(out, poolResource{ResourceID: id, ResourceType: "project", Name: pr.Name})
+		}
+	}
+	return http.StatusOK, out, nil
+}
+
+func (c *Controller) addPoolResource(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupPool(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	rid := r.PathValue("rid")
+	if _, ok := c.projects.get(rid); !ok {
+		return 0, nil, notFound("Project '%s' not found", rid)
+	}
+	if slices.Contains(p.resources, rid) {
+		return 0, nil, badRequest("Resource '%s' is already in resource pool '%s'", rid, p.Name)
+	}
+	p.resources = append(p.resources, rid)
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) removePoolResource(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupPool(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	rid := r.PathValue("rid")
+	if !slices.Contains(p.resources, rid) {
+		return 0, nil, notFound("Resource '%s' not found in resource pool '%s'", rid, p.Name)
+	}
+	p.resources = slices.DeleteFunc(p.resources, func(id string) bool { return id == rid })
+	return http.StatusNoContent, nil, nil
+}
@@ -0,0 +1,437 @@
+// Package fake implements an in-memory GNS3 v3 controller for offline
+// testing and rehearsals. It speaks the same JSON as a real controller for
+// the endpoints in pkg/api/endpoints, including authentication, FastAPI style
+// 422 validation payloads and the status codes a 3.x controller returns.
+//
+// Nodes never run anything, starting one only changes its status.
+package fake
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultUsername   = "admin"
+	DefaultPassword   = "admin"
+	DefaultTokenTTL   = 24 * time.Hour
+	ControllerVersion = "3.0.5"
+	LocalComputeID    = "local"
+	apiPrefix         = "/v3"
+)
+
+type Option func(*Controller)
+
+// WithAdmin replaces the credentials of the seeded superadmin, admin/admin
+// by default.
+func WithAdmin(username, password string) Option {
+	return func(c *Controller) {
+		c.adminUser = username
+		c.adminPassword = password
+	}
+}
+
+// WithUser seeds an additional regular user.
+func WithUser(username, password string) Option {
+	return func(c *Controller) {
+		c.seedUsers = append(c.seedUsers, [2]string{username, password})
+	}
+}
+
+// WithTokenTTL sets how long issued access tokens stay valid.
+func WithTokenTTL(ttl time.Duration) Option {
+	return func(c *Controller) {
+		c.tokenTTL = ttl
+	}
+}
+
+// WithClock overrides the time source used for tokens and timestamps.
+func WithClock(now func() time.Time) Option {
+	return func(c *Controller) {
+		c.now = now
+	}
+}
+
+// Controller holds the state of the fake controller. All handlers run under
+// a single mutex, so it is safe to use from concurrent clients.
+type Controller struct {
+	mu sync.Mutex
+
+	adminUser     string
+	adminPassword string
+	seedUsers     [][2]string
+	tokenTTL      time.Duration
+	now           func() time.Time
+	secret        []byte
+
+	users      *table[user]
+	groups     *table[group]
+	roles      *table[role]
+	privileges []*privilege
+	acl        *table[ace]
+	pools      *table[pool]
+	projects   *table[project]
+	templates  *table[template]
+	computes   *table[compute]
+}
+
+func NewController(opts ...Option) *Controller {
+	c := &Controller{
+		adminUser:     DefaultUsername,
+		adminPassword: DefaultPassword,
+		tokenTTL:      DefaultTokenTTL,
+		now:           time.Now,
+		users:         newTable[user](),
+		groups:        newTable[group](),
+		roles:         newTable[role](),
+		acl:           newTable[ace](),
+		pools:         newTable[pool](),
+		projects:      newTable[project](),
+		templates:     newTable[template](),
+		computes:      newTable[compute](),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.secret = make([]byte, 32)
+	_, _ = rand.Read(c.secret)
+	c.seed()
+	return c
+}
+
+// Server is a running fake controller. URL is the base url to pass to the
+// CLI or api.NewSettings, without the /v3 prefix.
+type Server struct {
+	*httptest.Server
+	Controller *Controller
+}
+
+// NewServer starts a fake controller on a random local port. Call Close when
+// done.
+func NewServer(opts ...Option) *Server {
+	c := NewController(opts...)
+	return &Server{Server: httptest.NewServer(c.Handler()), Controller: c}
+}
+
+// NewUnstartedServer returns a server that is not started yet, so the caller
+// can replace its listener before calling Start.
+func NewUnstartedServer(opts ...Option) *Server {
+	c := NewController(opts...)
+	return &Server{Server: httptest.NewUnstartedServer(c.Handler()), Controller: c}
+}
+
+// Token issues an access token for username without going through the
+// authenticate endpoint.
+func (c *Controller) Token(username string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.userByName(username) == nil {
+		return "", fmt.Errorf("user %s not found", username)
+	}
+	return c.issueToken(username), nil
+}
+
+func (c *Controller) timestamp() string {
+	return c.now().UTC().Format("2006-01-02T15:04:05.000000Z")
+}
+
+// table keeps items by id in insertion order, which is what list endpoints
+// return.
+type table[T any] struct {
+	items map[string]*T
+	order []string
+}
+
+func newTable[T any]() *table[T] {
+	return &table[T]{items: map[string]*T{}}
+}
+
+func (t *table[T]) get(id string) (*T, bool) {
+	v, ok := t.items[id]
+	return v, ok
+}
+
+func (t *table[T]) put(id string, v *T) {
+	if _, ok := t.items[id]; !ok {
+		t.order = append(t.order, id)
+	}
+	t.items[id] = v
+}
+
+func (t *table[T]) remove(id string) bool {
+	if _, ok := t.items[id]; !ok {
+		return false
+	}
+	delete(t.items, id)
+	t.order = slices.DeleteFunc(t.order, func(s string) bool { return s == id })
+	return true
+}
+
+func (t *table[T]) list() []*T {
+	out := make([]*T, 0, len(t.order))
+	for _, id := range t.order {
+		out = append(out, t.items[id])
+	}
+	return out
+}
+
+func (t *table[T]) find(match func(*T) bool) *T {
+	for _, id := range t.order {
+		if v := t.items[id]; match(v) {
+			return v
+		}
+	}
+	return nil
+}
+
+func newID() string {
+	return uuid.New().String()
+}
+
+// httpError is returned by handlers and written as the error payload.
+type httpError struct {
+	status int
+	body   any
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("%d: %v", e.status, e.body)
+}
+
+func controllerError(status int, format string, a ...any) error {
+	return &httpError{status: status, body: map[string]string{"message": fmt.Sprintf(format, a...)}}
+}
+
+func notFound(format string, a ...any) error {
+	return controllerError(http.StatusNotFound, format, a...)
+}
+
+func badRequest(format string, a ...any) error {
+	return controllerError(http.StatusBadRequest, format, a...)
+}
+
+func conflict(format string, a ...any) error {
+	return controllerError(http.StatusConflict, format, a...)
+}
+
+func forbidden(format string, a ...any) error {
+	return controllerError(http.StatusForbidden, format, a...)
+}
+
+func unauthorized(detail string) error {
+	return &httpError{status: http.StatusUnauthorized, body: map[string]string{"detail": detail}}
+}
+
+// fieldError is one entry of a FastAPI validation error.
+type fieldError struct {
+	Type  string `json:"type"`
+	Loc   []any  `json:"loc"`
+	Msg   string `json:"msg"`
+	Input any    `json:"input,omitempty"`
+}
+
+func invalid(errs ...fieldError) error {
+	return &httpError{status: http.StatusUnprocessableEntity, body: map[string]any{"detail": errs}}
+}
+
+func missing(loc ...any) fieldError {
+	return fieldError{Type: "missing", Loc: loc, Msg: "Field required"}
+}
+
+func tooShort(field string, minLen int, input string) fieldError {
+	return fieldError{
+		Type:  "string_too_short",
+		Loc:   []any{"body", field},
+		Msg:   fmt.Sprintf("String should have at least %d characters", minLen),
+		Input: input,
+	}
+}
+
+// decode reads the JSON request body into dst and reports missing or null
+// required fields and type mismatches the way FastAPI does.
+func decode(r *http.Request, dst any, required ...string) error {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return badRequest("could not read request body: %v", err)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		if len(required) == 0 {
+			return nil
+		}
+		return invalid(missing("body"))
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return invalid(fieldError{Type: "json_invalid", Loc: []any{"body", 0}, Msg: "JSON decode error"})
+	}
+	var errs []fieldError
+	for _, name := range required {
+		if v, ok := fields[name]; !ok || string(v) == "null" {
+			errs = append(errs, missing("body", name))
+		}
+	}
+	if len(errs) > 0 {
+		return invalid(errs...)
+	}
+
+	if err := json.Unmarshal(data, dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return invalid(fieldError{
+				Type: typeErr.Type.Kind().String() + "_type",
+				Loc:  []any{"body", typeErr.Field},
+				Msg:  fmt.Sprintf("Input should be a valid %s", typeErr.Type.Kind()),
+			})
+		}
+		return invalid(fieldError{Type: "value_error", Loc: []any{"body"}, Msg: err.Error()})
+	}
+	return nil
+}
+
+// handler is the signature of all route handlers. me is nil on public
+// routes. A []byte body is written as is, anything else as JSON.
+type handler func(r *http.Request, me *user) (int, any, error)
+
+func (c *Controller) Handler() http.Handler {
+	mux := http.NewServeMux()
+	c.routes(mux)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Not Found"})
+	})
+	return mux
+}
+
+func (c *Controller) route(mux *http.ServeMux, pattern string, h handler) {
+	c.register(mux, pattern, h, false)
+}
+
+func (c *Controller) public(mux *http.ServeMux, pattern string, h handler) {
+	c.register(mux, pattern, h, true)
+}
+
+func (c *Controller) register(mux *http.ServeMux, pattern string, h handler, public bool) {
+	method, path, _ := strings.Cut(pattern, " ")
+	mux.HandleFunc(method+" "+apiPrefix+path, func(w http.ResponseWriter, r *http.Request) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		var me *user
+		if !public {
+			var err error
+			if me, err = c.authenticate(r); err != nil {
+				writeError(w, err)
+				return
+			}
+			if !c.permitted(me, r.Method, strings.TrimPrefix(r.URL.Path, apiPrefix)) {
+				writeError(w, forbidden("Permission denied"))
+				return
+			}
+		}
+
+		status, body, err := h(r, me)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		switch b := body.(type) {
+		case nil:
+			w.WriteHeader(status)
+		case []byte:
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.WriteHeader(status)
+			_, _ = w.Write(b)
+		default:
+			writeJSON(w, status, body)
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var hErr *httpError
+	if !errors.As(err, &hErr) {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"message": err.Error()})
+		return
+	}
+	if hErr.status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+	writeJSON(w, hErr.status, hErr.body)
+}
+
+func (c *Controller) issueToken(username string) string {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	claims, _ := json.Marshal(map[string]any{
+		"sub": username,
+		"exp": c.now().Add(c.tokenTTL).Unix(),
+	})
+	payload := header + "." + enc.EncodeToString(claims)
+	return payload + "." + enc.EncodeToString(c.sign(payload))
+}
+
+func (c *Controller) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// authenticate resolves the bearer token, or the token query parameter used
+// by the websocket endpoints, to a user.
+func (c *Controller) authenticate(r *http.Request) (*user, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		return nil, unauthorized("Not authenticated")
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, unauthorized("Could not validate credentials")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, c.sign(parts[0]+"."+parts[1])) {
+		return nil, unauthorized("Could not validate credentials")
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, unauthorized("Could not validate credentials")
+	}
+	var claims struct {
+		Sub string `json:"sub"`
+		Exp int64  `json:"exp"`
+	}
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return nil, unauthorized("Could not validate credentials")
+	}
+	if c.now().Unix() >= claims.Exp {
+		return nil, unauthorized("Could not validate credentials")
+	}
+	u := c.userByName(claims.Sub)
+	if u == nil || !u.IsActive {
+		return nil, unauthorized("Could not validate credentials")
+	}
+	return u, nil
+}
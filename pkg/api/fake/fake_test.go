@@ -0,0 +1,597 @@
+package fake_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/fake"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
+)
+
+// request sends a raw request to srv and returns the status and body.
+func request(t *testing.T, srv *fake.Server, method, path, token, contentType, body string) (int, http.Header, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+"/v3"+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, resp.Header, string(data)
+}
+
+func token(t *testing.T, srv *fake.Server, username string) string {
+	t.Helper()
+	tok, err := srv.Controller.Token(username)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+func client(t *testing.T, srv *fake.Server, username string) *sdk.Client {
+	t.Helper()
+	return sdk.NewClient(api.NewSettings(api.WithBaseURL(srv.URL), api.WithToken(token(t, srv, username)), api.WithRetries(0)))
+}
+
+func check(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestLogin(t *testing.T) {
+	srv := fake.NewServer(fake.WithUser("alice", "alice-pass1"))
+	defer srv.Close()
+
+	form := func(username, password string) string {
+		return url.Values{"username": {username}, "password": {password}}.Encode()
+	}
+	tests := []struct {
+		name        string
+		path        string
+		contentType string
+		body        string
+		status      int
+	}{
+		{name: "form", path: "/access/users/login", contentType: "application/x-www-form-urlencoded", body: form("admin", "admin"), status: http.StatusOK},
+		{name: "json", path: "/access/users/authenticate", contentType: "application/json", body: `{"username":"alice","password":"alice-pass1"}`, status: http.StatusOK},
+		{name: "wrong password", path: "/access/users/login", contentType: "application/x-www-form-urlencoded", body: form("admin", "nope"), status: http.StatusUnauthorized},
+		{name: "unknown user", path: "/access/users/authenticate", contentType: "application/json", body: `{"username":"mallory","password":"admin"}`, status: http.StatusUnauthorized},
+		{name: "missing password", path: "/access/users/login", contentType: "application/x-www-form-urlencoded", body: "username=admin", status: http.StatusUnprocessableEntity},
+		{name: "missing username", path: "/access/users/authenticate", contentType: "application/json", body: `{"password":"admin"}`, status: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, header, body := request(t, srv, http.MethodPost, tt.path, "", tt.contentType, tt.body)
+			if status != tt.status {
+				t.Fatalf("status %d, want %d: %s", status, tt.status, body)
+			}
+			switch status {
+			case http.StatusOK:
+				var tok schemas.Token
+				check(t, json.Unmarshal([]byte(body), &tok))
+				if tok.AccessToken == nil || tok.TokenType == nil || *tok.TokenType != "bearer" {
+					t.Fatalf("token %s", body)
+				}
+				if status, _, body := request(t, srv, http.MethodGet, "/access/users/me", *tok.AccessToken, "", ""); status != http.StatusOK {
+					t.Errorf("the new token is refused: %d %s", status, body)
+				}
+			case http.StatusUnauthorized:
+				if header.Get("WWW-Authenticate") != "Bearer" {
+					t.Errorf("WWW-Authenticate %q", header.Get("WWW-Authenticate"))
+				}
+			}
+		})
+	}
+}
+
+func TestTokens(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	srv := fake.NewServer(fake.WithTokenTTL(time.Hour), fake.WithClock(func() time.Time { return now }))
+	defer srv.Close()
+	valid := token(t, srv, fake.DefaultUsername)
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name   string
+		token  string
+		after  time.Duration
+		query  bool
+		status int
+	}{
+		{name: "valid", token: valid, status: http.StatusOK},
+		{name: "query parameter", token: valid, query: true, status: http.StatusOK},
+		{name: "missing", status: http.StatusUnauthorized},
+		{name: "not a jwt", token: "garbage", status: http.StatusUnauthorized},
+		{name: "bad signature", token: parts[0] + "." + parts[1] + ".c2lnbmF0dXJl", status: http.StatusUnauthorized},
+		{name: "just before expiry", token: valid, after: time.Hour - time.Second, status: http.StatusOK},
+		{name: "expired", token: valid, after: time.Hour, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC).Add(tt.after)
+			path, bearer := "/projects", tt.token
+			if tt.query {
+				path, bearer = "/projects?token="+url.QueryEscape(tt.token), ""
+			}
+			if status, _, body := request(t, srv, http.MethodGet, path, bearer, "", ""); status != tt.status {
+				t.Errorf("status %d, want %d: %s", status, tt.status, body)
+			}
+		})
+	}
+
+	now = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := srv.Controller.Token("nobody"); err == nil {
+		t.Error("issued a token for an unknown user")
+	}
+}
+
+func TestCreateUserValidation(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	admin := token(t, srv, fake.DefaultUsername)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		detail string
+	}{
+		{name: "valid", body: `{"username":"bob","password":"bob12345","email":"bob@example.com"}`, status: http.StatusCreated},
+		{name: "short username", body: `{"username":"bo","password":"bob12345"}`, status: http.StatusUnprocessableEntity, detail: "string_too_short"},
+		{name: "username pattern", body: `{"username":"bob smith","password":"bob12345"}`, status: http.StatusUnprocessableEntity, detail: "string_pattern_mismatch"},
+		{name: "short password", body: `{"username":"carol","password":"c1"}`, status: http.StatusUnprocessableEntity, detail: "string_too_short"},
+		{name: "password without digit", body: `{"username":"carol","password":"carolcarol"}`, status: http.StatusUnprocessableEntity, detail: "one number"},
+		{name: "bad email", body: `{"username":"carol","password":"carol123","email":"carol"}`, status: http.StatusUnprocessableEntity, detail: "email"},
+		{name: "missing password", body: `{"username":"carol"}`, status: http.StatusUnprocessableEntity, detail: "missing"},
+		{name: "taken username", body: `{"username":"admin","password":"admin123"}`, status: http.StatusBadRequest, detail: "already registered"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _, body := request(t, srv, http.MethodPost, "/access/users", admin, "application/json", tt.body)
+			if status != tt.status {
+				t.Fatalf("status %d, want %d: %s", status, tt.status, body)
+			}
+			if !strings.Contains(body, tt.detail) {
+				t.Errorf("body %s does not mention %q", body, tt.detail)
+			}
+		})
+	}
+}
+
+func TestBuiltins(t *testing.T) {
+	srv := fake.NewServer()
+	defer srv.Close()
+	ctx := context.Background()
+	c := client(t, srv, fake.DefaultUsername)
+
+	role, err := c.Roles().ByName(ctx, "User")
+	check(t, err)
+	groups, err := c.Groups().List(ctx)
+	check(t, err)
+	me, err := c.Users().Me(ctx)
+	check(t, err)
+
+	for name, err := range map[string]error{
+		"delete builtin role":  adminDo(t, srv, http.MethodDelete, "/access/roles/"+role.RoleID),
+		"delete builtin group": c.Groups().Delete(ctx, groups[0].UserGroupID.String()),
+		"delete superadmin":    c.Users().Delete(ctx, me.UserID.String()),
+	} {
+		if !errors.Is(err, api.ErrForbidden) {
+			t.Errorf("%s: %v, want ErrForbidden", name, err)
+		}
+	}
+	if status, _, body := request(t, srv, http.MethodGet, "/no/such/endpoint", token(t, srv, fake.DefaultUsername), "", ""); status != http.StatusNotFound || !strings.Contains(body, "Not Found") {
+		t.Errorf("unknown endpoint: %d %s", status, body)
+	}
+}
+
+// adminDo sends a request as the admin and returns the error the api
+// client makes of the response.
+func adminDo(t *testing.T, srv *fake.Server, method api.HTTPMethod, path string) error {
+	t.Helper()
+	settings := api.NewSettings(api.WithBaseURL(srv.URL), api.WithToken(token(t, srv, fake.DefaultUsername)), api.WithRetries(0))
+	_, _, err := api.NewGNS3Client(settings).Do(context.Background(), api.NewRequestOptions(settings).WithURL(path).WithMethod(method))
+	return err
+}
+
+func TestPermissions(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		// grant gives alice access, project is hers and other is not
+		grant func(t *testing.T, admin *sdk.Client, aliceID, project string)
+		// read and write are whether alice may read and change her project,
+		// readOther whether she may read the other one
+		read, write, readOther bool
+	}{
+		{
+			name:  "no entry",
+			grant: func(*testing.T, *sdk.Client, string, string) {},
+		},
+		{
+			name: "user role on the project",
+			grant: func(t *testing.T, admin *sdk.Client, aliceID, project string) {
+				grant(t, admin, "user", aliceID, "/projects/"+project, "User", true)
+			},
+			read: true, write: true,
+		},
+		{
+			name: "auditor role on the project",
+			grant: func(t *testing.T, admin *sdk.Client, aliceID, project string) {
+				grant(t, admin, "user", aliceID, "/projects/"+project, "Auditor", true)
+			},
+			read: true,
+		},
+		{
+			name: "denied below an allowed parent",
+			grant: func(t *testing.T, admin *sdk.Client, aliceID, project string) {
+				grant(t, admin, "user", aliceID, "/projects", "User", true)
+				grant(t, admin, "user", aliceID, "/projects/"+project, "User", false)
+			},
+			readOther: true,
+		},
+		{
+			name: "group entry on a pool",
+			grant: func(t *testing.T, admin *sdk.Client, aliceID, project string) {
+				g, err := admin.Groups().Create(ctx, "students")
+				check(t, err)
+				check(t, admin.Groups().AddMember(ctx, g.UserGroupID.String(), aliceID))
+				pool, err := admin.Pools().Create(ctx, "lab")
+				check(t, err)
+				check(t, admin.Pools().AddResource(ctx, pool.ResourcePoolID, project))
+				grant(t, admin, "group", g.UserGroupID.String(), "/pools/"+pool.ResourcePoolID, "User", true)
+			},
+			read: true, write: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fake.NewServer(fake.WithUser("alice", "alice-pass1"))
+			defer srv.Close()
+			admin := client(t, srv, fake.DefaultUsername)
+			alice := client(t, srv, "alice")
+
+			users, err := admin.Users().List(ctx)
+			check(t, err)
+			var aliceID string
+			for _, u := range users {
+				if u.Username == "alice" {
+					aliceID = u.UserID.String()
+				}
+			}
+			own, err := admin.Projects().Create(ctx, schemas.ProjectCreate{Name: ptr("own")})
+			check(t, err)
+			other, err := admin.Projects().Create(ctx, schemas.ProjectCreate{Name: ptr("other")})
+			check(t, err)
+			tt.grant(t, admin, aliceID, own.ProjectID)
+
+			_, err = alice.Projects().Get(ctx, own.ProjectID)
+			if tt.read != (err == nil) {
+				t.Errorf("read own project: %v, want allowed %v", err, tt.read)
+			}
+			_, err = alice.Projects().Update(ctx, own.ProjectID, schemas.ProjectUpdate{Name: ptr("renamed")})
+			if tt.write != (err == nil) {
+				t.Errorf("change own project: %v, want allowed %v", err, tt.write)
+			}
+			if err != nil && !errors.Is(err, api.ErrForbidden) {
+				t.Errorf("change own project: %v, want ErrForbidden", err)
+			}
+			_, err = alice.Projects().Get(ctx, other.ProjectID)
+			if tt.readOther != (err == nil) {
+				t.Errorf("read other project: %v, want allowed %v", err, tt.readOther)
+			}
+			if _, err := alice.Users().List(ctx); !errors.Is(err, api.ErrForbidden) {
+				t.Errorf("list users: %v, want ErrForbidden", err)
+			}
+			if _, err := alice.Users().Me(ctx); err != nil {
+				t.Errorf("read herself: %v", err)
+			}
+
+			projects, err := alice.Projects().List(ctx)
+			if tt.read || tt.readOther {
+				check(t, err)
+				var ids []string
+				for _, p := range projects {
+					ids = append(ids, p.ProjectID)
+				}
+				var want []string
+				if tt.read {
+					want = append(want, own.ProjectID)
+				}
+				if tt.readOther {
+					want = append(want, other.ProjectID)
+				}
+				if !slices.Equal(ids, want) {
+					t.Errorf("alice lists %v, want %v", ids, want)
+				}
+			}
+		})
+	}
+}
+
+func grant(t *testing.T, admin *sdk.Client, aceType, id, path, roleName string, allowed bool) {
+	t.Helper()
+	ctx := context.Background()
+	role, err := admin.Roles().ByName(ctx, roleName)
+	check(t, err)
+	ace := schemas.ACECreate{ACEType: &aceType, Path: &path, Propagate: ptr(true), Allowed: &allowed, RoleID: &role.RoleID}
+	if aceType == "user" {
+		ace.UserID = &id
+	} else {
+		ace.GroupID = &id
+	}
+	_, err = admin.ACL().Create(ctx, ace)
+	check(t, err)
+}
+
+func newProject(t *testing.T, c *sdk.Client, name string) schemas.ProjectResponse {
+	t.Helper()
+	p, err := c.Projects().Create(context.Background(), schemas.ProjectCreate{Name: &name})
+	check(t, err)
+	return p
+}
+
+func newNode(t *testing.T, c *sdk.Client, projectID string) schemas.NodeResponse {
+	t.Helper()
+	ctx := context.Background()
+	templates, err := c.Templates().List(ctx)
+	check(t, err)
+	for _, tmpl := range templates {
+		if tmpl.TemplateType == "vpcs" {
+			n, err := c.Nodes(projectID).CreateFromTemplate(ctx, tmpl.TemplateID, 0, 0)
+			check(t, err)
+			return n
+		}
+	}
+	t.Fatal("no vpcs template")
+	return schemas.NodeResponse{}
+}
+
+func TestFlows(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, ctx context.Context, srv *fake.Server, c *sdk.Client)
+	}{
+		{
+			name: "version",
+			run: func(t *testing.T, ctx context.Context, _ *fake.Server, c *sdk.Client) {
+				v, err := c.Version(ctx)
+				check(t, err)
+				if v.Version != fake.ControllerVersion {
+					t.Errorf("version %q, want %q", v.Version, fake.ControllerVersion)
+				}
+			},
+		},
+		{
+			name: "users",
+			run: func(t *testing.T, ctx context.Context, _ *fake.Server, c *sdk.Client) {
+				u, err := c.Users().Create(ctx, schemas.UserCreate{Username: ptr("bob"), Password: ptr("bob12345"), IsActive: true})
+				check(t, err)
+				id := u.UserID.String()
+				updated, err := c.Users().Update(ctx, id, schemas.UserUpdate{FullName: ptr("Bob")})
+				check(t, err)
+				if updated.FullName == nil || *updated.FullName != "Bob" {
+					t.Errorf("full name %v, want Bob", updated.FullName)
+				}
+				if _, err := c.Users().Authenticate(ctx, schemas.Credentials{Username: "bob", Password: "bob12345"}); err != nil {
+					t.Errorf("bob cannot log in: %v", err)
+				}
+				check(t, c.Users().Delete(ctx, id))
+				if _, err := c.Users().Get(ctx, id); !errors.Is(err, api.ErrNotFound) {
+					t.Errorf("get deleted user: %v, want ErrNotFound", err)
+				}
+			},
+		},
+		{
+			name: "group members",
+			run: func(t *testing.T, ctx context.Context, _ *fake.Server, c *sdk.Client) {
+				u, err := c.Users().Create(ctx, schemas.UserCreate{Username: ptr("carol"), Password: ptr("carol123"), IsActive: true})
+				check(t, err)
+				g, err := c.Groups().Create(ctx, "students")
+				check(t, err)
+				groupID, userID := g.UserGroupID.String(), u.UserID.String()
+				check(t, c.Groups().AddMember(ctx, groupID, userID))
+				members, err := c.Groups().Members(ctx, groupID)
+				check(t, err)
+				if len(members) != 1 || members[0].Username != "carol" {
+					t.Errorf("members %v, want carol", members)
+				}
+				groups, err := c.Users().Groups(ctx, userID)
+				check(t, err)
+				if len(groups) != 1 || groups[0].Name != "students" {
+					t.Errorf("groups of carol %v, want students", groups)
+				}
+				if _, err := c.Groups().Create(ctx, "students"); err == nil {
+					t.Error("created a second group named students")
+				}
+				check(t, c.Groups().RemoveMember(ctx, groupID, userID))
+				members, err = c.Groups().Members(ctx, groupID)
+				check(t, err)
+				if len(members) != 0 {
+					t.Errorf("members after remove %v, want none", members)
+				}
+			},
+		},
+		{
+			name: "pool resources",
+			run: func(t *testing.T, ctx context.Context, _ *fake.Server, c *sdk.Client) {
+				p := newProject(t, c, "pooled")
+				pool, err := c.Pools().Create(ctx, "pool")
+				check(t, err)
+				poolID := pool.ResourcePoolID
+				check(t, c.Pools().AddResource(ctx, poolID, p.ProjectID))
+				res, err := c.Pools().Resources(ctx, poolID)
+				check(t, err)
+				if len(res) != 1 || res[0].ResourceID != p.ProjectID {
+					t.Errorf("resources %v, want %s", res, p.ProjectID)
+				}
+				if err := c.Pools().AddResource(ctx, poolID, uuid.NewString()); !errors.Is(err, api.ErrNotFound) {
+					t.Errorf("add an unknown project: %v, want ErrNotFound", err)
+				}
+				check(t, c.Pools().RemoveResource(ctx, poolID, p.ProjectID))
+				res, err = c.Pools().Resources(ctx, poolID)
+				check(t, err)
+				if len(res) != 0 {
+					t.Errorf("resources after remove %v, want none", res)
+				}
+			},
+		},
+		{
+			name: "project names",
+			run: func(t *testing.T, ctx context.Context, _ *fake.Server, c *sdk.Client) {
+				p := newProject(t, c, "original")
+				if _, err := c.Projects().Create(ctx, schemas.ProjectCreate{Name: ptr("original")}); !errors.Is(err, api.ErrConflict) {
+					t.Errorf("create a taken name: %v, want ErrConflict", err)
+				}
+				dup, err := c.Projects().Duplicate(ctx, p.ProjectID, schemas.ProjectDuplicate{Name: "copy"})
+				check(t, err)
+				if dup.Name != "copy" || dup.ProjectID == p.ProjectID {
+					t.Errorf("duplicate %s (%s) of %s", dup.Name, dup.ProjectID, p.ProjectID)
+				}
+				if _, err := c.Projects().Duplicate(ctx, p.ProjectID, schemas.ProjectDuplicate{Name: "copy"}); !errors.Is(err, api.ErrConflict) {
+					t.Errorf("duplicate to a taken name: %v, want ErrConflict", err)
+				}
+			},
+		},
+		{
+			name: "node power needs an opened project",
+			run: func(t *testing.T, ctx context.Context, _ *fake.Server, c *sdk.Client) {
+				p := newProject(t, c, "powered")
+				n := newNode(t, c, p.ProjectID)
+				nodes := c.Nodes(p.ProjectID)
+				check(t, nodes.Start(ctx, n.NodeID))
+				got, err := nodes.Get(ctx, n.NodeID)
+				check(t, err)
+				if got.Status != "started" {
+					t.Errorf("status %q after start", got.Status)
+				}
+				check(t, c.Projects().Close(ctx, p.ProjectID))
+				got, err = nodes.Get(ctx, n.NodeID)
+				check(t, err)
+				if got.Status != "stopped" {
+					t.Errorf("status %q after closing the project", got.Status)
+				}
+				if err := nodes.Start(ctx, n.NodeID); !errors.Is(err, api.ErrConflict) {
+					t.Errorf("start in a closed project: %v, want ErrConflict", err)
+				}
+				check(t, c.Projects().Open(ctx, p.ProjectID))
+				check(t, nodes.StartAll(ctx))
+			},
+		},
+		{
+			name: "links use free ports",
+			run: func(t *testing.T, ctx context.Context, _ *fake.Server, c *sdk.Client) {
+				p := newProject(t, c, "linked")
+				a, b, d := newNode(t, c, p.ProjectID), newNode(t, c, p.ProjectID), newNode(t, c, p.ProjectID)
+				links := c.Links(p.ProjectID)
+				_, err := links.Create(ctx, []schemas.LinkNode{{NodeID: a.NodeID}, {NodeID: b.NodeID}})
+				check(t, err)
+				if _, err := links.Create(ctx, []schemas.LinkNode{{NodeID: a.NodeID}, {NodeID: d.NodeID}}); !errors.Is(err, api.ErrConflict) {
+					t.Errorf("link a used port: %v, want ErrConflict", err)
+				}
+				if _, err := links.Create(ctx, []schemas.LinkNode{{NodeID: d.NodeID, PortNumber: 7}, {NodeID: b.NodeID}}); !errors.Is(err, api.ErrConflict) {
+					t.Errorf("link a missing port: %v, want ErrConflict", err)
+				}
+				check(t, c.Nodes(p.ProjectID).Delete(ctx, b.NodeID))
+				all, err := links.List(ctx)
+				check(t, err)
+				if len(all) != 0 {
+					t.Errorf("links after deleting a node: %v, want none", all)
+				}
+			},
+		},
+		{
+			name: "export and import",
+			run: func(t *testing.T, ctx context.Context, _ *fake.Server, c *sdk.Client) {
+				p := newProject(t, c, "exported")
+				newNode(t, c, p.ProjectID)
+
+				archive, err := c.Projects().Export(ctx, p.ProjectID, sdk.ExportOptions{})
+				check(t, err)
+				imported, err := c.Projects().Import(ctx, uuid.NewString(), "imported", archive)
+				check(t, err)
+				if imported.Name != "imported" || imported.Status == nil || *imported.Status != "closed" {
+					t.Errorf("imported %s with status %v", imported.Name, imported.Status)
+				}
+				nodes, err := c.Nodes(imported.ProjectID).List(ctx)
+				check(t, err)
+				if len(nodes) != 1 {
+					t.Errorf("imported project has %d nodes, want 1", len(nodes))
+				}
+				if _, err := c.Projects().Import(ctx, imported.ProjectID, "again", archive); !errors.Is(err, api.ErrConflict) {
+					t.Errorf("import to a taken id: %v, want ErrConflict", err)
+				}
+				if _, err := c.Projects().Import(ctx, uuid.NewString(), "broken", []byte("not a zip")); !errors.Is(err, api.ErrConflict) {
+					t.Errorf("import a broken archive: %v, want ErrConflict", err)
+				}
+				if _, err := c.Projects().Export(ctx, uuid.NewString(), sdk.ExportOptions{}); !errors.Is(err, api.ErrNotFound) {
+					t.Errorf("export of an unknown project: %v, want ErrNotFound", err)
+				}
+			},
+		},
+		{
+			name: "snapshot restore",
+			run: func(t *testing.T, ctx context.Context, srv *fake.Server, c *sdk.Client) {
+				p := newProject(t, c, "snapped")
+				node := newNode(t, c, p.ProjectID)
+				snap, err := c.Snapshots(p.ProjectID).Create(ctx, "baseline")
+				check(t, err)
+				if _, err := c.Snapshots(p.ProjectID).Create(ctx, "baseline"); !errors.Is(err, api.ErrConflict) {
+					t.Errorf("second snapshot named baseline: %v, want ErrConflict", err)
+				}
+				check(t, c.Nodes(p.ProjectID).Delete(ctx, node.NodeID))
+				newNode(t, c, p.ProjectID)
+
+				path := "/projects/" + p.ProjectID + "/snapshots/" + snap.SnapshotID + "/restore"
+				if status, _, body := request(t, srv, http.MethodPost, path, token(t, srv, fake.DefaultUsername), "", ""); status != http.StatusCreated {
+					t.Fatalf("restore: %d %s", status, body)
+				}
+				nodes, err := c.Nodes(p.ProjectID).List(ctx)
+				check(t, err)
+				if len(nodes) != 1 || nodes[0].NodeID != node.NodeID {
+					t.Errorf("nodes after restore %v, want %s", nodes, node.NodeID)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fake.NewServer()
+			defer srv.Close()
+			tt.run(t, context.Background(), srv, client(t, srv, fake.DefaultUsername))
+		})
+	}
+}
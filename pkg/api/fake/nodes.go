@@ -0,0 +1,640 @@
+package fake
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+)
+
+var nodeTypes = []string{
+	"cloud", "nat", "ethernet_hub", "ethernet_switch", "frame_relay_switch", "atm_switch",
+	"docker", "dynamips", "vpcs", "virtualbox", "vmware", "iou", "qemu",
+}
+
+const firstConsolePort = 5000
+
+func (c *Controller) nodeRoutes(mux *http.ServeMux) {
+	c.route(mux, "GET /projects/{id}/nodes", c.listNodes)
+	c.route(mux, "POST /projects/{id}/nodes", c.createNode)
+	c.route(mux, "POST /projects/{id}/nodes/start", c.powerAll("started"))
+	c.route(mux, "POST /projects/{id}/nodes/stop", c.powerAll("stopped"))
+	c.route(mux, "POST /projects/{id}/nodes/suspend", c.powerAll("suspended"))
+	c.route(mux, "POST /projects/{id}/nodes/reload", c.powerAll("started"))
+	c.route(mux, "GET /projects/{id}/nodes/{nid}", c.getNode)
+	c.route(mux, "PUT /projects/{id}/nodes/{nid}", c.updateNode)
+	c.route(mux, "DELETE /projects/{id}/nodes/{nid}", c.deleteNode)
+	c.route(mux, "POST /projects/{id}/nodes/{nid}/duplicate", c.duplicateNode)
+	c.route(mux, "POST /projects/{id}/nodes/{nid}/start", c.power("started"))
+	c.route(mux, "POST /projects/{id}/nodes/{nid}/stop", c.power("stopped"))
+	c.route(mux, "POST /projects/{id}/nodes/{nid}/suspend", c.power("suspended"))
+	c.route(mux, "POST /projects/{id}/nodes/{nid}/reload", c.power("started"))
+	c.route(mux, "GET /projects/{id}/nodes/{nid}/links", c.nodeLinks)
+
+	c.route(mux, "GET /projects/{id}/links", c.listLinks)
+	c.route(mux, "POST /projects/{id}/links", c.createLink)
+	c.route(mux, "GET /projects/{id}/links/{lid}", c.getLink)
+	c.route(mux, "PUT /projects/{id}/links/{lid}", c.updateLink)
+	c.route(mux, "DELETE /projects/{id}/links/{lid}", c.deleteLink)
+	c.route(mux, "POST /projects/{id}/links/{lid}/reset", c.resetLink)
+	c.route(mux, "GET /projects/{id}/links/{lid}/available_filters", c.linkFilters)
+	c.route(mux, "POST /projects/{id}/links/{lid}/capture/start", c.capture(true))
+	c.route(mux, "POST /projects/{id}/links/{lid}/capture/stop", c.capture(false))
+
+	c.route(mux, "GET /projects/{id}/drawings", c.listDrawings)
+	c.route(mux, "POST /projects/{id}/drawings", c.createDrawing)
+	c.route(mux, "GET /projects/{id}/drawings/{did}", c.getDrawing)
+	c.route(mux, "PUT /projects/{id}/drawings/{did}", c.updateDrawing)
+	c.route(mux, "DELETE /projects/{id}/drawings/{did}", c.deleteDrawing)
+
+	c.route(mux, "POST /projects/{id}/templates/{tid}", c.createNodeFromTemplate)
+}
+
+// ports returns the ports a node of the given type gets on creation. VMs
+// take their adapter count from the "adapters" property.
+func ports(nodeType string, properties map[string]any) []schemas.PortResponse {
+	count := func(key string, def int) int {
+		if v, ok := properties[key].(float64); ok && v > 0 {
+			return int(v)
+		}
+		return def
+	}
+	var out []schemas.PortResponse
+	add := func(name, short string, adapter, port int) {
+		out = append(out, schemas.PortResponse{
+			Name: name, ShortName: short, AdapterNumber: adapter, PortNumber: port, LinkType: "ethernet",
+		})
+	}
+	switch nodeType {
+	case "vpcs":
+		add("Ethernet0", "e0", 0, 0)
+	case "ethernet_switch", "ethernet_hub":
+		for i := range 8 {
+			add(fmt.Sprintf("Ethernet%d", i), fmt.Sprintf("e%d", i), 0, i)
+		}
+	case "nat":
+		add("nat0", "nat0", 0, 0)
+	case "cloud":
+		add("eth0", "eth0", 0, 0)
+	case "iou":
+		for a := range count("ethernet_adapters", 2) {
+			for p := range 4 {
+				add(fmt.Sprintf("Ethernet%d/%d", a, p), fmt.Sprintf("e%d/%d", a, p), a, p)
+			}
+		}
+	case "docker":
+		for i := range count("adapters", 1) {
+			add(fmt.Sprintf("eth%d", i), fmt.Sprintf("eth%d", i), i, 0)
+		}
+	case "qemu", "virtualbox", "vmware", "dynamips":
+		for i := range count("adapters", 1) {
+			add(fmt.Sprintf("Ethernet%d", i), fmt.Sprintf("e%d", i), i, 0)
+		}
+	}
+	return out
+}
+
+func (c *Controller) nextConsolePort() int {
+	used := map[int]bool{}
+	for _, p := range c.projects.list() {
+		for _, n := range p.nodes.list() {
+			if n.Console != nil {
+				used[*n.Console] = true
+			}
+		}
+	}
+	port := firstConsolePort
+	for used[port] {
+		port++
+	}
+	return port
+}
+
+func (c *Controller) lookupNode(r *http.Request) (*project, *schemas.NodeResponse, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	id := r.PathValue("nid")
+	n, ok := p.nodes.get(id)
+	if !ok {
+		return nil, nil, notFound("Node ID %s doesn't exist", id)
+	}
+	return p, n, nil
+}
+
+func (c *Controller) listNodes(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, p.nodes.list(), nil
+}
+
+// addNode fills in the defaults of a new node and adds it to the project.
+func (c *Controller) addNode(p *project, in schemas.NodeCreate) (*schemas.NodeResponse, error) {
+	if !slices.Contains(nodeTypes, *in.NodeType) {
+		return nil, invalid(fieldError{
+			Type:  "enum",
+			Loc:   []any{"body", "node_type"},
+			Msg:   "Input should be one of '" + strings.Join(nodeTypes, "', '") + "'",
+			Input: *in.NodeType,
+		})
+	}
+	if _, ok := c.computes.get(*in.ComputeID); !ok {
+		return nil, notFound("Compute ID %s doesn't exist", *in.ComputeID)
+	}
+
+	n := &schemas.NodeResponse{
+		NodeID:     newID(),
+		ProjectID:  p.ProjectID,
+		ComputeID:  *in.ComputeID,
+		Name:       *in.Name,
+		NodeType:   *in.NodeType,
+		Status:     "stopped",
+		Properties: in.Properties,
+		Locked:     p.locked,
+	}
+	if err := overlay(n, in); err != nil {
+		return nil, err
+	}
+	if n.Properties == nil {
+		n.Properties = map[string]any{}
+	}
+	n.Ports = ports(n.NodeType, n.Properties)
+
+	switch n.NodeType {
+	case "vpcs", "qemu", "iou", "dynamips", "virtualbox", "vmware", "docker", "ethernet_switch":
+		if n.Console == nil {
+			port := c.nextConsolePort()
+			n.Console = &port
+		}
+		if n.ConsoleType == nil {
+			ct := "telnet"
+			if n.NodeType == "ethernet_switch" {
+				ct = "none"
+			}
+			n.ConsoleType = &ct
+		}
+		host := "127.0.0.1"
+		n.ConsoleHost = &host
+	}
+	if n.Symbol == nil {
+		symbol := ":/symbols/computer.svg"
+		n.Symbol = &symbol
+	}
+	if n.Label == nil {
+		n.Label = &schemas.Label{}
+	}
+	n.Label.Text = &n.Name
+
+	p.nodes.put(n.NodeID, n)
+	return n, nil
+}
+
+func (c *Controller) createNode(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var in schemas.NodeCreate
+	if err := decode(r, &in, "name", "node_type", "compute_id"); err != nil {
+		return 0, nil, err
+	}
+	n, err := c.addNode(p, in)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, n, nil
+}
+
+func (c *Controller) getNode(r *http.Request, _ *user) (int, any, error) {
+	_, n, err := c.lookupNode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, n, nil
+}
+
+func (c *Controller) updateNode(r *http.Request, _ *user) (int, any, error) {
+	_, n, err := c.lookupNode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var in schemas.NodeUpdate
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	if in.NodeType != nil && *in.NodeType != n.NodeType {
+		return 0, nil, conflict("Cannot change the type of node %s", n.Name)
+	}
+	if in.ComputeID != nil && *in.ComputeID != n.ComputeID {
+		return 0, nil, conflict("Cannot move node %s to another compute", n.Name)
+	}
+	if err := overlay(n, in); err != nil {
+		return 0, nil, err
+	}
+	if n.Label != nil && in.Name != nil {
+		n.Label.Text = &n.Name
+	}
+	return http.StatusOK, n, nil
+}
+
+func (c *Controller) deleteNode(r *http.Request, _ *user) (int, any, error) {
+	p, n, err := c.lookupNode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if n.Locked {
+		return 0, nil, conflict("Node %s is locked", n.Name)
+	}
+	for _, l := range p.links.list() {
+		if linksNode(l, n.NodeID) {
+			p.links.remove(l.LinkID)
+		}
+	}
+	p.nodes.remove(n.NodeID)
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) duplicateNode(r *http.Request, _ *user) (int, any, error) {
+	p, n, err := c.lookupNode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var in struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+		Z int `json:"z"`
+	}
+	if err := decode(r, &in, "x", "y"); err != nil {
+		return 0, nil, err
+	}
+	name := freeNodeName(p, strings.TrimRight(n.Name, "0123456789")+"{0}")
+	dup, err := c.addNode(p, schemas.NodeCreate{
+		ComputeID:  &n.ComputeID,
+		Name:       &name,
+		NodeType:   &n.NodeType,
+		Properties: n.Properties,
+		Symbol:     n.Symbol,
+		X:          &in.X,
+		Y:          &in.Y,
+		Z:          &in.Z,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	dup.TemplateID = n.TemplateID
+	return http.StatusCreated, dup, nil
+}
+
+func (c *Controller) requireOpened(p *project) error {
+	if p.Status != "opened" {
+		return conflict("Project %s is not opened", p.Name)
+	}
+	return nil
+}
+
+func (c *Controller) power(status string) handler {
+	return func(r *http.Request, _ *user) (int, any, error) {
+		p, n, err := c.lookupNode(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := c.requireOpened(p); err != nil {
+			return 0, nil, err
+		}
+		n.Status = status
+		return http.StatusNoContent, nil, nil
+	}
+}
+
+func (c *Controller) powerAll(status string) handler {
+	return func(r *http.Request, _ *user) (int, any, error) {
+		p, err := c.lookupProject(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		if err := c.requireOpened(p); err != nil {
+			return 0, nil, err
+		}
+		for _, n := range p.nodes.list() {
+			n.Status = status
+		}
+		return http.StatusNoContent, nil, nil
+	}
+}
+
+func linksNode(l *schemas.LinkResponse, nodeID string) bool {
+	return slices.ContainsFunc(l.Nodes, func(ln schemas.LinkNode) bool { return ln.NodeID == nodeID })
+}
+
+func (c *Controller) nodeLinks(r *http.Request, _ *user) (int, any, error) {
+	p, n, err := c.lookupNode(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	out := []*schemas.LinkResponse{}
+	for _, l := range p.links.list() {
+		if linksNode(l, n.NodeID) {
+			out = append(out, l)
+		}
+	}
+	return http.StatusOK, out, nil
+}
+
+func (c *Controller) lookupLink(r *http.Request) (*project, *schemas.LinkResponse, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	id := r.PathValue("lid")
+	l, ok := p.links.get(id)
+	if !ok {
+		return nil, nil, notFound("Link ID %s doesn't exist", id)
+	}
+	return p, l, nil
+}
+
+func (c *Controller) listLinks(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, p.links.list(), nil
+}
+
+// checkLinkNodes validates link endpoints: two distinct nodes of the
+// project with existing ports that are not connected yet.
+func (c *Controller) checkLinkNodes(p *project, nodes []schemas.LinkNode, self string) error {
+	if len(nodes) != 2 {
+		return invalid(fieldError{
+			Type: "too_short",
+			Loc:  []any{"body", "nodes"},
+			Msg:  fmt.Sprintf("List should have 2 items after validation, not %d", len(nodes)),
+		})
+	}
+	if nodes[0].NodeID == nodes[1].NodeID {
+		return conflict("Cannot connect to itself")
+	}
+	for _, ln := range nodes {
+		n, ok := p.nodes.get(ln.NodeID)
+		if !ok {
+			return notFound("Node ID %s doesn't exist", ln.NodeID)
+		}
+		hasPort := slices.ContainsFunc(n.Ports, func(pr schemas.PortResponse) bool {
+			return pr.AdapterNumber == ln.AdapterNumber && pr.PortNumber == ln.PortNumber
+		})
+		if !hasPort {
+			return conflict("Port %d/%d is not found on node %s", ln.AdapterNumber, ln.PortNumber, n.Name)
+		}
+		for _, l := range p.links.list() {
+			if l.LinkID == self {
+				continue
+			}
+			for _, other := range l.Nodes {
+				if other.NodeID == ln.NodeID && other.AdapterNumber == ln.AdapterNumber && other.PortNumber == ln.PortNumber {
+					return conflict("Port %d/%d is already used on node %s", ln.AdapterNumber, ln.PortNumber, n.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type linkInput struct {
+	Nodes   []schemas.LinkNode `json:"nodes"`
+	Filters map[string]any     `json:"filters"`
+	Suspend *bool              `json:"suspend"`
+}
+
+func (c *Controller) createLink(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var in linkInput
+	if err := decode(r, &in, "nodes"); err != nil {
+		return 0, nil, err
+	}
+	if err := c.checkLinkNodes(p, in.Nodes, ""); err != nil {
+		return 0, nil, err
+	}
+	l := &schemas.LinkResponse{
+		LinkID:    newID(),
+		ProjectID: p.ProjectID,
+		LinkType:  "ethernet",
+		Nodes:     in.Nodes,
+		Filters:   in.Filters,
+	}
+	if in.Suspend != nil {
+		l.Suspend = *in.Suspend
+	}
+	p.links.put(l.LinkID, l)
+	return http.StatusCreated, l, nil
+}
+
+func (c *Controller) getLink(r *http.Request, _ *user) (int, any, error) {
+	_, l, err := c.lookupLink(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, l, nil
+}
+
+func (c *Controller) updateLink(r *http.Request, _ *user) (int, any, error) {
+	p, l, err := c.lookupLink(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var in linkInput
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	if in.Nodes != nil {
+		if err := c.checkLinkNodes(p, in.Nodes, l.LinkID); err != nil {
+			return 0, nil, err
+		}
+		l.Nodes = in.Nodes
+	}
+	if in.Filters != nil {
+		l.Filters = in.Filters
+	}
+	if in.Suspend != nil {
+		l.Suspend = *in.Suspend
+	}
+	return http.StatusCreated, l, nil
+}
+
+func (c *Controller) deleteLink(r *http.Request, _ *user) (int, any, error) {
+	p, l, err := c.lookupLink(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	p.links.remove(l.LinkID)
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) resetLink(r *http.Request, _ *user) (int, any, error) {
+	_, l, err := c.lookupLink(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	l.Capturing = false
+	return http.StatusCreated, l, nil
+}
+
+func (c *Controller) linkFilters(r *http.Request, _ *user) (int, any, error) {
+	if _, _, err := c.lookupLink(r); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, []any{}, nil
+}
+
+func (c *Controller) capture(start bool) handler {
+	return func(r *http.Request, _ *user) (int, any, error) {
+		_, l, err := c.lookupLink(r)
+		if err != nil {
+			return 0, nil, err
+		}
+		l.Capturing = start
+		if start {
+			return http.StatusCreated, l, nil
+		}
+		return http.StatusNoContent, nil, nil
+	}
+}
+
+func (c *Controller) lookupDrawing(r *http.Request) (*project, *schemas.DrawingResponse, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	id := r.PathValue("did")
+	d, ok := p.drawings.get(id)
+	if !ok {
+		return nil, nil, notFound("Drawing ID %s doesn't exist", id)
+	}
+	return p, d, nil
+}
+
+func (c *Controller) listDrawings(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, p.drawings.list(), nil
+}
+
+func (c *Controller) createDrawing(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	d := &schemas.DrawingResponse{}
+	if err := decode(r, d, "svg"); err != nil {
+		return 0, nil, err
+	}
+	d.DrawingID = newID()
+	d.ProjectID = p.ProjectID
+	p.drawings.put(d.DrawingID, d)
+	return http.StatusCreated, d, nil
+}
+
+func (c *Controller) getDrawing(r *http.Request, _ *user) (int, any, error) {
+	_, d, err := c.lookupDrawing(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, d, nil
+}
+
+func (c *Controller) updateDrawing(r *http.Request, _ *user) (int, any, error) {
+	_, d, err := c.lookupDrawing(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	updated := *d
+	if err := decode(r, &updated); err != nil {
+		return 0, nil, err
+	}
+	updated.DrawingID, updated.ProjectID = d.DrawingID, d.ProjectID
+	*d = updated
+	return http.StatusCreated, d, nil
+}
+
+func (c *Controller) deleteDrawing(r *http.Request, _ *user) (int, any, error) {
+	p, d, err := c.lookupDrawing(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	p.drawings.remove(d.DrawingID)
+	return http.StatusNoContent, nil, nil
+}
+
+// freeNodeName expands a default_name_format like "PC{0}" or "{name}-{0}"
+// with the first number that is not used by another node of the project.
+func freeNodeName(p *project, format string) string {
+	if !strings.Contains(format, "{0}") {
+		format += "{0}"
+	}
+	for i := 1; ; i++ {
+		name := strings.ReplaceAll(format, "{0}", fmt.Sprint(i))
+		if p.nodes.find(func(n *schemas.NodeResponse) bool { return n.Name == name }) == nil {
+			return name
+		}
+	}
+}
+
+func (c *Controller) createNodeFromTemplate(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	tid := r.PathValue("tid")
+	t, ok := c.templates.get(tid)
+	if !ok {
+		return 0, nil, notFound("Template ID %s doesn't exist", tid)
+	}
+	var in struct {
+		X         int     `json:"x"`
+		Y         int     `json:"y"`
+		Name      *string `json:"name"`
+		ComputeID *string `json:"compute_id"`
+	}
+	if err := decode(r, &in, "x", "y"); err != nil {
+		return 0, nil, err
+	}
+
+	computeID := t.computeID()
+	if in.ComputeID != nil {
+		computeID = *in.ComputeID
+	}
+	name := freeNodeName(p, strings.ReplaceAll(t.str("default_name_format", "{name}-{0}"), "{name}", t.name()))
+	if in.Name != nil {
+		name = *in.Name
+	}
+	nodeType := t.templateType()
+	create := schemas.NodeCreate{
+		ComputeID:  &computeID,
+		Name:       &name,
+		NodeType:   &nodeType,
+		Properties: t.properties(),
+		X:          &in.X,
+		Y:          &in.Y,
+	}
+	if s := t.str("symbol", ""); s != "" {
+		create.Symbol = &s
+	}
+	if ct := t.str("console_type", ""); ct != "" {
+		create.ConsoleType = &ct
+	}
+	n, err := c.addNode(p, create)
+	if err != nil {
+		return 0, nil, err
+	}
+	n.TemplateID = &tid
+	return http.StatusCreated, n, nil
+}
@@ -0,0 +1,608 @@
+package fake
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+)
+
+const projectsDir = "/opt/gns3/projects"
+
+type project struct {
+	ProjectID           string            `json:"project_id"`
+	Name                string            `json:"name"`
+	Status              string            `json:"status"`
+	Path                string            `json:"path"`
+	Filename            string            `json:"filename"`
+	AutoClose           bool              `json:"auto_close"`
+	AutoOpen            bool              `json:"auto_open"`
+	AutoStart           bool              `json:"auto_start"`
+	SceneHeight         int               `json:"scene_height"`
+	SceneWidth          int               `json:"scene_width"`
+	Zoom                int               `json:"zoom"`
+	ShowLayers          bool              `json:"show_layers"`
+	SnapToGrid          bool              `json:"snap_to_grid"`
+	ShowGrid            bool              `json:"show_grid"`
+	GridSize            int               `json:"grid_size"`
+	DrawingGridSize     int               `json:"drawing_grid_size"`
+	ShowInterfaceLabels bool              `json:"show_interface_labels"`
+	Supplier            *schemas.Supplier `json:"supplier"`
+
+	locked    bool
+	nodes     *table[schemas.NodeResponse]
+	links     *table[schemas.LinkResponse]
+	drawings  *table[schemas.DrawingResponse]
+	snapshots *table[snapshot]
+}
+
+type snapshot struct {
+	SnapshotID string `json:"snapshot_id"`
+	ProjectID  string `json:"project_id"`
+	Name       string `json:"name"`
+	CreatedAt  int64  `json:"created_at"`
+	topology   []byte
+}
+
+// topology is the "topology" section of a .gns3 project file, used for
+// snapshots, exports and imports.
+type topology struct {
+	Nodes    []*schemas.NodeResponse    `json:"nodes"`
+	Links    []*schemas.LinkResponse    `json:"links"`
+	Drawings []*schemas.DrawingResponse `json:"drawings"`
+}
+
+// projectFile is the project.gns3 file inside an exported archive.
+type projectFile struct {
+	project
+	Revision int      `json:"revision"`
+	Type     string   `json:"type"`
+	Version  string   `json:"version"`
+	Topology topology `json:"topology"`
+}
+
+func (c *Controller) newProject(id, name string) *project {
+	return &project{
+		ProjectID:       id,
+		Name:            name,
+		Status:          "opened",
+		Path:            projectsDir + "/" + id,
+		Filename:        name + ".gns3",
+		AutoClose:       true,
+		SceneHeight:     1000,
+		SceneWidth:      2000,
+		Zoom:            100,
+		GridSize:        75,
+		DrawingGridSize: 25,
+		nodes:           newTable[schemas.NodeResponse](),
+		links:           newTable[schemas.LinkResponse](),
+		drawings:        newTable[schemas.DrawingResponse](),
+		snapshots:       newTable[snapshot](),
+	}
+}
+
+func (p *project) topology() topology {
+	return topology{Nodes: p.nodes.list(), Links: p.links.list(), Drawings: p.drawings.list()}
+}
+
+// setTopology replaces nodes, links and drawings with a deep copy of t.
+func (p *project) setTopology(t topology) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	var cp topology
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return err
+	}
+	p.nodes = newTable[schemas.NodeResponse]()
+	p.links = newTable[schemas.LinkResponse]()
+	p.drawings = newTable[schemas.DrawingResponse]()
+	for _, n := range cp.Nodes {
+		n.ProjectID = p.ProjectID
+		n.Status = "stopped"
+		p.nodes.put(n.NodeID, n)
+	}
+	for _, l := range cp.Links {
+		l.ProjectID = p.ProjectID
+		p.links.put(l.LinkID, l)
+	}
+	for _, d := range cp.Drawings {
+		d.ProjectID = p.ProjectID
+		p.drawings.put(d.DrawingID, d)
+	}
+	return nil
+}
+
+func (c *Controller) projectRoutes(mux *http.ServeMux) {
+	c.route(mux, "GET /projects", c.listProjects)
+	c.route(mux, "POST /projects", c.createProject)
+	c.route(mux, "GET /projects/{id}", c.getProject)
+	c.route(mux, "PUT /projects/{id}", c.updateProject)
+	c.route(mux, "DELETE /projects/{id}", c.deleteProject)
+	c.route(mux, "GET /projects/{id}/stats", c.projectStats)
+	c.route(mux, "POST /projects/{id}/open", c.openProject)
+	c.route(mux, "POST /projects/{id}/close", c.closeProject)
+	c.route(mux, "POST /projects/{id}/lock", c.lockProject)
+	c.route(mux, "POST /projects/{id}/unlock", c.unlockProject)
+	c.route(mux, "GET /projects/{id}/locked", c.projectLocked)
+	c.route(mux, "POST /projects/{id}/duplicate", c.duplicateProject)
+	c.route(mux, "GET /projects/{id}/export", c.exportProject)
+	c.route(mux, "POST /projects/{id}/import", c.importProject)
+
+	c.route(mux, "GET /projects/{id}/snapshots", c.listSnapshots)
+	c.route(mux, "POST /projects/{id}/snapshots", c.createSnapshot)
+	c.route(mux, "DELETE /projects/{id}/snapshots/{sid}", c.deleteSnapshot)
+	c.route(mux, "POST /projects/{id}/snapshots/{sid}/restore", c.restoreSnapshot)
+}
+
+func (c *Controller) lookupProject(r *http.Request) (*project, error) {
+	id := r.PathValue("id")
+	p, ok := c.projects.get(id)
+	if !ok {
+		return nil, notFound("Project ID %s doesn't exist", id)
+	}
+	return p, nil
+}
+
+func (c *Controller) projectByName(name string) *project {
+	return c.projects.find(func(p *project) bool { return p.Name == name })
+}
+
+// freeProjectName returns name, or name-N for the first N that is not taken
+// yet, like the controller does for imports.
+func (c *Controller) freeProjectName(name string) string {
+	if c.projectByName(name) == nil {
+		return name
+	}
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		if c.projectByName(candidate) == nil {
+			return candidate
+		}
+	}
+}
+
+func validProjectID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return invalid(fieldError{
+			Type:  "uuid_parsing",
+			Loc:   []any{"body", "project_id"},
+			Msg:   "Input should be a valid UUID, " + err.Error(),
+			Input: id,
+		})
+	}
+	return nil
+}
+
+func (c *Controller) listProjects(_ *http.Request, me *user) (int, any, error) {
+	out := []*project{}
+	for _, p := range c.projects.list() {
+		if c.canSeeProject(me, p.ProjectID) {
+			out = append(out, p)
+		}
+	}
+	return http.StatusOK, out, nil
+}
+
+func (c *Controller) createProject(r *http.Request, _ *user) (int, any, error) {
+	var in schemas.ProjectCreate
+	if err := decode(r, &in, "name"); err != nil {
+		return 0, nil, err
+	}
+	id := newID()
+	if in.ProjectID != nil {
+		if err := validProjectID(*in.ProjectID); err != nil {
+			return 0, nil, err
+		}
+		id = *in.ProjectID
+		if _, exists := c.projects.get(id); exists {
+			return 0, nil, conflict("Project ID %s already exists", id)
+		}
+	}
+	if c.projectByName(*in.Name) != nil {
+		return 0, nil, conflict("Project '%s' already exists", *in.Name)
+	}
+
+	p := c.newProject(id, *in.Name)
+	if err := overlay(p, in); err != nil {
+		return 0, nil, err
+	}
+	p.ProjectID = id
+	c.projects.put(p.ProjectID, p)
+	return http.StatusCreated, p, nil
+}
+
+// overlay copies all fields set in src onto dst by round tripping src
+// through JSON.
+func overlay(dst, src any) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+func (c *Controller) getProject(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, p, nil
+}
+
+func (c *Controller) updateProject(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var in schemas.ProjectUpdate
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	if in.Name != nil && *in.Name != p.Name && c.projectByName(*in.Name) != nil {
+		return 0, nil, conflict("Project '%s' already exists", *in.Name)
+	}
+	in.ProjectID, in.Path = nil, nil
+	if err := overlay(p, in); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, p, nil
+}
+
+func (c *Controller) deleteProject(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	c.projects.remove(p.ProjectID)
+	for _, pl := range c.pools.list() {
+		pl.resources = slices.DeleteFunc(pl.resources, func(id string) bool { return id == p.ProjectID })
+	}
+	c.removeACEs(func(a *ace) bool {
+		return a.Path == "/projects/"+p.ProjectID || strings.HasPrefix(a.Path, "/projects/"+p.ProjectID+"/")
+	})
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) projectStats(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, map[string]int{
+		"nodes":     len(p.nodes.order),
+		"links":     len(p.links.order),
+		"drawings":  len(p.drawings.order),
+		"snapshots": len(p.snapshots.order),
+	}, nil
+}
+
+func (c *Controller) openProject(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	p.Status = "opened"
+	return http.StatusCreated, p, nil
+}
+
+func (c *Controller) closeProject(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	p.Status = "closed"
+	for _, n := range p.nodes.list() {
+		n.Status = "stopped"
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) setLocked(r *http.Request, locked bool) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	p.locked = locked
+	for _, n := range p.nodes.list() {
+		n.Locked = locked
+	}
+	for _, d := range p.drawings.list() {
+		d.Locked = locked
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) lockProject(r *http.Request, _ *user) (int, any, error) {
+	return c.setLocked(r, true)
+}
+
+func (c *Controller) unlockProject(r *http.Request, _ *user) (int, any, error) {
+	return c.setLocked(r, false)
+}
+
+func (c *Controller) projectLocked(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, p.locked, nil
+}
+
+func (c *Controller) duplicateProject(r *http.Request, _ *user) (int, any, error) {
+	src, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var in schemas.ProjectDuplicate
+	if err := decode(r, &in, "name"); err != nil {
+		return 0, nil, err
+	}
+	if c.projectByName(in.Name) != nil {
+		return 0, nil, conflict("Project '%s' already exists", in.Name)
+	}
+	id := newID()
+	if in.ProjectID != nil {
+		if err := validProjectID(*in.ProjectID); err != nil {
+			return 0, nil, err
+		}
+		id = *in.ProjectID
+	}
+
+	p := c.newProject(id, in.Name)
+	settings := *src
+	settings.ProjectID, settings.Name, settings.Path, settings.Filename = p.ProjectID, p.Name, p.Path, p.Filename
+	if err := overlay(p, settings); err != nil {
+		return 0, nil, err
+	}
+	if err := p.setTopology(src.topology()); err != nil {
+		return 0, nil, err
+	}
+	p.Status = "closed"
+	c.projects.put(p.ProjectID, p)
+	return http.StatusCreated, p, nil
+}
+
+var compressions = []string{"none", "zip", "bzip2", "lzma", "zstd"}
+
+func (c *Controller) exportProject(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	q := r.URL.Query()
+	if comp := q.Get("compression"); comp != "" && !slices.Contains(compressions, comp) {
+		return 0, nil, &httpError{status: http.StatusUnprocessableEntity, body: map[string]any{"detail": []fieldError{{
+			Type:  "enum",
+			Loc:   []any{"query", "compression"},
+			Msg:   "Input should be 'none', 'zip', 'bzip2', 'lzma' or 'zstd'",
+			Input: comp,
+		}}}}
+	}
+	includeSnapshots, _ := strconv.ParseBool(q.Get("include_snapshots"))
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	file := projectFile{project: *p, Revision: 10, Type: "topology", Version: ControllerVersion, Topology: p.topology()}
+	file.Status = ""
+	if err := writeZipJSON(zw, "project.gns3", file); err != nil {
+		return 0, nil, err
+	}
+	if includeSnapshots {
+		for _, s := range p.snapshots.list() {
+			w, err := zw.Create(fmt.Sprintf("snapshots/%s_%d.gns3", s.Name, s.CreatedAt))
+			if err != nil {
+				return 0, nil, err
+			}
+			if _, err := w.Write(s.topology); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, buf.Bytes(), nil
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v any) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(w).Encode(v)
+}
+
+func (c *Controller) importProject(r *http.Request, _ *user) (int, any, error) {
+	id := r.PathValue("id")
+	if err := validProjectID(id); err != nil {
+		return 0, nil, err
+	}
+	if _, exists := c.projects.get(id); exists {
+		return 0, nil, conflict("Project ID %s already exists", id)
+	}
+
+	ct := r.Header.Get("Content-Type")
+	if !strings.HasPrefix(ct, "multipart/form-data") {
+		return 0, nil, invalid(missing("body", "file"))
+	}
+	f, _, err := r.FormFile("file")
+	if err != nil {
+		return 0, nil, invalid(missing("body", "file"))
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return 0, nil, badRequest("could not read archive: %v", err)
+	}
+
+	file, err := readProjectArchive(data)
+	if err != nil {
+		return 0, nil, conflict("Cannot import project: %v", err)
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = file.Name
+	}
+
+	p := c.newProject(id, c.freeProjectName(name))
+	settings := file.project
+	settings.ProjectID, settings.Name, settings.Path, settings.Filename = p.ProjectID, p.Name, p.Path, p.Name+".gns3"
+	if err := overlay(p, settings); err != nil {
+		return 0, nil, err
+	}
+	if err := p.setTopology(file.Topology); err != nil {
+		return 0, nil, err
+	}
+	for _, snap := range readArchiveSnapshots(data) {
+		snap.ProjectID = p.ProjectID
+		p.snapshots.put(snap.SnapshotID, snap)
+	}
+	p.Status = "closed"
+	c.projects.put(p.ProjectID, p)
+	return http.StatusCreated, p, nil
+}
+
+func readProjectArchive(data []byte) (projectFile, error) {
+	var file projectFile
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return file, fmt.Errorf("not a valid project archive: %w", err)
+	}
+	for _, zf := range zr.File {
+		if !strings.HasSuffix(zf.Name, ".gns3") || strings.Contains(zf.Name, "/") {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return file, err
+		}
+		err = json.NewDecoder(rc).Decode(&file)
+		_ = rc.Close()
+		if err != nil {
+			return file, fmt.Errorf("invalid project file %s: %w", zf.Name, err)
+		}
+		return file, nil
+	}
+	return file, fmt.Errorf("no .gns3 file found in the archive")
+}
+
+// readArchiveSnapshots returns the snapshots/<name>_<created at>.gns3 files
+// of an archive exported with include_snapshots, GNS3 keeps them on import
+// under new IDs.
+func readArchiveSnapshots(data []byte) []*snapshot {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	var snaps []*snapshot
+	for _, zf := range zr.File {
+		base, ok := strings.CutPrefix(zf.Name, "snapshots/")
+		if !ok || !strings.HasSuffix(base, ".gns3") {
+			continue
+		}
+		sep := strings.LastIndex(base, "_")
+		if sep < 0 {
+			continue
+		}
+		createdAt, err := strconv.ParseInt(strings.TrimSuffix(base[sep+1:], ".gns3"), 10, 64)
+		if err != nil {
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			continue
+		}
+		topo, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			continue
+		}
+		snaps = append(snaps, &snapshot{SnapshotID: newID(), Name: base[:sep], CreatedAt: createdAt, topology: topo})
+	}
+	return snaps
+}
+
+func (c *Controller) listSnapshots(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, p.snapshots.list(), nil
+}
+
+func (c *Controller) createSnapshot(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	var in struct {
+		Name string `json:"name"`
+	}
+	if err := decode(r, &in, "name"); err != nil {
+		return 0, nil, err
+	}
+	if p.snapshots.find(func(s *snapshot) bool { return s.Name == in.Name }) != nil {
+		return 0, nil, conflict("The snapshot name %s already exists", in.Name)
+	}
+	topo, err := json.Marshal(p.topology())
+	if err != nil {
+		return 0, nil, err
+	}
+	s := &snapshot{SnapshotID: newID(), ProjectID: p.ProjectID, Name: in.Name, CreatedAt: c.now().Unix(), topology: topo}
+	p.snapshots.put(s.SnapshotID, s)
+	return http.StatusCreated, s, nil
+}
+
+func (c *Controller) lookupSnapshot(r *http.Request, p *project) (*snapshot, error) {
+	id := r.PathValue("sid")
+	s, ok := p.snapshots.get(id)
+	if !ok {
+		return nil, notFound("Snapshot ID %s doesn't exist", id)
+	}
+	return s, nil
+}
+
+func (c *Controller) deleteSnapshot(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	s, err := c.lookupSnapshot(r, p)
+	if err != nil {
+		return 0, nil, err
+	}
+	p.snapshots.remove(s.SnapshotID)
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) restoreSnapshot(r *http.Request, _ *user) (int, any, error) {
+	p, err := c.lookupProject(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	s, err := c.lookupSnapshot(r, p)
+	if err != nil {
+		return 0, nil, err
+	}
+	var topo topology
+	if err := json.Unmarshal(s.topology, &topo); err != nil {
+		return 0, nil, err
+	}
+	if err := p.setTopology(topo); err != nil {
+		return 0, nil, err
+	}
+	p.Status = "opened"
+	return http.StatusCreated, p, nil
+}
@@ -0,0 +1,283 @@
+package fake
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+)
+
+// template keeps the raw settings, templates carry type specific fields
+// that are copied into the properties of nodes created from them.
+type template map[string]any
+
+// templateOnly are the settings that describe the template itself and are
+// not passed on to nodes.
+var templateOnly = []string{
+	"template_id", "template_type", "name", "category", "builtin", "compute_id",
+	"symbol", "default_name_format", "usage", "console_type", "created_at", "updated_at",
+}
+
+func (t *template) str(key, def string) string {
+	if v, ok := (*t)[key].(string); ok && v != "" {
+		return v
+	}
+	return def
+}
+
+func (t *template) id() string           { return t.str("template_id", "") }
+func (t *template) name() string         { return t.str("name", "") }
+func (t *template) templateType() string { return t.str("template_type", "") }
+func (t *template) computeID() string    { return t.str("compute_id", LocalComputeID) }
+
+func (t *template) builtin() bool {
+	b, _ := (*t)["builtin"].(bool)
+	return b
+}
+
+func (t *template) properties() map[string]any {
+	props := maps.Clone(map[string]any(*t))
+	for _, k := range templateOnly {
+		delete(props, k)
+	}
+	return props
+}
+
+type compute struct {
+	ComputeID          string         `json:"compute_id"`
+	Name               string         `json:"name"`
+	Protocol           string         `json:"protocol"`
+	Host               string         `json:"host"`
+	Port               int            `json:"port"`
+	User               *string        `json:"user"`
+	Connected          bool           `json:"connected"`
+	CPUUsagePercent    float64        `json:"cpu_usage_percent"`
+	MemoryUsagePercent float64        `json:"memory_usage_percent"`
+	DiskUsagePercent   float64        `json:"disk_usage_percent"`
+	LastError          *string        `json:"last_error"`
+	Capabilities       map[string]any `json:"capabilities"`
+}
+
+func defaultCategory(templateType string) string {
+	switch templateType {
+	case "ethernet_switch", "ethernet_hub", "frame_relay_switch", "atm_switch":
+		return "switch"
+	case "dynamips", "iou":
+		return "router"
+	}
+	return "guest"
+}
+
+func (c *Controller) seedTemplates() {
+	builtins := []template{
+		{"name": "Cloud", "template_type": "cloud", "default_name_format": "Cloud{0}", "symbol": ":/symbols/cloud.svg"},
+		{"name": "NAT", "template_type": "nat", "default_name_format": "NAT{0}", "symbol": ":/symbols/cloud.svg"},
+		{"name": "VPCS", "template_type": "vpcs", "default_name_format": "PC{0}", "symbol": ":/symbols/vpcs_guest.svg", "console_type": "telnet"},
+		{"name": "Ethernet switch", "template_type": "ethernet_switch", "default_name_format": "Switch{0}", "symbol": ":/symbols/ethernet_switch.svg", "console_type": "none"},
+		{"name": "Ethernet hub", "template_type": "ethernet_hub", "default_name_format": "Hub{0}", "symbol": ":/symbols/hub.svg"},
+		{"name": "Frame Relay switch", "template_type": "frame_relay_switch", "default_name_format": "FR{0}", "symbol": ":/symbols/frame_relay_switch.svg"},
+		{"name": "ATM switch", "template_type": "atm_switch", "default_name_format": "ATM{0}", "symbol": ":/symbols/atm_switch.svg"},
+	}
+	for _, t := range builtins {
+		t["template_id"] = newID()
+		t["builtin"] = true
+		t["compute_id"] = nil
+		t["category"] = defaultCategory(t.templateType())
+		c.templates.put(t.id(), &t)
+	}
+}
+
+func (c *Controller) seedComputes() {
+	c.computes.put(LocalComputeID, &compute{
+		ComputeID:    LocalComputeID,
+		Name:         "local",
+		Protocol:     "http",
+		Host:         "127.0.0.1",
+		Port:         3080,
+		Connected:    true,
+		Capabilities: map[string]any{"version": ControllerVersion, "node_types": nodeTypes, "platform": "linux"},
+	})
+}
+
+func (c *Controller) templateRoutes(mux *http.ServeMux) {
+	c.route(mux, "GET /templates", c.listTemplates)
+	c.route(mux, "POST /templates", c.createTemplate)
+	c.route(mux, "GET /templates/{id}", c.getTemplate)
+	c.route(mux, "PUT /templates/{id}", c.updateTemplate)
+	c.route(mux, "DELETE /templates/{id}", c.deleteTemplate)
+	c.route(mux, "POST /templates/{id}/duplicate", c.duplicateTemplate)
+
+	c.route(mux, "GET /computes", c.listComputes)
+	c.route(mux, "GET /computes/{id}", c.getCompute)
+	c.route(mux, "GET /statistics", c.statistics)
+}
+
+func (c *Controller) lookupTemplate(r *http.Request) (*template, error) {
+	id := r.PathValue("id")
+	t, ok := c.templates.get(id)
+	if !ok {
+		return nil, notFound("Template '%s' not found", id)
+	}
+	return t, nil
+}
+
+func (c *Controller) templateNameTaken(name string, self *template) error {
+	if c.templates.find(func(t *template) bool { return t != self && t.name() == name }) != nil {
+		return conflict("A template with name '%s' already exists", name)
+	}
+	return nil
+}
+
+func (c *Controller) listTemplates(_ *http.Request, _ *user) (int, any, error) {
+	return http.StatusOK, c.templates.list(), nil
+}
+
+func (c *Controller) createTemplate(r *http.Request, _ *user) (int, any, error) {
+	t := template{}
+	if err := decode(r, &t, "name", "template_type"); err != nil {
+		return 0, nil, err
+	}
+	if !slices.Contains(nodeTypes, t.templateType()) {
+		return 0, nil, invalid(fieldError{
+			Type:  "union_tag_invalid",
+			Loc:   []any{"body"},
+			Msg:   "Input tag '" + t.templateType() + "' found using 'template_type' does not match any of the expected tags",
+			Input: t.templateType(),
+		})
+	}
+	if err := c.templateNameTaken(t.name(), nil); err != nil {
+		return 0, nil, err
+	}
+	if _, ok := t["template_id"]; !ok {
+		t["template_id"] = newID()
+	}
+	if _, exists := c.templates.get(t.id()); exists {
+		return 0, nil, conflict("Template '%s' already exists", t.id())
+	}
+	t["builtin"] = false
+	if _, ok := t["compute_id"]; !ok {
+		t["compute_id"] = LocalComputeID
+	}
+	if _, ok := t["category"]; !ok {
+		t["category"] = defaultCategory(t.templateType())
+	}
+	if _, ok := t["default_name_format"]; !ok {
+		t["default_name_format"] = "{name}-{0}"
+	}
+	now := c.timestamp()
+	t["created_at"], t["updated_at"] = now, now
+	c.templates.put(t.id(), &t)
+	return http.StatusCreated, t, nil
+}
+
+func (c *Controller) getTemplate(r *http.Request, _ *user) (int, any, error) {
+	t, err := c.lookupTemplate(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, t, nil
+}
+
+func (c *Controller) updateTemplate(r *http.Request, _ *user) (int, any, error) {
+	t, err := c.lookupTemplate(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if t.builtin() {
+		return 0, nil, forbidden("Template '%s' cannot be updated because it is built-in", t.id())
+	}
+	in := template{}
+	if err := decode(r, &in); err != nil {
+		return 0, nil, err
+	}
+	if name := in.name(); name != "" {
+		if err := c.templateNameTaken(name, t); err != nil {
+			return 0, nil, err
+		}
+	}
+	for _, k := range []string{"template_id", "template_type", "builtin", "created_at"} {
+		delete(in, k)
+	}
+	maps.Copy(*t, in)
+	(*t)["updated_at"] = c.timestamp()
+	return http.StatusOK, t, nil
+}
+
+func (c *Controller) deleteTemplate(r *http.Request, _ *user) (int, any, error) {
+	t, err := c.lookupTemplate(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if t.builtin() {
+		return 0, nil, forbidden("Template '%s' cannot be deleted because it is built-in", t.id())
+	}
+	c.templates.remove(t.id())
+	return http.StatusNoContent, nil, nil
+}
+
+func (c *Controller) duplicateTemplate(r *http.Request, _ *user) (int, any, error) {
+	t, err := c.lookupTemplate(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if t.builtin() {
+		return 0, nil, forbidden("Template '%s' cannot be duplicated because it is built-in", t.id())
+	}
+	dup := maps.Clone(*t)
+	dup["template_id"] = newID()
+	name := t.name() + "-copy"
+	for i := 2; c.templateNameTaken(name, nil) != nil; i++ {
+		name = fmt.Sprintf("%s-copy%d", t.name(), i)
+	}
+	dup["name"] = name
+	now := c.timestamp()
+	dup["created_at"], dup["updated_at"] = now, now
+	c.templates.put(dup.id(), &dup)
+	return http.StatusCreated, dup, nil
+}
+
+func (c *Controller) listComputes(_ *http.Request, _ *user) (int, any, error) {
+	return http.StatusOK, c.computes.list(), nil
+}
+
+func (c *Controller) getCompute(r *http.Request, _ *user) (int, any, error) {
+	id := r.PathValue("id")
+	cp, ok := c.computes.get(id)
+	if !ok {
+		return 0, nil, notFound("Compute ID %s doesn't exist", id)
+	}
+	return http.StatusOK, cp, nil
+}
+
+func (c *Controller) statistics(_ *http.Request, _ *user) (int, any, error) {
+	const gib = 1 << 30
+	out := []schemas.ComputeStatistics{}
+	for _, cp := range c.computes.list() {
+		out = append(out, schemas.ComputeStatistics{
+			ComputeID:   cp.ComputeID,
+			ComputeName: cp.Name,
+			Statistics: schemas.Statistics{
+				MemoryTotal:        16 * gib,
+				MemoryFree:         12 * gib,
+				MemoryUsed:         4 * gib,
+				SwapTotal:          2 * gib,
+				SwapFree:           2 * gib,
+				CPUUsagePercent:    cp.CPUUsagePercent,
+				MemoryUsagePercent: 25,
+				DiskUsagePercent:   cp.DiskUsagePercent,
+				LoadAveragePercent: []float64{0, 0, 0},
+			},
+		})
+	}
+	return http.StatusOK, out, nil
+}
+
+func (c *Controller) getVersion(_ *http.Request, _ *user) (int, any, error) {
+	return http.StatusOK, map[string]any{
+		"controller_host": "127.0.0.1",
+		"version":         ControllerVersion,
+		"local":           true,
+	}, nil
+}
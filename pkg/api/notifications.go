@@ -0,0 +1,451 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/endpoints"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"golang.org/x/net/websocket"
+)
+
+// Actions sent by the controller on its notification streams.
+const (
+	ActionPing             = "ping"
+	ActionNodeCreated      = "node.created"
+	ActionNodeUpdated      = "node.updated"
+	ActionNodeDeleted      = "node.deleted"
+	ActionLinkCreated      = "link.created"
+	ActionLinkUpdated      = "link.updated"
+	ActionLinkDeleted      = "link.deleted"
+	ActionDrawingCreated   = "drawing.created"
+	ActionDrawingUpdated   = "drawing.updated"
+	ActionDrawingDeleted   = "drawing.deleted"
+	ActionComputeCreated   = "compute.created"
+	ActionComputeUpdated   = "compute.updated"
+	ActionComputeDeleted   = "compute.deleted"
+	ActionProjectUpdated   = "project.updated"
+	ActionProjectClosed    = "project.closed"
+	ActionProjectDeleted   = "project.deleted"
+	ActionSnapshotRestored = "snapshot.restored"
+	ActionTemplateCreated  = "template.created"
+	ActionTemplateUpdated  = "template.updated"
+	ActionTemplateDeleted  = "template.deleted"
+	ActionLogError         = "log.error"
+	ActionLogWarning       = "log.warning"
+	ActionLogInfo          = "log.info"
+)
+
+// ErrStopSubscription can be returned by an event handler to end
+// Subscriber.Run without an error.
+var ErrStopSubscription = errors.New("stop subscription")
+
+type PingEvent struct {
+	ComputeID          string  `json:"compute_id,omitempty"`
+	CPUUsagePercent    float64 `json:"cpu_usage_percent"`
+	MemoryUsagePercent float64 `json:"memory_usage_percent"`
+}
+
+type LogEvent struct {
+	Message string `json:"message"`
+}
+
+// Event is a single notification. Payload holds the decoded event as one of
+// the schemas response types, *PingEvent or *LogEvent, or nil for actions
+// without a known payload, in which case Data can be decoded by hand.
+type Event struct {
+	Action    string
+	ProjectID string
+	Data      json.RawMessage
+	Raw       json.RawMessage
+	Payload   any
+}
+
+func (e Event) Node() (*schemas.NodeResponse, bool) {
+	n, ok := e.Payload.(*schemas.NodeResponse)
+	return n, ok
+}
+
+func (e Event) Link() (*schemas.LinkResponse, bool) {
+	l, ok := e.Payload.(*schemas.LinkResponse)
+	return l, ok
+}
+
+func (e Event) Compute() (*schemas.ComputeResponse, bool) {
+	c, ok := e.Payload.(*schemas.ComputeResponse)
+	return c, ok
+}
+
+func (e Event) Project() (*schemas.ProjectResponse, bool) {
+	p, ok := e.Payload.(*schemas.ProjectResponse)
+	return p, ok
+}
+
+func (e Event) Ping() (*PingEvent, bool) {
+	p, ok := e.Payload.(*PingEvent)
+	return p, ok
+}
+
+// ParseEvent decodes a raw notification message.
+func ParseEvent(raw []byte) (Event, error) {
+	var msg struct {
+		Action string          `json:"action"`
+		Event  json.RawMessage `json:"event"`
+	}
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return Event{}, fmt.Errorf("invalid notification: %w", err)
+	}
+	ev := Event{Action: msg.Action, Data: msg.Event, Raw: raw}
+
+	var payload any
+	switch strings.SplitN(msg.Action, ".", 2)[0] {
+	case "ping":
+		payload = &PingEvent{}
+	case "node":
+		payload = &schemas.NodeResponse{}
+	case "link":
+		payload = &schemas.LinkResponse{}
+	case "drawing":
+		payload = &schemas.DrawingResponse{}
+	case "compute":
+		payload = &schemas.ComputeResponse{}
+	case "project":
+		payload = &schemas.ProjectResponse{}
+	case "snapshot":
+		payload = &schemas.SnapshotResponse{}
+	case "template":
+		payload = &schemas.TemplateResponse{}
+	case "log":
+		payload = &LogEvent{}
+	}
+	if payload != nil && len(msg.Event) > 0 {
+		if err := json.Unmarshal(msg.Event, payload); err == nil {
+			ev.Payload = payload
+		}
+	}
+
+	var ids struct {
+		ProjectID string `json:"project_id"`
+	}
+	if len(msg.Event) > 0 && msg.Event[0] == '{' {
+		_ = json.Unmarshal(msg.Event, &ids)
+	}
+	ev.ProjectID = ids.ProjectID
+	return ev, nil
+}
+
+type SubscriberOption func(*Subscriber)
+
+// ForProject subscribes to the notification stream of a single project
+// instead of the controller wide stream.
+func ForProject(projectID string) SubscriberOption {
+	return func(s *Subscriber) {
+		s.projectID = projectID
+	}
+}
+
+// WithActions only delivers events whose action matches one of the given
+// patterns. A pattern is either an exact action like "node.updated" or a
+// prefix like "node.*".
+func WithActions(actions ...string) SubscriberOption {
+	return func(s *Subscriber) {
+		s.actions = append(s.actions, actions...)
+	}
+}
+
+// WithMaxReconnects limits how often the subscriber reconnects in a row
+// after transient failures before giving up. It defaults to the retries of
+// the settings, a negative value reconnects until the context is done.
+func WithMaxReconnects(n int) SubscriberOption {
+	return func(s *Subscriber) {
+		s.maxReconnects = n
+	}
+}
+
+// OnReconnect is called before every reconnect attempt with the error that
+// ended the previous connection.
+func OnReconnect(fn func(attempt int, err error)) SubscriberOption {
+	return func(s *Subscriber) {
+		s.onReconnect = fn
+	}
+}
+
+// Subscriber reads the controller's WebSocket notification stream and
+// reconnects with backoff when the connection drops.
+type Subscriber struct {
+	settings      Settings
+	projectID     string
+	actions       []string
+	maxReconnects int
+	onReconnect   func(attempt int, err error)
+}
+
+func NewSubscriber(settings Settings, opts ...SubscriberOption) *Subscriber {
+	s := &Subscriber{settings: settings, maxReconnects: settings.Retry.MaxRetries}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *Subscriber) wsConfig() (*websocket.Config, error) {
+	base, err := url.Parse(s.settings.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+	origin := *base
+	origin.Path = ""
+
+	switch base.Scheme {
+	case "https":
+		base.Scheme = "wss"
+	default:
+		base.Scheme = "ws"
+	}
+
+	ep := endpoints.GetEndpoints{}
+	path := ep.NotificationsWS()
+	if s.projectID != "" {
+		path = ep.ProjectNotificationsWS(s.projectID)
+	}
+	base.Path = strings.TrimSuffix(base.Path, "/") + path
+	q := base.Query()
+	q.Set("token", s.settings.Token)
+	base.RawQuery = q.Encode()
+
+	cfg, err := websocket.NewConfig(base.String(), origin.String())
+	if err != nil {
+		return nil, err
+	}
+	if !s.settings.Verify {
+		cfg.TlsConfig = &tls.Config{InsecureSkipVerify: true} // #nosec G402
+	}
+	return cfg, nil
+}
+
+func (s *Subscriber) matches(ev Event) bool {
+	if s.projectID != "" && ev.ProjectID != "" && ev.ProjectID != s.projectID {
+		return false
+	}
+	if len(s.actions) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(s.actions, func(pattern string) bool {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			return strings.HasPrefix(ev.Action, prefix)
+		}
+		return ev.Action == pattern
+	})
+}
+
+// Run delivers events to handler until ctx is done, handler returns an
+// error or reconnecting fails too often. Returning ErrStopSubscription from
+// handler ends Run with a nil error.
+func (s *Subscriber) Run(ctx context.Context, handler func(Event) error) error {
+	cfg, err := s.wsConfig()
+	if err != nil {
+		return err
+	}
+
+	attempt := 0
+	reauthed := false
+	for {
+		received, err := s.runOnce(ctx, cfg, handler)
+		var hErr *handlerError
+		switch {
+		case errors.Is(err, ErrStopSubscription):
+			return nil
+		case errors.As(err, &hErr):
+			return hErr.err
+		case ctx.Err() != nil:
+			return nil
+		}
+		if received {
+			attempt = 0
+			reauthed = false
+		}
+		if rejectedHandshake(err) {
+			status := s.handshakeStatus(ctx)
+			switch {
+			case (status == http.StatusUnauthorized || status == http.StatusForbidden) && s.settings.Reauth != nil && !reauthed:
+				reauthed = true
+				token, reauthErr := s.settings.Reauth(ctx)
+				if reauthErr != nil {
+					return fmt.Errorf("notification stream failed: %w", errors.Join(err, reauthErr))
+				}
+				s.settings.Token = token
+				if cfg, err = s.wsConfig(); err != nil {
+					return err
+				}
+				continue
+			case !transientStatus(status):
+				return fmt.Errorf("notification stream failed: %w", handshakeError(status, err))
+			}
+		}
+		if s.maxReconnects >= 0 && attempt >= s.maxReconnects {
+			return fmt.Errorf("notification stream failed: %w", err)
+		}
+		if s.onReconnect != nil {
+			s.onReconnect(attempt+1, err)
+		}
+		if sleepErr := sleepContext(ctx, s.settings.Retry.backoff(attempt)); sleepErr != nil {
+			return nil
+		}
+		attempt++
+	}
+}
+
+// rejectedHandshake reports whether the controller refused the WebSocket
+// upgrade. The websocket package drops the status code of the refusal.
+func rejectedHandshake(err error) bool {
+	var dialErr *websocket.DialError
+	return errors.As(err, &dialErr) && errors.Is(dialErr.Err, websocket.ErrBadStatus)
+}
+
+// handshakeStatus looks up why the controller refused the upgrade by
+// requesting what the stream needs over plain HTTP: the current user for the
+// token and the project for a project stream. It returns the first non 2xx
+// status, 0 if the request failed and 200 if both succeed.
+func (s *Subscriber) handshakeStatus(ctx context.Context) int {
+	settings := s.settings
+	settings.Reauth = nil
+	settings.Retry.MaxRetries = 0
+	client := NewGNS3Client(settings)
+	paths := []string{endpoints.GetEndpoints{}.Me()}
+	if s.projectID != "" {
+		paths = append(paths, endpoints.GetEndpoints{}.Project(s.projectID))
+	}
+	for _, path := range paths {
+		_, resp, err := client.Do(ctx, NewRequestOptions(settings).WithURL(path).WithMethod(GET))
+		if resp == nil {
+			return 0
+		}
+		_ = resp.Body.Close()
+		if err != nil {
+			return resp.StatusCode
+		}
+	}
+	return http.StatusOK
+}
+
+// transientStatus reports whether a refused upgrade with the status of
+// handshakeStatus is worth another attempt. Missing or forbidden resources
+// and a stream that is refused although the REST API works are not.
+func transientStatus(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+func handshakeError(status int, err error) error {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("the controller rejected the token (%d): %w", status, err)
+	case http.StatusNotFound:
+		return fmt.Errorf("the project or endpoint does not exist: %w", err)
+	case http.StatusOK:
+		return fmt.Errorf("the controller refused the stream: %w", err)
+	}
+	return fmt.Errorf("the controller answered %d: %w", status, err)
+}
+
+// handlerError marks errors returned by the event handler so they end Run
+// instead of triggering a reconnect.
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string { return e.err.Error() }
+
+func (e *handlerError) Unwrap() error { return e.err }
+
+// runOnce reads from a single connection and reports whether at least one
+// message was received before it ended.
+func (s *Subscriber) runOnce(ctx context.Context, cfg *websocket.Config, handler func(Event) error) (bool, error) {
+	dialCtx := ctx
+	if s.settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		dialCtx, cancel = context.WithTimeout(ctx, s.settings.Timeout)
+		defer cancel()
+	}
+	conn, err := cfg.DialContext(dialCtx)
+	if err != nil {
+		return false, err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = conn.Close()
+	}()
+
+	received := false
+	for {
+		var raw []byte
+		if err := websocket.Message.Receive(conn, &raw); err != nil {
+			return received, err
+		}
+		received = true
+		ev, err := ParseEvent(raw)
+		if err != nil || !s.matches(ev) {
+			continue
+		}
+		if err := handler(ev); err != nil {
+			if errors.Is(err, ErrStopSubscription) {
+				return received, err
+			}
+			return received, &handlerError{err: err}
+		}
+	}
+}
+
+// Subscribe runs the subscriber in the background and delivers events on
+// the returned channel, which is closed when the subscription ends. The
+// error channel receives at most one error.
+func (s *Subscriber) Subscribe(ctx context.Context) (<-chan Event, <-chan error) {
+	events := make(chan Event)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(events)
+		defer close(errs)
+		err := s.Run(ctx, func(ev Event) error {
+			select {
+			case events <- ev:
+				return nil
+			case <-ctx.Done():
+				return ErrStopSubscription
+			}
+		})
+		if err != nil {
+			errs <- err
+		}
+	}()
+	return events, errs
+}
+
+// WaitFor blocks until an event matching pred arrives and returns it.
+func (s *Subscriber) WaitFor(ctx context.Context, pred func(Event) bool) (Event, error) {
+	var found Event
+	err := s.Run(ctx, func(ev Event) error {
+		if pred(ev) {
+			found = ev
+			return ErrStopSubscription
+		}
+		return nil
+	})
+	if err != nil {
+		return Event{}, err
+	}
+	if found.Action == "" {
+		return Event{}, ctx.Err()
+	}
+	return found, nil
+}
@@ -0,0 +1,371 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"golang.org/x/net/websocket"
+)
+
+func TestParseEvent(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		action    string
+		projectID string
+		payload   any
+		wantErr   bool
+	}{
+		{
+			name:      "node",
+			raw:       `{"action":"node.updated","event":{"node_id":"n1","name":"PC1","project_id":"p1","status":"started"}}`,
+			action:    ActionNodeUpdated,
+			projectID: "p1",
+			payload:   &schemas.NodeResponse{NodeID: "n1", Name: "PC1", ProjectID: "p1", Status: "started"},
+		},
+		{
+			name:    "ping",
+			raw:     `{"action":"ping","event":{"compute_id":"local","cpu_usage_percent":12.5,"memory_usage_percent":40}}`,
+			action:  ActionPing,
+			payload: &PingEvent{ComputeID: "local", CPUUsagePercent: 12.5, MemoryUsagePercent: 40},
+		},
+		{
+			name:    "log",
+			raw:     `{"action":"log.error","event":{"message":"disk full"}}`,
+			action:  ActionLogError,
+			payload: &LogEvent{Message: "disk full"},
+		},
+		{
+			name:      "project closed",
+			raw:       `{"action":"project.closed","event":{"project_id":"p2","name":"lab"}}`,
+			action:    ActionProjectClosed,
+			projectID: "p2",
+			payload:   &schemas.ProjectResponse{ProjectID: "p2", Name: "lab"},
+		},
+		{
+			name:   "unknown action",
+			raw:    `{"action":"custom.thing","event":{"project_id":"p3"}}`,
+			action: "custom.thing",
+			// the project id is read from any object event
+			projectID: "p3",
+		},
+		{
+			name:   "payload of the wrong shape",
+			raw:    `{"action":"node.created","event":["not","a","node"]}`,
+			action: ActionNodeCreated,
+		},
+		{
+			name:   "no event",
+			raw:    `{"action":"ping"}`,
+			action: ActionPing,
+		},
+		{
+			name:    "not json",
+			raw:     `action: ping`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ev, err := ParseEvent([]byte(tt.raw))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parsed %+v, want an error", ev)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ev.Action != tt.action || ev.ProjectID != tt.projectID {
+				t.Errorf("action %q of project %q, want %q of %q", ev.Action, ev.ProjectID, tt.action, tt.projectID)
+			}
+			if !reflect.DeepEqual(ev.Payload, tt.payload) {
+				t.Errorf("payload %#v, want %#v", ev.Payload, tt.payload)
+			}
+			if string(ev.Raw) != tt.raw {
+				t.Errorf("raw %s, want %s", ev.Raw, tt.raw)
+			}
+		})
+	}
+}
+
+func TestSubscriberRefusedStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		dials  int32
+		err    string
+	}{
+		{name: "missing project", status: http.StatusNotFound, dials: 1, err: "does not exist"},
+		{name: "forbidden project", status: http.StatusForbidden, dials: 1, err: "rejected the token (403)"},
+		{name: "busy controller", status: http.StatusServiceUnavailable, dials: 3, err: "bad status"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the stream of the project is always refused, the REST API
+			// tells why
+			var dials atomic.Int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/v3/access/users/me", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"username":"admin"}`))
+			})
+			mux.HandleFunc("/v3/projects/p1", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+			mux.Handle("/v3/projects/p1/notifications/ws", websocket.Server{
+				Handshake: func(*websocket.Config, *http.Request) error {
+					dials.Add(1)
+					return fmt.Errorf("refused")
+				},
+				Handler: func(ws *websocket.Conn) {},
+			})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			s := NewSubscriber(NewSettings(WithBaseURL(srv.URL), WithToken("secret"), fastRetries()), ForProject("p1"), WithMaxReconnects(2))
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := s.Run(ctx, func(Event) error { return nil })
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error %v, want %q", err, tt.err)
+			}
+			if got := dials.Load(); got != tt.dials {
+				t.Errorf("dialed %d times, want %d", got, tt.dials)
+			}
+		})
+	}
+}
+
+func TestSubscriberMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []SubscriberOption
+		ev   Event
+		want bool
+	}{
+		{name: "no filter", ev: Event{Action: ActionPing}, want: true},
+		{name: "exact action", opts: []SubscriberOption{WithActions("node.updated")}, ev: Event{Action: ActionNodeUpdated}, want: true},
+		{name: "other action", opts: []SubscriberOption{WithActions("node.updated")}, ev: Event{Action: ActionNodeCreated}},
+		{name: "prefix", opts: []SubscriberOption{WithActions("node.*")}, ev: Event{Action: ActionNodeDeleted}, want: true},
+		{name: "prefix of another kind", opts: []SubscriberOption{WithActions("node.*")}, ev: Event{Action: ActionLinkCreated}},
+		{name: "any of several", opts: []SubscriberOption{WithActions("link.*"), WithActions("ping")}, ev: Event{Action: ActionPing}, want: true},
+		{name: "own project", opts: []SubscriberOption{ForProject("p1")}, ev: Event{Action: ActionNodeUpdated, ProjectID: "p1"}, want: true},
+		{name: "other project", opts: []SubscriberOption{ForProject("p1")}, ev: Event{Action: ActionNodeUpdated, ProjectID: "p2"}},
+		{name: "event without project", opts: []SubscriberOption{ForProject("p1")}, ev: Event{Action: ActionPing}, want: true},
+		{name: "project and action", opts: []SubscriberOption{ForProject("p1"), WithActions("link.*")}, ev: Event{Action: ActionNodeUpdated, ProjectID: "p1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSubscriber(NewSettings(), tt.opts...)
+			if got := s.matches(tt.ev); got != tt.want {
+				t.Errorf("matches %+v: %v, want %v", tt.ev, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscriberURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseURL string
+		opts    []SubscriberOption
+		want    string
+	}{
+		{name: "controller", baseURL: "http://gns3:3080", want: "ws://gns3:3080/v3/notifications/ws?token=secret"},
+		{name: "tls", baseURL: "https://gns3", want: "wss://gns3/v3/notifications/ws?token=secret"},
+		{name: "project", baseURL: "http://gns3:3080", opts: []SubscriberOption{ForProject("p1")}, want: "ws://gns3:3080/v3/projects/p1/notifications/ws?token=secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSubscriber(NewSettings(WithBaseURL(tt.baseURL), WithToken("secret")), tt.opts...)
+			cfg, err := s.wsConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.Location.String(); got != tt.want {
+				t.Errorf("url %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// wsServer serves one connection after the other, the i-th connection
+// sends the messages of conns[i] and is then closed. Connections past the
+// end get no messages.
+func wsServer(t *testing.T, conns ...[]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var dials atomic.Int32
+	srv := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		defer func() {
+			_ = ws.Close()
+		}()
+		i := int(dials.Add(1)) - 1
+		if i >= len(conns) {
+			return
+		}
+		for _, msg := range conns[i] {
+			if err := websocket.Message.Send(ws, msg); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &dials
+}
+
+func fastRetries() SettingOption {
+	return WithRetryPolicy(RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond})
+}
+
+func TestSubscriberReconnects(t *testing.T) {
+	srv, dials := wsServer(t,
+		[]string{`{"action":"node.created","event":{"node_id":"n1","project_id":"p1"}}`},
+		[]string{`{"action":"ping","event":{}}`, `{"action":"node.deleted","event":{"node_id":"n1","project_id":"p1"}}`},
+	)
+	var reconnects []int
+	s := NewSubscriber(NewSettings(WithBaseURL(srv.URL), WithToken("secret"), fastRetries()),
+		WithActions("node.*"),
+		WithMaxReconnects(5),
+		OnReconnect(func(attempt int, err error) { reconnects = append(reconnects, attempt) }),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var got []string
+	err := s.Run(ctx, func(ev Event) error {
+		got = append(got, ev.Action)
+		if len(got) == 2 {
+			return ErrStopSubscription
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{ActionNodeCreated, ActionNodeDeleted}; !reflect.DeepEqual(got, want) {
+		t.Errorf("events %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(reconnects, []int{1}) || dials.Load() != 2 {
+		t.Errorf("reconnects %v after %d dials, want one reconnect", reconnects, dials.Load())
+	}
+}
+
+func TestSubscriberGivesUp(t *testing.T) {
+	// every connection closes without a message, so the attempts count up
+	srv, dials := wsServer(t)
+	s := NewSubscriber(NewSettings(WithBaseURL(srv.URL), fastRetries()), WithMaxReconnects(2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := s.Run(ctx, func(Event) error { return nil })
+	if err == nil {
+		t.Fatal("want an error after the last reconnect")
+	}
+	if got := dials.Load(); got != 3 {
+		t.Errorf("dialed %d times, want 3", got)
+	}
+}
+
+func TestSubscriberHandlerError(t *testing.T) {
+	srv, dials := wsServer(t, []string{`{"action":"ping","event":{}}`})
+	s := NewSubscriber(NewSettings(WithBaseURL(srv.URL), fastRetries()), WithMaxReconnects(5))
+
+	boom := errors.New("boom")
+	err := s.Run(context.Background(), func(Event) error { return boom })
+	if !errors.Is(err, boom) {
+		t.Errorf("got %v, want the error of the handler", err)
+	}
+	if got := dials.Load(); got != 1 {
+		t.Errorf("dialed %d times, want 1", got)
+	}
+}
+
+// tokenServer serves a notification stream that only accepts the token
+// fresh, and the current user for the REST API with the same rule.
+func tokenServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var dials atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/access/users/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"username":"admin"}`))
+	})
+	mux.Handle("/v3/notifications/ws", websocket.Server{
+		Handshake: func(cfg *websocket.Config, r *http.Request) error {
+			dials.Add(1)
+			if r.URL.Query().Get("token") != "fresh" {
+				return fmt.Errorf("bad token")
+			}
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			_ = websocket.Message.Send(ws, `{"action":"ping","event":{}}`)
+			_ = ws.Close()
+		},
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &dials
+}
+
+func TestSubscriberReauthenticates(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		reauth  Reauthenticator
+		dials   int32
+		wantErr bool
+	}{
+		{
+			name:  "valid token",
+			token: "fresh",
+			dials: 1,
+		},
+		{
+			name:   "expired token",
+			token:  "stale",
+			reauth: func(context.Context) (string, error) { return "fresh", nil },
+			dials:  2,
+		},
+		{
+			name:    "login fails",
+			token:   "stale",
+			reauth:  func(context.Context) (string, error) { return "", errors.New("no password") },
+			dials:   1,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, dials := tokenServer(t)
+			opts := []SettingOption{WithBaseURL(srv.URL), WithToken(tt.token), fastRetries()}
+			if tt.reauth != nil {
+				opts = append(opts, WithReauthenticator(tt.reauth))
+			}
+			s := NewSubscriber(NewSettings(opts...), WithMaxReconnects(0))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, err := s.WaitFor(ctx, func(ev Event) bool { return ev.Action == ActionPing })
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := dials.Load(); got != tt.dials {
+				t.Errorf("dialed %d times, want %d", got, tt.dials)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	DefaultMaxRetries = 3
+	DefaultBaseDelay  = 500 * time.Millisecond
+	DefaultMaxDelay   = 10 * time.Second
+)
+
+// RetryPolicy controls how GNS3ApiClient.Do retries failed requests. Only
+// requests whose method is listed in Methods are retried, which keeps
+// non-idempotent calls like POST from being sent twice by default.
+type RetryPolicy struct {
+	MaxRetries  int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	Methods     []HTTPMethod
+	StatusCodes []int
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: DefaultMaxRetries,
+		BaseDelay:  DefaultBaseDelay,
+		MaxDelay:   DefaultMaxDelay,
+		Methods:    []HTTPMethod{GET, PUT, DELETE},
+		StatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+func WithRetryPolicy(p RetryPolicy) SettingOption {
+	return func(s *Settings) {
+		s.Retry = p
+	}
+}
+
+// WithRetries overrides only the number of retries of the current policy.
+// A negative value keeps the default, zero disables retries.
+func WithRetries(n int) SettingOption {
+	return func(s *Settings) {
+		if n >= 0 {
+			s.Retry.MaxRetries = n
+		}
+	}
+}
+
+// WithRetryDelays overrides the delays of the current policy. A value of
+// zero or less keeps the current one.
+func WithRetryDelays(base, maxDelay time.Duration) SettingOption {
+	return func(s *Settings) {
+		if base > 0 {
+			s.Retry.BaseDelay = base
+		}
+		if maxDelay > 0 {
+			s.Retry.MaxDelay = maxDelay
+		}
+	}
+}
+
+// WithRetryStatusCodes overrides the status codes that are retried. An
+// empty list keeps the current ones.
+func WithRetryStatusCodes(codes []int) SettingOption {
+	return func(s *Settings) {
+		if len(codes) > 0 {
+			s.Retry.StatusCodes = slices.Clone(codes)
+		}
+	}
+}
+
+func (p RetryPolicy) allowsMethod(m HTTPMethod) bool {
+	return p.MaxRetries > 0 && slices.Contains(p.Methods, m)
+}
+
+func (p RetryPolicy) retryableStatus(code int) bool {
+	return slices.Contains(p.StatusCodes, code)
+}
+
+func (p RetryPolicy) maxDelay() time.Duration {
+	if p.MaxDelay <= 0 {
+		return DefaultMaxDelay
+	}
+	return p.MaxDelay
+}
+
+// delay returns the delay before retry number attempt (starting at 0). A
+// Retry-After header of resp wins over the backoff, but never waits longer
+// than MaxDelay so a server asking for an hour cannot stall the command.
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if d, ok := retryAfter(resp); ok {
+		return min(d, p.maxDelay())
+	}
+	return p.backoff(attempt)
+}
+
+// backoff returns the delay before retry number attempt (starting at 0)
+// using exponential backoff with full jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	base := p.BaseDelay
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	maxDelay := p.maxDelay()
+	d := base << min(attempt, 16)
+	if d <= 0 || d > maxDelay {
+		d = maxDelay
+	}
+	return time.Duration(rand.Int64N(int64(d)) + 1) // #nosec G404 -- jitter does not need a CSPRNG
+}
+
+// retryAfter parses the Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+// isTransientErr reports whether a transport error is worth retrying.
+// Cancellation by the caller never is.
+func isTransientErr(parent context.Context, err error) bool {
+	if err == nil || parent.Err() != nil {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
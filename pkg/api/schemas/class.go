@@ -1,21 +1,21 @@
 package schemas
 
 type Student struct {
-	FullName *string `json:"fullName,omitempty"`
-	UserName string  `json:"userName" validate:"required"`
-	Password string  `json:"password" validate:"required,min=8,max=100"`
-	Email    *string `json:"email,omitempty"`
+	FullName *string `json:"fullName,omitempty" yaml:"fullName,omitempty"`
+	UserName string  `json:"userName" yaml:"userName" validate:"required"`
+	Password string  `json:"password" yaml:"password" validate:"required,min=8,max=100"`
+	Email    *string `json:"email,omitempty" yaml:"email,omitempty"`
 }
 
 type Group struct {
-	Name     string    `json:"name" validate:"required"`
-	Students []Student `json:"students" validate:"required"`
+	Name     string    `json:"name" yaml:"name" validate:"required"`
+	Students []Student `json:"students" yaml:"students" validate:"required"`
 }
 
 type Class struct {
-	Name   string  `json:"name" validate:"required"`
-	Desc   string  `json:"description" validate:"omitempty"`
-	Groups []Group `json:"groups" validate:"required"`
+	Name   string  `json:"name" yaml:"name" validate:"required"`
+	Desc   string  `json:"description" yaml:"description" validate:"omitempty"`
+	Groups []Group `json:"groups" yaml:"groups" validate:"required"`
 }
 
 type GroupListElement struct {
@@ -38,6 +38,7 @@ type ProjectResponse struct {
 	DrawingGridSize     *int      `json:"drawing_grid_size,omitempty"`
 	ShowInterfaceLabels *bool     `json:"show_interface_labels,omitempty"`
 	Supplier            *Supplier `json:"supplier,omitempty"`
+	Status              *string   `json:"status,omitempty"`
 }
 
 type ResourcePoolResponse struct {
@@ -60,3 +61,117 @@ type ACLResponse struct {
 	RoleID    *string `json:"role_id,omitempty"`
 	UserID    *string `json:"user_id,omitempty"`
 }
+
+type PoolResourceResponse struct {
+	ResourceID   string `json:"resource_id"`
+	ResourceType string `json:"resource_type"`
+	Name         string `json:"name"`
+}
+
+type PortResponse struct {
+	Name          string `json:"name"`
+	ShortName     string `json:"short_name"`
+	AdapterNumber int    `json:"adapter_number"`
+	PortNumber    int    `json:"port_number"`
+	LinkType      string `json:"link_type"`
+}
+
+type NodeResponse struct {
+	NodeID      string         `json:"node_id"`
+	ProjectID   string         `json:"project_id"`
+	ComputeID   string         `json:"compute_id"`
+	TemplateID  *string        `json:"template_id,omitempty"`
+	Name        string         `json:"name"`
+	NodeType    string         `json:"node_type"`
+	Status      string         `json:"status"`
+	Console     *int           `json:"console,omitempty"`
+	ConsoleHost *string        `json:"console_host,omitempty"`
+	ConsoleType *string        `json:"console_type,omitempty"`
+	Ports       []PortResponse `json:"ports,omitempty"`
+	Properties  map[string]any `json:"properties,omitempty"`
+	Label       *Label         `json:"label,omitempty"`
+	Symbol      *string        `json:"symbol,omitempty"`
+	X           int            `json:"x"`
+	Y           int            `json:"y"`
+	Z           int            `json:"z"`
+	Locked      bool           `json:"locked"`
+}
+
+type LinkNode struct {
+	NodeID        string `json:"node_id"`
+	AdapterNumber int    `json:"adapter_number"`
+	PortNumber    int    `json:"port_number"`
+	Label         *Label `json:"label,omitempty"`
+}
+
+type LinkResponse struct {
+	LinkID    string         `json:"link_id"`
+	ProjectID string         `json:"project_id"`
+	LinkType  string         `json:"link_type"`
+	Nodes     []LinkNode     `json:"nodes"`
+	Filters   map[string]any `json:"filters,omitempty"`
+	Suspend   bool           `json:"suspend"`
+	Capturing bool           `json:"capturing"`
+}
+
+type DrawingResponse struct {
+	DrawingID string `json:"drawing_id"`
+	ProjectID string `json:"project_id"`
+	SVG       string `json:"svg"`
+	X         int    `json:"x"`
+	Y         int    `json:"y"`
+	Z         int    `json:"z"`
+	Locked    bool   `json:"locked"`
+	Rotation  int    `json:"rotation"`
+}
+
+type SnapshotResponse struct {
+	SnapshotID string `json:"snapshot_id"`
+	ProjectID  string `json:"project_id"`
+	Name       string `json:"name"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+type TemplateResponse struct {
+	TemplateID   string  `json:"template_id"`
+	Name         string  `json:"name"`
+	Category     string  `json:"category"`
+	TemplateType string  `json:"template_type"`
+	ComputeID    *string `json:"compute_id,omitempty"`
+	Symbol       *string `json:"symbol,omitempty"`
+	Builtin      bool    `json:"builtin"`
+	RAM          *int    `json:"ram,omitempty"`
+}
+
+type ComputeResponse struct {
+	ComputeID          string   `json:"compute_id"`
+	Name               string   `json:"name"`
+	Protocol           string   `json:"protocol"`
+	Host               string   `json:"host"`
+	Port               int      `json:"port"`
+	User               *string  `json:"user,omitempty"`
+	Connected          bool     `json:"connected"`
+	CPUUsagePercent    *float64 `json:"cpu_usage_percent,omitempty"`
+	MemoryUsagePercent *float64 `json:"memory_usage_percent,omitempty"`
+	DiskUsagePercent   *float64 `json:"disk_usage_percent,omitempty"`
+}
+
+type Statistics struct {
+	MemoryTotal        int64     `json:"memory_total"`
+	MemoryFree         int64     `json:"memory_free"`
+	MemoryUsed         int64     `json:"memory_used"`
+	SwapTotal          int64     `json:"swap_total"`
+	SwapFree           int64     `json:"swap_free"`
+	SwapUsed           int64     `json:"swap_used"`
+	CPUUsagePercent    float64   `json:"cpu_usage_percent"`
+	MemoryUsagePercent float64   `json:"memory_usage_percent"`
+	SwapUsagePercent   float64   `json:"swap_usage_percent"`
+	DiskUsagePercent   float64   `json:"disk_usage_percent"`
+	LoadAveragePercent []float64 `json:"load_average_percent"`
+}
+
+type ComputeStatistics struct {
+	ComputeID   string     `json:"compute_id"`
+	ComputeName string     `json:"compute_name"`
+	Statistics  Statistics `json:"statistics"`
+}
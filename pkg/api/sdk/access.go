@@ -0,0 +1,243 @@
+package sdk
+
+import (
+	"context"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+)
+
+type UsersService struct {
+	c *Client
+}
+
+func (c *Client) Users() *UsersService {
+	return &UsersService{c: c}
+}
+
+func (s *UsersService) List(ctx context.Context) ([]schemas.UserResponse, error) {
+	return get[[]schemas.UserResponse](ctx, s.c, s.c.ep.Get.Users())
+}
+
+func (s *UsersService) Get(ctx context.Context, userID string) (schemas.UserResponse, error) {
+	if err := require(arg{"user id", userID}); err != nil {
+		return schemas.UserResponse{}, err
+	}
+	return get[schemas.UserResponse](ctx, s.c, s.c.ep.Get.User(userID))
+}
+
+func (s *UsersService) Me(ctx context.Context) (schemas.UserResponse, error) {
+	return get[schemas.UserResponse](ctx, s.c, s.c.ep.Get.Me())
+}
+
+func (s *UsersService) Create(ctx context.Context, user schemas.UserCreate) (schemas.UserResponse, error) {
+	if err := require(arg{"username", deref(user.Username)}, arg{"password", deref(user.Password)}); err != nil {
+		return schemas.UserResponse{}, err
+	}
+	return send[schemas.UserResponse](ctx, s.c, api.POST, s.c.ep.Post.CreateUser(), user)
+}
+
+func (s *UsersService) Update(ctx context.Context, userID string, user schemas.UserUpdate) (schemas.UserResponse, error) {
+	if err := require(arg{"user id", userID}); err != nil {
+		return schemas.UserResponse{}, err
+	}
+	return send[schemas.UserResponse](ctx, s.c, api.PUT, s.c.ep.Put.UpdateUser(userID), user)
+}
+
+func (s *UsersService) Delete(ctx context.Context, userID string) error {
+	if err := require(arg{"user id", userID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.DELETE, path: s.c.ep.Delete.DeleteUser(userID)}, nil)
+}
+
+// Groups returns the groups the user is a member of.
+func (s *UsersService) Groups(ctx context.Context, userID string) ([]schemas.UserGroupResponse, error) {
+	if err := require(arg{"user id", userID}); err != nil {
+		return nil, err
+	}
+	return get[[]schemas.UserGroupResponse](ctx, s.c, s.c.ep.Get.GroupMemberships(userID))
+}
+
+// Authenticate exchanges credentials for an access token. The client does
+// not need a token for this call.
+func (s *UsersService) Authenticate(ctx context.Context, creds schemas.Credentials) (schemas.Token, error) {
+	if err := require(arg{"username", creds.Username}, arg{"password", creds.Password}); err != nil {
+		return schemas.Token{}, err
+	}
+	return send[schemas.Token](ctx, s.c, api.POST, s.c.ep.Post.UserAuthenticate(), creds)
+}
+
+type GroupsService struct {
+	c *Client
+}
+
+func (c *Client) Groups() *GroupsService {
+	return &GroupsService{c: c}
+}
+
+func (s *GroupsService) List(ctx context.Context) ([]schemas.UserGroupResponse, error) {
+	return get[[]schemas.UserGroupResponse](ctx, s.c, s.c.ep.Get.Groups())
+}
+
+func (s *GroupsService) Get(ctx context.Context, groupID string) (schemas.UserGroupResponse, error) {
+	if err := require(arg{"group id", groupID}); err != nil {
+		return schemas.UserGroupResponse{}, err
+	}
+	return get[schemas.UserGroupResponse](ctx, s.c, s.c.ep.Get.Group(groupID))
+}
+
+func (s *GroupsService) Create(ctx context.Context, name string) (schemas.UserGroupResponse, error) {
+	if err := require(arg{"group name", name}); err != nil {
+		return schemas.UserGroupResponse{}, err
+	}
+	return send[schemas.UserGroupResponse](ctx, s.c, api.POST, s.c.ep.Post.CreateGroup(), schemas.UserGroupCreate{Name: &name})
+}
+
+func (s *GroupsService) Update(ctx context.Context, groupID string, group schemas.UserGroupUpdate) (schemas.UserGroupResponse, error) {
+	if err := require(arg{"group id", groupID}); err != nil {
+		return schemas.UserGroupResponse{}, err
+	}
+	return send[schemas.UserGroupResponse](ctx, s.c, api.PUT, s.c.ep.Put.UpdateGroup(groupID), group)
+}
+
+func (s *GroupsService) Delete(ctx context.Context, groupID string) error {
+	if err := require(arg{"group id", groupID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.DELETE, path: s.c.ep.Delete.DeleteGroup(groupID)}, nil)
+}
+
+func (s *GroupsService) Members(ctx context.Context, groupID string) ([]schemas.UserResponse, error) {
+	if err := require(arg{"group id", groupID}); err != nil {
+		return nil, err
+	}
+	return get[[]schemas.UserResponse](ctx, s.c, s.c.ep.Get.GroupMembers(groupID))
+}
+
+func (s *GroupsService) AddMember(ctx context.Context, groupID, userID string) error {
+	if err := require(arg{"group id", groupID}, arg{"user id", userID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.PUT, path: s.c.ep.Put.AddGroupMember(groupID, userID)}, nil)
+}
+
+func (s *GroupsService) RemoveMember(ctx context.Context, groupID, userID string) error {
+	if err := require(arg{"group id", groupID}, arg{"user id", userID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.DELETE, path: s.c.ep.Delete.DeleteUserFromGroup(groupID, userID)}, nil)
+}
+
+type RolesService struct {
+	c *Client
+}
+
+func (c *Client) Roles() *RolesService {
+	return &RolesService{c: c}
+}
+
+func (s *RolesService) List(ctx context.Context) ([]schemas.RoleResponse, error) {
+	return get[[]schemas.RoleResponse](ctx, s.c, s.c.ep.Get.Roles())
+}
+
+func (s *RolesService) Get(ctx context.Context, roleID string) (schemas.RoleResponse, error) {
+	if err := require(arg{"role id", roleID}); err != nil {
+		return schemas.RoleResponse{}, err
+	}
+	return get[schemas.RoleResponse](ctx, s.c, s.c.ep.Get.Role(roleID))
+}
+
+// ByName returns the role with the given name or an api.ErrNotFound error.
+func (s *RolesService) ByName(ctx context.Context, name string) (schemas.RoleResponse, error) {
+	roles, err := s.List(ctx)
+	if err != nil {
+		return schemas.RoleResponse{}, err
+	}
+	for _, r := range roles {
+		if r.Name == name {
+			return r, nil
+		}
+	}
+	return schemas.RoleResponse{}, &api.APIError{StatusCode: 404, Message: "role " + name + " not found"}
+}
+
+type ACLService struct {
+	c *Client
+}
+
+func (c *Client) ACL() *ACLService {
+	return &ACLService{c: c}
+}
+
+func (s *ACLService) List(ctx context.Context) ([]schemas.ACLResponse, error) {
+	return get[[]schemas.ACLResponse](ctx, s.c, s.c.ep.Get.ACL())
+}
+
+func (s *ACLService) Create(ctx context.Context, ace schemas.ACECreate) (schemas.ACLResponse, error) {
+	if err := require(arg{"path", deref(ace.Path)}, arg{"role id", deref(ace.RoleID)}); err != nil {
+		return schemas.ACLResponse{}, err
+	}
+	return send[schemas.ACLResponse](ctx, s.c, api.POST, s.c.ep.Post.CreateACL(), ace)
+}
+
+func (s *ACLService) Delete(ctx context.Context, aceID string) error {
+	if err := require(arg{"ace id", aceID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.DELETE, path: s.c.ep.Delete.DeleteACE(aceID)}, nil)
+}
+
+type PoolsService struct {
+	c *Client
+}
+
+func (c *Client) Pools() *PoolsService {
+	return &PoolsService{c: c}
+}
+
+func (s *PoolsService) List(ctx context.Context) ([]schemas.ResourcePoolResponse, error) {
+	return get[[]schemas.ResourcePoolResponse](ctx, s.c, s.c.ep.Get.Pools())
+}
+
+func (s *PoolsService) Get(ctx context.Context, poolID string) (schemas.ResourcePoolResponse, error) {
+	if err := require(arg{"pool id", poolID}); err != nil {
+		return schemas.ResourcePoolResponse{}, err
+	}
+	return get[schemas.ResourcePoolResponse](ctx, s.c, s.c.ep.Get.Pool(poolID))
+}
+
+func (s *PoolsService) Create(ctx context.Context, name string) (schemas.ResourcePoolResponse, error) {
+	if err := require(arg{"pool name", name}); err != nil {
+		return schemas.ResourcePoolResponse{}, err
+	}
+	return send[schemas.ResourcePoolResponse](ctx, s.c, api.POST, s.c.ep.Post.CreatePool(), schemas.ResourcePoolCreate{Name: &name})
+}
+
+func (s *PoolsService) Delete(ctx context.Context, poolID string) error {
+	if err := require(arg{"pool id", poolID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.DELETE, path: s.c.ep.Delete.DeletePool(poolID)}, nil)
+}
+
+func (s *PoolsService) Resources(ctx context.Context, poolID string) ([]schemas.PoolResourceResponse, error) {
+	if err := require(arg{"pool id", poolID}); err != nil {
+		return nil, err
+	}
+	return get[[]schemas.PoolResourceResponse](ctx, s.c, s.c.ep.Get.PoolResources(poolID))
+}
+
+func (s *PoolsService) AddResource(ctx context.Context, poolID, resourceID string) error {
+	if err := require(arg{"pool id", poolID}, arg{"resource id", resourceID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.PUT, path: s.c.ep.Put.AddToPool(poolID, resourceID)}, nil)
+}
+
+func (s *PoolsService) RemoveResource(ctx context.Context, poolID, resourceID string) error {
+	if err := require(arg{"pool id", poolID}, arg{"resource id", resourceID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.DELETE, path: s.c.ep.Delete.DeletePoolResource(poolID, resourceID)}, nil)
+}
@@ -0,0 +1,155 @@
+// Package sdk is a typed client for the GNS3v3 API built on top of
+// api.GNS3ApiClient and the endpoint definitions in pkg/api/endpoints.
+//
+//	client := sdk.NewClient(api.NewSettings(api.WithBaseURL(url), api.WithToken(token)))
+//	projects, err := client.Projects().List(ctx)
+//	err = client.Nodes(projectID).Start(ctx, nodeID)
+//
+// Errors returned by the API are *api.APIError values and can be matched
+// with errors.Is(err, api.ErrNotFound) and friends.
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/endpoints"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+)
+
+// ErrMissingArgument is returned when a required path argument like a
+// project or node id is empty.
+var ErrMissingArgument = errors.New("missing required argument")
+
+type Client struct {
+	settings api.Settings
+	http     *api.GNS3ApiClient
+	ep       endpoints.Endpoints
+}
+
+func NewClient(settings api.Settings) *Client {
+	return &Client{
+		settings: settings,
+		http:     api.NewGNS3Client(settings),
+	}
+}
+
+// Settings returns the settings the client was created with.
+func (c *Client) Settings() api.Settings {
+	return c.settings
+}
+
+type arg struct {
+	name  string
+	value string
+}
+
+func require(args ...arg) error {
+	var missing []string
+	for _, a := range args {
+		if strings.TrimSpace(a.value) == "" {
+			missing = append(missing, a.name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingArgument, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+type request struct {
+	method api.HTTPMethod
+	path   string
+	body   any
+	params map[string]string
+	header map[string]string
+	// upload replaces body with a reader opened for every attempt.
+	upload func() (io.Reader, error)
+	// stream leaves the body of a successful response unread.
+	stream bool
+}
+
+// send performs the request and returns the raw body.
+func (c *Client) send(ctx context.Context, r request) ([]byte, *http.Response, error) {
+	opts := api.NewRequestOptions(c.settings).
+		WithURL(r.path).
+		WithMethod(r.method)
+
+	switch v := r.body.(type) {
+	case nil:
+	case string:
+		opts = opts.WithData(v)
+	case []byte:
+		opts = opts.WithData(string(v))
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		opts = opts.WithData(string(b))
+	}
+	for k, v := range r.params {
+		opts = opts.WithParam(k, v)
+	}
+	for k, v := range r.header {
+		opts = opts.WithHeader(k, v)
+	}
+	if r.upload != nil {
+		opts = opts.WithBody(r.upload)
+	}
+	if r.stream {
+		opts = opts.WithStream()
+	}
+
+	return c.http.Do(ctx, opts)
+}
+
+// do performs the request and decodes the response into out unless out is
+// nil.
+func (c *Client) do(ctx context.Context, r request, out any) error {
+	body, _, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", r.method, r.path, err)
+	}
+	return nil
+}
+
+func get[T any](ctx context.Context, c *Client, path string) (T, error) {
+	var out T
+	err := c.do(ctx, request{method: api.GET, path: path}, &out)
+	return out, err
+}
+
+func send[T any](ctx context.Context, c *Client, method api.HTTPMethod, path string, body any) (T, error) {
+	var out T
+	err := c.do(ctx, request{method: method, path: path, body: body}, &out)
+	return out, err
+}
+
+func (c *Client) Version(ctx context.Context) (schemas.Version, error) {
+	return get[schemas.Version](ctx, c, c.ep.Get.Version())
+}
+
+// Statistics returns the resource usage of every compute of the controller.
+func (c *Client) Statistics(ctx context.Context) ([]schemas.ComputeStatistics, error) {
+	return get[[]schemas.ComputeStatistics](ctx, c, c.ep.Get.Statistics())
+}
@@ -0,0 +1,177 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+)
+
+type NodesService struct {
+	c         *Client
+	projectID string
+}
+
+func (c *Client) Nodes(projectID string) *NodesService {
+	return &NodesService{c: c, projectID: projectID}
+}
+
+func (s *NodesService) List(ctx context.Context) ([]schemas.NodeResponse, error) {
+	if err := require(arg{"project id", s.projectID}); err != nil {
+		return nil, err
+	}
+	return get[[]schemas.NodeResponse](ctx, s.c, s.c.ep.Get.Nodes(s.projectID))
+}
+
+func (s *NodesService) Get(ctx context.Context, nodeID string) (schemas.NodeResponse, error) {
+	if err := require(arg{"project id", s.projectID}, arg{"node id", nodeID}); err != nil {
+		return schemas.NodeResponse{}, err
+	}
+	return get[schemas.NodeResponse](ctx, s.c, s.c.ep.Get.Node(s.projectID, nodeID))
+}
+
+func (s *NodesService) Create(ctx context.Context, node schemas.NodeCreate) (schemas.NodeResponse, error) {
+	if err := require(arg{"project id", s.projectID}); err != nil {
+		return schemas.NodeResponse{}, err
+	}
+	return send[schemas.NodeResponse](ctx, s.c, api.POST, s.c.ep.Post.CreateNode(s.projectID), node)
+}
+
+func (s *NodesService) CreateFromTemplate(ctx context.Context, templateID string, x, y int) (schemas.NodeResponse, error) {
+	if err := require(arg{"project id", s.projectID}, arg{"template id", templateID}); err != nil {
+		return schemas.NodeResponse{}, err
+	}
+	body := map[string]int{"x": x, "y": y}
+	return send[schemas.NodeResponse](ctx, s.c, api.POST, s.c.ep.Post.CreateProjectNodeFromTemplate(s.projectID, templateID), body)
+}
+
+func (s *NodesService) Update(ctx context.Context, nodeID string, node schemas.NodeUpdate) (schemas.NodeResponse, error) {
+	if err := require(arg{"project id", s.projectID}, arg{"node id", nodeID}); err != nil {
+		return schemas.NodeResponse{}, err
+	}
+	return send[schemas.NodeResponse](ctx, s.c, api.PUT, s.c.ep.Put.UpdateNode(s.projectID, nodeID), node)
+}
+
+func (s *NodesService) Delete(ctx context.Context, nodeID string) error {
+	return s.action(ctx, api.DELETE, nodeID, s.c.ep.Delete.DeleteNode)
+}
+
+func (s *NodesService) Start(ctx context.Context, nodeID string) error {
+	return s.action(ctx, api.POST, nodeID, s.c.ep.Post.StartNode)
+}
+
+func (s *NodesService) Stop(ctx context.Context, nodeID string) error {
+	return s.action(ctx, api.POST, nodeID, s.c.ep.Post.StopNode)
+}
+
+func (s *NodesService) Suspend(ctx context.Context, nodeID string) error {
+	return s.action(ctx, api.POST, nodeID, s.c.ep.Post.SuspendNode)
+}
+
+func (s *NodesService) Reload(ctx context.Context, nodeID string) error {
+	return s.action(ctx, api.POST, nodeID, s.c.ep.Post.ReloadNode)
+}
+
+func (s *NodesService) StartAll(ctx context.Context) error {
+	return s.projectAction(ctx, s.c.ep.Post.StartNodes)
+}
+
+func (s *NodesService) StopAll(ctx context.Context) error {
+	return s.projectAction(ctx, s.c.ep.Post.StopNodes)
+}
+
+func (s *NodesService) SuspendAll(ctx context.Context) error {
+	return s.projectAction(ctx, s.c.ep.Post.SuspendNodes)
+}
+
+func (s *NodesService) ReloadAll(ctx context.Context) error {
+	return s.projectAction(ctx, s.c.ep.Post.ReloadNodes)
+}
+
+// Links returns the links connected to the node.
+func (s *NodesService) Links(ctx context.Context, nodeID string) ([]schemas.LinkResponse, error) {
+	if err := require(arg{"project id", s.projectID}, arg{"node id", nodeID}); err != nil {
+		return nil, err
+	}
+	return get[[]schemas.LinkResponse](ctx, s.c, s.c.ep.Get.NodeLinks(s.projectID, nodeID))
+}
+
+func (s *NodesService) action(ctx context.Context, method api.HTTPMethod, nodeID string, path func(string, string) string) error {
+	if err := require(arg{"project id", s.projectID}, arg{"node id", nodeID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: method, path: path(s.projectID, nodeID)}, nil)
+}
+
+func (s *NodesService) projectAction(ctx context.Context, path func(string) string) error {
+	if err := require(arg{"project id", s.projectID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.POST, path: path(s.projectID)}, nil)
+}
+
+type LinksService struct {
+	c         *Client
+	projectID string
+}
+
+func (c *Client) Links(projectID string) *LinksService {
+	return &LinksService{c: c, projectID: projectID}
+}
+
+func (s *LinksService) List(ctx context.Context) ([]schemas.LinkResponse, error) {
+	if err := require(arg{"project id", s.projectID}); err != nil {
+		return nil, err
+	}
+	return get[[]schemas.LinkResponse](ctx, s.c, s.c.ep.Get.Links(s.projectID))
+}
+
+func (s *LinksService) Get(ctx context.Context, linkID string) (schemas.LinkResponse, error) {
+	if err := require(arg{"project id", s.projectID}, arg{"link id", linkID}); err != nil {
+		return schemas.LinkResponse{}, err
+	}
+	return get[schemas.LinkResponse](ctx, s.c, s.c.ep.Get.Link(s.projectID, linkID))
+}
+
+// Create connects the given node ports. At least two endpoints are required.
+func (s *LinksService) Create(ctx context.Context, nodes []schemas.LinkNode) (schemas.LinkResponse, error) {
+	if err := require(arg{"project id", s.projectID}); err != nil {
+		return schemas.LinkResponse{}, err
+	}
+	if len(nodes) < 2 {
+		return schemas.LinkResponse{}, fmt.Errorf("%w: at least two link endpoints", ErrMissingArgument)
+	}
+	body := map[string]any{"nodes": nodes}
+	return send[schemas.LinkResponse](ctx, s.c, api.POST, s.c.ep.Post.CreateLink(s.projectID), body)
+}
+
+func (s *LinksService) Delete(ctx context.Context, linkID string) error {
+	if err := require(arg{"project id", s.projectID}, arg{"link id", linkID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.DELETE, path: s.c.ep.Delete.DeleteLink(s.projectID, linkID)}, nil)
+}
+
+func (s *LinksService) Reset(ctx context.Context, linkID string) error {
+	if err := require(arg{"project id", s.projectID}, arg{"link id", linkID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.POST, path: s.c.ep.Post.ResetLink(s.projectID, linkID)}, nil)
+}
+
+type DrawingsService struct {
+	c         *Client
+	projectID string
+}
+
+func (c *Client) Drawings(projectID string) *DrawingsService {
+	return &DrawingsService{c: c, projectID: projectID}
+}
+
+func (s *DrawingsService) List(ctx context.Context) ([]schemas.DrawingResponse, error) {
+	if err := require(arg{"project id", s.projectID}); err != nil {
+		return nil, err
+	}
+	return get[[]schemas.DrawingResponse](ctx, s.c, s.c.ep.Get.Drawings(s.projectID))
+}
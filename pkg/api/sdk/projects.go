@@ -0,0 +1,265 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"strconv"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+)
+
+type ProjectsService struct {
+	c *Client
+}
+
+func (c *Client) Projects() *ProjectsService {
+	return &ProjectsService{c: c}
+}
+
+func (s *ProjectsService) List(ctx context.Context) ([]schemas.ProjectResponse, error) {
+	return get[[]schemas.ProjectResponse](ctx, s.c, s.c.ep.Get.Projects())
+}
+
+func (s *ProjectsService) Get(ctx context.Context, projectID string) (schemas.ProjectResponse, error) {
+	if err := require(arg{"project id", projectID}); err != nil {
+		return schemas.ProjectResponse{}, err
+	}
+	return get[schemas.ProjectResponse](ctx, s.c, s.c.ep.Get.Project(projectID))
+}
+
+func (s *ProjectsService) Create(ctx context.Context, project schemas.ProjectCreate) (schemas.ProjectResponse, error) {
+	return send[schemas.ProjectResponse](ctx, s.c, api.POST, s.c.ep.Post.CreateProject(), project)
+}
+
+func (s *ProjectsService) Update(ctx context.Context, projectID string, project schemas.ProjectUpdate) (schemas.ProjectResponse, error) {
+	if err := require(arg{"project id", projectID}); err != nil {
+		return schemas.ProjectResponse{}, err
+	}
+	return send[schemas.ProjectResponse](ctx, s.c, api.PUT, s.c.ep.Put.UpdateProject(projectID), project)
+}
+
+func (s *ProjectsService) Delete(ctx context.Context, projectID string) error {
+	return s.action(ctx, api.DELETE, projectID, s.c.ep.Delete.DeleteProject)
+}
+
+func (s *ProjectsService) Open(ctx context.Context, projectID string) error {
+	return s.action(ctx, api.POST, projectID, s.c.ep.Post.OpenProject)
+}
+
+func (s *ProjectsService) Close(ctx context.Context, projectID string) error {
+	return s.action(ctx, api.POST, projectID, s.c.ep.Post.CloseProject)
+}
+
+func (s *ProjectsService) Lock(ctx context.Context, projectID string) error {
+	return s.action(ctx, api.POST, projectID, s.c.ep.Post.LockProject)
+}
+
+func (s *ProjectsService) Unlock(ctx context.Context, projectID string) error {
+	return s.action(ctx, api.POST, projectID, s.c.ep.Post.UnlockProject)
+}
+
+func (s *ProjectsService) Locked(ctx context.Context, projectID string) (bool, error) {
+	if err := require(arg{"project id", projectID}); err != nil {
+		return false, err
+	}
+	return get[bool](ctx, s.c, s.c.ep.Get.ProjectLocked(projectID))
+}
+
+func (s *ProjectsService) Duplicate(ctx context.Context, projectID string, dup schemas.ProjectDuplicate) (schemas.ProjectResponse, error) {
+	if err := require(arg{"project id", projectID}, arg{"project name", dup.Name}); err != nil {
+		return schemas.ProjectResponse{}, err
+	}
+	return send[schemas.ProjectResponse](ctx, s.c, api.POST, s.c.ep.Post.DuplicateProject(projectID), dup)
+}
+
+// ExportOptions maps to the query parameters of the project export
+// endpoint. The zero value exports without snapshots and images using the
+// server defaults for compression.
+type ExportOptions struct {
+	IncludeSnapshots  bool
+	IncludeImages     bool
+	ResetMacAddresses bool
+	KeepComputeIDs    bool
+	Compression       string
+	CompressionLevel  int
+}
+
+func (o ExportOptions) params() map[string]string {
+	p := map[string]string{
+		"include_snapshots":   strconv.FormatBool(o.IncludeSnapshots),
+		"include_images":      strconv.FormatBool(o.IncludeImages),
+		"reset_mac_addresses": strconv.FormatBool(o.ResetMacAddresses),
+		"keep_compute_ids":    strconv.FormatBool(o.KeepComputeIDs),
+	}
+	if o.Compression != "" {
+		p["compression"] = o.Compression
+	}
+	if o.CompressionLevel > 0 {
+		p["compression_level"] = strconv.Itoa(o.CompressionLevel)
+	}
+	return p
+}
+
+// Export returns the project as a .gns3project archive.
+func (s *ProjectsService) Export(ctx context.Context, projectID string, opts ExportOptions) ([]byte, error) {
+	if err := require(arg{"project id", projectID}); err != nil {
+		return nil, err
+	}
+	body, _, err := s.c.send(ctx, request{
+		method: api.GET,
+		path:   s.c.ep.Get.ProjectExport(projectID),
+		params: opts.params(),
+	})
+	return body, err
+}
+
+// ExportTo writes the project as a .gns3project archive to w while it is
+// downloaded and returns the number of bytes written.
+func (s *ProjectsService) ExportTo(ctx context.Context, projectID string, opts ExportOptions, w io.Writer) (int64, error) {
+	if err := require(arg{"project id", projectID}); err != nil {
+		return 0, err
+	}
+	_, resp, err := s.c.send(ctx, request{
+		method: api.GET,
+		path:   s.c.ep.Get.ProjectExport(projectID),
+		params: opts.params(),
+		stream: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	return io.Copy(w, resp.Body)
+}
+
+// Import uploads a .gns3project archive as a new project with the given id
+// and name.
+func (s *ProjectsService) Import(ctx context.Context, projectID, name string, archive []byte) (schemas.ProjectResponse, error) {
+	if err := require(arg{"project id", projectID}, arg{"project name", name}); err != nil {
+		return schemas.ProjectResponse{}, err
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	fw, err := w.CreateFormFile("file", fmt.Sprintf("%s.gns3project", name))
+	if err != nil {
+		return schemas.ProjectResponse{}, err
+	}
+	if _, err := fw.Write(archive); err != nil {
+		return schemas.ProjectResponse{}, err
+	}
+	if err := w.Close(); err != nil {
+		return schemas.ProjectResponse{}, err
+	}
+
+	var out schemas.ProjectResponse
+	err = s.c.do(ctx, request{
+		method: api.POST,
+		path:   s.c.ep.Post.ProjectImport(projectID),
+		body:   buf.Bytes(),
+		params: map[string]string{"name": name},
+		header: map[string]string{"Content-Type": w.FormDataContentType()},
+	}, &out)
+	return out, err
+}
+
+// ImportFile uploads the .gns3project archive at path as a new project with
+// the given id and name, reading the file while it is sent.
+func (s *ProjectsService) ImportFile(ctx context.Context, projectID, name, path string) (schemas.ProjectResponse, error) {
+	if err := require(arg{"project id", projectID}, arg{"project name", name}, arg{"archive path", path}); err != nil {
+		return schemas.ProjectResponse{}, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return schemas.ProjectResponse{}, err
+	}
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	open := func() (io.Reader, error) {
+		f, err := os.Open(path) // #nosec G304
+		if err != nil {
+			return nil, err
+		}
+		pr, pw := io.Pipe()
+		go func() {
+			defer func() {
+				_ = f.Close()
+			}()
+			w := multipart.NewWriter(pw)
+			err := w.SetBoundary(boundary)
+			var fw io.Writer
+			if err == nil {
+				fw, err = w.CreateFormFile("file", fmt.Sprintf("%s.gns3project", name))
+			}
+			if err == nil {
+				_, err = io.Copy(fw, f)
+			}
+			if err == nil {
+				err = w.Close()
+			}
+			_ = pw.CloseWithError(err)
+		}()
+		return pr, nil
+	}
+
+	var out schemas.ProjectResponse
+	err := s.c.do(ctx, request{
+		method: api.POST,
+		path:   s.c.ep.Post.ProjectImport(projectID),
+		upload: open,
+		params: map[string]string{"name": name},
+		header: map[string]string{"Content-Type": "multipart/form-data; boundary=" + boundary},
+	}, &out)
+	return out, err
+}
+
+func (s *ProjectsService) action(ctx context.Context, method api.HTTPMethod, projectID string, path func(string) string) error {
+	if err := require(arg{"project id", projectID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: method, path: path(projectID)}, nil)
+}
+
+type SnapshotsService struct {
+	c         *Client
+	projectID string
+}
+
+func (c *Client) Snapshots(projectID string) *SnapshotsService {
+	return &SnapshotsService{c: c, projectID: projectID}
+}
+
+func (s *SnapshotsService) List(ctx context.Context) ([]schemas.SnapshotResponse, error) {
+	if err := require(arg{"project id", s.projectID}); err != nil {
+		return nil, err
+	}
+	return get[[]schemas.SnapshotResponse](ctx, s.c, s.c.ep.Get.Snapshots(s.projectID))
+}
+
+func (s *SnapshotsService) Create(ctx context.Context, name string) (schemas.SnapshotResponse, error) {
+	if err := require(arg{"project id", s.projectID}, arg{"snapshot name", name}); err != nil {
+		return schemas.SnapshotResponse{}, err
+	}
+	return send[schemas.SnapshotResponse](ctx, s.c, api.POST, s.c.ep.Post.CreateSnapshot(s.projectID), schemas.SnapshotCreate{Name: &name})
+}
+
+func (s *SnapshotsService) Delete(ctx context.Context, snapshotID string) error {
+	if err := require(arg{"project id", s.projectID}, arg{"snapshot id", snapshotID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.DELETE, path: s.c.ep.Delete.DeleteSnapshot(s.projectID, snapshotID)}, nil)
+}
+
+// Restore rolls the project back to the snapshot and returns the restored
+// project.
+func (s *SnapshotsService) Restore(ctx context.Context, snapshotID string) (schemas.ProjectResponse, error) {
+	if err := require(arg{"project id", s.projectID}, arg{"snapshot id", snapshotID}); err != nil {
+		return schemas.ProjectResponse{}, err
+	}
+	return send[schemas.ProjectResponse](ctx, s.c, api.POST, s.c.ep.Post.RestoreSnapshot(s.projectID, snapshotID), nil)
+}
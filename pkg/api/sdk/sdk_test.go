@@ -0,0 +1,262 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
+)
+
+// recorded is the request a stub server received.
+type recorded struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   string
+}
+
+// stub answers every request with status and body and records it.
+func stub(t *testing.T, status int, body string) (*sdk.Client, *recorded) {
+	t.Helper()
+	got := &recorded{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		*got = recorded{method: r.Method, path: r.URL.Path, query: r.URL.Query(), header: r.Header.Clone(), body: string(data)}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return sdk.NewClient(api.NewSettings(api.WithBaseURL(srv.URL), api.WithToken("token"), api.WithRetries(0))), got
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+const projectID = "0b7a4b8e-8d6c-4b0a-9c3f-5a1d2e3f4a5b"
+
+func TestRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context, c *sdk.Client) (any, error)
+		response string
+		method   string
+		path     string
+		body     string
+		want     any
+	}{
+		{
+			name:     "version",
+			call:     func(ctx context.Context, c *sdk.Client) (any, error) { return c.Version(ctx) },
+			response: `{"version":"3.0.5","controller_host":"gns3"}`,
+			method:   http.MethodGet,
+			path:     "/v3/version",
+			want:     schemas.Version{Version: "3.0.5", ControllerHost: "gns3"},
+		},
+		{
+			name: "create user",
+			call: func(ctx context.Context, c *sdk.Client) (any, error) {
+				u, err := c.Users().Create(ctx, schemas.UserCreate{Username: ptr("bob"), Password: ptr("bob12345"), IsActive: true})
+				return u.Username, err
+			},
+			response: `{"username":"bob","is_active":true}`,
+			method:   http.MethodPost,
+			path:     "/v3/access/users",
+			body:     `{"username":"bob","is_active":true,"email":null,"full_name":null,"password":"bob12345"}`,
+			want:     "bob",
+		},
+		{
+			name: "add group member",
+			call: func(ctx context.Context, c *sdk.Client) (any, error) {
+				return nil, c.Groups().AddMember(ctx, "g1", "u1")
+			},
+			method: http.MethodPut,
+			path:   "/v3/access/groups/g1/members/u1",
+		},
+		{
+			name: "list nodes",
+			call: func(ctx context.Context, c *sdk.Client) (any, error) {
+				nodes, err := c.Nodes(projectID).List(ctx)
+				return len(nodes), err
+			},
+			response: `[{"node_id":"n1","name":"PC1"},{"node_id":"n2","name":"PC2"}]`,
+			method:   http.MethodGet,
+			path:     "/v3/projects/" + projectID + "/nodes",
+			want:     2,
+		},
+		{
+			name: "start node",
+			call: func(ctx context.Context, c *sdk.Client) (any, error) {
+				return nil, c.Nodes(projectID).Start(ctx, "n1")
+			},
+			method: http.MethodPost,
+			path:   "/v3/projects/" + projectID + "/nodes/n1/start",
+		},
+		{
+			name: "delete project",
+			call: func(ctx context.Context, c *sdk.Client) (any, error) {
+				return nil, c.Projects().Delete(ctx, projectID)
+			},
+			method: http.MethodDelete,
+			path:   "/v3/projects/" + projectID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, got := stub(t, http.StatusOK, tt.response)
+			out, err := tt.call(context.Background(), c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.method != tt.method || got.path != tt.path {
+				t.Errorf("sent %s %s, want %s %s", got.method, got.path, tt.method, tt.path)
+			}
+			if got.body != tt.body {
+				t.Errorf("sent body %s, want %s", got.body, tt.body)
+			}
+			if auth := got.header.Get("Authorization"); auth != "Bearer token" {
+				t.Errorf("authorization %q", auth)
+			}
+			if out != tt.want {
+				t.Errorf("got %#v, want %#v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportParams(t *testing.T) {
+	c, got := stub(t, http.StatusOK, "archive")
+	archive, err := c.Projects().Export(context.Background(), projectID, sdk.ExportOptions{IncludeSnapshots: true, Compression: "zip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(archive) != "archive" {
+		t.Errorf("archive %q", archive)
+	}
+	want := url.Values{
+		"include_snapshots":   {"true"},
+		"include_images":      {"false"},
+		"reset_mac_addresses": {"false"},
+		"keep_compute_ids":    {"false"},
+		"compression":         {"zip"},
+	}
+	if got.query.Encode() != want.Encode() {
+		t.Errorf("query %s, want %s", got.query.Encode(), want.Encode())
+	}
+}
+
+func TestExportTo(t *testing.T) {
+	c, got := stub(t, http.StatusOK, "archive")
+	var buf strings.Builder
+	n, err := c.Projects().ExportTo(context.Background(), projectID, sdk.ExportOptions{IncludeSnapshots: true}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 7 || buf.String() != "archive" || got.query.Get("include_snapshots") != "true" {
+		t.Errorf("wrote %d bytes %q with query %s", n, buf.String(), got.query.Encode())
+	}
+
+	// an error body is not written as the archive
+	c, _ = stub(t, http.StatusNotFound, `{"message":"Project ID x doesn't exist"}`)
+	buf.Reset()
+	if _, err := c.Projects().ExportTo(context.Background(), projectID, sdk.ExportOptions{}, &buf); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("export of a missing project: %v, want ErrNotFound", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("wrote %q for a missing project", buf.String())
+	}
+}
+
+func TestImportMultipart(t *testing.T) {
+	c, got := stub(t, http.StatusCreated, `{"project_id":"`+projectID+`","name":"lab"}`)
+	p, err := c.Projects().Import(context.Background(), projectID, "lab", []byte("zip data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "lab" || got.query.Get("name") != "lab" {
+		t.Errorf("imported %+v with query %s", p, got.query.Encode())
+	}
+	mediaType, params, err := mime.ParseMediaType(got.header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		t.Fatalf("content type %q: %v", got.header.Get("Content-Type"), err)
+	}
+	part, err := multipart.NewReader(strings.NewReader(got.body), params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(part)
+	if part.FormName() != "file" || part.FileName() != "lab.gns3project" || string(data) != "zip data" {
+		t.Errorf("part %s %s with %q", part.FormName(), part.FileName(), data)
+	}
+}
+
+func TestImportFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lab.gns3project")
+	if err := os.WriteFile(path, []byte("zip data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, got := stub(t, http.StatusCreated, `{"project_id":"`+projectID+`","name":"lab"}`)
+	p, err := c.Projects().ImportFile(context.Background(), projectID, "lab", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "lab" || got.query.Get("name") != "lab" {
+		t.Errorf("imported %+v with query %s", p, got.query.Encode())
+	}
+	_, params, err := mime.ParseMediaType(got.header.Get("Content-Type"))
+	if err != nil {
+		t.Fatalf("content type %q: %v", got.header.Get("Content-Type"), err)
+	}
+	part, err := multipart.NewReader(strings.NewReader(got.body), params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(part)
+	if part.FormName() != "file" || part.FileName() != "lab.gns3project" || string(data) != "zip data" {
+		t.Errorf("part %s %s with %q", part.FormName(), part.FileName(), data)
+	}
+
+	if _, err := c.Projects().ImportFile(context.Background(), projectID, "lab", filepath.Join(t.TempDir(), "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("import of a missing file: %v, want ErrNotExist", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c, _ := stub(t, http.StatusNotFound, `{"message":"Project ID x doesn't exist"}`)
+	_, err := c.Projects().Get(context.Background(), projectID)
+	if !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("get: %v, want ErrNotFound", err)
+	}
+
+	c, got := stub(t, http.StatusOK, `{}`)
+	_, projectErr := c.Projects().Get(context.Background(), " ")
+	_, nodesErr := c.Nodes("").List(context.Background())
+	_, userErr := c.Users().Create(context.Background(), schemas.UserCreate{Username: ptr("bob")})
+	for name, err := range map[string]error{
+		"project get":   projectErr,
+		"node list":     nodesErr,
+		"user create":   userErr,
+		"pool resource": c.Pools().AddResource(context.Background(), "pool", ""),
+	} {
+		if !errors.Is(err, sdk.ErrMissingArgument) {
+			t.Errorf("%s: %v, want ErrMissingArgument", name, err)
+		}
+	}
+	if got.method != "" {
+		t.Errorf("sent %s %s for a missing argument", got.method, got.path)
+	}
+}
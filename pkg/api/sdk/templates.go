@@ -0,0 +1,53 @@
+package sdk
+
+import (
+	"context"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+)
+
+type TemplatesService struct {
+	c *Client
+}
+
+func (c *Client) Templates() *TemplatesService {
+	return &TemplatesService{c: c}
+}
+
+func (s *TemplatesService) List(ctx context.Context) ([]schemas.TemplateResponse, error) {
+	return get[[]schemas.TemplateResponse](ctx, s.c, s.c.ep.Get.Templates())
+}
+
+func (s *TemplatesService) Get(ctx context.Context, templateID string) (schemas.TemplateResponse, error) {
+	if err := require(arg{"template id", templateID}); err != nil {
+		return schemas.TemplateResponse{}, err
+	}
+	return get[schemas.TemplateResponse](ctx, s.c, s.c.ep.Get.Template(templateID))
+}
+
+func (s *TemplatesService) Delete(ctx context.Context, templateID string) error {
+	if err := require(arg{"template id", templateID}); err != nil {
+		return err
+	}
+	return s.c.do(ctx, request{method: api.DELETE, path: s.c.ep.Delete.DeleteTemplate(templateID)}, nil)
+}
+
+type ComputesService struct {
+	c *Client
+}
+
+func (c *Client) Computes() *ComputesService {
+	return &ComputesService{c: c}
+}
+
+func (s *ComputesService) List(ctx context.Context) ([]schemas.ComputeResponse, error) {
+	return get[[]schemas.ComputeResponse](ctx, s.c, s.c.ep.Get.Computes())
+}
+
+func (s *ComputesService) Get(ctx context.Context, computeID string) (schemas.ComputeResponse, error) {
+	if err := require(arg{"compute id", computeID}); err != nil {
+		return schemas.ComputeResponse{}, err
+	}
+	return get[schemas.ComputeResponse](ctx, s.c, s.c.ep.Get.Compute(computeID))
+}
@@ -1,12 +1,14 @@
 package authentication
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/stefanistkuhl/gns3util/pkg/api"
 	"github.com/stefanistkuhl/gns3util/pkg/api/endpoints"
@@ -16,64 +18,102 @@ import (
 	"github.com/stefanistkuhl/gns3util/pkg/utils/pathUtils"
 )
 
+// LoadKeys returns the keys of the configured key store. keyFileLocation
+// overrides the location of file based stores.
 func LoadKeys(keyFileLocation string) ([]pathUtils.GNS3Key, error) {
-	var filePath string
+	store, err := DefaultKeyStore(keyFileLocation)
+	if err != nil {
+		return nil, err
+	}
+	return store.Load()
+}
 
-	if keyFileLocation == "" {
-		dir, err := pathUtils.GetGNS3Dir()
+// TryKeys checks the key selected for cfg among keys against the server.
+func TryKeys(keys []pathUtils.GNS3Key, cfg config.GlobalOptions) ([]byte, error) {
+	if key, ok := selectKey(keys, cfg); ok {
+		result, success, err := tryKey(key, cfg)
 		if err != nil {
 			return nil, err
 		}
-		filePath = filepath.Join(dir, "gns3key")
-	} else {
-		expanded, err := pathUtils.ExpandPath(keyFileLocation)
-		if err != nil {
-			return nil, err
+		if success {
+			return result, nil
 		}
-		filePath = expanded
+	}
+	return nil, fmt.Errorf("no working API-Key found for %s. Please use the %s command to authenticate. ", identity(cfg), messageUtils.Bold("auth login"))
+}
 
-		if info, err := os.Stat(filePath); err == nil && info.IsDir() {
-			filePath = filepath.Join(filePath, "gns3key")
-		} else if err != nil && !os.IsNotExist(err) {
-			return nil, err
+// ServerKey normalizes a server URL to scheme://host:port so keys only
+// match the exact controller. A missing port defaults to the one of the
+// scheme.
+func ServerKey(raw string) string {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Hostname() == "" {
+		return strings.ToLower(strings.TrimRight(raw, "/"))
+	}
+	scheme := strings.ToLower(u.Scheme)
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if scheme == "https" {
+			port = "443"
 		}
 	}
-
-	keys, err := pathUtils.LoadGNS3KeysFile(filePath)
-	return keys, err
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(strings.ToLower(u.Hostname()), port))
 }
 
-func TryKeys(keys []pathUtils.GNS3Key, cfg config.GlobalOptions) ([]byte, error) {
+// KeysForServer returns the identities stored for server.
+func KeysForServer(keys []pathUtils.GNS3Key, server string) []pathUtils.GNS3Key {
+	var out []pathUtils.GNS3Key
 	for _, key := range keys {
-		if normalizeURL(cfg.Server) == normalizeURL(key.ServerURL) {
-			result, success := tryKey(key, cfg)
-			if success {
-				return result, nil
-			}
+		if ServerKey(key.ServerURL) == ServerKey(server) {
+			out = append(out, key)
 		}
 	}
-	return nil, fmt.Errorf("no working API-Key found for the server %s. Please use the %s command to authenticate. ", messageUtils.Bold(cfg.Server), messageUtils.Bold("auth login"))
+	return out
 }
 
-func normalizeURL(url string) string {
-	if strings.HasPrefix(url, "http://") {
-		url = url[7:]
-	} else if strings.HasPrefix(url, "https://") {
-		url = url[8:]
+// selectKey picks the identity for cfg: the user given with --user,
+// otherwise the default of the server, otherwise the first one stored.
+func selectKey(keys []pathUtils.GNS3Key, cfg config.GlobalOptions) (pathUtils.GNS3Key, bool) {
+	candidates := KeysForServer(keys, cfg.Server)
+	if cfg.User != "" {
+		for _, key := range candidates {
+			if key.User == cfg.User {
+				return key, true
+			}
+		}
+		return pathUtils.GNS3Key{}, false
 	}
-
-	if colonIndex := strings.Index(url, ":"); colonIndex != -1 {
-		url = url[:colonIndex]
+	for _, key := range candidates {
+		if key.Default {
+			return key, true
+		}
 	}
+	if len(candidates) > 0 {
+		return candidates[0], true
+	}
+	return pathUtils.GNS3Key{}, false
+}
 
-	return url
+func identity(cfg config.GlobalOptions) string {
+	if cfg.User != "" {
+		return fmt.Sprintf("user %s on %s", messageUtils.Bold(cfg.User), messageUtils.Bold(cfg.Server))
+	}
+	return fmt.Sprintf("the server %s", messageUtils.Bold(cfg.Server))
 }
 
-func tryKey(key pathUtils.GNS3Key, cfg config.GlobalOptions) ([]byte, bool) {
+func tryKey(key pathUtils.GNS3Key, cfg config.GlobalOptions) ([]byte, bool, error) {
 	settings := api.NewSettings(
 		api.WithBaseURL(cfg.Server),
 		api.WithVerify(!cfg.Insecure),
 		api.WithToken(key.AccessToken),
+		api.WithRetries(cfg.Retries),
+		api.WithRetryDelays(cfg.RetryDelay, cfg.RetryMaxDelay),
+		api.WithRetryStatusCodes(cfg.RetryStatus),
+		api.WithReauthenticator(Reauthenticator(cfg)),
 	)
 
 	ep := endpoints.GetEndpoints{}
@@ -84,43 +124,26 @@ func tryKey(key pathUtils.GNS3Key, cfg config.GlobalOptions) ([]byte, bool) {
 		WithURL(ep.Me()).
 		WithMethod(api.GET)
 
-	body, resp, err := client.Do(reqOpts)
-	if err != nil {
-		log.Fatalf("API error: %v", err)
+	body, resp, err := client.Do(cfg.Context(), reqOpts)
+	if resp != nil {
+		_ = resp.Body.Close()
 	}
-	defer func() {
-		if resp != nil {
-			_ = resp.Body.Close()
-		}
-	}()
-	if resp.StatusCode == 200 {
-		return body, true
-	} else {
-		return body, false
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+		return body, false, nil
 	}
+	if err != nil {
+		return nil, false, fmt.Errorf("API error: %w", err)
+	}
+	return body, resp.StatusCode == http.StatusOK, nil
 }
 
+// SaveAuthData stores the token of username for the server of cfg. The
+// first identity stored for a server becomes its default.
 func SaveAuthData(cfg config.GlobalOptions, token schemas.Token, username string) error {
-	var keyFileLocation string
-	if cfg.KeyFile != "" {
-		k, err := pathUtils.ExpandPath(cfg.KeyFile)
-		if err != nil {
-			return err
-		}
-		keyFileLocation = k
-	} else {
-		k, err := pathUtils.GetGNS3Dir()
-		if err != nil {
-			return err
-		}
-		keyFileLocation = filepath.Join(k, "gns3key")
-	}
-
 	keys, err := LoadKeys(cfg.KeyFile)
 	if err != nil {
-		if !os.IsNotExist(err) {
-			panic(err)
-		}
+		return err
 	}
 
 	newKey := pathUtils.GNS3Key{
@@ -128,74 +151,115 @@ func SaveAuthData(cfg config.GlobalOptions, token schemas.Token, username string
 		User:        username,
 		AccessToken: *token.AccessToken,
 		TokenType:   *token.TokenType,
+		IssuedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+	if issuedAt, expiresAt, err := TokenTimes(newKey.AccessToken); err == nil {
+		if !issuedAt.IsZero() {
+			newKey.IssuedAt = issuedAt.UTC()
+		}
+		newKey.ExpiresAt = expiresAt.UTC()
 	}
 
 	found := false
 	for i, key := range keys {
-		if key.ServerURL == newKey.ServerURL {
+		if ServerKey(key.ServerURL) == ServerKey(newKey.ServerURL) && key.User == newKey.User {
+			newKey.Default = key.Default
 			keys[i] = newKey
 			found = true
 			break
 		}
 	}
 	if !found {
+		newKey.Default = len(KeysForServer(keys, cfg.Server)) == 0
 		keys = append(keys, newKey)
 	}
 
-	f, err := os.OpenFile(keyFileLocation, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) // #nosec G304
+	return writeKeys(cfg, keys)
+}
+
+// SetDefaultKey makes user the identity used for the server of cfg when no
+// --user is given.
+func SetDefaultKey(cfg config.GlobalOptions, user string) error {
+	keys, err := LoadKeys(cfg.KeyFile)
 	if err != nil {
-		return fmt.Errorf("failed to open key file %q: %w", keyFileLocation, err)
+		return err
 	}
-	defer func() {
-		if f != nil {
-			_ = f.Close()
+	found := false
+	for i, key := range keys {
+		if ServerKey(key.ServerURL) != ServerKey(cfg.Server) {
+			continue
 		}
-	}()
+		keys[i].Default = key.User == user
+		found = found || key.User == user
+	}
+	if !found {
+		return fmt.Errorf("no stored login for user %s on %s, use auth login first", user, cfg.Server)
+	}
+	return writeKeys(cfg, keys)
+}
+
+// RemoveKeys deletes the identity selected for cfg, or every identity of
+// the server when all is set, and returns the removed keys. If the default
+// is removed the next remaining identity becomes the default.
+func RemoveKeys(cfg config.GlobalOptions, all bool) ([]pathUtils.GNS3Key, error) {
+	keys, err := LoadKeys(cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	selected, ok := selectKey(keys, cfg)
+	if !ok {
+		return nil, fmt.Errorf("no stored login for %s", identity(cfg))
+	}
 
+	var kept, removed []pathUtils.GNS3Key
 	for _, key := range keys {
-		buff_key, err := json.Marshal(key)
-		if err != nil {
-			return err
+		if ServerKey(key.ServerURL) == ServerKey(cfg.Server) && (all || key.User == selected.User) {
+			removed = append(removed, key)
+			continue
 		}
-		_, err = f.Write(buff_key)
-		if err != nil {
-			return fmt.Errorf("failed to write key to file: %w", err)
-		}
-		_, err = f.WriteString("\n")
-		if err != nil {
-			return fmt.Errorf("failed to write newline to file: %w", err)
+		kept = append(kept, key)
+	}
+	sameServer := func(k pathUtils.GNS3Key) bool { return ServerKey(k.ServerURL) == ServerKey(cfg.Server) }
+	if !slices.ContainsFunc(kept, func(k pathUtils.GNS3Key) bool { return k.Default && sameServer(k) }) {
+		for i := range kept {
+			if sameServer(kept[i]) {
+				kept[i].Default = true
+				break
+			}
 		}
 	}
+	return removed, writeKeys(cfg, kept)
+}
 
-	return nil
+func writeKeys(cfg config.GlobalOptions, keys []pathUtils.GNS3Key) error {
+	store, err := DefaultKeyStore(cfg.KeyFile)
+	if err != nil {
+		return err
+	}
+	return store.Save(keys)
 }
 
 func GetKeyForServer(cfg config.GlobalOptions) (string, error) {
-	var keyFileLocation string
-	if cfg.KeyFile != "" {
-		k, err := pathUtils.ExpandPath(cfg.KeyFile)
-		if err != nil {
-			return "", err
-		}
-		keyFileLocation = k
-	} else {
-		k, err := pathUtils.GetGNS3Dir()
-		if err != nil {
-			return "", err
-		}
-		keyFileLocation = filepath.Join(k, "gns3key")
+	key, ok, err := FindKey(cfg)
+	if err != nil {
+		return "", err
 	}
+	if ok {
+		return key.AccessToken, nil
+	}
+	// cassettes are recorded with redacted tokens so no login is needed to replay them
+	if api.Replaying() {
+		return api.Redacted, nil
+	}
+	return "", fmt.Errorf("could not find a matching access token for %s, please use the %s command to login to the server. ", identity(cfg), messageUtils.Bold("auth login"))
+}
 
-	keys, err := LoadKeys(keyFileLocation)
+// FindKey returns the stored key selected for cfg.
+func FindKey(cfg config.GlobalOptions) (pathUtils.GNS3Key, bool, error) {
+	keys, err := LoadKeys(cfg.KeyFile)
 	if err != nil {
-		if !os.IsNotExist(err) {
-			panic(err)
-		}
-	}
-	for _, key := range keys {
-		if normalizeURL(key.ServerURL) == normalizeURL(cfg.Server) {
-			return key.AccessToken, nil
-		}
+		return pathUtils.GNS3Key{}, false, err
 	}
-	return "", fmt.Errorf("could not find find a matching access token for the server %s, please use the %s command to login to the server. ", cfg.Server, messageUtils.Bold("auth login"))
+	key, ok := selectKey(keys, cfg)
+	return key, ok, nil
 }
@@ -0,0 +1,206 @@
+package authentication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/stefanistkuhl/gns3util/pkg/api"
+	"github.com/stefanistkuhl/gns3util/pkg/api/endpoints"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+// ErrNoCredentials is returned by a CredentialSource that has no password
+// for the requested user.
+var ErrNoCredentials = errors.New("no credentials available")
+
+// CredentialSource provides the password used to log in again once a
+// stored token stopped working.
+type CredentialSource interface {
+	Name() string
+	Password(ctx context.Context, serverURL, user string) (string, error)
+}
+
+// EnvCredentials reads GNS3_PASSWORD, the same variable auth login uses.
+// If GNS3_USER is set it has to match the user of the stored key.
+type EnvCredentials struct{}
+
+func (EnvCredentials) Name() string { return "environment" }
+
+func (EnvCredentials) Password(_ context.Context, _, user string) (string, error) {
+	pw := os.Getenv("GNS3_PASSWORD")
+	if envUser := os.Getenv("GNS3_USER"); pw == "" || (envUser != "" && envUser != user) {
+		return "", ErrNoCredentials
+	}
+	return pw, nil
+}
+
+// KeyringCredentials reads passwords stored by auth login --remember.
+type KeyringCredentials struct{}
+
+func (KeyringCredentials) Name() string { return "keyring" }
+
+func (KeyringCredentials) Password(ctx context.Context, serverURL, user string) (string, error) {
+	pw, err := KeyringGet(ctx, passwordAccount(serverURL, user))
+	if errors.Is(err, ErrKeyringUnavailable) || errors.Is(err, ErrNotInKeyring) {
+		return "", ErrNoCredentials
+	}
+	return pw, err
+}
+
+// PromptCredentials asks for the password interactively.
+type PromptCredentials struct {
+	Prompt func(user string) (string, error)
+}
+
+func (PromptCredentials) Name() string { return "prompt" }
+
+// promptMu keeps prompts of concurrent workers from interleaving on stdin.
+var promptMu sync.Mutex
+
+func (p PromptCredentials) Password(_ context.Context, _, user string) (string, error) {
+	if p.Prompt == nil || !stdinIsTerminal() {
+		return "", ErrNoCredentials
+	}
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	return p.Prompt(user)
+}
+
+func stdinIsTerminal() bool {
+	return isatty.IsTerminal(os.Stdin.Fd())
+}
+
+// DefaultCredentialSources are the sources that work without a terminal.
+func DefaultCredentialSources() []CredentialSource {
+	return []CredentialSource{EnvCredentials{}, KeyringCredentials{}}
+}
+
+func passwordAccount(serverURL, user string) string {
+	return fmt.Sprintf("%s@%s", user, ServerKey(serverURL))
+}
+
+// RememberPassword stores the password in the OS keyring so expired
+// tokens can be renewed without asking.
+func RememberPassword(ctx context.Context, serverURL, user, password string) error {
+	return KeyringSet(ctx, passwordAccount(serverURL, user), password)
+}
+
+// ForgetPassword removes a password stored with RememberPassword.
+func ForgetPassword(ctx context.Context, serverURL, user string) error {
+	return KeyringDelete(ctx, passwordAccount(serverURL, user))
+}
+
+// Login authenticates against the server of cfg and stores the new token
+// in the keyfile.
+func Login(ctx context.Context, cfg config.GlobalOptions, username, password string) (schemas.Token, error) {
+	settings := api.NewSettings(
+		api.WithBaseURL(cfg.Server),
+		api.WithVerify(!cfg.Insecure),
+		api.WithRetries(cfg.Retries),
+		api.WithRetryDelays(cfg.RetryDelay, cfg.RetryMaxDelay),
+		api.WithRetryStatusCodes(cfg.RetryStatus),
+	)
+	data, err := json.Marshal(schemas.Credentials{Username: username, Password: password})
+	if err != nil {
+		return schemas.Token{}, fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	reqOpts := api.NewRequestOptions(settings).
+		WithURL(endpoints.PostEndpoints{}.UserAuthenticate()).
+		WithMethod(api.POST).
+		WithData(string(data))
+
+	body, resp, err := api.NewGNS3Client(settings).Do(ctx, reqOpts)
+	if err != nil {
+		return schemas.Token{}, err
+	}
+	_ = resp.Body.Close()
+
+	var token schemas.Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return schemas.Token{}, fmt.Errorf("failed to unmarshall response: %w", err)
+	}
+	if token.AccessToken == nil || token.TokenType == nil {
+		return schemas.Token{}, fmt.Errorf("server returned no access token")
+	}
+	if err := SaveAuthData(cfg, token, username); err != nil {
+		return schemas.Token{}, fmt.Errorf("failed to write authentication data to the keyfile: %w", err)
+	}
+	return token, nil
+}
+
+// reauthState is the last login of an identity on a server, shared by all
+// clients of the process.
+type reauthState struct {
+	mu        sync.Mutex
+	lastToken string
+	lastLogin time.Time
+}
+
+var (
+	reauthMu     sync.Mutex
+	reauthStates = map[string]*reauthState{}
+)
+
+func reauthStateFor(cfg config.GlobalOptions) *reauthState {
+	reauthMu.Lock()
+	defer reauthMu.Unlock()
+	key := ServerKey(cfg.Server) + "\x00" + cfg.User
+	st, ok := reauthStates[key]
+	if !ok {
+		st = &reauthState{}
+		reauthStates[key] = st
+	}
+	return st
+}
+
+// Reauthenticator returns a function for api.WithReauthenticator that logs
+// in again as the user of the stored key with the first source that has a
+// password. All clients of the process for the same server and identity
+// share one login, so requests that fail at the same time log in once.
+func Reauthenticator(cfg config.GlobalOptions, sources ...CredentialSource) api.Reauthenticator {
+	if len(sources) == 0 {
+		sources = DefaultCredentialSources()
+	}
+	st := reauthStateFor(cfg)
+	return func(ctx context.Context) (string, error) {
+		st.mu.Lock()
+		defer st.mu.Unlock()
+		if st.lastToken != "" && time.Since(st.lastLogin) < 10*time.Second {
+			return st.lastToken, nil
+		}
+
+		key, ok, err := FindKey(cfg)
+		if err != nil {
+			return "", err
+		}
+		if !ok || key.User == "" {
+			return "", fmt.Errorf("%w: no stored login for %s", ErrNoCredentials, cfg.Server)
+		}
+
+		for _, src := range sources {
+			pw, err := src.Password(ctx, cfg.Server, key.User)
+			if errors.Is(err, ErrNoCredentials) {
+				continue
+			}
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", src.Name(), err)
+			}
+			token, err := Login(ctx, cfg, key.User, pw)
+			if err != nil {
+				return "", fmt.Errorf("login as %s with password from %s: %w", key.User, src.Name(), err)
+			}
+			fmt.Fprintf(os.Stderr, "%v Token expired, logged in again as %s\n", messageUtils.InfoMsg("Re-authenticated"), messageUtils.Bold(key.User))
+			st.lastToken, st.lastLogin = *token.AccessToken, time.Now()
+			return st.lastToken, nil
+		}
+		return "", fmt.Errorf("%w for %s, set GNS3_PASSWORD, use auth login --remember or run auth login again", ErrNoCredentials, key.User)
+	}
+}
@@ -0,0 +1,78 @@
+package authentication
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/stefanistkuhl/gns3util/pkg/api/fake"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+)
+
+// countingCredentials hands out the password of the fake admin and counts
+// how often it was asked.
+type countingCredentials struct {
+	calls atomic.Int32
+}
+
+func (c *countingCredentials) Name() string { return "test" }
+
+func (c *countingCredentials) Password(context.Context, string, string) (string, error) {
+	c.calls.Add(1)
+	return fake.DefaultPassword, nil
+}
+
+func TestReauthenticatorSharesLogin(t *testing.T) {
+	homedir.DisableCache = true
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GNS3_KEY_STORE", KeyStoreFile)
+	srv := fake.NewServer()
+	defer srv.Close()
+	cfg := config.GlobalOptions{Server: srv.URL}
+	token, err := Login(context.Background(), cfg, fake.DefaultUsername, fake.DefaultPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveAuthData(cfg, token, fake.DefaultUsername); err != nil {
+		t.Fatal(err)
+	}
+
+	// clients of one server made independently of each other
+	src := &countingCredentials{}
+	var wg sync.WaitGroup
+	tokens := make([]string, 4)
+	for i := range tokens {
+		reauth := Reauthenticator(cfg, src)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tokens[i], _ = reauth(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	if got := src.calls.Load(); got != 1 {
+		t.Errorf("asked for the password %d times, want once", got)
+	}
+	for i, tok := range tokens {
+		if tok == "" || tok != tokens[0] {
+			t.Errorf("client %d got token %q, want the shared %q", i, tok, tokens[0])
+		}
+	}
+}
+
+func TestSecurityQuote(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"gns3util", `"gns3util"`},
+		{"http://gns3:3080 admin", `"http://gns3:3080 admin"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\keys`, `"C:\\keys"`},
+	}
+	for _, tt := range tests {
+		if got := securityQuote(tt.in); got != tt.want {
+			t.Errorf("securityQuote(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
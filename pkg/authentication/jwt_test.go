@@ -0,0 +1,47 @@
+package authentication
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func jwt(payload string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(payload)) + ".sig"
+}
+
+func TestTokenTimes(t *testing.T) {
+	tests := []struct {
+		name      string
+		token     string
+		issuedAt  time.Time
+		expiresAt time.Time
+		wantErr   bool
+	}{
+		{name: "exp only", token: jwt(`{"sub":"admin","exp":1735732800}`), expiresAt: time.Unix(1735732800, 0)},
+		{name: "iat and exp", token: jwt(`{"sub":"admin","iat":1735729200,"exp":1735732800}`), issuedAt: time.Unix(1735729200, 0), expiresAt: time.Unix(1735732800, 0)},
+		{name: "no claims", token: jwt(`{"sub":"admin"}`)},
+		{name: "padded payload", token: "header." + base64.URLEncoding.EncodeToString([]byte(`{"exp": 1735732800}`)) + ".sig", expiresAt: time.Unix(1735732800, 0)},
+		{name: "not a jwt", token: "opaque-token", wantErr: true},
+		{name: "payload not base64", token: "a.!!!.c", wantErr: true},
+		{name: "payload not json", token: jwt(`not json`), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iat, exp, err := TokenTimes(tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v %v, want an error", iat, exp)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !iat.Equal(tt.issuedAt) || !exp.Equal(tt.expiresAt) {
+				t.Errorf("got %v %v, want %v %v", iat, exp, tt.issuedAt, tt.expiresAt)
+			}
+		})
+	}
+}
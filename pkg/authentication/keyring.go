@@ -0,0 +1,121 @@
+package authentication
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// KeyringService is the service name entries are stored under in the OS
+// keyring.
+const KeyringService = "gns3util"
+
+// ErrKeyringUnavailable is returned when no supported keyring tool is
+// installed.
+var ErrKeyringUnavailable = errors.New("no OS keyring available")
+
+// ErrNotInKeyring is returned when the keyring has no entry for an account.
+var ErrNotInKeyring = errors.New("not found in keyring")
+
+// The keyring is driven through the tools every desktop already ships,
+// secret-tool for the Secret Service on Linux and security on macOS, so no
+// cgo or D-Bus bindings are needed.
+
+func KeyringGet(ctx context.Context, account string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.CommandContext(ctx, "secret-tool", "lookup", "service", KeyringService, "account", account) // #nosec G204
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "security", "find-generic-password", "-s", KeyringService, "-a", account, "-w") // #nosec G204
+	default:
+		return "", errNoKeyring()
+	}
+	out, err := runKeyring(cmd, nil)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimRight(string(out), "\n")
+	if secret == "" {
+		return "", ErrNotInKeyring
+	}
+	return secret, nil
+}
+
+func KeyringSet(ctx context.Context, account, secret string) error {
+	var cmd *exec.Cmd
+	var stdin []byte
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		label := fmt.Sprintf("%s %s", KeyringService, account)
+		cmd = exec.CommandContext(ctx, "secret-tool", "store", "--label", label, "service", KeyringService, "account", account) // #nosec G204
+		stdin = []byte(secret)
+	case "darwin":
+		// security only takes the secret as an argument, so the command is
+		// fed to its interactive mode on stdin to keep it out of ps
+		cmd = exec.CommandContext(ctx, "security", "-i")
+		stdin = fmt.Appendf(nil, "add-generic-password -U -s %s -a %s -X %s\n",
+			securityQuote(KeyringService), securityQuote(account), hex.EncodeToString([]byte(secret)))
+	default:
+		return errNoKeyring()
+	}
+	_, err := runKeyring(cmd, stdin)
+	return err
+}
+
+// securityQuote quotes an argument for the interactive mode of security.
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func KeyringDelete(ctx context.Context, account string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		cmd = exec.CommandContext(ctx, "secret-tool", "clear", "service", KeyringService, "account", account) // #nosec G204
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "security", "delete-generic-password", "-s", KeyringService, "-a", account) // #nosec G204
+	default:
+		return errNoKeyring()
+	}
+	_, err := runKeyring(cmd, nil)
+	return err
+}
+
+func errNoKeyring() error {
+	return fmt.Errorf("%w on %s, use the %s or %s key store", ErrKeyringUnavailable, runtime.GOOS, KeyStoreFile, KeyStoreEncrypted)
+}
+
+func runKeyring(cmd *exec.Cmd, stdin []byte) ([]byte, error) {
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err == nil {
+		return out, nil
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		hint := ""
+		if cmd.Args[0] == "secret-tool" {
+			hint = " (libsecret-tools on Debian and Ubuntu, libsecret on Fedora and Arch)"
+		}
+		return nil, fmt.Errorf("%w: %s is not installed%s, install it or move the keys to the %s or %s key store with auth migrate",
+			ErrKeyringUnavailable, cmd.Args[0], hint, KeyStoreFile, KeyStoreEncrypted)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// both tools exit non-zero without output for missing entries
+		if msg := strings.TrimSpace(stderr.String()); msg != "" && !strings.Contains(msg, "could not be found") {
+			return nil, fmt.Errorf("keyring: %s", msg)
+		}
+		return nil, ErrNotInKeyring
+	}
+	return nil, fmt.Errorf("keyring: %w", err)
+}
@@ -0,0 +1,93 @@
+package authentication_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+	"github.com/stefanistkuhl/gns3util/pkg/authentication"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/pathUtils"
+)
+
+func TestKeyring(t *testing.T) {
+	ctx := context.Background()
+	store := testutil.FakeKeyring(t)
+
+	if _, err := authentication.KeyringGet(ctx, "alice"); !errors.Is(err, authentication.ErrNotInKeyring) {
+		t.Fatalf("get of a missing entry: %v, want ErrNotInKeyring", err)
+	}
+	if err := authentication.KeyringSet(ctx, "alice", "s3cret pass"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := authentication.KeyringGet(ctx, "alice"); err != nil || got != "s3cret pass" {
+		t.Fatalf("get %q %v, want the stored secret", got, err)
+	}
+	if err := authentication.KeyringDelete(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authentication.KeyringGet(ctx, "alice"); !errors.Is(err, authentication.ErrNotInKeyring) {
+		t.Errorf("get after delete: %v, want ErrNotInKeyring", err)
+	}
+
+	// the secret goes to the tool on stdin, never as an argument
+	args, err := os.ReadFile(filepath.Join(store, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(args), "s3cret") {
+		t.Errorf("the secret was passed as an argument: %s", args)
+	}
+}
+
+func TestKeyringKeyStore(t *testing.T) {
+	testutil.FakeKeyring(t)
+	keys := []pathUtils.GNS3Key{
+		{ServerURL: "http://gns3:3080", User: "admin", AccessToken: "a.b.c", TokenType: "bearer", Default: true},
+	}
+	s := &authentication.KeyringKeyStore{Account: "api-keys"}
+	other := &authentication.KeyringKeyStore{Account: "api-keys:/home/alice/keys"}
+
+	if got, err := s.Load(); err != nil || len(got) != 0 {
+		t.Fatalf("load of an empty keyring: %v %v", got, err)
+	}
+	if err := s.Save(keys); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Load(); err != nil || !reflect.DeepEqual(got, keys) {
+		t.Errorf("loaded %+v %v, want %+v", got, err, keys)
+	}
+	if got, err := other.Load(); err != nil || len(got) != 0 {
+		t.Errorf("the account of another key file holds %+v %v", got, err)
+	}
+	if err := s.Save(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := s.Load(); err != nil || len(got) != 0 {
+		t.Errorf("load after saving no keys: %+v %v", got, err)
+	}
+	if err := s.Delete(); err != nil {
+		t.Errorf("delete of a missing entry: %v", err)
+	}
+}
+
+func TestKeyringMissingTool(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("checks the message for a missing secret-tool")
+	}
+	t.Setenv("PATH", t.TempDir())
+	_, err := authentication.KeyringGet(context.Background(), "alice")
+	if !errors.Is(err, authentication.ErrKeyringUnavailable) {
+		t.Fatalf("get without secret-tool: %v, want ErrKeyringUnavailable", err)
+	}
+	for _, want := range []string{"secret-tool is not installed", "libsecret", authentication.KeyStoreEncrypted} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package authentication
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/pathUtils"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+// Names of the key stores, used for key_store in config.toml and
+// GNS3_KEY_STORE.
+const (
+	KeyStoreFile      = "file"
+	KeyStoreEncrypted = "encrypted-file"
+	KeyStoreKeyring   = "keyring"
+)
+
+// KeyStores lists the supported key store names.
+var KeyStores = []string{KeyStoreFile, KeyStoreEncrypted, KeyStoreKeyring}
+
+// KeyStore persists the stored logins.
+type KeyStore interface {
+	Name() string
+	// Location describes where the keys are kept, for messages.
+	Location() string
+	Load() ([]pathUtils.GNS3Key, error)
+	Save(keys []pathUtils.GNS3Key) error
+	// Delete removes every stored key.
+	Delete() error
+}
+
+// ConfiguredKeyStore returns the name of the key store to use, set by
+// GNS3_KEY_STORE or key_store in config.toml and the plaintext file
+// otherwise.
+func ConfiguredKeyStore() (string, error) {
+	name := os.Getenv("GNS3_KEY_STORE")
+	if name == "" {
+		uc, err := config.LoadUserConfig()
+		if err != nil {
+			return "", err
+		}
+		name = uc.KeyStore
+	}
+	if name == "" {
+		return KeyStoreFile, nil
+	}
+	return name, nil
+}
+
+// DefaultKeyStore opens the configured key store. keyFile overrides the
+// location of file based stores like --key-file does.
+func DefaultKeyStore(keyFile string) (KeyStore, error) {
+	name, err := ConfiguredKeyStore()
+	if err != nil {
+		return nil, err
+	}
+	return OpenKeyStore(name, keyFile)
+}
+
+func OpenKeyStore(name, keyFile string) (KeyStore, error) {
+	switch name {
+	case KeyStoreFile:
+		path, err := keyFilePath(keyFile, "gns3key")
+		if err != nil {
+			return nil, err
+		}
+		return &FileKeyStore{Path: path}, nil
+	case KeyStoreEncrypted:
+		path, err := keyFilePath(keyFile, "gns3key.enc")
+		if err != nil {
+			return nil, err
+		}
+		return &EncryptedKeyStore{Path: path}, nil
+	case KeyStoreKeyring:
+		account := "api-keys"
+		if keyFile != "" {
+			// a separate key set per --key-file, like with the file stores
+			path, err := keyFilePath(keyFile, "gns3key")
+			if err != nil {
+				return nil, err
+			}
+			account += ":" + path
+		}
+		return &KeyringKeyStore{Account: account}, nil
+	}
+	return nil, fmt.Errorf("unknown key store %q, use one of %s", name, strings.Join(KeyStores, ", "))
+}
+
+// keyFilePath resolves keyFile like --key-file, a directory gets name
+// appended. Without keyFile name in ~/.gns3 is used.
+func keyFilePath(keyFile, name string) (string, error) {
+	if keyFile != "" {
+		k, err := pathUtils.ExpandPath(keyFile)
+		if err != nil {
+			return "", err
+		}
+		if info, err := os.Stat(k); err == nil && info.IsDir() {
+			k = filepath.Join(k, name)
+		} else if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		return k, nil
+	}
+	dir, err := pathUtils.GetGNS3Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// ExportKeys returns the keys of store in the format of the plaintext
+// gns3key file, empty if it holds none.
+func ExportKeys(store KeyStore) ([]byte, error) {
+	keys, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return encodeKeys(keys)
+}
+
+func encodeKeys(keys []pathUtils.GNS3Key) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, key := range keys {
+		line, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// FileKeyStore keeps the keys as plaintext JSON lines, the format
+// gns3util always used.
+type FileKeyStore struct {
+	Path string
+}
+
+func (s *FileKeyStore) Name() string     { return KeyStoreFile }
+func (s *FileKeyStore) Location() string { return s.Path }
+
+func (s *FileKeyStore) Load() ([]pathUtils.GNS3Key, error) {
+	return pathUtils.LoadGNS3KeysFile(s.Path)
+}
+
+func (s *FileKeyStore) Save(keys []pathUtils.GNS3Key) error {
+	data, err := encodeKeys(keys)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.Path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write key file %q: %w", s.Path, err)
+	}
+	return nil
+}
+
+func (s *FileKeyStore) Delete() error {
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// KeyringKeyStore keeps all keys as one entry in the OS keyring, the
+// Secret Service on Linux and the Keychain on macOS.
+type KeyringKeyStore struct {
+	Account string
+}
+
+func (s *KeyringKeyStore) Name() string { return KeyStoreKeyring }
+func (s *KeyringKeyStore) Location() string {
+	return fmt.Sprintf("OS keyring (service %s, account %s)", KeyringService, s.Account)
+}
+
+func (s *KeyringKeyStore) Load() ([]pathUtils.GNS3Key, error) {
+	data, err := KeyringGet(context.Background(), s.Account)
+	if errors.Is(err, ErrNotInKeyring) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pathUtils.DecodeGNS3Keys(strings.NewReader(data), s.Location())
+}
+
+func (s *KeyringKeyStore) Save(keys []pathUtils.GNS3Key) error {
+	if len(keys) == 0 {
+		return s.Delete()
+	}
+	data, err := encodeKeys(keys)
+	if err != nil {
+		return err
+	}
+	return KeyringSet(context.Background(), s.Account, string(data))
+}
+
+func (s *KeyringKeyStore) Delete() error {
+	if err := KeyringDelete(context.Background(), s.Account); err != nil && !errors.Is(err, ErrNotInKeyring) {
+		return err
+	}
+	return nil
+}
+
+// EncryptedKeyStore keeps the keys in a file encrypted with a passphrase,
+// taken from GNS3_KEY_PASSPHRASE or asked for on the terminal. The key is
+// derived with scrypt and the data sealed with XChaCha20-Poly1305.
+type EncryptedKeyStore struct {
+	Path string
+}
+
+// encryptedKeyFile is the JSON document in gns3key.enc. Version 1 derives a
+// 32 byte key from the passphrase with scrypt, using the cost parameters N,
+// r and p and the 16 byte salt stored next to them, and seals the keys in
+// the plaintext gns3key format with XChaCha20-Poly1305 under a random
+// 24 byte nonce. Salt, nonce and data are base64 like encoding/json writes
+// byte slices. Files with another version or kdf are refused rather than
+// guessed at.
+type encryptedKeyFile struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// ErrWrongPassphrase is returned when the encrypted key file can not be
+// opened with the given passphrase.
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted key file")
+
+func (s *EncryptedKeyStore) Name() string     { return KeyStoreEncrypted }
+func (s *EncryptedKeyStore) Location() string { return s.Path }
+
+func (s *EncryptedKeyStore) Load() ([]pathUtils.GNS3Key, error) {
+	raw, err := os.ReadFile(s.Path) // #nosec G304
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not open %q: %w", s.Path, err)
+	}
+	var f encryptedKeyFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("failed to decode %q: %w", s.Path, err)
+	}
+	if f.Version != 1 || f.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported key file format in %q", s.Path)
+	}
+
+	pass, err := passphrase(s.Path, false)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newKeyFileAEAD(pass, f.Salt, f.N, f.R, f.P)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := aead.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		forgetPassphrase(s.Path)
+		return nil, fmt.Errorf("%q: %w", s.Path, ErrWrongPassphrase)
+	}
+	return pathUtils.DecodeGNS3Keys(bytes.NewReader(plain), s.Path)
+}
+
+func (s *EncryptedKeyStore) Save(keys []pathUtils.GNS3Key) error {
+	data, err := encodeKeys(keys)
+	if err != nil {
+		return err
+	}
+	_, statErr := os.Stat(s.Path)
+	pass, err := passphrase(s.Path, os.IsNotExist(statErr))
+	if err != nil {
+		return err
+	}
+
+	// keep the salt of the existing file so the key derived on Load is reused
+	f := encryptedKeyFile{Version: 1, KDF: "scrypt", N: 1 << 15, R: 8, P: 1}
+	if raw, err := os.ReadFile(s.Path); err == nil { // #nosec G304
+		var old encryptedKeyFile
+		if json.Unmarshal(raw, &old) == nil && old.Version == 1 && old.KDF == "scrypt" && len(old.Salt) == 16 {
+			f.N, f.R, f.P, f.Salt = old.N, old.R, old.P, old.Salt
+		}
+	}
+	if f.Salt == nil {
+		f.Salt = make([]byte, 16)
+		if _, err := rand.Read(f.Salt); err != nil {
+			return err
+		}
+	}
+	aead, err := newKeyFileAEAD(pass, f.Salt, f.N, f.R, f.P)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Data = aead.Seal(nil, f.Nonce, data, nil)
+
+	out, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.Path, out, 0o600); err != nil {
+		return fmt.Errorf("failed to write key file %q: %w", s.Path, err)
+	}
+	return nil
+}
+
+func (s *EncryptedKeyStore) Delete() error {
+	if err := os.Remove(s.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+var (
+	derivedKeysMu sync.Mutex
+	derivedKeys   = map[[sha256.Size]byte][]byte{}
+)
+
+// newKeyFileAEAD derives the key of an encrypted key file. scrypt takes
+// about 32 MB and a noticeable moment, and every API call loads the keys, so
+// derived keys are kept for the rest of the run.
+func newKeyFileAEAD(pass string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	id := sha256.Sum256(fmt.Appendf(nil, "%d:%d:%d:%x:%s", n, r, p, salt, pass))
+	derivedKeysMu.Lock()
+	defer derivedKeysMu.Unlock()
+	key, ok := derivedKeys[id]
+	if !ok {
+		var err error
+		key, err = scrypt.Key([]byte(pass), salt, n, r, p, chacha20poly1305.KeySize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+		derivedKeys[id] = key
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+var (
+	passphraseMu    sync.Mutex
+	passphraseCache = map[string]string{}
+)
+
+// passphrase returns the passphrase for the encrypted key file at path. It
+// is asked for once per run, twice when confirm is set for a new file.
+func passphrase(path string, confirm bool) (string, error) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	if p, ok := passphraseCache[path]; ok {
+		return p, nil
+	}
+	if p := os.Getenv("GNS3_KEY_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	if !stdinIsTerminal() {
+		return "", fmt.Errorf("the key file %s is encrypted, set GNS3_KEY_PASSPHRASE to use it without a terminal", path)
+	}
+
+	p, err := readPassphrase(fmt.Sprintf("Passphrase for %s: ", path))
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", fmt.Errorf("empty passphrase")
+	}
+	if confirm {
+		again, err := readPassphrase("Repeat the passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != p {
+			return "", fmt.Errorf("the passphrases do not match")
+		}
+	}
+	passphraseCache[path] = p
+	return p, nil
+}
+
+func forgetPassphrase(path string) {
+	passphraseMu.Lock()
+	defer passphraseMu.Unlock()
+	delete(passphraseCache, path)
+}
+
+func readPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	p, err := term.ReadPassword(int(os.Stdin.Fd())) // #nosec G115
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %w", err)
+	}
+	return string(p), nil
+}
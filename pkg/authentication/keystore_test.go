@@ -0,0 +1,227 @@
+package authentication
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/pkg/utils/pathUtils"
+)
+
+func testKeys() []pathUtils.GNS3Key {
+	return []pathUtils.GNS3Key{
+		{ServerURL: "http://gns3:3080", User: "admin", AccessToken: "a.b.c", TokenType: "bearer", Default: true},
+		{ServerURL: "http://gns3:3080", User: "teacher", AccessToken: "d.e.f", TokenType: "bearer"},
+	}
+}
+
+func TestFileKeyStore(t *testing.T) {
+	s := &FileKeyStore{Path: filepath.Join(t.TempDir(), "gns3key")}
+	keys, err := s.Load()
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("load of a missing file: %v %v", keys, err)
+	}
+	if err := s.Save(testKeys()); err != nil {
+		t.Fatal(err)
+	}
+	keys, err = s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, testKeys()) {
+		t.Errorf("loaded %+v, want %+v", keys, testKeys())
+	}
+	if err := s.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(s.Path); !os.IsNotExist(err) {
+		t.Errorf("key file still there: %v", err)
+	}
+}
+
+func TestEncryptedKeyStoreRoundTrip(t *testing.T) {
+	t.Setenv("GNS3_KEY_PASSPHRASE", "correct horse")
+	s := &EncryptedKeyStore{Path: filepath.Join(t.TempDir(), "gns3key.enc")}
+	if err := s.Save(testKeys()); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(s.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "a.b.c") || strings.Contains(string(raw), "teacher") {
+		t.Errorf("key file holds plaintext: %s", raw)
+	}
+	var f encryptedKeyFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Version != 1 || f.KDF != "scrypt" || f.N != 1<<15 || f.R != 8 || f.P != 1 {
+		t.Errorf("header version %d kdf %s n %d r %d p %d", f.Version, f.KDF, f.N, f.R, f.P)
+	}
+	if len(f.Salt) != 16 || len(f.Nonce) != 24 {
+		t.Errorf("salt of %d and nonce of %d bytes, want 16 and 24", len(f.Salt), len(f.Nonce))
+	}
+
+	keys, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, testKeys()) {
+		t.Errorf("loaded %+v, want %+v", keys, testKeys())
+	}
+}
+
+func TestEncryptedKeyStoreKeepsSalt(t *testing.T) {
+	t.Setenv("GNS3_KEY_PASSPHRASE", "correct horse")
+	s := &EncryptedKeyStore{Path: filepath.Join(t.TempDir(), "gns3key.enc")}
+	header := func() encryptedKeyFile {
+		t.Helper()
+		raw, err := os.ReadFile(s.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f encryptedKeyFile
+		if err := json.Unmarshal(raw, &f); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+	if err := s.Save(testKeys()); err != nil {
+		t.Fatal(err)
+	}
+	first := header()
+	if err := s.Save(testKeys()[:1]); err != nil {
+		t.Fatal(err)
+	}
+	second := header()
+	// the derived key is reused, the nonce never is
+	if !reflect.DeepEqual(first.Salt, second.Salt) {
+		t.Errorf("salt changed from %x to %x", first.Salt, second.Salt)
+	}
+	if reflect.DeepEqual(first.Nonce, second.Nonce) {
+		t.Errorf("nonce %x used twice", first.Nonce)
+	}
+	keys, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, testKeys()[:1]) {
+		t.Errorf("loaded %+v, want %+v", keys, testKeys()[:1])
+	}
+}
+
+func TestEncryptedKeyStoreWrongPassphrase(t *testing.T) {
+	s := &EncryptedKeyStore{Path: filepath.Join(t.TempDir(), "gns3key.enc")}
+	t.Setenv("GNS3_KEY_PASSPHRASE", "correct horse")
+	if err := s.Save(testKeys()); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GNS3_KEY_PASSPHRASE", "battery staple")
+	if _, err := s.Load(); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("load: %v, want ErrWrongPassphrase", err)
+	}
+}
+
+func TestEncryptedKeyStoreFormat(t *testing.T) {
+	t.Setenv("GNS3_KEY_PASSPHRASE", "correct horse")
+	tests := []struct {
+		name string
+		edit func(f map[string]any)
+		want error
+	}{
+		{name: "unknown version", edit: func(f map[string]any) { f["version"] = 2 }},
+		{name: "unknown kdf", edit: func(f map[string]any) { f["kdf"] = "argon2id" }},
+		{name: "tampered data", edit: func(f map[string]any) {
+			data, _ := base64.StdEncoding.DecodeString(f["data"].(string))
+			data[0] ^= 1
+			f["data"] = data
+		}, want: ErrWrongPassphrase},
+		{name: "other salt", edit: func(f map[string]any) { f["salt"] = "AAAAAAAAAAAAAAAAAAAAAA==" }, want: ErrWrongPassphrase},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &EncryptedKeyStore{Path: filepath.Join(t.TempDir(), "gns3key.enc")}
+			if err := s.Save(testKeys()); err != nil {
+				t.Fatal(err)
+			}
+			raw, err := os.ReadFile(s.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var f map[string]any
+			if err := json.Unmarshal(raw, &f); err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(f)
+			raw, _ = json.Marshal(f)
+			if err := os.WriteFile(s.Path, raw, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			keys, err := s.Load()
+			if err == nil {
+				t.Fatalf("loaded %+v, want an error", keys)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("load: %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenKeyStore(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		store   string
+		keyFile string
+		want    KeyStore
+		wantErr bool
+	}{
+		{name: "file", store: KeyStoreFile, keyFile: dir, want: &FileKeyStore{Path: filepath.Join(dir, "gns3key")}},
+		{name: "encrypted", store: KeyStoreEncrypted, keyFile: dir, want: &EncryptedKeyStore{Path: filepath.Join(dir, "gns3key.enc")}},
+		{name: "file path", store: KeyStoreFile, keyFile: filepath.Join(dir, "keys"), want: &FileKeyStore{Path: filepath.Join(dir, "keys")}},
+		{name: "keyring per key file", store: KeyStoreKeyring, keyFile: dir, want: &KeyringKeyStore{Account: "api-keys:" + filepath.Join(dir, "gns3key")}},
+		{name: "unknown", store: "vault", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OpenKeyStore(tt.store, tt.keyFile)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("opened %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportKeys(t *testing.T) {
+	s := &FileKeyStore{Path: filepath.Join(t.TempDir(), "gns3key")}
+	data, err := ExportKeys(s)
+	if err != nil || len(data) != 0 {
+		t.Fatalf("export of an empty store: %q %v", data, err)
+	}
+	if err := s.Save(testKeys()); err != nil {
+		t.Fatal(err)
+	}
+	data, err = ExportKeys(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(s.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(raw) {
+		t.Errorf("export %q, want the gns3key file %q", data, raw)
+	}
+}
@@ -13,7 +13,10 @@ type Settings struct {
 type Cluster struct {
 	Name        string `toml:"name"`
 	Description string `toml:"description"`
-	Nodes       []Node `toml:"node"`
+	// Placement is the default placement strategy of class create and
+	// class apply, it only lives in the config file.
+	Placement string `toml:"placement,omitempty"`
+	Nodes     []Node `toml:"node"`
 }
 
 type Node struct {
@@ -23,6 +26,8 @@ type Node struct {
 	Protocol  string `toml:"protocol"`
 	Weight    int    `toml:"weight"`
 	MaxGroups int    `toml:"max_groups"`
+	// Label groups nodes for the spread placement, e.g. by rack or site.
+	Label string `toml:"label,omitempty"`
 }
 
 func NewConfig() Config {
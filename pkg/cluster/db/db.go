@@ -4,9 +4,10 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
@@ -23,7 +24,9 @@ type Store struct {
 }
 
 func openDB(ctx context.Context, dbPath string) (*sql.DB, error) {
-	dsn := fmt.Sprintf("file:%s", dbPath)
+	// these pragmas are per connection, the DSN sets them on every
+	// connection of the pool
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_pragma=synchronous(NORMAL)", dbPath)
 	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, err
@@ -34,30 +37,51 @@ func openDB(ctx context.Context, dbPath string) (*sql.DB, error) {
 		return nil, err
 	}
 
-	setupQuery := `
-		PRAGMA journal_mode = WAL;
-		PRAGMA synchronous = NORMAL;
-		PRAGMA foreign_keys = ON;
-		PRAGMA busy_timeout = 5000;
-	`
-	if _, err := db.ExecContext(ctx, setupQuery); err != nil {
+	// WAL is kept in the database file, switching to it needs the database
+	// to itself, which fails while other processes have it open
+	var journalMode string
+	if err := db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&journalMode); err != nil {
 		_ = db.Close()
-		return nil, fmt.Errorf("failed to apply sqlite pragmas: %w", err)
+		return nil, fmt.Errorf("failed to read the journal mode: %w", err)
+	}
+	if !strings.EqualFold(journalMode, "wal") {
+		if _, err := db.ExecContext(ctx, "PRAGMA journal_mode = WAL"); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to apply sqlite pragmas: %w", err)
+		}
 	}
 
 	return db, nil
 }
 
+// Init opens the cluster database, creating it if needed, and applies
+// the pending migrations. A database migrated by a newer gns3util is
+// refused with ErrSchemaTooNew.
 func Init() (*Store, error) {
+	store, err := Open()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := store.Migrate(ctx); err != nil {
+		_ = store.DB.Close()
+		return nil, fmt.Errorf("apply migrations: %w", err)
+	}
+	return store, nil
+}
+
+// Open opens the cluster database like Init without applying migrations,
+// for inspecting its schema version.
+func Open() (*Store, error) {
 	dir, err := utils.GetGNS3Dir()
 	if err != nil {
 		return nil, fmt.Errorf("get dir: %w", err)
 	}
 	dbPath := filepath.Join(dir, "clusterData.db")
 
-	_, statErr := os.Stat(dbPath)
-	dbExists := !os.IsNotExist(statErr)
-
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -66,11 +90,9 @@ func Init() (*Store, error) {
 		return nil, fmt.Errorf("open db: %w", err)
 	}
 
-	if !dbExists {
-		if _, err := db.ExecContext(ctx, Schema); err != nil {
-			_ = db.Close()
-			return nil, fmt.Errorf("apply initial schema: %w", err)
-		}
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("prepare schema_migrations: %w", err)
 	}
 
 	return &Store{
@@ -88,7 +110,7 @@ func (s *Store) ReadOnly(ctx context.Context, fn func(*sqlc.Queries) error) erro
 	}
 
 	defer func() {
-		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
 			// Log the rollback error but don't override the original error
 			fmt.Printf("Warning: failed to rollback transaction: %v\n", rollbackErr)
 		}
@@ -0,0 +1,313 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// setHome gives the test a HOME of its own and returns the path of its
+// cluster database.
+func setHome(t *testing.T) string {
+	t.Helper()
+	homedir.DisableCache = true
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".gns3"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(home, ".gns3", "clusterData.db")
+}
+
+// schemaOf returns the sorted columns of every table by table name.
+func schemaOf(t *testing.T, db *sql.DB) map[string][]string {
+	t.Helper()
+	rows, err := db.Query("SELECT m.name, p.name FROM sqlite_master m JOIN pragma_table_info(m.name) p WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	out := map[string][]string{}
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			t.Fatal(err)
+		}
+		out[table] = append(out[table], column)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	for _, cols := range out {
+		slices.Sort(cols)
+	}
+	return out
+}
+
+func freshSchema(t *testing.T) map[string][]string {
+	t.Helper()
+	setHome(t)
+	store, err := Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = store.DB.Close()
+	}()
+	return schemaOf(t, store.DB)
+}
+
+// legacyRows is a cluster with one exercise, which has to survive every
+// upgrade that rebuilds a table.
+const legacyRows = `
+INSERT INTO clusters (name) VALUES ('lab');
+INSERT INTO classes (cluster_id, name) VALUES (1, 'NET');
+INSERT INTO groups (class_id, name) VALUES (1, 'NET-g1');
+INSERT INTO exercises (project_uuid, group_id, name, state) VALUES ('0a1b2c3d', 1, 'ospf', 'running');
+`
+
+// legacyDatabase creates a database with the schema of testdata/schema_v0.sql,
+// legacyRows and the migration files of upgrades applied the way gns3util
+// did before schema_migrations existed.
+func legacyDatabase(t *testing.T, upgrades ...string) {
+	t.Helper()
+	old, err := os.ReadFile(filepath.Join("testdata", "schema_v0.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := setHome(t)
+	legacy, err := openDB(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = legacy.Close()
+	}()
+	if _, err := legacy.Exec(string(old)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := legacy.Exec(legacyRows); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range upgrades {
+		stmts, err := migrationFiles.ReadFile("migrations/" + name + ".sql")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := legacy.Exec(string(stmts)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// applied returns the names of the applied migrations of the database.
+func applied(t *testing.T, store *Store) []string {
+	t.Helper()
+	status, err := store.MigrationStatus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, m := range status {
+		if m.Applied {
+			names = append(names, m.Name)
+		}
+	}
+	return names
+}
+
+func knownNames(t *testing.T) []string {
+	t.Helper()
+	known, err := Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, m := range known {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+func TestInitUpgradesOldDatabases(t *testing.T) {
+	want := freshSchema(t)
+	if _, ok := want["notifications"]; !ok {
+		t.Fatalf("a new database has no notifications table: %v", want)
+	}
+
+	legacyDatabase(t)
+
+	// the second Init finds nothing left to do
+	for range 2 {
+		store, err := Init()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := schemaOf(t, store.DB)
+		clusters, err := store.GetClusters(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		exercises, err := store.GetExercisesForGroup(context.Background(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = store.DB.Close()
+		if len(clusters) != 1 || clusters[0].Name != "lab" {
+			t.Errorf("clusters after the upgrade %+v, want lab", clusters)
+		}
+		if len(exercises) != 1 || exercises[0].ProjectUuid != "0a1b2c3d" || exercises[0].State.String != "running" {
+			t.Errorf("exercises after the upgrade %+v, want ospf", exercises)
+		}
+		for table, cols := range want {
+			if !slices.Equal(got[table], cols) {
+				t.Errorf("columns of %s after the upgrade %v, want %v", table, got[table], cols)
+			}
+		}
+	}
+
+	store, err := Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = store.DB.Close()
+	}()
+	// the rebuilt table accepts the stopped state
+	if _, err := store.DB.Exec("UPDATE exercises SET state = 'stopped' WHERE exercise_id = 1"); err != nil {
+		t.Errorf("stop an upgraded exercise: %v", err)
+	}
+}
+
+func TestNewDatabaseRecordsEveryMigration(t *testing.T) {
+	setHome(t)
+	store, err := Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = store.DB.Close()
+	}()
+	if got, want := applied(t, store), knownNames(t); !slices.Equal(got, want) {
+		t.Errorf("applied migrations %v, want %v", got, want)
+	}
+	done, err := store.Migrate(context.Background())
+	if err != nil || len(done) > 0 {
+		t.Errorf("migrate a new database applied %+v: %v", done, err)
+	}
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	// a database upgraded by a gns3util that knew the first two migrations
+	legacyDatabase(t, "001_notifications", "002_exercise_lifecycle")
+
+	store, err := Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := applied(t, store), knownNames(t)[:2]; !slices.Equal(got, want) {
+		t.Errorf("applied migrations of the legacy database %v, want %v", got, want)
+	}
+	done, err := store.Migrate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(done) != len(knownNames(t))-2 || done[0].Name != "003_exercise_baseline" {
+		t.Errorf("migrate applied %+v, want everything after 002", done)
+	}
+	if got, want := applied(t, store), knownNames(t); !slices.Equal(got, want) {
+		t.Errorf("applied migrations %v, want %v", got, want)
+	}
+	got := schemaOf(t, store.DB)
+	_ = store.DB.Close()
+
+	want := freshSchema(t)
+	for table, cols := range want {
+		if !slices.Equal(got[table], cols) {
+			t.Errorf("columns of %s after migrate %v, want %v", table, got[table], cols)
+		}
+	}
+}
+
+func TestInitRefusesNewerDatabase(t *testing.T) {
+	setHome(t)
+	store, err := Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.DB.Exec("INSERT INTO schema_migrations (version, name) VALUES (999, '999_future')"); err != nil {
+		t.Fatal(err)
+	}
+	status, err := store.MigrationStatus(context.Background())
+	_ = store.DB.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := status[len(status)-1]; last.Name != "999_future" || !last.Unknown {
+		t.Errorf("status of the newer migration %+v, want unknown", last)
+	}
+
+	if _, err := Init(); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("init a newer database: %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestConcurrentInit(t *testing.T) {
+	// each Init opens a pool of its own like a separate gns3util would
+	legacyDatabase(t)
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store, err := Init()
+			if err == nil {
+				_ = store.DB.Close()
+			}
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = store.DB.Close()
+	}()
+	var rows, versions int
+	if err := store.DB.QueryRow("SELECT count(*), count(DISTINCT version) FROM schema_migrations").Scan(&rows, &versions); err != nil {
+		t.Fatal(err)
+	}
+	if rows != len(knownNames(t)) || versions != rows {
+		t.Errorf("schema_migrations has %d rows of %d versions, want each of %d once", rows, versions, len(knownNames(t)))
+	}
+
+	// every connection of the pool enforces foreign keys
+	store.DB.SetMaxIdleConns(0)
+	for range 3 {
+		var on int
+		if err := store.DB.QueryRow("PRAGMA foreign_keys").Scan(&on); err != nil {
+			t.Fatal(err)
+		}
+		if on != 1 {
+			t.Fatal("a connection without foreign keys")
+		}
+	}
+}
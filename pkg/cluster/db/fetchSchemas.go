@@ -1,6 +1,9 @@
 package db
 
-import "database/sql"
+import (
+	"database/sql"
+	"time"
+)
 
 type ClusterName struct {
 	Id   int
@@ -70,13 +73,15 @@ type NodeGroupsForClass struct {
 }
 
 type ExerciseItem struct {
-	Name        string
-	ProjectUUID string
-	GroupName   string
-	State       string
+	Name        string `json:"name"`
+	ProjectUUID string `json:"project_uuid"`
+	GroupName   string `json:"group"`
+	State       string `json:"state"`
+	// Since is when the exercise entered its state, zero if unknown.
+	Since time.Time `json:"since"`
 }
 
 type NodeExercisesForClass struct {
-	NodeURL   string
-	Exercises []ExerciseItem
+	NodeURL   string         `json:"node"`
+	Exercises []ExerciseItem `json:"exercises"`
 }
@@ -0,0 +1,275 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles are applied to existing databases in the order of the
+// version their file name starts with, like 006_name.sql. New databases get
+// schema.sql, which already has every change they make.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// ErrSchemaTooNew is returned for a database migrated by a newer gns3util.
+var ErrSchemaTooNew = errors.New("the database was migrated by a newer gns3util")
+
+// Migration is a schema change of the cluster database.
+type Migration struct {
+	Version int
+	Name    string
+	// Applied and AppliedAt are set by MigrationStatus.
+	Applied   bool
+	AppliedAt time.Time
+	// Unknown marks an applied migration this gns3util has no file for.
+	Unknown bool
+}
+
+const createMigrationsTable = `CREATE TABLE schema_migrations (
+    version integer PRIMARY KEY,
+    name text NOT NULL,
+    applied_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+// legacyChecks count what a migration adds, zero while a database has not
+// got it. gns3util applied the first migrations before schema_migrations
+// existed, a database from then gets those it has recorded as applied.
+var legacyChecks = map[int]string{
+	1: "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'notifications'",
+	2: "SELECT count(*) FROM pragma_table_info('exercises') WHERE name = 'started_at'",
+	3: "SELECT count(*) FROM pragma_table_info('exercises') WHERE name = 'baseline_snapshot_id'",
+	4: "SELECT count(*) FROM pragma_table_info('exercises') WHERE name = 'name_format'",
+	5: "SELECT count(*) FROM pragma_table_info('nodes') WHERE name = 'cordoned'",
+}
+
+// Migrations returns the migrations this gns3util knows, ordered by version.
+func Migrations() ([]Migration, error) {
+	files, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations := make([]Migration, 0, len(files))
+	seen := map[int]string{}
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), ".sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s does not start with a version", f.Name())
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, name)
+		}
+		seen[version] = name
+		migrations = append(migrations, Migration{Version: version, Name: name})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// ensureMigrationsTable creates schema_migrations if it is missing. A new
+// database gets the latest schema with every known migration recorded as
+// applied, an older one the migrations its legacy checks find.
+func ensureMigrationsTable(ctx context.Context, db *sql.DB) error {
+	exists, err := tableExists(ctx, db, "schema_migrations")
+	if err != nil || exists {
+		return err
+	}
+	known, err := Migrations()
+	if err != nil {
+		return err
+	}
+	return immediate(ctx, db, func(conn *sql.Conn) error {
+		// another gns3util may have prepared the database while this one
+		// waited for the lock
+		if exists, err := tableExists(ctx, conn, "schema_migrations"); err != nil || exists {
+			return err
+		}
+		hasSchema, err := tableExists(ctx, conn, "clusters")
+		if err != nil {
+			return err
+		}
+		if !hasSchema {
+			if _, err := conn.ExecContext(ctx, Schema); err != nil {
+				return fmt.Errorf("apply initial schema: %w", err)
+			}
+		}
+		if _, err := conn.ExecContext(ctx, createMigrationsTable); err != nil {
+			return err
+		}
+		for _, m := range known {
+			if hasSchema {
+				check, ok := legacyChecks[m.Version]
+				if !ok {
+					continue
+				}
+				var n int
+				if err := conn.QueryRowContext(ctx, check).Scan(&n); err != nil {
+					return fmt.Errorf("check %s: %w", m.Name, err)
+				}
+				if n == 0 {
+					continue
+				}
+			}
+			if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func tableExists(ctx context.Context, q queryer, name string) (bool, error) {
+	var n int
+	if err := q.QueryRowContext(ctx, "SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// immediate runs fn in a transaction that takes the write lock when it
+// begins instead of on its first write, so processes opening the database
+// at the same time change its schema one after the other.
+func immediate(ctx context.Context, db *sql.DB, fn func(*sql.Conn) error) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return err
+	}
+	if err := fn(conn); err != nil {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK")
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK")
+		return err
+	}
+	return nil
+}
+
+// MigrationStatus returns the known migrations, marked if applied,
+// followed by the applied ones this gns3util does not know.
+func (s *Store) MigrationStatus(ctx context.Context) ([]Migration, error) {
+	known, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("read schema_migrations: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	applied := map[int]Migration{}
+	var order []int
+	for rows.Next() {
+		var m Migration
+		var at sql.NullTime
+		if err := rows.Scan(&m.Version, &m.Name, &at); err != nil {
+			return nil, err
+		}
+		m.Applied, m.AppliedAt = true, at.Time
+		applied[m.Version] = m
+		order = append(order, m.Version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, m := range known {
+		if a, ok := applied[m.Version]; ok {
+			known[i].Applied, known[i].AppliedAt = true, a.AppliedAt
+			delete(applied, m.Version)
+		}
+	}
+	for _, v := range order {
+		if m, ok := applied[v]; ok {
+			m.Unknown = true
+			known = append(known, m)
+		}
+	}
+	return known, nil
+}
+
+// Migrate applies the pending migrations in order, each in a transaction of
+// its own, and returns them.
+func (s *Store) Migrate(ctx context.Context) ([]Migration, error) {
+	status, err := s.MigrationStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest := 0
+	for _, m := range status {
+		if !m.Unknown {
+			latest = max(latest, m.Version)
+		}
+	}
+	for _, m := range status {
+		if m.Unknown && m.Version > latest {
+			return nil, fmt.Errorf("%w, it is at version %d and this one knows up to %d, update gns3util", ErrSchemaTooNew, m.Version, latest)
+		}
+	}
+
+	var done []Migration
+	for _, m := range status {
+		if m.Applied {
+			continue
+		}
+		stmts, err := migrationFiles.ReadFile("migrations/" + m.Name + ".sql")
+		if err != nil {
+			return done, err
+		}
+		applied, err := s.applyMigration(ctx, m, string(stmts))
+		if err != nil {
+			return done, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		if !applied {
+			continue
+		}
+		m.Applied, m.AppliedAt = true, time.Now().UTC()
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+// applyMigration applies a migration unless another gns3util applied it
+// while this one waited for the lock, and reports whether it did.
+func (s *Store) applyMigration(ctx context.Context, m Migration, stmts string) (bool, error) {
+	applied := false
+	err := immediate(ctx, s.DB, func(conn *sql.Conn) error {
+		var n int
+		if err := conn.QueryRowContext(ctx, "SELECT count(*) FROM schema_migrations WHERE version = ?", m.Version).Scan(&n); err != nil {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+		if _, err := conn.ExecContext(ctx, stmts); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+			return err
+		}
+		applied = true
+		return nil
+	})
+	return applied, err
+}
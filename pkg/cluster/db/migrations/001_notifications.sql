@@ -0,0 +1,10 @@
+-- Delivery log of class notify.
+CREATE TABLE notifications (
+    notification_id integer PRIMARY KEY autoincrement,
+    user_id integer NOT NULL,
+    email text NOT NULL,
+    status text CHECK (status IN ('sent', 'failed')) NOT NULL,
+    error text,
+    sent_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
@@ -0,0 +1,32 @@
+-- Adds the stopped state and when exercises were last started, stopped and
+-- completed. SQLite cannot change a CHECK constraint, so the table is rebuilt.
+CREATE TABLE exercises_new (
+    exercise_id integer PRIMARY KEY autoincrement,
+    project_uuid text NOT NULL CHECK (length(project_uuid) = 8),
+    group_id integer NOT NULL,
+    name text NOT NULL,
+    state text CHECK (
+        state IN ('created', 'running', 'stopped', 'completed', 'deleted')
+    ) DEFAULT 'created',
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    started_at timestamp,
+    stopped_at timestamp,
+    completed_at timestamp,
+    FOREIGN KEY (group_id) REFERENCES groups(group_id) ON DELETE CASCADE
+);
+
+INSERT INTO
+    exercises_new (exercise_id, project_uuid, group_id, name, state, created_at)
+SELECT
+    exercise_id,
+    project_uuid,
+    group_id,
+    name,
+    state,
+    created_at
+FROM
+    exercises;
+
+DROP TABLE exercises;
+
+ALTER TABLE exercises_new RENAME TO exercises;
@@ -0,0 +1,5 @@
+-- Baseline snapshot taken when the project of a group was created and the
+-- template project it was made from, for resetting it.
+ALTER TABLE exercises ADD COLUMN baseline_snapshot_id text;
+
+ALTER TABLE exercises ADD COLUMN template_project_id text;
@@ -0,0 +1,3 @@
+-- Project name format an exercise was created with, for deploying it to
+-- groups added later.
+ALTER TABLE exercises ADD COLUMN name_format text;
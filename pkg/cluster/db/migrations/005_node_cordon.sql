@@ -0,0 +1,2 @@
+-- Cordoned nodes get no new groups, set by cluster cordon and drain.
+ALTER TABLE nodes ADD COLUMN cordoned boolean NOT NULL DEFAULT 0;
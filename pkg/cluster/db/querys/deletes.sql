@@ -116,3 +116,15 @@ DELETE FROM
 
 DELETE FROM
     sqlite_sequence;
+
+-- name: DeleteUser :exec
+DELETE FROM
+    users
+WHERE
+    user_id = ?;
+
+-- name: DeleteGroup :exec
+DELETE FROM
+    groups
+WHERE
+    group_id = ?;
@@ -29,7 +29,8 @@ RETURNING
     host,
     port,
     weight,
-    max_groups;
+    max_groups,
+    cordoned;
 
 -- name: InsertNode :exec
 INSERT INTO
@@ -71,9 +72,17 @@ VALUES
 
 -- name: InsertExerciseRecord :exec
 INSERT INTO
-    exercises (project_uuid, group_id, name, state)
+    exercises (
+        project_uuid,
+        group_id,
+        name,
+        state,
+        baseline_snapshot_id,
+        template_project_id,
+        name_format
+    )
 VALUES
-    (?, ?, ?, ?);
+    (?, ?, ?, ?, ?, ?, ?);
 
 -- name: CreateClassReturning :one
 INSERT INTO
@@ -98,3 +107,9 @@ VALUES
     (?, ?, ?, ?)
 RETURNING
     user_id;
+
+-- name: CreateNotification :exec
+INSERT INTO
+    notifications (user_id, email, status, error)
+VALUES
+    (?, ?, ?, ?);
@@ -49,7 +49,8 @@ SELECT
     host,
     port,
     weight,
-    max_groups
+    max_groups,
+    cordoned
 FROM
     nodes
 ORDER BY
@@ -64,7 +65,8 @@ SELECT
     host,
     port,
     weight,
-    max_groups
+    max_groups,
+    cordoned
 FROM
     nodes
 WHERE
@@ -114,6 +116,19 @@ ORDER BY
     g.group_id,
     u.user_id;
 
+-- name: GetGroupIDForClass :one
+SELECT
+    g.group_id
+FROM
+    groups g
+    JOIN classes c ON g.class_id = c.class_id
+WHERE
+    c.cluster_id = ?
+    AND c.name = ?
+    AND g.name = ?
+LIMIT
+    1;
+
 -- name: GetClusterByID :one
 SELECT
     cluster_id,
@@ -166,6 +181,9 @@ SELECT
     e.project_uuid,
     g.name AS group_name,
     e.state,
+    e.started_at,
+    e.stopped_at,
+    e.completed_at,
     c.class_id,
     c.name
 FROM
@@ -226,3 +244,139 @@ WHERE
         ? = ''
         OR g.name = ?
     );
+
+-- name: GetClassByName :one
+SELECT
+    class_id,
+    cluster_id,
+    name,
+    description
+FROM
+    classes
+WHERE
+    cluster_id = ?
+    AND name = ?;
+
+-- name: GetClassMembers :many
+SELECT
+    g.group_id,
+    g.name AS group_name,
+    u.user_id,
+    u.username,
+    u.full_name,
+    u.default_password,
+    ga.node_id
+FROM
+    classes c
+    JOIN groups g ON g.class_id = c.class_id
+    LEFT JOIN users u ON u.group_id = g.group_id
+    LEFT JOIN group_assignments ga ON ga.group_id = g.group_id
+WHERE
+    c.cluster_id = ?
+    AND c.name = ?
+ORDER BY
+    g.group_id,
+    u.user_id;
+
+-- name: GetExercisesForGroup :many
+SELECT
+    exercise_id,
+    project_uuid,
+    group_id,
+    name,
+    state,
+    created_at,
+    started_at,
+    stopped_at,
+    completed_at,
+    baseline_snapshot_id,
+    template_project_id,
+    name_format
+FROM
+    exercises
+WHERE
+    group_id = ?
+ORDER BY
+    exercise_id;
+
+-- name: GetNotificationsForClass :many
+SELECT
+    n.notification_id,
+    u.username,
+    n.email,
+    n.status,
+    n.error,
+    n.sent_at
+FROM
+    notifications n
+    JOIN users u ON u.user_id = n.user_id
+    JOIN groups g ON g.group_id = u.group_id
+    JOIN classes c ON c.class_id = g.class_id
+WHERE
+    c.cluster_id = ?
+    AND c.name = ?
+ORDER BY
+    n.notification_id;
+
+-- name: GetExerciseProjects :many
+SELECT
+    e.exercise_id,
+    e.project_uuid,
+    e.state,
+    e.baseline_snapshot_id,
+    e.template_project_id,
+    e.name_format,
+    g.name AS group_name,
+    c.name AS class_name,
+    n.protocol || '://' || n.host || ':' || CAST(n.port AS TEXT) AS node_url
+FROM
+    exercises e
+    JOIN groups g ON e.group_id = g.group_id
+    JOIN classes c ON g.class_id = c.class_id
+    JOIN group_assignments ga ON ga.group_id = g.group_id
+    JOIN nodes n ON n.node_id = ga.node_id
+WHERE
+    c.cluster_id = ?
+    AND e.name = ?
+    AND e.state <> 'deleted'
+    AND (
+        ? = ''
+        OR c.name = ?
+    )
+    AND (
+        ? = ''
+        OR g.name = ?
+    )
+ORDER BY
+    n.node_id,
+    g.group_id;
+
+-- name: GetGroupNode :one
+SELECT
+    g.group_id,
+    n.protocol || '://' || n.host || ':' || CAST(n.port AS TEXT) AS node_url
+FROM
+    groups g
+    JOIN classes c ON g.class_id = c.class_id
+    JOIN group_assignments ga ON ga.group_id = g.group_id
+    JOIN nodes n ON n.node_id = ga.node_id
+WHERE
+    c.cluster_id = ?
+    AND c.name = ?
+    AND g.name = ?;
+
+-- name: GetGroupsOnNode :many
+SELECT
+    g.group_id,
+    g.name,
+    c.class_id,
+    c.name AS class_name
+FROM
+    group_assignments ga
+    JOIN groups g ON g.group_id = ga.group_id
+    JOIN classes c ON c.class_id = g.class_id
+WHERE
+    ga.node_id = ?
+ORDER BY
+    c.name,
+    g.name;
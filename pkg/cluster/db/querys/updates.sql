@@ -18,3 +18,84 @@ SET
     description = ?
 WHERE
     cluster_id = ?;
+
+-- name: UpdateClassDescription :exec
+UPDATE
+    classes
+SET
+    description = ?
+WHERE
+    class_id = ?;
+
+-- name: UpdateUserGroup :exec
+UPDATE
+    users
+SET
+    group_id = ?
+WHERE
+    user_id = ?;
+
+-- name: SetExerciseRunning :exec
+UPDATE
+    exercises
+SET
+    state = 'running',
+    started_at = CURRENT_TIMESTAMP
+WHERE
+    exercise_id = ?;
+
+-- name: SetExerciseStopped :exec
+UPDATE
+    exercises
+SET
+    state = 'stopped',
+    stopped_at = CURRENT_TIMESTAMP
+WHERE
+    exercise_id = ?;
+
+-- name: SetExerciseCompleted :exec
+UPDATE
+    exercises
+SET
+    state = 'completed',
+    completed_at = CURRENT_TIMESTAMP
+WHERE
+    exercise_id = ?;
+
+-- name: ResetExercise :exec
+UPDATE
+    exercises
+SET
+    state = 'created',
+    started_at = NULL,
+    stopped_at = NULL,
+    completed_at = NULL,
+    baseline_snapshot_id = ?
+WHERE
+    exercise_id = ?;
+
+-- name: UpdateNodeCordoned :exec
+UPDATE
+    nodes
+SET
+    cordoned = ?
+WHERE
+    node_id = ?;
+
+-- name: UpdateGroupAssignment :exec
+UPDATE
+    group_assignments
+SET
+    node_id = ?,
+    assigned_at = CURRENT_TIMESTAMP
+WHERE
+    group_id = ?;
+
+-- name: UpdateExerciseProjectRefs :exec
+UPDATE
+    exercises
+SET
+    baseline_snapshot_id = ?,
+    template_project_id = ?
+WHERE
+    exercise_id = ?;
@@ -1,3 +1,5 @@
+-- The latest schema, used for new databases and by sqlc. Each change made
+-- by a file in migrations/ has to be made here too.
 PRAGMA foreign_keys = ON;
 
 CREATE TABLE clusters(
@@ -14,7 +16,8 @@ CREATE TABLE nodes (
     host text NOT NULL,
     port integer NOT NULL,
     weight integer NOT NULL DEFAULT 5 CHECK (weight BETWEEN 0 AND 10),
-    max_groups integer DEFAULT 3
+    max_groups integer DEFAULT 3,
+    cordoned boolean NOT NULL DEFAULT 0
     -- unique(protocol, host, port)
 );
 
@@ -57,8 +60,24 @@ CREATE TABLE exercises (
     group_id integer NOT NULL,
     name text NOT NULL,
     state text CHECK (
-        state IN ('created', 'running', 'completed', 'deleted')
+        state IN ('created', 'running', 'stopped', 'completed', 'deleted')
     ) DEFAULT 'created',
     created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    started_at timestamp,
+    stopped_at timestamp,
+    completed_at timestamp,
+    baseline_snapshot_id text,
+    template_project_id text,
+    name_format text,
     FOREIGN KEY (group_id) REFERENCES groups(group_id) ON DELETE CASCADE
 );
+
+CREATE TABLE notifications (
+    notification_id integer PRIMARY KEY autoincrement,
+    user_id integer NOT NULL,
+    email text NOT NULL,
+    status text CHECK (status IN ('sent', 'failed')) NOT NULL,
+    error text,
+    sent_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
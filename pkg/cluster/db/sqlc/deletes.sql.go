@@ -154,6 +154,18 @@ func (q *Queries) DeleteExerciseScoped(ctx context.Context, arg DeleteExerciseSc
 	return err
 }
 
+const deleteGroup = `-- name: DeleteGroup :exec
+DELETE FROM
+    groups
+WHERE
+    group_id = ?
+`
+
+func (q *Queries) DeleteGroup(ctx context.Context, groupID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteGroup, groupID)
+	return err
+}
+
 const deleteNode = `-- name: DeleteNode :exec
 DELETE FROM
     nodes
@@ -166,6 +178,18 @@ func (q *Queries) DeleteNode(ctx context.Context, nodeID int64) error {
 	return err
 }
 
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM
+    users
+WHERE
+    user_id = ?
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, deleteUser, userID)
+	return err
+}
+
 const nukeEverything = `-- name: NukeEverything :exec
 DELETE FROM clusters
 `
@@ -128,6 +128,30 @@ func (q *Queries) CreateGroupReturning(ctx context.Context, arg CreateGroupRetur
 	return group_id, err
 }
 
+const createNotification = `-- name: CreateNotification :exec
+INSERT INTO
+    notifications (user_id, email, status, error)
+VALUES
+    (?, ?, ?, ?)
+`
+
+type CreateNotificationParams struct {
+	UserID int64
+	Email  string
+	Status string
+	Error  sql.NullString
+}
+
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) error {
+	_, err := q.db.ExecContext(ctx, createNotification,
+		arg.UserID,
+		arg.Email,
+		arg.Status,
+		arg.Error,
+	)
+	return err
+}
+
 const createUser = `-- name: CreateUser :exec
 INSERT INTO
     users (group_id, username, full_name, default_password)
@@ -182,16 +206,27 @@ func (q *Queries) CreateUserReturning(ctx context.Context, arg CreateUserReturni
 
 const insertExerciseRecord = `-- name: InsertExerciseRecord :exec
 INSERT INTO
-    exercises (project_uuid, group_id, name, state)
+    exercises (
+        project_uuid,
+        group_id,
+        name,
+        state,
+        baseline_snapshot_id,
+        template_project_id,
+        name_format
+    )
 VALUES
-    (?, ?, ?, ?)
+    (?, ?, ?, ?, ?, ?, ?)
 `
 
 type InsertExerciseRecordParams struct {
-	ProjectUuid string
-	GroupID     int64
-	Name        string
-	State       sql.NullString
+	ProjectUuid        string
+	GroupID            int64
+	Name               string
+	State              sql.NullString
+	BaselineSnapshotID sql.NullString
+	TemplateProjectID  sql.NullString
+	NameFormat         sql.NullString
 }
 
 func (q *Queries) InsertExerciseRecord(ctx context.Context, arg InsertExerciseRecordParams) error {
@@ -200,6 +235,9 @@ func (q *Queries) InsertExerciseRecord(ctx context.Context, arg InsertExerciseRe
 		arg.GroupID,
 		arg.Name,
 		arg.State,
+		arg.BaselineSnapshotID,
+		arg.TemplateProjectID,
+		arg.NameFormat,
 	)
 	return err
 }
@@ -263,7 +301,8 @@ RETURNING
     host,
     port,
     weight,
-    max_groups
+    max_groups,
+    cordoned
 `
 
 type InsertNodeIntoClusterParams struct {
@@ -296,6 +335,7 @@ func (q *Queries) InsertNodeIntoCluster(ctx context.Context, arg InsertNodeIntoC
 		&i.Port,
 		&i.Weight,
 		&i.MaxGroups,
+		&i.Cordoned,
 	)
 	return i, err
 }
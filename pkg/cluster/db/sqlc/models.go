@@ -22,12 +22,18 @@ type Cluster struct {
 }
 
 type Exercise struct {
-	ExerciseID  int64
-	ProjectUuid string
-	GroupID     int64
-	Name        string
-	State       sql.NullString
-	CreatedAt   sql.NullTime
+	ExerciseID         int64
+	ProjectUuid        string
+	GroupID            int64
+	Name               string
+	State              sql.NullString
+	CreatedAt          sql.NullTime
+	StartedAt          sql.NullTime
+	StoppedAt          sql.NullTime
+	CompletedAt        sql.NullTime
+	BaselineSnapshotID sql.NullString
+	TemplateProjectID  sql.NullString
+	NameFormat         sql.NullString
 }
 
 type Group struct {
@@ -51,6 +57,16 @@ type Node struct {
 	Port      int64
 	Weight    int64
 	MaxGroups sql.NullInt64
+	Cordoned  bool
+}
+
+type Notification struct {
+	NotificationID int64
+	UserID         int64
+	Email          string
+	Status         string
+	Error          sql.NullString
+	SentAt         sql.NullTime
 }
 
 type User struct {
@@ -143,6 +143,36 @@ func (q *Queries) GetAllExerciseNameFromCluster(ctx context.Context, name string
 	return items, nil
 }
 
+const getClassByName = `-- name: GetClassByName :one
+SELECT
+    class_id,
+    cluster_id,
+    name,
+    description
+FROM
+    classes
+WHERE
+    cluster_id = ?
+    AND name = ?
+`
+
+type GetClassByNameParams struct {
+	ClusterID int64
+	Name      string
+}
+
+func (q *Queries) GetClassByName(ctx context.Context, arg GetClassByNameParams) (Class, error) {
+	row := q.db.QueryRowContext(ctx, getClassByName, arg.ClusterID, arg.Name)
+	var i Class
+	err := row.Scan(
+		&i.ClassID,
+		&i.ClusterID,
+		&i.Name,
+		&i.Description,
+	)
+	return i, err
+}
+
 const getClassDisribution = `-- name: GetClassDisribution :many
 SELECT
     c.name AS class_name,
@@ -202,6 +232,74 @@ func (q *Queries) GetClassDisribution(ctx context.Context, clusterID int64) ([]G
 	return items, nil
 }
 
+const getClassMembers = `-- name: GetClassMembers :many
+SELECT
+    g.group_id,
+    g.name AS group_name,
+    u.user_id,
+    u.username,
+    u.full_name,
+    u.default_password,
+    ga.node_id
+FROM
+    classes c
+    JOIN groups g ON g.class_id = c.class_id
+    LEFT JOIN users u ON u.group_id = g.group_id
+    LEFT JOIN group_assignments ga ON ga.group_id = g.group_id
+WHERE
+    c.cluster_id = ?
+    AND c.name = ?
+ORDER BY
+    g.group_id,
+    u.user_id
+`
+
+type GetClassMembersParams struct {
+	ClusterID int64
+	Name      string
+}
+
+type GetClassMembersRow struct {
+	GroupID         int64
+	GroupName       string
+	UserID          sql.NullInt64
+	Username        sql.NullString
+	FullName        sql.NullString
+	DefaultPassword sql.NullString
+	NodeID          sql.NullInt64
+}
+
+func (q *Queries) GetClassMembers(ctx context.Context, arg GetClassMembersParams) ([]GetClassMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getClassMembers, arg.ClusterID, arg.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetClassMembersRow
+	for rows.Next() {
+		var i GetClassMembersRow
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.GroupName,
+			&i.UserID,
+			&i.Username,
+			&i.FullName,
+			&i.DefaultPassword,
+			&i.NodeID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getClasses = `-- name: GetClasses :many
 SELECT
     class_id,
@@ -298,6 +396,101 @@ func (q *Queries) GetClusters(ctx context.Context) ([]Cluster, error) {
 	return items, nil
 }
 
+const getExerciseProjects = `-- name: GetExerciseProjects :many
+SELECT
+    e.exercise_id,
+    e.project_uuid,
+    e.state,
+    e.baseline_snapshot_id,
+    e.template_project_id,
+    e.name_format,
+    g.name AS group_name,
+    c.name AS class_name,
+    n.protocol || '://' || n.host || ':' || CAST(n.port AS TEXT) AS node_url
+FROM
+    exercises e
+    JOIN groups g ON e.group_id = g.group_id
+    JOIN classes c ON g.class_id = c.class_id
+    JOIN group_assignments ga ON ga.group_id = g.group_id
+    JOIN nodes n ON n.node_id = ga.node_id
+WHERE
+    c.cluster_id = ?
+    AND e.name = ?
+    AND e.state <> 'deleted'
+    AND (
+        ? = ''
+        OR c.name = ?
+    )
+    AND (
+        ? = ''
+        OR g.name = ?
+    )
+ORDER BY
+    n.node_id,
+    g.group_id
+`
+
+type GetExerciseProjectsParams struct {
+	ClusterID int64
+	Name      string
+	Column3   interface{}
+	Name_2    string
+	Column5   interface{}
+	Name_3    string
+}
+
+type GetExerciseProjectsRow struct {
+	ExerciseID         int64
+	ProjectUuid        string
+	State              sql.NullString
+	BaselineSnapshotID sql.NullString
+	TemplateProjectID  sql.NullString
+	NameFormat         sql.NullString
+	GroupName          string
+	ClassName          string
+	NodeUrl            interface{}
+}
+
+func (q *Queries) GetExerciseProjects(ctx context.Context, arg GetExerciseProjectsParams) ([]GetExerciseProjectsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getExerciseProjects,
+		arg.ClusterID,
+		arg.Name,
+		arg.Column3,
+		arg.Name_2,
+		arg.Column5,
+		arg.Name_3,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetExerciseProjectsRow
+	for rows.Next() {
+		var i GetExerciseProjectsRow
+		if err := rows.Scan(
+			&i.ExerciseID,
+			&i.ProjectUuid,
+			&i.State,
+			&i.BaselineSnapshotID,
+			&i.TemplateProjectID,
+			&i.NameFormat,
+			&i.GroupName,
+			&i.ClassName,
+			&i.NodeUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getExercisesForDeletion = `-- name: GetExercisesForDeletion :many
 SELECT
     e.project_uuid,
@@ -369,6 +562,176 @@ func (q *Queries) GetExercisesForDeletion(ctx context.Context, arg GetExercisesF
 	return items, nil
 }
 
+const getExercisesForGroup = `-- name: GetExercisesForGroup :many
+SELECT
+    exercise_id,
+    project_uuid,
+    group_id,
+    name,
+    state,
+    created_at,
+    started_at,
+    stopped_at,
+    completed_at,
+    baseline_snapshot_id,
+    template_project_id,
+    name_format
+FROM
+    exercises
+WHERE
+    group_id = ?
+ORDER BY
+    exercise_id
+`
+
+func (q *Queries) GetExercisesForGroup(ctx context.Context, groupID int64) ([]Exercise, error) {
+	rows, err := q.db.QueryContext(ctx, getExercisesForGroup, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Exercise
+	for rows.Next() {
+		var i Exercise
+		if err := rows.Scan(
+			&i.ExerciseID,
+			&i.ProjectUuid,
+			&i.GroupID,
+			&i.Name,
+			&i.State,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.StoppedAt,
+			&i.CompletedAt,
+			&i.BaselineSnapshotID,
+			&i.TemplateProjectID,
+			&i.NameFormat,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getGroupIDForClass = `-- name: GetGroupIDForClass :one
+SELECT
+    g.group_id
+FROM
+    groups g
+    JOIN classes c ON g.class_id = c.class_id
+WHERE
+    c.cluster_id = ?
+    AND c.name = ?
+    AND g.name = ?
+LIMIT
+    1
+`
+
+type GetGroupIDForClassParams struct {
+	ClusterID int64
+	Name      string
+	Name_2    string
+}
+
+func (q *Queries) GetGroupIDForClass(ctx context.Context, arg GetGroupIDForClassParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getGroupIDForClass, arg.ClusterID, arg.Name, arg.Name_2)
+	var group_id int64
+	err := row.Scan(&group_id)
+	return group_id, err
+}
+
+const getGroupNode = `-- name: GetGroupNode :one
+SELECT
+    g.group_id,
+    n.protocol || '://' || n.host || ':' || CAST(n.port AS TEXT) AS node_url
+FROM
+    groups g
+    JOIN classes c ON g.class_id = c.class_id
+    JOIN group_assignments ga ON ga.group_id = g.group_id
+    JOIN nodes n ON n.node_id = ga.node_id
+WHERE
+    c.cluster_id = ?
+    AND c.name = ?
+    AND g.name = ?
+`
+
+type GetGroupNodeParams struct {
+	ClusterID int64
+	Name      string
+	Name_2    string
+}
+
+type GetGroupNodeRow struct {
+	GroupID int64
+	NodeUrl interface{}
+}
+
+func (q *Queries) GetGroupNode(ctx context.Context, arg GetGroupNodeParams) (GetGroupNodeRow, error) {
+	row := q.db.QueryRowContext(ctx, getGroupNode, arg.ClusterID, arg.Name, arg.Name_2)
+	var i GetGroupNodeRow
+	err := row.Scan(&i.GroupID, &i.NodeUrl)
+	return i, err
+}
+
+const getGroupsOnNode = `-- name: GetGroupsOnNode :many
+SELECT
+    g.group_id,
+    g.name,
+    c.class_id,
+    c.name AS class_name
+FROM
+    group_assignments ga
+    JOIN groups g ON g.group_id = ga.group_id
+    JOIN classes c ON c.class_id = g.class_id
+WHERE
+    ga.node_id = ?
+ORDER BY
+    c.name,
+    g.name
+`
+
+type GetGroupsOnNodeRow struct {
+	GroupID   int64
+	Name      string
+	ClassID   int64
+	ClassName string
+}
+
+func (q *Queries) GetGroupsOnNode(ctx context.Context, nodeID int64) ([]GetGroupsOnNodeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getGroupsOnNode, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGroupsOnNodeRow
+	for rows.Next() {
+		var i GetGroupsOnNodeRow
+		if err := rows.Scan(
+			&i.GroupID,
+			&i.Name,
+			&i.ClassID,
+			&i.ClassName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getNodeExercisesForCluster = `-- name: GetNodeExercisesForCluster :many
 SELECT
     n.protocol || '://' || n.host || ':' || CAST(n.port AS TEXT) AS node_url,
@@ -376,6 +739,9 @@ SELECT
     e.project_uuid,
     g.name AS group_name,
     e.state,
+    e.started_at,
+    e.stopped_at,
+    e.completed_at,
     c.class_id,
     c.name
 FROM
@@ -399,6 +765,9 @@ type GetNodeExercisesForClusterRow struct {
 	ProjectUuid  string
 	GroupName    string
 	State        sql.NullString
+	StartedAt    sql.NullTime
+	StoppedAt    sql.NullTime
+	CompletedAt  sql.NullTime
 	ClassID      int64
 	Name         string
 }
@@ -418,6 +787,9 @@ func (q *Queries) GetNodeExercisesForCluster(ctx context.Context, clusterID int6
 			&i.ProjectUuid,
 			&i.GroupName,
 			&i.State,
+			&i.StartedAt,
+			&i.StoppedAt,
+			&i.CompletedAt,
 			&i.ClassID,
 			&i.Name,
 		); err != nil {
@@ -548,7 +920,8 @@ SELECT
     host,
     port,
     weight,
-    max_groups
+    max_groups,
+    cordoned
 FROM
     nodes
 ORDER BY
@@ -573,6 +946,7 @@ func (q *Queries) GetNodes(ctx context.Context) ([]Node, error) {
 			&i.Port,
 			&i.Weight,
 			&i.MaxGroups,
+			&i.Cordoned,
 		); err != nil {
 			return nil, err
 		}
@@ -596,7 +970,8 @@ SELECT
     host,
     port,
     weight,
-    max_groups
+    max_groups,
+    cordoned
 FROM
     nodes
 WHERE
@@ -621,6 +996,71 @@ func (q *Queries) GetNodesFromClusterID(ctx context.Context, clusterID int64) ([
 			&i.Port,
 			&i.Weight,
 			&i.MaxGroups,
+			&i.Cordoned,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationsForClass = `-- name: GetNotificationsForClass :many
+SELECT
+    n.notification_id,
+    u.username,
+    n.email,
+    n.status,
+    n.error,
+    n.sent_at
+FROM
+    notifications n
+    JOIN users u ON u.user_id = n.user_id
+    JOIN groups g ON g.group_id = u.group_id
+    JOIN classes c ON c.class_id = g.class_id
+WHERE
+    c.cluster_id = ?
+    AND c.name = ?
+ORDER BY
+    n.notification_id
+`
+
+type GetNotificationsForClassParams struct {
+	ClusterID int64
+	Name      string
+}
+
+type GetNotificationsForClassRow struct {
+	NotificationID int64
+	Username       string
+	Email          string
+	Status         string
+	Error          sql.NullString
+	SentAt         sql.NullTime
+}
+
+func (q *Queries) GetNotificationsForClass(ctx context.Context, arg GetNotificationsForClassParams) ([]GetNotificationsForClassRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNotificationsForClass, arg.ClusterID, arg.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNotificationsForClassRow
+	for rows.Next() {
+		var i GetNotificationsForClassRow
+		if err := rows.Scan(
+			&i.NotificationID,
+			&i.Username,
+			&i.Email,
+			&i.Status,
+			&i.Error,
+			&i.SentAt,
 		); err != nil {
 			return nil, err
 		}
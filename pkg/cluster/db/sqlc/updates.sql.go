@@ -10,6 +10,93 @@ import (
 	"database/sql"
 )
 
+const resetExercise = `-- name: ResetExercise :exec
+UPDATE
+    exercises
+SET
+    state = 'created',
+    started_at = NULL,
+    stopped_at = NULL,
+    completed_at = NULL,
+    baseline_snapshot_id = ?
+WHERE
+    exercise_id = ?
+`
+
+type ResetExerciseParams struct {
+	BaselineSnapshotID sql.NullString
+	ExerciseID         int64
+}
+
+func (q *Queries) ResetExercise(ctx context.Context, arg ResetExerciseParams) error {
+	_, err := q.db.ExecContext(ctx, resetExercise, arg.BaselineSnapshotID, arg.ExerciseID)
+	return err
+}
+
+const setExerciseCompleted = `-- name: SetExerciseCompleted :exec
+UPDATE
+    exercises
+SET
+    state = 'completed',
+    completed_at = CURRENT_TIMESTAMP
+WHERE
+    exercise_id = ?
+`
+
+func (q *Queries) SetExerciseCompleted(ctx context.Context, exerciseID int64) error {
+	_, err := q.db.ExecContext(ctx, setExerciseCompleted, exerciseID)
+	return err
+}
+
+const setExerciseRunning = `-- name: SetExerciseRunning :exec
+UPDATE
+    exercises
+SET
+    state = 'running',
+    started_at = CURRENT_TIMESTAMP
+WHERE
+    exercise_id = ?
+`
+
+func (q *Queries) SetExerciseRunning(ctx context.Context, exerciseID int64) error {
+	_, err := q.db.ExecContext(ctx, setExerciseRunning, exerciseID)
+	return err
+}
+
+const setExerciseStopped = `-- name: SetExerciseStopped :exec
+UPDATE
+    exercises
+SET
+    state = 'stopped',
+    stopped_at = CURRENT_TIMESTAMP
+WHERE
+    exercise_id = ?
+`
+
+func (q *Queries) SetExerciseStopped(ctx context.Context, exerciseID int64) error {
+	_, err := q.db.ExecContext(ctx, setExerciseStopped, exerciseID)
+	return err
+}
+
+const updateClassDescription = `-- name: UpdateClassDescription :exec
+UPDATE
+    classes
+SET
+    description = ?
+WHERE
+    class_id = ?
+`
+
+type UpdateClassDescriptionParams struct {
+	Description sql.NullString
+	ClassID     int64
+}
+
+func (q *Queries) UpdateClassDescription(ctx context.Context, arg UpdateClassDescriptionParams) error {
+	_, err := q.db.ExecContext(ctx, updateClassDescription, arg.Description, arg.ClassID)
+	return err
+}
+
 const updateClusterDescription = `-- name: UpdateClusterDescription :exec
 UPDATE
     clusters
@@ -29,6 +116,47 @@ func (q *Queries) UpdateClusterDescription(ctx context.Context, arg UpdateCluste
 	return err
 }
 
+const updateExerciseProjectRefs = `-- name: UpdateExerciseProjectRefs :exec
+UPDATE
+    exercises
+SET
+    baseline_snapshot_id = ?,
+    template_project_id = ?
+WHERE
+    exercise_id = ?
+`
+
+type UpdateExerciseProjectRefsParams struct {
+	BaselineSnapshotID sql.NullString
+	TemplateProjectID  sql.NullString
+	ExerciseID         int64
+}
+
+func (q *Queries) UpdateExerciseProjectRefs(ctx context.Context, arg UpdateExerciseProjectRefsParams) error {
+	_, err := q.db.ExecContext(ctx, updateExerciseProjectRefs, arg.BaselineSnapshotID, arg.TemplateProjectID, arg.ExerciseID)
+	return err
+}
+
+const updateGroupAssignment = `-- name: UpdateGroupAssignment :exec
+UPDATE
+    group_assignments
+SET
+    node_id = ?,
+    assigned_at = CURRENT_TIMESTAMP
+WHERE
+    group_id = ?
+`
+
+type UpdateGroupAssignmentParams struct {
+	NodeID  int64
+	GroupID int64
+}
+
+func (q *Queries) UpdateGroupAssignment(ctx context.Context, arg UpdateGroupAssignmentParams) error {
+	_, err := q.db.ExecContext(ctx, updateGroupAssignment, arg.NodeID, arg.GroupID)
+	return err
+}
+
 const updateNode = `-- name: UpdateNode :exec
 UPDATE
     nodes
@@ -65,3 +193,41 @@ func (q *Queries) UpdateNode(ctx context.Context, arg UpdateNodeParams) error {
 	)
 	return err
 }
+
+const updateNodeCordoned = `-- name: UpdateNodeCordoned :exec
+UPDATE
+    nodes
+SET
+    cordoned = ?
+WHERE
+    node_id = ?
+`
+
+type UpdateNodeCordonedParams struct {
+	Cordoned bool
+	NodeID   int64
+}
+
+func (q *Queries) UpdateNodeCordoned(ctx context.Context, arg UpdateNodeCordonedParams) error {
+	_, err := q.db.ExecContext(ctx, updateNodeCordoned, arg.Cordoned, arg.NodeID)
+	return err
+}
+
+const updateUserGroup = `-- name: UpdateUserGroup :exec
+UPDATE
+    users
+SET
+    group_id = ?
+WHERE
+    user_id = ?
+`
+
+type UpdateUserGroupParams struct {
+	GroupID int64
+	UserID  int64
+}
+
+func (q *Queries) UpdateUserGroup(ctx context.Context, arg UpdateUserGroupParams) error {
+	_, err := q.db.ExecContext(ctx, updateUserGroup, arg.GroupID, arg.UserID)
+	return err
+}
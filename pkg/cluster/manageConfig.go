@@ -37,7 +37,7 @@ func ApplyConfig(cfg Config) error {
 		return fmt.Errorf("begin tx: %w", err)
 	}
 	defer func() {
-		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
 			// Log the rollback error but don't override the original error
 			fmt.Printf("Warning: failed to rollback transaction: %v\n", rollbackErr)
 		}
@@ -174,6 +174,14 @@ func syncNodes(ctx context.Context, qtx *sqlc.Queries, cfg Config, cfgCluster Cl
 	for _, dbNode := range dbNodes {
 		key := nodeKey(dbNode.Host, int(dbNode.Port))
 		if !configNodeKeys[key] {
+			// deleting the node would cascade away the groups assigned to it
+			groups, err := qtx.GetGroupsOnNode(ctx, dbNode.NodeID)
+			if err != nil {
+				return fmt.Errorf("get groups of node %s: %w", key, err)
+			}
+			if len(groups) > 0 {
+				return fmt.Errorf("node %s still has %d groups, move them with cluster drain first", key, len(groups))
+			}
 			if err := qtx.DeleteNode(ctx, dbNode.NodeID); err != nil {
 				return fmt.Errorf("delete node %s: %w", key, err)
 			}
@@ -373,12 +381,14 @@ func mergeConfigWithDb(cfg Config, dbClusters []sqlc.Cluster, dbNodes []sqlc.Nod
 				Weight:    int(dbNode.Weight),
 				MaxGroups: maxGroups,
 				User:      user,
+				Label:     existingNode.Label,
 			})
 		}
 
 		rebuilt.Clusters = append(rebuilt.Clusters, Cluster{
 			Name:        dbCluster.Name,
 			Description: desc,
+			Placement:   existing.Placement,
 			Nodes:       nodes,
 		})
 	}
@@ -393,6 +403,7 @@ func mergeConfigWithDb(cfg Config, dbClusters []sqlc.Cluster, dbNodes []sqlc.Nod
 
 type clusterView struct {
 	Description string
+	Placement   string
 	Nodes       map[string]nodeView
 }
 
@@ -401,6 +412,7 @@ type nodeView struct {
 	Weight    int
 	MaxGroups int
 	User      string
+	Label     string
 }
 
 func buildDbView(clusters []sqlc.Cluster, nodes []sqlc.Node) map[string]clusterView {
@@ -438,6 +450,7 @@ func buildCfgView(cfg Config) map[string]clusterView {
 	for _, c := range cfg.Clusters {
 		cv := clusterView{
 			Description: strings.TrimSpace(c.Description),
+			Placement:   strings.TrimSpace(c.Placement),
 			Nodes:       make(map[string]nodeView),
 		}
 
@@ -448,6 +461,7 @@ func buildCfgView(cfg Config) map[string]clusterView {
 				Weight:    n.Weight,
 				MaxGroups: defaultInt(n.MaxGroups, cfg.Settings.DefaultMaxGroups),
 				User:      strings.TrimSpace(n.User),
+				Label:     strings.TrimSpace(n.Label),
 			}
 		}
 		res[norm(c.Name)] = cv
@@ -471,6 +485,7 @@ func normalizeConfig(cfg Config) Config {
 				Weight:    n.Weight,
 				MaxGroups: n.MaxGroups,
 				User:      strings.TrimSpace(n.User),
+				Label:     strings.TrimSpace(n.Label),
 			}
 		}
 		sort.Slice(nodes, func(a, b int) bool {
@@ -483,6 +498,7 @@ func normalizeConfig(cfg Config) Config {
 		normalized.Clusters[i] = Cluster{
 			Name:        strings.TrimSpace(cl.Name),
 			Description: strings.TrimSpace(cl.Description),
+			Placement:   strings.TrimSpace(cl.Placement),
 			Nodes:       nodes,
 		}
 	}
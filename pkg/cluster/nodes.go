@@ -0,0 +1,53 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+)
+
+// FindNode looks up a cluster by name or ID and one of its nodes by ID,
+// URL or host:port.
+func FindNode(ctx context.Context, store *db.Store, clusterRef, nodeRef string) (sqlc.Cluster, sqlc.Node, error) {
+	clusters, err := store.GetClusters(ctx)
+	if err != nil {
+		return sqlc.Cluster{}, sqlc.Node{}, fmt.Errorf("failed to get clusters: %w", err)
+	}
+	var found *sqlc.Cluster
+	clusterID, idErr := strconv.Atoi(clusterRef)
+	for i, c := range clusters {
+		if c.Name == clusterRef || (idErr == nil && int(c.ClusterID) == clusterID) {
+			found = &clusters[i]
+			break
+		}
+	}
+	if found == nil {
+		return sqlc.Cluster{}, sqlc.Node{}, fmt.Errorf("cluster not found: %s", clusterRef)
+	}
+
+	nodes, err := store.GetNodesFromClusterID(ctx, found.ClusterID)
+	if err != nil {
+		return *found, sqlc.Node{}, fmt.Errorf("failed to get nodes: %w", err)
+	}
+	nodeID, idErr := strconv.Atoi(nodeRef)
+	hostPort := strings.ToLower(strings.TrimSpace(nodeRef))
+	if u, err := url.Parse(hostPort); err == nil && u.Host != "" {
+		hostPort = u.Host
+	}
+	for _, n := range nodes {
+		if (idErr == nil && int(n.NodeID) == nodeID) || nodeKey(n.Host, int(n.Port)) == hostPort {
+			return *found, n, nil
+		}
+	}
+	return *found, sqlc.Node{}, fmt.Errorf("node %s not found in cluster %s", nodeRef, found.Name)
+}
+
+// NodeURL returns the URL of a node.
+func NodeURL(n sqlc.Node) string {
+	return fmt.Sprintf("%s://%s:%d", n.Protocol, n.Host, n.Port)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
 type globalOptionsKey string
@@ -15,6 +16,29 @@ type GlobalOptions struct {
 	Raw      bool
 	NoColors bool
 	KeyFile  string
+	User     string
+	Retries  int
+	// RetryDelay, RetryMaxDelay and RetryStatus tune the retries of
+	// failed requests, zero values keep the defaults of the API client.
+	RetryDelay    time.Duration
+	RetryMaxDelay time.Duration
+	RetryStatus   []int
+	// Output, Columns and SortBy come from the --output, --columns and
+	// --sort-by flags of commands that print API responses.
+	Output  string
+	Columns []string
+	SortBy  string
+
+	ctx context.Context
+}
+
+// Context returns the context of the command the options were created for
+// so API calls made with these options are canceled together with it.
+func (o GlobalOptions) Context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
 }
 
 func GetGlobalOptionsFromContext(ctx context.Context) (GlobalOptions, error) {
@@ -26,5 +50,6 @@ func GetGlobalOptionsFromContext(ctx context.Context) (GlobalOptions, error) {
 }
 
 func WithGlobalOptions(ctx context.Context, opts GlobalOptions) context.Context {
+	opts.ctx = ctx
 	return context.WithValue(ctx, optsKey, opts)
 }
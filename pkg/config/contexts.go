@@ -0,0 +1,126 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/pathUtils"
+)
+
+// Context is a named set of defaults for the global flags, stored in
+// ~/.gns3/config.toml.
+type Context struct {
+	Name     string `toml:"name" json:"name"`
+	Server   string `toml:"server,omitempty" json:"server"`
+	Insecure bool   `toml:"insecure,omitempty" json:"insecure"`
+	User     string `toml:"user,omitempty" json:"user"`
+	KeyFile  string `toml:"key_file,omitempty" json:"key_file"`
+	Cluster  string `toml:"cluster,omitempty" json:"cluster"`
+}
+
+type UserConfig struct {
+	CurrentContext string `toml:"current_context,omitempty" json:"current_context"`
+	// KeyStore selects where API tokens are kept, see
+	// authentication.KeyStores.
+	KeyStore string    `toml:"key_store,omitempty" json:"key_store"`
+	SMTP     *SMTP     `toml:"smtp,omitempty" json:"smtp,omitempty"`
+	Contexts []Context `toml:"context,omitempty" json:"contexts"`
+}
+
+// ErrUnknownContext is returned for context names not in the config file.
+var ErrUnknownContext = errors.New("unknown context")
+
+func userConfigPath() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", fmt.Errorf("could not detect home dir: %w", err)
+	}
+	return filepath.Join(home, ".gns3", "config.toml"), nil
+}
+
+// LoadUserConfig reads config.toml. A missing file is an empty config.
+func LoadUserConfig() (UserConfig, error) {
+	var c UserConfig
+	path, err := userConfigPath()
+	if err != nil {
+		return c, err
+	}
+	data, err := os.ReadFile(path) // #nosec G304
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("could not read %q: %w", path, err)
+	}
+	if err := toml.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("could not parse %q: %w", path, err)
+	}
+	return c, nil
+}
+
+func WriteUserConfig(c UserConfig) error {
+	dir, err := pathUtils.GetGNS3Dir()
+	if err != nil {
+		return err
+	}
+	res, err := toml.Marshal(&c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "config.toml"), res, 0o600)
+}
+
+func (c UserConfig) Context(name string) (Context, bool) {
+	i := slices.IndexFunc(c.Contexts, func(ctx Context) bool { return ctx.Name == name })
+	if i < 0 {
+		return Context{}, false
+	}
+	return c.Contexts[i], true
+}
+
+// Active returns the context named override, or the current context when
+// override is empty. ok is false if neither is set.
+func (c UserConfig) Active(override string) (ctx Context, ok bool, err error) {
+	name := override
+	if name == "" {
+		name = c.CurrentContext
+	}
+	if name == "" {
+		return Context{}, false, nil
+	}
+	ctx, ok = c.Context(name)
+	if !ok {
+		return Context{}, false, fmt.Errorf("%w %q, see context ls", ErrUnknownContext, name)
+	}
+	return ctx, true, nil
+}
+
+// SetContext adds ctx or replaces the context with the same name and
+// reports whether it already existed.
+func (c *UserConfig) SetContext(ctx Context) bool {
+	i := slices.IndexFunc(c.Contexts, func(existing Context) bool { return existing.Name == ctx.Name })
+	if i < 0 {
+		c.Contexts = append(c.Contexts, ctx)
+		return false
+	}
+	c.Contexts[i] = ctx
+	return true
+}
+
+// RemoveContext deletes the context and unsets it if it was the current one.
+func (c *UserConfig) RemoveContext(name string) error {
+	i := slices.IndexFunc(c.Contexts, func(ctx Context) bool { return ctx.Name == name })
+	if i < 0 {
+		return fmt.Errorf("%w %q", ErrUnknownContext, name)
+	}
+	c.Contexts = slices.Delete(c.Contexts, i, i+1)
+	if c.CurrentContext == name {
+		c.CurrentContext = ""
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package config
+
+// SMTP is the mail server class notify sends through, stored in the
+// [smtp] table of ~/.gns3/config.toml. The password is not stored, it
+// comes from GNS3_SMTP_PASSWORD or the terminal.
+type SMTP struct {
+	Host     string `toml:"host,omitempty" json:"host"`
+	Port     int    `toml:"port,omitempty" json:"port"`
+	Username string `toml:"username,omitempty" json:"username"`
+	From     string `toml:"from,omitempty" json:"from"`
+	// TLS is starttls, tls for implicit TLS or none.
+	TLS      string `toml:"tls,omitempty" json:"tls"`
+	Insecure bool   `toml:"insecure,omitempty" json:"insecure"`
+}
@@ -47,17 +47,11 @@ func FuzzyInfo(params *FuzzyInfoParams) error {
 
 	toPrint := buf.Bytes()
 
-	if params.Cfg.Raw {
-		utils.PrintJson(toPrint)
-		return nil
-	} else {
-		if params.ExtraInfo && params.ContextType != "" && params.ContextLabel != "" {
-			utils.PrintKVWithResourceContext(toPrint, params.ContextType, params.ContextLabel)
-		} else {
-			utils.PrintKV(toPrint)
-		}
+	if params.ExtraInfo && params.ContextType != "" && params.ContextLabel != "" && !params.Cfg.Raw && params.Cfg.Output == "" {
+		utils.PrintKVWithResourceContext(toPrint, params.ContextType, params.ContextLabel)
 		return nil
 	}
+	return utils.PrintBody(params.Cfg, toPrint, utils.ResourceForCommand(params.Method))
 }
 
 func FuzzyInfoIDs(params *FuzzyInfoParams) ([]string, error) {
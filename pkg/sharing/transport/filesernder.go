@@ -1,6 +1,7 @@
 package transport
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"fmt"
@@ -10,24 +11,31 @@ import (
 	"github.com/quic-go/quic-go"
 )
 
-func SendFiles(ctx context.Context, conn *quic.Conn, absPaths []string, metas []FileMeta) error {
+func SendFiles(ctx context.Context, conn *quic.Conn, artifacts []Artifact, metas []FileMeta) error {
 	for i, meta := range metas {
-		if err := sendOne(ctx, conn, absPaths[i], meta); err != nil {
+		if err := sendOne(ctx, conn, artifacts[i], meta); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func sendOne(ctx context.Context, conn *quic.Conn, absPath string, meta FileMeta) error {
+func sendOne(ctx context.Context, conn *quic.Conn, artifact Artifact, meta FileMeta) error {
 	s, err := conn.OpenUniStreamSync(ctx)
 	if err != nil {
 		return err
 	}
-	f, err := os.Open(absPath) // #nosec G304
-	if err != nil {
-		s.CancelWrite(0)
-		return err
+	var f io.Reader = bytes.NewReader(artifact.Data)
+	if artifact.Data == nil {
+		file, err := os.Open(artifact.Path) // #nosec G304
+		if err != nil {
+			s.CancelWrite(0)
+			return err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		f = file
 	}
 	defer func() {
 		if err != nil {
@@ -9,28 +9,62 @@ import (
 	"github.com/quic-go/quic-go"
 )
 
-func BuildOffer(absPaths []string) (OfferMsg, []FileMeta, error) {
-	metas := make([]FileMeta, 0, len(absPaths))
+// Artifact is a file to send. Its content is read from Path, or taken from
+// Data for content that must not be written to disk first, like the keys of
+// the OS keyring. Rel is the name the receiver stores it under and defaults
+// to the base name of Path.
+type Artifact struct {
+	Rel  string
+	Path string
+	Data []byte
+}
+
+// FileArtifacts returns an artifact for each file in absPaths.
+func FileArtifacts(absPaths []string) []Artifact {
+	out := make([]Artifact, len(absPaths))
+	for i, abs := range absPaths {
+		out[i] = Artifact{Path: abs}
+	}
+	return out
+}
+
+func (a Artifact) name() string {
+	if a.Rel != "" {
+		return a.Rel
+	}
+	return filepath.Base(a.Path)
+}
+
+// BuildOffer returns the offer for artifacts, skipping directories, and the
+// artifacts it contains in the order of its files.
+func BuildOffer(artifacts []Artifact) (OfferMsg, []Artifact, []FileMeta, error) {
+	metas := make([]FileMeta, 0, len(artifacts))
+	offered := make([]Artifact, 0, len(artifacts))
 	var total int64
-	for _, abs := range absPaths {
-		st, err := os.Stat(abs)
-		if err != nil {
-			return OfferMsg{}, nil, err
-		}
-		if st.IsDir() {
-			continue
+	for _, a := range artifacts {
+		size := int64(len(a.Data))
+		if a.Data == nil {
+			st, err := os.Stat(a.Path)
+			if err != nil {
+				return OfferMsg{}, nil, nil, err
+			}
+			if st.IsDir() {
+				continue
+			}
+			size = st.Size()
 		}
 		metas = append(metas, FileMeta{
-			Rel:  filepath.Base(abs),
-			Size: st.Size(),
+			Rel:  a.name(),
+			Size: size,
 		})
-		total += st.Size()
+		offered = append(offered, a)
+		total += size
 	}
-	return OfferMsg{Files: metas, Total: total}, metas, nil
+	return OfferMsg{Files: metas, Total: total}, offered, metas, nil
 }
 
-func SendOfferAndFiles(ctx context.Context, ctrl *quic.Stream, conn *quic.Conn, absPaths []string) error {
-	offer, metas, err := BuildOffer(absPaths)
+func SendOfferAndFiles(ctx context.Context, ctrl *quic.Stream, conn *quic.Conn, artifacts []Artifact) error {
+	offer, offered, metas, err := BuildOffer(artifacts)
 	if err != nil {
 		return err
 	}
@@ -48,7 +82,7 @@ func SendOfferAndFiles(ctx context.Context, ctrl *quic.Stream, conn *quic.Conn,
 	}
 
 	// Send the files
-	if err := SendFiles(ctx, conn, absPaths, metas); err != nil {
+	if err := SendFiles(ctx, conn, offered, metas); err != nil {
 		return err
 	}
 
@@ -0,0 +1,814 @@
+package class
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/colorUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+// ApplyPlan holds the changes that turn a class in the cluster database
+// into the one of a class file.
+type ApplyPlan struct {
+	ClusterID int
+	ClassID   int64
+	ClassName string
+	// Description is set when it changes.
+	Description *string
+
+	AddGroups      []PlannedGroup
+	RemoveGroups   []PlannedGroup
+	AddStudents    []PlannedStudent
+	MoveStudents   []PlannedStudent
+	RemoveStudents []PlannedStudent
+	// RepairStudents are in the right group in the cluster database but
+	// missing on their node or not in their groups there.
+	RepairStudents []PlannedStudent
+
+	// OverCapacity is set when the new groups only fit by going above the
+	// max_groups of the nodes.
+	OverCapacity bool
+}
+
+// PlannedGroup is a student group with the node it is assigned to.
+type PlannedGroup struct {
+	Name      string
+	GroupID   int64
+	NodeID    int64
+	NodeURL   string
+	Exercises []sqlc.Exercise
+}
+
+// PlannedStudent is a student to add, move, remove or repair. Moves and
+// repairs keep the stored password, From* is where the student is now.
+type PlannedStudent struct {
+	Username    string
+	FullName    string
+	Email       string
+	Password    string
+	UserID      int64
+	Group       string
+	NodeURL     string
+	FromGroup   string
+	FromNodeURL string
+	// Missing is set for repairs of users that do not exist on the node.
+	Missing bool
+}
+
+// Empty reports whether the class already matches.
+func (p *ApplyPlan) Empty() bool {
+	return p.Description == nil && len(p.AddGroups) == 0 && len(p.RemoveGroups) == 0 &&
+		len(p.AddStudents) == 0 && len(p.MoveStudents) == 0 && len(p.RemoveStudents) == 0 && len(p.RepairStudents) == 0
+}
+
+// Print lists the changes of the plan.
+func (p *ApplyPlan) Print() {
+	fmt.Printf("Plan for class %s:\n", messageUtils.Bold(p.ClassName))
+	if p.Description != nil {
+		fmt.Printf("  %s description %q\n", colorUtils.Warning("~"), *p.Description)
+	}
+	for _, g := range p.AddGroups {
+		fmt.Printf("  %s group %s on %s\n", colorUtils.Success("+"), messageUtils.Bold(g.Name), g.NodeURL)
+	}
+	for _, s := range p.AddStudents {
+		fmt.Printf("  %s student %s in %s\n", colorUtils.Success("+"), messageUtils.Bold(s.Username), s.Group)
+	}
+	for _, s := range p.MoveStudents {
+		where := ""
+		if s.FromNodeURL != s.NodeURL {
+			where = fmt.Sprintf(" (%s -> %s, recreated with the initial password)", s.FromNodeURL, s.NodeURL)
+		}
+		fmt.Printf("  %s student %s from %s to %s%s\n", colorUtils.Warning("~"), messageUtils.Bold(s.Username), s.FromGroup, s.Group, where)
+	}
+	for _, s := range p.RepairStudents {
+		what := "added back to its groups"
+		if s.Missing {
+			what = "recreated with the initial password"
+		}
+		fmt.Printf("  %s student %s on %s %s\n", colorUtils.Warning("!"), messageUtils.Bold(s.Username), s.NodeURL, what)
+	}
+	for _, s := range p.RemoveStudents {
+		fmt.Printf("  %s student %s from %s\n", colorUtils.Error("-"), messageUtils.Bold(s.Username), s.Group)
+	}
+	for _, g := range p.RemoveGroups {
+		projects := ""
+		if len(g.Exercises) > 0 {
+			projects = fmt.Sprintf(" and its %d exercise project(s)", len(g.Exercises))
+		}
+		fmt.Printf("  %s group %s on %s%s\n", colorUtils.Error("-"), messageUtils.Bold(g.Name), g.NodeURL, projects)
+	}
+	if p.OverCapacity {
+		fmt.Printf("%v the new groups exceed max_groups of the nodes and are assigned by weight\n", messageUtils.WarningMsg("Warning"))
+	}
+}
+
+type currentStudent struct {
+	userID   int64
+	fullName string
+	password string
+	group    string
+}
+
+// PlanClassApply compares classData with the class of the same name in
+// the cluster database and checks that the students who stay are still
+// on their nodes. New groups are placed on the nodes by scheduler. It
+// returns ErrClassNotFound when the class does not exist yet.
+func PlanClassApply(ctx context.Context, cfg config.GlobalOptions, clusterID int, classData schemas.Class, scheduler Scheduler) (*ApplyPlan, error) {
+	if err := validateClassFile(classData); err != nil {
+		return nil, err
+	}
+	store, err := db.Init()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init db: %w", err)
+	}
+	cls, err := store.GetClassByName(ctx, sqlc.GetClassByNameParams{ClusterID: int64(clusterID), Name: classData.Name})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrClassNotFound, classData.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get class %s: %w", messageUtils.Bold(classData.Name), err)
+	}
+	nodes, nodeURLs, err := clusterNodes(ctx, store, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := store.GetClassMembers(ctx, sqlc.GetClassMembersParams{ClusterID: int64(clusterID), Name: classData.Name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the members of class %s: %w", messageUtils.Bold(classData.Name), err)
+	}
+
+	plan := &ApplyPlan{ClusterID: clusterID, ClassID: cls.ClassID, ClassName: cls.Name}
+	if classData.Desc != cls.Description.String {
+		plan.Description = &classData.Desc
+	}
+
+	groups := map[string]PlannedGroup{}
+	students := map[string]currentStudent{}
+	for _, row := range rows {
+		if _, ok := groups[row.GroupName]; !ok {
+			groups[row.GroupName] = PlannedGroup{Name: row.GroupName, GroupID: row.GroupID, NodeID: row.NodeID.Int64, NodeURL: nodeURLs[row.NodeID.Int64]}
+		}
+		if row.Username.Valid {
+			students[row.Username.String] = currentStudent{userID: row.UserID.Int64, fullName: row.FullName.String, password: row.DefaultPassword.String, group: row.GroupName}
+		}
+	}
+
+	desiredGroups := map[string]bool{}
+	var newGroups []schemas.Group
+	for _, g := range classData.Groups {
+		desiredGroups[g.Name] = true
+		if _, ok := groups[g.Name]; !ok {
+			newGroups = append(newGroups, g)
+		}
+	}
+	freed := map[int64]int{}
+	for _, name := range slices.Sorted(maps.Keys(groups)) {
+		if desiredGroups[name] {
+			continue
+		}
+		g := groups[name]
+		g.Exercises, err = store.GetExercisesForGroup(ctx, g.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the exercises of group %s: %w", g.Name, err)
+		}
+		plan.RemoveGroups = append(plan.RemoveGroups, g)
+		freed[g.NodeID]++
+	}
+
+	if len(newGroups) > 0 {
+		assigned, err := store.GetNodeGroupAssignments(ctx, int64(clusterID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node group assignments: %w", err)
+		}
+		used := map[int]int{}
+		for _, a := range assigned {
+			used[int(a.NodeID)] = int(a.Count) - freed[a.NodeID]
+		}
+		available := make([]db.NodeDataAll, 0, len(nodes))
+		for _, n := range nodes {
+			n.MaxGroups = max(0, n.MaxGroups-used[n.ID])
+			available = append(available, n)
+		}
+		dist, err := scheduler.Place(ctx, available, len(newGroups), true)
+		if err != nil {
+			plan.OverCapacity = true
+			dist, err = scheduler.Place(ctx, nodes, len(newGroups), false)
+			if err != nil {
+				return nil, fmt.Errorf("distribution failed: %w", err)
+			}
+		}
+		next := 0
+		for _, d := range dist {
+			for range d.NumGroups {
+				if next == len(newGroups) {
+					break
+				}
+				g := PlannedGroup{Name: newGroups[next].Name, NodeID: int64(d.NodeID), NodeURL: nodeURLs[int64(d.NodeID)]}
+				plan.AddGroups = append(plan.AddGroups, g)
+				groups[g.Name] = g
+				next++
+			}
+		}
+		if next < len(newGroups) {
+			return nil, fmt.Errorf("the cluster has no node for the new groups")
+		}
+	}
+
+	var unchanged []PlannedStudent
+	for _, g := range classData.Groups {
+		for _, s := range g.Students {
+			planned := PlannedStudent{Username: s.UserName, Password: s.Password, Group: g.Name, NodeURL: groups[g.Name].NodeURL}
+			if s.FullName != nil {
+				planned.FullName = *s.FullName
+			}
+			if s.Email != nil {
+				planned.Email = *s.Email
+			}
+			current, ok := students[s.UserName]
+			switch {
+			case !ok:
+				plan.AddStudents = append(plan.AddStudents, planned)
+			case current.group != g.Name:
+				planned.UserID = current.userID
+				planned.Password = current.password
+				planned.FromGroup = current.group
+				planned.FromNodeURL = groups[current.group].NodeURL
+				if planned.FullName == "" {
+					planned.FullName = current.fullName
+				}
+				plan.MoveStudents = append(plan.MoveStudents, planned)
+			default:
+				planned.UserID = current.userID
+				planned.Password = current.password
+				unchanged = append(unchanged, planned)
+			}
+			delete(students, s.UserName)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(students)) {
+		s := students[name]
+		plan.RemoveStudents = append(plan.RemoveStudents, PlannedStudent{Username: name, UserID: s.userID, Group: s.group, NodeURL: groups[s.group].NodeURL})
+	}
+	if err := planRepairs(cfg, plan, unchanged); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// planRepairs adds the students that do not exist on their node or are
+// not members of the class and student group there.
+func planRepairs(cfg config.GlobalOptions, plan *ApplyPlan, students []PlannedStudent) error {
+	byNode := map[string][]PlannedStudent{}
+	for _, s := range students {
+		byNode[s.NodeURL] = append(byNode[s.NodeURL], s)
+	}
+	for _, url := range slices.Sorted(maps.Keys(byNode)) {
+		c := cfg
+		c.Server = url
+		users, err := apiUsers(c)
+		if err != nil {
+			return err
+		}
+		for _, s := range byNode[url] {
+			userID, ok := users[s.Username]
+			if !ok {
+				s.Missing = true
+				plan.RepairStudents = append(plan.RepairStudents, s)
+				continue
+			}
+			member, err := apiMemberships(c, userID)
+			if err != nil {
+				return err
+			}
+			if !member[plan.ClassName] || !member[s.Group] {
+				plan.RepairStudents = append(plan.RepairStudents, s)
+			}
+		}
+	}
+	return nil
+}
+
+func validateClassFile(classData schemas.Class) error {
+	if classData.Name == "" {
+		return fmt.Errorf("class name is required")
+	}
+	groups := map[string]bool{}
+	users := map[string]string{}
+	for _, g := range classData.Groups {
+		if g.Name == "" || g.Name == classData.Name {
+			return fmt.Errorf("invalid group name %q", g.Name)
+		}
+		if groups[g.Name] {
+			return fmt.Errorf("the group %s is listed twice", g.Name)
+		}
+		groups[g.Name] = true
+		for _, s := range g.Students {
+			if other, ok := users[s.UserName]; ok {
+				return fmt.Errorf("the student %s is in both %s and %s", s.UserName, other, g.Name)
+			}
+			users[s.UserName] = g.Name
+		}
+	}
+	return nil
+}
+
+// clusterNodes returns the nodes of a cluster that take new groups,
+// without max_groups meaning no limit like in class create, and the URLs
+// of all nodes by node id.
+func clusterNodes(ctx context.Context, store *db.Store, clusterID int) ([]db.NodeDataAll, map[int64]string, error) {
+	all, err := store.GetNodes(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get nodes for cluster: %w", err)
+	}
+	var nodes []db.NodeDataAll
+	urls := map[int64]string{}
+	for _, n := range all {
+		if int(n.ClusterID) != clusterID {
+			continue
+		}
+		urls[n.NodeID] = fmt.Sprintf("%s://%s:%d", n.Protocol, n.Host, n.Port)
+		if n.Cordoned {
+			continue
+		}
+		maxGroups := math.MaxInt32
+		if n.MaxGroups.Valid {
+			maxGroups = int(n.MaxGroups.Int64)
+		}
+		nodes = append(nodes, db.NodeDataAll{ID: int(n.NodeID), ClusterID: int(n.ClusterID), User: n.AuthUser, Protocol: n.Protocol, Host: n.Host, Port: int(n.Port), Weight: int(n.Weight), MaxGroups: maxGroups})
+	}
+	return nodes, urls, nil
+}
+
+// ApplyClassPlan carries out a plan on the nodes and in the cluster
+// database. Projects of removed groups are exported to archiveDir first
+// when it is set.
+//
+// The database changes run in one transaction that is only committed once
+// every node has been changed, so a failed apply leaves the rows as they
+// were. The node side steps skip what is already done, which makes running
+// apply again with the same file the way to finish a failed run.
+func ApplyClassPlan(ctx context.Context, cfg config.GlobalOptions, plan *ApplyPlan, archiveDir string) error {
+	store, err := db.Init()
+	if err != nil {
+		return fmt.Errorf("failed to init db: %w", err)
+	}
+	tx, err := store.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	q := store.WithTx(tx)
+
+	nodeCfg := func(url string) config.GlobalOptions {
+		c := cfg
+		c.Server = url
+		return c
+	}
+
+	if plan.Description != nil {
+		if err := q.UpdateClassDescription(ctx, sqlc.UpdateClassDescriptionParams{
+			Description: sql.NullString{String: *plan.Description, Valid: *plan.Description != ""},
+			ClassID:     plan.ClassID,
+		}); err != nil {
+			return fmt.Errorf("failed to update the description: %w", err)
+		}
+		fmt.Printf("%v description of class %s\n", messageUtils.SuccessMsg("Updated"), messageUtils.Bold(plan.ClassName))
+	}
+
+	groupIDs := map[string]int64{}
+	rows, err := q.GetClassMembers(ctx, sqlc.GetClassMembersParams{ClusterID: int64(plan.ClusterID), Name: plan.ClassName})
+	if err != nil {
+		return fmt.Errorf("failed to get the members of class %s: %w", messageUtils.Bold(plan.ClassName), err)
+	}
+	for _, row := range rows {
+		groupIDs[row.GroupName] = row.GroupID
+	}
+
+	for _, g := range plan.AddGroups {
+		groupID, err := q.CreateGroupReturning(ctx, sqlc.CreateGroupReturningParams{ClassID: plan.ClassID, Name: g.Name})
+		if err != nil {
+			return fmt.Errorf("failed to create group %s: %w", g.Name, err)
+		}
+		if err := q.AssignGroupToNode(ctx, sqlc.AssignGroupToNodeParams{NodeID: g.NodeID, GroupID: groupID}); err != nil {
+			return fmt.Errorf("failed to assign group %s to node: %w", g.Name, err)
+		}
+		groupIDs[g.Name] = groupID
+		if _, err := ensureAPIGroup(nodeCfg(g.NodeURL), plan.ClassName); err != nil {
+			return err
+		}
+		if _, err := ensureAPIGroup(nodeCfg(g.NodeURL), g.Name); err != nil {
+			return err
+		}
+		fmt.Printf("%v group %s on %s\n", messageUtils.SuccessMsg("Created"), messageUtils.Bold(g.Name), messageUtils.Highlight(g.NodeURL))
+	}
+
+	for _, s := range plan.AddStudents {
+		if _, err := q.CreateUserReturning(ctx, sqlc.CreateUserReturningParams{
+			GroupID:         groupIDs[s.Group],
+			Username:        s.Username,
+			FullName:        sql.NullString{String: s.FullName, Valid: s.FullName != ""},
+			DefaultPassword: s.Password,
+		}); err != nil {
+			return fmt.Errorf("failed to create user %s: %w", s.Username, err)
+		}
+		if err := createAPIStudent(nodeCfg(s.NodeURL), plan.ClassName, s); err != nil {
+			return err
+		}
+		fmt.Printf("%v student %s in %s\n", messageUtils.SuccessMsg("Added"), messageUtils.Bold(s.Username), s.Group)
+	}
+
+	for _, s := range plan.RepairStudents {
+		c := nodeCfg(s.NodeURL)
+		if s.Missing {
+			if err := createAPIStudent(c, plan.ClassName, s); err != nil {
+				return err
+			}
+			fmt.Printf("%v student %s on %s\n", messageUtils.SuccessMsg("Recreated"), messageUtils.Bold(s.Username), messageUtils.Highlight(s.NodeURL))
+			continue
+		}
+		userID, err := findAPIUser(c, s.Username)
+		if err != nil {
+			return err
+		}
+		member, err := apiMemberships(c, userID)
+		if err != nil {
+			return err
+		}
+		if err := joinAPIGroups(c, userID, s.Username, member, plan.ClassName, s.Group); err != nil {
+			return err
+		}
+		fmt.Printf("%v student %s to its groups on %s\n", messageUtils.SuccessMsg("Added"), messageUtils.Bold(s.Username), messageUtils.Highlight(s.NodeURL))
+	}
+
+	for _, s := range plan.MoveStudents {
+		if s.FromNodeURL == s.NodeURL {
+			c := nodeCfg(s.NodeURL)
+			userID, err := findAPIUser(c, s.Username)
+			if err != nil {
+				return err
+			}
+			member, err := apiMemberships(c, userID)
+			if err != nil {
+				return err
+			}
+			if member[s.FromGroup] {
+				oldGroupID, err := ensureAPIGroup(c, s.FromGroup)
+				if err != nil {
+					return err
+				}
+				if _, status, err := utils.CallClient(c, "deleteUserFromGroup", []string{oldGroupID, userID}, nil); err != nil || (status != 200 && status != 204) {
+					if err == nil {
+						err = fmt.Errorf("status %d", status)
+					}
+					return fmt.Errorf("failed to remove %s from group %s: %w", s.Username, s.FromGroup, err)
+				}
+			}
+			if err := joinAPIGroups(c, userID, s.Username, member, s.Group); err != nil {
+				return err
+			}
+		} else {
+			if err := deleteAPIStudent(nodeCfg(s.FromNodeURL), s.Username); err != nil {
+				return err
+			}
+			if err := createAPIStudent(nodeCfg(s.NodeURL), plan.ClassName, s); err != nil {
+				return err
+			}
+		}
+		if err := q.UpdateUserGroup(ctx, sqlc.UpdateUserGroupParams{GroupID: groupIDs[s.Group], UserID: s.UserID}); err != nil {
+			return fmt.Errorf("failed to move user %s: %w", s.Username, err)
+		}
+		fmt.Printf("%v student %s from %s to %s\n", messageUtils.SuccessMsg("Moved"), messageUtils.Bold(s.Username), s.FromGroup, s.Group)
+	}
+
+	for _, s := range plan.RemoveStudents {
+		if err := deleteAPIStudent(nodeCfg(s.NodeURL), s.Username); err != nil {
+			return err
+		}
+		if err := q.DeleteUser(ctx, s.UserID); err != nil {
+			return fmt.Errorf("failed to delete user %s: %w", s.Username, err)
+		}
+		fmt.Printf("%v student %s\n", messageUtils.SuccessMsg("Removed"), messageUtils.Bold(s.Username))
+	}
+
+	emptied := map[string]bool{}
+	for _, g := range plan.RemoveGroups {
+		c := nodeCfg(g.NodeURL)
+		if err := removeGroupProjects(ctx, c, plan.ClassName, g, archiveDir); err != nil {
+			return err
+		}
+		groupID, err := findAPIGroup(c, g.Name)
+		if err != nil {
+			return err
+		}
+		if groupID != "" {
+			if err := deleteGroup(c, groupID); err != nil {
+				return fmt.Errorf("failed to delete group %s on %s: %w", g.Name, g.NodeURL, err)
+			}
+		}
+		if err := q.DeleteGroup(ctx, g.GroupID); err != nil {
+			return fmt.Errorf("failed to delete group %s: %w", g.Name, err)
+		}
+		emptied[g.NodeURL] = true
+		fmt.Printf("%v group %s\n", messageUtils.SuccessMsg("Removed"), messageUtils.Bold(g.Name))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit the class changes: %w", err)
+	}
+	return removeEmptyClassGroups(ctx, store, cfg, plan, emptied)
+}
+
+// removeEmptyClassGroups deletes the class group on nodes that no longer
+// host a group of the class.
+func removeEmptyClassGroups(ctx context.Context, store *db.Store, cfg config.GlobalOptions, plan *ApplyPlan, candidates map[string]bool) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	_, nodeURLs, err := clusterNodes(ctx, store, plan.ClusterID)
+	if err != nil {
+		return err
+	}
+	rows, err := store.GetClassMembers(ctx, sqlc.GetClassMembersParams{ClusterID: int64(plan.ClusterID), Name: plan.ClassName})
+	if err != nil {
+		return fmt.Errorf("failed to get the members of class %s: %w", messageUtils.Bold(plan.ClassName), err)
+	}
+	for _, row := range rows {
+		delete(candidates, nodeURLs[row.NodeID.Int64])
+	}
+	for url := range candidates {
+		c := cfg
+		c.Server = url
+		groupID, err := findAPIGroup(c, plan.ClassName)
+		if err != nil {
+			return err
+		}
+		if groupID == "" {
+			continue
+		}
+		if err := deleteGroup(c, groupID); err != nil {
+			return fmt.Errorf("failed to delete class group on %s: %w", url, err)
+		}
+		fmt.Printf("%v class group %s on %s\n", messageUtils.SuccessMsg("Removed"), messageUtils.Bold(plan.ClassName), messageUtils.Highlight(url))
+	}
+	return nil
+}
+
+// apiGroups returns the group ids of a node by name.
+func apiGroups(cfg config.GlobalOptions) (map[string]string, error) {
+	body, status, err := utils.CallClient(cfg, "getGroups", []string{}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	if status != 200 {
+		return nil, fmt.Errorf("failed to get groups: status %d", status)
+	}
+	var groups []schemas.UserGroupResponse
+	if err := json.Unmarshal(body, &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse groups response: %w", err)
+	}
+	ids := make(map[string]string, len(groups))
+	for _, g := range groups {
+		ids[g.Name] = g.UserGroupID.String()
+	}
+	return ids, nil
+}
+
+// findAPIGroup returns the id of a group or "" when it does not exist.
+func findAPIGroup(cfg config.GlobalOptions, name string) (string, error) {
+	groups, err := apiGroups(cfg)
+	if err != nil {
+		return "", err
+	}
+	return groups[name], nil
+}
+
+func ensureAPIGroup(cfg config.GlobalOptions, name string) (string, error) {
+	id, err := findAPIGroup(cfg, name)
+	if err != nil || id != "" {
+		return id, err
+	}
+	body, status, err := utils.CallClient(cfg, "createGroup", []string{}, schemas.UserGroupCreate{Name: &name})
+	if err != nil {
+		return "", fmt.Errorf("failed to create group %s: %w", name, err)
+	}
+	if status != 201 {
+		return "", fmt.Errorf("failed to create group %s: status %d", name, status)
+	}
+	var group schemas.UserGroupResponse
+	if err := json.Unmarshal(body, &group); err != nil {
+		return "", fmt.Errorf("failed to parse group response: %w", err)
+	}
+	return group.UserGroupID.String(), nil
+}
+
+func apiUserList(cfg config.GlobalOptions, command string, args ...string) ([]schemas.UserResponse, error) {
+	body, status, err := utils.CallClient(cfg, command, args, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	if status != 200 {
+		return nil, fmt.Errorf("failed to get users: status %d", status)
+	}
+	var users []schemas.UserResponse
+	if err := json.Unmarshal(body, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse users response: %w", err)
+	}
+	return users, nil
+}
+
+// apiUsers returns the user ids of a node by username.
+func apiUsers(cfg config.GlobalOptions) (map[string]string, error) {
+	users, err := apiUserList(cfg, "getUsers")
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]string, len(users))
+	for _, u := range users {
+		ids[u.Username] = u.UserID.String()
+	}
+	return ids, nil
+}
+
+func findAPIUser(cfg config.GlobalOptions, username string) (string, error) {
+	users, err := apiUsers(cfg)
+	if err != nil {
+		return "", err
+	}
+	if id, ok := users[username]; ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("user %s not found on %s", username, cfg.Server)
+}
+
+// apiMemberships returns the names of the groups a user is in.
+func apiMemberships(cfg config.GlobalOptions, userID string) (map[string]bool, error) {
+	body, status, err := utils.CallClient(cfg, "getGroupMemberships", []string{userID}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group memberships: %w", err)
+	}
+	if status != 200 {
+		return nil, fmt.Errorf("failed to get group memberships: status %d", status)
+	}
+	var groups []schemas.UserGroupResponse
+	if err := json.Unmarshal(body, &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse groups response: %w", err)
+	}
+	member := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		member[g.Name] = true
+	}
+	return member, nil
+}
+
+// joinAPIGroups adds a user to the groups it is not a member of yet,
+// creating them when needed.
+func joinAPIGroups(cfg config.GlobalOptions, userID, username string, member map[string]bool, groups ...string) error {
+	for _, group := range groups {
+		if member[group] {
+			continue
+		}
+		groupID, err := ensureAPIGroup(cfg, group)
+		if err != nil {
+			return err
+		}
+		if err := addUserToGroup(cfg, userID, groupID); err != nil {
+			return fmt.Errorf("failed to add user %s to group %s: %w", username, group, err)
+		}
+	}
+	return nil
+}
+
+func createAPIStudent(cfg config.GlobalOptions, className string, s PlannedStudent) error {
+	users, err := apiUsers(cfg)
+	if err != nil {
+		return err
+	}
+	// left over from an apply whose database changes were rolled back
+	if userID, ok := users[s.Username]; ok {
+		member, err := apiMemberships(cfg, userID)
+		if err != nil {
+			return err
+		}
+		return joinAPIGroups(cfg, userID, s.Username, member, className, s.Group)
+	}
+	userData := schemas.UserCreate{Username: &s.Username, Password: &s.Password, IsActive: true}
+	// the controller rejects empty strings for optional fields
+	if s.Email != "" {
+		userData.Email = &s.Email
+	}
+	if s.FullName != "" {
+		userData.FullName = &s.FullName
+	}
+	body, status, err := utils.CallClient(cfg, "createUser", []string{}, userData)
+	if err != nil {
+		return fmt.Errorf("failed to create user %s: %w", s.Username, err)
+	}
+	if status != 201 {
+		return fmt.Errorf("failed to create user %s: status %d", s.Username, status)
+	}
+	var user schemas.UserResponse
+	if err := json.Unmarshal(body, &user); err != nil {
+		return fmt.Errorf("failed to parse user response: %w", err)
+	}
+	return joinAPIGroups(cfg, user.UserID.String(), s.Username, nil, className, s.Group)
+}
+
+func deleteAPIStudent(cfg config.GlobalOptions, username string) error {
+	userID, err := findAPIUser(cfg, username)
+	if err != nil {
+		fmt.Printf("%v %v\n", messageUtils.WarningMsg("Warning"), err)
+		return nil
+	}
+	if err := deleteUser(cfg, userID); err != nil {
+		return fmt.Errorf("failed to delete user %s on %s: %w", username, cfg.Server, err)
+	}
+	return nil
+}
+
+// removeGroupProjects deletes the exercise projects of a group with their
+// pools and ACLs, exporting them to archiveDir first when it is set.
+func removeGroupProjects(ctx context.Context, cfg config.GlobalOptions, className string, g PlannedGroup, archiveDir string) error {
+	if len(g.Exercises) == 0 {
+		return nil
+	}
+	body, status, err := utils.CallClient(cfg, "getProjects", []string{}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get projects from API: %w", err)
+	}
+	if status != 200 {
+		return fmt.Errorf("unexpected status code %d when getting projects", status)
+	}
+	var projects []schemas.ProjectResponse
+	if err := json.Unmarshal(body, &projects); err != nil {
+		return fmt.Errorf("failed to parse projects response: %w", err)
+	}
+
+	for _, ex := range g.Exercises {
+		idx := findExerciseProject(projects, ex.NameFormat, className, g.Name, ex.ProjectUuid)
+		if idx < 0 {
+			fmt.Printf("%v project of exercise %s for %s not found on %s\n",
+				messageUtils.WarningMsg("Warning"), ex.Name, g.Name, cfg.Server)
+			continue
+		}
+		project := projects[idx]
+
+		if archiveDir != "" {
+			if err := os.MkdirAll(archiveDir, 0o750); err != nil {
+				return fmt.Errorf("failed to create archive directory: %w", err)
+			}
+			client, err := utils.NewClient(cfg)
+			if err != nil {
+				return err
+			}
+			// the project name is up to the students, the file is named after the exercise
+			path := filepath.Join(archiveDir, archiveName(className, g.Name, ex.ProjectUuid))
+			if _, _, err := exportArchive(ctx, client, project.ProjectID, sdk.ExportOptions{}, path); err != nil {
+				return fmt.Errorf("failed to export project %s, keeping the group: %w", project.Name, err)
+			}
+			fmt.Printf("%v project %s to %s\n", messageUtils.SuccessMsg("Archived"), messageUtils.Bold(project.Name), path)
+		}
+
+		if err := closeProject(cfg, project.ProjectID); err != nil {
+			fmt.Printf("%v Failed to close project %s: %v\n", messageUtils.WarningMsg("Warning"), project.Name, err)
+		}
+		pools, err := getPoolsForProject(cfg, project.ProjectID, project.Name, "", "")
+		if err != nil {
+			fmt.Printf("%v Failed to get pools for project %s: %v\n", messageUtils.WarningMsg("Warning"), project.Name, err)
+		}
+		for _, pool := range pools {
+			acls, err := listACLsForPool(cfg, pool.ResourcePoolID, pool.Name)
+			if err != nil {
+				fmt.Printf("%v Failed to enumerate ACLs for pool %s: %v\n", messageUtils.WarningMsg("Warning"), pool.Name, err)
+				continue
+			}
+			for _, aclID := range acls {
+				if err := deleteACL(cfg, aclID); err != nil {
+					fmt.Printf("%v Failed to delete ACL %s for pool %s: %v\n", messageUtils.WarningMsg("Warning"), aclID, pool.Name, err)
+				}
+			}
+			if err := deletePool(cfg, pool.ResourcePoolID); err != nil {
+				fmt.Printf("%v Failed to delete pool %s: %v\n", messageUtils.WarningMsg("Warning"), pool.Name, err)
+			}
+		}
+		if err := deleteProject(cfg, project.ProjectID); err != nil {
+			return fmt.Errorf("failed to delete project %s: %w", project.Name, err)
+		}
+		fmt.Printf("%v project %s\n", messageUtils.SuccessMsg("Deleted"), messageUtils.Bold(project.Name))
+	}
+	return nil
+}
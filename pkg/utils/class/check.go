@@ -0,0 +1,556 @@
+package class
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of WriteCheckReport.
+const (
+	CheckTable = "table"
+	CheckCSV   = "csv"
+	CheckJSON  = "json"
+)
+
+var CheckFormats = []string{CheckTable, CheckCSV, CheckJSON}
+
+// CheckRules is a rules file of exercise check.
+type CheckRules struct {
+	Checks []Check `yaml:"checks" json:"checks"`
+}
+
+// Check is a single assertion on the project of a group. Exactly one of
+// Node, Template, Link, Running, Filter and Drawing is set. Link endpoints
+// are "node" or "node:interface", the interface by its name or short name.
+type Check struct {
+	Name   string   `yaml:"name" json:"name"`
+	Points *float64 `yaml:"points,omitempty" json:"points,omitempty"`
+
+	// Node is the name of a node that must exist.
+	Node string `yaml:"node,omitempty" json:"node,omitempty"`
+	// Template requires at least Count nodes of a template, by name or id.
+	Template string `yaml:"template,omitempty" json:"template,omitempty"`
+	Count    int    `yaml:"count,omitempty" json:"count,omitempty"`
+	// Link requires a link between two endpoints.
+	Link []string `yaml:"link,omitempty" json:"link,omitempty"`
+	// Running names the nodes that must be started, "*" for all.
+	Running []string `yaml:"running,omitempty" json:"running,omitempty"`
+	// Filter requires a filter on a link, of Type if set.
+	Filter *LinkFilterCheck `yaml:"filter,omitempty" json:"filter,omitempty"`
+	// Drawing is text that one of the drawings must contain.
+	Drawing string `yaml:"drawing,omitempty" json:"drawing,omitempty"`
+}
+
+type LinkFilterCheck struct {
+	Link []string `yaml:"link" json:"link"`
+	Type string   `yaml:"type,omitempty" json:"type,omitempty"`
+}
+
+// CheckResult is the outcome of one check for one group.
+type CheckResult struct {
+	Check   string  `json:"check"`
+	Passed  bool    `json:"passed"`
+	Points  float64 `json:"points"`
+	Message string  `json:"message,omitempty"`
+}
+
+// GroupReport holds the results of all checks for the project of a group.
+// Error is set when the project could not be checked at all.
+type GroupReport struct {
+	Class    string        `json:"class"`
+	Group    string        `json:"group"`
+	Project  string        `json:"project"`
+	Server   string        `json:"server"`
+	Score    float64       `json:"score"`
+	MaxScore float64       `json:"max_score"`
+	Error    string        `json:"error,omitempty"`
+	Results  []CheckResult `json:"results"`
+}
+
+// CheckOptions configures CheckExercise.
+type CheckOptions struct {
+	ClusterID int
+	Exercise  string
+	ClassName string
+	GroupName string
+	Rules     CheckRules
+}
+
+// LoadCheckRules reads a YAML or JSON rules file and validates it.
+func LoadCheckRules(filePath string) (CheckRules, error) {
+	var rules CheckRules
+	data, err := os.ReadFile(filePath) // #nosec G304
+	if err != nil {
+		return rules, fmt.Errorf("failed to read rules file %s: %w", filePath, err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&rules); err != nil && !errors.Is(err, io.EOF) {
+		return rules, fmt.Errorf("failed to parse rules file %s: %w", filePath, err)
+	}
+	if len(rules.Checks) == 0 {
+		return rules, fmt.Errorf("rules file %s has no checks", filePath)
+	}
+	for i, c := range rules.Checks {
+		if err := c.validate(); err != nil {
+			if c.Name != "" {
+				return rules, fmt.Errorf("check %d (%s): %w", i+1, c.Name, err)
+			}
+			return rules, fmt.Errorf("check %d: %w", i+1, err)
+		}
+		if c.Name == "" {
+			rules.Checks[i].Name = c.describe()
+		}
+	}
+	return rules, nil
+}
+
+func (c Check) validate() error {
+	kinds := 0
+	for _, set := range []bool{c.Node != "", c.Template != "", len(c.Link) > 0, len(c.Running) > 0, c.Filter != nil, c.Drawing != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("set exactly one of node, template, link, running, filter or drawing")
+	}
+	if c.Points != nil && *c.Points < 0 {
+		return fmt.Errorf("points must not be negative")
+	}
+	if c.Count < 0 {
+		return fmt.Errorf("count must not be negative")
+	}
+	if len(c.Link) > 0 && len(c.Link) != 2 {
+		return fmt.Errorf("a link has two endpoints")
+	}
+	if c.Filter != nil && len(c.Filter.Link) != 2 {
+		return fmt.Errorf("the link of a filter has two endpoints")
+	}
+	return nil
+}
+
+// describe names a check without a name after what it asserts.
+func (c Check) describe() string {
+	switch {
+	case c.Node != "":
+		return "node " + c.Node
+	case c.Template != "":
+		if c.Count > 1 {
+			return fmt.Sprintf("%d x %s", c.Count, c.Template)
+		}
+		return "template " + c.Template
+	case len(c.Link) > 0:
+		return "link " + strings.Join(c.Link, " - ")
+	case len(c.Running) > 0:
+		return "running " + strings.Join(c.Running, ", ")
+	case c.Filter != nil:
+		name := "filter"
+		if c.Filter.Type != "" {
+			name += " " + c.Filter.Type
+		}
+		return name + " on " + strings.Join(c.Filter.Link, " - ")
+	default:
+		return "drawing " + c.Drawing
+	}
+}
+
+func (c Check) points() float64 {
+	if c.Points == nil {
+		return 1
+	}
+	return *c.Points
+}
+
+// CheckExercise evaluates the rules against the project of every group of
+// an exercise on all nodes of a cluster. Closed projects are opened for the
+// check and closed again. Reports are ordered by class and group.
+func CheckExercise(ctx context.Context, cfg config.GlobalOptions, opts CheckOptions) ([]GroupReport, error) {
+	store, err := db.Init()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init db: %w", err)
+	}
+	nodeURLs, byNode, err := exerciseProjectsByNode(ctx, store, opts.ClusterID, opts.Exercise, opts.ClassName, opts.GroupName)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		reports []GroupReport
+	)
+	for _, url := range nodeURLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodeCfg := cfg
+			nodeCfg.Server = url
+			nodeReports := checkProjectsOnNode(nodeCfg, opts.Rules, byNode[url])
+			mu.Lock()
+			reports = append(reports, nodeReports...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	slices.SortFunc(reports, func(a, b GroupReport) int {
+		if c := strings.Compare(a.Class, b.Class); c != 0 {
+			return c
+		}
+		return compareGroupNames(a.Group, b.Group)
+	})
+	return reports, nil
+}
+
+// compareGroupNames sorts group-2 before group-10.
+func compareGroupNames(a, b string) int {
+	ai, aerr := strconv.Atoi(a[strings.LastIndexAny(a, "-_ ")+1:])
+	bi, berr := strconv.Atoi(b[strings.LastIndexAny(b, "-_ ")+1:])
+	if aerr == nil && berr == nil && ai != bi {
+		return ai - bi
+	}
+	return strings.Compare(a, b)
+}
+
+// projectTopology is what the checks of one project are evaluated on.
+type projectTopology struct {
+	nodes     []schemas.NodeResponse
+	links     []schemas.LinkResponse
+	drawings  []schemas.DrawingResponse
+	templates map[string]string
+}
+
+func checkProjectsOnNode(cfg config.GlobalOptions, rules CheckRules, rows []sqlc.GetExerciseProjectsRow) []GroupReport {
+	reports := make([]GroupReport, 0, len(rows))
+	fail := func(report GroupReport, err error) GroupReport {
+		report.Error = err.Error()
+		for _, c := range rules.Checks {
+			report.MaxScore += c.points()
+			report.Results = append(report.Results, CheckResult{Check: c.Name, Message: "not checked"})
+		}
+		return report
+	}
+
+	projects, err := getProjects(cfg)
+	var templates map[string]string
+	if err == nil {
+		templates, err = getTemplateNames(cfg)
+	}
+	for _, row := range rows {
+		report := GroupReport{Class: row.ClassName, Group: row.GroupName, Project: row.ProjectUuid, Server: cfg.Server}
+		if err != nil {
+			reports = append(reports, fail(report, err))
+			continue
+		}
+		idx := findExerciseProject(projects, row.NameFormat, row.ClassName, row.GroupName, row.ProjectUuid)
+		if idx < 0 {
+			reports = append(reports, fail(report, fmt.Errorf("project not found")))
+			continue
+		}
+		project := projects[idx]
+		report.Project = project.Name
+
+		topo, err := loadTopology(cfg, project)
+		if err != nil {
+			reports = append(reports, fail(report, err))
+			continue
+		}
+		topo.templates = templates
+		for _, c := range rules.Checks {
+			passed, msg := topo.evaluate(c)
+			result := CheckResult{Check: c.Name, Passed: passed, Message: msg}
+			if passed {
+				result.Points = c.points()
+				report.Score += result.Points
+			}
+			report.MaxScore += c.points()
+			report.Results = append(report.Results, result)
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// loadTopology reads nodes, links and drawings of a project. A closed
+// project has none, so it is opened and closed again afterwards.
+func loadTopology(cfg config.GlobalOptions, project schemas.ProjectResponse) (*projectTopology, error) {
+	if project.Status == nil || *project.Status != "opened" {
+		if err := projectCall(cfg, "openProject", project.ProjectID); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := projectCall(cfg, "closeProject", project.ProjectID); err != nil {
+				fmt.Printf("%v failed to close project %s again: %v\n", messageUtils.WarningMsg("Warning"), project.Name, err)
+			}
+		}()
+	}
+	topo := &projectTopology{}
+	if err := getProjectItems(cfg, "getNodes", project.ProjectID, &topo.nodes); err != nil {
+		return nil, err
+	}
+	if err := getProjectItems(cfg, "getLinks", project.ProjectID, &topo.links); err != nil {
+		return nil, err
+	}
+	if err := getProjectItems(cfg, "getDrawings", project.ProjectID, &topo.drawings); err != nil {
+		return nil, err
+	}
+	return topo, nil
+}
+
+func getProjectItems(cfg config.GlobalOptions, command, projectID string, v any) error {
+	body, status, err := utils.CallClient(cfg, command, []string{projectID}, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	if status != 200 {
+		return fmt.Errorf("%s: status %d", command, status)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("%s: failed to parse response: %w", command, err)
+	}
+	return nil
+}
+
+// getTemplateNames maps template ids to names.
+func getTemplateNames(cfg config.GlobalOptions) (map[string]string, error) {
+	body, status, err := utils.CallClient(cfg, "getTemplates", []string{}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+	if status != 200 {
+		return nil, fmt.Errorf("unexpected status code %d when getting templates", status)
+	}
+	var templates []schemas.TemplateResponse
+	if err := json.Unmarshal(body, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse templates response: %w", err)
+	}
+	names := make(map[string]string, len(templates))
+	for _, t := range templates {
+		names[t.TemplateID] = t.Name
+	}
+	return names, nil
+}
+
+func (t *projectTopology) evaluate(c Check) (bool, string) {
+	switch {
+	case c.Node != "":
+		if t.node(c.Node) == nil {
+			return false, "node " + c.Node + " missing"
+		}
+		return true, ""
+	case c.Template != "":
+		want := max(c.Count, 1)
+		found := 0
+		for _, n := range t.nodes {
+			if n.TemplateID == nil {
+				continue
+			}
+			if *n.TemplateID == c.Template || strings.EqualFold(t.templates[*n.TemplateID], c.Template) {
+				found++
+			}
+		}
+		if found < want {
+			return false, fmt.Sprintf("%d of %d nodes of template %s", found, want, c.Template)
+		}
+		return true, ""
+	case len(c.Link) > 0:
+		link, err := t.link(c.Link[0], c.Link[1])
+		if err != nil {
+			return false, err.Error()
+		}
+		if link == nil {
+			return false, "no link " + strings.Join(c.Link, " - ")
+		}
+		return true, ""
+	case len(c.Running) > 0:
+		var stopped []string
+		for _, n := range t.nodes {
+			if n.Status != "started" && (slices.Contains(c.Running, "*") || slices.ContainsFunc(c.Running, func(name string) bool { return strings.EqualFold(name, n.Name) })) {
+				stopped = append(stopped, n.Name)
+			}
+		}
+		for _, name := range c.Running {
+			if name != "*" && t.node(name) == nil {
+				stopped = append(stopped, name+" (missing)")
+			}
+		}
+		if len(stopped) > 0 {
+			return false, "not running: " + strings.Join(stopped, ", ")
+		}
+		return true, ""
+	case c.Filter != nil:
+		link, err := t.link(c.Filter.Link[0], c.Filter.Link[1])
+		if err != nil {
+			return false, err.Error()
+		}
+		if link == nil {
+			return false, "no link " + strings.Join(c.Filter.Link, " - ")
+		}
+		for name, value := range link.Filters {
+			if (c.Filter.Type == "" || strings.EqualFold(name, c.Filter.Type)) && filterSet(value) {
+				return true, ""
+			}
+		}
+		if c.Filter.Type != "" {
+			return false, "no " + c.Filter.Type + " filter"
+		}
+		return false, "no filter"
+	default:
+		for _, d := range t.drawings {
+			if strings.Contains(strings.ToLower(svgText(d.SVG)), strings.ToLower(c.Drawing)) {
+				return true, ""
+			}
+		}
+		return false, "no drawing with " + strconv.Quote(c.Drawing)
+	}
+}
+
+func (t *projectTopology) node(name string) *schemas.NodeResponse {
+	for i := range t.nodes {
+		if strings.EqualFold(t.nodes[i].Name, name) {
+			return &t.nodes[i]
+		}
+	}
+	return nil
+}
+
+// endpoint resolves "node" or "node:interface" to a node and, if an
+// interface is given, its port.
+func (t *projectTopology) endpoint(ref string) (*schemas.NodeResponse, *schemas.PortResponse, error) {
+	name, iface, hasIface := strings.Cut(ref, ":")
+	n := t.node(strings.TrimSpace(name))
+	if n == nil {
+		return nil, nil, fmt.Errorf("node %s missing", name)
+	}
+	if !hasIface {
+		return n, nil, nil
+	}
+	iface = strings.TrimSpace(iface)
+	for i := range n.Ports {
+		if strings.EqualFold(n.Ports[i].Name, iface) || strings.EqualFold(n.Ports[i].ShortName, iface) {
+			return n, &n.Ports[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("node %s has no interface %s", n.Name, iface)
+}
+
+// link returns the link between two endpoints in either direction, nil if
+// there is none.
+func (t *projectTopology) link(a, b string) (*schemas.LinkResponse, error) {
+	aNode, aPort, err := t.endpoint(a)
+	if err != nil {
+		return nil, err
+	}
+	bNode, bPort, err := t.endpoint(b)
+	if err != nil {
+		return nil, err
+	}
+	matches := func(ln schemas.LinkNode, n *schemas.NodeResponse, p *schemas.PortResponse) bool {
+		return ln.NodeID == n.NodeID && (p == nil || (ln.AdapterNumber == p.AdapterNumber && ln.PortNumber == p.PortNumber))
+	}
+	for i := range t.links {
+		l := &t.links[i]
+		if len(l.Nodes) != 2 {
+			continue
+		}
+		if (matches(l.Nodes[0], aNode, aPort) && matches(l.Nodes[1], bNode, bPort)) ||
+			(matches(l.Nodes[0], bNode, bPort) && matches(l.Nodes[1], aNode, aPort)) {
+			return l, nil
+		}
+	}
+	return nil, nil
+}
+
+// filterSet reports whether a link filter has a value, GNS3 keeps unset
+// filters as empty lists.
+func filterSet(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case []any:
+		return len(v) > 0
+	case string:
+		return v != ""
+	default:
+		return true
+	}
+}
+
+// svgText returns the text content of a drawing, or the svg itself if it
+// does not parse.
+func svgText(svg string) string {
+	var sb strings.Builder
+	dec := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return sb.String()
+		}
+		if err != nil {
+			return svg
+		}
+		if data, ok := tok.(xml.CharData); ok {
+			sb.Write(data)
+			sb.WriteByte(' ')
+		}
+	}
+}
+
+// WriteCheckReport writes the results as a csv matrix with one row per
+// group and one column per check, or as json with the messages.
+func WriteCheckReport(w io.Writer, format string, rules CheckRules, reports []GroupReport) error {
+	switch format {
+	case CheckCSV:
+		cw := csv.NewWriter(w)
+		header := []string{"class", "group", "project", "server"}
+		for _, c := range rules.Checks {
+			header = append(header, c.Name)
+		}
+		header = append(header, "score", "max_score", "error")
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, r := range reports {
+			record := []string{r.Class, r.Group, r.Project, r.Server}
+			for _, res := range r.Results {
+				record = append(record, formatPoints(res.Points))
+			}
+			record = append(record, formatPoints(r.Score), formatPoints(r.MaxScore), r.Error)
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case CheckJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	default:
+		return fmt.Errorf("unknown format %q, use one of %s", format, strings.Join(CheckFormats, ", "))
+	}
+}
+
+func formatPoints(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
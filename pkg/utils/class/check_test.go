@@ -0,0 +1,217 @@
+package class
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+)
+
+func writeRules(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadCheckRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		data  string
+		names []string
+		err   string
+	}{
+		{
+			name: "yaml with generated names",
+			file: "rules.yaml",
+			data: `checks:
+  - name: router present
+    node: R1
+    points: 2
+  - template: VPCS
+    count: 2
+  - link: [R1:e0, PC1]
+  - running: ["*"]
+  - filter: {link: [R1, PC1], type: packet_loss}
+  - drawing: Lab 1
+`,
+			names: []string{"router present", "2 x VPCS", "link R1:e0 - PC1", "running *", "filter packet_loss on R1 - PC1", "drawing Lab 1"},
+		},
+		{
+			name:  "json",
+			file:  "rules.json",
+			data:  `{"checks": [{"node": "R1"}, {"template": "VPCS"}]}`,
+			names: []string{"node R1", "template VPCS"},
+		},
+		{name: "no checks", file: "rules.yaml", data: "checks: []\n", err: "has no checks"},
+		{name: "empty file", file: "rules.yaml", data: "", err: "has no checks"},
+		{name: "unknown field", file: "rules.yaml", data: "checks:\n  - nodes: R1\n", err: "failed to parse"},
+		{name: "two kinds", file: "rules.yaml", data: "checks:\n  - node: R1\n    drawing: x\n", err: "check 1: set exactly one"},
+		{name: "no kind", file: "rules.yaml", data: "checks:\n  - name: empty\n", err: "check 1 (empty): set exactly one"},
+		{name: "negative points", file: "rules.yaml", data: "checks:\n  - node: R1\n    points: -1\n", err: "points must not be negative"},
+		{name: "negative count", file: "rules.yaml", data: "checks:\n  - template: VPCS\n    count: -1\n", err: "count must not be negative"},
+		{name: "link with one endpoint", file: "rules.yaml", data: "checks:\n  - link: [R1]\n", err: "a link has two endpoints"},
+		{name: "filter with one endpoint", file: "rules.yaml", data: "checks:\n  - filter: {link: [R1]}\n", err: "the link of a filter has two endpoints"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := LoadCheckRules(writeRules(t, tt.file, tt.data))
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, c := range rules.Checks {
+				names = append(names, c.Name)
+			}
+			if strings.Join(names, "|") != strings.Join(tt.names, "|") {
+				t.Errorf("names %q, want %q", names, tt.names)
+			}
+		})
+	}
+
+	if _, err := LoadCheckRules(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loaded a file that does not exist")
+	}
+}
+
+// testTopology is R1 with interfaces e0 and e1, PC1 linked to R1 e0 with a
+// packet loss filter, and PC2 linked to nothing.
+func testTopology() *projectTopology {
+	vpcs, router := "vpcs-id", "router-id"
+	ports := []schemas.PortResponse{
+		{Name: "Ethernet0", ShortName: "e0", AdapterNumber: 0},
+		{Name: "Ethernet1", ShortName: "e1", AdapterNumber: 1},
+	}
+	return &projectTopology{
+		nodes: []schemas.NodeResponse{
+			{NodeID: "r1", Name: "R1", Status: "started", TemplateID: &router, Ports: ports},
+			{NodeID: "pc1", Name: "PC1", Status: "started", TemplateID: &vpcs},
+			{NodeID: "pc2", Name: "PC2", Status: "stopped", TemplateID: &vpcs},
+		},
+		links: []schemas.LinkResponse{
+			{Nodes: []schemas.LinkNode{{NodeID: "pc1"}, {NodeID: "r1", AdapterNumber: 0}}, Filters: map[string]any{
+				"packet_loss": []any{float64(10)},
+				"delay":       []any{},
+			}},
+		},
+		drawings: []schemas.DrawingResponse{
+			{SVG: `<svg><text>Lab 1: <tspan>OSPF</tspan></text></svg>`},
+			{SVG: `not svg <text`},
+		},
+		templates: map[string]string{vpcs: "VPCS", router: "Cisco 7200"},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name   string
+		check  Check
+		passed bool
+		msg    string
+	}{
+		{name: "node", check: Check{Node: "r1"}, passed: true},
+		{name: "missing node", check: Check{Node: "R2"}, msg: "node R2 missing"},
+		{name: "template by name", check: Check{Template: "vpcs", Count: 2}, passed: true},
+		{name: "template by id", check: Check{Template: "router-id"}, passed: true},
+		{name: "too few of a template", check: Check{Template: "VPCS", Count: 3}, msg: "2 of 3 nodes of template VPCS"},
+		{name: "link", check: Check{Link: []string{"R1", "PC1"}}, passed: true},
+		{name: "link reversed with interface", check: Check{Link: []string{"PC1", "R1:Ethernet0"}}, passed: true},
+		{name: "link on short name", check: Check{Link: []string{"R1: e0", "PC1"}}, passed: true},
+		{name: "link on another interface", check: Check{Link: []string{"R1:e1", "PC1"}}, msg: "no link R1:e1 - PC1"},
+		{name: "unknown interface", check: Check{Link: []string{"R1:e7", "PC1"}}, msg: "node R1 has no interface e7"},
+		{name: "no link", check: Check{Link: []string{"R1", "PC2"}}, msg: "no link R1 - PC2"},
+		{name: "running", check: Check{Running: []string{"R1", "pc1"}}, passed: true},
+		{name: "all running", check: Check{Running: []string{"*"}}, msg: "not running: PC2"},
+		{name: "running missing node", check: Check{Running: []string{"PC3"}}, msg: "not running: PC3 (missing)"},
+		{name: "any filter", check: Check{Filter: &LinkFilterCheck{Link: []string{"R1", "PC1"}}}, passed: true},
+		{name: "filter of type", check: Check{Filter: &LinkFilterCheck{Link: []string{"R1", "PC1"}, Type: "Packet_Loss"}}, passed: true},
+		{name: "empty filter", check: Check{Filter: &LinkFilterCheck{Link: []string{"R1", "PC1"}, Type: "delay"}}, msg: "no delay filter"},
+		{name: "filter without link", check: Check{Filter: &LinkFilterCheck{Link: []string{"R1", "PC2"}}}, msg: "no link R1 - PC2"},
+		{name: "drawing across elements", check: Check{Drawing: "lab 1:  ospf"}, passed: true},
+		{name: "drawing that does not parse", check: Check{Drawing: "not svg"}, passed: true},
+		{name: "missing drawing", check: Check{Drawing: "BGP"}, msg: `no drawing with "BGP"`},
+	}
+	topo := testTopology()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passed, msg := topo.evaluate(tt.check)
+			if passed != tt.passed || msg != tt.msg {
+				t.Errorf("evaluate = %v %q, want %v %q", passed, msg, tt.passed, tt.msg)
+			}
+		})
+	}
+}
+
+func TestCompareGroupNames(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"NET-group-2", "NET-group-10", -1},
+		{"NET-group-10", "NET-group-2", 1},
+		{"NET_3", "NET 3", 1},
+		{"NET-a", "NET-b", -1},
+		{"NET-1", "NET-1", 0},
+	}
+	for _, tt := range tests {
+		got := compareGroupNames(tt.a, tt.b)
+		if (got < 0) != (tt.want < 0) || (got > 0) != (tt.want > 0) {
+			t.Errorf("compareGroupNames(%q, %q) = %d, want sign of %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestWriteCheckReport(t *testing.T) {
+	two := 2.0
+	rules := CheckRules{Checks: []Check{{Name: "router", Points: &two}, {Name: "pc"}}}
+	reports := []GroupReport{
+		{Class: "NET", Group: "NET-g1", Project: "p1", Server: "http://a:3080", Score: 2.5, MaxScore: 3, Results: []CheckResult{
+			{Check: "router", Passed: true, Points: 2},
+			{Check: "pc", Points: 0.5, Message: "half"},
+		}},
+		{Class: "NET", Group: "NET-g2", Project: "p2", Server: "http://a:3080", MaxScore: 3, Error: "project not found", Results: []CheckResult{
+			{Check: "router", Message: "not checked"},
+			{Check: "pc", Message: "not checked"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCheckReport(&buf, CheckCSV, rules, reports); err != nil {
+		t.Fatal(err)
+	}
+	want := "class,group,project,server,router,pc,score,max_score,error\n" +
+		"NET,NET-g1,p1,http://a:3080,2,0.5,2.5,3,\n" +
+		"NET,NET-g2,p2,http://a:3080,0,0,0,3,project not found\n"
+	if buf.String() != want {
+		t.Errorf("csv:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := WriteCheckReport(&buf, CheckJSON, rules, reports); err != nil {
+		t.Fatal(err)
+	}
+	var got []GroupReport
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Results[1].Message != "half" || got[1].Error != "project not found" {
+		t.Errorf("json round trip %+v", got)
+	}
+
+	if err := WriteCheckReport(&buf, "xml", rules, reports); err == nil {
+		t.Error("wrote an unknown format")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"slices"
 	"sort"
 	"strings"
@@ -19,6 +20,7 @@ import (
 	"github.com/stefanistkuhl/gns3util/pkg/config"
 	"github.com/stefanistkuhl/gns3util/pkg/utils"
 	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+	"gopkg.in/yaml.v3"
 )
 
 type NodeAndGroups struct {
@@ -52,8 +54,15 @@ func LoadClassFromFile(filePath string) (schemas.Class, error) {
 		return classData, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	if err := json.Unmarshal(data, &classData); err != nil {
-		return classData, fmt.Errorf("failed to parse JSON: %w", err)
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &classData); err != nil {
+			return classData, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+	default:
+		if err := json.Unmarshal(data, &classData); err != nil {
+			return classData, fmt.Errorf("failed to parse JSON: %w", err)
+		}
 	}
 
 	if classData.Name == "" {
@@ -72,7 +81,7 @@ func LoadClassFromFile(filePath string) (schemas.Class, error) {
 	return classData, nil
 }
 
-func CreateClass(cfg config.GlobalOptions, clusterID int, classData schemas.Class, insertedNodes []db.NodeDataAll) (bool, error) {
+func CreateClass(cfg config.GlobalOptions, clusterID int, classData schemas.Class, insertedNodes []db.NodeDataAll, scheduler Scheduler) (bool, error) {
 	store, err := db.Init()
 	if err != nil {
 		return false, fmt.Errorf("failed to init db: %w", err)
@@ -174,14 +183,15 @@ func CreateClass(cfg config.GlobalOptions, clusterID int, classData schemas.Clas
 		}
 	}
 
-	dist, err := distributeGroupsWithMode(
+	dist, err := scheduler.Place(
+		ctx,
 		func() []db.NodeDataAll {
 			if allowOverAssign {
 				return insertedNodes
 			}
 			return nodesAdjusted
 		}(),
-		classData,
+		totalGroups,
 		!allowOverAssign,
 	)
 	if err != nil {
@@ -223,6 +233,28 @@ func CreateClass(cfg config.GlobalOptions, clusterID int, classData schemas.Clas
 	}
 
 	plans := transformNodeGroupRows(planRows)
+	// groups without students have no rows above but still need their
+	// user group on the node
+	for _, g := range classData.Groups {
+		if len(g.Students) > 0 {
+			continue
+		}
+		row, err := store.GetGroupNode(ctx, sqlc.GetGroupNodeParams{
+			ClusterID: int64(clusterID),
+			Name:      classData.Name,
+			Name_2:    g.Name,
+		})
+		if err != nil {
+			return false, fmt.Errorf("failed to get the node of group %s: %w", g.Name, err)
+		}
+		nodeURL, _ := row.NodeUrl.(string)
+		idx := slices.IndexFunc(plans, func(p db.NodeGroupsForClass) bool { return p.NodeURL == nodeURL })
+		if idx < 0 {
+			plans = append(plans, db.NodeGroupsForClass{NodeURL: nodeURL})
+			idx = len(plans) - 1
+		}
+		plans[idx].Groups = append(plans[idx].Groups, db.GroupData{Name: g.Name, Students: []db.UserData{}})
+	}
 
 	emailByGroupUser := make(map[string]map[string]string)
 	for _, g := range classData.Groups {
@@ -699,7 +731,8 @@ func DeleteExercise(cfg config.GlobalOptions, exerciseName, className, groupName
 		}
 
 		if storeErr == nil {
-			deleteProjectErr := store.DeleteExerciseRecord(ctx, projectID)
+			// records are keyed by the short uuid that ends the project name
+			deleteProjectErr := store.DeleteExerciseRecord(ctx, parts[len(parts)-1])
 			if deleteProjectErr != nil {
 				fmt.Printf("%v Failed to delete exercise record for project %s: %v\n",
 					messageUtils.WarningMsg("Warning"),
@@ -1329,9 +1362,7 @@ func getClusterIDForServer(cfg config.GlobalOptions) (int, error) {
 	return 0, fmt.Errorf("cluster not found")
 }
 
-func distributeGroupsWithMode(nodes []db.NodeDataAll, classData schemas.Class, respectCaps bool) ([]NodeAndGroups, error) {
-	totalGroups := len(classData.Groups)
-
+func distributeGroupsWithMode(nodes []db.NodeDataAll, totalGroups int, respectCaps bool) ([]NodeAndGroups, error) {
 	totalWeight := 0
 	for _, n := range nodes {
 		w := max(n.Weight, 0)
@@ -1462,7 +1493,7 @@ func distributeGroupsWithMode(nodes []db.NodeDataAll, classData schemas.Class, r
 }
 
 func addUserToGroup(cfg config.GlobalOptions, userID, groupID string) error {
-	_, status, err := utils.CallClient(cfg, "addUserToGroup", []string{groupID, userID}, nil)
+	_, status, err := utils.CallClient(cfg, "addGroupMember", []string{groupID, userID}, nil)
 	if err != nil {
 		return fmt.Errorf("failed to add user to group: %w", err)
 	}
@@ -1587,8 +1618,14 @@ func runPlans(cfg config.GlobalOptions, classData schemas.Class, plans []db.Node
 					userData := schemas.UserCreate{
 						Username: &user.Username,
 						Password: &user.Password,
-						Email:    &user.Email,
-						FullName: &user.FullName,
+						IsActive: true,
+					}
+					// the controller rejects empty strings for optional fields
+					if user.Email != "" {
+						userData.Email = &user.Email
+					}
+					if user.FullName != "" {
+						userData.FullName = &user.FullName
 					}
 
 					userBody, status, err := utils.CallClient(nodeCfg, "createUser", []string{}, userData)
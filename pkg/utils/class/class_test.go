@@ -0,0 +1,287 @@
+package class
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+)
+
+func student(name string) schemas.Student {
+	return schemas.Student{UserName: name, Password: name + "-pass1"}
+}
+
+func testClass() schemas.Class {
+	return schemas.Class{
+		Name: "NET",
+		Desc: "networking",
+		Groups: []schemas.Group{
+			{Name: "NET-g1", Students: []schemas.Student{student("alice"), student("bob")}},
+			{Name: "NET-g2", Students: []schemas.Student{student("carol")}},
+		},
+	}
+}
+
+func createClass(t *testing.T, tc *testutil.Cluster, classData schemas.Class) {
+	t.Helper()
+	ok, err := CreateClass(tc.Cfg, tc.ClusterID, classData, tc.Nodes, weightedScheduler{})
+	if err != nil || !ok {
+		t.Fatalf("create class %s: %v", classData.Name, err)
+	}
+}
+
+func TestCreateClass(t *testing.T) {
+	tests := []struct {
+		name  string
+		class schemas.Class
+		want  map[string][]string
+	}{
+		{
+			name:  "two groups",
+			class: testClass(),
+			want: map[string][]string{
+				"NET":    {"alice", "bob", "carol"},
+				"NET-g1": {"alice", "bob"},
+				"NET-g2": {"carol"},
+			},
+		},
+		{
+			name: "empty group",
+			class: schemas.Class{Name: "LAB", Groups: []schemas.Group{
+				{Name: "LAB-g1", Students: []schemas.Student{student("alice")}},
+				{Name: "LAB-g2"},
+			}},
+			want: map[string][]string{
+				"LAB":    {"alice"},
+				"LAB-g1": {"alice"},
+				"LAB-g2": {},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := testutil.NewCluster(t)
+			createClass(t, tc, tt.class)
+
+			got := tc.GroupMembers(t)
+			for group, want := range tt.want {
+				members, ok := got[group]
+				if !ok {
+					t.Errorf("group %s was not created", group)
+				} else if !slices.Equal(members, want) {
+					t.Errorf("members of %s: %v, want %v", group, members, want)
+				}
+			}
+			if findings := diagnose(t, tc, PolicyRecreate, PolicyKeep); len(findings) != 0 {
+				t.Errorf("drift right after create: %+v", findings)
+			}
+		})
+	}
+}
+
+func TestApplyClass(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *schemas.Class)
+		// drift changes the controller behind the back of the database
+		drift func(t *testing.T, tc *testutil.Cluster)
+		want  map[string][]string
+	}{
+		{
+			name: "add student",
+			change: func(c *schemas.Class) {
+				c.Groups[1].Students = append(c.Groups[1].Students, student("dave"))
+			},
+			want: map[string][]string{"NET": {"alice", "bob", "carol", "dave"}, "NET-g2": {"carol", "dave"}},
+		},
+		{
+			name: "move student",
+			change: func(c *schemas.Class) {
+				c.Groups[0].Students = c.Groups[0].Students[:1]
+				c.Groups[1].Students = append(c.Groups[1].Students, student("bob"))
+			},
+			want: map[string][]string{"NET-g1": {"alice"}, "NET-g2": {"bob", "carol"}},
+		},
+		{
+			name: "remove student",
+			change: func(c *schemas.Class) {
+				c.Groups[0].Students = c.Groups[0].Students[1:]
+			},
+			want: map[string][]string{"NET": {"bob", "carol"}, "NET-g1": {"bob"}},
+		},
+		{
+			name: "add group",
+			change: func(c *schemas.Class) {
+				c.Groups = append(c.Groups, schemas.Group{Name: "NET-g3", Students: []schemas.Student{student("erin")}})
+			},
+			want: map[string][]string{"NET": {"alice", "bob", "carol", "erin"}, "NET-g3": {"erin"}},
+		},
+		{
+			name: "remove group",
+			change: func(c *schemas.Class) {
+				c.Groups = c.Groups[:1]
+			},
+			want: map[string][]string{"NET": {"alice", "bob"}, "NET-g2": nil},
+		},
+		{
+			name:   "recreate deleted student",
+			change: func(c *schemas.Class) {},
+			drift: func(t *testing.T, tc *testutil.Cluster) {
+				u, _ := tc.User(t, "bob")
+				if err := tc.Client.Users().Delete(context.Background(), u.UserID.String()); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: map[string][]string{"NET": {"alice", "bob", "carol"}, "NET-g1": {"alice", "bob"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tc := testutil.NewCluster(t)
+			createClass(t, tc, testClass())
+			if tt.drift != nil {
+				tt.drift(t, tc)
+			}
+
+			classData := testClass()
+			tt.change(&classData)
+			plan, err := PlanClassApply(ctx, tc.Cfg, tc.ClusterID, classData, weightedScheduler{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plan.Empty() {
+				t.Fatal("empty plan")
+			}
+			if err := ApplyClassPlan(ctx, tc.Cfg, plan, t.TempDir()); err != nil {
+				t.Fatal(err)
+			}
+
+			got := tc.GroupMembers(t)
+			for group, want := range tt.want {
+				if want == nil {
+					if _, ok := got[group]; ok {
+						t.Errorf("group %s still exists", group)
+					}
+					continue
+				}
+				if !slices.Equal(got[group], want) {
+					t.Errorf("members of %s: %v, want %v", group, got[group], want)
+				}
+			}
+
+			again, err := PlanClassApply(ctx, tc.Cfg, tc.ClusterID, classData, weightedScheduler{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !again.Empty() {
+				t.Errorf("applying again still plans changes: %+v", again)
+			}
+		})
+	}
+
+	if _, err := PlanClassApply(context.Background(), testutil.NewCluster(t).Cfg, 1, testClass(), weightedScheduler{}); !errors.Is(err, ErrClassNotFound) {
+		t.Errorf("plan for a class that does not exist: %v, want ErrClassNotFound", err)
+	}
+}
+
+func TestApplyClassRollsBack(t *testing.T) {
+	ctx := context.Background()
+	tc := testutil.NewCluster(t)
+	createClass(t, tc, testClass())
+	// a user outside the class holds the email of the new student
+	name, password, email := "stray", "stray-pass1", "dave@school.edu"
+	stray, err := tc.Client.Users().Create(ctx, schemas.UserCreate{Username: &name, Password: &password, Email: &email, IsActive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	classData := testClass()
+	dave := student("dave")
+	dave.Email = &email
+	classData.Groups = append(classData.Groups, schemas.Group{Name: "NET-g3", Students: []schemas.Student{dave}})
+	plan, err := PlanClassApply(ctx, tc.Cfg, tc.ClusterID, classData, weightedScheduler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyClassPlan(ctx, tc.Cfg, plan, t.TempDir()); err == nil {
+		t.Fatal("apply with a taken email succeeded")
+	}
+	creds, err := GetClassCredentials(ctx, tc.ClusterID, "NET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range creds {
+		if c.Group == "NET-g3" || c.Username == "dave" {
+			t.Errorf("rows of the failed apply were kept: %+v", c)
+		}
+	}
+
+	// the second run picks up the user group the first one left behind
+	if err := tc.Client.Users().Delete(ctx, stray.UserID.String()); err != nil {
+		t.Fatal(err)
+	}
+	plan, err = PlanClassApply(ctx, tc.Cfg, tc.ClusterID, classData, weightedScheduler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyClassPlan(ctx, tc.Cfg, plan, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if got := tc.GroupMembers(t)["NET-g3"]; !slices.Equal(got, []string{"dave"}) {
+		t.Errorf("members of NET-g3: %v, want dave", got)
+	}
+}
+
+func TestExportClass(t *testing.T) {
+	tests := []struct {
+		name          string
+		withPasswords bool
+	}{
+		{name: "with passwords", withPasswords: true},
+		{name: "without passwords"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tc := testutil.NewCluster(t)
+			want := testClass()
+			createClass(t, tc, want)
+
+			got, _, err := ExportClass(ctx, tc.Cfg, tc.ClusterID, want.Name, tt.withPasswords)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != want.Name || got.Desc != want.Desc || len(got.Groups) != len(want.Groups) {
+				t.Fatalf("exported %+v, want %+v", got, want)
+			}
+			for i, g := range want.Groups {
+				if got.Groups[i].Name != g.Name || len(got.Groups[i].Students) != len(g.Students) {
+					t.Fatalf("group %d: %+v, want %+v", i, got.Groups[i], g)
+				}
+				for j, s := range g.Students {
+					gs := got.Groups[i].Students[j]
+					wantPassword := ""
+					if tt.withPasswords {
+						wantPassword = s.Password
+					}
+					if gs.UserName != s.UserName || gs.Password != wantPassword {
+						t.Errorf("student %+v, want %s with password %q", gs, s.UserName, wantPassword)
+					}
+				}
+			}
+
+			// the export is a class file that matches the class
+			plan, err := PlanClassApply(ctx, tc.Cfg, tc.ClusterID, got, weightedScheduler{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !plan.Empty() {
+				t.Errorf("applying the export plans changes: %+v", plan)
+			}
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package class
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+// CollectOptions configures CollectExercise.
+type CollectOptions struct {
+	ClusterID int
+	Exercise  string
+	ClassName string
+	GroupName string
+	OutDir    string
+	Export    sdk.ExportOptions
+	// Lock locks every project before it is exported.
+	Lock bool
+	// Workers is the number of projects exported at the same time.
+	Workers int
+}
+
+// Submission is the manifest entry of one group. Error is set when the
+// project could not be collected.
+type Submission struct {
+	Class       string    `json:"class"`
+	Group       string    `json:"group"`
+	Students    []string  `json:"students"`
+	ProjectUUID string    `json:"project_uuid"`
+	ProjectID   string    `json:"project_id,omitempty"`
+	Project     string    `json:"project,omitempty"`
+	Server      string    `json:"server"`
+	Locked      bool      `json:"locked"`
+	File        string    `json:"file,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"`
+	ExportedAt  time.Time `json:"exported_at,omitzero"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// Manifest describes a collection and is written next to the archives.
+type Manifest struct {
+	Exercise         string       `json:"exercise"`
+	CollectedAt      time.Time    `json:"collected_at"`
+	IncludeSnapshots bool         `json:"include_snapshots"`
+	IncludeImages    bool         `json:"include_images"`
+	Compression      string       `json:"compression"`
+	CompressionLevel int          `json:"compression_level"`
+	Submissions      []Submission `json:"submissions"`
+}
+
+type collectJob struct {
+	row      sqlc.GetExerciseProjectsRow
+	cfg      config.GlobalOptions
+	projects []schemas.ProjectResponse
+	err      error
+}
+
+// CollectExercise exports the project of every group of an exercise on all
+// nodes of a cluster into OutDir, optionally locking it first, and writes
+// manifest.json and SHA256SUMS. Up to Workers projects are exported at the
+// same time across all nodes.
+func CollectExercise(ctx context.Context, cfg config.GlobalOptions, opts CollectOptions) (Manifest, error) {
+	manifest := Manifest{
+		Exercise:         opts.Exercise,
+		CollectedAt:      time.Now().UTC(),
+		IncludeSnapshots: opts.Export.IncludeSnapshots,
+		IncludeImages:    opts.Export.IncludeImages,
+		Compression:      opts.Export.Compression,
+		CompressionLevel: opts.Export.CompressionLevel,
+	}
+	store, err := db.Init()
+	if err != nil {
+		return manifest, fmt.Errorf("failed to init db: %w", err)
+	}
+	nodeURLs, byNode, err := exerciseProjectsByNode(ctx, store, opts.ClusterID, opts.Exercise, opts.ClassName, opts.GroupName)
+	if err != nil {
+		return manifest, err
+	}
+	students, err := groupStudents(ctx, store, opts.ClusterID, byNode)
+	if err != nil {
+		return manifest, err
+	}
+	if err := os.MkdirAll(opts.OutDir, 0o750); err != nil {
+		return manifest, fmt.Errorf("failed to create %s: %w", opts.OutDir, err)
+	}
+
+	var jobs []collectJob
+	for _, url := range nodeURLs {
+		nodeCfg := cfg
+		nodeCfg.Server = url
+		projects, err := getProjects(nodeCfg)
+		for _, row := range byNode[url] {
+			jobs = append(jobs, collectJob{row: row, cfg: nodeCfg, projects: projects, err: err})
+		}
+	}
+
+	queue := make(chan int)
+	manifest.Submissions = make([]Submission, len(jobs))
+	var wg sync.WaitGroup
+	for range max(opts.Workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				job := jobs[i]
+				sub := Submission{
+					Class:       job.row.ClassName,
+					Group:       job.row.GroupName,
+					Students:    students[job.row.ClassName+"/"+job.row.GroupName],
+					ProjectUUID: job.row.ProjectUuid,
+					Server:      job.cfg.Server,
+				}
+				if job.err != nil {
+					sub.Error = job.err.Error()
+				} else if err := collectProject(ctx, job.cfg, opts, job.projects, job.row.NameFormat, &sub); err != nil {
+					sub.Error = err.Error()
+				}
+				if sub.Error != "" {
+					fmt.Printf("%v to collect %s of group %s on %s: %s\n", messageUtils.ErrorMsg("Failed"),
+						sub.ProjectUUID, messageUtils.Bold(sub.Group), sub.Server, sub.Error)
+				} else {
+					fmt.Printf("%v %s of group %s (%s)\n", messageUtils.SuccessMsg("Collected"),
+						messageUtils.Bold(sub.Project), sub.Group, sub.File)
+				}
+				manifest.Submissions[i] = sub
+			}
+		}()
+	}
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	slices.SortFunc(manifest.Submissions, func(a, b Submission) int {
+		if c := strings.Compare(a.Class, b.Class); c != 0 {
+			return c
+		}
+		return compareGroupNames(a.Group, b.Group)
+	})
+	if err := writeManifest(opts.OutDir, manifest); err != nil {
+		return manifest, err
+	}
+	return manifest, nil
+}
+
+func collectProject(ctx context.Context, cfg config.GlobalOptions, opts CollectOptions, projects []schemas.ProjectResponse, format sql.NullString, sub *Submission) error {
+	idx := findExerciseProject(projects, format, sub.Class, sub.Group, sub.ProjectUUID)
+	if idx < 0 {
+		return fmt.Errorf("project not found")
+	}
+	project := projects[idx]
+	sub.ProjectID = project.ProjectID
+	sub.Project = project.Name
+
+	client, err := utils.NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	if opts.Lock {
+		// a closed project has no nodes or drawings to lock, it keeps the
+		// lock when it is closed again after the export
+		if project.Status == nil || *project.Status != "opened" {
+			if err := client.Projects().Open(ctx, project.ProjectID); err != nil {
+				return fmt.Errorf("failed to open the project to lock it: %w", err)
+			}
+			defer func() {
+				if err := client.Projects().Close(ctx, project.ProjectID); err != nil {
+					fmt.Printf("%v failed to close %s: %v\n", messageUtils.WarningMsg("Warning"), messageUtils.Bold(project.Name), err)
+				}
+			}()
+		}
+		if err := client.Projects().Lock(ctx, project.ProjectID); err != nil {
+			return fmt.Errorf("failed to lock the project: %w", err)
+		}
+		sub.Locked = true
+	}
+
+	// the project name is up to the students, the file is named after the
+	// class, group and exercise instead
+	sub.File = archiveName(sub.Class, sub.Group, sub.ProjectUUID)
+	size, sum, err := exportArchive(ctx, client, project.ProjectID, opts.Export, filepath.Join(opts.OutDir, sub.File))
+	if err != nil {
+		sub.File = ""
+		return fmt.Errorf("failed to export the project: %w", err)
+	}
+	sub.ExportedAt = time.Now().UTC()
+	sub.SHA256 = sum
+	sub.Size = size
+	return nil
+}
+
+// archiveName returns the file name of an exported project made of parts
+// with everything but letters, digits, dots and dashes replaced, so it
+// stays in the directory it is joined to.
+func archiveName(parts ...string) string {
+	safe := make([]string, len(parts))
+	for i, part := range parts {
+		safe[i] = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' {
+				return r
+			}
+			return '_'
+		}, part)
+	}
+	return strings.Join(safe, "_") + ".gns3project"
+}
+
+// exportArchive streams the export of a project to path and returns its
+// size and SHA-256. The file is removed again when the export fails.
+func exportArchive(ctx context.Context, client *sdk.Client, projectID string, opts sdk.ExportOptions, path string) (int64, string, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return 0, "", err
+	}
+	hash := sha256.New()
+	size, err := client.Projects().ExportTo(ctx, projectID, opts, io.MultiWriter(f, hash))
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write %s: %w", path, closeErr)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// groupStudents returns the usernames of every group of the classes in
+// byNode, keyed by class and group name.
+func groupStudents(ctx context.Context, store *db.Store, clusterID int, byNode map[string][]sqlc.GetExerciseProjectsRow) (map[string][]string, error) {
+	out := map[string][]string{}
+	done := map[string]bool{}
+	for _, rows := range byNode {
+		for _, row := range rows {
+			if done[row.ClassName] {
+				continue
+			}
+			done[row.ClassName] = true
+			members, err := store.GetClassMembers(ctx, sqlc.GetClassMembersParams{ClusterID: int64(clusterID), Name: row.ClassName})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the members of class %s: %w", messageUtils.Bold(row.ClassName), err)
+			}
+			for _, m := range members {
+				if m.Username.Valid {
+					key := row.ClassName + "/" + m.GroupName
+					out[key] = append(out[key], m.Username.String)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// writeManifest writes manifest.json and a SHA256SUMS file that
+// sha256sum -c can verify the archives with.
+func writeManifest(dir string, manifest Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write the manifest: %w", err)
+	}
+	var sums strings.Builder
+	for _, s := range manifest.Submissions {
+		if s.SHA256 != "" {
+			fmt.Fprintf(&sums, "%s  %s\n", s.SHA256, s.File)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "SHA256SUMS"), []byte(sums.String()), 0o600); err != nil {
+		return fmt.Errorf("failed to write SHA256SUMS: %w", err)
+	}
+	return nil
+}
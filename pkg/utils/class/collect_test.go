@@ -0,0 +1,19 @@
+package class
+
+import "testing"
+
+func TestArchiveName(t *testing.T) {
+	tests := []struct {
+		parts []string
+		want  string
+	}{
+		{[]string{"NET", "NET-g1", "0a1b2c3d"}, "NET_NET-g1_0a1b2c3d.gns3project"},
+		{[]string{"NET", "../../etc", "0a1b2c3d"}, "NET_.._.._etc_0a1b2c3d.gns3project"},
+		{[]string{"Netze 1", "Gruppe ä/1", "0a1b2c3d"}, "Netze_1_Gruppe_ä_1_0a1b2c3d.gns3project"},
+	}
+	for _, tt := range tests {
+		if got := archiveName(tt.parts...); got != tt.want {
+			t.Errorf("archiveName(%q) = %q, want %q", tt.parts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package class
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+// Formats of WriteCredentials.
+const (
+	CredentialsCSV      = "csv"
+	CredentialsHTML     = "html"
+	CredentialsMarkdown = "markdown"
+)
+
+var CredentialFormats = []string{CredentialsCSV, CredentialsHTML, CredentialsMarkdown}
+
+// Credential is the login of one student as stored when the class was
+// created. Students may have changed the password since.
+type Credential struct {
+	Username string `json:"username"`
+	FullName string `json:"full_name"`
+	Password string `json:"password"`
+	Group    string `json:"group"`
+	NodeURL  string `json:"node_url"`
+}
+
+//go:embed credentials.html
+var credentialsHTML string
+
+var credentialsTemplate = template.Must(template.New("credentials").Parse(credentialsHTML))
+
+// GetClassCredentials returns the logins of a class from the cluster
+// database, ordered by node, group and student.
+func GetClassCredentials(ctx context.Context, clusterID int, className string) ([]Credential, error) {
+	store, err := db.Init()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init db: %w", err)
+	}
+	exists, err := store.CheckIfClassExists(ctx, sqlc.CheckIfClassExistsParams{ClusterID: int64(clusterID), Name: className})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if the class %s exists: %w", messageUtils.Bold(className), err)
+	}
+	if exists != 1 {
+		return nil, fmt.Errorf("%w: %s", ErrClassNotFound, className)
+	}
+	rows, err := store.GetNodeGroupNamesForClass(ctx, sqlc.GetNodeGroupNamesForClassParams{ClusterID: int64(clusterID), Name: className})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the students of class %s: %w", messageUtils.Bold(className), err)
+	}
+
+	creds := make([]Credential, 0, len(rows))
+	for _, row := range rows {
+		nodeURL, _ := row.NodeUrl.(string)
+		creds = append(creds, Credential{
+			Username: row.Username,
+			FullName: row.FullName.String,
+			Password: row.DefaultPassword,
+			Group:    row.GroupName,
+			NodeURL:  nodeURL,
+		})
+	}
+	return creds, nil
+}
+
+// WriteCredentials writes the logins of a class as a csv or markdown
+// sheet, or as an html page with one card per student to cut out.
+func WriteCredentials(w io.Writer, format, className string, creds []Credential) error {
+	switch format {
+	case CredentialsCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"username", "full_name", "password", "group", "node_url"}); err != nil {
+			return err
+		}
+		for _, c := range creds {
+			if err := cw.Write([]string{c.Username, c.FullName, c.Password, c.Group, c.NodeURL}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case CredentialsMarkdown:
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "# %s\n\n", markdownCell(className))
+		sb.WriteString("| Name | Username | Password | Group | Server |\n")
+		sb.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, c := range creds {
+			fmt.Fprintf(&sb, "| %s | `%s` | `%s` | %s | %s |\n",
+				markdownCell(c.FullName), c.Username, strings.ReplaceAll(c.Password, "|", `\|`), markdownCell(c.Group), c.NodeURL)
+		}
+		_, err := io.WriteString(w, sb.String())
+		return err
+	case CredentialsHTML:
+		return credentialsTemplate.Execute(w, struct {
+			Class       string
+			Generated   string
+			Credentials []Credential
+		}{className, time.Now().Format(time.DateOnly), creds})
+	}
+	return fmt.Errorf("unknown format %q, use one of %s", format, strings.Join(CredentialFormats, ", "))
+}
+
+// markdownCell keeps s from ending the table cell or being rendered as
+// html. Code spans only need the pipe escaped, entities show up literally
+// in them.
+var markdownCell = strings.NewReplacer("|", `\|`, "<", "&lt;", ">", "&gt;").Replace
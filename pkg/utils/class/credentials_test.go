@@ -0,0 +1,85 @@
+package class
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func TestWriteCredentials(t *testing.T) {
+	creds := []Credential{
+		{Username: "alice", FullName: "Alice O'Neil", Password: "Xk7pQ2mN9bRt", Group: "NET-g1", NodeURL: "http://gns3-a:3080"},
+		{Username: "bob", FullName: `Bob "Bobby" <script>alert(1)</script> & Co`, Password: "a|b<c>&d", Group: "NET-g1", NodeURL: "http://gns3-a:3080"},
+		{Username: "carol", Password: "Pw3,with,commas", Group: "NET-g2", NodeURL: "https://gns3-b:3443"},
+	}
+	for _, tt := range []struct {
+		format string
+		golden string
+	}{
+		{CredentialsCSV, "credentials.csv"},
+		{CredentialsMarkdown, "credentials.md"},
+		{CredentialsHTML, "credentials.html"},
+	} {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteCredentials(&buf, tt.format, "NET <2025>", creds); err != nil {
+				t.Fatal(err)
+			}
+			// the page carries the day it was generated
+			got := strings.ReplaceAll(buf.String(), time.Now().Format(time.DateOnly), "2025-01-01")
+
+			path := filepath.Join("testdata", tt.golden)
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("%s output differs from %s:\n%s", tt.format, path, got)
+			}
+		})
+	}
+
+	if err := WriteCredentials(&bytes.Buffer{}, "pdf", "NET", creds); err == nil {
+		t.Error("wrote an unknown format")
+	}
+}
+
+func TestGetClassCredentials(t *testing.T) {
+	tc := testutil.NewCluster(t)
+	createClass(t, tc, testClass())
+
+	creds, err := GetClassCredentials(context.Background(), tc.ClusterID, "NET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range creds {
+		got = append(got, c.Group+"/"+c.Username+"/"+c.Password)
+		if c.NodeURL != tc.Server.URL {
+			t.Errorf("node url of %s %q, want %q", c.Username, c.NodeURL, tc.Server.URL)
+		}
+	}
+	want := []string{"NET-g1/alice/alice-pass1", "NET-g1/bob/bob-pass1", "NET-g2/carol/carol-pass1"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("credentials %v, want %v", got, want)
+	}
+
+	if _, err := GetClassCredentials(context.Background(), tc.ClusterID, "LAB"); !errors.Is(err, ErrClassNotFound) {
+		t.Errorf("credentials of an unknown class: %v, want ErrClassNotFound", err)
+	}
+}
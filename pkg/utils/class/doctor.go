@@ -0,0 +1,898 @@
+package class
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+)
+
+// Policies of RepairFindings. Rows whose controller object is gone are
+// recreated or pruned, controller objects of a class without a row are
+// adopted, pruned or kept.
+const (
+	PolicyRecreate = "recreate"
+	PolicyPrune    = "prune"
+	PolicyAdopt    = "adopt"
+	PolicyKeep     = "keep"
+)
+
+// Sides of a Finding.
+const (
+	// SideDB is a row of the cluster database without its controller object.
+	SideDB = "db"
+	// SideController is a controller object of a class without a row.
+	SideController = "controller"
+)
+
+var projectUUIDSuffix = regexp.MustCompile(`-([0-9a-f]{8})$`)
+
+// Redeployer creates the project of exercise ex for a group of a class
+// again and replaces its row, like exercise deploy.
+type Redeployer func(ctx context.Context, store *db.Store, className, groupName string, ex sqlc.Exercise) error
+
+// DoctorOptions configures DiagnoseCluster.
+type DoctorOptions struct {
+	ClusterID int
+	// Missing is PolicyRecreate or PolicyPrune.
+	Missing string
+	// Untracked is PolicyAdopt, PolicyPrune or PolicyKeep.
+	Untracked string
+	// Redeploy recreates missing exercise projects under PolicyRecreate,
+	// without it their rows are deleted.
+	Redeploy Redeployer
+}
+
+// Finding is a drift between the cluster database and a controller.
+type Finding struct {
+	Node    string `json:"node"`
+	Side    string `json:"side"`
+	Kind    string `json:"kind"`
+	Class   string `json:"class"`
+	Name    string `json:"name"`
+	Problem string `json:"problem"`
+	// Fix is what RepairFindings does under the policies, empty for nothing.
+	Fix   string `json:"fix,omitempty"`
+	Fixed bool   `json:"fixed,omitempty"`
+	Error string `json:"error,omitempty"`
+
+	repair func(ctx context.Context) error
+}
+
+// doctorGroup is a group of a class in the database.
+type doctorGroup struct {
+	ID        int64
+	Name      string
+	NodeURL   string
+	Students  []sqlc.GetClassMembersRow
+	Exercises []sqlc.Exercise
+}
+
+// doctor collects the findings of one DiagnoseCluster run.
+type doctor struct {
+	store    *db.Store
+	opts     DoctorOptions
+	nodes    map[string]*doctorNode
+	nodeIDs  map[string]int64
+	findings []Finding
+
+	classes map[string]sqlc.Class
+	groups  map[string][]*doctorGroup
+	// users maps the usernames of the cluster to their class and group
+	users map[string][2]string
+	// exercises match the project names of the exercises
+	exercises []func(name string) bool
+	templates map[string]bool
+	// claimed are usernames an adopted group takes along
+	claimed map[string]bool
+}
+
+// DiagnoseCluster cross-checks the classes, groups, users, group
+// assignments and exercises of a cluster with the users, user groups,
+// projects, pools and ACEs of its nodes. Every finding carries the fix of
+// the policies of opts.
+func DiagnoseCluster(ctx context.Context, cfg config.GlobalOptions, opts DoctorOptions) ([]Finding, error) {
+	if !slices.Contains([]string{PolicyRecreate, PolicyPrune}, opts.Missing) {
+		return nil, fmt.Errorf("unknown policy %q for missing objects, use %s or %s", opts.Missing, PolicyRecreate, PolicyPrune)
+	}
+	if !slices.Contains([]string{PolicyKeep, PolicyAdopt, PolicyPrune}, opts.Untracked) {
+		return nil, fmt.Errorf("unknown policy %q for untracked objects, use %s, %s or %s", opts.Untracked, PolicyKeep, PolicyAdopt, PolicyPrune)
+	}
+	store, err := db.Init()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init db: %w", err)
+	}
+	_, nodeURLs, err := clusterNodes(ctx, store, opts.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	d := &doctor{
+		store: store, opts: opts, nodes: map[string]*doctorNode{}, nodeIDs: map[string]int64{},
+		classes: map[string]sqlc.Class{}, groups: map[string][]*doctorGroup{}, users: map[string][2]string{},
+		templates: map[string]bool{}, claimed: map[string]bool{},
+	}
+
+	urls := make([]string, 0, len(nodeURLs))
+	for id, url := range nodeURLs {
+		urls = append(urls, url)
+		d.nodeIDs[url] = id
+	}
+	slices.Sort(urls)
+	for _, url := range urls {
+		n := &doctorNode{cfg: cfg}
+		n.cfg.Server = url
+		if err := n.load(ctx); err != nil {
+			d.add(Finding{Node: url, Side: SideController, Kind: "node", Problem: fmt.Sprintf("unreachable, skipped: %v", err)})
+			continue
+		}
+		d.nodes[url] = n
+	}
+
+	classes, err := store.GetClasses(ctx, int64(opts.ClusterID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get classes: %w", err)
+	}
+	for _, c := range classes {
+		d.classes[c.Name] = c
+		rows, err := store.GetClassMembers(ctx, sqlc.GetClassMembersParams{ClusterID: int64(opts.ClusterID), Name: c.Name})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the members of class %s: %w", c.Name, err)
+		}
+		for _, row := range rows {
+			groups := d.groups[c.Name]
+			if len(groups) == 0 || groups[len(groups)-1].ID != row.GroupID {
+				g := &doctorGroup{ID: row.GroupID, Name: row.GroupName}
+				if row.NodeID.Valid {
+					g.NodeURL = nodeURLs[row.NodeID.Int64]
+				}
+				if g.Exercises, err = store.GetExercisesForGroup(ctx, g.ID); err != nil {
+					return nil, fmt.Errorf("failed to get the exercises of group %s: %w", g.Name, err)
+				}
+				for _, ex := range g.Exercises {
+					d.exercises = append(d.exercises, exerciseProject(ex.NameFormat, c.Name, g.Name, ex.ProjectUuid))
+					if ex.TemplateProjectID.Valid {
+						d.templates[ex.TemplateProjectID.String] = true
+					}
+				}
+				d.groups[c.Name] = append(groups, g)
+			}
+			if row.Username.Valid {
+				g := d.groups[c.Name][len(d.groups[c.Name])-1]
+				g.Students = append(g.Students, row)
+				d.users[row.Username.String] = [2]string{c.Name, g.Name}
+			}
+		}
+	}
+
+	for _, c := range classes {
+		if err := d.checkClass(ctx, c.Name); err != nil {
+			return nil, err
+		}
+	}
+	for _, url := range urls {
+		if n, ok := d.nodes[url]; ok {
+			if err := d.checkNode(ctx, n); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return d.findings, nil
+}
+
+// RepairFindings runs the fixes of the findings in order and records the
+// outcome in them. It returns an error if a fix failed.
+func RepairFindings(ctx context.Context, findings []Finding) error {
+	failed, total := 0, 0
+	for i := range findings {
+		if findings[i].repair == nil {
+			continue
+		}
+		total++
+		if err := findings[i].repair(ctx); err != nil {
+			findings[i].Error = err.Error()
+			failed++
+			continue
+		}
+		findings[i].Fixed = true
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d fixes failed", failed, total)
+	}
+	return nil
+}
+
+func (d *doctor) add(f Finding) {
+	d.findings = append(d.findings, f)
+}
+
+// checkClass looks for the controller objects of the rows of a class.
+func (d *doctor) checkClass(ctx context.Context, className string) error {
+	checkedClassGroup := map[string]bool{}
+	for _, g := range d.groups[className] {
+		if g.NodeURL == "" {
+			d.checkUnassigned(className, g)
+			continue
+		}
+		n, ok := d.nodes[g.NodeURL]
+		if !ok {
+			continue
+		}
+
+		if _, ok := n.groups[className]; !ok && !checkedClassGroup[n.cfg.Server] {
+			d.add(Finding{Node: n.cfg.Server, Side: SideDB, Kind: "class-group", Class: className, Name: className,
+				Problem: "user group of the class missing", Fix: "create it",
+				repair: func(context.Context) error {
+					_, err := ensureAPIGroup(n.cfg, className)
+					return err
+				}})
+		}
+		checkedClassGroup[n.cfg.Server] = true
+
+		_, groupExists := n.groups[g.Name]
+		if !groupExists {
+			f := Finding{Node: n.cfg.Server, Side: SideDB, Kind: "group", Class: className, Name: g.Name, Problem: "user group missing"}
+			if d.opts.Missing == PolicyPrune {
+				f.Fix = "delete the group row with its students and exercises"
+				groupID := g.ID
+				f.repair = func(ctx context.Context) error { return d.store.DeleteGroup(ctx, groupID) }
+				d.add(f)
+				continue
+			}
+			f.Fix = "create it and add its students"
+			students := g.Students
+			groupName := g.Name
+			f.repair = func(ctx context.Context) error {
+				if _, err := ensureAPIGroup(n.cfg, groupName); err != nil {
+					return err
+				}
+				for _, s := range students {
+					if user, ok := n.users[s.Username.String]; ok {
+						if err := joinAPIGroups(n.cfg, user.UserID.String(), user.Username, nil, className, groupName); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			}
+			d.add(f)
+		}
+
+		for _, s := range g.Students {
+			username := s.Username.String
+			user, ok := n.users[username]
+			if !ok {
+				f := Finding{Node: n.cfg.Server, Side: SideDB, Kind: "user", Class: className, Name: username, Problem: "user missing"}
+				switch {
+				case d.opts.Missing == PolicyPrune:
+					f.Fix = "delete the user row"
+					userID := s.UserID.Int64
+					f.repair = func(ctx context.Context) error { return d.store.DeleteUser(ctx, userID) }
+				case s.DefaultPassword.String == "":
+					f.Problem += ", no initial password to recreate it with"
+				default:
+					f.Fix = "create it with its initial password"
+					student := PlannedStudent{Username: username, FullName: s.FullName.String, Password: s.DefaultPassword.String, Group: g.Name}
+					f.repair = func(context.Context) error { return createAPIStudent(n.cfg, className, student) }
+				}
+				d.add(f)
+				continue
+			}
+			if !groupExists {
+				continue
+			}
+			var missing []string
+			for _, name := range []string{className, g.Name} {
+				members, err := n.members(ctx, name)
+				if err != nil {
+					return err
+				}
+				if members != nil && !members[username] {
+					missing = append(missing, name)
+				}
+			}
+			if len(missing) > 0 {
+				d.add(Finding{Node: n.cfg.Server, Side: SideDB, Kind: "membership", Class: className, Name: username,
+					Problem: "not in group " + strings.Join(missing, ", "), Fix: "add it",
+					repair: func(context.Context) error {
+						return joinAPIGroups(n.cfg, user.UserID.String(), username, nil, missing...)
+					}})
+			}
+		}
+
+		for _, ex := range g.Exercises {
+			d.checkExercise(n, className, g.Name, groupExists, ex)
+		}
+	}
+	return nil
+}
+
+// checkUnassigned reports a group without a node, it is assigned to the
+// only node that has its user group.
+func (d *doctor) checkUnassigned(className string, g *doctorGroup) {
+	f := Finding{Side: SideDB, Kind: "assignment", Class: className, Name: g.Name, Problem: "group not assigned to a node"}
+	var found []string
+	for url, n := range d.nodes {
+		if _, ok := n.groups[g.Name]; ok {
+			found = append(found, url)
+		}
+	}
+	groupID := g.ID
+	switch {
+	case len(found) == 1:
+		nodeID, err := d.nodeID(found[0])
+		if err == nil {
+			f.Fix = "assign it to " + found[0]
+			f.repair = func(ctx context.Context) error {
+				return d.store.AssignGroupToNode(ctx, sqlc.AssignGroupToNodeParams{NodeID: nodeID, GroupID: groupID})
+			}
+		}
+	case d.opts.Missing == PolicyPrune:
+		f.Fix = "delete the group row with its students and exercises"
+		f.repair = func(ctx context.Context) error { return d.store.DeleteGroup(ctx, groupID) }
+	case len(found) > 1:
+		f.Problem += ", its user group is on " + strings.Join(found, ", ")
+	}
+	d.add(f)
+}
+
+// checkExercise looks for the project of an exercise and its pool and ACE.
+func (d *doctor) checkExercise(n *doctorNode, className, groupName string, groupExists bool, ex sqlc.Exercise) {
+	name := fmt.Sprintf("%s (%s)", ex.Name, ex.ProjectUuid)
+	project := n.project(exerciseProject(ex.NameFormat, className, groupName, ex.ProjectUuid))
+	if project == nil {
+		uuid := ex.ProjectUuid
+		f := Finding{Node: n.cfg.Server, Side: SideDB, Kind: "exercise", Class: className, Name: name, Problem: "project missing",
+			Fix:    "delete the exercise row",
+			repair: func(ctx context.Context) error { return d.store.DeleteExerciseRecord(ctx, uuid) }}
+		switch {
+		case ex.State.String == "deleted":
+			f.Problem = "row left by a failed exercise delete"
+		case d.opts.Missing == PolicyRecreate && d.opts.Redeploy != nil:
+			f.Fix = "create it again from the template of the exercise"
+			f.repair = func(ctx context.Context) error { return d.opts.Redeploy(ctx, d.store, className, groupName, ex) }
+		default:
+			f.Fix += ", exercise deploy creates a new copy"
+		}
+		d.add(f)
+		return
+	}
+	if ex.State.String == "deleted" {
+		uuid := ex.ProjectUuid
+		d.add(Finding{Node: n.cfg.Server, Side: SideDB, Kind: "exercise", Class: className, Name: name,
+			Problem: "project left by a failed exercise delete", Fix: "delete it with its pool and the exercise row",
+			repair: func(ctx context.Context) error {
+				if err := n.deleteProject(ctx, *project); err != nil {
+					return err
+				}
+				return d.store.DeleteExerciseRecord(ctx, uuid)
+			}})
+		return
+	}
+	if !groupExists && d.opts.Missing == PolicyPrune {
+		return
+	}
+
+	pool := n.poolOf(project.ProjectID)
+	if pool == nil {
+		d.add(Finding{Node: n.cfg.Server, Side: SideDB, Kind: "pool", Class: className, Name: name,
+			Problem: "project in no pool, the group has no access", Fix: "create " + project.Name + "-pool with an ACE for " + groupName,
+			repair: func(ctx context.Context) error { return n.grantPool(ctx, *project, "", groupName) }})
+		return
+	}
+	if group, ok := n.groups[groupName]; !ok || !n.hasGroupACE(pool.ResourcePoolID, group.UserGroupID.String()) {
+		poolID := pool.ResourcePoolID
+		d.add(Finding{Node: n.cfg.Server, Side: SideDB, Kind: "ace", Class: className, Name: name,
+			Problem: "no ACE for " + groupName + " on " + pool.Name, Fix: "create it",
+			repair: func(ctx context.Context) error { return n.grantPool(ctx, *project, poolID, groupName) }})
+	}
+}
+
+// checkNode looks for the user groups, users, projects and pools of the
+// classes on a node that no row knows.
+func (d *doctor) checkNode(ctx context.Context, n *doctorNode) error {
+	url := n.cfg.Server
+	groupNames := make([]string, 0, len(n.groups))
+	for name := range n.groups {
+		groupNames = append(groupNames, name)
+	}
+	slices.Sort(groupNames)
+
+	for _, name := range groupNames {
+		className := d.classOf(name)
+		if className == "" {
+			continue
+		}
+		if name == className {
+			if !slices.ContainsFunc(d.groups[className], func(g *doctorGroup) bool { return g.NodeURL == url }) {
+				f := Finding{Node: url, Side: SideController, Kind: "class-group", Class: className, Name: name, Problem: "no group of the class on this node"}
+				d.prune(&f, "delete it", func(context.Context) error { return deleteGroup(n.cfg, n.groups[name].UserGroupID.String()) })
+				d.add(f)
+			}
+			continue
+		}
+		idx := slices.IndexFunc(d.groups[className], func(g *doctorGroup) bool { return g.Name == name })
+		if idx >= 0 && d.groups[className][idx].NodeURL == url {
+			continue
+		}
+		f := Finding{Node: url, Side: SideController, Kind: "group", Class: className, Name: name}
+		if idx >= 0 {
+			f.Problem = "copy of a group assigned to " + d.groups[className][idx].NodeURL
+			d.prune(&f, "delete it", func(context.Context) error { return deleteGroup(n.cfg, n.groups[name].UserGroupID.String()) })
+			d.add(f)
+			continue
+		}
+		f.Problem = "no group row"
+		switch d.opts.Untracked {
+		case PolicyPrune:
+			f.Fix = "delete it"
+			f.repair = func(context.Context) error { return deleteGroup(n.cfg, n.groups[name].UserGroupID.String()) }
+		case PolicyAdopt:
+			members, err := n.members(ctx, name)
+			if err != nil {
+				return err
+			}
+			var adopt []schemas.UserResponse
+			for username := range members {
+				if _, tracked := d.users[username]; !tracked && !d.claimed[username] {
+					d.claimed[username] = true
+					adopt = append(adopt, n.users[username])
+				}
+			}
+			f.Fix = fmt.Sprintf("add it to class %s on this node with %d members", className, len(adopt))
+			f.repair = func(ctx context.Context) error { return d.adoptGroup(ctx, className, name, url, adopt) }
+		}
+		d.add(f)
+	}
+
+	for _, className := range slices.Sorted(maps.Keys(d.classes)) {
+		members, err := d.classMembers(ctx, n, className)
+		if err != nil {
+			return err
+		}
+		for _, username := range slices.Sorted(maps.Keys(members)) {
+			if d.claimed[username] {
+				continue
+			}
+			f := Finding{Node: url, Side: SideController, Kind: "user", Class: className, Name: username}
+			prune := func(context.Context) error { return deleteAPIStudent(n.cfg, username) }
+			if owner, tracked := d.users[username]; tracked {
+				if owner[0] != className {
+					continue
+				}
+				idx := slices.IndexFunc(d.groups[className], func(g *doctorGroup) bool { return g.Name == owner[1] })
+				if d.groups[className][idx].NodeURL == url || d.groups[className][idx].NodeURL == "" {
+					continue
+				}
+				f.Problem = "copy of a student of " + owner[1] + " on " + d.groups[className][idx].NodeURL
+				d.prune(&f, "delete it", prune)
+				d.add(f)
+				continue
+			}
+			f.Problem = "no user row"
+			switch d.opts.Untracked {
+			case PolicyPrune:
+				f.Fix, f.repair = "delete it", prune
+			case PolicyAdopt:
+				group, err := d.groupOfUser(ctx, n, className, username)
+				if err != nil {
+					return err
+				}
+				if group == nil {
+					f.Problem += ", in no group of the class on this node"
+					break
+				}
+				user := n.users[username]
+				f.Fix = "add it to " + group.Name + " without an initial password"
+				f.repair = func(ctx context.Context) error {
+					_, err := d.store.CreateUserReturning(ctx, sqlc.CreateUserReturningParams{
+						GroupID: group.ID, Username: username, FullName: nullString(user.FullName),
+					})
+					return err
+				}
+			}
+			d.add(f)
+		}
+	}
+
+	d.checkProjects(n)
+	return nil
+}
+
+// checkProjects reports the projects shared with a group of a class or
+// named like one that no exercise row knows, and the pools of a class left
+// without a project.
+func (d *doctor) checkProjects(n *doctorNode) {
+	url := n.cfg.Server
+	owner := map[string]string{}
+	for _, pool := range n.pools {
+		group := ""
+		for _, ace := range n.aces {
+			if ace.Path == "/pools/"+pool.ResourcePoolID && ace.ACEType == "group" && d.classOf(n.groupNames[deref(ace.GroupID)]) != "" {
+				group = n.groupNames[deref(ace.GroupID)]
+				break
+			}
+		}
+		if group == "" {
+			continue
+		}
+		alive := 0
+		for _, id := range n.resources[pool.ResourcePoolID] {
+			if slices.ContainsFunc(n.projects, func(p schemas.ProjectResponse) bool { return p.ProjectID == id }) {
+				owner[id] = group
+				alive++
+			}
+		}
+		if alive == 0 {
+			f := Finding{Node: url, Side: SideController, Kind: "pool", Class: d.classOf(group), Name: pool.Name, Problem: "pool without a project"}
+			poolID := pool.ResourcePoolID
+			d.prune(&f, "delete it with its ACEs", func(ctx context.Context) error { return n.deletePool(ctx, poolID) })
+			d.add(f)
+		}
+	}
+
+	for _, p := range n.projects {
+		if d.templates[p.ProjectID] || d.tracked(p.Name) {
+			continue
+		}
+		m := projectUUIDSuffix.FindStringSubmatch(p.Name)
+		className := d.classOf(owner[p.ProjectID])
+		if className == "" && m != nil {
+			className = d.classOf(strings.TrimSuffix(p.Name, m[0]))
+		}
+		if className == "" {
+			continue
+		}
+		project := p
+		f := Finding{Node: url, Side: SideController, Kind: "project", Class: className, Name: p.Name, Problem: "no exercise row"}
+		switch d.opts.Untracked {
+		case PolicyPrune:
+			f.Fix = "delete it with its pool"
+			f.repair = func(ctx context.Context) error { return n.deleteProject(ctx, project) }
+		case PolicyAdopt:
+			exercise, uuid, format, group := d.parseProjectName(className, url, project.Name, owner[p.ProjectID])
+			if group == nil {
+				f.Problem += ", its name does not follow the name format of an exercise of the class for a group on this node"
+				break
+			}
+			f.Fix = fmt.Sprintf("add it as exercise %s of %s", exercise, group.Name)
+			if owner[p.ProjectID] == "" {
+				f.Fix += " and share it with the group"
+			}
+			f.repair = func(ctx context.Context) error {
+				if err := d.store.InsertExerciseRecord(ctx, sqlc.InsertExerciseRecordParams{
+					ProjectUuid: uuid,
+					GroupID:     group.ID,
+					Name:        exercise,
+					State:       sql.NullString{String: ExerciseCreated, Valid: true},
+					NameFormat:  sql.NullString{String: format, Valid: true},
+				}); err != nil {
+					return err
+				}
+				if n.poolOf(project.ProjectID) != nil {
+					return nil
+				}
+				return n.grantPool(ctx, project, "", group.Name)
+			}
+		}
+		d.add(f)
+	}
+}
+
+// tracked reports whether a project belongs to an exercise row.
+func (d *doctor) tracked(projectName string) bool {
+	return slices.ContainsFunc(d.exercises, func(match func(string) bool) bool { return match(projectName) })
+}
+
+// prune sets the fix of a controller finding that can only be pruned.
+func (d *doctor) prune(f *Finding, fix string, repair func(context.Context) error) {
+	if d.opts.Untracked == PolicyPrune {
+		f.Fix, f.repair = fix, repair
+	}
+}
+
+// classOf returns the class a user group belongs to by its name, the
+// class itself or a group of it, or "".
+func (d *doctor) classOf(groupName string) string {
+	best := ""
+	for name := range d.classes {
+		if (groupName == name || strings.HasPrefix(groupName, name+"-")) && len(name) > len(best) {
+			best = name
+		}
+	}
+	for name, groups := range d.groups {
+		if slices.ContainsFunc(groups, func(g *doctorGroup) bool { return g.Name == groupName }) {
+			return name
+		}
+	}
+	return best
+}
+
+// groupOfUser returns the group of a class on node n the user is a member of.
+func (d *doctor) groupOfUser(ctx context.Context, n *doctorNode, className, username string) (*doctorGroup, error) {
+	for _, g := range d.groups[className] {
+		if g.NodeURL != n.cfg.Server {
+			continue
+		}
+		members, err := n.members(ctx, g.Name)
+		if err != nil {
+			return nil, err
+		}
+		if members[username] {
+			return g, nil
+		}
+	}
+	return nil, nil
+}
+
+// parseProjectName matches a project name against the name formats of the
+// exercises of a class and returns the exercise, short uuid and format of
+// the first match for a group of the class on node url, preferring
+// ownerGroup.
+func (d *doctor) parseProjectName(className, url, name, ownerGroup string) (string, string, string, *doctorGroup) {
+	formats := []string{}
+	for _, g := range d.groups[className] {
+		for _, ex := range g.Exercises {
+			if ex.NameFormat.Valid && !slices.Contains(formats, ex.NameFormat.String) {
+				formats = append(formats, ex.NameFormat.String)
+			}
+		}
+	}
+	if !slices.Contains(formats, DefaultNameFormat) {
+		formats = append(formats, DefaultNameFormat)
+	}
+	groups := slices.Clone(d.groups[className])
+	slices.SortStableFunc(groups, func(a, b *doctorGroup) int {
+		return boolRank(b.Name == ownerGroup) - boolRank(a.Name == ownerGroup)
+	})
+	for _, g := range groups {
+		if g.NodeURL != url {
+			continue
+		}
+		for _, format := range formats {
+			if exercise, uuid, ok := ParseProjectName(format, className, GroupPart(g.Name, className), name); ok {
+				return exercise, uuid, format, g
+			}
+		}
+	}
+	return "", "", "", nil
+}
+
+// adoptGroup records a user group of a node as a group of a class with
+// members as its students.
+func (d *doctor) adoptGroup(ctx context.Context, className, name, url string, members []schemas.UserResponse) error {
+	nodeID, err := d.nodeID(url)
+	if err != nil {
+		return err
+	}
+	groupID, err := d.store.CreateGroupReturning(ctx, sqlc.CreateGroupReturningParams{ClassID: d.classes[className].ClassID, Name: name})
+	if err != nil {
+		return fmt.Errorf("failed to create group %s: %w", name, err)
+	}
+	if err := d.store.AssignGroupToNode(ctx, sqlc.AssignGroupToNodeParams{NodeID: nodeID, GroupID: groupID}); err != nil {
+		return fmt.Errorf("failed to assign group %s: %w", name, err)
+	}
+	for _, u := range members {
+		if _, err := d.store.CreateUserReturning(ctx, sqlc.CreateUserReturningParams{
+			GroupID: groupID, Username: u.Username, FullName: nullString(u.FullName),
+		}); err != nil {
+			return fmt.Errorf("failed to add user %s: %w", u.Username, err)
+		}
+	}
+	return nil
+}
+
+func (d *doctor) nodeID(url string) (int64, error) {
+	id, ok := d.nodeIDs[url]
+	if !ok {
+		return 0, fmt.Errorf("node %s not found", url)
+	}
+	return id, nil
+}
+
+// doctorNode holds what DiagnoseCluster reads from a controller.
+type doctorNode struct {
+	cfg        config.GlobalOptions
+	client     *sdk.Client
+	users      map[string]schemas.UserResponse
+	groups     map[string]schemas.UserGroupResponse
+	groupNames map[string]string
+	memberSets map[string]map[string]bool
+	projects   []schemas.ProjectResponse
+	pools      []schemas.ResourcePoolResponse
+	resources  map[string][]string
+	aces       []schemas.ACLResponse
+}
+
+func (n *doctorNode) load(ctx context.Context) error {
+	var err error
+	if n.client, err = utils.NewClient(n.cfg); err != nil {
+		return err
+	}
+	users, err := n.client.Users().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get users: %w", err)
+	}
+	n.users = map[string]schemas.UserResponse{}
+	for _, u := range users {
+		n.users[u.Username] = u
+	}
+	groups, err := n.client.Groups().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get groups: %w", err)
+	}
+	n.groups, n.groupNames, n.memberSets = map[string]schemas.UserGroupResponse{}, map[string]string{}, map[string]map[string]bool{}
+	for _, g := range groups {
+		n.groups[g.Name] = g
+		n.groupNames[g.UserGroupID.String()] = g.Name
+	}
+	if n.projects, err = n.client.Projects().List(ctx); err != nil {
+		return fmt.Errorf("failed to get projects: %w", err)
+	}
+	if n.pools, err = n.client.Pools().List(ctx); err != nil {
+		return fmt.Errorf("failed to get pools: %w", err)
+	}
+	n.resources = map[string][]string{}
+	for _, p := range n.pools {
+		resources, err := n.client.Pools().Resources(ctx, p.ResourcePoolID)
+		if err != nil {
+			return fmt.Errorf("failed to get the resources of pool %s: %w", p.Name, err)
+		}
+		for _, r := range resources {
+			n.resources[p.ResourcePoolID] = append(n.resources[p.ResourcePoolID], r.ResourceID)
+		}
+	}
+	if n.aces, err = n.client.ACL().List(ctx); err != nil {
+		return fmt.Errorf("failed to get ACLs: %w", err)
+	}
+	return nil
+}
+
+// classMembers returns the members of the user group of a class on a node
+// and of the groups of the class assigned to it.
+func (d *doctor) classMembers(ctx context.Context, n *doctorNode, className string) (map[string]bool, error) {
+	names := []string{className}
+	for _, g := range d.groups[className] {
+		if g.NodeURL == n.cfg.Server {
+			names = append(names, g.Name)
+		}
+	}
+	members := map[string]bool{}
+	for _, name := range names {
+		m, err := n.members(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(members, m)
+	}
+	return members, nil
+}
+
+// members returns the usernames in a user group, nil if it does not exist.
+func (n *doctorNode) members(ctx context.Context, groupName string) (map[string]bool, error) {
+	group, ok := n.groups[groupName]
+	if !ok {
+		return nil, nil
+	}
+	if members, ok := n.memberSets[groupName]; ok {
+		return members, nil
+	}
+	users, err := n.client.Groups().Members(ctx, group.UserGroupID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the members of %s on %s: %w", groupName, n.cfg.Server, err)
+	}
+	members := map[string]bool{}
+	for _, u := range users {
+		members[u.Username] = true
+	}
+	n.memberSets[groupName] = members
+	return members, nil
+}
+
+func (n *doctorNode) project(match func(name string) bool) *schemas.ProjectResponse {
+	idx := slices.IndexFunc(n.projects, func(p schemas.ProjectResponse) bool { return match(p.Name) })
+	if idx < 0 {
+		return nil
+	}
+	return &n.projects[idx]
+}
+
+func (n *doctorNode) poolOf(projectID string) *schemas.ResourcePoolResponse {
+	for i, p := range n.pools {
+		if slices.Contains(n.resources[p.ResourcePoolID], projectID) {
+			return &n.pools[i]
+		}
+	}
+	return nil
+}
+
+func (n *doctorNode) hasGroupACE(poolID, groupID string) bool {
+	return slices.ContainsFunc(n.aces, func(a schemas.ACLResponse) bool {
+		return a.Path == "/pools/"+poolID && a.ACEType == "group" && deref(a.GroupID) == groupID
+	})
+}
+
+// grantPool gives a group access to a project like exercise create, in the
+// pool poolID or a new <project>-pool when poolID is empty.
+func (n *doctorNode) grantPool(ctx context.Context, project schemas.ProjectResponse, poolID, groupName string) error {
+	if poolID == "" {
+		pool, err := n.client.Pools().Create(ctx, project.Name+"-pool")
+		if err != nil {
+			return fmt.Errorf("failed to create pool: %w", err)
+		}
+		if err := n.client.Pools().AddResource(ctx, pool.ResourcePoolID, project.ProjectID); err != nil {
+			return fmt.Errorf("failed to add the project to the pool: %w", err)
+		}
+		poolID = pool.ResourcePoolID
+	}
+	groupID, err := findAPIGroup(n.cfg, groupName)
+	if err != nil {
+		return err
+	}
+	if groupID == "" {
+		return fmt.Errorf("group %s not found on %s", groupName, n.cfg.Server)
+	}
+	role, err := n.client.Roles().ByName(ctx, "User")
+	if err != nil {
+		return fmt.Errorf("failed to get the User role: %w", err)
+	}
+	aceType, path, yes := "group", "/pools/"+poolID, true
+	if _, err := n.client.ACL().Create(ctx, schemas.ACECreate{
+		ACEType: &aceType, Path: &path, Propagate: &yes, Allowed: &yes, GroupID: &groupID, RoleID: &role.RoleID,
+	}); err != nil {
+		return fmt.Errorf("failed to create ACE: %w", err)
+	}
+	return nil
+}
+
+func (n *doctorNode) deletePool(ctx context.Context, poolID string) error {
+	for _, ace := range n.aces {
+		if ace.Path == "/pools/"+poolID {
+			if err := n.client.ACL().Delete(ctx, ace.ACLID); err != nil {
+				return fmt.Errorf("failed to delete ACE %s: %w", ace.ACLID, err)
+			}
+		}
+	}
+	if err := n.client.Pools().Delete(ctx, poolID); err != nil {
+		return fmt.Errorf("failed to delete pool: %w", err)
+	}
+	return nil
+}
+
+func (n *doctorNode) deleteProject(ctx context.Context, project schemas.ProjectResponse) error {
+	for _, p := range n.pools {
+		if slices.Contains(n.resources[p.ResourcePoolID], project.ProjectID) {
+			if err := n.deletePool(ctx, p.ResourcePoolID); err != nil {
+				return err
+			}
+		}
+	}
+	_ = n.client.Projects().Close(ctx, project.ProjectID)
+	if err := n.client.Projects().Delete(ctx, project.ProjectID); err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+	return nil
+}
+
+func nullString(s *string) sql.NullString {
+	if s == nil || *s == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
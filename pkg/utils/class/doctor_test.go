@@ -0,0 +1,180 @@
+package class
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/authentication"
+)
+
+func diagnose(t *testing.T, tc *testutil.Cluster, missing, untracked string) []Finding {
+	t.Helper()
+	findings, err := DiagnoseCluster(context.Background(), tc.Cfg, DoctorOptions{
+		ClusterID: tc.ClusterID,
+		Missing:   missing,
+		Untracked: untracked,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return findings
+}
+
+func TestDoctorFindsNoDriftAfterCreate(t *testing.T) {
+	tc := testutil.NewCluster(t)
+	createClass(t, tc, testClass())
+	if findings := diagnose(t, tc, PolicyRecreate, PolicyKeep); len(findings) != 0 {
+		t.Errorf("drift right after create: %+v", findings)
+	}
+
+	for _, opts := range []DoctorOptions{
+		{ClusterID: tc.ClusterID, Missing: "ignore", Untracked: PolicyKeep},
+		{ClusterID: tc.ClusterID, Missing: PolicyPrune, Untracked: PolicyRecreate},
+	} {
+		if _, err := DiagnoseCluster(context.Background(), tc.Cfg, opts); err == nil {
+			t.Errorf("diagnose with policies %s and %s succeeded", opts.Missing, opts.Untracked)
+		}
+	}
+}
+
+func TestDoctorRepairs(t *testing.T) {
+	tests := []struct {
+		name      string
+		missing   string
+		untracked string
+		drift     func(t *testing.T, tc *testutil.Cluster)
+		kinds     []string
+		check     func(t *testing.T, tc *testutil.Cluster)
+	}{
+		{
+			name:    "deleted student is recreated",
+			missing: PolicyRecreate,
+			drift: func(t *testing.T, tc *testutil.Cluster) {
+				u, _ := tc.User(t, "bob")
+				if err := tc.Client.Users().Delete(context.Background(), u.UserID.String()); err != nil {
+					t.Fatal(err)
+				}
+			},
+			kinds: []string{"user"},
+			check: func(t *testing.T, tc *testutil.Cluster) {
+				if _, ok := tc.User(t, "bob"); !ok {
+					t.Error("bob was not recreated")
+				}
+				if _, err := authentication.Login(context.Background(), tc.Cfg, "bob", "bob-pass1"); err != nil {
+					t.Errorf("bob cannot log in with the initial password: %v", err)
+				}
+			},
+		},
+		{
+			name:    "deleted student is pruned",
+			missing: PolicyPrune,
+			drift: func(t *testing.T, tc *testutil.Cluster) {
+				u, _ := tc.User(t, "carol")
+				if err := tc.Client.Users().Delete(context.Background(), u.UserID.String()); err != nil {
+					t.Fatal(err)
+				}
+			},
+			kinds: []string{"user"},
+			check: func(t *testing.T, tc *testutil.Cluster) {
+				creds, err := GetClassCredentials(context.Background(), tc.ClusterID, "NET")
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, c := range creds {
+					if c.Username == "carol" {
+						t.Error("the row of carol was not deleted")
+					}
+				}
+			},
+		},
+		{
+			name:    "lost membership is restored",
+			missing: PolicyPrune,
+			drift: func(t *testing.T, tc *testutil.Cluster) {
+				ctx := context.Background()
+				u, _ := tc.User(t, "alice")
+				groups, err := tc.Client.Users().Groups(ctx, u.UserID.String())
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, g := range groups {
+					if g.Name == "NET-g1" {
+						if err := tc.Client.Groups().RemoveMember(ctx, g.UserGroupID.String(), u.UserID.String()); err != nil {
+							t.Fatal(err)
+						}
+					}
+				}
+			},
+			kinds: []string{"membership"},
+			check: func(t *testing.T, tc *testutil.Cluster) {
+				if got := tc.GroupMembers(t)["NET-g1"]; !slices.Equal(got, []string{"alice", "bob"}) {
+					t.Errorf("members of NET-g1: %v, want alice and bob", got)
+				}
+			},
+		},
+		{
+			name:      "untracked student is pruned",
+			missing:   PolicyRecreate,
+			untracked: PolicyPrune,
+			drift: func(t *testing.T, tc *testutil.Cluster) {
+				ctx := context.Background()
+				name, password := "mallory", "mallory-pass1"
+				u, err := tc.Client.Users().Create(ctx, schemas.UserCreate{Username: &name, Password: &password, IsActive: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				groups, err := tc.Client.Groups().List(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, g := range groups {
+					if g.Name == "NET-g2" {
+						if err := tc.Client.Groups().AddMember(ctx, g.UserGroupID.String(), u.UserID.String()); err != nil {
+							t.Fatal(err)
+						}
+					}
+				}
+			},
+			kinds: []string{"user"},
+			check: func(t *testing.T, tc *testutil.Cluster) {
+				if _, ok := tc.User(t, "mallory"); ok {
+					t.Error("mallory is still on the node")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.untracked == "" {
+				tt.untracked = PolicyKeep
+			}
+			tc := testutil.NewCluster(t)
+			createClass(t, tc, testClass())
+			tt.drift(t, tc)
+
+			findings := diagnose(t, tc, tt.missing, tt.untracked)
+			var kinds []string
+			for _, f := range findings {
+				kinds = append(kinds, f.Kind)
+			}
+			if !slices.Equal(kinds, tt.kinds) {
+				t.Fatalf("kinds of findings %v, want %v: %+v", kinds, tt.kinds, findings)
+			}
+			if err := RepairFindings(context.Background(), findings); err != nil {
+				t.Fatalf("repair: %v", err)
+			}
+			for _, f := range findings {
+				if !f.Fixed {
+					t.Errorf("not fixed: %+v", f)
+				}
+			}
+			tt.check(t, tc)
+			if findings := diagnose(t, tc, tt.missing, tt.untracked); len(findings) != 0 {
+				t.Errorf("drift after repair: %+v", findings)
+			}
+		})
+	}
+}
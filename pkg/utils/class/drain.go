@@ -0,0 +1,594 @@
+package class
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/colorUtils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+// DrainPlan holds the groups DrainNode moves off a node. Groups with a
+// running exercise project are only moved with Force, which stops its nodes.
+type DrainPlan struct {
+	ClusterID int
+	NodeID    int64
+	NodeURL   string
+	Moves     []GroupMove
+	Force     bool
+}
+
+// GroupMove is a group on the drained node with its students and exercise
+// projects and the node it moves to.
+type GroupMove struct {
+	ClassName string
+	Group     PlannedGroup
+	ToNodeID  int64
+	ToNodeURL string
+	Students  []PlannedStudent
+}
+
+// PlanNodeDrain assigns the groups of a node to the other nodes of its
+// cluster that are not cordoned, placed by scheduler within their
+// max_groups, or all to toNodeID if it is not 0.
+func PlanNodeDrain(ctx context.Context, clusterID int, nodeID, toNodeID int64, scheduler Scheduler) (*DrainPlan, error) {
+	store, err := db.Init()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init db: %w", err)
+	}
+	nodes, nodeURLs, err := clusterNodes(ctx, store, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := nodeURLs[nodeID]; !ok {
+		return nil, fmt.Errorf("node %d is not in the cluster", nodeID)
+	}
+	plan := &DrainPlan{ClusterID: clusterID, NodeID: nodeID, NodeURL: nodeURLs[nodeID]}
+
+	groups, err := store.GetGroupsOnNode(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the groups of %s: %w", plan.NodeURL, err)
+	}
+	if len(groups) == 0 {
+		return plan, nil
+	}
+	nodes = slices.DeleteFunc(nodes, func(n db.NodeDataAll) bool { return int64(n.ID) == nodeID })
+
+	var targets []int64
+	if toNodeID != 0 {
+		switch {
+		case toNodeID == nodeID:
+			return nil, fmt.Errorf("can not drain %s onto itself", plan.NodeURL)
+		case nodeURLs[toNodeID] == "":
+			return nil, fmt.Errorf("node %d is not in the cluster", toNodeID)
+		case !slices.ContainsFunc(nodes, func(n db.NodeDataAll) bool { return int64(n.ID) == toNodeID }):
+			return nil, fmt.Errorf("node %s is cordoned", nodeURLs[toNodeID])
+		}
+		for range groups {
+			targets = append(targets, toNodeID)
+		}
+	} else {
+		assigned, err := store.GetNodeGroupAssignments(ctx, int64(clusterID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node group assignments: %w", err)
+		}
+		used := map[int]int{}
+		for _, a := range assigned {
+			used[int(a.NodeID)] = int(a.Count)
+		}
+		available := make([]db.NodeDataAll, 0, len(nodes))
+		for _, n := range nodes {
+			n.MaxGroups = max(0, n.MaxGroups-used[n.ID])
+			available = append(available, n)
+		}
+		dist, err := scheduler.Place(ctx, available, len(groups), true)
+		if err != nil {
+			return nil, fmt.Errorf("the other nodes have no room for %d groups, raise their max_groups, add a node or pick one with --to: %w", len(groups), err)
+		}
+		for _, d := range dist {
+			for range d.NumGroups {
+				targets = append(targets, int64(d.NodeID))
+			}
+		}
+	}
+	if len(targets) < len(groups) {
+		return nil, fmt.Errorf("the cluster has no other node for the groups of %s", plan.NodeURL)
+	}
+
+	members := map[string][]sqlc.GetClassMembersRow{}
+	for i, g := range groups {
+		exercises, err := store.GetExercisesForGroup(ctx, g.GroupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the exercises of group %s: %w", g.Name, err)
+		}
+		if _, ok := members[g.ClassName]; !ok {
+			members[g.ClassName], err = store.GetClassMembers(ctx, sqlc.GetClassMembersParams{ClusterID: int64(clusterID), Name: g.ClassName})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the members of class %s: %w", messageUtils.Bold(g.ClassName), err)
+			}
+		}
+		move := GroupMove{
+			ClassName: g.ClassName,
+			Group:     PlannedGroup{Name: g.Name, GroupID: g.GroupID, NodeID: nodeID, NodeURL: plan.NodeURL, Exercises: exercises},
+			ToNodeID:  targets[i],
+			ToNodeURL: nodeURLs[targets[i]],
+		}
+		for _, row := range members[g.ClassName] {
+			if row.GroupID != g.GroupID || !row.Username.Valid {
+				continue
+			}
+			move.Students = append(move.Students, PlannedStudent{
+				Username:    row.Username.String,
+				FullName:    row.FullName.String,
+				Password:    row.DefaultPassword.String,
+				UserID:      row.UserID.Int64,
+				Group:       g.Name,
+				NodeURL:     move.ToNodeURL,
+				FromGroup:   g.Name,
+				FromNodeURL: plan.NodeURL,
+			})
+		}
+		plan.Moves = append(plan.Moves, move)
+	}
+	return plan, nil
+}
+
+// Print lists the groups of the plan and where they go.
+func (p *DrainPlan) Print() {
+	fmt.Printf("Plan for draining %s:\n", messageUtils.Bold(p.NodeURL))
+	for _, m := range p.Moves {
+		projects := ""
+		if len(m.Group.Exercises) > 0 {
+			projects = fmt.Sprintf(" with %d exercise project(s)", len(m.Group.Exercises))
+		}
+		fmt.Printf("  %s group %s/%s%s to %s\n", colorUtils.Warning("~"), m.ClassName, messageUtils.Bold(m.Group.Name), projects, m.ToNodeURL)
+	}
+	if len(p.Moves) > 0 {
+		fmt.Println(messageUtils.WarningMsg("the students are recreated on the new nodes with their initial password"))
+	}
+}
+
+// DrainNode moves the groups of a plan one after another. The students and
+// the exercise projects with their snapshots, pools and ACLs are created
+// on the target node and removed from the drained node once the group is
+// reassigned. A group that fails to move stays on the drained node.
+func DrainNode(ctx context.Context, cfg config.GlobalOptions, plan *DrainPlan) error {
+	store, err := db.Init()
+	if err != nil {
+		return fmt.Errorf("failed to init db: %w", err)
+	}
+	templates := map[string]string{}
+	moved := map[string]bool{}
+	failed := 0
+	for _, m := range plan.Moves {
+		if err := moveGroup(ctx, cfg, store, m, plan.Force, templates); err != nil {
+			fmt.Printf("%v to move group %s to %s: %v\n", messageUtils.ErrorMsg("Failed"),
+				messageUtils.Bold(m.Group.Name), m.ToNodeURL, err)
+			failed++
+			continue
+		}
+		moved[m.ClassName] = true
+		fmt.Printf("%v group %s from %s to %s\n", messageUtils.SuccessMsg("Moved"),
+			messageUtils.Bold(m.Group.Name), plan.NodeURL, messageUtils.Highlight(m.ToNodeURL))
+	}
+	for _, className := range slices.Sorted(func(yield func(string) bool) {
+		for name := range moved {
+			if !yield(name) {
+				return
+			}
+		}
+	}) {
+		classPlan := &ApplyPlan{ClusterID: plan.ClusterID, ClassName: className}
+		if err := removeEmptyClassGroups(ctx, store, cfg, classPlan, map[string]bool{plan.NodeURL: true}); err != nil {
+			fmt.Printf("%v %v\n", messageUtils.WarningMsg("Warning"), err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d groups failed to move", failed, len(plan.Moves))
+	}
+	return nil
+}
+
+// moveGroup copies a group to its target node, reassigns it and removes it
+// from the drained node. A group with running exercise projects is refused
+// unless force is set, which stops their nodes first. templates caches the
+// template projects copied to a node by "url/source id".
+func moveGroup(ctx context.Context, cfg config.GlobalOptions, store *db.Store, m GroupMove, force bool, templates map[string]string) error {
+	srcCfg, dstCfg := cfg, cfg
+	srcCfg.Server, dstCfg.Server = m.Group.NodeURL, m.ToNodeURL
+	src, err := utils.NewClient(srcCfg)
+	if err != nil {
+		return err
+	}
+	dst, err := utils.NewClient(dstCfg)
+	if err != nil {
+		return err
+	}
+
+	srcProjects, err := src.Projects().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the projects of %s: %w", srcCfg.Server, err)
+	}
+	projects := make([]int, len(m.Group.Exercises))
+	for i, ex := range m.Group.Exercises {
+		projects[i] = findExerciseProject(srcProjects, ex.NameFormat, m.ClassName, m.Group.Name, ex.ProjectUuid)
+		if projects[i] < 0 {
+			continue
+		}
+		if err := stopRunningProject(ctx, src, srcProjects[projects[i]], force); err != nil {
+			return err
+		}
+	}
+
+	// the students come first, the ACLs of the projects refer to their group
+	srcUsers, err := src.Users().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the users of %s: %w", srcCfg.Server, err)
+	}
+	emails := map[string]string{}
+	for _, u := range srcUsers {
+		if u.Email != nil {
+			emails[u.Username] = *u.Email
+		}
+	}
+	dstUsers, err := apiUsers(dstCfg)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{m.ClassName, m.Group.Name} {
+		if _, err := ensureAPIGroup(dstCfg, name); err != nil {
+			return err
+		}
+	}
+	for _, s := range m.Students {
+		s.Email = emails[s.Username]
+		if userID, ok := dstUsers[s.Username]; ok {
+			member, err := apiMemberships(dstCfg, userID)
+			if err != nil {
+				return err
+			}
+			if err := joinAPIGroups(dstCfg, userID, s.Username, member, m.ClassName, m.Group.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := createAPIStudent(dstCfg, m.ClassName, s); err != nil {
+			return err
+		}
+	}
+
+	acl, err := newACLCopier(ctx, src, dst)
+	if err != nil {
+		return err
+	}
+	dstProjects, err := dst.Projects().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the projects of %s: %w", dstCfg.Server, err)
+	}
+	for i, ex := range m.Group.Exercises {
+		idx := projects[i]
+		if idx < 0 {
+			fmt.Printf("%v project of exercise %s for %s not found on %s\n",
+				messageUtils.WarningMsg("Warning"), ex.Name, m.Group.Name, srcCfg.Server)
+			continue
+		}
+		project := srcProjects[idx]
+		baseline, err := copyProject(ctx, srcCfg, src, dst, project, dstProjects, acl)
+		if err != nil {
+			return fmt.Errorf("project %s: %w", project.Name, err)
+		}
+
+		if baseline == "" && ex.BaselineSnapshotID.Valid {
+			fmt.Printf("%v the baseline snapshot of %s is gone after the import, exercise reset can only use the template\n",
+				messageUtils.WarningMsg("Warning"), messageUtils.Bold(project.Name))
+		}
+
+		templateRef := ex.TemplateProjectID
+		if templateRef.Valid {
+			id, err := copyTemplate(ctx, src, dst, dstCfg.Server, templateRef.String, templates)
+			if err != nil {
+				fmt.Printf("%v %v, exercise reset of %s can only use the baseline snapshot\n",
+					messageUtils.WarningMsg("Warning"), err, messageUtils.Bold(project.Name))
+			} else {
+				templateRef.String = id
+			}
+		}
+		if ex.State.String == ExerciseRunning {
+			if err := dst.Projects().Open(ctx, project.ProjectID); err == nil {
+				err = projectCall(dstCfg, "startAllNodes", project.ProjectID)
+			}
+			if err != nil {
+				fmt.Printf("%v failed to start %s again on %s: %v\n",
+					messageUtils.WarningMsg("Warning"), messageUtils.Bold(project.Name), dstCfg.Server, err)
+			}
+		}
+		if err := store.UpdateExerciseProjectRefs(ctx, sqlc.UpdateExerciseProjectRefsParams{
+			BaselineSnapshotID: sql.NullString{String: baseline, Valid: baseline != ""},
+			TemplateProjectID:  templateRef,
+			ExerciseID:         ex.ExerciseID,
+		}); err != nil {
+			return fmt.Errorf("failed to update exercise %s: %w", ex.ProjectUuid, err)
+		}
+	}
+
+	if err := store.UpdateGroupAssignment(ctx, sqlc.UpdateGroupAssignmentParams{NodeID: m.ToNodeID, GroupID: m.Group.GroupID}); err != nil {
+		return fmt.Errorf("failed to reassign group %s: %w", m.Group.Name, err)
+	}
+
+	// the group lives on the target now, failures below only leave leftovers
+	if err := removeGroupProjects(ctx, srcCfg, m.ClassName, m.Group, ""); err != nil {
+		fmt.Printf("%v %v\n", messageUtils.WarningMsg("Warning"), err)
+	}
+	for _, s := range m.Students {
+		if err := deleteAPIStudent(srcCfg, s.Username); err != nil {
+			fmt.Printf("%v %v\n", messageUtils.WarningMsg("Warning"), err)
+		}
+	}
+	if groupID, err := findAPIGroup(srcCfg, m.Group.Name); err == nil && groupID != "" {
+		if err := deleteGroup(srcCfg, groupID); err != nil {
+			fmt.Printf("%v failed to delete group %s on %s: %v\n", messageUtils.WarningMsg("Warning"), m.Group.Name, srcCfg.Server, err)
+		}
+	}
+	return nil
+}
+
+// stopRunningProject stops the nodes of an opened project when force is set
+// and refuses it otherwise, a running project can not be exported safely.
+func stopRunningProject(ctx context.Context, src *sdk.Client, project schemas.ProjectResponse, force bool) error {
+	if project.Status == nil || *project.Status != "opened" {
+		return nil
+	}
+	nodes, err := src.Nodes(project.ProjectID).List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the nodes of %s: %w", project.Name, err)
+	}
+	if !slices.ContainsFunc(nodes, func(n schemas.NodeResponse) bool { return n.Status != "stopped" }) {
+		return nil
+	}
+	if !force {
+		return fmt.Errorf("project %s is running, stop it with exercise stop or drain with --force", project.Name)
+	}
+	if err := src.Nodes(project.ProjectID).StopAll(ctx); err != nil {
+		return fmt.Errorf("failed to stop the nodes of %s: %w", project.Name, err)
+	}
+	fmt.Printf("%v the nodes of %s\n", messageUtils.SuccessMsg("Stopped"), messageUtils.Bold(project.Name))
+	return nil
+}
+
+// copyProject imports a project with its snapshots on dst under the same id
+// and name, unless an earlier drain already did, adds it to the pools it is
+// in on src with their ACLs and returns the id of its baseline snapshot.
+func copyProject(ctx context.Context, srcCfg config.GlobalOptions, src, dst *sdk.Client, project schemas.ProjectResponse, dstProjects []schemas.ProjectResponse, acl *aclCopier) (string, error) {
+	if !slices.ContainsFunc(dstProjects, func(p schemas.ProjectResponse) bool { return p.ProjectID == project.ProjectID }) {
+		if project.Status != nil && *project.Status == "opened" {
+			if err := src.Projects().Close(ctx, project.ProjectID); err != nil {
+				return "", fmt.Errorf("failed to close: %w", err)
+			}
+		}
+		if _, err := transferProject(ctx, src, dst, project.ProjectID, project.ProjectID, project.Name, sdk.ExportOptions{IncludeSnapshots: true}); err != nil {
+			return "", err
+		}
+	}
+
+	pools, err := getPoolsForProject(srcCfg, project.ProjectID, project.Name, "", "")
+	if err != nil {
+		return "", err
+	}
+	if len(pools) == 0 {
+		fmt.Printf("%v %s is in no pool, no group gets access to it on the new node\n",
+			messageUtils.WarningMsg("Warning"), messageUtils.Bold(project.Name))
+	}
+	dstPools, err := dst.Pools().List(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get pools: %w", err)
+	}
+	for _, pool := range pools {
+		var dstPool schemas.ResourcePoolResponse
+		if idx := slices.IndexFunc(dstPools, func(p schemas.ResourcePoolResponse) bool { return p.Name == pool.Name }); idx >= 0 {
+			dstPool = dstPools[idx]
+		} else if dstPool, err = dst.Pools().Create(ctx, pool.Name); err != nil {
+			return "", fmt.Errorf("failed to create pool %s: %w", pool.Name, err)
+		}
+		resources, err := dst.Pools().Resources(ctx, dstPool.ResourcePoolID)
+		if err != nil {
+			return "", fmt.Errorf("failed to get the resources of pool %s: %w", pool.Name, err)
+		}
+		if !slices.ContainsFunc(resources, func(r schemas.PoolResourceResponse) bool { return r.ResourceID == project.ProjectID }) {
+			if err := dst.Pools().AddResource(ctx, dstPool.ResourcePoolID, project.ProjectID); err != nil {
+				return "", fmt.Errorf("failed to add the project to pool %s: %w", pool.Name, err)
+			}
+		}
+		if err := acl.copy(ctx, "/pools/"+pool.ResourcePoolID, "/pools/"+dstPool.ResourcePoolID); err != nil {
+			return "", err
+		}
+	}
+
+	snapshots, err := dst.Snapshots(project.ProjectID).List(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get snapshots: %w", err)
+	}
+	if err := dst.Projects().Close(ctx, project.ProjectID); err != nil {
+		fmt.Printf("%v failed to close %s on the new node: %v\n",
+			messageUtils.WarningMsg("Warning"), messageUtils.Bold(project.Name), err)
+	}
+	for _, s := range snapshots {
+		if s.Name == BaselineSnapshot {
+			return s.SnapshotID, nil
+		}
+	}
+	return "", nil
+}
+
+// transferProject exports project srcID of src to a temporary file and
+// imports it from there on dst as dstID with name, so the archive is never
+// held in memory.
+func transferProject(ctx context.Context, src, dst *sdk.Client, srcID, dstID, name string, opts sdk.ExportOptions) (schemas.ProjectResponse, error) {
+	tmp, err := os.CreateTemp("", "gns3util-drain-*.gns3project")
+	if err != nil {
+		return schemas.ProjectResponse{}, err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	_, err = src.Projects().ExportTo(ctx, srcID, opts, tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return schemas.ProjectResponse{}, fmt.Errorf("failed to export: %w", err)
+	}
+	imported, err := dst.Projects().ImportFile(ctx, dstID, name, tmp.Name())
+	if err != nil {
+		return schemas.ProjectResponse{}, fmt.Errorf("failed to import: %w", err)
+	}
+	return imported, nil
+}
+
+// copyTemplate returns the id of the template project srcID of src on dst,
+// copying it when dst has no project of the same name.
+func copyTemplate(ctx context.Context, src, dst *sdk.Client, dstURL, srcID string, copied map[string]string) (string, error) {
+	key := dstURL + "/" + srcID
+	if id, ok := copied[key]; ok {
+		return id, nil
+	}
+	template, err := src.Projects().Get(ctx, srcID)
+	if err != nil {
+		return "", fmt.Errorf("template project %s is gone", srcID)
+	}
+	dstProjects, err := dst.Projects().List(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get projects: %w", err)
+	}
+	if idx := slices.IndexFunc(dstProjects, func(p schemas.ProjectResponse) bool { return p.Name == template.Name }); idx >= 0 {
+		copied[key] = dstProjects[idx].ProjectID
+		return copied[key], nil
+	}
+	// the template is exported as it is, it may be open for someone else
+	imported, err := transferProject(ctx, src, dst, srcID, uuid.New().String(), template.Name, sdk.ExportOptions{})
+	if err != nil {
+		return "", fmt.Errorf("template %s: %w", template.Name, err)
+	}
+	fmt.Printf("%v template project %s to %s\n", messageUtils.SuccessMsg("Copied"), messageUtils.Bold(template.Name), dstURL)
+	copied[key] = imported.ProjectID
+	return imported.ProjectID, nil
+}
+
+// aclCopier recreates the ACEs of one node on another, with users, groups
+// and roles mapped by name.
+type aclCopier struct {
+	src, dst             *sdk.Client
+	aces                 []schemas.ACLResponse
+	users, groups, roles map[string]string
+}
+
+func newACLCopier(ctx context.Context, src, dst *sdk.Client) (*aclCopier, error) {
+	c := &aclCopier{src: src, dst: dst, users: map[string]string{}, groups: map[string]string{}, roles: map[string]string{}}
+	var err error
+	if c.aces, err = src.ACL().List(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get ACLs: %w", err)
+	}
+
+	srcUsers, err := src.Users().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	dstUsers, err := dst.Users().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	for _, su := range srcUsers {
+		if idx := slices.IndexFunc(dstUsers, func(u schemas.UserResponse) bool { return u.Username == su.Username }); idx >= 0 {
+			c.users[su.UserID.String()] = dstUsers[idx].UserID.String()
+		}
+	}
+
+	srcGroups, err := src.Groups().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	dstGroups, err := dst.Groups().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get groups: %w", err)
+	}
+	for _, sg := range srcGroups {
+		if idx := slices.IndexFunc(dstGroups, func(g schemas.UserGroupResponse) bool { return g.Name == sg.Name }); idx >= 0 {
+			c.groups[sg.UserGroupID.String()] = dstGroups[idx].UserGroupID.String()
+		}
+	}
+
+	srcRoles, err := src.Roles().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	dstRoles, err := dst.Roles().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	for _, sr := range srcRoles {
+		if idx := slices.IndexFunc(dstRoles, func(r schemas.RoleResponse) bool { return r.Name == sr.Name }); idx >= 0 {
+			c.roles[sr.RoleID] = dstRoles[idx].RoleID
+		}
+	}
+	return c, nil
+}
+
+// copy creates the ACEs of srcPath on dstPath that are not there yet.
+func (c *aclCopier) copy(ctx context.Context, srcPath, dstPath string) error {
+	existing, err := c.dst.ACL().List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get ACLs: %w", err)
+	}
+	for _, ace := range c.aces {
+		if ace.Path != srcPath {
+			continue
+		}
+		roleID, ok := c.roles[deref(ace.RoleID)]
+		if !ok {
+			fmt.Printf("%v role of ACE %s not found on the new node, skipping it\n", messageUtils.WarningMsg("Warning"), ace.ACLID)
+			continue
+		}
+		create := schemas.ACECreate{ACEType: &ace.ACEType, Path: &dstPath, Propagate: &ace.Propagate, Allowed: &ace.Allowed, RoleID: &roleID}
+		var userID, groupID string
+		switch ace.ACEType {
+		case "user":
+			if userID, ok = c.users[deref(ace.UserID)]; !ok {
+				fmt.Printf("%v user of ACE %s not found on the new node, skipping it\n", messageUtils.WarningMsg("Warning"), ace.ACLID)
+				continue
+			}
+			create.UserID = &userID
+		case "group":
+			if groupID, ok = c.groups[deref(ace.GroupID)]; !ok {
+				fmt.Printf("%v group of ACE %s not found on the new node, skipping it\n", messageUtils.WarningMsg("Warning"), ace.ACLID)
+				continue
+			}
+			create.GroupID = &groupID
+		}
+		if slices.ContainsFunc(existing, func(e schemas.ACLResponse) bool {
+			return e.Path == dstPath && e.ACEType == ace.ACEType && deref(e.RoleID) == roleID &&
+				deref(e.UserID) == userID && deref(e.GroupID) == groupID
+		}) {
+			continue
+		}
+		if _, err := c.dst.ACL().Create(ctx, create); err != nil {
+			return fmt.Errorf("failed to create ACL on %s: %w", dstPath, err)
+		}
+	}
+	return nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,194 @@
+package class
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of WriteClassFile.
+const (
+	ClassFileJSON = "json"
+	ClassFileYAML = "yaml"
+)
+
+var ClassFileFormats = []string{ClassFileJSON, ClassFileYAML}
+
+// ExportClass rebuilds the class file of a class from the cluster
+// database. The nodes are checked for the students and their groups, the
+// returned warnings list what differs there. Emails are only kept by the
+// controller and taken from it. Passwords are left empty unless
+// withPasswords is set.
+func ExportClass(ctx context.Context, cfg config.GlobalOptions, clusterID int, className string, withPasswords bool) (schemas.Class, []string, error) {
+	store, err := db.Init()
+	if err != nil {
+		return schemas.Class{}, nil, fmt.Errorf("failed to init db: %w", err)
+	}
+	cls, err := store.GetClassByName(ctx, sqlc.GetClassByNameParams{ClusterID: int64(clusterID), Name: className})
+	if errors.Is(err, sql.ErrNoRows) {
+		return schemas.Class{}, nil, fmt.Errorf("%w: %s", ErrClassNotFound, className)
+	}
+	if err != nil {
+		return schemas.Class{}, nil, fmt.Errorf("failed to get class %s: %w", messageUtils.Bold(className), err)
+	}
+	_, nodeURLs, err := clusterNodes(ctx, store, clusterID)
+	if err != nil {
+		return schemas.Class{}, nil, err
+	}
+	rows, err := store.GetClassMembers(ctx, sqlc.GetClassMembersParams{ClusterID: int64(clusterID), Name: className})
+	if err != nil {
+		return schemas.Class{}, nil, fmt.Errorf("failed to get the members of class %s: %w", messageUtils.Bold(className), err)
+	}
+
+	classData := schemas.Class{Name: cls.Name, Desc: cls.Description.String, Groups: []schemas.Group{}}
+	groupIndex := map[string]int{}
+	groupsByNode := map[string][]string{}
+	for _, row := range rows {
+		i, ok := groupIndex[row.GroupName]
+		if !ok {
+			i = len(classData.Groups)
+			groupIndex[row.GroupName] = i
+			classData.Groups = append(classData.Groups, schemas.Group{Name: row.GroupName, Students: []schemas.Student{}})
+			url := nodeURLs[row.NodeID.Int64]
+			groupsByNode[url] = append(groupsByNode[url], row.GroupName)
+		}
+		if !row.Username.Valid {
+			continue
+		}
+		s := schemas.Student{UserName: row.Username.String}
+		if row.FullName.Valid && row.FullName.String != "" {
+			fullName := row.FullName.String
+			s.FullName = &fullName
+		}
+		if withPasswords {
+			s.Password = row.DefaultPassword.String
+		}
+		classData.Groups[i].Students = append(classData.Groups[i].Students, s)
+	}
+
+	var warnings []string
+	for _, url := range slices.Sorted(maps.Keys(groupsByNode)) {
+		if url == "" {
+			warnings = append(warnings, fmt.Sprintf("groups %s are not assigned to a node", strings.Join(groupsByNode[url], ", ")))
+			continue
+		}
+		c := cfg
+		c.Server = url
+		nodeWarnings, err := crossCheckNode(c, &classData, groupIndex, groupsByNode[url])
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("could not check %s: %v", url, err))
+			continue
+		}
+		warnings = append(warnings, nodeWarnings...)
+	}
+	return classData, warnings, nil
+}
+
+// crossCheckNode compares the groups of a class hosted on a node with the
+// groups and users there and fills in the emails of the students.
+func crossCheckNode(cfg config.GlobalOptions, classData *schemas.Class, groupIndex map[string]int, groups []string) ([]string, error) {
+	users, err := apiUserList(cfg, "getUsers")
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]schemas.UserResponse, len(users))
+	for _, u := range users {
+		byName[u.Username] = u
+	}
+	groupIDs, err := apiGroups(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var warnings []string
+	classGroupID := groupIDs[classData.Name]
+	classMembers := map[string]bool{}
+	if classGroupID == "" {
+		warnings = append(warnings, fmt.Sprintf("the class group %s is missing on %s", classData.Name, cfg.Server))
+	} else {
+		members, err := apiUserList(cfg, "getGroupMembers", classGroupID)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			classMembers[m.Username] = true
+		}
+	}
+
+	for _, name := range groups {
+		g := &classData.Groups[groupIndex[name]]
+		id := groupIDs[name]
+		if id == "" {
+			warnings = append(warnings, fmt.Sprintf("the group %s is missing on %s", name, cfg.Server))
+			continue
+		}
+		members, err := apiUserList(cfg, "getGroupMembers", id)
+		if err != nil {
+			return nil, err
+		}
+		inGroup := map[string]bool{}
+		for _, m := range members {
+			inGroup[m.Username] = true
+		}
+		for i := range g.Students {
+			s := &g.Students[i]
+			u, ok := byName[s.UserName]
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("the student %s is missing on %s", s.UserName, cfg.Server))
+				continue
+			}
+			if u.Email != nil && *u.Email != "" {
+				email := *u.Email
+				s.Email = &email
+			}
+			if s.FullName == nil && u.FullName != nil && *u.FullName != "" {
+				fullName := *u.FullName
+				s.FullName = &fullName
+			}
+			if !inGroup[s.UserName] || (classGroupID != "" && !classMembers[s.UserName]) {
+				warnings = append(warnings, fmt.Sprintf("the student %s is not in its groups on %s", s.UserName, cfg.Server))
+			}
+			delete(inGroup, s.UserName)
+		}
+		for _, username := range slices.Sorted(maps.Keys(inGroup)) {
+			warnings = append(warnings, fmt.Sprintf("%s is in group %s on %s but not in the database and was left out", username, name, cfg.Server))
+		}
+	}
+	return warnings, nil
+}
+
+// WriteClassFile writes a class in the format of class create --file.
+func WriteClassFile(w io.Writer, format string, classData schemas.Class) error {
+	var data []byte
+	var err error
+	switch format {
+	case ClassFileJSON:
+		data, err = json.MarshalIndent(classData, "", "  ")
+		data = append(data, '\n')
+	case ClassFileYAML:
+		var sb strings.Builder
+		enc := yaml.NewEncoder(&sb)
+		enc.SetIndent(2)
+		err = enc.Encode(classData)
+		data = []byte(sb.String())
+	default:
+		return fmt.Errorf("unknown format %q, use one of %s", format, strings.Join(ClassFileFormats, ", "))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal class: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,345 @@
+package class
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+// Actions of RunExerciseLifecycle.
+const (
+	ExerciseStart    = "start"
+	ExerciseStop     = "stop"
+	ExerciseComplete = "complete"
+)
+
+// States of the exercises table.
+const (
+	ExerciseCreated   = "created"
+	ExerciseRunning   = "running"
+	ExerciseStopped   = "stopped"
+	ExerciseCompleted = "completed"
+)
+
+// DefaultNameFormat is the project name format of exercise create. The
+// format an exercise was created with is stored in its rows, older rows
+// have none and use this one.
+const DefaultNameFormat = "{{class}}-{{exercise}}-{{group}}-{{uuid}}"
+
+// ProjectName fills in a project name format. group is the name of the
+// group without the class prefix.
+func ProjectName(format, className, exerciseName, group, uuid string) string {
+	return strings.NewReplacer(
+		"{{class}}", className,
+		"{{exercise}}", exerciseName,
+		"{{group}}", group,
+		"{{uuid}}", uuid,
+	).Replace(format)
+}
+
+// GroupPart returns the name of a group without the class prefix, as it
+// is used in project names.
+func GroupPart(groupName, className string) string {
+	if after, ok := strings.CutPrefix(groupName, className+"-"); ok {
+		return after
+	}
+	return groupName
+}
+
+// ParseProjectName matches a project name against a name format with the
+// class and group filled in and returns the exercise and the short uuid.
+func ParseProjectName(format, className, group, name string) (exercise, uuid string, ok bool) {
+	re, err := projectNameRegexp(format, className, group)
+	if err != nil {
+		return "", "", false
+	}
+	m := re.FindStringSubmatch(name)
+	if m == nil {
+		return "", "", false
+	}
+	if i := re.SubexpIndex("exercise"); i > 0 {
+		exercise = m[i]
+	}
+	if i := re.SubexpIndex("uuid"); i > 0 {
+		uuid = m[i]
+	}
+	return exercise, uuid, exercise != "" && uuid != ""
+}
+
+func projectNameRegexp(format, className, group string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	rest := format
+	for rest != "" {
+		start := strings.Index(rest, "{{")
+		end := strings.Index(rest, "}}")
+		if start < 0 || end < start {
+			expr.WriteString(regexp.QuoteMeta(rest))
+			break
+		}
+		expr.WriteString(regexp.QuoteMeta(rest[:start]))
+		switch rest[start : end+2] {
+		case "{{class}}":
+			expr.WriteString(regexp.QuoteMeta(className))
+		case "{{group}}":
+			expr.WriteString(regexp.QuoteMeta(group))
+		case "{{exercise}}":
+			expr.WriteString("(?P<exercise>.+)")
+		case "{{uuid}}":
+			expr.WriteString("(?P<uuid>[0-9a-f]{8})")
+		default:
+			expr.WriteString(regexp.QuoteMeta(rest[start : end+2]))
+		}
+		rest = rest[end+2:]
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+// exerciseProject returns a check for the name of the project of an
+// exercise. Names are parsed with the name format the exercise was created
+// with, the default one for exercises from before formats were stored, so a
+// short uuid showing up in the name of another project never matches.
+func exerciseProject(format sql.NullString, className, groupName, uuid string) func(name string) bool {
+	nameFormat := DefaultNameFormat
+	if format.Valid && format.String != "" {
+		nameFormat = format.String
+	}
+	re, err := projectNameRegexp(nameFormat, className, GroupPart(groupName, className))
+	if err != nil {
+		return func(string) bool { return false }
+	}
+	i, j := re.SubexpIndex("exercise"), re.SubexpIndex("uuid")
+	return func(name string) bool {
+		m := re.FindStringSubmatch(name)
+		return m != nil && i > 0 && j > 0 && m[j] == uuid
+	}
+}
+
+// findExerciseProject returns the index of the project of an exercise in
+// projects, -1 if it is missing.
+func findExerciseProject(projects []schemas.ProjectResponse, format sql.NullString, className, groupName, uuid string) int {
+	match := exerciseProject(format, className, groupName, uuid)
+	return slices.IndexFunc(projects, func(p schemas.ProjectResponse) bool { return match(p.Name) })
+}
+
+// LifecycleOptions configures RunExerciseLifecycle.
+type LifecycleOptions struct {
+	ClusterID int
+	Exercise  string
+	Action    string
+	// ClassName and GroupName limit the projects, empty means all.
+	ClassName string
+	GroupName string
+}
+
+type lifecycleResult struct {
+	project sqlc.GetExerciseProjectsRow
+	err     error
+}
+
+// RunExerciseLifecycle starts, stops or completes the project of every group
+// of an exercise on all nodes of a cluster and records the new state in the
+// cluster database. Nodes are handled in parallel, a failed project does not
+// stop the others and keeps its state.
+func RunExerciseLifecycle(ctx context.Context, cfg config.GlobalOptions, opts LifecycleOptions) error {
+	target, ok := map[string]string{
+		ExerciseStart:    ExerciseRunning,
+		ExerciseStop:     ExerciseStopped,
+		ExerciseComplete: ExerciseCompleted,
+	}[opts.Action]
+	if !ok {
+		return fmt.Errorf("unknown exercise action %q", opts.Action)
+	}
+
+	store, err := db.Init()
+	if err != nil {
+		return fmt.Errorf("failed to init db: %w", err)
+	}
+	nodeURLs, byNode, err := exerciseProjectsByNode(ctx, store, opts.ClusterID, opts.Exercise, opts.ClassName, opts.GroupName)
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results []lifecycleResult
+	)
+	for _, url := range nodeURLs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodeCfg := cfg
+			nodeCfg.Server = url
+			nodeResults := runLifecycleOnNode(nodeCfg, opts.Action, byNode[url])
+			mu.Lock()
+			results = append(results, nodeResults...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			fmt.Printf("%v to %s %s of group %s on %s: %v\n", messageUtils.ErrorMsg("Failed"), opts.Action,
+				r.project.ProjectUuid, messageUtils.Bold(r.project.GroupName), r.project.NodeUrl, r.err)
+			failed++
+			continue
+		}
+		// keep the time of the first transition when run again
+		if r.project.State.String == target {
+			continue
+		}
+		if err := setExerciseState(ctx, store, target, r.project.ExerciseID); err != nil {
+			return fmt.Errorf("failed to update the state of %s: %w", r.project.ProjectUuid, err)
+		}
+	}
+
+	fmt.Printf("%v %d of %d projects of exercise %s are %s\n", messageUtils.InfoMsg("Done"),
+		len(results)-failed, len(results), messageUtils.Bold(opts.Exercise), target)
+	if failed > 0 {
+		return fmt.Errorf("%d projects failed, run the command again to retry them", failed)
+	}
+	return nil
+}
+
+// exerciseProjectsByNode returns the projects of an exercise grouped by the
+// node they are on, and the nodes in cluster order.
+func exerciseProjectsByNode(ctx context.Context, store *db.Store, clusterID int, exercise, className, groupName string) ([]string, map[string][]sqlc.GetExerciseProjectsRow, error) {
+	rows, err := store.GetExerciseProjects(ctx, sqlc.GetExerciseProjectsParams{
+		ClusterID: int64(clusterID),
+		Name:      exercise,
+		Column3:   className,
+		Name_2:    className,
+		Column5:   groupName,
+		Name_3:    groupName,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get the projects of exercise %s: %w", messageUtils.Bold(exercise), err)
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("no projects found for exercise %s", messageUtils.Bold(exercise))
+	}
+
+	var nodeURLs []string
+	byNode := map[string][]sqlc.GetExerciseProjectsRow{}
+	for _, row := range rows {
+		url, _ := row.NodeUrl.(string)
+		if _, seen := byNode[url]; !seen {
+			nodeURLs = append(nodeURLs, url)
+		}
+		byNode[url] = append(byNode[url], row)
+	}
+	return nodeURLs, byNode, nil
+}
+
+func runLifecycleOnNode(cfg config.GlobalOptions, action string, rows []sqlc.GetExerciseProjectsRow) []lifecycleResult {
+	results := make([]lifecycleResult, 0, len(rows))
+	projects, err := getProjects(cfg)
+	if err != nil {
+		for _, row := range rows {
+			results = append(results, lifecycleResult{project: row, err: err})
+		}
+		return results
+	}
+	for _, row := range rows {
+		idx := findExerciseProject(projects, row.NameFormat, row.ClassName, row.GroupName, row.ProjectUuid)
+		if idx < 0 {
+			results = append(results, lifecycleResult{project: row, err: fmt.Errorf("project not found")})
+			continue
+		}
+		project := projects[idx]
+		opened := project.Status != nil && *project.Status == "opened"
+
+		var err error
+		switch action {
+		case ExerciseStart:
+			if !opened {
+				err = projectCall(cfg, "openProject", project.ProjectID)
+			}
+			if err == nil {
+				err = projectCall(cfg, "startAllNodes", project.ProjectID)
+			}
+		case ExerciseStop:
+			// nodes of a closed project are not running
+			if opened {
+				err = projectCall(cfg, "stopAllNodes", project.ProjectID)
+			}
+		case ExerciseComplete:
+			if opened {
+				err = projectCall(cfg, "stopAllNodes", project.ProjectID)
+				if err == nil {
+					err = projectCall(cfg, "closeProject", project.ProjectID)
+				}
+			}
+		}
+		if err == nil {
+			fmt.Printf("%v project %s of group %s on %s\n", messageUtils.SuccessMsg(lifecycleVerb(action)),
+				messageUtils.Bold(project.Name), row.GroupName, cfg.Server)
+		}
+		results = append(results, lifecycleResult{project: row, err: err})
+	}
+	return results
+}
+
+func getProjects(cfg config.GlobalOptions) ([]schemas.ProjectResponse, error) {
+	body, status, err := utils.CallClient(cfg, "getProjects", []string{}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get projects: %w", err)
+	}
+	if status != 200 {
+		return nil, fmt.Errorf("unexpected status code %d when getting projects", status)
+	}
+	var projects []schemas.ProjectResponse
+	if err := json.Unmarshal(body, &projects); err != nil {
+		return nil, fmt.Errorf("failed to parse projects response: %w", err)
+	}
+	return projects, nil
+}
+
+func projectCall(cfg config.GlobalOptions, command, projectID string) error {
+	_, status, err := utils.CallClient(cfg, command, []string{projectID}, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", command, err)
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("%s: status %d", command, status)
+	}
+	return nil
+}
+
+func setExerciseState(ctx context.Context, store *db.Store, state string, exerciseID int64) error {
+	switch state {
+	case ExerciseRunning:
+		return store.SetExerciseRunning(ctx, exerciseID)
+	case ExerciseStopped:
+		return store.SetExerciseStopped(ctx, exerciseID)
+	default:
+		return store.SetExerciseCompleted(ctx, exerciseID)
+	}
+}
+
+func lifecycleVerb(action string) string {
+	switch action {
+	case ExerciseStart:
+		return "Started"
+	case ExerciseStop:
+		return "Stopped"
+	default:
+		return "Completed"
+	}
+}
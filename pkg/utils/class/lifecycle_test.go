@@ -0,0 +1,79 @@
+package class
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+)
+
+func TestProjectName(t *testing.T) {
+	tests := []struct {
+		format, group, want string
+	}{
+		{format: DefaultNameFormat, group: "NET-g1", want: "NET-lab1-g1-0a1b2c3d"},
+		{format: "{{exercise}}_{{group}}_{{uuid}}", group: "NET-g1", want: "lab1_g1_0a1b2c3d"},
+		{format: DefaultNameFormat, group: "blue", want: "NET-lab1-blue-0a1b2c3d"},
+	}
+	for _, tt := range tests {
+		group := GroupPart(tt.group, "NET")
+		name := ProjectName(tt.format, "NET", "lab1", group, "0a1b2c3d")
+		if name != tt.want {
+			t.Errorf("ProjectName(%q, %s) = %q, want %q", tt.format, tt.group, name, tt.want)
+		}
+		exercise, uuid, ok := ParseProjectName(tt.format, "NET", group, name)
+		if !ok || exercise != "lab1" || uuid != "0a1b2c3d" {
+			t.Errorf("ParseProjectName(%q, %q) = %q, %q, %v", tt.format, name, exercise, uuid, ok)
+		}
+	}
+}
+
+func TestParseProjectName(t *testing.T) {
+	tests := []struct {
+		format, group, name string
+		exercise, uuid      string
+	}{
+		{format: DefaultNameFormat, group: "g1", name: "NET-lab-1-g1-0a1b2c3d", exercise: "lab-1", uuid: "0a1b2c3d"},
+		{format: "[{{class}}] {{exercise}}.{{uuid}}", group: "g1", name: "[NET] lab1.0a1b2c3d", exercise: "lab1", uuid: "0a1b2c3d"},
+		// another group, class or a uuid that is not a short uuid
+		{format: DefaultNameFormat, group: "g1", name: "NET-lab1-g2-0a1b2c3d"},
+		{format: DefaultNameFormat, group: "g1", name: "SEC-lab1-g1-0a1b2c3d"},
+		{format: DefaultNameFormat, group: "g1", name: "NET-lab1-g1-0A1B2C3D"},
+		{format: DefaultNameFormat, group: "g1", name: "NET-lab1-g1-0a1b2c3d-reset"},
+		// a format without the exercise or uuid never matches
+		{format: "{{class}}-{{group}}", group: "g1", name: "NET-g1"},
+	}
+	for _, tt := range tests {
+		exercise, uuid, ok := ParseProjectName(tt.format, "NET", tt.group, tt.name)
+		if ok != (tt.exercise != "") || exercise != tt.exercise || uuid != tt.uuid {
+			t.Errorf("ParseProjectName(%q, %q) = %q, %q, %v, want %q, %q", tt.format, tt.name, exercise, uuid, ok, tt.exercise, tt.uuid)
+		}
+	}
+}
+
+func TestFindExerciseProject(t *testing.T) {
+	custom := sql.NullString{String: "{{exercise}}_{{group}}_{{uuid}}", Valid: true}
+	tests := []struct {
+		format   sql.NullString
+		group    string
+		projects []string
+		want     int
+	}{
+		{group: "NET-g1", projects: []string{"NET-lab1-g1-0a1b2c3d"}, want: 0},
+		{format: sql.NullString{Valid: true}, group: "NET-g1", projects: []string{"NET-lab1-g1-0a1b2c3d"}, want: 0},
+		{format: custom, group: "NET-g1", projects: []string{"NET-lab1-g1-0a1b2c3d", "lab1_g1_0a1b2c3d"}, want: 1},
+		// copies, other groups and other uuids holding the short uuid
+		{group: "NET-g1", projects: []string{"NET-lab1-g1-0a1b2c3d (old)", "NET-lab1-g10-0a1b2c3d", "NET-lab1-g1-0a1b2c3de", "NET-lab1-g1-0a1b2c3d"}, want: 3},
+		{group: "NET-g1", projects: []string{"NET-lab1-g2-0a1b2c3d", "0a1b2c3d", "NET-lab1-g1-0a1b2c3d-reset"}, want: -1},
+		{format: sql.NullString{String: "{{class}}-{{group}}", Valid: true}, group: "NET-g1", projects: []string{"NET-g1"}, want: -1},
+	}
+	for _, tt := range tests {
+		projects := make([]schemas.ProjectResponse, 0, len(tt.projects))
+		for _, name := range tt.projects {
+			projects = append(projects, schemas.ProjectResponse{Name: name})
+		}
+		if got := findExerciseProject(projects, tt.format, "NET", tt.group, "0a1b2c3d"); got != tt.want {
+			t.Errorf("findExerciseProject(%q, %v) = %d, want %d", tt.format.String, tt.projects, got, tt.want)
+		}
+	}
+}
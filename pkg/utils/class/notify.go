@@ -0,0 +1,327 @@
+package class
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/mailer"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+// Default templates of class notify.
+const (
+	DefaultNotifySubject = `Your GNS3 login for {{.Class}}`
+	DefaultNotifyBody    = `Hello {{if .FullName}}{{.FullName}}{{else}}{{.Username}}{{end}},
+
+your account for {{.Class}} is ready.
+
+Server:   {{.Server}}
+Username: {{.Username}}
+Password: {{.Password}}
+Group:    {{.Group}}
+
+Please change your password after the first login.
+`
+)
+
+// Statuses of the delivery log.
+const (
+	NotificationSent   = "sent"
+	NotificationFailed = "failed"
+)
+
+// NotifyData is what the subject and body templates of class notify are
+// executed with.
+type NotifyData struct {
+	Class    string
+	Username string
+	FullName string
+	Password string
+	Group    string
+	Server   string
+	Email    string
+}
+
+// Notification is an entry of the delivery log.
+type Notification struct {
+	SentAt   time.Time `json:"sent_at"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// NotifyOptions configures NotifyClass.
+type NotifyOptions struct {
+	ClusterID int
+	ClassName string
+	SMTP      mailer.Config
+	// Subject and Body are text/template templates over NotifyData.
+	Subject string
+	Body    string
+	// DryRunDir receives one .eml file per student instead of sending.
+	DryRunDir string
+	// Rate limits the messages per minute, 0 sends as fast as possible.
+	Rate int
+	// Resend also mails students that were already mailed successfully.
+	Resend bool
+	// Only limits the students by username.
+	Only []string
+	// Emails by username win over the emails on the nodes.
+	Emails map[string]string
+}
+
+type recipient struct {
+	userID int64
+	data   NotifyData
+}
+
+// NotifyClass mails every student of a class its login and records each
+// attempt in the cluster database. Students without an email and students
+// that were already mailed are skipped.
+func NotifyClass(ctx context.Context, cfg config.GlobalOptions, opts NotifyOptions) error {
+	subjectTmpl, err := template.New("subject").Parse(opts.Subject)
+	if err != nil {
+		return fmt.Errorf("invalid subject template: %w", err)
+	}
+	bodyTmpl, err := template.New("body").Parse(opts.Body)
+	if err != nil {
+		return fmt.Errorf("invalid body template: %w", err)
+	}
+	// fail on unknown fields before anything is sent
+	if _, _, err := renderNotification(subjectTmpl, bodyTmpl, NotifyData{}); err != nil {
+		return err
+	}
+
+	store, err := db.Init()
+	if err != nil {
+		return fmt.Errorf("failed to init db: %w", err)
+	}
+	recipients, err := notifyRecipients(ctx, store, cfg, opts)
+	if err != nil {
+		return err
+	}
+	if len(opts.Only) > 0 {
+		recipients = slices.DeleteFunc(recipients, func(r recipient) bool { return !slices.Contains(opts.Only, r.data.Username) })
+		if len(recipients) == 0 {
+			return fmt.Errorf("none of %s is in class %s", strings.Join(opts.Only, ", "), messageUtils.Bold(opts.ClassName))
+		}
+	}
+
+	sent := map[string]bool{}
+	if !opts.Resend {
+		log, err := store.GetNotificationsForClass(ctx, sqlc.GetNotificationsForClassParams{ClusterID: int64(opts.ClusterID), Name: opts.ClassName})
+		if err != nil {
+			return fmt.Errorf("failed to get the delivery log: %w", err)
+		}
+		for _, n := range log {
+			if n.Status == NotificationSent {
+				sent[n.Username] = true
+			}
+		}
+	}
+
+	if opts.DryRunDir != "" {
+		if err := os.MkdirAll(opts.DryRunDir, 0o750); err != nil {
+			return fmt.Errorf("failed to create %s: %w", opts.DryRunDir, err)
+		}
+	}
+
+	var interval time.Duration
+	if opts.Rate > 0 {
+		interval = time.Minute / time.Duration(opts.Rate)
+	}
+	var last time.Time
+	delivered, failed, skipped := 0, 0, 0
+	for _, r := range recipients {
+		switch {
+		case r.data.Email == "":
+			fmt.Printf("%v %s has no email\n", messageUtils.WarningMsg("Skipped"), messageUtils.Bold(r.data.Username))
+			skipped++
+			continue
+		case sent[r.data.Username]:
+			fmt.Printf("%v %s was already mailed, use --resend to mail again\n", messageUtils.InfoMsg("Skipped"), messageUtils.Bold(r.data.Username))
+			skipped++
+			continue
+		}
+		subject, body, err := renderNotification(subjectTmpl, bodyTmpl, r.data)
+		if err != nil {
+			return err
+		}
+		msg := mailer.Message{To: r.data.Email, Subject: subject, Body: body}
+
+		if opts.DryRunDir != "" {
+			data, err := mailer.Compose(opts.SMTP.From, msg, time.Now())
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(opts.DryRunDir, r.data.Username+".eml")
+			// the message holds the password
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				return fmt.Errorf("failed to write %s: %w", path, err)
+			}
+			fmt.Printf("%v mail to %s in %s\n", messageUtils.SuccessMsg("Rendered"), r.data.Email, path)
+			delivered++
+			continue
+		}
+
+		if wait := time.Until(last.Add(interval)); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+		last = time.Now()
+		sendErr := mailer.Send(opts.SMTP, msg)
+		status := NotificationSent
+		if sendErr != nil {
+			status = NotificationFailed
+		}
+		if err := store.CreateNotification(ctx, sqlc.CreateNotificationParams{
+			UserID: r.userID,
+			Email:  r.data.Email,
+			Status: status,
+			Error:  errorString(sendErr),
+		}); err != nil {
+			return fmt.Errorf("failed to log the mail to %s: %w", r.data.Username, err)
+		}
+		if sendErr != nil {
+			fmt.Printf("%v mail to %s (%s): %v\n", messageUtils.ErrorMsg("Failed"), messageUtils.Bold(r.data.Username), r.data.Email, sendErr)
+			failed++
+			continue
+		}
+		fmt.Printf("%v login to %s (%s)\n", messageUtils.SuccessMsg("Mailed"), messageUtils.Bold(r.data.Username), r.data.Email)
+		delivered++
+	}
+
+	verb := "Mailed"
+	if opts.DryRunDir != "" {
+		verb = "Rendered"
+	}
+	fmt.Printf("%v %d of %d students of class %s, %d skipped, %d failed\n",
+		messageUtils.InfoMsg(verb), delivered, len(recipients), messageUtils.Bold(opts.ClassName), skipped, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d mails failed, run the command again to retry them", failed)
+	}
+	return nil
+}
+
+// GetNotificationLog returns the delivery log of a class, oldest first.
+func GetNotificationLog(ctx context.Context, clusterID int, className string) ([]Notification, error) {
+	store, err := db.Init()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init db: %w", err)
+	}
+	if _, err := store.GetClassByName(ctx, sqlc.GetClassByNameParams{ClusterID: int64(clusterID), Name: className}); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s", ErrClassNotFound, className)
+		}
+		return nil, fmt.Errorf("failed to get class %s: %w", messageUtils.Bold(className), err)
+	}
+	rows, err := store.GetNotificationsForClass(ctx, sqlc.GetNotificationsForClassParams{ClusterID: int64(clusterID), Name: className})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the delivery log: %w", err)
+	}
+	log := make([]Notification, 0, len(rows))
+	for _, row := range rows {
+		log = append(log, Notification{SentAt: row.SentAt.Time, Username: row.Username, Email: row.Email, Status: row.Status, Error: row.Error.String})
+	}
+	return log, nil
+}
+
+// notifyRecipients returns the students of a class with the emails of
+// their users on the nodes.
+func notifyRecipients(ctx context.Context, store *db.Store, cfg config.GlobalOptions, opts NotifyOptions) ([]recipient, error) {
+	cls, err := store.GetClassByName(ctx, sqlc.GetClassByNameParams{ClusterID: int64(opts.ClusterID), Name: opts.ClassName})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrClassNotFound, opts.ClassName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get class %s: %w", messageUtils.Bold(opts.ClassName), err)
+	}
+	_, nodeURLs, err := clusterNodes(ctx, store, opts.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := store.GetClassMembers(ctx, sqlc.GetClassMembersParams{ClusterID: int64(opts.ClusterID), Name: opts.ClassName})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the members of class %s: %w", messageUtils.Bold(opts.ClassName), err)
+	}
+
+	var recipients []recipient
+	nodes := map[string]bool{}
+	for _, row := range rows {
+		if !row.Username.Valid {
+			continue
+		}
+		url := nodeURLs[row.NodeID.Int64]
+		nodes[url] = true
+		recipients = append(recipients, recipient{
+			userID: row.UserID.Int64,
+			data: NotifyData{
+				Class:    cls.Name,
+				Username: row.Username.String,
+				FullName: row.FullName.String,
+				Password: row.DefaultPassword.String,
+				Group:    row.GroupName,
+				Server:   url,
+			},
+		})
+	}
+
+	emails := map[string]string{}
+	for _, url := range slices.Sorted(maps.Keys(nodes)) {
+		if url == "" {
+			continue
+		}
+		c := cfg
+		c.Server = url
+		users, err := apiUserList(c, "getUsers")
+		if err != nil {
+			fmt.Printf("%v could not get the emails from %s: %v\n", messageUtils.WarningMsg("Warning"), url, err)
+			continue
+		}
+		for _, u := range users {
+			if u.Email != nil {
+				emails[u.Username] = *u.Email
+			}
+		}
+	}
+	maps.Copy(emails, opts.Emails)
+	for i := range recipients {
+		recipients[i].data.Email = emails[recipients[i].data.Username]
+	}
+	return recipients, nil
+}
+
+func renderNotification(subjectTmpl, bodyTmpl *template.Template, data NotifyData) (string, string, error) {
+	var subject, body bytes.Buffer
+	if err := subjectTmpl.Execute(&subject, data); err != nil {
+		return "", "", fmt.Errorf("failed to render the subject: %w", err)
+	}
+	if err := bodyTmpl.Execute(&body, data); err != nil {
+		return "", "", fmt.Errorf("failed to render the body: %w", err)
+	}
+	return subject.String(), body.String(), nil
+}
+
+func errorString(err error) sql.NullString {
+	if err == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: err.Error(), Valid: true}
+}
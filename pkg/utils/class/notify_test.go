@@ -0,0 +1,136 @@
+package class
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/mailer"
+)
+
+// smtpServer starts a fake SMTP server and returns its config and the
+// recipients of every message it received so far.
+func smtpServer(t *testing.T) (mailer.Config, func() []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var to []string
+	srv := mailer.NewFakeServer(ln, func(e mailer.Envelope) {
+		mu.Lock()
+		defer mu.Unlock()
+		to = append(to, e.To...)
+	})
+	go func() {
+		_ = srv.Serve()
+	}()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+	port := ln.Addr().(*net.TCPAddr).Port
+	return mailer.Config{Host: "127.0.0.1", Port: port, From: "teacher@school.edu", TLS: mailer.TLSNone}, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(to)
+	}
+}
+
+func TestNotifyClass(t *testing.T) {
+	ctx := context.Background()
+	tc := testutil.NewCluster(t)
+	classData := testClass()
+	for i, name := range []string{"alice", "bob"} {
+		email := name + "@school.edu"
+		classData.Groups[0].Students[i].Email = &email
+	}
+	createClass(t, tc, classData)
+	smtp, received := smtpServer(t)
+
+	opts := NotifyOptions{ClusterID: tc.ClusterID, ClassName: "NET", SMTP: smtp, Subject: DefaultNotifySubject, Body: DefaultNotifyBody}
+	if err := NotifyClass(ctx, tc.Cfg, opts); err != nil {
+		t.Fatal(err)
+	}
+	// carol has no email
+	want := []string{"alice@school.edu", "bob@school.edu"}
+	if got := received(); !slices.Equal(got, want) {
+		t.Fatalf("mailed %v, want %v", got, want)
+	}
+
+	// students already mailed are skipped
+	if err := NotifyClass(ctx, tc.Cfg, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := received(); len(got) != 2 {
+		t.Errorf("mailed %v after a second run, want no new mails", got)
+	}
+	opts.Resend, opts.Only = true, []string{"bob"}
+	if err := NotifyClass(ctx, tc.Cfg, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got := received(); len(got) != 3 || got[2] != "bob@school.edu" {
+		t.Errorf("mailed %v, want bob again", got)
+	}
+
+	log, err := GetNotificationLog(ctx, tc.ClusterID, "NET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range log {
+		got = append(got, n.Username+" "+n.Status)
+	}
+	if want := []string{"alice sent", "bob sent", "bob sent"}; !slices.Equal(got, want) {
+		t.Errorf("delivery log %v, want %v", got, want)
+	}
+}
+
+func TestNotifyClassFailures(t *testing.T) {
+	ctx := context.Background()
+	tc := testutil.NewCluster(t)
+	classData := schemas.Class{Name: "NET", Groups: []schemas.Group{{Name: "NET-g1", Students: []schemas.Student{student("alice")}}}}
+	createClass(t, tc, classData)
+
+	// nothing listens on the port of a closed listener
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	opts := NotifyOptions{
+		ClusterID: tc.ClusterID,
+		ClassName: "NET",
+		SMTP:      mailer.Config{Host: "127.0.0.1", Port: port, From: "teacher@school.edu", TLS: mailer.TLSNone},
+		Subject:   DefaultNotifySubject,
+		Body:      DefaultNotifyBody,
+		Emails:    map[string]string{"alice": "alice@school.edu"},
+	}
+	if err := NotifyClass(ctx, tc.Cfg, opts); err == nil || !strings.Contains(err.Error(), "1 mails failed") {
+		t.Fatalf("notify through a closed port: %v", err)
+	}
+	log, err := GetNotificationLog(ctx, tc.ClusterID, "NET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 1 || log[0].Status != NotificationFailed || log[0].Error == "" {
+		t.Errorf("delivery log %+v, want one failure", log)
+	}
+
+	opts.Body = "{{.Nickname}}"
+	if err := NotifyClass(ctx, tc.Cfg, opts); err == nil {
+		t.Error("rendered a body with an unknown field")
+	}
+	opts.Body, opts.ClassName = DefaultNotifyBody, "LAB"
+	if err := NotifyClass(ctx, tc.Cfg, opts); !errors.Is(err, ErrClassNotFound) {
+		t.Errorf("notify an unknown class: %v", err)
+	}
+}
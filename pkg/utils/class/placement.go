@@ -0,0 +1,309 @@
+package class
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+)
+
+// Placement strategies for distributing the groups of a class over the
+// nodes of a cluster.
+const (
+	PlacementWeighted    = "weighted"
+	PlacementLeastLoaded = "least-loaded"
+	PlacementBinPack     = "binpack"
+	PlacementSpread      = "spread"
+)
+
+// PlacementStrategies lists the strategies NewScheduler knows.
+var PlacementStrategies = []string{PlacementWeighted, PlacementLeastLoaded, PlacementBinPack, PlacementSpread}
+
+// Scheduler decides on which nodes new groups are created.
+type Scheduler interface {
+	// Place returns how many of count groups go to each node, ordered by
+	// node ID. With respectCaps no node gets more than MaxGroups groups.
+	Place(ctx context.Context, nodes []db.NodeDataAll, count int, respectCaps bool) ([]NodeAndGroups, error)
+}
+
+// PlacementOptions configures NewScheduler.
+type PlacementOptions struct {
+	// Strategy is one of PlacementStrategies, empty means weighted.
+	Strategy string
+	// GroupRAM is the memory one group needs in MB, for binpack.
+	GroupRAM int
+	// RAMProject is a project whose nodes need the memory of one group,
+	// for binpack without GroupRAM.
+	RAMProject string
+	// Labels maps LabelKey of nodes to their label, for spread.
+	Labels map[string]string
+}
+
+// NewScheduler returns the scheduler of a placement strategy. Strategies
+// working with live statistics query the nodes with cfg.
+func NewScheduler(cfg config.GlobalOptions, opts PlacementOptions) (Scheduler, error) {
+	switch opts.Strategy {
+	case "", PlacementWeighted:
+		return weightedScheduler{}, nil
+	case PlacementLeastLoaded:
+		return leastLoadedScheduler{cfg: cfg}, nil
+	case PlacementBinPack:
+		if opts.GroupRAM <= 0 && opts.RAMProject == "" {
+			return nil, fmt.Errorf("placement %s needs the memory of a group, set --group-ram or --ram-project", PlacementBinPack)
+		}
+		return binPackScheduler{cfg: cfg, groupRAM: opts.GroupRAM, ramProject: opts.RAMProject}, nil
+	case PlacementSpread:
+		return spreadScheduler{labels: opts.Labels}, nil
+	}
+	return nil, fmt.Errorf("unknown placement %q, use one of %s", opts.Strategy, strings.Join(PlacementStrategies, ", "))
+}
+
+// ClusterScheduler returns the scheduler for the groups of a cluster. The
+// strategy of opts wins over the placement set for the cluster in the
+// cluster config, whose node labels are added for spread. An empty
+// clusterName means the single node cluster of cfg.Server.
+func ClusterScheduler(cfg config.GlobalOptions, clusterName string, opts PlacementOptions) (Scheduler, error) {
+	labels := map[string]string{}
+	for k, v := range opts.Labels {
+		labels[k] = v
+	}
+	opts.Labels = labels
+
+	if clusterName == "" && cfg.Server != "" {
+		clusterName = utils.ValidateUrlWithReturn(cfg.Server).Hostname() + "_single_node_cluster"
+	}
+	clusterCfg, err := cluster.LoadClusterConfig()
+	if err != nil && !errors.Is(err, cluster.ErrNoConfig) {
+		return nil, fmt.Errorf("failed to load cluster config: %w", err)
+	}
+	for _, c := range clusterCfg.Clusters {
+		if !strings.EqualFold(strings.TrimSpace(c.Name), strings.TrimSpace(clusterName)) {
+			continue
+		}
+		if opts.Strategy == "" {
+			opts.Strategy = strings.TrimSpace(c.Placement)
+		}
+		for _, n := range c.Nodes {
+			if n.Label != "" {
+				opts.Labels[LabelKey(n.Host, n.Port)] = strings.TrimSpace(n.Label)
+			}
+		}
+	}
+	return NewScheduler(cfg, opts)
+}
+
+// LabelKey is the key of a node in PlacementOptions.Labels.
+func LabelKey(host string, port int) string {
+	return fmt.Sprintf("%s:%d", strings.ToLower(strings.TrimSpace(host)), port)
+}
+
+// weightedScheduler splits the groups in proportion to the node weights.
+type weightedScheduler struct{}
+
+func (weightedScheduler) Place(_ context.Context, nodes []db.NodeDataAll, count int, respectCaps bool) ([]NodeAndGroups, error) {
+	return distributeGroupsWithMode(nodes, count, respectCaps)
+}
+
+// leastLoadedScheduler puts every group on the node with the fewest
+// projects per GiB of free memory, a busy CPU counting as less memory.
+type leastLoadedScheduler struct {
+	cfg config.GlobalOptions
+}
+
+func (s leastLoadedScheduler) Place(ctx context.Context, nodes []db.NodeDataAll, count int, respectCaps bool) ([]NodeAndGroups, error) {
+	type load struct {
+		projects int
+		freeGiB  float64
+		cpu      float64
+	}
+	loads := make([]load, len(nodes))
+	for i, n := range nodes {
+		client, err := utils.NewClient(s.nodeCfg(n))
+		if err != nil {
+			return nil, err
+		}
+		stats, err := client.Statistics(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the statistics of %s: %w", nodeURL(n), err)
+		}
+		projects, err := client.Projects().List(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the projects of %s: %w", nodeURL(n), err)
+		}
+		loads[i].projects = len(projects)
+		for _, st := range stats {
+			loads[i].freeGiB += float64(st.Statistics.MemoryFree) / (1 << 30)
+			loads[i].cpu += st.Statistics.CPUUsagePercent / float64(len(stats))
+		}
+	}
+	return placeGreedy(nodes, count, respectCaps, func(i int, assigned []int) (float64, bool) {
+		l := loads[i]
+		return float64(l.projects+assigned[i]+1) * (1 + l.cpu/100) / max(l.freeGiB, 0.1), true
+	})
+}
+
+func (s leastLoadedScheduler) nodeCfg(n db.NodeDataAll) config.GlobalOptions {
+	cfg := s.cfg
+	cfg.Server = nodeURL(n)
+	return cfg
+}
+
+// binPackScheduler puts every group on the node with the least free memory
+// that still fits it, so the groups fill as few nodes as possible.
+type binPackScheduler struct {
+	cfg        config.GlobalOptions
+	groupRAM   int
+	ramProject string
+}
+
+func (s binPackScheduler) Place(ctx context.Context, nodes []db.NodeDataAll, count int, respectCaps bool) ([]NodeAndGroups, error) {
+	groupRAM := s.groupRAM
+	if groupRAM <= 0 {
+		var err error
+		groupRAM, err = s.projectRAM(ctx, nodes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	freeMB := make([]int, len(nodes))
+	for i, n := range nodes {
+		client, err := utils.NewClient(leastLoadedScheduler{cfg: s.cfg}.nodeCfg(n))
+		if err != nil {
+			return nil, err
+		}
+		stats, err := client.Statistics(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the statistics of %s: %w", nodeURL(n), err)
+		}
+		for _, st := range stats {
+			freeMB[i] += int(st.Statistics.MemoryFree >> 20)
+		}
+	}
+	dist, err := placeGreedy(nodes, count, respectCaps, func(i int, assigned []int) (float64, bool) {
+		left := freeMB[i] - (assigned[i]+1)*groupRAM
+		return float64(left), left >= 0
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %d groups of %d MB do not fit into the free memory of the nodes", err, count, groupRAM)
+	}
+	return dist, nil
+}
+
+// projectRAM adds up the memory of the nodes of ramProject, from their
+// own ram property or else the one of their template.
+func (s binPackScheduler) projectRAM(ctx context.Context, nodes []db.NodeDataAll) (int, error) {
+	for _, n := range nodes {
+		client, err := utils.NewClient(leastLoadedScheduler{cfg: s.cfg}.nodeCfg(n))
+		if err != nil {
+			return 0, err
+		}
+		projects, err := client.Projects().List(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the projects of %s: %w", nodeURL(n), err)
+		}
+		idx := slices.IndexFunc(projects, func(p schemas.ProjectResponse) bool {
+			return p.Name == s.ramProject || p.ProjectID == s.ramProject
+		})
+		if idx < 0 {
+			continue
+		}
+		projectNodes, err := client.Nodes(projects[idx].ProjectID).List(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the nodes of %s: %w", s.ramProject, err)
+		}
+		templates, err := client.Templates().List(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get the templates of %s: %w", nodeURL(n), err)
+		}
+		templateRAM := map[string]int{}
+		for _, t := range templates {
+			if t.RAM != nil {
+				templateRAM[t.TemplateID] = *t.RAM
+			}
+		}
+		total := 0
+		for _, pn := range projectNodes {
+			if ram, ok := pn.Properties["ram"].(float64); ok {
+				total += int(ram)
+			} else if pn.TemplateID != nil {
+				total += templateRAM[*pn.TemplateID]
+			}
+		}
+		if total == 0 {
+			return 0, fmt.Errorf("the nodes of project %s need no memory, set --group-ram", s.ramProject)
+		}
+		return total, nil
+	}
+	return 0, fmt.Errorf("project %s not found on any node", s.ramProject)
+}
+
+// spreadScheduler deals the groups out evenly over the labels of the
+// nodes, and within a label in proportion to the node weights. Nodes
+// without a label share the empty label.
+type spreadScheduler struct {
+	labels map[string]string
+}
+
+func (s spreadScheduler) Place(_ context.Context, nodes []db.NodeDataAll, count int, respectCaps bool) ([]NodeAndGroups, error) {
+	labels := make([]string, len(nodes))
+	for i, n := range nodes {
+		labels[i] = s.labels[LabelKey(n.Host, n.Port)]
+	}
+	return placeGreedy(nodes, count, respectCaps, func(i int, assigned []int) (float64, bool) {
+		inLabel := 0
+		for j := range nodes {
+			if labels[j] == labels[i] {
+				inLabel += assigned[j]
+			}
+		}
+		// the groups of the label count whole, the share of the node is below one
+		share := float64(assigned[i]) / float64(max(nodes[i].Weight, 1)*(count+1))
+		return float64(inLabel) + share, true
+	})
+}
+
+// placeGreedy places count groups one after another on the node with the
+// lowest cost. cost returns false for a node that can not take one more
+// group, with respectCaps nodes at MaxGroups take no more either.
+func placeGreedy(nodes []db.NodeDataAll, count int, respectCaps bool, cost func(i int, assigned []int) (float64, bool)) ([]NodeAndGroups, error) {
+	assigned := make([]int, len(nodes))
+	for range count {
+		best := -1
+		bestCost := 0.0
+		for i, n := range nodes {
+			if respectCaps && assigned[i] >= n.MaxGroups {
+				continue
+			}
+			c, ok := cost(i, assigned)
+			if !ok {
+				continue
+			}
+			if best < 0 || c < bestCost || (c == bestCost && n.ID < nodes[best].ID) {
+				best, bestCost = i, c
+			}
+		}
+		if best < 0 {
+			return nil, ErrInsufficientCapacity
+		}
+		assigned[best]++
+	}
+
+	result := make([]NodeAndGroups, 0, len(nodes))
+	for i, n := range nodes {
+		result = append(result, NodeAndGroups{NodeID: n.ID, NumGroups: assigned[i]})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].NodeID < result[j].NodeID })
+	return result, nil
+}
+
+func nodeURL(n db.NodeDataAll) string {
+	return fmt.Sprintf("%s://%s:%d", n.Protocol, n.Host, n.Port)
+}
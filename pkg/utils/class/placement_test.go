@@ -0,0 +1,295 @@
+package class
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+)
+
+func testNodes(weights ...int) []db.NodeDataAll {
+	nodes := make([]db.NodeDataAll, len(weights))
+	for i, w := range weights {
+		nodes[i] = db.NodeDataAll{ID: i + 1, Host: fmt.Sprintf("gns3-%d", i+1), Port: 3080, Weight: w, MaxGroups: 3}
+	}
+	return nodes
+}
+
+// counts returns the number of groups of each node by node ID.
+func counts(dist []NodeAndGroups) []int {
+	out := make([]int, len(dist))
+	for i, d := range dist {
+		out[i] = d.NumGroups
+	}
+	return out
+}
+
+func TestNewScheduler(t *testing.T) {
+	tests := []struct {
+		opts PlacementOptions
+		want Scheduler
+		err  string
+	}{
+		{opts: PlacementOptions{}, want: weightedScheduler{}},
+		{opts: PlacementOptions{Strategy: PlacementWeighted}, want: weightedScheduler{}},
+		{opts: PlacementOptions{Strategy: PlacementLeastLoaded}, want: leastLoadedScheduler{}},
+		{opts: PlacementOptions{Strategy: PlacementBinPack, GroupRAM: 512}, want: binPackScheduler{groupRAM: 512}},
+		{opts: PlacementOptions{Strategy: PlacementBinPack, RAMProject: "lab"}, want: binPackScheduler{ramProject: "lab"}},
+		{opts: PlacementOptions{Strategy: PlacementBinPack}, err: "needs the memory of a group"},
+		{opts: PlacementOptions{Strategy: PlacementSpread}, want: spreadScheduler{}},
+		{opts: PlacementOptions{Strategy: "random"}, err: `unknown placement "random"`},
+	}
+	for _, tt := range tests {
+		got, err := NewScheduler(config.GlobalOptions{}, tt.opts)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("NewScheduler(%+v) error %v, want %q", tt.opts, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NewScheduler(%+v): %v", tt.opts, err)
+			continue
+		}
+		if fmt.Sprintf("%T", got) != fmt.Sprintf("%T", tt.want) {
+			t.Errorf("NewScheduler(%+v) = %T, want %T", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestClusterScheduler(t *testing.T) {
+	homedir.DisableCache = true
+	t.Setenv("HOME", t.TempDir())
+	if err := cluster.WriteClusterConfig(cluster.Config{Clusters: []cluster.Cluster{{
+		Name:      "lab",
+		Placement: PlacementSpread,
+		Nodes:     []cluster.Node{{Host: "GNS3-1", Port: 3080, Label: "rack-a"}, {Host: "gns3-2", Port: 3080}},
+	}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cluster string
+		opts    PlacementOptions
+		want    Scheduler
+	}{
+		{cluster: "lab", want: spreadScheduler{labels: map[string]string{"gns3-1:3080": "rack-a"}}},
+		{cluster: " LAB ", want: spreadScheduler{labels: map[string]string{"gns3-1:3080": "rack-a"}}},
+		{cluster: "lab", opts: PlacementOptions{Strategy: PlacementLeastLoaded}, want: leastLoadedScheduler{}},
+		{cluster: "other", want: weightedScheduler{}},
+	}
+	for _, tt := range tests {
+		got, err := ClusterScheduler(config.GlobalOptions{}, tt.cluster, tt.opts)
+		if err != nil {
+			t.Errorf("ClusterScheduler(%q, %+v): %v", tt.cluster, tt.opts, err)
+			continue
+		}
+		if fmt.Sprintf("%T %v", got, got) != fmt.Sprintf("%T %v", tt.want, tt.want) {
+			t.Errorf("ClusterScheduler(%q, %+v) = %T %v, want %T %v", tt.cluster, tt.opts, got, got, tt.want, tt.want)
+		}
+	}
+}
+
+func TestPlacement(t *testing.T) {
+	tests := []struct {
+		name        string
+		scheduler   Scheduler
+		nodes       []db.NodeDataAll
+		count       int
+		respectCaps bool
+		want        []int
+		err         error
+	}{
+		{name: "weighted", scheduler: weightedScheduler{}, nodes: testNodes(1, 3), count: 4, want: []int{1, 3}},
+		{name: "weighted without weights", scheduler: weightedScheduler{}, nodes: testNodes(0, 0, 0), count: 4, want: []int{2, 1, 1}},
+		{name: "weighted over the caps", scheduler: weightedScheduler{}, nodes: testNodes(1, 1), count: 7, respectCaps: true, err: ErrInsufficientCapacity},
+		{
+			name:      "spread over labels",
+			scheduler: spreadScheduler{labels: map[string]string{"gns3-1:3080": "rack-a", "gns3-2:3080": "rack-a", "gns3-3:3080": "rack-b"}},
+			nodes:     testNodes(1, 1, 1),
+			count:     4,
+			want:      []int{1, 1, 2},
+		},
+		{
+			name:      "spread by weight within a label",
+			scheduler: spreadScheduler{labels: map[string]string{"gns3-1:3080": "rack-a", "gns3-2:3080": "rack-a"}},
+			nodes:     testNodes(1, 3, 1),
+			count:     6,
+			want:      []int{1, 2, 3},
+		},
+		{
+			name:      "spread without labels",
+			scheduler: spreadScheduler{},
+			nodes:     testNodes(1, 1),
+			count:     3,
+			want:      []int{2, 1},
+		},
+		{
+			name:        "spread moves on from a full node",
+			scheduler:   spreadScheduler{labels: map[string]string{"gns3-2:3080": "rack-b"}},
+			nodes:       testNodes(1, 1),
+			count:       6,
+			respectCaps: true,
+			want:        []int{3, 3},
+		},
+		{
+			name:        "spread over the caps",
+			scheduler:   spreadScheduler{},
+			nodes:       testNodes(1, 1),
+			count:       7,
+			respectCaps: true,
+			err:         ErrInsufficientCapacity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dist, err := tt.scheduler.Place(context.Background(), tt.nodes, tt.count, tt.respectCaps)
+			if tt.err != nil {
+				if err == nil || (!errors.Is(err, tt.err) && err.Error() != tt.err.Error()) {
+					t.Fatalf("error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := counts(dist); !slices.Equal(got, tt.want) {
+				t.Errorf("groups per node %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// createProjects creates empty projects on the controller of client.
+func createProjects(t *testing.T, client *sdk.Client, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if _, err := client.Projects().Create(context.Background(), schemas.ProjectCreate{Name: &name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLeastLoadedPlacement(t *testing.T) {
+	tc := testutil.NewCluster(t)
+	tc.AddNode(t)
+	// the first node is busier
+	createProjects(t, tc.Client, "a", "b", "c")
+
+	scheduler, err := NewScheduler(tc.Cfg, PlacementOptions{Strategy: PlacementLeastLoaded})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for count, want := range map[int][]int{2: {0, 2}, 4: {1, 3}} {
+		dist, err := scheduler.Place(context.Background(), tc.Nodes, count, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := counts(dist); !slices.Equal(got, want) {
+			t.Errorf("%d groups per node %v, want %v", count, got, want)
+		}
+	}
+}
+
+func TestBinPackPlacement(t *testing.T) {
+	ctx := context.Background()
+	tc := testutil.NewCluster(t)
+	tc.AddNode(t)
+	// every fake controller has 12 GiB free, a project of 6 GiB fits twice
+	name := "router-lab"
+	p, err := tc.Client.Projects().Create(ctx, schemas.ProjectCreate{Name: &name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ram := range []int{4096, 2048} {
+		nodeName, nodeType, compute := fmt.Sprintf("R%d", ram), "qemu", "local"
+		if _, err := tc.Client.Nodes(p.ProjectID).Create(ctx, schemas.NodeCreate{
+			Name: &nodeName, NodeType: &nodeType, ComputeID: &compute, Properties: map[string]any{"ram": ram},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createProjects(t, tc.Client, "empty")
+
+	tests := []struct {
+		name  string
+		opts  PlacementOptions
+		count int
+		want  []int
+		err   string
+	}{
+		{name: "fill the first node", opts: PlacementOptions{GroupRAM: 5000}, count: 3, want: []int{2, 1}},
+		{name: "one node is enough", opts: PlacementOptions{GroupRAM: 1024}, count: 5, want: []int{5, 0}},
+		{name: "memory of a project", opts: PlacementOptions{RAMProject: name}, count: 3, want: []int{2, 1}},
+		{name: "memory of a project by id", opts: PlacementOptions{RAMProject: p.ProjectID}, count: 4, want: []int{2, 2}},
+		{name: "too little memory", opts: PlacementOptions{RAMProject: name}, count: 5, err: "5 groups of 6144 MB do not fit"},
+		{name: "project without memory", opts: PlacementOptions{RAMProject: "empty"}, count: 1, err: "need no memory"},
+		{name: "unknown project", opts: PlacementOptions{RAMProject: "nope"}, count: 1, err: "project nope not found on any node"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Strategy = PlacementBinPack
+			scheduler, err := NewScheduler(tc.Cfg, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dist, err := scheduler.Place(ctx, tc.Nodes, tt.count, false)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := counts(dist); !slices.Equal(got, tt.want) {
+				t.Errorf("groups per node %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// usernames returns the sorted users of the controller of client.
+func usernames(t *testing.T, client *sdk.Client) []string {
+	t.Helper()
+	users, err := client.Users().List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, u := range users {
+		if u.Username != "admin" {
+			names = append(names, u.Username)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestCreateClassPlacement(t *testing.T) {
+	tc := testutil.NewCluster(t)
+	second := tc.AddNode(t)
+	// one group for each label, the second node has none
+	scheduler := spreadScheduler{labels: map[string]string{LabelKey(tc.Nodes[0].Host, tc.Nodes[0].Port): "rack-a"}}
+	if ok, err := CreateClass(tc.Cfg, tc.ClusterID, testClass(), tc.Nodes, scheduler); err != nil || !ok {
+		t.Fatalf("create class: %v", err)
+	}
+
+	if got, want := usernames(t, tc.Client), []string{"alice", "bob"}; !slices.Equal(got, want) {
+		t.Errorf("users on the first node %v, want %v", got, want)
+	}
+	if got, want := usernames(t, second), []string{"carol"}; !slices.Equal(got, want) {
+		t.Errorf("users on the second node %v, want %v", got, want)
+	}
+}
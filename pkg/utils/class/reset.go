@@ -0,0 +1,250 @@
+package class
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
+	"github.com/stefanistkuhl/gns3util/pkg/cluster/db/sqlc"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
+)
+
+// BaselineSnapshot is the name of the snapshot exercise create takes of
+// every project.
+const BaselineSnapshot = "baseline"
+
+// ResetOptions configures ResetExercise.
+type ResetOptions struct {
+	ClusterID int
+	Exercise  string
+	ClassName string
+	GroupName string
+}
+
+// ResetTarget is a project ResetExercise would reset and how.
+type ResetTarget struct {
+	Row sqlc.GetExerciseProjectsRow
+	// FromTemplate is set when the baseline snapshot is missing and the
+	// project is duplicated from the template again.
+	FromTemplate bool
+}
+
+// PlanExerciseReset returns the projects of the groups to reset. It fails
+// if a project has neither a baseline snapshot nor a template to start
+// over from.
+func PlanExerciseReset(ctx context.Context, opts ResetOptions) ([]ResetTarget, error) {
+	store, err := db.Init()
+	if err != nil {
+		return nil, fmt.Errorf("failed to init db: %w", err)
+	}
+	nodeURLs, byNode, err := exerciseProjectsByNode(ctx, store, opts.ClusterID, opts.Exercise, opts.ClassName, opts.GroupName)
+	if err != nil {
+		return nil, err
+	}
+	var targets []ResetTarget
+	for _, url := range nodeURLs {
+		for _, row := range byNode[url] {
+			if !row.BaselineSnapshotID.Valid && !row.TemplateProjectID.Valid {
+				return nil, fmt.Errorf("the project of group %s has no baseline snapshot and no template, it was created before exercise reset existed",
+					messageUtils.Bold(row.GroupName))
+			}
+			targets = append(targets, ResetTarget{Row: row, FromTemplate: !row.BaselineSnapshotID.Valid})
+		}
+	}
+	return targets, nil
+}
+
+// ResetExercise restores the projects of the targets from their baseline
+// snapshot. When the snapshot is gone the project is duplicated from the
+// template again, takes the place of the old project in its pool and gets
+// a new baseline. The exercise is marked as created afterwards.
+func ResetExercise(ctx context.Context, cfg config.GlobalOptions, targets []ResetTarget) error {
+	store, err := db.Init()
+	if err != nil {
+		return fmt.Errorf("failed to init db: %w", err)
+	}
+	failed := 0
+	for _, t := range targets {
+		nodeCfg := cfg
+		nodeCfg.Server, _ = t.Row.NodeUrl.(string)
+		baseline, err := resetProject(ctx, nodeCfg, t.Row)
+		if err != nil {
+			fmt.Printf("%v to reset the project of group %s on %s: %v\n", messageUtils.ErrorMsg("Failed"),
+				messageUtils.Bold(t.Row.GroupName), nodeCfg.Server, err)
+			failed++
+			continue
+		}
+		if err := store.ResetExercise(ctx, sqlc.ResetExerciseParams{
+			BaselineSnapshotID: sql.NullString{String: baseline, Valid: baseline != ""},
+			ExerciseID:         t.Row.ExerciseID,
+		}); err != nil {
+			return fmt.Errorf("failed to update exercise %s: %w", t.Row.ProjectUuid, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d projects failed", failed, len(targets))
+	}
+	return nil
+}
+
+// resetProject resets one project and returns the id of its baseline
+// snapshot, which is new if the project was duplicated.
+func resetProject(ctx context.Context, cfg config.GlobalOptions, row sqlc.GetExerciseProjectsRow) (string, error) {
+	client, err := utils.NewClient(cfg)
+	if err != nil {
+		return "", err
+	}
+	projects, err := client.Projects().List(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get projects: %w", err)
+	}
+	match := exerciseProject(row.NameFormat, row.ClassName, row.GroupName, row.ProjectUuid)
+	idx := slices.IndexFunc(projects, func(p schemas.ProjectResponse) bool { return match(p.Name) })
+	if idx < 0 {
+		// the copy left by a reset that failed after deleting the project
+		idx = slices.IndexFunc(projects, func(p schemas.ProjectResponse) bool {
+			name, ok := strings.CutSuffix(p.Name, resetSuffix)
+			return ok && match(name)
+		})
+	}
+	if idx < 0 {
+		return "", fmt.Errorf("project not found")
+	}
+	project := projects[idx]
+
+	if row.BaselineSnapshotID.Valid {
+		snapshots, err := client.Snapshots(project.ProjectID).List(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get snapshots: %w", err)
+		}
+		if slices.ContainsFunc(snapshots, func(s schemas.SnapshotResponse) bool { return s.SnapshotID == row.BaselineSnapshotID.String }) {
+			if _, err := client.Snapshots(project.ProjectID).Restore(ctx, row.BaselineSnapshotID.String); err != nil {
+				return "", fmt.Errorf("failed to restore the baseline snapshot: %w", err)
+			}
+			fmt.Printf("%v project %s of group %s from its baseline snapshot\n", messageUtils.SuccessMsg("Restored"),
+				messageUtils.Bold(project.Name), row.GroupName)
+			return row.BaselineSnapshotID.String, nil
+		}
+		if !row.TemplateProjectID.Valid {
+			return "", fmt.Errorf("the baseline snapshot was deleted and there is no template to duplicate")
+		}
+		fmt.Printf("%v the baseline snapshot of %s was deleted, duplicating the template\n",
+			messageUtils.WarningMsg("Warning"), messageUtils.Bold(project.Name))
+	}
+	return reduplicateProject(ctx, client, project, row.TemplateProjectID.String)
+}
+
+// resetSuffix marks the copy of the template while a reset replaces the
+// project of a group. A copy left by a failed reset is found by its name
+// without the suffix and finished by the next one.
+const resetSuffix = "-reset"
+
+// reduplicateProject replaces a project with a fresh copy of the template
+// under the same name and in the same pool, so the ACLs of the group keep
+// applying.
+func reduplicateProject(ctx context.Context, client *sdk.Client, project schemas.ProjectResponse, templateID string) (string, error) {
+	name := strings.TrimSuffix(project.Name, resetSuffix)
+	pools, err := client.Pools().List(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get pools: %w", err)
+	}
+	poolIdx := slices.IndexFunc(pools, func(p schemas.ResourcePoolResponse) bool { return p.Name == name+"-pool" })
+
+	fresh := project
+	if name == project.Name {
+		if _, err := client.Projects().Get(ctx, templateID); err != nil {
+			return "", fmt.Errorf("template project %s is gone: %w", templateID, err)
+		}
+		// duplicate under a temporary name first so a failure keeps the old project
+		fresh, err = client.Projects().Duplicate(ctx, templateID, schemas.ProjectDuplicate{Name: name + resetSuffix})
+		if err != nil {
+			return "", fmt.Errorf("failed to duplicate the template: %w", err)
+		}
+		discard := func(cause error) error {
+			if err := client.Projects().Delete(ctx, fresh.ProjectID); err != nil {
+				return fmt.Errorf("%w, the copy %s is left behind and has to be deleted by hand: %v", cause, fresh.Name, err)
+			}
+			return cause
+		}
+		if poolIdx >= 0 {
+			if err := client.Pools().RemoveResource(ctx, pools[poolIdx].ResourcePoolID, project.ProjectID); err != nil {
+				return "", discard(fmt.Errorf("failed to remove the old project from pool %s: %w", pools[poolIdx].Name, err))
+			}
+		}
+		if err := client.Projects().Close(ctx, project.ProjectID); err != nil {
+			fmt.Printf("%v failed to close %s: %v\n", messageUtils.WarningMsg("Warning"), messageUtils.Bold(name), err)
+		}
+		if err := client.Projects().Delete(ctx, project.ProjectID); err != nil {
+			err = fmt.Errorf("failed to delete the old project: %w", err)
+			if poolIdx >= 0 {
+				if addErr := client.Pools().AddResource(ctx, pools[poolIdx].ResourcePoolID, project.ProjectID); addErr != nil {
+					err = fmt.Errorf("%w, it is no longer in pool %s: %v", err, pools[poolIdx].Name, addErr)
+				}
+			}
+			return "", discard(err)
+		}
+	} else {
+		fmt.Printf("%v the reset of %s, its old project was already replaced by %s\n",
+			messageUtils.InfoMsg("Finishing"), messageUtils.Bold(name), fresh.Name)
+	}
+
+	// from here on the old project is gone and the copy is the project of
+	// the group, failures leave it for the next reset to finish
+	leftover := func(err error) error {
+		return fmt.Errorf("%w, the old project is deleted and %s is left in its place, run exercise reset again to finish it", err, fresh.Name)
+	}
+	if fresh.Name != name {
+		if _, err := client.Projects().Update(ctx, fresh.ProjectID, schemas.ProjectUpdate{Name: &name}); err != nil {
+			return "", leftover(fmt.Errorf("failed to rename %s: %w", fresh.Name, err))
+		}
+		fresh.Name = name
+	}
+	if poolIdx >= 0 {
+		pool := pools[poolIdx]
+		resources, err := client.Pools().Resources(ctx, pool.ResourcePoolID)
+		if err != nil {
+			return "", leftover(fmt.Errorf("failed to get the resources of pool %s: %w", pool.Name, err))
+		}
+		if !slices.ContainsFunc(resources, func(r schemas.PoolResourceResponse) bool { return r.ResourceID == fresh.ProjectID }) {
+			if err := client.Pools().AddResource(ctx, pool.ResourcePoolID, fresh.ProjectID); err != nil {
+				return "", leftover(fmt.Errorf("failed to add the project to pool %s: %w", pool.Name, err))
+			}
+		}
+	} else {
+		fmt.Printf("%v pool %s not found, the group may not have access to the new project\n",
+			messageUtils.WarningMsg("Warning"), name+"-pool")
+	}
+
+	if err := client.Projects().Open(ctx, fresh.ProjectID); err != nil {
+		return "", leftover(fmt.Errorf("failed to open the new project: %w", err))
+	}
+	snapshot, err := baselineSnapshot(ctx, client, fresh.ProjectID)
+	if err != nil {
+		fmt.Printf("%v failed to take a new baseline snapshot of %s: %v\n", messageUtils.WarningMsg("Warning"), name, err)
+	}
+	if err := client.Projects().Close(ctx, fresh.ProjectID); err != nil {
+		fmt.Printf("%v failed to close %s: %v\n", messageUtils.WarningMsg("Warning"), messageUtils.Bold(name), err)
+	}
+	fmt.Printf("%v project %s from its template\n", messageUtils.SuccessMsg("Recreated"), messageUtils.Bold(name))
+	return snapshot.SnapshotID, nil
+}
+
+// baselineSnapshot takes the baseline snapshot of a project, or returns
+// the one a copy already has, like one taken by a reset that failed after.
+func baselineSnapshot(ctx context.Context, client *sdk.Client, projectID string) (schemas.SnapshotResponse, error) {
+	snapshots, err := client.Snapshots(projectID).List(ctx)
+	if err != nil {
+		return schemas.SnapshotResponse{}, err
+	}
+	if idx := slices.IndexFunc(snapshots, func(s schemas.SnapshotResponse) bool { return s.Name == BaselineSnapshot }); idx >= 0 {
+		return snapshots[idx], nil
+	}
+	return client.Snapshots(projectID).Create(ctx, BaselineSnapshot)
+}
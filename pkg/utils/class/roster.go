@@ -0,0 +1,467 @@
+package class
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/csv"
+	"fmt"
+	"math/big"
+	mrand "math/rand/v2"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/stefanistkuhl/gns3util/pkg/api/schemas"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// RosterOptions controls how a roster export is turned into a class.
+type RosterOptions struct {
+	ClassName   string
+	Description string
+
+	// Header names of the roster columns. Empty ones are looked up by
+	// common names like "Full Name" or "E-Mail".
+	NameColumn      string
+	FirstNameColumn string
+	LastNameColumn  string
+	IDColumn        string
+	EmailColumn     string
+	GroupColumn     string
+
+	// GroupSize or GroupCount split the students into groups in roster
+	// order, shuffled with Shuffle. Without them the group column is used.
+	GroupSize  int
+	GroupCount int
+	Shuffle    bool
+
+	// UsernamePattern and GroupPattern take the placeholders listed in
+	// UsernamePlaceholders and GroupPlaceholders.
+	UsernamePattern string
+	GroupPattern    string
+	PasswordLength  int
+}
+
+const (
+	DefaultGroupPattern   = "{class}-group-{n}"
+	DefaultPasswordLength = 12
+)
+
+var (
+	UsernamePlaceholders = []string{"first", "last", "f", "l", "id", "email", "class", "n"}
+	GroupPlaceholders    = []string{"class", "n"}
+)
+
+var rosterColumnAliases = map[string][]string{
+	"name":  {"name", "full name", "fullname", "student", "student name"},
+	"first": {"first name", "firstname", "first", "given name"},
+	"last":  {"last name", "lastname", "last", "surname", "family name"},
+	"id":    {"id", "student id", "student_id", "studentid", "student number", "matriculation number"},
+	"email": {"email", "e-mail", "mail", "email address"},
+	"group": {"group", "team"},
+}
+
+var placeholderRe = regexp.MustCompile(`\{([a-z]+)\}`)
+
+type rosterStudent struct {
+	row   int
+	first string
+	last  string
+	full  string
+	id    string
+	email string
+	group string
+}
+
+// ReadRosterCSV reads a CSV roster. A zero delimiter is detected from the
+// header line, which covers the ; separated exports of spreadsheets.
+func ReadRosterCSV(filePath string, delimiter rune) ([][]string, error) {
+	data, err := os.ReadFile(filePath) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("failed to read roster: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if delimiter == 0 {
+		header, _, _ := bytes.Cut(data, []byte("\n"))
+		delimiter = ','
+		for _, d := range []rune{';', '\t'} {
+			if bytes.Count(header, []byte(string(d))) > bytes.Count(header, []byte(string(delimiter))) {
+				delimiter = d
+			}
+		}
+	}
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = delimiter
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse roster: %w", err)
+	}
+	return records, nil
+}
+
+// ReadRosterXLSX reads a roster from a sheet of an .xlsx workbook, the
+// first one when sheet is empty.
+func ReadRosterXLSX(filePath, sheet string) ([][]string, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, fmt.Errorf("failed to read roster: %w", err)
+	}
+	return readXLSX(filePath, sheet)
+}
+
+// ClassFromRoster builds a class from roster records, the first one being
+// the header. Every student gets a generated password.
+func ClassFromRoster(records [][]string, opts RosterOptions) (schemas.Class, error) {
+	classData := schemas.Class{Name: strings.TrimSpace(opts.ClassName), Desc: opts.Description}
+	if classData.Name == "" {
+		return classData, fmt.Errorf("class name is required")
+	}
+	if opts.GroupSize < 0 || opts.GroupCount < 0 {
+		return classData, fmt.Errorf("group size and count must be positive")
+	}
+	if opts.GroupSize > 0 && opts.GroupCount > 0 {
+		return classData, fmt.Errorf("use either a group size or a group count")
+	}
+	if len(records) < 2 {
+		return classData, fmt.Errorf("the roster has no students")
+	}
+
+	students, err := parseRoster(records, opts)
+	if err != nil {
+		return classData, err
+	}
+	if len(students) == 0 {
+		return classData, fmt.Errorf("the roster has no students")
+	}
+	usernames, err := rosterUsernames(students, opts)
+	if err != nil {
+		return classData, err
+	}
+	groups, err := rosterGroups(students, opts)
+	if err != nil {
+		return classData, err
+	}
+
+	passwordLength := opts.PasswordLength
+	if passwordLength == 0 {
+		passwordLength = DefaultPasswordLength
+	}
+	for _, g := range groups {
+		group := schemas.Group{Name: g.name, Students: make([]schemas.Student, 0, len(g.members))}
+		for _, i := range g.members {
+			s := students[i]
+			password, err := GeneratePassword(passwordLength)
+			if err != nil {
+				return classData, err
+			}
+			student := schemas.Student{UserName: usernames[i], Password: password}
+			if s.full != "" {
+				student.FullName = &s.full
+			}
+			if s.email != "" {
+				student.Email = &s.email
+			}
+			group.Students = append(group.Students, student)
+		}
+		classData.Groups = append(classData.Groups, group)
+	}
+	return classData, nil
+}
+
+func parseRoster(records [][]string, opts RosterOptions) ([]rosterStudent, error) {
+	// spreadsheets may have empty rows above the header
+	first := slices.IndexFunc(records, func(record []string) bool {
+		return slices.ContainsFunc(record, func(cell string) bool { return strings.TrimSpace(cell) != "" })
+	})
+	if first < 0 || first == len(records)-1 {
+		return nil, fmt.Errorf("the roster has no students")
+	}
+	header := records[first]
+	columns := map[string]int{}
+	for _, c := range []struct {
+		key, explicit, flag string
+	}{
+		{"name", opts.NameColumn, "name"},
+		{"first", opts.FirstNameColumn, "first name"},
+		{"last", opts.LastNameColumn, "last name"},
+		{"id", opts.IDColumn, "id"},
+		{"email", opts.EmailColumn, "email"},
+		{"group", opts.GroupColumn, "group"},
+	} {
+		idx := -1
+		if c.explicit != "" {
+			idx = headerIndex(header, c.explicit)
+			if idx < 0 {
+				return nil, fmt.Errorf("%s column %q not found, the roster has the columns %s", c.flag, c.explicit, strings.Join(header, ", "))
+			}
+		} else if c.key != "group" || (opts.GroupSize == 0 && opts.GroupCount == 0) {
+			for _, alias := range rosterColumnAliases[c.key] {
+				if idx = headerIndex(header, alias); idx >= 0 {
+					break
+				}
+			}
+		}
+		columns[c.key] = idx
+	}
+	if columns["name"] < 0 && columns["first"] < 0 && columns["last"] < 0 && columns["id"] < 0 && columns["email"] < 0 {
+		return nil, fmt.Errorf("no name, id or email column found, the roster has the columns %s", strings.Join(header, ", "))
+	}
+	if columns["group"] >= 0 && (opts.GroupSize > 0 || opts.GroupCount > 0) {
+		return nil, fmt.Errorf("a group column can not be combined with a group size or count")
+	}
+	if columns["group"] < 0 && opts.GroupSize == 0 && opts.GroupCount == 0 {
+		return nil, fmt.Errorf("the roster has no group column, set a group column, size or count")
+	}
+
+	var students []rosterStudent
+	for n, record := range records[first+1:] {
+		cell := func(key string) string {
+			if i := columns[key]; i >= 0 && i < len(record) {
+				return strings.Join(strings.Fields(record[i]), " ")
+			}
+			return ""
+		}
+		s := rosterStudent{row: first + n + 2, first: cell("first"), last: cell("last"), id: cell("id"), email: cell("email"), group: cell("group")}
+		name := cell("name")
+		if before, after, ok := strings.Cut(name, ","); ok {
+			// registry exports often write "Last, First"
+			name = strings.TrimSpace(after) + " " + strings.TrimSpace(before)
+		}
+		if fields := strings.Fields(name); len(fields) > 0 {
+			if s.first == "" {
+				s.first = fields[0]
+			}
+			if s.last == "" && len(fields) > 1 {
+				s.last = fields[len(fields)-1]
+			}
+		}
+		s.full = strings.TrimSpace(name)
+		if s.full == "" {
+			s.full = strings.TrimSpace(s.first + " " + s.last)
+		}
+		if s.full == "" && s.id == "" && s.email == "" {
+			continue
+		}
+		if columns["group"] >= 0 && s.group == "" {
+			return nil, fmt.Errorf("row %d: the student %s has no group", s.row, s.display())
+		}
+		students = append(students, s)
+	}
+	return students, nil
+}
+
+func (s rosterStudent) display() string {
+	for _, v := range []string{s.full, s.id, s.email} {
+		if v != "" {
+			return v
+		}
+	}
+	return fmt.Sprintf("in row %d", s.row)
+}
+
+func headerIndex(header []string, name string) int {
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func rosterUsernames(students []rosterStudent, opts RosterOptions) ([]string, error) {
+	pattern := strings.ToLower(opts.UsernamePattern)
+	if pattern == "" {
+		switch {
+		case slices.ContainsFunc(students, func(s rosterStudent) bool { return s.first != "" }):
+			pattern = "{first}-{last}"
+		case slices.ContainsFunc(students, func(s rosterStudent) bool { return s.id != "" }):
+			pattern = "{id}"
+		default:
+			pattern = "{email}"
+		}
+	}
+	if err := checkPlaceholders(pattern, UsernamePlaceholders); err != nil {
+		return nil, fmt.Errorf("invalid username pattern: %w", err)
+	}
+
+	usernames := make([]string, len(students))
+	taken := map[string]bool{}
+	for i, s := range students {
+		localPart, _, _ := strings.Cut(s.email, "@")
+		values := map[string]string{
+			"first": s.first,
+			"last":  s.last,
+			"f":     firstRune(s.first),
+			"l":     firstRune(s.last),
+			"id":    s.id,
+			"email": localPart,
+			"class": opts.ClassName,
+			"n":     strconv.Itoa(i + 1),
+		}
+		name := placeholderRe.ReplaceAllStringFunc(pattern, func(m string) string {
+			return usernamePart(values[m[1:len(m)-1]])
+		})
+		name = strings.Trim(usernamePart(name), "-_")
+		if len(name) < 3 {
+			return nil, fmt.Errorf("row %d: the username %q of %s is shorter than 3 characters, change the username pattern", s.row, name, s.display())
+		}
+		unique := name
+		for n := 2; taken[unique]; n++ {
+			unique = fmt.Sprintf("%s-%d", name, n)
+		}
+		taken[unique] = true
+		usernames[i] = unique
+	}
+	return usernames, nil
+}
+
+type rosterGroup struct {
+	name    string
+	members []int
+}
+
+func rosterGroups(students []rosterStudent, opts RosterOptions) ([]rosterGroup, error) {
+	prefix := opts.ClassName + "-"
+	if opts.GroupSize == 0 && opts.GroupCount == 0 {
+		var groups []rosterGroup
+		index := map[string]int{}
+		for i, s := range students {
+			name := s.group
+			if !strings.HasPrefix(name, prefix) {
+				name = prefix + name
+			}
+			g, ok := index[name]
+			if !ok {
+				g = len(groups)
+				index[name] = g
+				groups = append(groups, rosterGroup{name: name})
+			}
+			groups[g].members = append(groups[g].members, i)
+		}
+		return groups, nil
+	}
+
+	pattern := opts.GroupPattern
+	if pattern == "" {
+		pattern = DefaultGroupPattern
+	}
+	if err := checkPlaceholders(pattern, GroupPlaceholders); err != nil {
+		return nil, fmt.Errorf("invalid group pattern: %w", err)
+	}
+	count := opts.GroupCount
+	if opts.GroupSize > 0 {
+		count = (len(students) + opts.GroupSize - 1) / opts.GroupSize
+	}
+	if count > 1 && !strings.Contains(pattern, "{n}") {
+		return nil, fmt.Errorf("the group pattern needs {n} for more than one group")
+	}
+	if count > len(students) {
+		return nil, fmt.Errorf("%d groups are more than the %d students", count, len(students))
+	}
+
+	order := make([]int, len(students))
+	for i := range order {
+		order[i] = i
+	}
+	if opts.Shuffle {
+		mrand.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+
+	// sizes differ by one at most, 10 students in groups of 4 are 4, 3, 3
+	groups := make([]rosterGroup, count)
+	start := 0
+	for g := range groups {
+		size := len(students) / count
+		if g < len(students)%count {
+			size++
+		}
+		name := placeholderRe.ReplaceAllStringFunc(pattern, func(m string) string {
+			if m == "{n}" {
+				return strconv.Itoa(g + 1)
+			}
+			return opts.ClassName
+		})
+		if !strings.HasPrefix(name, prefix) {
+			return nil, fmt.Errorf("group names have to start with %q, change the group pattern", prefix)
+		}
+		groups[g] = rosterGroup{name: name, members: order[start : start+size]}
+		start += size
+	}
+	return groups, nil
+}
+
+func checkPlaceholders(pattern string, allowed []string) error {
+	for _, m := range placeholderRe.FindAllStringSubmatch(pattern, -1) {
+		if !slices.Contains(allowed, m[1]) {
+			return fmt.Errorf("unknown placeholder {%s}, use one of {%s}", m[1], strings.Join(allowed, "}, {"))
+		}
+	}
+	return nil
+}
+
+var stripMarks = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// usernamePart lowercases s and keeps only what GNS3 allows in usernames,
+// ASCII letters, digits, dashes and underscores. Accents are dropped, ß
+// becomes ss and spaces become dashes.
+func usernamePart(s string) string {
+	s = strings.ReplaceAll(strings.ToLower(s), "ß", "ss")
+	if plain, _, err := transform.String(stripMarks, s); err == nil {
+		s = plain
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			sb.WriteRune(r)
+		case r == ' ':
+			sb.WriteRune('-')
+		}
+	}
+	return sb.String()
+}
+
+func firstRune(s string) string {
+	for _, r := range s {
+		return string(r)
+	}
+	return ""
+}
+
+const (
+	passwordLower  = "abcdefghijkmnpqrstuvwxyz"
+	passwordUpper  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	passwordDigits = "23456789"
+)
+
+// GeneratePassword returns a random password that passes
+// utils.ValidatePassword and has an upper case letter. Characters that are
+// easy to confuse on a printout, like l, 1, O and 0, are left out.
+func GeneratePassword(length int) (string, error) {
+	if length < 8 {
+		return "", fmt.Errorf("passwords need at least 8 characters")
+	}
+	charset := passwordLower + passwordUpper + passwordDigits
+	limit := big.NewInt(int64(len(charset)))
+	for {
+		b := make([]byte, length)
+		for i := range b {
+			n, err := rand.Int(rand.Reader, limit)
+			if err != nil {
+				return "", fmt.Errorf("failed to generate password: %w", err)
+			}
+			b[i] = charset[n.Int64()]
+		}
+		password := string(b)
+		if utils.ValidatePassword(password) && strings.ContainsAny(password, passwordUpper) {
+			return password, nil
+		}
+	}
+}
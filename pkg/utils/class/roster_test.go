@@ -0,0 +1,417 @@
+package class
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadRosterCSV(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		delimiter rune
+		want      [][]string
+	}{
+		{
+			name:    "comma",
+			content: "Name,Email\nJane Doe,jane@school.edu\n",
+			want:    [][]string{{"Name", "Email"}, {"Jane Doe", "jane@school.edu"}},
+		},
+		{
+			name:    "semicolon detected",
+			content: "Name;Email;Group\n\"Doe, Jane\";jane@school.edu;A\n",
+			want:    [][]string{{"Name", "Email", "Group"}, {"Doe, Jane", "jane@school.edu", "A"}},
+		},
+		{
+			name:    "tab detected",
+			content: "Name\tGroup\nJane Doe\tA\n",
+			want:    [][]string{{"Name", "Group"}, {"Jane Doe", "A"}},
+		},
+		{
+			name:      "explicit delimiter",
+			content:   "Name|Group\nJane, Doe|A\n",
+			delimiter: '|',
+			want:      [][]string{{"Name", "Group"}, {"Jane, Doe", "A"}},
+		},
+		{
+			name:    "byte order mark and ragged rows",
+			content: "\xef\xbb\xbfName,Email,Group\nJane Doe, jane@school.edu\n",
+			want:    [][]string{{"Name", "Email", "Group"}, {"Jane Doe", "jane@school.edu"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadRosterCSV(writeFile(t, "roster.csv", tt.content), tt.delimiter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ReadRosterCSV(filepath.Join(t.TempDir(), "missing.csv"), 0); err == nil {
+		t.Error("read a missing roster")
+	}
+	if _, err := ReadRosterCSV(writeFile(t, "bad.csv", "Name\n\"Jane\n"), 0); err == nil {
+		t.Error("read a roster with an unclosed quote")
+	}
+}
+
+// writeWorkbook writes an .xlsx file with the given sheets, sheet XML by
+// name in order, and shared strings if sst is set.
+func writeWorkbook(t *testing.T, sst string, sheets ...[2]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "roster.xlsx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	add := func(name, content string) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var workbook, rels strings.Builder
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, s := range sheets {
+		n := strconv.Itoa(i + 1)
+		id := "rId" + n
+		target := "worksheets/sheet" + n + ".xml"
+		workbook.WriteString(`<sheet name="` + s[0] + `" sheetId="` + n + `" r:id="` + id + `"/>`)
+		rels.WriteString(`<Relationship Id="` + id + `" Target="` + target + `"/>`)
+		add("xl/"+target, `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`+s[1]+`</sheetData></worksheet>`)
+	}
+	workbook.WriteString(`</sheets></workbook>`)
+	rels.WriteString(`</Relationships>`)
+	add("xl/workbook.xml", workbook.String())
+	add("xl/_rels/workbook.xml.rels", rels.String())
+	if sst != "" {
+		add("xl/sharedStrings.xml", `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`+sst+`</sst>`)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadRosterXLSX(t *testing.T) {
+	shared := `<si><t>Name</t></si><si><t>Group</t></si><si><r><t>Jane </t></r><r><t>Doe</t></r></si>`
+	tests := []struct {
+		name    string
+		sst     string
+		sheets  [][2]string
+		sheet   string
+		want    [][]string
+		wantErr string
+	}{
+		{
+			name:   "shared strings",
+			sst:    shared,
+			sheets: [][2]string{{"Roster", `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row><row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>3</v></c></row>`}},
+			want:   [][]string{{"Name", "Group"}, {"Jane Doe", "3"}},
+		},
+		{
+			name:   "inline strings and booleans",
+			sheets: [][2]string{{"Roster", `<row r="1"><c r="A1" t="inlineStr"><is><t>Name</t></is></c><c r="B1" t="inlineStr"><is><t>Active</t></is></c></row><row r="2"><c r="A2" t="inlineStr"><is><r><t>Jane</t></r><r><t> Doe</t></r></is></c><c r="B2" t="b"><v>1</v></c></row>`}},
+			want:   [][]string{{"Name", "Active"}, {"Jane Doe", "true"}},
+		},
+		{
+			name:   "sparse cells",
+			sheets: [][2]string{{"Roster", `<row r="1"><c r="A1" t="inlineStr"><is><t>Name</t></is></c><c r="C1" t="inlineStr"><is><t>Group</t></is></c></row><row r="2"><c r="C2"><v>2</v></c></row>`}},
+			want:   [][]string{{"Name", "", "Group"}, {"", "", "2"}},
+		},
+		{
+			name:   "cells without reference",
+			sheets: [][2]string{{"Roster", `<row><c t="inlineStr"><is><t>Name</t></is></c><c t="inlineStr"><is><t>Group</t></is></c></row>`}},
+			want:   [][]string{{"Name", "Group"}},
+		},
+		{
+			name: "sheet by name",
+			sheets: [][2]string{
+				{"Notes", `<row r="1"><c r="A1" t="inlineStr"><is><t>ignore me</t></is></c></row>`},
+				{"Roster", `<row r="1"><c r="AA1" t="inlineStr"><is><t>Name</t></is></c></row>`},
+			},
+			sheet: "roster",
+			want:  [][]string{append(make([]string, 26), "Name")},
+		},
+		{
+			name:   "rows keep their number",
+			sheets: [][2]string{{"Roster", `<row r="2"><c r="A2" t="inlineStr"><is><t>Name</t></is></c></row><row r="5"><c r="A5" t="inlineStr"><is><t>Jane</t></is></c></row><row><c t="inlineStr"><is><t>John</t></is></c></row>`}},
+			want:   [][]string{nil, {"Name"}, nil, nil, {"Jane"}, {"John"}},
+		},
+		{
+			name:    "row number out of range",
+			sheets:  [][2]string{{"Roster", `<row r="2000000"><c r="A2000000"><v>1</v></c></row>`}},
+			wantErr: "invalid row number 2000000",
+		},
+		{
+			name:    "unknown sheet",
+			sheets:  [][2]string{{"Roster", `<row r="1"/>`}},
+			sheet:   "Grades",
+			wantErr: `sheet "Grades" not found, the workbook has Roster`,
+		},
+		{
+			name:    "shared string out of range",
+			sst:     shared,
+			sheets:  [][2]string{{"Roster", `<row r="1"><c r="A1" t="s"><v>7</v></c></row>`}},
+			wantErr: "invalid shared string in cell A1",
+		},
+		{
+			name:    "no workbook parts",
+			wantErr: "the workbook has no sheets",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadRosterXLSX(writeWorkbook(t, tt.sst, tt.sheets...), tt.sheet)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %q, %v, want an error containing %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ReadRosterXLSX(writeFile(t, "roster.xlsx", "Name,Group\n"), ""); err == nil {
+		t.Error("read a CSV file as a workbook")
+	}
+}
+
+func TestClassFromRoster(t *testing.T) {
+	tests := []struct {
+		name    string
+		records [][]string
+		opts    RosterOptions
+		// want maps group names to the usernames of their students
+		want    map[string][]string
+		wantErr string
+	}{
+		{
+			name: "group column",
+			records: [][]string{
+				{"Name", "E-Mail", "Group"},
+				{"Jane Doe", "jane@school.edu", "A"},
+				{"Doe, John", "john@school.edu", "B"},
+				{"Max Mustermann", "", "A"},
+			},
+			opts: RosterOptions{ClassName: "CS"},
+			want: map[string][]string{"CS-A": {"jane-doe", "max-mustermann"}, "CS-B": {"john-doe"}},
+		},
+		{
+			name:    "group names with the class prefix",
+			records: [][]string{{"Name", "Team"}, {"Jane Doe", "CS-red"}},
+			opts:    RosterOptions{ClassName: "CS"},
+			want:    map[string][]string{"CS-red": {"jane-doe"}},
+		},
+		{
+			name:    "mapped columns",
+			records: [][]string{{"Student", "Matr.Nr.", "Kurs"}, {"Jane Doe", "4711", "1"}, {"John Doe", "4712", "2"}},
+			opts:    RosterOptions{ClassName: "CS", NameColumn: "student", IDColumn: "Matr.Nr.", GroupColumn: "Kurs", UsernamePattern: "s{id}"},
+			want:    map[string][]string{"CS-1": {"s4711"}, "CS-2": {"s4712"}},
+		},
+		{
+			name:    "first and last name columns",
+			records: [][]string{{"First Name", "Last Name"}, {"Jürgen", "Groß"}, {"Zoë", "Ølund"}},
+			opts:    RosterOptions{ClassName: "CS", GroupCount: 1, UsernamePattern: "{f}{last}"},
+			want:    map[string][]string{"CS-group-1": {"jgross", "zlund"}},
+		},
+		{
+			name:    "ids without names",
+			records: [][]string{{"Student ID", "Email"}, {"1001", "a@school.edu"}, {"1002", "b@school.edu"}},
+			opts:    RosterOptions{ClassName: "CS", GroupCount: 1},
+			want:    map[string][]string{"CS-group-1": {"1001", "1002"}},
+		},
+		{
+			name:    "emails only",
+			records: [][]string{{"Mail"}, {"jane.doe@school.edu"}, {"j.smith@school.edu"}},
+			opts:    RosterOptions{ClassName: "CS", GroupCount: 1},
+			want:    map[string][]string{"CS-group-1": {"janedoe", "jsmith"}},
+		},
+		{
+			name:    "class and position placeholders",
+			records: [][]string{{"Name"}, {"Jane Doe"}, {"John Doe"}},
+			opts:    RosterOptions{ClassName: "CS", GroupCount: 1, UsernamePattern: "{class}_{n}"},
+			want:    map[string][]string{"CS-group-1": {"cs_1", "cs_2"}},
+		},
+		{
+			name:    "duplicate usernames",
+			records: [][]string{{"Name"}, {"Jane Doe"}, {"Jane Doe"}, {"Jane  Doe"}},
+			opts:    RosterOptions{ClassName: "CS", GroupCount: 1},
+			want:    map[string][]string{"CS-group-1": {"jane-doe", "jane-doe-2", "jane-doe-3"}},
+		},
+		{
+			name:    "empty rows are skipped",
+			records: [][]string{{"Name", "Group"}, {"", ""}, {"Jane Doe", "A"}, {}},
+			opts:    RosterOptions{ClassName: "CS"},
+			want:    map[string][]string{"CS-A": {"jane-doe"}},
+		},
+		{
+			name:    "group size",
+			records: [][]string{{"ID"}, {"s01"}, {"s02"}, {"s03"}, {"s04"}, {"s05"}},
+			opts:    RosterOptions{ClassName: "CS", GroupSize: 2},
+			want:    map[string][]string{"CS-group-1": {"s01", "s02"}, "CS-group-2": {"s03", "s04"}, "CS-group-3": {"s05"}},
+		},
+		{
+			name:    "group size spreads the rest",
+			records: [][]string{{"ID"}, {"s01"}, {"s02"}, {"s03"}, {"s04"}, {"s05"}, {"s06"}, {"s07"}, {"s08"}, {"s09"}, {"s10"}},
+			opts:    RosterOptions{ClassName: "CS", GroupSize: 4},
+			want:    map[string][]string{"CS-group-1": {"s01", "s02", "s03", "s04"}, "CS-group-2": {"s05", "s06", "s07"}, "CS-group-3": {"s08", "s09", "s10"}},
+		},
+		{
+			name:    "group count with a pattern",
+			records: [][]string{{"ID"}, {"s01"}, {"s02"}, {"s03"}, {"s04"}, {"s05"}},
+			opts:    RosterOptions{ClassName: "CS", GroupCount: 2, GroupPattern: "{class}-team{n}"},
+			want:    map[string][]string{"CS-team1": {"s01", "s02", "s03"}, "CS-team2": {"s04", "s05"}},
+		},
+		{
+			name:    "group size ignores the group column",
+			records: [][]string{{"ID", "Group"}, {"s01", "A"}, {"s02", "B"}},
+			opts:    RosterOptions{ClassName: "CS", GroupSize: 2},
+			want:    map[string][]string{"CS-group-1": {"s01", "s02"}},
+		},
+		{name: "no class name", records: [][]string{{"Name", "Group"}, {"Jane Doe", "A"}}, wantErr: "class name is required"},
+		{name: "size and count", records: [][]string{{"Name"}, {"Jane Doe"}}, opts: RosterOptions{ClassName: "CS", GroupSize: 2, GroupCount: 2}, wantErr: "either a group size or a group count"},
+		{name: "negative size", records: [][]string{{"Name"}, {"Jane Doe"}}, opts: RosterOptions{ClassName: "CS", GroupSize: -1}, wantErr: "must be positive"},
+		{name: "header only", records: [][]string{{"Name", "Group"}}, opts: RosterOptions{ClassName: "CS"}, wantErr: "no students"},
+		{name: "only empty rows", records: [][]string{{"Name", "Group"}, {"", ""}}, opts: RosterOptions{ClassName: "CS"}, wantErr: "no students"},
+		{name: "mapped column missing", records: [][]string{{"Name", "Group"}, {"Jane Doe", "A"}}, opts: RosterOptions{ClassName: "CS", IDColumn: "Matr.Nr."}, wantErr: `id column "Matr.Nr." not found, the roster has the columns Name, Group`},
+		{name: "no name column", records: [][]string{{"Phone", "Group"}, {"123", "A"}}, opts: RosterOptions{ClassName: "CS"}, wantErr: "no name, id or email column"},
+		{name: "group column with size", records: [][]string{{"Name", "Group"}, {"Jane Doe", "A"}}, opts: RosterOptions{ClassName: "CS", GroupColumn: "Group", GroupSize: 2}, wantErr: "can not be combined"},
+		{name: "no groups", records: [][]string{{"Name"}, {"Jane Doe"}}, opts: RosterOptions{ClassName: "CS"}, wantErr: "no group column"},
+		{name: "student without group", records: [][]string{{"Name", "Group"}, {"Jane Doe", "A"}, {"John Doe", ""}}, opts: RosterOptions{ClassName: "CS"}, wantErr: "row 3: the student John Doe has no group"},
+		{name: "row numbers below empty rows", records: [][]string{nil, {"", ""}, {"Name", "Group"}, {"Jane Doe", "A"}, nil, {"John Doe", ""}}, opts: RosterOptions{ClassName: "CS"}, wantErr: "row 6: the student John Doe has no group"},
+		{name: "only empty rows", records: [][]string{nil, {""}}, opts: RosterOptions{ClassName: "CS", GroupCount: 1}, wantErr: "the roster has no students"},
+		{name: "unknown username placeholder", records: [][]string{{"Name"}, {"Jane Doe"}}, opts: RosterOptions{ClassName: "CS", GroupCount: 1, UsernamePattern: "{nick}"}, wantErr: "unknown placeholder {nick}"},
+		{name: "username too short", records: [][]string{{"Name"}, {"Jane Doe"}}, opts: RosterOptions{ClassName: "CS", GroupCount: 1, UsernamePattern: "{f}{l}"}, wantErr: `row 2: the username "jd" of Jane Doe is shorter than 3 characters`},
+		{name: "unknown group placeholder", records: [][]string{{"Name"}, {"Jane Doe"}}, opts: RosterOptions{ClassName: "CS", GroupCount: 1, GroupPattern: "{class}-{id}"}, wantErr: "unknown placeholder {id}"},
+		{name: "group pattern without number", records: [][]string{{"Name"}, {"Jane Doe"}, {"John Doe"}}, opts: RosterOptions{ClassName: "CS", GroupCount: 2, GroupPattern: "{class}-team"}, wantErr: "needs {n}"},
+		{name: "group pattern without prefix", records: [][]string{{"Name"}, {"Jane Doe"}}, opts: RosterOptions{ClassName: "CS", GroupCount: 1, GroupPattern: "team-{n}"}, wantErr: `have to start with "CS-"`},
+		{name: "more groups than students", records: [][]string{{"Name"}, {"Jane Doe"}}, opts: RosterOptions{ClassName: "CS", GroupCount: 2}, wantErr: "2 groups are more than the 1 students"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classData, err := ClassFromRoster(tt.records, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got %+v, %v, want an error containing %q", classData, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string][]string{}
+			for _, g := range classData.Groups {
+				names := []string{}
+				for _, s := range g.Students {
+					names = append(names, s.UserName)
+					if !utils.ValidatePassword(s.Password) || len(s.Password) != DefaultPasswordLength {
+						t.Errorf("password %q of %s", s.Password, s.UserName)
+					}
+				}
+				got[g.Name] = names
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groups %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassFromRosterStudentFields(t *testing.T) {
+	records := [][]string{{"Name", "Email", "Group"}, {"Doe,  Jane ", "jane@school.edu", "A"}, {"", "john@school.edu", "A"}}
+	classData, err := ClassFromRoster(records, RosterOptions{ClassName: "CS", Description: "networks", UsernamePattern: "{email}", PasswordLength: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if classData.Name != "CS" || classData.Desc != "networks" || len(classData.Groups) != 1 {
+		t.Fatalf("class %+v", classData)
+	}
+	jane, john := classData.Groups[0].Students[0], classData.Groups[0].Students[1]
+	if jane.FullName == nil || *jane.FullName != "Jane Doe" || jane.Email == nil || *jane.Email != "jane@school.edu" {
+		t.Errorf("jane %+v", jane)
+	}
+	if john.FullName != nil || john.Email == nil || john.UserName != "john" {
+		t.Errorf("john %+v", john)
+	}
+	if len(jane.Password) != 16 || jane.Password == john.Password {
+		t.Errorf("passwords %q and %q", jane.Password, john.Password)
+	}
+}
+
+func TestClassFromRosterShuffle(t *testing.T) {
+	records := [][]string{{"ID"}}
+	for _, id := range []string{"s01", "s02", "s03", "s04", "s05", "s06", "s07", "s08"} {
+		records = append(records, []string{id})
+	}
+	classData, err := ClassFromRoster(records, RosterOptions{ClassName: "CS", GroupSize: 3, Shuffle: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	var sizes []int
+	for _, g := range classData.Groups {
+		sizes = append(sizes, len(g.Students))
+		for _, s := range g.Students {
+			seen[s.UserName] = true
+		}
+	}
+	if !reflect.DeepEqual(sizes, []int{3, 3, 2}) || len(seen) != 8 {
+		t.Errorf("group sizes %v with %d students, want 3, 3, 2 with all 8", sizes, len(seen))
+	}
+}
+
+func TestGeneratePassword(t *testing.T) {
+	for _, length := range []int{8, 12, 64} {
+		for range 50 {
+			p, err := GeneratePassword(length)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(p) != length || !utils.ValidatePassword(p) || !strings.ContainsAny(p, passwordUpper) {
+				t.Fatalf("password %q of length %d", p, length)
+			}
+			if strings.ContainsAny(p, "lI1O0o") {
+				t.Fatalf("password %q has a confusable character", p)
+			}
+		}
+	}
+	if _, err := GeneratePassword(7); err == nil {
+		t.Error("generated a password shorter than 8 characters")
+	}
+}
@@ -0,0 +1,202 @@
+package class
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// readXLSX returns the cell texts of a sheet in an .xlsx workbook, the
+// first one when sheet is empty. Only what roster exports need is
+// supported: shared, inline and plain cell values without formatting.
+func readXLSX(filePath, sheet string) ([][]string, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+	defer func() {
+		_ = zr.Close()
+	}()
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name  string     `xml:"name,attr"`
+			Attrs []xml.Attr `xml:",any,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeZipXML(files, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeZipXML(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+
+	relID := ""
+	names := make([]string, 0, len(workbook.Sheets))
+	for _, s := range workbook.Sheets {
+		names = append(names, s.Name)
+		if relID != "" || (sheet != "" && !strings.EqualFold(s.Name, sheet)) {
+			continue
+		}
+		for _, a := range s.Attrs {
+			if a.Name.Local == "id" {
+				relID = a.Value
+			}
+		}
+	}
+	if relID == "" {
+		if sheet != "" {
+			return nil, fmt.Errorf("sheet %q not found, the workbook has %s", sheet, strings.Join(names, ", "))
+		}
+		return nil, fmt.Errorf("the workbook has no sheets")
+	}
+	sheetPath := ""
+	for _, r := range rels.Relationships {
+		if r.ID == relID {
+			sheetPath = r.Target
+		}
+	}
+	if strings.HasPrefix(sheetPath, "/") {
+		sheetPath = strings.TrimPrefix(sheetPath, "/")
+	} else {
+		sheetPath = path.Join("xl", sheetPath)
+	}
+
+	var shared []string
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := decodeZipXML(files, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		for _, si := range sst.Items {
+			shared = append(shared, si.String())
+		}
+	}
+
+	var ws struct {
+		Rows []struct {
+			Ref   string `xml:"r,attr"`
+			Cells []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline xlsxText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeZipXML(files, sheetPath, &ws); err != nil {
+		return nil, err
+	}
+
+	records := make([][]string, 0, len(ws.Rows))
+	for _, row := range ws.Rows {
+		// empty rows are left out of the sheet, keep their place so errors
+		// name the row shown in the spreadsheet
+		if n, err := strconv.Atoi(row.Ref); err == nil && n > len(records) {
+			if n > maxXLSXRows {
+				return nil, fmt.Errorf("invalid row number %s", row.Ref)
+			}
+			for len(records) < n-1 {
+				records = append(records, nil)
+			}
+		}
+		var record []string
+		for _, c := range row.Cells {
+			col := len(record)
+			if i := columnIndex(c.Ref); i >= 0 {
+				col = i
+			}
+			if col >= 16384 {
+				return nil, fmt.Errorf("invalid cell reference %s", c.Ref)
+			}
+			for len(record) <= col {
+				record = append(record, "")
+			}
+			switch c.Type {
+			case "s":
+				i, err := strconv.Atoi(c.Value)
+				if err != nil || i < 0 || i >= len(shared) {
+					return nil, fmt.Errorf("invalid shared string in cell %s", c.Ref)
+				}
+				record[col] = shared[i]
+			case "inlineStr":
+				record[col] = c.Inline.String()
+			case "b":
+				record[col] = strconv.FormatBool(c.Value == "1")
+			default:
+				record[col] = c.Value
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// maxXLSXRows is the number of rows of a sheet in Excel.
+const maxXLSXRows = 1 << 20
+
+// xlsxText is a string item, either plain or split into formatted runs.
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var sb strings.Builder
+	for _, r := range t.Runs {
+		sb.WriteString(r.Text)
+	}
+	return sb.String()
+}
+
+func decodeZipXML(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("invalid workbook: %s is missing", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	if err := xml.NewDecoder(io.LimitReader(rc, 64<<20)).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return nil
+}
+
+// columnIndex turns the letters of a cell reference like AB12 into a zero
+// based column number, -1 without letters.
+func columnIndex(ref string) int {
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		n = n*26 + int(r-'A'+1)
+	}
+	return n - 1
+}
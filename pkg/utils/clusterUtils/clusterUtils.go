@@ -2,6 +2,7 @@ package clusterutils
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/stefanistkuhl/gns3util/pkg/cluster/db"
@@ -91,12 +92,22 @@ func TransformNodeExercises(rows []sqlc.GetNodeExercisesForClusterRow, className
 		if row.State.Valid {
 			state = row.State.String
 		}
+		var since sql.NullTime
+		switch state {
+		case "running":
+			since = row.StartedAt
+		case "stopped":
+			since = row.StoppedAt
+		case "completed":
+			since = row.CompletedAt
+		}
 
 		current.Exercises = append(current.Exercises, db.ExerciseItem{
 			Name:        row.ExerciseName,
 			ProjectUUID: row.ProjectUuid,
 			GroupName:   row.GroupName,
 			State:       state,
+			Since:       since.Time,
 		})
 	}
 	if current != nil {
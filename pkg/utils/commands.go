@@ -6,7 +6,9 @@ import (
 )
 
 type CommandConfig struct {
-	Method   api.HTTPMethod
+	Method api.HTTPMethod
+	// Args is the number of positional arguments Endpoint reads.
+	Args     int
 	Endpoint func(ep endpoints.Endpoints, args []string) string
 }
 
@@ -37,6 +39,7 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getUser": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.User(args[0])
 		},
@@ -49,6 +52,7 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getGroupMemberships": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.GroupMemberships(args[0])
 		},
@@ -61,12 +65,14 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getGroup": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Group(args[0])
 		},
 	},
 	"getGroupMembers": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.GroupMembers(args[0])
 		},
@@ -79,18 +85,21 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getProject": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Project(args[0])
 		},
 	},
 	"getProjectStats": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.ProjectStats(args[0])
 		},
 	},
 	"getProjectLocked": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.ProjectLocked(args[0])
 		},
@@ -103,6 +112,7 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getRole": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Role(args[0])
 		},
@@ -115,6 +125,7 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getRolePrivs": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.RolePrivs(args[0])
 		},
@@ -127,6 +138,7 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getAce": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.ACE(args[0])
 		},
@@ -139,12 +151,14 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getImages": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Images(args[0])
 		},
 	},
 	"getImage": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Image(args[0])
 		},
@@ -157,6 +171,7 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getTemplate": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Template(args[0])
 		},
@@ -175,78 +190,91 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getPool": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Pool(args[0])
 		},
 	},
 	"getPoolResources": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.PoolResources(args[0])
 		},
 	},
 	"getNodes": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Nodes(args[0])
 		},
 	},
 	"getNode": {
 		Method: api.GET,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Node(args[0], args[1])
 		},
 	},
 	"getNodeLinks": {
 		Method: api.GET,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.NodeLinks(args[0], args[1])
 		},
 	},
 	"getNodeAutoIdlePc": {
 		Method: api.GET,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.NodeAutoIdlePc(args[0], args[1])
 		},
 	},
 	"getNodeAutoIdlePcProposals": {
 		Method: api.GET,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.NodeAutoIdlePcProposals(args[0], args[1])
 		},
 	},
 	"getLinks": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Links(args[0])
 		},
 	},
 	"getLink": {
 		Method: api.GET,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Link(args[0], args[1])
 		},
 	},
 	"getLinkIface": {
 		Method: api.GET,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.LinkIface(args[0], args[1])
 		},
 	},
 	"getDrawing": {
 		Method: api.GET,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Drawing(args[0], args[1])
 		},
 	},
 	"getDrawings": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Drawings(args[0])
 		},
 	},
 	"getLinkFilters": {
 		Method: api.GET,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.LinkFilters(args[0], args[1])
 		},
@@ -259,12 +287,14 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getSymbol": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Symbol(args[0])
 		},
 	},
 	"getSymbolDimensions": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.SymbolDimensions(args[0])
 		},
@@ -277,48 +307,56 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getSnapshots": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Snapshots(args[0])
 		},
 	},
 	"exportProject": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.ProjectExport(args[0])
 		},
 	},
 	"getProjectFile": {
 		Method: api.GET,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.ProjectFile(args[0], args[1])
 		},
 	},
 	"getNodeFile": {
 		Method: api.GET,
+		Args:   3,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.NodeFile(args[0], args[1], args[2])
 		},
 	},
 	"streamPcap": {
 		Method: api.GET,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.StreamPcap(args[0], args[1])
 		},
 	},
 	"getCompute": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Compute(args[0])
 		},
 	},
 	"getComputeDockerImgs": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.ComputeDocker(args[0])
 		},
 	},
 	"getComputeVirtualboxVms": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.ComputeVirtualbox(args[0])
 		},
@@ -331,18 +369,21 @@ var commandMap = map[string]CommandConfig{
 	},
 	"getAppliance": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.Appliance(args[0])
 		},
 	},
 	"getComputeVmwareVms": {
 		Method: api.GET,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Get.ComputeVmware(args[0])
 		},
 	},
 	"lockProject": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.LockProject(args[0])
 		},
@@ -373,6 +414,7 @@ var commandMap = map[string]CommandConfig{
 	},
 	"createQemuImage": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.CreateQemuImage(args[0])
 		},
@@ -391,42 +433,56 @@ var commandMap = map[string]CommandConfig{
 	},
 	"createProjectNodeFromTemplate": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.CreateProjectNodeFromTemplate(args[0], args[1])
 		},
 	},
 	"createNode": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.CreateNode(args[0])
 		},
 	},
 	"createDiskImage": {
 		Method: api.POST,
+		Args:   3,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.CreateDiskImage(args[0], args[1], args[2])
 		},
 	},
 	"createLink": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.CreateLink(args[0])
 		},
 	},
 	"createDrawing": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.CreateDrawing(args[0])
 		},
 	},
 	"createSnapshot": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.CreateSnapshot(args[0])
 		},
 	},
+	"restoreSnapshot": {
+		Method: api.POST,
+		Args:   2,
+		Endpoint: func(ep endpoints.Endpoints, args []string) string {
+			return ep.Post.RestoreSnapshot(args[0], args[1])
+		},
+	},
 	"createCompute": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			// args[0] should be stringified bool for connect; endpoint formats it properly
 			return ep.Post.CreateCompute(args[0] == "true")
@@ -440,6 +496,7 @@ var commandMap = map[string]CommandConfig{
 	},
 	"closeProject": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.CloseProject(args[0])
 		},
@@ -458,72 +515,84 @@ var commandMap = map[string]CommandConfig{
 	},
 	"updateUser": {
 		Method: api.PUT,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdateUser(args[0])
 		},
 	},
 	"updateGroup": {
 		Method: api.PUT,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdateGroup(args[0])
 		},
 	},
 	"updateRole": {
 		Method: api.PUT,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdateRole(args[0])
 		},
 	},
 	"updateACE": {
 		Method: api.PUT,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdateACE(args[0])
 		},
 	},
 	"updateTemplate": {
 		Method: api.PUT,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdateTemplate(args[0])
 		},
 	},
 	"updateProject": {
 		Method: api.PUT,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdateProject(args[0])
 		},
 	},
 	"updateNode": {
 		Method: api.PUT,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdateNode(args[0], args[1])
 		},
 	},
 	"updateQemuDiskImage": {
 		Method: api.PUT,
+		Args:   3,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdateQemuDiskImage(args[0], args[1], args[2])
 		},
 	},
 	"updateLink": {
 		Method: api.PUT,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdateLink(args[0], args[1])
 		},
 	},
 	"updateDrawing": {
 		Method: api.PUT,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdateDrawing(args[0], args[1])
 		},
 	},
 	"updateCompute": {
 		Method: api.PUT,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdateCompute(args[0])
 		},
 	},
 	"updatePool": {
 		Method: api.PUT,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.UpdatePool(args[0])
 		},
@@ -531,18 +600,21 @@ var commandMap = map[string]CommandConfig{
 	// Add commands
 	"addGroupMember": {
 		Method: api.PUT,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.AddGroupMember(args[0], args[1])
 		},
 	},
 	"addPrivilege": {
 		Method: api.PUT,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.AddPrivilege(args[0], args[1])
 		},
 	},
 	"addToPool": {
 		Method: api.PUT,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Put.AddToPool(args[0], args[1])
 		},
@@ -550,96 +622,112 @@ var commandMap = map[string]CommandConfig{
 	// Delete commands
 	"deleteUser": {
 		Method: api.DELETE,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteUser(args[0])
 		},
 	},
 	"deleteGroup": {
 		Method: api.DELETE,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteGroup(args[0])
 		},
 	},
 	"deleteRole": {
 		Method: api.DELETE,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteRole(args[0])
 		},
 	},
 	"deleteTemplate": {
 		Method: api.DELETE,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteTemplate(args[0])
 		},
 	},
 	"deleteProject": {
 		Method: api.DELETE,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteProject(args[0])
 		},
 	},
 	"deleteCompute": {
 		Method: api.DELETE,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteCompute(args[0])
 		},
 	},
 	"deleteImage": {
 		Method: api.DELETE,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteImage(args[0])
 		},
 	},
 	"deleteNode": {
 		Method: api.DELETE,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteNode(args[0], args[1])
 		},
 	},
 	"deleteLink": {
 		Method: api.DELETE,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteLink(args[0], args[1])
 		},
 	},
 	"deleteDrawing": {
 		Method: api.DELETE,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteDrawing(args[0], args[1])
 		},
 	},
 	"deletePool": {
 		Method: api.DELETE,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeletePool(args[0])
 		},
 	},
 	"deletePoolResource": {
 		Method: api.DELETE,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeletePoolResource(args[0], args[1])
 		},
 	},
 	"deleteACE": {
 		Method: api.DELETE,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteACE(args[0])
 		},
 	},
 	"deleteRolePrivilege": {
 		Method: api.DELETE,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteRolePrivilege(args[0], args[1])
 		},
 	},
 	"deleteUserFromGroup": {
 		Method: api.DELETE,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteUserFromGroup(args[0], args[1])
 		},
 	},
 	"deleteSnapshot": {
 		Method: api.DELETE,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Delete.DeleteSnapshot(args[0], args[1])
 		},
@@ -677,6 +765,7 @@ var commandMap = map[string]CommandConfig{
 	},
 	"openProject": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.OpenProject(args[0])
 		},
@@ -689,120 +778,140 @@ var commandMap = map[string]CommandConfig{
 	},
 	"duplicateProject": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.DuplicateProject(args[0])
 		},
 	},
 	"projectImport": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.ProjectImport(args[0])
 		},
 	},
 	"duplicateTemplate": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.DuplicateTemplate(args[0])
 		},
 	},
 	"duplicateNode": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.DuplicateNode(args[0], args[1])
 		},
 	},
 	"startAllNodes": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.StartNodes(args[0])
 		},
 	},
 	"stopAllNodes": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.StopNodes(args[0])
 		},
 	},
 	"suspendAllNodes": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.SuspendNodes(args[0])
 		},
 	},
 	"reloadAllNodes": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.ReloadNodes(args[0])
 		},
 	},
 	"startNode": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.StartNode(args[0], args[1])
 		},
 	},
 	"stopNode": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.StopNode(args[0], args[1])
 		},
 	},
 	"suspendNode": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.SuspendNode(args[0], args[1])
 		},
 	},
 	"reloadNode": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.ReloadNode(args[0], args[1])
 		},
 	},
 	"isolateNode": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.IsolateNode(args[0], args[1])
 		},
 	},
 	"unisolateNode": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.UnisolateNode(args[0], args[1])
 		},
 	},
 	"resetConsoleAllNodes": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.NodesConsoleReset(args[0])
 		},
 	},
 	"resetConsoleNode": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.NodeConsoleReset(args[0], args[1])
 		},
 	},
 	"resetLink": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.ResetLink(args[0], args[1])
 		},
 	},
 	"startCapture": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.StartCapture(args[0], args[1])
 		},
 	},
 	"stopCapture": {
 		Method: api.POST,
+		Args:   2,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.StopCapture(args[0], args[1])
 		},
 	},
 	"uploadImage": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.UploadImage(args[0])
 		},
@@ -815,6 +924,7 @@ var commandMap = map[string]CommandConfig{
 	},
 	"unlockProject": {
 		Method: api.POST,
+		Args:   1,
 		Endpoint: func(ep endpoints.Endpoints, args []string) string {
 			return ep.Post.UnlockProject(args[0])
 		},
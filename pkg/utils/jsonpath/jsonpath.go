@@ -0,0 +1,511 @@
+// Package jsonpath evaluates the JSONPath subset used by --output
+// jsonpath=...: $, .field, ['field'], [n], [a:b], [*], .*, ..field and
+// filters like [?(@.name=='lab')]. kubectl style {...} braces around a
+// single expression are accepted as well. Everything else, like unions,
+// slice steps, script expressions, combined filters and kubectl templates
+// with {range} or several {...}, is rejected with an error instead of
+// silently selecting nothing.
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type stepKind int
+
+const (
+	stepField stepKind = iota
+	stepRecursive
+	stepIndex
+	stepSlice
+	stepWildcard
+	stepFilter
+)
+
+type step struct {
+	kind   stepKind
+	name   string
+	index  int
+	self   bool
+	start  *int
+	end    *int
+	filter *filter
+}
+
+type filter struct {
+	path  *Path
+	op    string
+	value any
+}
+
+// Path is a compiled expression.
+type Path struct {
+	expr  string
+	steps []step
+}
+
+func (p *Path) String() string { return p.expr }
+
+// Compile parses expr.
+func Compile(expr string) (*Path, error) {
+	steps, err := compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jsonpath %q: %w", expr, err)
+	}
+	return &Path{expr: expr, steps: steps}, nil
+}
+
+func compile(expr string) ([]step, error) {
+	src := strings.TrimSpace(expr)
+	if src == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	if strings.HasPrefix(src, "{") && strings.HasSuffix(src, "}") {
+		src = strings.TrimSpace(src[1 : len(src)-1])
+		if strings.ContainsAny(src, "{}") {
+			return nil, fmt.Errorf("kubectl templates like {range} or several {...} are not supported, use a single expression or --output go-template")
+		}
+	} else if strings.ContainsAny(src, "{}") {
+		return nil, fmt.Errorf("braces have to enclose the whole expression")
+	}
+	src = strings.TrimPrefix(src, "$")
+	p := &parser{src: src}
+	return p.parse()
+}
+
+// Find returns every value the path selects in data, which has to be
+// decoded with encoding/json.
+func (p *Path) Find(data any) []any {
+	current := []any{data}
+	for _, s := range p.steps {
+		var next []any
+		for _, v := range current {
+			next = append(next, s.apply(v)...)
+		}
+		current = next
+	}
+	return current
+}
+
+func (s step) apply(v any) []any {
+	switch s.kind {
+	case stepField:
+		if m, ok := v.(map[string]any); ok {
+			if val, ok := m[s.name]; ok {
+				return []any{val}
+			}
+		}
+	case stepRecursive:
+		if s.self {
+			return append([]any{v}, descend(v, "")...)
+		}
+		return descend(v, s.name)
+	case stepIndex:
+		if a, ok := v.([]any); ok {
+			i := s.index
+			if i < 0 {
+				i += len(a)
+			}
+			if i >= 0 && i < len(a) {
+				return []any{a[i]}
+			}
+		}
+	case stepSlice:
+		if a, ok := v.([]any); ok {
+			start, end := 0, len(a)
+			if s.start != nil {
+				start = clamp(*s.start, len(a))
+			}
+			if s.end != nil {
+				end = clamp(*s.end, len(a))
+			}
+			if start < end {
+				return append([]any(nil), a[start:end]...)
+			}
+		}
+	case stepWildcard:
+		return children(v)
+	case stepFilter:
+		var out []any
+		for _, c := range children(v) {
+			if s.filter.match(c) {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func clamp(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	return max(0, min(i, n))
+}
+
+func children(v any) []any {
+	switch t := v.(type) {
+	case []any:
+		return t
+	case map[string]any:
+		out := make([]any, 0, len(t))
+		for _, k := range sortedKeys(t) {
+			out = append(out, t[k])
+		}
+		return out
+	}
+	return nil
+}
+
+// descend collects name in v and everything below it, or every value when
+// name is empty ($..*).
+func descend(v any, name string) []any {
+	var out []any
+	if m, ok := v.(map[string]any); ok && name != "" {
+		if val, ok := m[name]; ok {
+			out = append(out, val)
+		}
+	}
+	for _, c := range children(v) {
+		if name == "" {
+			out = append(out, c)
+		}
+		out = append(out, descend(c, name)...)
+	}
+	return out
+}
+
+func (f *filter) match(v any) bool {
+	found := f.path.Find(v)
+	if f.op == "" {
+		return len(found) > 0
+	}
+	for _, got := range found {
+		if compare(got, f.op, f.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compare(a any, op string, b any) bool {
+	if af, ok := number(a); ok {
+		if bf, ok := number(b); ok {
+			switch op {
+			case "==":
+				return af == bf
+			case "!=":
+				return af != bf
+			case "<":
+				return af < bf
+			case "<=":
+				return af <= bf
+			case ">":
+				return af > bf
+			case ">=":
+				return af >= bf
+			}
+			return false
+		}
+	}
+	// b is always a scalar from the expression, so comparing never panics
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	}
+	as, aok := a.(string)
+	bs, bok := b.(string)
+	if !aok || !bok {
+		return false
+	}
+	switch op {
+	case "<":
+		return as < bs
+	case "<=":
+		return as <= bs
+	case ">":
+		return as > bs
+	case ">=":
+		return as >= bs
+	}
+	return false
+}
+
+func number(v any) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) parse() ([]step, error) {
+	var steps []step
+	for p.pos < len(p.src) {
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], ".."):
+			p.pos += 2
+			if p.peek() == '*' {
+				p.pos++
+				steps = append(steps, step{kind: stepRecursive})
+				continue
+			}
+			if p.peek() == '[' {
+				// the bracket applies to every node, including this one
+				steps = append(steps, step{kind: stepRecursive, self: true})
+				continue
+			}
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			if name == "" {
+				return nil, fmt.Errorf("expected a field name after .. at %d", p.pos)
+			}
+			steps = append(steps, step{kind: stepRecursive, name: name})
+		case p.peek() == '.':
+			p.pos++
+			if p.peek() == '*' {
+				p.pos++
+				steps = append(steps, step{kind: stepWildcard})
+				continue
+			}
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			if name == "" {
+				return nil, fmt.Errorf("expected a field name at %d", p.pos)
+			}
+			steps = append(steps, step{kind: stepField, name: name})
+		case p.peek() == '[':
+			s, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, s)
+		default:
+			// a leading field without dot, like name or items[0]
+			if len(steps) == 0 {
+				name, err := p.ident()
+				if err != nil {
+					return nil, err
+				}
+				if name != "" {
+					steps = append(steps, step{kind: stepField, name: name})
+					continue
+				}
+			}
+			return nil, fmt.Errorf("unexpected %q at %d", p.src[p.pos], p.pos)
+		}
+	}
+	return steps, nil
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// ident reads a field name up to the next step or comparison. Characters
+// with a meaning elsewhere in JSONPath are rejected, quote such names like
+// ['a*b'].
+func (p *parser) ident() (string, error) {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == '.' || c == '[' || c == ' ' || c == '=' || c == '!' || c == '<' || c == '>' || c == ')' || c == ']' {
+			break
+		}
+		if strings.IndexByte("*,:?@$()'\"&|", c) >= 0 {
+			return "", fmt.Errorf("unexpected %q in field name at %d, quote the name like ['%s']", c, p.pos, p.src[start:p.pos])
+		}
+		p.pos++
+	}
+	return p.src[start:p.pos], nil
+}
+
+func (p *parser) bracket() (step, error) {
+	end := p.closing()
+	if end < 0 {
+		return step{}, fmt.Errorf("unclosed [ at %d", p.pos)
+	}
+	inner := strings.TrimSpace(p.src[p.pos+1 : end])
+	p.pos = end + 1
+
+	switch {
+	case inner == "*":
+		return step{kind: stepWildcard}, nil
+	case strings.HasPrefix(inner, "?"):
+		if strings.HasPrefix(strings.TrimSpace(inner[1:]), "(") && indexOutsideQuotes(inner, ",") >= 0 {
+			return step{}, fmt.Errorf("unions like [?(...),?(...)] are not supported")
+		}
+		f, err := parseFilter(inner[1:])
+		if err != nil {
+			return step{}, err
+		}
+		return step{kind: stepFilter, filter: f}, nil
+	case strings.HasPrefix(inner, "("):
+		return step{}, fmt.Errorf("script expressions like [(@.length-1)] are not supported")
+	case indexOutsideQuotes(inner, ",") >= 0:
+		return step{}, fmt.Errorf("unions like [%s] are not supported, select one field or index at a time", inner)
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"'):
+		name, err := unquote(inner)
+		if err != nil {
+			return step{}, err
+		}
+		return step{kind: stepField, name: name}, nil
+	case strings.Contains(inner, ":"):
+		a, b, _ := strings.Cut(inner, ":")
+		if strings.Contains(b, ":") {
+			return step{}, fmt.Errorf("slice steps like [%s] are not supported", inner)
+		}
+		s := step{kind: stepSlice}
+		for _, part := range []struct {
+			src string
+			dst **int
+		}{{a, &s.start}, {b, &s.end}} {
+			if part.src = strings.TrimSpace(part.src); part.src == "" {
+				continue
+			}
+			n, err := strconv.Atoi(part.src)
+			if err != nil {
+				return step{}, fmt.Errorf("invalid slice %q", inner)
+			}
+			*part.dst = &n
+		}
+		return s, nil
+	}
+	n, err := strconv.Atoi(inner)
+	if err != nil {
+		return step{}, fmt.Errorf("invalid index %q", inner)
+	}
+	return step{kind: stepIndex, index: n}, nil
+}
+
+// closing returns the position of the ] matching the [ at p.pos, skipping
+// quoted strings and nested brackets inside filters.
+func (p *parser) closing() int {
+	depth := 0
+	var quote byte
+	for i := p.pos; i < len(p.src); i++ {
+		c := p.src[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parseFilter(src string) (*filter, error) {
+	src = strings.TrimSpace(src)
+	if !strings.HasPrefix(src, "(") || !strings.HasSuffix(src, ")") {
+		return nil, fmt.Errorf("filter %q must look like ?(@.field=='value')", src)
+	}
+	src = strings.TrimSpace(src[1 : len(src)-1])
+	if !strings.HasPrefix(src, "@") {
+		return nil, fmt.Errorf("filter %q must start with @", src)
+	}
+	for _, op := range []string{"&&", "||", "=~"} {
+		if indexOutsideQuotes(src, op) >= 0 {
+			return nil, fmt.Errorf("%s in filters is not supported", op)
+		}
+	}
+
+	lhs, op, rhs := src, "", ""
+	for _, candidate := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if i := indexOutsideQuotes(src, candidate); i >= 0 {
+			lhs, op, rhs = src[:i], candidate, src[i+len(candidate):]
+			break
+		}
+	}
+
+	lhs = strings.TrimPrefix(strings.TrimSpace(lhs), "@")
+	steps, err := (&parser{src: lhs}).parse()
+	if err != nil {
+		return nil, err
+	}
+	f := &filter{path: &Path{expr: "@" + lhs, steps: steps}, op: op}
+	if op == "" {
+		return f, nil
+	}
+	rhs = strings.TrimSpace(rhs)
+	switch {
+	case len(rhs) >= 2 && (rhs[0] == '\'' || rhs[0] == '"'):
+		f.value, err = unquote(rhs)
+	case rhs == "true" || rhs == "false":
+		f.value = rhs == "true"
+	case rhs == "null":
+		f.value = nil
+	default:
+		var n float64
+		n, err = strconv.ParseFloat(rhs, 64)
+		f.value = n
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q in filter", rhs)
+	}
+	return f, nil
+}
+
+func indexOutsideQuotes(s, sub string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '\'' || c == '"' {
+			quote = c
+			continue
+		}
+		if strings.HasPrefix(s[i:], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != s[len(s)-1] || strings.IndexByte(s[1:len(s)-1], s[0]) >= 0 {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+	return s[1 : len(s)-1], nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,115 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testDoc = `{
+	"name": "lab",
+	"count": 3,
+	"tags": ["a", "b", "c", "d"],
+	"nodes": [
+		{"name": "R1", "status": "started", "ports": [{"number": 0}, {"number": 1}]},
+		{"name": "R2", "status": "stopped", "ports": [{"number": 0}]},
+		{"name": "SW", "status": "started", "console": null}
+	],
+	"owner": {"name": "alice", "a*b": true}
+}`
+
+func testData(t *testing.T) any {
+	t.Helper()
+	var data any
+	if err := json.Unmarshal([]byte(testDoc), &data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestFind(t *testing.T) {
+	data := testData(t)
+	tests := []struct {
+		expr string
+		want []any
+	}{
+		{"$", []any{data}},
+		{"$.name", []any{"lab"}},
+		{"name", []any{"lab"}},
+		{"{.name}", []any{"lab"}},
+		{"{$.owner.name}", []any{"alice"}},
+		{"$['name']", []any{"lab"}},
+		{`$["owner"]["a*b"]`, []any{true}},
+		{"$.tags[0]", []any{"a"}},
+		{"$.tags[-1]", []any{"d"}},
+		{"$.tags[5]", nil},
+		{"$.tags[1:3]", []any{"b", "c"}},
+		{"$.tags[:2]", []any{"a", "b"}},
+		{"$.tags[-2:]", []any{"c", "d"}},
+		{"$.tags[*]", []any{"a", "b", "c", "d"}},
+		{"$.owner.*", []any{true, "alice"}},
+		{"$.nodes[*].name", []any{"R1", "R2", "SW"}},
+		{"$..number", []any{0.0, 1.0, 0.0}},
+		{"$.nodes[?(@.status=='started')].name", []any{"R1", "SW"}},
+		{"$.nodes[?(@.status != 'started')].name", []any{"R2"}},
+		{"$.nodes[?(@.ports)].name", []any{"R1", "R2"}},
+		{"$.nodes[?(@.console==null)].name", []any{"SW"}},
+		{"$..ports[?(@.number>0)].number", []any{1.0}},
+		{"$.nodes[?(@.name<'S')].name", []any{"R1", "R2"}},
+		{"$.missing", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := Compile(tt.expr)
+			if err != nil {
+				t.Fatalf("Compile: %v", err)
+			}
+			if got := p.Find(data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileRejectsUnsupported(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "empty expression"},
+		{"$[*]['name','id']", "unions"},
+		{"$.tags[0,1]", "unions"},
+		{"$.nodes[?(@.a==1),?(@.b==2)]", "unions"},
+		{"{range .nodes[*]}{.name}{end}", "{range}"},
+		{"{.name}{.count}", "{range}"},
+		{".name}", "braces"},
+		{"$.tags[::2]", "slice steps"},
+		{"$.tags[0:4:2]", "slice steps"},
+		{"$.tags[(@.length-1)]", "script expressions"},
+		{"$.nodes[?(@.a==1 && @.b==2)]", "&&"},
+		{"$.nodes[?(@.a==1 || @.b==2)]", "||"},
+		{"$.nodes[?(@.name =~ /R.*/)]", "=~"},
+		{"$.nodes[?@.name]", "must look like"},
+		{"$.nodes[?(.name)]", "must start with @"},
+		{"$.na*me", "quote the name"},
+		{"$.a,b", "quote the name"},
+		{"$.tags[x]", "invalid index"},
+		{"$.tags[1", "unclosed"},
+		{`$['a'"b"]`, "invalid string"},
+		{"$.", "expected a field name"},
+		{"$..", "expected a field name"},
+		{"$.nodes[?(@.name=='R1)]", "unclosed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Compile(tt.expr)
+			if err == nil {
+				t.Fatalf("Compile(%q) succeeded, want an error containing %q", tt.expr, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile(%q) = %v, want an error containing %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package mailer
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Envelope is a message received by FakeServer.
+type Envelope struct {
+	From string
+	To   []string
+	Data []byte
+}
+
+// FakeServer is a minimal SMTP server that accepts every message without
+// TLS and hands it to a callback, for rehearsing class notify without a
+// mail server. Any AUTH is accepted.
+type FakeServer struct {
+	ln      net.Listener
+	deliver func(Envelope)
+	wg      sync.WaitGroup
+}
+
+func NewFakeServer(ln net.Listener, deliver func(Envelope)) *FakeServer {
+	return &FakeServer{ln: ln, deliver: deliver}
+}
+
+// Serve accepts connections until the listener is closed.
+func (s *FakeServer) Serve() error {
+	for {
+		conn, err := s.ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			s.wg.Wait()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *FakeServer) Close() error {
+	return s.ln.Close()
+}
+
+func (s *FakeServer) handle(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	defer func() {
+		_ = tp.Close()
+	}()
+
+	var env Envelope
+	reply := func(format string, args ...any) bool {
+		return tp.PrintfLine(format, args...) == nil
+	}
+	if !reply("220 gns3util fake SMTP server") {
+		return
+	}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			reply("250 gns3util")
+		case "EHLO":
+			reply("250-gns3util\r\n250-8BITMIME\r\n250 AUTH PLAIN LOGIN")
+		case "AUTH":
+			if strings.EqualFold(strings.TrimSpace(arg), "LOGIN") {
+				// username and password, both ignored
+				for range 2 {
+					reply("334 ")
+					if _, err := tp.ReadLine(); err != nil {
+						return
+					}
+				}
+			}
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			env = Envelope{From: address(arg)}
+			reply("250 2.1.0 OK")
+		case "RCPT":
+			env.To = append(env.To, address(arg))
+			reply("250 2.1.5 OK")
+		case "DATA":
+			if len(env.To) == 0 {
+				reply("503 5.5.1 RCPT first")
+				continue
+			}
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(tp.DotReader())
+			if err != nil {
+				return
+			}
+			env.Data = data
+			s.deliver(env)
+			env = Envelope{}
+			reply("250 2.0.0 OK")
+		case "RSET":
+			env = Envelope{}
+			reply("250 2.0.0 OK")
+		case "NOOP":
+			reply("250 2.0.0 OK")
+		case "QUIT":
+			reply("221 2.0.0 Bye")
+			return
+		default:
+			reply("502 5.5.2 Command not implemented")
+		}
+	}
+}
+
+// address returns the path of MAIL FROM:<a> and RCPT TO:<a>.
+func address(arg string) string {
+	_, path, _ := strings.Cut(arg, ":")
+	path, _, _ = strings.Cut(strings.TrimSpace(path), " ")
+	return strings.Trim(path, "<>")
+}
@@ -0,0 +1,150 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLS modes of Config.
+const (
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+	TLSNone     = "none"
+)
+
+var TLSModes = []string{TLSStartTLS, TLSImplicit, TLSNone}
+
+// Config is an SMTP server to send through. Username may be empty for
+// servers without authentication.
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	TLS      string
+	Insecure bool
+}
+
+// Message is a plain text mail to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Compose returns msg as an RFC 5322 message with a quoted-printable
+// utf-8 body.
+func Compose(from string, msg Message, date time.Time) ([]byte, error) {
+	fromAddr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", from, err)
+	}
+	toAddr, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	_, domain, _ := strings.Cut(fromAddr.Address, "@")
+	// a template may end the subject with a newline
+	subject := strings.Join(strings.Fields(msg.Subject), " ")
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", fromAddr.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", toAddr.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(msg.Body, "\r\n", "\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\r\n")
+	return buf.Bytes(), nil
+}
+
+// Send delivers msg through the server of cfg on a new connection.
+func Send(cfg Config, msg Message) error {
+	data, err := Compose(cfg.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	fromAddr, _ := mail.ParseAddress(cfg.From)
+	toAddr, _ := mail.ParseAddress(msg.To)
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host, InsecureSkipVerify: cfg.Insecure, MinVersion: tls.VersionTLS12} // #nosec G402
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	switch cfg.TLS {
+	case TLSImplicit:
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	case TLSStartTLS, TLSNone:
+		conn, err = dialer.Dial("tcp", addr)
+	default:
+		return fmt.Errorf("unknown tls mode %q, use one of %s", cfg.TLS, strings.Join(TLSModes, ", "))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Minute))
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to talk to %s: %w", addr, err)
+	}
+	defer func() {
+		_ = c.Close()
+	}()
+	if cfg.TLS == TLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("%s does not support STARTTLS, use tls or none", addr)
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("failed to authenticate as %s: %w", cfg.Username, err)
+		}
+	}
+	if err := c.Mail(fromAddr.Address); err != nil {
+		return fmt.Errorf("sender rejected: %w", err)
+	}
+	if err := c.Rcpt(toAddr.Address); err != nil {
+		return fmt.Errorf("recipient rejected: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("message rejected: %w", err)
+	}
+	return c.Quit()
+}
@@ -0,0 +1,431 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils/jsonpath"
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by --output. jsonpath and go-template take the
+// expression after a =, like jsonpath=$[*].name.
+const (
+	OutputJSON       = "json"
+	OutputYAML       = "yaml"
+	OutputTable      = "table"
+	OutputCSV        = "csv"
+	OutputJSONPath   = "jsonpath"
+	OutputGoTemplate = "go-template"
+)
+
+const outputFlagsAnnotation = "outputFlags"
+
+// tableExtras are the columns shown after the id and name from
+// idElementName in table and csv output.
+var tableExtras = map[string][]string{
+	"user":      {"full_name", "email", "is_active", "is_superadmin"},
+	"group":     {"is_builtin"},
+	"role":      {"is_builtin"},
+	"privilege": {"description"},
+	"acl-rule":  {"ace_type", "allowed", "propagate", "user_id", "group_id", "role_id"},
+	"template":  {"template_type", "category", "compute_id"},
+	"project":   {"status", "filename"},
+	"compute":   {"host", "port", "protocol", "connected"},
+	"appliance": {"category", "vendor_name", "status"},
+	"node":      {"node_type", "status", "console_type", "console"},
+	"image":     {"image_type", "image_size"},
+	"link":      {"link_type", "suspend"},
+	"drawing":   {"x", "y", "z"},
+	"snapshot":  {"created_at"},
+	"symbol":    {"builtin"},
+}
+
+// commandResources maps commands whose name does not tell the resource
+// type of their response.
+var commandResources = map[string]string{
+	"getMe":               "user",
+	"getGroupMembers":     "user",
+	"getGroupMemberships": "group",
+	"getRolePrivs":        "privilege",
+	"getAcl":              "acl-rule",
+	"getAce":              "acl-rule",
+	"getNodeLinks":        "link",
+}
+
+// ResourceForCommand returns the idElementName key of the resources
+// returned by a command from commandMap, or "" if it is not a resource.
+func ResourceForCommand(cmdName string) string {
+	if r, ok := commandResources[cmdName]; ok {
+		return r
+	}
+	name := strings.ToLower(strings.TrimPrefix(cmdName, "get"))
+	for _, candidate := range []string{name, strings.TrimSuffix(name, "s")} {
+		if _, ok := idElementName[candidate]; ok {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// DefaultColumns returns the table columns of a resource type.
+func DefaultColumns(resource string) []string {
+	fields, ok := idElementName[resource]
+	if !ok {
+		return nil
+	}
+	cols := []string{fields[0]}
+	if fields[1] != fields[0] {
+		cols = append(cols, fields[1])
+	}
+	return append(cols, tableExtras[resource]...)
+}
+
+// AddOutputFlags adds --output, --columns and --sort-by to a command that
+// prints API responses.
+func AddOutputFlags(cmd *cobra.Command) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[outputFlagsAnnotation] = "true"
+	cmd.Flags().StringP("output", "o", "", "Output format: json, yaml, table, csv, jsonpath=EXPR or go-template=TEMPLATE")
+	cmd.Flags().StringSlice("columns", nil, "Fields shown by table and csv output, e.g. name,status (implies -o table)")
+	cmd.Flags().String("sort-by", "", "Sort lists by a field, e.g. name or properties.ram")
+}
+
+// AddFileOutputFlags adds -o, --output with the formats of a command that
+// writes its own format, like a credential sheet, and --out for the file
+// to write to. --format is kept as a deprecated alias of --output.
+func AddFileOutputFlags(cmd *cobra.Command, formats []string, def, usage string) {
+	cmd.Flags().StringP("output", "o", def, "Output format: "+strings.Join(formats, ", ")+usage)
+	cmd.Flags().String("out", "", "Write to this file instead of stdout")
+	cmd.Flags().String("format", "", "Output format")
+	_ = cmd.Flags().MarkDeprecated("format", "use --output instead")
+}
+
+// FileOutputFlags returns the format and file from the flags added by
+// AddFileOutputFlags. An empty format is returned as is when the command
+// has no default.
+func FileOutputFlags(cmd *cobra.Command, formats []string) (string, string, error) {
+	format, _ := cmd.Flags().GetString("output")
+	if !cmd.Flags().Changed("output") && cmd.Flags().Changed("format") {
+		format, _ = cmd.Flags().GetString("format")
+	}
+	out, _ := cmd.Flags().GetString("out")
+	if format == "" || slices.Contains(formats, format) {
+		return format, out, nil
+	}
+	hint := ""
+	if strings.ContainsAny(format, "./"+string(os.PathSeparator)) {
+		hint = ", write to a file with --out"
+	}
+	return "", "", fmt.Errorf("unknown output format %q, use one of %s%s", format, strings.Join(formats, ", "), hint)
+}
+
+// ApplyOutputFlags copies the flags added by AddOutputFlags into opts and
+// validates them.
+func ApplyOutputFlags(cmd *cobra.Command, opts *config.GlobalOptions) error {
+	if cmd.Annotations[outputFlagsAnnotation] != "true" {
+		return nil
+	}
+	opts.Output, _ = cmd.Flags().GetString("output")
+	opts.Columns, _ = cmd.Flags().GetStringSlice("columns")
+	opts.SortBy, _ = cmd.Flags().GetString("sort-by")
+
+	if opts.Output == "" && len(opts.Columns) > 0 {
+		opts.Output = OutputTable
+	}
+	if opts.Output == "" {
+		return nil
+	}
+	if opts.Raw {
+		return fmt.Errorf("--raw cannot be combined with --output, use -o json instead")
+	}
+	kind, expr, _ := strings.Cut(opts.Output, "=")
+	switch kind {
+	case OutputJSON, OutputYAML, OutputTable, OutputCSV:
+	case OutputJSONPath:
+		if _, err := jsonpath.Compile(expr); err != nil {
+			return err
+		}
+	case OutputGoTemplate:
+		if _, err := newOutputTemplate(expr); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown output format %q, use json, yaml, table, csv, jsonpath=EXPR or go-template=TEMPLATE", opts.Output)
+	}
+	if len(opts.Columns) > 0 && kind != OutputTable && kind != OutputCSV {
+		return fmt.Errorf("--columns only works with table and csv output")
+	}
+	return nil
+}
+
+// PrintBody prints an API response in the format selected by cfg, the
+// key/value listing when none was selected.
+func PrintBody(cfg config.GlobalOptions, body []byte, resource string) error {
+	if cfg.SortBy != "" {
+		body = sortBody(body, cfg.SortBy)
+	}
+	switch {
+	case cfg.Output != "":
+		return PrintOutput(cfg, body, resource)
+	case cfg.Raw:
+		if cfg.NoColors {
+			PrintJsonUgly(body)
+		} else {
+			PrintJson(body)
+		}
+	default:
+		PrintKV(body)
+	}
+	return nil
+}
+
+// StructuredOutput reports whether cfg asks for --raw or an --output
+// format other than the own table of a command.
+func StructuredOutput(cfg config.GlobalOptions) bool {
+	return cfg.Raw || (cfg.Output != "" && (cfg.Output != OutputTable || len(cfg.Columns) > 0))
+}
+
+// PrintValue prints v like an API response, for commands that list local
+// data.
+func PrintValue(cfg config.GlobalOptions, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal results: %w", err)
+	}
+	return PrintBody(cfg, data, "")
+}
+
+// PrintOutput prints body in the --output format of cfg.
+func PrintOutput(cfg config.GlobalOptions, body []byte, resource string) error {
+	kind, expr, _ := strings.Cut(cfg.Output, "=")
+	switch kind {
+	case OutputJSON:
+		if cfg.NoColors || !isatty.IsTerminal(os.Stdout.Fd()) {
+			PrintJsonUgly(body)
+		} else {
+			PrintJson(body)
+		}
+		return nil
+	case OutputTable, OutputCSV:
+		rows, columns := tableData(body, resource, cfg.Columns)
+		if kind == OutputCSV {
+			return writeCSV(rows, columns)
+		}
+		tableColumns := make([]Column[gjson.Result], 0, len(columns))
+		for _, c := range columns {
+			tableColumns = append(tableColumns, Column[gjson.Result]{Header: c, Value: func(r gjson.Result) string { return cellValue(r.Get(c)) }})
+		}
+		PrintTable(rows, tableColumns)
+		return nil
+	}
+
+	data, err := decodeBody(body)
+	if err != nil {
+		return err
+	}
+	switch kind {
+	case OutputYAML:
+		out, err := yaml.Marshal(yamlValue(data))
+		if err != nil {
+			return fmt.Errorf("failed to encode yaml: %w", err)
+		}
+		fmt.Print(string(out))
+	case OutputJSONPath:
+		path, err := jsonpath.Compile(expr)
+		if err != nil {
+			return err
+		}
+		for _, v := range path.Find(data) {
+			fmt.Println(scalarString(v))
+		}
+	case OutputGoTemplate:
+		tmpl, err := newOutputTemplate(expr)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(os.Stdout, data); err != nil {
+			return fmt.Errorf("failed to execute template: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown output format %q", cfg.Output)
+	}
+	return nil
+}
+
+func newOutputTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"join": func(sep string, v []any) string {
+			parts := make([]string, 0, len(v))
+			for _, p := range v {
+				parts = append(parts, scalarString(p))
+			}
+			return strings.Join(parts, sep)
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid go-template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// decodeBody keeps numbers as written so ids and sizes are not printed in
+// exponent notation.
+func decodeBody(body []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var data any
+	if err := dec.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to parse API response: %w", err)
+	}
+	return data, nil
+}
+
+// yamlValue converts json.Number so yaml prints numbers instead of strings.
+func yamlValue(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+		return t.String()
+	case map[string]any:
+		for k, val := range t {
+			t[k] = yamlValue(val)
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = yamlValue(val)
+		}
+	}
+	return v
+}
+
+func scalarString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	case nil:
+		return "null"
+	case map[string]any, []any:
+		b, _ := json.Marshal(t)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// tableData returns the rows of body and the columns to show: the given
+// ones, the defaults of the resource or all top level scalar fields.
+func tableData(body []byte, resource string, columns []string) ([]gjson.Result, []string) {
+	result := gjson.ParseBytes(body)
+	var rows []gjson.Result
+	if result.IsArray() {
+		rows = result.Array()
+	} else {
+		rows = []gjson.Result{result}
+	}
+	if len(columns) > 0 {
+		return rows, columns
+	}
+	if defaults := DefaultColumns(resource); len(defaults) > 0 {
+		return rows, defaults
+	}
+	for _, row := range rows {
+		if !row.IsObject() {
+			continue
+		}
+		row.ForEach(func(key, value gjson.Result) bool {
+			if !value.IsObject() && !value.IsArray() && !slices.Contains(columns, key.String()) {
+				columns = append(columns, key.String())
+			}
+			return true
+		})
+	}
+	if len(columns) == 0 {
+		columns = []string{"@this"}
+	}
+	return rows, columns
+}
+
+func cellValue(v gjson.Result) string {
+	switch v.Type {
+	case gjson.String:
+		return v.String()
+	case gjson.Null:
+		return ""
+	}
+	return v.Raw
+}
+
+func writeCSV(rows []gjson.Result, columns []string) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write(columns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		record := make([]string, len(columns))
+		for i, c := range columns {
+			record[i] = cellValue(row.Get(c))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// sortBody sorts a JSON array by the field path, numbers numerically and
+// everything else as text. Other bodies are returned unchanged.
+func sortBody(body []byte, field string) []byte {
+	result := gjson.ParseBytes(body)
+	if !result.IsArray() {
+		return body
+	}
+	items := result.Array()
+	slices.SortStableFunc(items, func(a, b gjson.Result) int {
+		va, vb := a.Get(field), b.Get(field)
+		if va.Type == gjson.Number && vb.Type == gjson.Number {
+			switch {
+			case va.Float() < vb.Float():
+				return -1
+			case va.Float() > vb.Float():
+				return 1
+			}
+			return 0
+		}
+		return strings.Compare(va.String(), vb.String())
+	})
+
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, it := range items {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(it.Raw)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes()
+}
@@ -0,0 +1,127 @@
+package utils_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stefanistkuhl/gns3util/internal/testutil"
+	"github.com/stefanistkuhl/gns3util/pkg/config"
+	"github.com/stefanistkuhl/gns3util/pkg/utils"
+)
+
+func TestApplyOutputFlags(t *testing.T) {
+	tests := []struct {
+		args   []string
+		raw    bool
+		output string
+		err    string
+	}{
+		{args: nil},
+		{args: []string{"-o", "yaml"}, output: "yaml"},
+		{args: []string{"--columns", "name"}, output: "table"},
+		{args: []string{"-o", "csv", "--columns", "name"}, output: "csv"},
+		{args: []string{"-o", "jsonpath=$[*].name"}, output: "jsonpath=$[*].name"},
+		{args: []string{"-o", "xml"}, err: "unknown output format"},
+		{args: []string{"-o", "json", "--columns", "name"}, err: "--columns only works"},
+		{args: []string{"-o", "jsonpath=$[0,1]"}, err: "unions"},
+		{args: []string{"-o", "go-template={{.name"}, err: "invalid go-template"},
+		{args: []string{"-o", "json"}, raw: true, err: "--raw cannot be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			cmd := &cobra.Command{Use: "ls"}
+			utils.AddOutputFlags(cmd)
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			opts := config.GlobalOptions{Raw: tt.raw}
+			err := utils.ApplyOutputFlags(cmd, &opts)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if opts.Output != tt.output {
+				t.Errorf("output %q, want %q", opts.Output, tt.output)
+			}
+		})
+	}
+}
+
+func TestFileOutputFlags(t *testing.T) {
+	formats := []string{"csv", "html"}
+	tests := []struct {
+		def    string
+		args   []string
+		format string
+		out    string
+		err    string
+	}{
+		{def: "csv", format: "csv"},
+		{def: "csv", args: []string{"-o", "html", "--out", "sheet.html"}, format: "html", out: "sheet.html"},
+		{def: "csv", args: []string{"--format", "html"}, format: "html"},
+		{def: "csv", args: []string{"--format", "html", "-o", "csv"}, format: "csv"},
+		{def: "", format: ""},
+		{def: "csv", args: []string{"-o", "pdf"}, err: "use one of csv, html"},
+		{def: "csv", args: []string{"-o", "sheet.html"}, err: "write to a file with --out"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			cmd := &cobra.Command{Use: "credentials"}
+			cmd.SetErr(io.Discard)
+			utils.AddFileOutputFlags(cmd, formats, tt.def, "")
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			format, out, err := utils.FileOutputFlags(cmd, formats)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want one containing %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.format || out != tt.out {
+				t.Errorf("format %q and file %q, want %q and %q", format, out, tt.format, tt.out)
+			}
+		})
+	}
+}
+
+func TestPrintValue(t *testing.T) {
+	value := []map[string]any{
+		{"name": "lab", "nodes": 2},
+		{"name": "exam", "nodes": 10},
+	}
+	tests := []struct {
+		cfg  config.GlobalOptions
+		want string
+	}{
+		{cfg: config.GlobalOptions{Output: "json"}, want: "[\n  {\n    \"name\": \"lab\",\n    \"nodes\": 2\n  },\n  {\n    \"name\": \"exam\",\n    \"nodes\": 10\n  }\n]\n"},
+		{cfg: config.GlobalOptions{Output: "csv", Columns: []string{"name", "nodes"}}, want: "name,nodes\nlab,2\nexam,10\n"},
+		{cfg: config.GlobalOptions{Output: "csv", Columns: []string{"name"}, SortBy: "name"}, want: "name\nexam\nlab\n"},
+		{cfg: config.GlobalOptions{Output: "jsonpath=$[?(@.nodes>5)].name"}, want: "exam\n"},
+		{cfg: config.GlobalOptions{Output: "yaml"}, want: "- name: lab\n  nodes: 2\n- name: exam\n  nodes: 10\n"},
+		{cfg: config.GlobalOptions{Output: "go-template={{range .}}{{.name}} {{end}}"}, want: "lab exam "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.cfg.Output, func(t *testing.T) {
+			got := testutil.Stdout(t, func() {
+				if err := utils.PrintValue(tt.cfg, value); err != nil {
+					t.Error(err)
+				}
+			})
+			if got != tt.want {
+				t.Errorf("printed %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
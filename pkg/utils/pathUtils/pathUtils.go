@@ -6,15 +6,25 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	homedir "github.com/mitchellh/go-homedir"
 )
 
 type GNS3Key struct {
-	ServerURL   string `json:"server_url"`
-	User        string `json:"user"`
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
+	ServerURL   string    `json:"server_url"`
+	User        string    `json:"user"`
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	IssuedAt    time.Time `json:"issued_at,omitzero"`
+	ExpiresAt   time.Time `json:"expires_at,omitzero"`
+	Default     bool      `json:"default,omitempty"`
+}
+
+// Expired reports whether the token is known to be expired. Keys written
+// before expiry times were recorded never count as expired.
+func (k GNS3Key) Expired(now time.Time) bool {
+	return !k.ExpiresAt.IsZero() && !now.Before(k.ExpiresAt)
 }
 
 func ExpandPath(p string) (string, error) {
@@ -71,15 +81,21 @@ func LoadGNS3KeysFile(path string) ([]GNS3Key, error) {
 		}
 	}()
 
+	return DecodeGNS3Keys(f, path)
+}
+
+// DecodeGNS3Keys reads keys stored as one JSON object per line. name is
+// only used in errors.
+func DecodeGNS3Keys(r io.Reader, name string) ([]GNS3Key, error) {
 	var keys []GNS3Key
-	dec := json.NewDecoder(f)
+	dec := json.NewDecoder(r)
 	for {
 		var k GNS3Key
 		if err := dec.Decode(&k); err != nil {
 			if err == io.EOF {
 				break
 			}
-			return nil, fmt.Errorf("failed to decode JSON in %q: %w", path, err)
+			return nil, fmt.Errorf("failed to decode JSON in %q: %w", name, err)
 		}
 		keys = append(keys, k)
 	}
@@ -2,14 +2,15 @@ package utils
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -17,10 +18,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/stefanistkuhl/gns3util/pkg/api"
 	"github.com/stefanistkuhl/gns3util/pkg/api/endpoints"
+	"github.com/stefanistkuhl/gns3util/pkg/api/sdk"
 	"github.com/stefanistkuhl/gns3util/pkg/authentication"
 	"github.com/stefanistkuhl/gns3util/pkg/config"
 	"github.com/stefanistkuhl/gns3util/pkg/utils/messageUtils"
-	"github.com/stefanistkuhl/gns3util/pkg/utils/pathUtils"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/pretty"
 	"golang.org/x/text/cases"
@@ -82,16 +83,22 @@ func CallClient(cfg config.GlobalOptions, cmdName string, args []string, body an
 		}
 	}
 
-	settings := api.NewSettings(
+	opts := []api.SettingOption{
 		api.WithBaseURL(cfg.Server),
 		api.WithVerify(!cfg.Insecure),
 		api.WithToken(token),
-	)
+		api.WithRetries(cfg.Retries),
+		api.WithRetryDelays(cfg.RetryDelay, cfg.RetryMaxDelay),
+		api.WithRetryStatusCodes(cfg.RetryStatus),
+	}
+	if cmdName != "userAuthenticate" {
+		opts = append(opts, api.WithReauthenticator(Reauthenticator(cfg)))
+	}
+	settings := api.NewSettings(opts...)
 
-	ep := endpoints.Endpoints{}
-	endpointPath := cmd.Endpoint(ep, args)
-	if endpointPath == "" {
-		return nil, 0, fmt.Errorf("missing required arguments for command: %s", cmdName)
+	endpointPath, err := resolveEndpoint(cmdName, cmd, args)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	client := api.NewGNS3Client(settings)
@@ -117,7 +124,7 @@ func CallClient(cfg config.GlobalOptions, cmdName string, args []string, body an
 		reqOpts = reqOpts.WithData(dataStr)
 	}
 
-	respBody, resp, err := client.Do(reqOpts)
+	respBody, resp, err := client.Do(cfg.Context(), reqOpts)
 	if err != nil {
 		status := 0
 		if resp != nil {
@@ -129,9 +136,55 @@ func CallClient(cfg config.GlobalOptions, cmdName string, args []string, body an
 	return respBody, resp.StatusCode, nil
 }
 
+// resolveEndpoint builds the endpoint path of a command after checking args
+// holds the positional arguments the command declares.
+func resolveEndpoint(cmdName string, cmd CommandConfig, args []string) (string, error) {
+	if len(args) < cmd.Args {
+		return "", fmt.Errorf("missing required arguments for command: %s, it takes %d and got %d", cmdName, cmd.Args, len(args))
+	}
+	path := cmd.Endpoint(endpoints.Endpoints{}, args)
+	if path == "" {
+		return "", fmt.Errorf("missing required arguments for command: %s", cmdName)
+	}
+	return path, nil
+}
+
+// NewClient returns a typed API client for the server of cfg using the
+// stored access token.
+func NewClient(cfg config.GlobalOptions) (*sdk.Client, error) {
+	token, err := authentication.GetKeyForServer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	settings := api.NewSettings(
+		api.WithBaseURL(cfg.Server),
+		api.WithVerify(!cfg.Insecure),
+		api.WithToken(token),
+		api.WithRetries(cfg.Retries),
+		api.WithRetryDelays(cfg.RetryDelay, cfg.RetryMaxDelay),
+		api.WithRetryStatusCodes(cfg.RetryStatus),
+		api.WithReauthenticator(Reauthenticator(cfg)),
+	)
+	return sdk.NewClient(settings), nil
+}
+
+// Reauthenticator logs in again when the stored token expired, using the
+// password from GNS3_PASSWORD, the OS keyring or an interactive prompt.
+func Reauthenticator(cfg config.GlobalOptions) api.Reauthenticator {
+	prompt := authentication.PromptCredentials{Prompt: func(user string) (string, error) {
+		fmt.Printf("%v The session of %s expired, enter the password to continue\n", messageUtils.WarningMsg("Session expired"), messageUtils.Bold(user))
+		return GetPasswordFromInput()
+	}}
+	return authentication.Reauthenticator(cfg, append(authentication.DefaultCredentialSources(), prompt)...)
+}
+
 func ExecuteAndPrint(cfg config.GlobalOptions, cmdName string, args []string) {
 	body, status, err := CallClient(cfg, cmdName, args, nil)
 	if err != nil {
+		if errors.Is(err, authentication.ErrNoCredentials) {
+			fmt.Printf("%v The stored token expired. Set GNS3_PASSWORD, use auth login --remember or run auth login again.\n", messageUtils.ErrorMsg("Session expired"))
+			return
+		}
 		if strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "Authentication was unsuccessful") {
 			fmt.Printf("%v Authentication failed. Please check your username and password.\n", messageUtils.ErrorMsg("Authentication failed"))
 			return
@@ -149,14 +202,8 @@ func ExecuteAndPrint(cfg config.GlobalOptions, cmdName string, args []string) {
 			messageUtils.SuccessMsg("Command executed successfully"), cmdName)
 		return
 	}
-	if cfg.Raw {
-		if cfg.NoColors {
-			PrintJsonUgly(body)
-		} else {
-			PrintJson(body)
-		}
-	} else {
-		PrintKV(body)
+	if err := PrintBody(cfg, body, ResourceForCommand(cmdName)); err != nil {
+		fmt.Printf("%v %v\n", messageUtils.ErrorMsg("Output error"), err)
 	}
 }
 
@@ -290,6 +337,10 @@ func PrintSeperator() {
 func ExecuteAndPrintWithBody(cfg config.GlobalOptions, cmdName string, args []string, body any) {
 	respBody, status, err := CallClient(cfg, cmdName, args, body)
 	if err != nil {
+		if errors.Is(err, authentication.ErrNoCredentials) {
+			fmt.Printf("%v The stored token expired. Set GNS3_PASSWORD, use auth login --remember or run auth login again.\n", messageUtils.ErrorMsg("Session expired"))
+			return
+		}
 		if strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "Authentication was unsuccessful") {
 			fmt.Printf("%v Authentication failed. Please check your username and password.\n", messageUtils.ErrorMsg("Authentication failed"))
 			return
@@ -338,17 +389,23 @@ func ResolveID(cfg config.GlobalOptions, subcommand, name string, args []string)
 		api.WithBaseURL(cfg.Server),
 		api.WithVerify(!cfg.Insecure),
 		api.WithToken(token),
+		api.WithRetries(cfg.Retries),
+		api.WithRetryDelays(cfg.RetryDelay, cfg.RetryMaxDelay),
+		api.WithRetryStatusCodes(cfg.RetryStatus),
+		api.WithReauthenticator(Reauthenticator(cfg)),
 	)
 	client := api.NewGNS3Client(settings)
 
-	ep := endpoints.Endpoints{}
-	endpointPath := cmd.Endpoint(ep, args)
+	endpointPath, err := resolveEndpoint("get"+titleCaser.String(key), cmd, args)
+	if err != nil {
+		return "", err
+	}
 
 	reqOpts := api.NewRequestOptions(settings).
 		WithURL(endpointPath).
 		WithMethod(api.GET)
 
-	body, resp, err := client.Do(reqOpts)
+	body, resp, err := client.Do(cfg.Context(), reqOpts)
 	if err != nil {
 		return "", fmt.Errorf("API error: %w", err)
 	}
@@ -424,7 +481,18 @@ func GetResourceWithContext(cfg config.GlobalOptions, commandName string, resour
 	return resourceData, nil
 }
 
-func PrintResourceWithContext(resourceData map[string][]byte, contextLabel string) {
+// PrintResourceWithContext prints the responses of cmdName for several
+// parents, like the nodes of multiple projects. With --output the
+// responses are combined into one object keyed by parent, or one list for
+// table and csv output.
+func PrintResourceWithContext(cfg config.GlobalOptions, resourceData map[string][]byte, contextLabel, cmdName string) {
+	if cfg.Output != "" || cfg.Raw {
+		if err := PrintBody(cfg, combineResources(cfg, resourceData), ResourceForCommand(cmdName)); err != nil {
+			fmt.Printf("%v %v\n", messageUtils.ErrorMsg("Output error"), err)
+		}
+		return
+	}
+
 	for i, contextKey := range getSortedKeys(resourceData) {
 		resourceBody := resourceData[contextKey]
 
@@ -444,12 +512,55 @@ func PrintResourceWithContext(resourceData map[string][]byte, contextLabel strin
 			if resourceResult.IsArray() && len(resourceResult.Array()) == 0 {
 				fmt.Println("  No data found")
 			} else {
+				if cfg.SortBy != "" {
+					resourceBody = sortBody(resourceBody, cfg.SortBy)
+				}
 				PrintKV(resourceBody)
 			}
 		}
 	}
 }
 
+func combineResources(cfg config.GlobalOptions, resourceData map[string][]byte) []byte {
+	kind, _, _ := strings.Cut(cfg.Output, "=")
+	var buf bytes.Buffer
+	if kind == OutputTable || kind == OutputCSV {
+		buf.WriteByte('[')
+		n := 0
+		for _, key := range getSortedKeys(resourceData) {
+			gjson.ParseBytes(resourceData[key]).ForEach(func(_, v gjson.Result) bool {
+				if n > 0 {
+					buf.WriteByte(',')
+				}
+				buf.WriteString(v.Raw)
+				n++
+				return true
+			})
+		}
+		buf.WriteByte(']')
+		return buf.Bytes()
+	}
+
+	buf.WriteByte('{')
+	for i, key := range getSortedKeys(resourceData) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		data := resourceData[key]
+		if len(data) == 0 {
+			data = []byte("null")
+		} else if cfg.SortBy != "" {
+			data = sortBody(data, cfg.SortBy)
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
+
 func getContextCommand(resourceType string) string {
 	contextCommands := map[string]string{
 		"user":      "getUser",
@@ -658,27 +769,15 @@ func ConfirmPrompt(msg string, defaultYes bool) bool {
 }
 
 func GetUserInKeyFileForUrl(cfg config.GlobalOptions) (string, error) {
-	var keys []pathUtils.GNS3Key
-	var getKeyErr error
-	if cfg.KeyFile != "" {
-		keys, getKeyErr = pathUtils.LoadGNS3KeysFile(cfg.KeyFile)
-		if getKeyErr != nil {
-			return "", fmt.Errorf("%s: %w", "failed to load keys", getKeyErr)
-		}
-	} else {
-		path, getPathErr := pathUtils.GetGNS3Dir()
-		if getPathErr != nil {
-			return "", fmt.Errorf("%s: %w", "failed to get the gns3 dir", getPathErr)
-		}
-		keys, getKeyErr = pathUtils.LoadGNS3KeysFile(filepath.Join(path, "gns3key"))
-		if getKeyErr != nil {
-			return "", fmt.Errorf("%s: %w", "failed to load keys", getKeyErr)
-		}
+	key, ok, err := authentication.FindKey(cfg)
+	if err != nil && !api.Replaying() {
+		return "", fmt.Errorf("%s: %w", "failed to load keys", err)
 	}
-	for _, key := range keys {
-		if key.ServerURL == cfg.Server {
-			return key.User, nil
-		}
+	if ok {
+		return key.User, nil
+	}
+	if api.Replaying() {
+		return api.Redacted, nil
 	}
 
 	return "", fmt.Errorf("failed to get a matching entry in key file for the url %s", cfg.Server)